@@ -4,6 +4,7 @@ package all
 import (
 	"github.com/rmsj/service/app/domain/authapp"
 	"github.com/rmsj/service/app/domain/checkapp"
+	"github.com/rmsj/service/app/domain/oauthapp"
 	"github.com/rmsj/service/app/sdk/mux"
 	"github.com/rmsj/service/foundation/web"
 )
@@ -25,8 +26,41 @@ func (add) Add(app *web.App, cfg mux.Config) {
 	})
 
 	authapp.Routes(app, authapp.Config{
-		AuthBus: cfg.BusConfig.AuthBus,
-		UserBus: cfg.BusConfig.UserBus,
-		Auth:    cfg.AuthConfig.Auth,
+		Log:               cfg.Log,
+		DB:                cfg.DB,
+		AuthBus:           cfg.BusConfig.AuthBus,
+		AuditBus:          cfg.BusConfig.AuditBus,
+		UserBus:           cfg.BusConfig.UserBus,
+		Auth:              cfg.AuthConfig.Auth,
+		Notifier:          cfg.AuthConfig.Notifier,
+		ResetURL:          cfg.AuthConfig.ResetURL,
+		InviteURL:         cfg.AuthConfig.InviteURL,
+		VerifyURL:         cfg.AuthConfig.VerifyURL,
+		AllowRegistration: cfg.AuthConfig.AllowRegistration,
+		RefreshTTL:        cfg.AuthConfig.RefreshTTL,
+		SessionTTL:        cfg.AuthConfig.SessionTTL,
+		PublicURL:         cfg.AuthConfig.PublicURL,
+		MFAIssuer:         cfg.AuthConfig.MFAIssuer,
+		MFARoles:          cfg.AuthConfig.MFARoles,
+		RateLimiter:       cfg.AuthConfig.RateLimiter,
 	})
+
+	// The oauth routes are only bound when a provider is configured.
+
+	if len(cfg.AuthConfig.OAuthProviders) > 0 {
+		oauthapp.Routes(app, oauthapp.Config{
+			Log:         cfg.Log,
+			Auth:        cfg.AuthConfig.Auth,
+			AuthBus:     cfg.BusConfig.AuthBus,
+			AuditBus:    cfg.BusConfig.AuditBus,
+			IdentityBus: cfg.BusConfig.IdentityBus,
+			TokenKey:    cfg.AuthConfig.OAuthTokenKey,
+			UIURL:       cfg.AuthConfig.OAuthUIURL,
+			Providers:   cfg.AuthConfig.OAuthProviders,
+			Provision:   cfg.AuthConfig.OAuthProvision,
+			RefreshTTL:  cfg.AuthConfig.RefreshTTL,
+			SessionTTL:  cfg.AuthConfig.SessionTTL,
+			MFARoles:    cfg.AuthConfig.MFARoles,
+		})
+	}
 }
@@ -6,6 +6,7 @@ import (
 	"expvar"
 	"fmt"
 	"net/http"
+	"net/mail"
 	"os"
 	"os/signal"
 	"runtime"
@@ -15,18 +16,31 @@ import (
 	"github.com/ardanlabs/conf/v3"
 
 	"github.com/rmsj/service/api/services/auth/build/all"
+	"github.com/rmsj/service/app/domain/oauthapp"
 	"github.com/rmsj/service/app/sdk/auth"
 	"github.com/rmsj/service/app/sdk/debug"
 	"github.com/rmsj/service/app/sdk/mux"
+	"github.com/rmsj/service/business/domain/auditbus"
+	"github.com/rmsj/service/business/domain/auditbus/stores/auditdb"
 	"github.com/rmsj/service/business/domain/authbus"
 	"github.com/rmsj/service/business/domain/authbus/stores/authdb"
+	"github.com/rmsj/service/business/domain/identitybus"
+	"github.com/rmsj/service/business/domain/identitybus/stores/identitydb"
 	"github.com/rmsj/service/business/domain/userbus"
 	"github.com/rmsj/service/business/domain/userbus/stores/userdb"
 	"github.com/rmsj/service/business/sdk/delegate"
+	"github.com/rmsj/service/business/sdk/notify"
+	"github.com/rmsj/service/business/sdk/notify/stores/notifydb"
+	"github.com/rmsj/service/business/sdk/ratelimit"
+	"github.com/rmsj/service/business/sdk/ratelimit/stores/ratelimitdb"
+	"github.com/rmsj/service/business/sdk/ratelimit/stores/ratelimitmem"
 	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/business/types/role"
 	"github.com/rmsj/service/foundation/keystore"
 	"github.com/rmsj/service/foundation/logger"
+	mailer "github.com/rmsj/service/foundation/mail"
 	"github.com/rmsj/service/foundation/otel"
+	"github.com/rmsj/service/foundation/worker"
 )
 
 var build = "develop"
@@ -78,11 +92,50 @@ func run(ctx context.Context, log *logger.Logger) error {
 			CORSAllowedOrigins []string      `conf:"default:*"`
 		}
 		Auth struct {
-			KeysEnvVar string
-			KeysFolder string `conf:"default:zarf/keys/"`
-			ActiveKID  string `conf:"default:54bb2165-71e1-41a6-af3e-7da4a0e1e2c1"`
-			Issuer     string `conf:"default:service project"`
-			APIKey     string `conf:"default:api_key"`
+			KeysEnvVar        string
+			KeysFolder        string        `conf:"default:zarf/keys/"`
+			KeysReload        time.Duration `conf:"default:1m"`
+			KeysGrace         time.Duration `conf:"default:8h"`
+			Issuer            string        `conf:"default:service project"`
+			RefreshTTL        time.Duration `conf:"default:720h"`
+			SessionTTL        time.Duration `conf:"default:2160h"`
+			PublicURL         string        `conf:"default:http://localhost:6000"`
+			ActiveKID         string
+			AllowRegistration bool `conf:"default:false"`
+		}
+		Policy struct {
+			Folder             string
+			Reload             time.Duration `conf:"default:1m"`
+			DecisionSampleRate float64       `conf:"default:0.01"`
+		}
+		OAuth struct {
+			UIURL           string `conf:"default:http://localhost:3000"`
+			CallbackURL     string `conf:"default:http://localhost:6000"`
+			Provision       bool   `conf:"default:false"`
+			GoogleKey       string
+			GoogleSecret    string `conf:"mask"`
+			GitHubKey       string
+			GitHubSecret    string `conf:"mask"`
+			GitLabKey       string
+			GitLabSecret    string `conf:"mask"`
+			MicrosoftKey    string
+			MicrosoftSecret string `conf:"mask"`
+		}
+		MFA struct {
+			Issuer        string   `conf:"default:Service"`
+			RequiredRoles []string `conf:"default:admin;support"`
+		}
+		Lockout struct {
+			MaxAccountFailures int           `conf:"default:5"`
+			MaxIPFailures      int           `conf:"default:50"`
+			Window             time.Duration `conf:"default:15m"`
+			Duration           time.Duration `conf:"default:15m"`
+			BaseDelay          time.Duration `conf:"default:1s"`
+			MaxDelay           time.Duration `conf:"default:30s"`
+		}
+		RateLimit struct {
+			Shared bool     `conf:"default:false"`
+			Quotas []string `conf:"default:accounts=5/1m"`
 		}
 		DB struct {
 			User         string `conf:"default:db_user"`
@@ -93,6 +146,21 @@ func run(ctx context.Context, log *logger.Logger) error {
 			MaxOpenConns int    `conf:"default:0"`
 			DisableTLS   bool   `conf:"default:true"`
 		}
+		Mail struct {
+			Backend      string        `conf:"default:log"`
+			FromName     string        `conf:"default:Service"`
+			FromAddress  string        `conf:"default:no-reply@example.com"`
+			ResetURL     string        `conf:"default:http://localhost:3000/reset-password"`
+			InviteURL    string        `conf:"default:http://localhost:3000/accept-invitation"`
+			VerifyURL    string        `conf:"default:http://localhost:3000/verify-email"`
+			MaxSending   int           `conf:"default:10"`
+			Timeout      time.Duration `conf:"default:30s"`
+			FileDir      string        `conf:"default:zarf/mail/"`
+			SMTPHost     string        `conf:"default:localhost"`
+			SMTPPort     int           `conf:"default:587"`
+			SMTPUsername string
+			SMTPPassword string `conf:"mask"`
+		}
 		Tempo struct {
 			Host        string  `conf:"default:tempo:4317"`
 			ServiceName string  `conf:"default:auth"`
@@ -156,7 +224,96 @@ func run(ctx context.Context, log *logger.Logger) error {
 
 	dlg := delegate.New(log)
 	userBus := userbus.NewBusiness(log, dlg, userdb.NewStore(log, db, time.Second*30))
-	authBus := authbus.NewBusiness(log, authdb.NewStore(log, db))
+	auditBus := auditbus.NewBusiness(log, auditdb.NewStore(log, db))
+	authBus := authbus.NewBusiness(log, auditBus, authdb.NewStore(log, db), authbus.WithLockout(authbus.LockoutConfig{
+		MaxAccountFailures: cfg.Lockout.MaxAccountFailures,
+		MaxIPFailures:      cfg.Lockout.MaxIPFailures,
+		Window:             cfg.Lockout.Window,
+		Duration:           cfg.Lockout.Duration,
+		BaseDelay:          cfg.Lockout.BaseDelay,
+		MaxDelay:           cfg.Lockout.MaxDelay,
+	}))
+	identityBus := identitybus.NewBusiness(log, userBus, identitydb.NewStore(log, db))
+
+	// -------------------------------------------------------------------------
+	// Initialize rate limiting support
+
+	quotas, err := ratelimit.ParsePolicies(cfg.RateLimit.Quotas)
+	if err != nil {
+		return fmt.Errorf("parsing rate limit quotas: %w", err)
+	}
+
+	// The buckets are kept in process unless they must be shared between the
+	// replicas of the service.
+	var rateLimitStorage ratelimit.Storer = ratelimitmem.NewStore()
+	if cfg.RateLimit.Shared {
+		rateLimitStorage = ratelimitdb.NewStore(log, db)
+	}
+
+	rateLimiter := ratelimit.New(ratelimit.Config{
+		Log:      log,
+		Storer:   rateLimitStorage,
+		Policies: quotas,
+	})
+
+	// -------------------------------------------------------------------------
+	// Initialize mail support
+
+	log.Info(ctx, "startup", "status", "initializing mail support", "backend", cfg.Mail.Backend)
+
+	var sender mailer.Sender
+
+	switch cfg.Mail.Backend {
+	case "smtp":
+		sender = mailer.NewSMTP(mailer.SMTPConfig{
+			Host:     cfg.Mail.SMTPHost,
+			Port:     cfg.Mail.SMTPPort,
+			Username: cfg.Mail.SMTPUsername,
+			Password: cfg.Mail.SMTPPassword,
+			Timeout:  cfg.Mail.Timeout,
+		})
+
+	case "file":
+		sender, err = mailer.NewFile(cfg.Mail.FileDir)
+		if err != nil {
+			return fmt.Errorf("constructing file mailer: %w", err)
+		}
+
+	case "log":
+		sender = mailer.NewLog(log)
+
+	default:
+		return fmt.Errorf("unknown mail backend %q", cfg.Mail.Backend)
+	}
+
+	mailWorker, err := worker.New(cfg.Mail.MaxSending)
+	if err != nil {
+		return fmt.Errorf("constructing mail worker: %w", err)
+	}
+
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Web.ShutdownTimeout)
+		defer cancel()
+
+		if err := mailWorker.Shutdown(ctx); err != nil {
+			log.Error(ctx, "shutdown", "status", "mail worker", "msg", err)
+		}
+	}()
+
+	notifier, err := notify.New(notify.Config{
+		Log:    log,
+		Sender: sender,
+		Storer: notifydb.NewStore(log, db),
+		Worker: mailWorker,
+		From: mail.Address{
+			Name:    cfg.Mail.FromName,
+			Address: cfg.Mail.FromAddress,
+		},
+		Timeout: cfg.Mail.Timeout,
+	})
+	if err != nil {
+		return fmt.Errorf("constructing notifier: %w", err)
+	}
 
 	// -------------------------------------------------------------------------
 	// Initialize authentication support
@@ -168,7 +325,7 @@ func run(ctx context.Context, log *logger.Logger) error {
 	// Vault has created these files already. How that happens is not our
 	// concern.
 
-	ks := keystore.New()
+	ks := keystore.New(keystore.WithGracePeriod(cfg.Auth.KeysGrace))
 
 	n1, err := ks.LoadByJSON(cfg.Auth.KeysEnvVar)
 	if err != nil {
@@ -184,13 +341,32 @@ func run(ctx context.Context, log *logger.Logger) error {
 		return errors.New("no keys exist")
 	}
 
+	// Reload the keys folder in the background, so keys can be rotated
+	// without a restart. When no active kid is configured, the key that
+	// signs is picked from the metadata of the keys.
+
+	reloadCtx, reloadCancel := context.WithCancel(ctx)
+	defer reloadCancel()
+
+	go ks.Reload(reloadCtx, os.DirFS(cfg.Auth.KeysFolder), cfg.Auth.KeysReload, func(err error) {
+		log.Error(ctx, "keystore", "status", "reloading keys", "msg", err)
+	})
+
+	mfaRoles, err := role.ParseMany(cfg.MFA.RequiredRoles)
+	if err != nil {
+		return fmt.Errorf("parsing mfa required roles: %w", err)
+	}
+
 	authCfg := auth.Config{
 		Log:       log,
 		UserBus:   userBus,
+		AuthBus:   authBus,
 		KeyLookup: ks,
 		Issuer:    cfg.Auth.Issuer,
-		APIKey:    cfg.Auth.APIKey,
 		ActiveKID: cfg.Auth.ActiveKID,
+
+		DecisionLog:        log,
+		DecisionSampleRate: cfg.Policy.DecisionSampleRate,
 	}
 
 	ath, err := auth.New(authCfg)
@@ -198,6 +374,44 @@ func run(ctx context.Context, log *logger.Logger) error {
 		return fmt.Errorf("constructing auth: %w", err)
 	}
 
+	// The embedded policies are used unless a folder is configured, which is
+	// reloaded in the background so policies can change without a restart.
+
+	if cfg.Policy.Folder != "" {
+		if _, err := ath.LoadPolicies(ctx, os.DirFS(cfg.Policy.Folder)); err != nil {
+			return fmt.Errorf("loading policies: %w", err)
+		}
+
+		go ath.ReloadPolicies(reloadCtx, os.DirFS(cfg.Policy.Folder), cfg.Policy.Reload, func(err error) {
+			log.Error(ctx, "auth", "status", "reloading policies", "msg", err)
+		})
+	}
+
+	// -------------------------------------------------------------------------
+	// Initialize oauth support
+
+	// Only the providers with a key configured are offered.
+
+	var providerCfgs []oauthapp.ProviderConfig
+
+	for _, pc := range []oauthapp.ProviderConfig{
+		{Name: "google", Key: cfg.OAuth.GoogleKey, Secret: cfg.OAuth.GoogleSecret},
+		{Name: "github", Key: cfg.OAuth.GitHubKey, Secret: cfg.OAuth.GitHubSecret},
+		{Name: "gitlab", Key: cfg.OAuth.GitLabKey, Secret: cfg.OAuth.GitLabSecret},
+		{Name: "microsoftonline", Key: cfg.OAuth.MicrosoftKey, Secret: cfg.OAuth.MicrosoftSecret},
+	} {
+		if pc.Key != "" {
+			providerCfgs = append(providerCfgs, pc)
+		}
+	}
+
+	oauthProviders, err := oauthapp.NewProviders(cfg.OAuth.CallbackURL, providerCfgs)
+	if err != nil {
+		return fmt.Errorf("constructing oauth providers: %w", err)
+	}
+
+	log.Info(ctx, "startup", "status", "initializing oauth support", "providers", len(oauthProviders))
+
 	// -------------------------------------------------------------------------
 	// Start Tracing Support
 
@@ -245,11 +459,30 @@ func run(ctx context.Context, log *logger.Logger) error {
 		DB:     db,
 		Tracer: tracer,
 		BusConfig: mux.BusConfig{
-			AuthBus: authBus,
-			UserBus: userBus,
+			AuditBus:    auditBus,
+			AuthBus:     authBus,
+			IdentityBus: identityBus,
+			UserBus:     userBus,
 		},
 		AuthConfig: mux.AuthConfig{
-			Auth: ath,
+			Auth:       ath,
+			Notifier:   notifier,
+			ResetURL:   cfg.Mail.ResetURL,
+			InviteURL:  cfg.Mail.InviteURL,
+			VerifyURL:  cfg.Mail.VerifyURL,
+			RefreshTTL: cfg.Auth.RefreshTTL,
+			SessionTTL: cfg.Auth.SessionTTL,
+			PublicURL:  cfg.Auth.PublicURL,
+			MFAIssuer:  cfg.MFA.Issuer,
+			MFARoles:   mfaRoles,
+
+			RateLimiter:       rateLimiter,
+			AllowRegistration: cfg.Auth.AllowRegistration,
+
+			OAuthProviders: oauthProviders,
+			OAuthUIURL:     cfg.OAuth.UIURL,
+			OAuthProvision: cfg.OAuth.Provision,
+			OAuthTokenKey:  cfg.Auth.ActiveKID,
 		},
 	}
 
@@ -0,0 +1,196 @@
+package oauth_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/markbates/goth/gothic"
+	"github.com/markbates/goth/providers/faux"
+
+	authbuild "github.com/rmsj/service/api/services/auth/build/all"
+	"github.com/rmsj/service/app/domain/oauthapp"
+	"github.com/rmsj/service/app/sdk/apitest"
+	"github.com/rmsj/service/app/sdk/auth"
+	"github.com/rmsj/service/app/sdk/mux"
+	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/sdk/dbtest"
+	"github.com/rmsj/service/business/types/role"
+)
+
+type oauthTable struct {
+	name       string
+	session    faux.Session
+	statusCode int
+	expUser    func(ctx context.Context) (userbus.User, error)
+}
+
+func Test_OAuth(t *testing.T) {
+	t.Parallel()
+
+	db := dbtest.New(t, "Test_OAuth")
+
+	ath, err := auth.New(auth.Config{
+		Log:       db.Log,
+		UserBus:   db.BusDomain.User,
+		AuthBus:   db.BusDomain.Auth,
+		KeyLookup: &apitest.KeyStore{},
+		ActiveKID: "54bb2165-71e1-41a6-af3e-7da4a0e1e2c1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	providers, err := oauthapp.NewProviders("http://localhost:6000", []oauthapp.ProviderConfig{{Name: "faux"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the default store needs SESSION_SECRET to be set before the package
+	// is loaded
+	gothic.Store = sessions.NewCookieStore([]byte("oauth-test-session-secret"))
+
+	tMux := mux.WebAPI(mux.Config{
+		Log: db.Log,
+		DB:  db.DB,
+		BusConfig: mux.BusConfig{
+			AuditBus:    db.BusDomain.Audit,
+			AuthBus:     db.BusDomain.Auth,
+			UserBus:     db.BusDomain.User,
+			IdentityBus: db.BusDomain.Identity,
+		},
+		AuthConfig: mux.AuthConfig{
+			Auth:           ath,
+			RefreshTTL:     time.Hour,
+			SessionTTL:     24 * time.Hour,
+			OAuthProviders: providers,
+			OAuthUIURL:     "http://localhost:3000",
+			OAuthProvision: true,
+		},
+	}, authbuild.Routes())
+
+	usrs, err := userbus.TestSeedUsers(context.Background(), 1, role.User, db.BusDomain.User)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	run(t, tMux, ath, callback(db, usrs[0]), "callback")
+}
+
+func run(t *testing.T, handler http.Handler, ath *auth.Auth, tt []oauthTable, testName string) {
+	for _, tc := range tt {
+		f := func(t *testing.T) {
+			ctx := context.Background()
+
+			// the provider keeps the user it returns in the session, as if
+			// the redirect to the provider already happened
+			sr := httptest.NewRequest(http.MethodGet, "/", nil)
+			sw := httptest.NewRecorder()
+			if err := gothic.StoreInSession("faux", tc.session.Marshal(), sr, sw); err != nil {
+				t.Fatalf("Should be able to store the session : %s", err)
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/api/auth/faux/callback", nil)
+			for _, c := range sw.Result().Cookies() {
+				r.AddCookie(c)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tc.statusCode {
+				t.Fatalf("%s: Should receive a status code of %d for the response : %d", tc.name, tc.statusCode, w.Code)
+			}
+
+			if tc.statusCode != http.StatusFound {
+				return
+			}
+
+			loc, err := url.Parse(w.Header().Get("Location"))
+			if err != nil {
+				t.Fatalf("Should be able to parse the redirect : %s", err)
+			}
+
+			if exp := "http://localhost:3000/app/admin"; fmt.Sprintf("%s://%s%s", loc.Scheme, loc.Host, loc.Path) != exp {
+				t.Fatalf("Should redirect to %s : %s", exp, loc)
+			}
+
+			fragment, err := url.ParseQuery(loc.Fragment)
+			if err != nil {
+				t.Fatalf("Should be able to parse the fragment : %s", err)
+			}
+
+			if loc.RawQuery != "" {
+				t.Fatalf("Should not pass the tokens in the query : %s", loc.RawQuery)
+			}
+
+			if fragment.Get("refresh_token") == "" {
+				t.Fatalf("Should receive a refresh token : %s", loc.Fragment)
+			}
+
+			claims, err := ath.Authenticate(ctx, "Bearer "+fragment.Get("token"))
+			if err != nil {
+				t.Fatalf("Should receive a valid token : %s", err)
+			}
+
+			usr, err := tc.expUser(ctx)
+			if err != nil {
+				t.Fatalf("Should be able to find the user : %s", err)
+			}
+
+			if claims.Subject != usr.ID.String() {
+				t.Fatalf("Should log in as %s : %s", usr.ID, claims.Subject)
+			}
+		}
+
+		t.Run(testName+"-"+tc.name, f)
+	}
+}
+
+// =============================================================================
+
+func callback(db *dbtest.Database, usr userbus.User) []oauthTable {
+	provisioned := mail.Address{Address: "provisioned@example.com"}
+
+	existing := func(ctx context.Context) (userbus.User, error) {
+		return usr, nil
+	}
+
+	table := []oauthTable{
+		{
+			name:       "unverified",
+			session:    faux.Session{ID: "faux-unverified", Name: "Unverified"},
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "link",
+			session:    faux.Session{ID: "faux-link", Name: usr.Name.String(), Email: usr.Email.Address},
+			statusCode: http.StatusFound,
+			expUser:    existing,
+		},
+		{
+			name:       "linked",
+			session:    faux.Session{ID: "faux-link", Name: usr.Name.String()},
+			statusCode: http.StatusFound,
+			expUser:    existing,
+		},
+		{
+			name:       "provision",
+			session:    faux.Session{ID: "faux-provision", Name: "Provisioned", Email: provisioned.Address},
+			statusCode: http.StatusFound,
+			expUser: func(ctx context.Context) (userbus.User, error) {
+				return db.BusDomain.User.QueryByEmail(ctx, provisioned)
+			},
+		},
+	}
+
+	return table
+}
@@ -2,12 +2,16 @@
 package all
 
 import (
+	"github.com/rmsj/service/app/domain/auditapp"
 	"github.com/rmsj/service/app/domain/checkapp"
+	"github.com/rmsj/service/app/domain/orderapp"
 	"github.com/rmsj/service/app/domain/productapp"
 	"github.com/rmsj/service/app/domain/rawapp"
+	"github.com/rmsj/service/app/domain/schedulerapp"
 	"github.com/rmsj/service/app/domain/tranapp"
 	"github.com/rmsj/service/app/domain/userapp"
 	"github.com/rmsj/service/app/domain/vproductapp"
+	"github.com/rmsj/service/app/domain/webhookapp"
 	"github.com/rmsj/service/app/sdk/mux"
 	"github.com/rmsj/service/foundation/web"
 )
@@ -22,33 +26,68 @@ type add struct{}
 
 // Add implements the RouterAdder interface.
 func (add) Add(app *web.App, cfg mux.Config) {
+	auditapp.Routes(app, auditapp.Config{
+		Log:        cfg.Log,
+		AuditBus:   cfg.BusConfig.AuditBus,
+		AuthClient: cfg.SalesConfig.AuthClient,
+	})
+
 	checkapp.Routes(app, checkapp.Config{
 		Build: cfg.Build,
 		Log:   cfg.Log,
 		DB:    cfg.DB,
 	})
 
+	orderapp.Routes(app, orderapp.Config{
+		Log:         cfg.Log,
+		DB:          cfg.DB,
+		OrderBus:    cfg.BusConfig.OrderBus,
+		AuditBus:    cfg.BusConfig.AuditBus,
+		AuthClient:  cfg.SalesConfig.AuthClient,
+		RateLimiter: cfg.SalesConfig.RateLimiter,
+	})
+
 	productapp.Routes(app, productapp.Config{
-		Log:        cfg.Log,
-		UserBus:    cfg.BusConfig.UserBus,
-		ProductBus: cfg.BusConfig.ProductBus,
-		AuthClient: cfg.SalesConfig.AuthClient,
+		Log:         cfg.Log,
+		DB:          cfg.DB,
+		UserBus:     cfg.BusConfig.UserBus,
+		ProductBus:  cfg.BusConfig.ProductBus,
+		AuditBus:    cfg.BusConfig.AuditBus,
+		AuthClient:  cfg.SalesConfig.AuthClient,
+		RateLimiter: cfg.SalesConfig.RateLimiter,
+
+		IdempotencyBus: cfg.BusConfig.IdempotencyBus,
+		IdempotencyTTL: cfg.SalesConfig.IdempotencyTTL,
 	})
 
 	rawapp.Routes(app)
 
-	tranapp.Routes(app, tranapp.Config{
+	schedulerapp.Routes(app, schedulerapp.Config{
 		Log:        cfg.Log,
-		DB:         cfg.DB,
-		UserBus:    cfg.BusConfig.UserBus,
-		ProductBus: cfg.BusConfig.ProductBus,
+		Scheduler:  cfg.SalesConfig.Scheduler,
 		AuthClient: cfg.SalesConfig.AuthClient,
 	})
 
+	tranapp.Routes(app, tranapp.Config{
+		Log:         cfg.Log,
+		DB:          cfg.DB,
+		UserBus:     cfg.BusConfig.UserBus,
+		ProductBus:  cfg.BusConfig.ProductBus,
+		AuditBus:    cfg.BusConfig.AuditBus,
+		AuthClient:  cfg.SalesConfig.AuthClient,
+		RateLimiter: cfg.SalesConfig.RateLimiter,
+
+		IdempotencyBus: cfg.BusConfig.IdempotencyBus,
+		IdempotencyTTL: cfg.SalesConfig.IdempotencyTTL,
+	})
+
 	userapp.Routes(app, userapp.Config{
-		Log:        cfg.Log,
-		UserBus:    cfg.BusConfig.UserBus,
-		AuthClient: cfg.SalesConfig.AuthClient,
+		Log:         cfg.Log,
+		DB:          cfg.DB,
+		UserBus:     cfg.BusConfig.UserBus,
+		AuditBus:    cfg.BusConfig.AuditBus,
+		AuthClient:  cfg.SalesConfig.AuthClient,
+		RateLimiter: cfg.SalesConfig.RateLimiter,
 	})
 
 	vproductapp.Routes(app, vproductapp.Config{
@@ -56,5 +95,15 @@ func (add) Add(app *web.App, cfg mux.Config) {
 		UserBus:     cfg.BusConfig.UserBus,
 		VProductBus: cfg.BusConfig.VProductBus,
 		AuthClient:  cfg.SalesConfig.AuthClient,
+		RateLimiter: cfg.SalesConfig.RateLimiter,
+	})
+
+	webhookapp.Routes(app, webhookapp.Config{
+		Log:         cfg.Log,
+		DB:          cfg.DB,
+		WebhookBus:  cfg.BusConfig.WebhookBus,
+		AuditBus:    cfg.BusConfig.AuditBus,
+		AuthClient:  cfg.SalesConfig.AuthClient,
+		RateLimiter: cfg.SalesConfig.RateLimiter,
 	})
 }
@@ -3,9 +3,11 @@ package crud
 
 import (
 	"github.com/rmsj/service/app/domain/checkapp"
+	"github.com/rmsj/service/app/domain/orderapp"
 	"github.com/rmsj/service/app/domain/productapp"
 	"github.com/rmsj/service/app/domain/tranapp"
 	"github.com/rmsj/service/app/domain/userapp"
+	"github.com/rmsj/service/app/domain/webhookapp"
 	"github.com/rmsj/service/app/sdk/mux"
 	"github.com/rmsj/service/foundation/web"
 )
@@ -26,22 +28,48 @@ func (add) Add(app *web.App, cfg mux.Config) {
 		DB:    cfg.DB,
 	})
 
+	orderapp.Routes(app, orderapp.Config{
+		Log:         cfg.Log,
+		DB:          cfg.DB,
+		OrderBus:    cfg.BusConfig.OrderBus,
+		AuthClient:  cfg.SalesConfig.AuthClient,
+		RateLimiter: cfg.SalesConfig.RateLimiter,
+	})
+
 	productapp.Routes(app, productapp.Config{
-		UserBus:    cfg.BusConfig.UserBus,
-		ProductBus: cfg.BusConfig.ProductBus,
-		AuthClient: cfg.SalesConfig.AuthClient,
+		UserBus:     cfg.BusConfig.UserBus,
+		ProductBus:  cfg.BusConfig.ProductBus,
+		AuthClient:  cfg.SalesConfig.AuthClient,
+		RateLimiter: cfg.SalesConfig.RateLimiter,
+
+		IdempotencyBus: cfg.BusConfig.IdempotencyBus,
+		IdempotencyTTL: cfg.SalesConfig.IdempotencyTTL,
 	})
 
 	tranapp.Routes(app, tranapp.Config{
-		UserBus:    cfg.BusConfig.UserBus,
-		ProductBus: cfg.BusConfig.ProductBus,
-		Log:        cfg.Log,
-		AuthClient: cfg.SalesConfig.AuthClient,
-		DB:         cfg.DB,
+		UserBus:     cfg.BusConfig.UserBus,
+		ProductBus:  cfg.BusConfig.ProductBus,
+		Log:         cfg.Log,
+		AuthClient:  cfg.SalesConfig.AuthClient,
+		RateLimiter: cfg.SalesConfig.RateLimiter,
+		DB:          cfg.DB,
+
+		IdempotencyBus: cfg.BusConfig.IdempotencyBus,
+		IdempotencyTTL: cfg.SalesConfig.IdempotencyTTL,
 	})
 
 	userapp.Routes(app, userapp.Config{
-		UserBus:    cfg.BusConfig.UserBus,
-		AuthClient: cfg.SalesConfig.AuthClient,
+		Log:         cfg.Log,
+		DB:          cfg.DB,
+		UserBus:     cfg.BusConfig.UserBus,
+		AuthClient:  cfg.SalesConfig.AuthClient,
+		RateLimiter: cfg.SalesConfig.RateLimiter,
+	})
+
+	webhookapp.Routes(app, webhookapp.Config{
+		Log:         cfg.Log,
+		WebhookBus:  cfg.BusConfig.WebhookBus,
+		AuthClient:  cfg.SalesConfig.AuthClient,
+		RateLimiter: cfg.SalesConfig.RateLimiter,
 	})
 }
@@ -28,5 +28,6 @@ func (add) Add(app *web.App, cfg mux.Config) {
 		UserBus:     cfg.BusConfig.UserBus,
 		VProductBus: cfg.BusConfig.VProductBus,
 		AuthClient:  cfg.SalesConfig.AuthClient,
+		RateLimiter: cfg.SalesConfig.RateLimiter,
 	})
 }
@@ -18,19 +18,41 @@ import (
 	"github.com/rmsj/service/api/services/sales/build/all"
 	"github.com/rmsj/service/api/services/sales/build/crud"
 	"github.com/rmsj/service/api/services/sales/build/reporting"
+	"github.com/rmsj/service/api/services/sales/tasks"
+	"github.com/rmsj/service/app/domain/userapp"
+	"github.com/rmsj/service/app/sdk/auth"
 	"github.com/rmsj/service/app/sdk/authclient"
 	"github.com/rmsj/service/app/sdk/debug"
 	"github.com/rmsj/service/app/sdk/mux"
+	"github.com/rmsj/service/business/domain/auditbus"
+	"github.com/rmsj/service/business/domain/auditbus/stores/auditdb"
+	"github.com/rmsj/service/business/domain/authbus"
+	"github.com/rmsj/service/business/domain/authbus/stores/authdb"
+	"github.com/rmsj/service/business/domain/idempotencybus"
+	"github.com/rmsj/service/business/domain/idempotencybus/stores/idempotencydb"
+	"github.com/rmsj/service/business/domain/orderbus"
+	"github.com/rmsj/service/business/domain/orderbus/stores/orderdb"
 	"github.com/rmsj/service/business/domain/productbus"
 	"github.com/rmsj/service/business/domain/productbus/stores/productdb"
 	"github.com/rmsj/service/business/domain/userbus"
 	"github.com/rmsj/service/business/domain/userbus/stores/userdb"
 	"github.com/rmsj/service/business/domain/vproductbus"
 	"github.com/rmsj/service/business/domain/vproductbus/stores/vproductdb"
+	"github.com/rmsj/service/business/domain/webhookbus"
+	"github.com/rmsj/service/business/domain/webhookbus/stores/webhookdb"
 	"github.com/rmsj/service/business/sdk/delegate"
+	"github.com/rmsj/service/business/sdk/delegate/stores/outboxdb"
+	"github.com/rmsj/service/business/sdk/jobqueue"
+	"github.com/rmsj/service/business/sdk/jobqueue/stores/jobdb"
+	"github.com/rmsj/service/business/sdk/ratelimit"
+	"github.com/rmsj/service/business/sdk/ratelimit/stores/ratelimitdb"
+	"github.com/rmsj/service/business/sdk/ratelimit/stores/ratelimitmem"
+	"github.com/rmsj/service/business/sdk/scheduler"
+	"github.com/rmsj/service/business/sdk/scheduler/stores/schedulerdb"
 	"github.com/rmsj/service/business/sdk/sqldb"
 	"github.com/rmsj/service/foundation/logger"
 	"github.com/rmsj/service/foundation/otel"
+	"github.com/rmsj/service/foundation/worker"
 )
 
 /*
@@ -90,7 +112,15 @@ func run(ctx context.Context, log *logger.Logger) error {
 			CORSAllowedOrigins []string      `conf:"default:*"`
 		}
 		Auth struct {
-			Host string `conf:"default:http://auth-service:6000"`
+			Host        string        `conf:"default:http://auth-service:6000"`
+			LocalVerify bool          `conf:"default:true"`
+			Issuer      string        `conf:"default:service project"`
+			JWKSTTL     time.Duration `conf:"default:5m"`
+		}
+		Policy struct {
+			Folder             string
+			Reload             time.Duration `conf:"default:1m"`
+			DecisionSampleRate float64       `conf:"default:0.01"`
 		}
 		DB struct {
 			User         string `conf:"default:db_user"`
@@ -101,6 +131,50 @@ func run(ctx context.Context, log *logger.Logger) error {
 			MaxOpenConns int    `conf:"default:0"`
 			DisableTLS   bool   `conf:"default:true"`
 		}
+		Outbox struct {
+			Interval    time.Duration `conf:"default:1s"`
+			BatchSize   int           `conf:"default:50"`
+			Lease       time.Duration `conf:"default:1m"`
+			MaxAttempts int           `conf:"default:10"`
+			MinBackoff  time.Duration `conf:"default:1s"`
+			MaxBackoff  time.Duration `conf:"default:1h"`
+		}
+		Webhook struct {
+			Interval    time.Duration `conf:"default:1s"`
+			BatchSize   int           `conf:"default:20"`
+			Timeout     time.Duration `conf:"default:10s"`
+			Lease       time.Duration `conf:"default:1m"`
+			MaxAttempts int           `conf:"default:8"`
+			MinBackoff  time.Duration `conf:"default:10s"`
+			MaxBackoff  time.Duration `conf:"default:6h"`
+		}
+		Jobs struct {
+			MaxRunning        int           `conf:"default:10"`
+			Interval          time.Duration `conf:"default:1s"`
+			VisibilityTimeout time.Duration `conf:"default:5m"`
+			MinBackoff        time.Duration `conf:"default:5s"`
+			MaxBackoff        time.Duration `conf:"default:1h"`
+		}
+		Scheduler struct {
+			MaxRunning               int           `conf:"default:4"`
+			Interval                 time.Duration `conf:"default:10s"`
+			Lease                    time.Duration `conf:"default:10m"`
+			PurgeResetsSchedule      string        `conf:"default:*/15 * * * *"`
+			StockReportSchedule      string        `conf:"default:0 3 * * *"`
+			PurgeTokensSchedule      string        `conf:"default:30 3 * * *"`
+			PurgeLoginsSchedule      string        `conf:"default:45 * * * *"`
+			PurgeUserTokensSchedule  string        `conf:"default:50 * * * *"`
+			PurgeIdempotencySchedule string        `conf:"default:55 * * * *"`
+			PurgeRateLimitsSchedule  string        `conf:"default:*/10 * * * *"`
+			LowStock                 int           `conf:"default:10"`
+		}
+		Idempotency struct {
+			TTL time.Duration `conf:"default:24h"`
+		}
+		RateLimit struct {
+			Shared bool     `conf:"default:false"`
+			Quotas []string `conf:"default:*=300/1m;*:admin=3000/1m"`
+		}
 		Tempo struct {
 			Host        string  `conf:"default:tempo:4317"`
 			ServiceName string  `conf:"default:sales"`
@@ -167,17 +241,87 @@ func run(ctx context.Context, log *logger.Logger) error {
 
 	userStorage := userdb.NewStore(log, db, time.Minute)
 
-	dlg := delegate.New(log)
+	outboxStorage := outboxdb.NewStore(log, db)
+
+	dlg := delegate.NewWithOutbox(log, outboxStorage)
+	auditBus := auditbus.NewBusiness(log, auditdb.NewStore(log, db))
+	authBus := authbus.NewBusiness(log, auditBus, authdb.NewStore(log, db))
+	idempotencyBus := idempotencybus.NewBusiness(log, idempotencydb.NewStore(log, db))
 	userBus := userbus.NewBusiness(log, dlg, userStorage)
 	productBus := productbus.NewBusiness(log, userBus, dlg, productdb.NewStore(log, db))
+	orderBus := orderbus.NewBusiness(log, userBus, productBus, dlg, orderdb.NewStore(log, db))
 	vproductBus := vproductbus.NewBusiness(vproductdb.NewStore(log, db))
+	webhookBus := webhookbus.NewBusiness(log, dlg, webhookdb.NewStore(log, db))
+	jobQueue := jobqueue.New(log, jobdb.NewStore(log, db))
+
+	// -------------------------------------------------------------------------
+	// Initialize rate limiting support
+
+	quotas, err := ratelimit.ParsePolicies(cfg.RateLimit.Quotas)
+	if err != nil {
+		return fmt.Errorf("parsing rate limit quotas: %w", err)
+	}
+
+	// The buckets are kept in process unless they must be shared between the
+	// replicas of the service.
+	var rateLimitStorage ratelimit.Storer = ratelimitmem.NewStore()
+	if cfg.RateLimit.Shared {
+		rateLimitStorage = ratelimitdb.NewStore(log, db)
+	}
+
+	rateLimiter := ratelimit.New(ratelimit.Config{
+		Log:      log,
+		Storer:   rateLimitStorage,
+		Policies: quotas,
+	})
 
 	// -------------------------------------------------------------------------
 	// Initialize authentication support
 
 	log.Info(ctx, "startup", "status", "initializing authentication support")
 
-	authClient := authclient.New(log, cfg.Auth.Host)
+	var authOptions []func(*authclient.Client)
+
+	if cfg.Auth.LocalVerify {
+		ath, err := auth.New(auth.Config{
+			Log:     log,
+			UserBus: userBus,
+			AuthBus: authBus,
+			KeyLookup: auth.NewJWKSLookup(auth.JWKSConfig{
+				Log: log,
+				URL: authclient.JWKSURL(cfg.Auth.Host),
+				TTL: cfg.Auth.JWKSTTL,
+			}),
+			Issuer:             cfg.Auth.Issuer,
+			DecisionLog:        log,
+			DecisionSampleRate: cfg.Policy.DecisionSampleRate,
+		})
+		if err != nil {
+			return fmt.Errorf("constructing auth: %w", err)
+		}
+
+		// The policies must match the ones of the auth service, reloaded the
+		// same way.
+
+		if cfg.Policy.Folder != "" {
+			if _, err := ath.LoadPolicies(ctx, os.DirFS(cfg.Policy.Folder)); err != nil {
+				return fmt.Errorf("loading policies: %w", err)
+			}
+
+			reloadCtx, reloadCancel := context.WithCancel(ctx)
+			defer reloadCancel()
+
+			go ath.ReloadPolicies(reloadCtx, os.DirFS(cfg.Policy.Folder), cfg.Policy.Reload, func(err error) {
+				log.Error(ctx, "auth", "status", "reloading policies", "msg", err)
+			})
+		}
+
+		authOptions = append(authOptions, authclient.WithLocalAuth(ath))
+	}
+
+	authClient := authclient.New(log, cfg.Auth.Host, authOptions...)
+
+	userapp.RegisterDelegateFunctions(dlg, authClient)
 
 	// -------------------------------------------------------------------------
 	// Start Tracing Support
@@ -212,6 +356,170 @@ func run(ctx context.Context, log *logger.Logger) error {
 		}
 	}()
 
+	// -------------------------------------------------------------------------
+	// Start Outbox Dispatcher
+
+	dispatcher := delegate.NewDispatcher(delegate.DispatcherConfig{
+		Log:         log,
+		Delegate:    dlg,
+		Beginner:    sqldb.NewBeginner(db),
+		Storer:      outboxStorage,
+		Interval:    cfg.Outbox.Interval,
+		BatchSize:   cfg.Outbox.BatchSize,
+		Lease:       cfg.Outbox.Lease,
+		MaxAttempts: cfg.Outbox.MaxAttempts,
+		MinBackoff:  cfg.Outbox.MinBackoff,
+		MaxBackoff:  cfg.Outbox.MaxBackoff,
+	})
+
+	dispatcherCtx, dispatcherCancel := context.WithCancel(ctx)
+	dispatcherDone := make(chan struct{})
+
+	go func() {
+		defer close(dispatcherDone)
+		dispatcher.Run(dispatcherCtx)
+	}()
+
+	defer func() {
+		dispatcherCancel()
+		<-dispatcherDone
+	}()
+
+	// -------------------------------------------------------------------------
+	// Start Webhook Sender
+
+	sender := webhookbus.NewSender(webhookbus.SenderConfig{
+		Log:        log,
+		WebhookBus: webhookBus,
+		Beginner:   sqldb.NewBeginner(db),
+		Client: &http.Client{
+			Timeout: cfg.Webhook.Timeout,
+		},
+		Interval:    cfg.Webhook.Interval,
+		BatchSize:   cfg.Webhook.BatchSize,
+		Lease:       cfg.Webhook.Lease,
+		MaxAttempts: cfg.Webhook.MaxAttempts,
+		MinBackoff:  cfg.Webhook.MinBackoff,
+		MaxBackoff:  cfg.Webhook.MaxBackoff,
+	})
+
+	senderCtx, senderCancel := context.WithCancel(ctx)
+	senderDone := make(chan struct{})
+
+	go func() {
+		defer close(senderDone)
+		sender.Run(senderCtx)
+	}()
+
+	defer func() {
+		senderCancel()
+		<-senderDone
+	}()
+
+	// -------------------------------------------------------------------------
+	// Start Job Runner
+
+	wrk, err := worker.New(cfg.Jobs.MaxRunning)
+	if err != nil {
+		return fmt.Errorf("constructing job worker: %w", err)
+	}
+
+	runner := jobqueue.NewRunner(jobqueue.RunnerConfig{
+		Log:               log,
+		Queue:             jobQueue,
+		Beginner:          sqldb.NewBeginner(db),
+		Worker:            wrk,
+		Interval:          cfg.Jobs.Interval,
+		VisibilityTimeout: cfg.Jobs.VisibilityTimeout,
+		MinBackoff:        cfg.Jobs.MinBackoff,
+		MaxBackoff:        cfg.Jobs.MaxBackoff,
+	})
+
+	runnerCtx, runnerCancel := context.WithCancel(ctx)
+	runnerDone := make(chan struct{})
+
+	go func() {
+		defer close(runnerDone)
+		runner.Run(runnerCtx)
+	}()
+
+	defer func() {
+		runnerCancel()
+		<-runnerDone
+
+		// Jobs that don't finish in time stay claimed and are picked up again
+		// once their visibility timeout expires.
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Web.ShutdownTimeout)
+		defer cancel()
+
+		if err := wrk.Shutdown(ctx); err != nil {
+			log.Error(ctx, "shutdown", "status", "job worker", "msg", err)
+		}
+	}()
+
+	// -------------------------------------------------------------------------
+	// Start Scheduler
+
+	schWorker, err := worker.New(cfg.Scheduler.MaxRunning)
+	if err != nil {
+		return fmt.Errorf("constructing scheduler worker: %w", err)
+	}
+
+	owner, err := os.Hostname()
+	if err != nil {
+		return fmt.Errorf("hostname: %w", err)
+	}
+
+	sch := scheduler.New(scheduler.Config{
+		Log:      log,
+		Storer:   schedulerdb.NewStore(log, db),
+		Worker:   schWorker,
+		Owner:    owner,
+		Interval: cfg.Scheduler.Interval,
+		Lease:    cfg.Scheduler.Lease,
+	})
+
+	err = tasks.Register(sch, tasks.Config{
+		Log:                      log,
+		AuthBus:                  authBus,
+		IdempotencyBus:           idempotencyBus,
+		ProductBus:               productBus,
+		RateLimiter:              rateLimiter,
+		PurgeResetsSchedule:      cfg.Scheduler.PurgeResetsSchedule,
+		StockReportSchedule:      cfg.Scheduler.StockReportSchedule,
+		PurgeTokensSchedule:      cfg.Scheduler.PurgeTokensSchedule,
+		PurgeLoginsSchedule:      cfg.Scheduler.PurgeLoginsSchedule,
+		PurgeUserTokensSchedule:  cfg.Scheduler.PurgeUserTokensSchedule,
+		PurgeIdempotencySchedule: cfg.Scheduler.PurgeIdempotencySchedule,
+		PurgeRateLimitsSchedule:  cfg.Scheduler.PurgeRateLimitsSchedule,
+		LowStock:                 cfg.Scheduler.LowStock,
+	})
+	if err != nil {
+		return fmt.Errorf("registering tasks: %w", err)
+	}
+
+	schCtx, schCancel := context.WithCancel(ctx)
+	schDone := make(chan struct{})
+
+	go func() {
+		defer close(schDone)
+		sch.Run(schCtx)
+	}()
+
+	defer func() {
+		schCancel()
+		<-schDone
+
+		// Tasks that don't finish in time are cancelled and recorded as
+		// failed.
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Web.ShutdownTimeout)
+		defer cancel()
+
+		if err := schWorker.Shutdown(ctx); err != nil {
+			log.Error(ctx, "shutdown", "status", "scheduler worker", "msg", err)
+		}
+	}()
+
 	// -------------------------------------------------------------------------
 	// Start API Service
 
@@ -226,12 +534,19 @@ func run(ctx context.Context, log *logger.Logger) error {
 		DB:     db,
 		Tracer: tracer,
 		BusConfig: mux.BusConfig{
-			UserBus:     userBus,
-			ProductBus:  productBus,
-			VProductBus: vproductBus,
+			AuditBus:       auditBus,
+			IdempotencyBus: idempotencyBus,
+			UserBus:        userBus,
+			ProductBus:     productBus,
+			OrderBus:       orderBus,
+			VProductBus:    vproductBus,
+			WebhookBus:     webhookBus,
 		},
 		SalesConfig: mux.SalesConfig{
-			AuthClient: authClient,
+			AuthClient:     authClient,
+			Scheduler:      sch,
+			RateLimiter:    rateLimiter,
+			IdempotencyTTL: cfg.Idempotency.TTL,
 		},
 	}
 
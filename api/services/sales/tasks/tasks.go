@@ -0,0 +1,204 @@
+// Package tasks registers the maintenance tasks the sales service runs on a
+// schedule.
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rmsj/service/business/domain/authbus"
+	"github.com/rmsj/service/business/domain/idempotencybus"
+	"github.com/rmsj/service/business/domain/productbus"
+	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/business/sdk/ratelimit"
+	"github.com/rmsj/service/business/sdk/scheduler"
+	"github.com/rmsj/service/foundation/logger"
+)
+
+// Set of task names.
+const (
+	PurgePasswordResets = "purge-password-resets"
+	StockReport         = "stock-report"
+	PurgeRefreshTokens  = "purge-refresh-tokens"
+	PurgeLoginAttempts  = "purge-login-attempts"
+	PurgeUserTokens     = "purge-user-tokens"
+	PurgeIdempotency    = "purge-idempotency-keys"
+	PurgeRateLimits     = "purge-rate-limits"
+)
+
+// loginAttemptsRetention is how long failed logins are kept once they no
+// longer block anything. It must be longer than the lockout window.
+const loginAttemptsRetention = 24 * time.Hour
+
+// Config contains the dependencies and schedules of the tasks.
+type Config struct {
+	Log                      *logger.Logger
+	AuthBus                  *authbus.Business
+	IdempotencyBus           *idempotencybus.Business
+	ProductBus               *productbus.Business
+	RateLimiter              *ratelimit.Limiter
+	PurgeResetsSchedule      string
+	StockReportSchedule      string
+	PurgeTokensSchedule      string
+	PurgeLoginsSchedule      string
+	PurgeUserTokensSchedule  string
+	PurgeIdempotencySchedule string
+	PurgeRateLimitsSchedule  string
+	LowStock                 int
+}
+
+// Register adds the tasks to the scheduler.
+func Register(sch *scheduler.Scheduler, cfg Config) error {
+	t := tasks{cfg: cfg}
+
+	if err := sch.Register(PurgePasswordResets, cfg.PurgeResetsSchedule, t.purgePasswordResets); err != nil {
+		return err
+	}
+
+	if err := sch.Register(StockReport, cfg.StockReportSchedule, t.stockReport); err != nil {
+		return err
+	}
+
+	if err := sch.Register(PurgeRefreshTokens, cfg.PurgeTokensSchedule, t.purgeRefreshTokens); err != nil {
+		return err
+	}
+
+	if err := sch.Register(PurgeLoginAttempts, cfg.PurgeLoginsSchedule, t.purgeLoginAttempts); err != nil {
+		return err
+	}
+
+	if err := sch.Register(PurgeUserTokens, cfg.PurgeUserTokensSchedule, t.purgeUserTokens); err != nil {
+		return err
+	}
+
+	if err := sch.Register(PurgeIdempotency, cfg.PurgeIdempotencySchedule, t.purgeIdempotency); err != nil {
+		return err
+	}
+
+	if err := sch.Register(PurgeRateLimits, cfg.PurgeRateLimitsSchedule, t.purgeRateLimits); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+type tasks struct {
+	cfg Config
+}
+
+// purgePasswordResets removes the password reset tokens that expired.
+func (t tasks) purgePasswordResets(ctx context.Context) error {
+	count, err := t.cfg.AuthBus.DeleteExpiredPasswordResets(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("delete expired password resets: %w", err)
+	}
+
+	t.cfg.Log.Info(ctx, "tasks", "task", PurgePasswordResets, "removed", count)
+
+	return nil
+}
+
+// purgeRefreshTokens removes the sessions and the refresh tokens that
+// expired. Rotated and revoked tokens are kept until then to detect their
+// reuse.
+func (t tasks) purgeRefreshTokens(ctx context.Context) error {
+	sessions, tokens, err := t.cfg.AuthBus.DeleteExpiredSessions(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("delete expired sessions: %w", err)
+	}
+
+	t.cfg.Log.Info(ctx, "tasks", "task", PurgeRefreshTokens, "sessions", sessions, "removed", tokens)
+
+	return nil
+}
+
+// purgeLoginAttempts removes the failed logins that are old enough to no
+// longer count towards a lockout.
+func (t tasks) purgeLoginAttempts(ctx context.Context) error {
+	count, err := t.cfg.AuthBus.DeleteExpiredLoginAttempts(ctx, time.Now().Add(-loginAttemptsRetention))
+	if err != nil {
+		return fmt.Errorf("delete expired login attempts: %w", err)
+	}
+
+	t.cfg.Log.Info(ctx, "tasks", "task", PurgeLoginAttempts, "removed", count)
+
+	return nil
+}
+
+// purgeUserTokens removes the invitations and email verifications that
+// expired.
+func (t tasks) purgeUserTokens(ctx context.Context) error {
+	count, err := t.cfg.AuthBus.DeleteExpiredUserTokens(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("delete expired user tokens: %w", err)
+	}
+
+	t.cfg.Log.Info(ctx, "tasks", "task", PurgeUserTokens, "removed", count)
+
+	return nil
+}
+
+// purgeIdempotency removes the idempotency keys that expired, along with the
+// responses stored for them.
+func (t tasks) purgeIdempotency(ctx context.Context) error {
+	count, err := t.cfg.IdempotencyBus.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("delete expired idempotency keys: %w", err)
+	}
+
+	t.cfg.Log.Info(ctx, "tasks", "task", PurgeIdempotency, "removed", count)
+
+	return nil
+}
+
+// purgeRateLimits removes the rate limit buckets that are full again, since
+// those are the same as no bucket.
+func (t tasks) purgeRateLimits(ctx context.Context) error {
+	count, err := t.cfg.RateLimiter.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("delete expired rate limits: %w", err)
+	}
+
+	t.cfg.Log.Info(ctx, "tasks", "task", PurgeRateLimits, "removed", count)
+
+	return nil
+}
+
+// stockReport logs the stock levels, listing the products that are running
+// low.
+func (t tasks) stockReport(ctx context.Context) error {
+	const rowsPerPage = 100
+
+	var products, units int
+	var lowStock []string
+
+	for number := 1; ; number++ {
+		pg := page.MustParse(strconv.Itoa(number), strconv.Itoa(rowsPerPage))
+
+		prds, err := t.cfg.ProductBus.Query(ctx, productbus.QueryFilter{}, productbus.DefaultOrderBy, pg)
+		if err != nil {
+			return fmt.Errorf("query products: page[%d]: %w", number, err)
+		}
+
+		for _, prd := range prds {
+			products++
+			units += prd.Quantity.Value()
+
+			if prd.Quantity.Value() <= t.cfg.LowStock {
+				lowStock = append(lowStock, fmt.Sprintf("%s[%s]: %d", prd.Name, prd.ID, prd.Quantity.Value()))
+			}
+		}
+
+		if len(prds) < rowsPerPage {
+			break
+		}
+	}
+
+	t.cfg.Log.Info(ctx, "tasks", "task", StockReport, "products", products, "units", units, "low_stock_threshold", t.cfg.LowStock, "low_stock", lowStock)
+
+	return nil
+}
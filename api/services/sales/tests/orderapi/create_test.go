@@ -0,0 +1,75 @@
+package order_test
+
+import (
+	"net/http"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rmsj/service/app/domain/orderapp"
+	"github.com/rmsj/service/app/sdk/apitest"
+	"github.com/rmsj/service/app/sdk/errs"
+)
+
+func create200(sd apitest.SeedData) []apitest.Table {
+	prd := sd.Users[0].Products[0]
+
+	table := []apitest.Table{
+		{
+			Name:       "basic",
+			URL:        "/v1/orders",
+			Token:      sd.Users[0].Token,
+			Method:     http.MethodPost,
+			StatusCode: http.StatusOK,
+			Input: &orderapp.NewOrder{
+				Items: []orderapp.NewItem{
+					{ProductID: prd.ID.String(), Quantity: 2},
+				},
+			},
+			GotResp: &orderapp.Order{},
+			ExpResp: &orderapp.Order{
+				UserID: sd.Users[0].ID.String(),
+				Status: "draft",
+				Items: []orderapp.Item{
+					{ProductID: prd.ID.String(), Quantity: 2, Price: "10.50", Currency: "USD"},
+				},
+				Total:    "21.00",
+				Currency: "USD",
+			},
+			CmpFunc: func(got any, exp any) string {
+				gotResp, exists := got.(*orderapp.Order)
+				if !exists {
+					return "error occurred"
+				}
+
+				expResp := exp.(*orderapp.Order)
+
+				expResp.ID = gotResp.ID
+				expResp.DateCreated = gotResp.DateCreated
+				expResp.DateUpdated = gotResp.DateUpdated
+
+				return cmp.Diff(gotResp, expResp)
+			},
+		},
+	}
+
+	return table
+}
+
+func create400(sd apitest.SeedData) []apitest.Table {
+	table := []apitest.Table{
+		{
+			Name:       "missing-input",
+			URL:        "/v1/orders",
+			Token:      sd.Users[0].Token,
+			Method:     http.MethodPost,
+			StatusCode: http.StatusBadRequest,
+			Input:      &orderapp.NewOrder{},
+			GotResp:    &errs.Error{},
+			ExpResp:    errs.Newf(errs.InvalidArgument, "validate: [{\"field\":\"items\",\"error\":\"items is a required field\"}]"),
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
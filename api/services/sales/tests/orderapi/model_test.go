@@ -0,0 +1,50 @@
+package order_test
+
+import (
+	"time"
+
+	"github.com/rmsj/service/app/domain/orderapp"
+	"github.com/rmsj/service/business/domain/orderbus"
+)
+
+func toAppOrder(ord orderbus.Order) orderapp.Order {
+	items := make([]orderapp.Item, len(ord.Items))
+	for i, itm := range ord.Items {
+		items[i] = orderapp.Item{
+			ProductID: itm.ProductID.String(),
+			Quantity:  itm.Quantity.Value(),
+			Price:     itm.Price.String(),
+			Currency:  itm.Price.Currency().Code(),
+		}
+	}
+
+	total, err := ord.Total()
+	if err != nil {
+		panic(err)
+	}
+
+	return orderapp.Order{
+		ID:          ord.ID.String(),
+		UserID:      ord.UserID.String(),
+		Status:      ord.Status.String(),
+		Items:       items,
+		Total:       total.String(),
+		Currency:    total.Currency().Code(),
+		DateCreated: ord.DateCreated.Format(time.RFC3339),
+		DateUpdated: ord.DateUpdated.Format(time.RFC3339),
+	}
+}
+
+func toAppOrderPtr(ord orderbus.Order) *orderapp.Order {
+	appOrd := toAppOrder(ord)
+	return &appOrd
+}
+
+func toAppOrders(ords []orderbus.Order) []orderapp.Order {
+	items := make([]orderapp.Order, len(ords))
+	for i, ord := range ords {
+		items[i] = toAppOrder(ord)
+	}
+
+	return items
+}
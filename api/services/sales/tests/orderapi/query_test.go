@@ -0,0 +1,81 @@
+package order_test
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rmsj/service/app/domain/orderapp"
+	"github.com/rmsj/service/app/sdk/apitest"
+	"github.com/rmsj/service/app/sdk/query"
+	"github.com/rmsj/service/business/domain/orderbus"
+	"github.com/rmsj/service/business/sdk/dbtest"
+)
+
+func query200(sd apitest.SeedData) []apitest.Table {
+	ords := make([]orderbus.Order, 0, len(sd.Users[0].Orders))
+	ords = append(ords, sd.Users[0].Orders...)
+
+	sort.Slice(ords, func(i, j int) bool {
+		return ords[i].ID.String() <= ords[j].ID.String()
+	})
+
+	table := []apitest.Table{
+		{
+			Name:       "basic",
+			URL:        "/v1/orders?page=1&rows=10&orderBy=order_id,ASC",
+			Token:      sd.Admins[0].Token,
+			StatusCode: http.StatusOK,
+			Method:     http.MethodGet,
+			GotResp:    &query.Result[orderapp.Order]{},
+			ExpResp: &query.Result[orderapp.Order]{
+				Page:        1,
+				RowsPerPage: 10,
+				Total:       dbtest.IntPointer(len(ords)),
+				Items:       toAppOrders(ords),
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:       "own-orders",
+			URL:        "/v1/orders?page=1&rows=10&orderBy=order_id,ASC",
+			Token:      sd.Users[0].Token,
+			StatusCode: http.StatusOK,
+			Method:     http.MethodGet,
+			GotResp:    &query.Result[orderapp.Order]{},
+			ExpResp: &query.Result[orderapp.Order]{
+				Page:        1,
+				RowsPerPage: 10,
+				Total:       dbtest.IntPointer(len(ords)),
+				Items:       toAppOrders(ords),
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+func queryByID200(sd apitest.SeedData) []apitest.Table {
+	table := []apitest.Table{
+		{
+			Name:       "basic",
+			URL:        fmt.Sprintf("/v1/orders/%s", sd.Users[0].Orders[0].ID),
+			Token:      sd.Users[0].Token,
+			StatusCode: http.StatusOK,
+			Method:     http.MethodGet,
+			GotResp:    &orderapp.Order{},
+			ExpResp:    toAppOrderPtr(sd.Users[0].Orders[0]),
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
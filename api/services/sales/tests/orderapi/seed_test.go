@@ -0,0 +1,74 @@
+package order_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rmsj/service/app/sdk/apitest"
+	"github.com/rmsj/service/app/sdk/auth"
+	"github.com/rmsj/service/business/domain/orderbus"
+	"github.com/rmsj/service/business/domain/productbus"
+	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/sdk/dbtest"
+	"github.com/rmsj/service/business/types/money"
+	"github.com/rmsj/service/business/types/name"
+	"github.com/rmsj/service/business/types/quantity"
+	"github.com/rmsj/service/business/types/role"
+)
+
+func insertSeedData(db *dbtest.Database, ath *auth.Auth) (apitest.SeedData, error) {
+	ctx := context.Background()
+	busDomain := db.BusDomain
+
+	usrs, err := userbus.TestSeedUsers(ctx, 1, role.User, busDomain.User)
+	if err != nil {
+		return apitest.SeedData{}, fmt.Errorf("seeding users : %w", err)
+	}
+
+	np := productbus.NewProduct{
+		UserID:   usrs[0].ID,
+		Name:     name.MustParse("Guitar"),
+		Cost:     money.MustParse("10.50", "USD"),
+		Quantity: quantity.MustParse(5),
+	}
+
+	prd, err := busDomain.Product.Create(ctx, np)
+	if err != nil {
+		return apitest.SeedData{}, fmt.Errorf("seeding products : %w", err)
+	}
+
+	prds := []productbus.Product{prd}
+
+	ords, err := orderbus.TestGenerateSeedOrders(ctx, 2, busDomain.Order, usrs[0].ID, prds)
+	if err != nil {
+		return apitest.SeedData{}, fmt.Errorf("seeding orders : %w", err)
+	}
+
+	tu1 := apitest.User{
+		User:     usrs[0],
+		Products: prds,
+		Orders:   ords,
+		Token:    apitest.Token(db.BusDomain.User, ath, usrs[0].Email.Address),
+	}
+
+	// -------------------------------------------------------------------------
+
+	usrs, err = userbus.TestSeedUsers(ctx, 1, role.Admin, busDomain.User)
+	if err != nil {
+		return apitest.SeedData{}, fmt.Errorf("seeding users : %w", err)
+	}
+
+	tu2 := apitest.User{
+		User:  usrs[0],
+		Token: apitest.Token(db.BusDomain.User, ath, usrs[0].Email.Address),
+	}
+
+	// -------------------------------------------------------------------------
+
+	sd := apitest.SeedData{
+		Admins: []apitest.User{tu2},
+		Users:  []apitest.User{tu1},
+	}
+
+	return sd, nil
+}
@@ -0,0 +1,103 @@
+package order_test
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rmsj/service/app/domain/orderapp"
+	"github.com/rmsj/service/app/sdk/apitest"
+	"github.com/rmsj/service/app/sdk/errs"
+)
+
+func updateStatus200(sd apitest.SeedData) []apitest.Table {
+	ord := toAppOrder(sd.Users[0].Orders[0])
+	ord.Status = "placed"
+
+	table := []apitest.Table{
+		{
+			Name:       "place",
+			URL:        fmt.Sprintf("/v1/orders/%s/status", sd.Users[0].Orders[0].ID),
+			Token:      sd.Users[0].Token,
+			Method:     http.MethodPut,
+			StatusCode: http.StatusOK,
+			Input: &orderapp.UpdateStatus{
+				Status: "placed",
+			},
+			GotResp: &orderapp.Order{},
+			ExpResp: &ord,
+			CmpFunc: func(got any, exp any) string {
+				gotResp, exists := got.(*orderapp.Order)
+				if !exists {
+					return "error occurred"
+				}
+
+				expResp := exp.(*orderapp.Order)
+				expResp.DateUpdated = gotResp.DateUpdated
+
+				return cmp.Diff(gotResp, expResp)
+			},
+		},
+	}
+
+	return table
+}
+
+func updateStatus400(sd apitest.SeedData) []apitest.Table {
+	table := []apitest.Table{
+		{
+			Name:       "bad-status",
+			URL:        fmt.Sprintf("/v1/orders/%s/status", sd.Users[0].Orders[1].ID),
+			Token:      sd.Users[0].Token,
+			Method:     http.MethodPut,
+			StatusCode: http.StatusBadRequest,
+			Input: &orderapp.UpdateStatus{
+				Status: "lost",
+			},
+			GotResp: &errs.Error{},
+			ExpResp: errs.Newf(errs.InvalidArgument, "parse status: invalid order status \"lost\""),
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:       "invalid-transition",
+			URL:        fmt.Sprintf("/v1/orders/%s/status", sd.Users[0].Orders[1].ID),
+			Token:      sd.Admins[0].Token,
+			Method:     http.MethodPut,
+			StatusCode: http.StatusBadRequest,
+			Input: &orderapp.UpdateStatus{
+				Status: "shipped",
+			},
+			GotResp: &errs.Error{},
+			ExpResp: errs.Newf(errs.FailedPrecondition, "invalid order status transition: draft -> shipped"),
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+func updateStatus401(sd apitest.SeedData) []apitest.Table {
+	table := []apitest.Table{
+		{
+			Name:       "owner-pays",
+			URL:        fmt.Sprintf("/v1/orders/%s/status", sd.Users[0].Orders[0].ID),
+			Token:      sd.Users[0].Token,
+			Method:     http.MethodPut,
+			StatusCode: http.StatusUnauthorized,
+			Input: &orderapp.UpdateStatus{
+				Status: "paid",
+			},
+			GotResp: &errs.Error{},
+			ExpResp: errs.Newf(errs.Unauthenticated, "only staff can set the order status to paid"),
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/rmsj/service/app/domain/productapp"
 	"github.com/rmsj/service/app/sdk/apitest"
 	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/business/domain/idempotencybus"
 )
 
 func create200(sd apitest.SeedData) []apitest.Table {
@@ -19,14 +20,15 @@ func create200(sd apitest.SeedData) []apitest.Table {
 			StatusCode: http.StatusOK,
 			Input: &productapp.NewProduct{
 				Name:     "Guitar",
-				Cost:     10.34,
+				Cost:     "10.34",
 				Quantity: 10,
 			},
 			GotResp: &productapp.Product{},
 			ExpResp: &productapp.Product{
 				Name:     "Guitar",
 				UserID:   sd.Users[0].ID.String(),
-				Cost:     10.34,
+				Cost:     "10.34",
+				Currency: "USD",
 				Quantity: 10,
 			},
 			CmpFunc: func(got any, exp any) string {
@@ -41,6 +43,40 @@ func create200(sd apitest.SeedData) []apitest.Table {
 				expResp.DateCreated = gotResp.DateCreated
 				expResp.DateUpdated = gotResp.DateUpdated
 
+				return cmp.Diff(gotResp, expResp)
+			},
+		},
+		{
+			Name:       "asadmin",
+			URL:        "/v1/products",
+			Token:      sd.Admins[0].Token,
+			Method:     http.MethodPost,
+			StatusCode: http.StatusOK,
+			Input: &productapp.NewProduct{
+				Name:     "Piano",
+				Cost:     "1200.00",
+				Quantity: 1,
+			},
+			GotResp: &productapp.Product{},
+			ExpResp: &productapp.Product{
+				Name:     "Piano",
+				UserID:   sd.Admins[0].ID.String(),
+				Cost:     "1200.00",
+				Currency: "USD",
+				Quantity: 1,
+			},
+			CmpFunc: func(got any, exp any) string {
+				gotResp, exists := got.(*productapp.Product)
+				if !exists {
+					return "error occurred"
+				}
+
+				expResp := exp.(*productapp.Product)
+
+				expResp.ID = gotResp.ID
+				expResp.DateCreated = gotResp.DateCreated
+				expResp.DateUpdated = gotResp.DateUpdated
+
 				return cmp.Diff(gotResp, expResp)
 			},
 		},
@@ -49,6 +85,104 @@ func create200(sd apitest.SeedData) []apitest.Table {
 	return table
 }
 
+func createIdempotent(sd apitest.SeedData) []apitest.Table {
+	headers := map[string]string{"Idempotency-Key": "create-guitar"}
+
+	np := productapp.NewProduct{
+		Name:     "Guitar",
+		Cost:     "10.34",
+		Quantity: 10,
+	}
+
+	// The retry must get the product created by the first request.
+	var productID string
+
+	cmpFunc := func(got any, exp any) string {
+		gotResp, exists := got.(*productapp.Product)
+		if !exists {
+			return "error occurred"
+		}
+
+		if productID == "" {
+			productID = gotResp.ID
+		}
+
+		expResp := exp.(*productapp.Product)
+
+		expResp.ID = productID
+		expResp.DateCreated = gotResp.DateCreated
+		expResp.DateUpdated = gotResp.DateUpdated
+
+		return cmp.Diff(gotResp, expResp)
+	}
+
+	table := []apitest.Table{
+		{
+			Name:       "first",
+			URL:        "/v1/products",
+			Token:      sd.Users[0].Token,
+			Method:     http.MethodPost,
+			Headers:    headers,
+			StatusCode: http.StatusOK,
+			Input:      &np,
+			GotResp:    &productapp.Product{},
+			ExpResp: &productapp.Product{
+				Name:     "Guitar",
+				UserID:   sd.Users[0].ID.String(),
+				Cost:     "10.34",
+				Currency: "USD",
+				Quantity: 10,
+			},
+			CmpFunc: cmpFunc,
+		},
+		{
+			Name:       "retry",
+			URL:        "/v1/products",
+			Token:      sd.Users[0].Token,
+			Method:     http.MethodPost,
+			Headers:    headers,
+			StatusCode: http.StatusOK,
+			Input:      &np,
+			GotResp:    &productapp.Product{},
+			ExpResp: &productapp.Product{
+				Name:     "Guitar",
+				UserID:   sd.Users[0].ID.String(),
+				Cost:     "10.34",
+				Currency: "USD",
+				Quantity: 10,
+			},
+			CmpFunc: cmpFunc,
+		},
+	}
+
+	return table
+}
+
+func create422(sd apitest.SeedData) []apitest.Table {
+	table := []apitest.Table{
+		{
+			Name:       "key-reused",
+			URL:        "/v1/products",
+			Token:      sd.Users[0].Token,
+			Method:     http.MethodPost,
+			Headers:    map[string]string{"Idempotency-Key": "create-guitar"},
+			StatusCode: http.StatusUnprocessableEntity,
+			Input: &productapp.NewProduct{
+				Name:     "Piano",
+				Cost:     "1200.00",
+				Quantity: 1,
+			},
+			GotResp: &errs.Error{},
+			ExpResp: errs.New(errs.Unprocessable, idempotencybus.ErrMismatch),
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
 func create400(sd apitest.SeedData) []apitest.Table {
 	table := []apitest.Table{
 		{
@@ -107,18 +241,6 @@ func create401(sd apitest.SeedData) []apitest.Table {
 				return cmp.Diff(got, exp)
 			},
 		},
-		{
-			Name:       "wronguser",
-			URL:        "/v1/products",
-			Token:      sd.Admins[0].Token,
-			Method:     http.MethodPost,
-			StatusCode: http.StatusUnauthorized,
-			GotResp:    &errs.Error{},
-			ExpResp:    errs.Newf(errs.Unauthenticated, "authorize: you are not authorized for that action, claims[[admin]] rule[rule_user_only]: rego evaluation failed : bindings results[[{[true] map[x:false]}]] ok[true]"),
-			CmpFunc: func(got any, exp any) string {
-				return cmp.Diff(got, exp)
-			},
-		},
 	}
 
 	return table
@@ -12,7 +12,8 @@ func toAppProduct(prd productbus.Product) productapp.Product {
 		ID:          prd.ID.String(),
 		UserID:      prd.UserID.String(),
 		Name:        prd.Name.String(),
-		Cost:        prd.Cost.Value(),
+		Cost:        prd.Cost.String(),
+		Currency:    prd.Cost.Currency().Code(),
 		Quantity:    prd.Quantity.Value(),
 		DateCreated: prd.DateCreated.Format(time.RFC3339),
 		DateUpdated: prd.DateUpdated.Format(time.RFC3339),
@@ -22,15 +22,21 @@ func Test_Product(t *testing.T) {
 
 	test.Run(t, query200(sd), "query-200")
 	test.Run(t, query400(sd), "query-400")
+	test.Run(t, queryCursor200(sd), "query-cursor-200")
 	test.Run(t, queryByID200(sd), "querybyid-200")
+	test.Run(t, queryByID401(sd), "querybyid-401")
+	test.Run(t, queryByID304(sd), "querybyid-304")
 
 	test.Run(t, create200(sd), "create-200")
 	test.Run(t, create401(sd), "create-401")
 	test.Run(t, create400(sd), "create-400")
+	test.Run(t, createIdempotent(sd), "create-idempotent")
+	test.Run(t, create422(sd), "create-422")
 
 	test.Run(t, update200(sd), "update-200")
 	test.Run(t, update401(sd), "update-401")
 	test.Run(t, update400(sd), "update-400")
+	test.Run(t, update412(sd), "update-412")
 
 	test.Run(t, delete200(sd), "delete-200")
 	test.Run(t, delete401(sd), "delete-401")
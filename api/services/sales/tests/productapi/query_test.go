@@ -3,6 +3,7 @@ package product_test
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"sort"
 
 	"github.com/google/go-cmp/cmp"
@@ -11,6 +12,9 @@ import (
 	"github.com/rmsj/service/app/sdk/errs"
 	"github.com/rmsj/service/app/sdk/query"
 	"github.com/rmsj/service/business/domain/productbus"
+	"github.com/rmsj/service/business/sdk/dbtest"
+	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/foundation/web"
 )
 
 func query200(sd apitest.SeedData) []apitest.Table {
@@ -33,13 +37,30 @@ func query200(sd apitest.SeedData) []apitest.Table {
 			ExpResp: &query.Result[productapp.Product]{
 				Page:        1,
 				RowsPerPage: 10,
-				Total:       len(prds),
+				Total:       dbtest.IntPointer(len(prds)),
 				Items:       toAppProducts(prds),
 			},
 			CmpFunc: func(got any, exp any) string {
 				return cmp.Diff(got, exp)
 			},
 		},
+		{
+			Name:       "filter",
+			URL:        "/v1/products?page=1&rows=10&orderBy=product_id,ASC&filter=" + url.QueryEscape(fmt.Sprintf("product_id in (%s, %s) and (quantity gte 0 or cost is null)", prds[0].ID, prds[2].ID)),
+			Token:      sd.Admins[0].Token,
+			StatusCode: http.StatusOK,
+			Method:     http.MethodGet,
+			GotResp:    &query.Result[productapp.Product]{},
+			ExpResp: &query.Result[productapp.Product]{
+				Page:        1,
+				RowsPerPage: 10,
+				Total:       dbtest.IntPointer(2),
+				Items:       toAppProducts([]productbus.Product{prds[0], prds[2]}),
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
 	}
 
 	return table
@@ -71,11 +92,141 @@ func query400(sd apitest.SeedData) []apitest.Table {
 				return cmp.Diff(got, exp)
 			},
 		},
+		{
+			Name:       "bad-filter-field",
+			URL:        "/v1/products?page=1&rows=10&filter=" + url.QueryEscape("version gt 1"),
+			Token:      sd.Admins[0].Token,
+			StatusCode: http.StatusBadRequest,
+			Method:     http.MethodGet,
+			GotResp:    &errs.Error{},
+			ExpResp:    errs.Newf(errs.InvalidArgument, "[{\"field\":\"filter\",\"error\":\"unknown field \\\"version\\\"\"}]"),
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:       "cursor-other-order",
+			URL:        "/v1/products?rows=2&orderBy=name,ASC&cursor=" + productCursor(sd.Users[0].Products[0], false),
+			Token:      sd.Admins[0].Token,
+			StatusCode: http.StatusBadRequest,
+			Method:     http.MethodGet,
+			GotResp:    &errs.Error{},
+			ExpResp:    errs.Newf(errs.InvalidArgument, "[{\"field\":\"cursor\",\"error\":\"cursor was made for another order\"}]"),
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:       "page-and-cursor",
+			URL:        "/v1/products?page=1&rows=2&cursor=" + productCursor(sd.Users[0].Products[0], false),
+			Token:      sd.Admins[0].Token,
+			StatusCode: http.StatusBadRequest,
+			Method:     http.MethodGet,
+			GotResp:    &errs.Error{},
+			ExpResp:    errs.Newf(errs.InvalidArgument, "[{\"field\":\"page\",\"error\":\"page and cursor can't be used together\"}]"),
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
 	}
 
 	return table
 }
 
+func queryCursor200(sd apitest.SeedData) []apitest.Table {
+	prds := make([]productbus.Product, 0, len(sd.Admins[0].Products)+len(sd.Users[0].Products))
+	prds = append(prds, sd.Admins[0].Products...)
+	prds = append(prds, sd.Users[0].Products...)
+
+	sort.Slice(prds, func(i, j int) bool {
+		return prds[i].ID.String() <= prds[j].ID.String()
+	})
+
+	table := []apitest.Table{
+		{
+			Name:       "first",
+			URL:        "/v1/products?page=1&rows=2",
+			Token:      sd.Admins[0].Token,
+			StatusCode: http.StatusOK,
+			Method:     http.MethodGet,
+			GotResp:    &query.Result[productapp.Product]{},
+			ExpResp: &query.Result[productapp.Product]{
+				Page:        1,
+				RowsPerPage: 2,
+				Total:       dbtest.IntPointer(len(prds)),
+				Items:       toAppProducts(prds[:2]),
+				Next:        productCursor(prds[1], false),
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:       "next",
+			URL:        "/v1/products?rows=2&cursor=" + productCursor(prds[1], false),
+			Token:      sd.Admins[0].Token,
+			StatusCode: http.StatusOK,
+			Method:     http.MethodGet,
+			GotResp:    &query.Result[productapp.Product]{},
+			ExpResp: &query.Result[productapp.Product]{
+				RowsPerPage: 2,
+				Items:       toAppProducts(prds[2:]),
+				Next:        productCursor(prds[3], false),
+				Prev:        productCursor(prds[2], true),
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:       "last",
+			URL:        "/v1/products?rows=2&cursor=" + productCursor(prds[3], false),
+			Token:      sd.Admins[0].Token,
+			StatusCode: http.StatusOK,
+			Method:     http.MethodGet,
+			GotResp:    &query.Result[productapp.Product]{},
+			ExpResp: &query.Result[productapp.Product]{
+				RowsPerPage: 2,
+				Items:       []productapp.Product{},
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:       "prev",
+			URL:        "/v1/products?rows=2&cursor=" + productCursor(prds[2], true),
+			Token:      sd.Admins[0].Token,
+			StatusCode: http.StatusOK,
+			Method:     http.MethodGet,
+			GotResp:    &query.Result[productapp.Product]{},
+			ExpResp: &query.Result[productapp.Product]{
+				RowsPerPage: 2,
+				Items:       toAppProducts(prds[:2]),
+				Next:        productCursor(prds[1], false),
+				Prev:        productCursor(prds[0], true),
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+// productCursor returns the cursor of the product in the default order.
+func productCursor(prd productbus.Product, backward bool) string {
+	c := page.Cursor{
+		OrderBy:  productbus.DefaultOrderBy,
+		Value:    prd.ID.String(),
+		ID:       prd.ID.String(),
+		Backward: backward,
+	}
+
+	return c.String()
+}
+
 func queryByID200(sd apitest.SeedData) []apitest.Table {
 	table := []apitest.Table{
 		{
@@ -94,3 +245,37 @@ func queryByID200(sd apitest.SeedData) []apitest.Table {
 
 	return table
 }
+
+func queryByID401(sd apitest.SeedData) []apitest.Table {
+	table := []apitest.Table{
+		{
+			Name:       "wronguser",
+			URL:        fmt.Sprintf("/v1/products/%s", sd.Admins[0].Products[0].ID),
+			Token:      sd.Users[0].Token,
+			StatusCode: http.StatusUnauthorized,
+			Method:     http.MethodGet,
+			GotResp:    &errs.Error{},
+			ExpResp:    errs.Newf(errs.Unauthenticated, "authorize: you are not authorized for that action, claims[[user]] permission[product:read]: rego evaluation failed : bindings results[[{[true] map[x:false]}]] ok[true]"),
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+func queryByID304(sd apitest.SeedData) []apitest.Table {
+	table := []apitest.Table{
+		{
+			Name:       "basic",
+			URL:        fmt.Sprintf("/v1/products/%s", sd.Users[0].Products[0].ID),
+			Token:      sd.Users[0].Token,
+			Headers:    map[string]string{"If-None-Match": web.ETag(sd.Users[0].Products[0].Version)},
+			StatusCode: http.StatusNotModified,
+			Method:     http.MethodGet,
+		},
+	}
+
+	return table
+}
@@ -9,7 +9,9 @@ import (
 	"github.com/rmsj/service/app/domain/productapp"
 	"github.com/rmsj/service/app/sdk/apitest"
 	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/business/domain/productbus"
 	"github.com/rmsj/service/business/sdk/dbtest"
+	"github.com/rmsj/service/foundation/web"
 )
 
 func update200(sd apitest.SeedData) []apitest.Table {
@@ -22,7 +24,7 @@ func update200(sd apitest.SeedData) []apitest.Table {
 			StatusCode: http.StatusOK,
 			Input: &productapp.UpdateProduct{
 				Name:     dbtest.StringPointer("Guitar"),
-				Cost:     dbtest.FloatPointer(10.34),
+				Cost:     dbtest.StringPointer("10.34"),
 				Quantity: dbtest.IntPointer(10),
 			},
 			GotResp: &productapp.Product{},
@@ -30,7 +32,8 @@ func update200(sd apitest.SeedData) []apitest.Table {
 				ID:          sd.Users[0].Products[0].ID.String(),
 				UserID:      sd.Users[0].ID.String(),
 				Name:        "Guitar",
-				Cost:        10.34,
+				Cost:        "10.34",
+				Currency:    "USD",
 				Quantity:    10,
 				DateCreated: sd.Users[0].Products[0].DateCreated.Format(time.RFC3339),
 				DateUpdated: sd.Users[0].Products[0].DateCreated.Format(time.RFC3339),
@@ -61,11 +64,11 @@ func update400(sd apitest.SeedData) []apitest.Table {
 			Method:     http.MethodPut,
 			StatusCode: http.StatusBadRequest,
 			Input: &productapp.UpdateProduct{
-				Cost:     dbtest.FloatPointer(-1.0),
+				Cost:     dbtest.StringPointer("abc"),
 				Quantity: dbtest.IntPointer(0),
 			},
 			GotResp: &errs.Error{},
-			ExpResp: errs.Newf(errs.InvalidArgument, "validate: [{\"field\":\"cost\",\"error\":\"cost must be 0 or greater\"},{\"field\":\"quantity\",\"error\":\"quantity must be 1 or greater\"}]"),
+			ExpResp: errs.Newf(errs.InvalidArgument, "validate: [{\"field\":\"cost\",\"error\":\"cost must be a valid numeric value\"},{\"field\":\"quantity\",\"error\":\"quantity must be 1 or greater\"}]"),
 			CmpFunc: func(got any, exp any) string {
 				return cmp.Diff(got, exp)
 			},
@@ -109,11 +112,34 @@ func update401(sd apitest.SeedData) []apitest.Table {
 			StatusCode: http.StatusUnauthorized,
 			Input: &productapp.UpdateProduct{
 				Name:     dbtest.StringPointer("Guitar"),
-				Cost:     dbtest.FloatPointer(10.34),
+				Cost:     dbtest.StringPointer("10.34"),
 				Quantity: dbtest.IntPointer(10),
 			},
 			GotResp: &errs.Error{},
-			ExpResp: errs.Newf(errs.Unauthenticated, "authorize: you are not authorized for that action, claims[[user]] rule[rule_admin_or_subject]: rego evaluation failed : bindings results[[{[true] map[x:false]}]] ok[true]"),
+			ExpResp: errs.Newf(errs.Unauthenticated, "authorize: you are not authorized for that action, claims[[user]] permission[product:write]: rego evaluation failed : bindings results[[{[true] map[x:false]}]] ok[true]"),
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+func update412(sd apitest.SeedData) []apitest.Table {
+	table := []apitest.Table{
+		{
+			Name:       "stale",
+			URL:        fmt.Sprintf("/v1/products/%s", sd.Users[0].Products[1].ID),
+			Token:      sd.Users[0].Token,
+			Method:     http.MethodPut,
+			Headers:    map[string]string{"If-Match": web.ETag(sd.Users[0].Products[1].Version + 1)},
+			StatusCode: http.StatusPreconditionFailed,
+			Input: &productapp.UpdateProduct{
+				Name: dbtest.StringPointer("Guitar"),
+			},
+			GotResp: &errs.Error{},
+			ExpResp: errs.New(errs.PreconditionFailed, productbus.ErrConflict),
 			CmpFunc: func(got any, exp any) string {
 				return cmp.Diff(got, exp)
 			},
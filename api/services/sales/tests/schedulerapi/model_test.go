@@ -0,0 +1,30 @@
+package scheduler_test
+
+import (
+	"time"
+
+	"github.com/rmsj/service/app/domain/schedulerapp"
+	"github.com/rmsj/service/business/sdk/scheduler"
+)
+
+func toAppRun(run scheduler.Run) schedulerapp.Run {
+	return schedulerapp.Run{
+		ID:          run.ID.String(),
+		Task:        run.Task,
+		Owner:       run.Owner,
+		Status:      run.Status,
+		Error:       run.Error,
+		ScheduledAt: run.ScheduledAt.Format(time.RFC3339),
+		StartedAt:   run.StartedAt.Format(time.RFC3339),
+		FinishedAt:  run.FinishedAt.Format(time.RFC3339),
+	}
+}
+
+func toAppRuns(runs []scheduler.Run) []schedulerapp.Run {
+	items := make([]schedulerapp.Run, len(runs))
+	for i, run := range runs {
+		items[i] = toAppRun(run)
+	}
+
+	return items
+}
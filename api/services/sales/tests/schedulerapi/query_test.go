@@ -0,0 +1,96 @@
+package scheduler_test
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/rmsj/service/app/domain/schedulerapp"
+	"github.com/rmsj/service/app/sdk/apitest"
+	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/app/sdk/query"
+	"github.com/rmsj/service/business/sdk/dbtest"
+)
+
+func queryRuns200(sd apitest.SeedData) []apitest.Table {
+	runs := slices.Clone(sd.Runs)
+	slices.Reverse(runs)
+
+	table := []apitest.Table{
+		{
+			Name:       "basic",
+			URL:        "/v1/scheduler/runs?page=1&rows=10&task=test-task",
+			Token:      sd.Admins[0].Token,
+			StatusCode: http.StatusOK,
+			Method:     http.MethodGet,
+			GotResp:    &query.Result[schedulerapp.Run]{},
+			ExpResp: &query.Result[schedulerapp.Run]{
+				Page:        1,
+				RowsPerPage: 10,
+				Total:       dbtest.IntPointer(len(runs)),
+				Items:       toAppRuns(runs),
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:       "status",
+			URL:        "/v1/scheduler/runs?page=1&rows=10&status=failed",
+			Token:      sd.Admins[0].Token,
+			StatusCode: http.StatusOK,
+			Method:     http.MethodGet,
+			GotResp:    &query.Result[schedulerapp.Run]{},
+			ExpResp: &query.Result[schedulerapp.Run]{
+				Page:        1,
+				RowsPerPage: 10,
+				Total:       dbtest.IntPointer(0),
+				Items:       []schedulerapp.Run{},
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+func queryRuns401(sd apitest.SeedData) []apitest.Table {
+	table := []apitest.Table{
+		{
+			Name:       "wronguser",
+			URL:        "/v1/scheduler/runs",
+			Token:      sd.Users[0].Token,
+			Method:     http.MethodGet,
+			StatusCode: http.StatusUnauthorized,
+			GotResp:    &errs.Error{},
+			ExpResp:    errs.Newf(errs.Unauthenticated, "authorize: you are not authorized for that action, claims[[user]] rule[rule_admin_only]: rego evaluation failed : bindings results[[{[true] map[x:false]}]] ok[true]"),
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+func queryTasks200(sd apitest.SeedData) []apitest.Table {
+	table := []apitest.Table{
+		{
+			Name:       "basic",
+			URL:        "/v1/scheduler/tasks",
+			Token:      sd.Admins[0].Token,
+			StatusCode: http.StatusOK,
+			Method:     http.MethodGet,
+			GotResp:    &schedulerapp.Tasks{},
+			ExpResp:    &schedulerapp.Tasks{},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
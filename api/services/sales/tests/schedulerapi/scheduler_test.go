@@ -0,0 +1,26 @@
+package scheduler_test
+
+import (
+	"testing"
+
+	"github.com/rmsj/service/app/sdk/apitest"
+)
+
+func Test_Scheduler(t *testing.T) {
+	t.Parallel()
+
+	test := apitest.New(t, "Test_Scheduler")
+
+	// -------------------------------------------------------------------------
+
+	sd, err := insertSeedData(test.DB, test.Auth)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	test.Run(t, queryRuns200(sd), "queryruns-200")
+	test.Run(t, queryRuns401(sd), "queryruns-401")
+	test.Run(t, queryTasks200(sd), "querytasks-200")
+}
@@ -20,7 +20,7 @@ func create200(sd apitest.SeedData) []apitest.Table {
 			Input: &tranapp.NewTran{
 				Product: tranapp.NewProduct{
 					Name:     "Guitar",
-					Cost:     10.34,
+					Cost:     "10.34",
 					Quantity: 10,
 				},
 				User: tranapp.NewUser{
@@ -35,7 +35,8 @@ func create200(sd apitest.SeedData) []apitest.Table {
 			GotResp: &tranapp.Product{},
 			ExpResp: &tranapp.Product{
 				Name:     "Guitar",
-				Cost:     10.34,
+				Cost:     "10.34",
+				Currency: "USD",
 				Quantity: 10,
 			},
 			CmpFunc: func(got any, exp any) string {
@@ -83,7 +84,7 @@ func create400(sd apitest.SeedData) []apitest.Table {
 			Input: &tranapp.NewTran{
 				Product: tranapp.NewProduct{
 					Name:     "Gu",
-					Cost:     10.34,
+					Cost:     "10.34",
 					Quantity: 10,
 				},
 				User: tranapp.NewUser{
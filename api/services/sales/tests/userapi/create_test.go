@@ -27,11 +27,12 @@ func create200(sd apitest.SeedData) []apitest.Table {
 			},
 			GotResp: &userapp.User{},
 			ExpResp: &userapp.User{
-				Name:       "Bill Kennedy",
-				Email:      "bill@ardanlabs.com",
-				Roles:      []string{"admin"},
-				Department: "ITO",
-				Enabled:    true,
+				Name:          "Bill Kennedy",
+				Email:         "bill@ardanlabs.com",
+				Roles:         []string{"admin"},
+				Department:    "ITO",
+				Enabled:       true,
+				EmailVerified: true,
 			},
 			CmpFunc: func(got any, exp any) string {
 				gotResp, exists := got.(*userapp.User)
@@ -158,7 +159,7 @@ func create401(sd apitest.SeedData) []apitest.Table {
 			Method:     http.MethodPost,
 			StatusCode: http.StatusUnauthorized,
 			GotResp:    &errs.Error{},
-			ExpResp:    errs.Newf(errs.Unauthenticated, "authorize: you are not authorized for that action, claims[[user]] rule[rule_admin_only]: rego evaluation failed : bindings results[[{[true] map[x:false]}]] ok[true]"),
+			ExpResp:    errs.Newf(errs.Unauthenticated, "authorize: you are not authorized for that action, claims[[user]] permission[user:create]: rego evaluation failed : bindings results[[{[true] map[x:false]}]] ok[true]"),
 			CmpFunc: func(got any, exp any) string {
 				return cmp.Diff(got, exp)
 			},
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"strings"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/rmsj/service/app/domain/userapp"
@@ -11,6 +12,10 @@ import (
 	"github.com/rmsj/service/app/sdk/errs"
 	"github.com/rmsj/service/app/sdk/query"
 	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/sdk/dbtest"
+	"github.com/rmsj/service/business/sdk/order"
+	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/business/types/role"
 )
 
 func query200(sd apitest.SeedData) []apitest.Table {
@@ -39,7 +44,7 @@ func query200(sd apitest.SeedData) []apitest.Table {
 			ExpResp: &query.Result[userapp.User]{
 				Page:        1,
 				RowsPerPage: 10,
-				Total:       len(usrs),
+				Total:       dbtest.IntPointer(len(usrs)),
 				Items:       toAppUsers(usrs),
 			},
 			CmpFunc: func(got any, exp any) string {
@@ -51,6 +56,61 @@ func query200(sd apitest.SeedData) []apitest.Table {
 	return table
 }
 
+func queryCursor200(sd apitest.SeedData) []apitest.Table {
+	usrs := make([]userbus.User, 0, len(sd.Users))
+	for _, usr := range sd.Users {
+		usrs = append(usrs, usr.User)
+	}
+
+	adms := make([]userbus.User, 0, len(sd.Admins))
+	for _, adm := range sd.Admins {
+		adms = append(adms, adm.User)
+	}
+
+	sort.Slice(usrs, func(i, j int) bool {
+		return usrs[i].ID.String() <= usrs[j].ID.String()
+	})
+
+	sort.Slice(adms, func(i, j int) bool {
+		return adms[i].ID.String() <= adms[j].ID.String()
+	})
+
+	// The roles sort by the order they are declared in, where the users come
+	// before the admins, while their names sort the other way around.
+	table := []apitest.Table{
+		{
+			Name:       "roles",
+			URL:        "/v1/users?rows=10&orderBy=roles,ASC&name=Name&cursor=" + rolesCursor(usrs[len(usrs)-1], false),
+			Token:      sd.Admins[0].Token,
+			StatusCode: http.StatusOK,
+			Method:     http.MethodGet,
+			GotResp:    &query.Result[userapp.User]{},
+			ExpResp: &query.Result[userapp.User]{
+				RowsPerPage: 10,
+				Items:       toAppUsers(adms),
+				Prev:        rolesCursor(adms[0], true),
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+// rolesCursor returns the cursor of the user in the results ordered by role.
+func rolesCursor(usr userbus.User, backward bool) string {
+	c := page.Cursor{
+		OrderBy:  order.NewBy(userbus.OrderByRoles, order.ASC),
+		Value:    strings.Join(role.ParseToString(usr.Roles), ","),
+		ID:       usr.ID.String(),
+		Backward: backward,
+	}
+
+	return c.String()
+}
+
 func query400(sd apitest.SeedData) []apitest.Table {
 	table := []apitest.Table{
 		{
@@ -29,14 +29,15 @@ func update200(sd apitest.SeedData) []apitest.Table {
 			},
 			GotResp: &userapp.User{},
 			ExpResp: &userapp.User{
-				ID:          sd.Users[0].ID.String(),
-				Name:        "Jack Kennedy",
-				Email:       "jack@ardanlabs.com",
-				Roles:       []string{"user"},
-				Department:  "ITO",
-				Enabled:     true,
-				DateCreated: sd.Users[0].DateCreated.Format(time.RFC3339),
-				DateUpdated: sd.Users[0].DateUpdated.Format(time.RFC3339),
+				ID:            sd.Users[0].ID.String(),
+				Name:          "Jack Kennedy",
+				Email:         "jack@ardanlabs.com",
+				Roles:         []string{"user"},
+				Department:    "ITO",
+				Enabled:       true,
+				EmailVerified: false,
+				DateCreated:   sd.Users[0].DateCreated.Format(time.RFC3339),
+				DateUpdated:   sd.Users[0].DateUpdated.Format(time.RFC3339),
 			},
 			CmpFunc: func(got any, exp any) string {
 				gotResp, exists := got.(*userapp.User)
@@ -61,14 +62,15 @@ func update200(sd apitest.SeedData) []apitest.Table {
 			},
 			GotResp: &userapp.User{},
 			ExpResp: &userapp.User{
-				ID:          sd.Admins[0].ID.String(),
-				Name:        sd.Admins[0].Name.String(),
-				Email:       sd.Admins[0].Email.Address,
-				Roles:       []string{"user"},
-				Department:  sd.Admins[0].Department.String(),
-				Enabled:     true,
-				DateCreated: sd.Admins[0].DateCreated.Format(time.RFC3339),
-				DateUpdated: sd.Admins[0].DateUpdated.Format(time.RFC3339),
+				ID:            sd.Admins[0].ID.String(),
+				Name:          sd.Admins[0].Name.String(),
+				Email:         sd.Admins[0].Email.Address,
+				Roles:         []string{"user"},
+				Department:    sd.Admins[0].Department.String(),
+				Enabled:       true,
+				EmailVerified: true,
+				DateCreated:   sd.Admins[0].DateCreated.Format(time.RFC3339),
+				DateUpdated:   sd.Admins[0].DateUpdated.Format(time.RFC3339),
 			},
 			CmpFunc: func(got any, exp any) string {
 				gotResp, exists := got.(*userapp.User)
@@ -165,7 +167,22 @@ func update401(sd apitest.SeedData) []apitest.Table {
 				PasswordConfirm: dbtest.StringPointer("123"),
 			},
 			GotResp: &errs.Error{},
-			ExpResp: errs.Newf(errs.Unauthenticated, "authorize: you are not authorized for that action, claims[[user]] rule[rule_admin_or_subject]: rego evaluation failed : bindings results[[{[true] map[x:false]}]] ok[true]"),
+			ExpResp: errs.Newf(errs.Unauthenticated, "authorize: you are not authorized for that action, claims[[user]] permission[user:write]: rego evaluation failed : bindings results[[{[true] map[x:false]}]] ok[true]"),
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:       "emailverifiedadminonly",
+			URL:        fmt.Sprintf("/v1/users/%s", sd.Users[0].ID),
+			Token:      sd.Users[0].Token,
+			Method:     http.MethodPut,
+			StatusCode: http.StatusUnauthorized,
+			Input: &userapp.UpdateUser{
+				EmailVerified: dbtest.BoolPointer(true),
+			},
+			GotResp: &errs.Error{},
+			ExpResp: errs.Newf(errs.Unauthenticated, "only admins can set if the email is verified"),
 			CmpFunc: func(got any, exp any) string {
 				return cmp.Diff(got, exp)
 			},
@@ -180,7 +197,7 @@ func update401(sd apitest.SeedData) []apitest.Table {
 				Roles: []string{"ADMIN"},
 			},
 			GotResp: &errs.Error{},
-			ExpResp: errs.Newf(errs.Unauthenticated, "authorize: you are not authorized for that action, claims[[user]] rule[rule_admin_only]: rego evaluation failed : bindings results[[{[true] map[x:false]}]] ok[true]"),
+			ExpResp: errs.Newf(errs.Unauthenticated, "authorize: you are not authorized for that action, claims[[user]] permission[user:role]: rego evaluation failed : bindings results[[{[true] map[x:false]}]] ok[true]"),
 			CmpFunc: func(got any, exp any) string {
 				return cmp.Diff(got, exp)
 			},
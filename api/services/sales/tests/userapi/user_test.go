@@ -21,6 +21,7 @@ func Test_User(t *testing.T) {
 	// -------------------------------------------------------------------------
 
 	test.Run(t, query200(sd), "query-200")
+	test.Run(t, queryCursor200(sd), "query-cursor-200")
 	test.Run(t, query400(sd), "query-400")
 	test.Run(t, queryByID200(sd), "querybyid-200")
 
@@ -13,7 +13,8 @@ func toAppVProduct(usr userbus.User, prd productbus.Product) vproductapp.Product
 		ID:          prd.ID.String(),
 		UserID:      prd.UserID.String(),
 		Name:        prd.Name.String(),
-		Cost:        prd.Cost.Value(),
+		Cost:        prd.Cost.String(),
+		Currency:    prd.Cost.Currency().Code(),
 		Quantity:    prd.Quantity.Value(),
 		DateCreated: prd.DateCreated.Format(time.RFC3339),
 		DateUpdated: prd.DateUpdated.Format(time.RFC3339),
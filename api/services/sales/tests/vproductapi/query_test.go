@@ -9,6 +9,7 @@ import (
 	"github.com/rmsj/service/app/sdk/apitest"
 	"github.com/rmsj/service/app/sdk/errs"
 	"github.com/rmsj/service/app/sdk/query"
+	"github.com/rmsj/service/business/sdk/dbtest"
 )
 
 func query200(sd apitest.SeedData) []apitest.Table {
@@ -30,7 +31,7 @@ func query200(sd apitest.SeedData) []apitest.Table {
 			ExpResp: &query.Result[vproductapp.Product]{
 				Page:        1,
 				RowsPerPage: 10,
-				Total:       len(prds),
+				Total:       dbtest.IntPointer(len(prds)),
 				Items:       prds,
 			},
 			CmpFunc: func(got any, exp any) string {
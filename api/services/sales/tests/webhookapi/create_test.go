@@ -0,0 +1,118 @@
+package webhook_test
+
+import (
+	"net/http"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rmsj/service/app/domain/webhookapp"
+	"github.com/rmsj/service/app/sdk/apitest"
+	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/business/domain/webhookbus"
+)
+
+func create200(sd apitest.SeedData) []apitest.Table {
+	table := []apitest.Table{
+		{
+			Name:       "basic",
+			URL:        "/v1/webhooks",
+			Token:      sd.Admins[0].Token,
+			Method:     http.MethodPost,
+			StatusCode: http.StatusOK,
+			Input: &webhookapp.NewSubscription{
+				URL:    "https://partner.example.com/products",
+				Events: []string{webhookbus.EventProductUpdated, webhookbus.EventProductDeleted},
+				Secret: "0123456789abcdef0123456789abcdef",
+			},
+			GotResp: &webhookapp.Subscription{},
+			ExpResp: &webhookapp.Subscription{
+				URL:     "https://partner.example.com/products",
+				Events:  []string{webhookbus.EventProductUpdated, webhookbus.EventProductDeleted},
+				Enabled: true,
+			},
+			CmpFunc: func(got any, exp any) string {
+				gotResp, exists := got.(*webhookapp.Subscription)
+				if !exists {
+					return "error occurred"
+				}
+
+				expResp := exp.(*webhookapp.Subscription)
+
+				expResp.ID = gotResp.ID
+				expResp.DateCreated = gotResp.DateCreated
+				expResp.DateUpdated = gotResp.DateUpdated
+
+				return cmp.Diff(gotResp, expResp)
+			},
+		},
+	}
+
+	return table
+}
+
+func create400(sd apitest.SeedData) []apitest.Table {
+	table := []apitest.Table{
+		{
+			Name:       "missing-input",
+			URL:        "/v1/webhooks",
+			Token:      sd.Admins[0].Token,
+			Method:     http.MethodPost,
+			StatusCode: http.StatusBadRequest,
+			Input:      &webhookapp.NewSubscription{},
+			GotResp:    &errs.Error{},
+			ExpResp:    errs.Newf(errs.InvalidArgument, "validate: [{\"field\":\"url\",\"error\":\"url is a required field\"},{\"field\":\"events\",\"error\":\"events is a required field\"},{\"field\":\"secret\",\"error\":\"secret is a required field\"}]"),
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:       "unknown-event",
+			URL:        "/v1/webhooks",
+			Token:      sd.Admins[0].Token,
+			Method:     http.MethodPost,
+			StatusCode: http.StatusBadRequest,
+			Input: &webhookapp.NewSubscription{
+				URL:    "https://partner.example.com/orders",
+				Events: []string{"order.created"},
+				Secret: "0123456789abcdef0123456789abcdef",
+			},
+			GotResp: &errs.Error{},
+			ExpResp: errs.Newf(errs.InvalidArgument, "\"order.created\": unknown event"),
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+func create401(sd apitest.SeedData) []apitest.Table {
+	table := []apitest.Table{
+		{
+			Name:       "emptytoken",
+			URL:        "/v1/webhooks",
+			Token:      "&nbsp;",
+			Method:     http.MethodPost,
+			StatusCode: http.StatusUnauthorized,
+			GotResp:    &errs.Error{},
+			ExpResp:    errs.Newf(errs.Unauthenticated, "error parsing token: token contains an invalid number of segments"),
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:       "wronguser",
+			URL:        "/v1/webhooks",
+			Token:      sd.Users[0].Token,
+			Method:     http.MethodPost,
+			StatusCode: http.StatusUnauthorized,
+			GotResp:    &errs.Error{},
+			ExpResp:    errs.Newf(errs.Unauthenticated, "authorize: you are not authorized for that action, claims[[user]] rule[rule_admin_only]: rego evaluation failed : bindings results[[{[true] map[x:false]}]] ok[true]"),
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
@@ -0,0 +1,33 @@
+package webhook_test
+
+import (
+	"time"
+
+	"github.com/rmsj/service/app/domain/webhookapp"
+	"github.com/rmsj/service/business/domain/webhookbus"
+)
+
+func toAppSubscription(sub webhookbus.Subscription) webhookapp.Subscription {
+	return webhookapp.Subscription{
+		ID:          sub.ID.String(),
+		URL:         sub.URL,
+		Events:      sub.Events,
+		Enabled:     sub.Enabled,
+		DateCreated: sub.DateCreated.Format(time.RFC3339),
+		DateUpdated: sub.DateUpdated.Format(time.RFC3339),
+	}
+}
+
+func toAppSubscriptions(subs []webhookbus.Subscription) []webhookapp.Subscription {
+	items := make([]webhookapp.Subscription, len(subs))
+	for i, sub := range subs {
+		items[i] = toAppSubscription(sub)
+	}
+
+	return items
+}
+
+func toAppSubscriptionPtr(sub webhookbus.Subscription) *webhookapp.Subscription {
+	appSub := toAppSubscription(sub)
+	return &appSub
+}
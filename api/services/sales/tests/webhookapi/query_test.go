@@ -0,0 +1,132 @@
+package webhook_test
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/rmsj/service/app/domain/webhookapp"
+	"github.com/rmsj/service/app/sdk/apitest"
+	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/app/sdk/query"
+	"github.com/rmsj/service/business/domain/webhookbus"
+	"github.com/rmsj/service/business/sdk/dbtest"
+)
+
+func query200(sd apitest.SeedData) []apitest.Table {
+	subs := make([]webhookbus.Subscription, 0, len(sd.Subscriptions))
+	subs = append(subs, sd.Subscriptions...)
+
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].ID.String() <= subs[j].ID.String()
+	})
+
+	table := []apitest.Table{
+		{
+			Name:       "basic",
+			URL:        "/v1/webhooks?page=1&rows=10&orderBy=subscription_id,ASC",
+			Token:      sd.Admins[0].Token,
+			StatusCode: http.StatusOK,
+			Method:     http.MethodGet,
+			GotResp:    &query.Result[webhookapp.Subscription]{},
+			ExpResp: &query.Result[webhookapp.Subscription]{
+				Page:        1,
+				RowsPerPage: 10,
+				Total:       dbtest.IntPointer(len(subs)),
+				Items:       toAppSubscriptions(subs),
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+func queryByID200(sd apitest.SeedData) []apitest.Table {
+	table := []apitest.Table{
+		{
+			Name:       "basic",
+			URL:        fmt.Sprintf("/v1/webhooks/%s", sd.Subscriptions[0].ID),
+			Token:      sd.Admins[0].Token,
+			StatusCode: http.StatusOK,
+			Method:     http.MethodGet,
+			GotResp:    &webhookapp.Subscription{},
+			ExpResp:    toAppSubscriptionPtr(sd.Subscriptions[0]),
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+func queryByID404(sd apitest.SeedData) []apitest.Table {
+	id := uuid.New()
+
+	table := []apitest.Table{
+		{
+			Name:       "unknown",
+			URL:        fmt.Sprintf("/v1/webhooks/%s", id),
+			Token:      sd.Admins[0].Token,
+			StatusCode: http.StatusNotFound,
+			Method:     http.MethodGet,
+			GotResp:    &errs.Error{},
+			ExpResp:    errs.Newf(errs.NotFound, "query: subscriptionID[%s]: db: subscription not found", id),
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+func queryDeliveries200(sd apitest.SeedData) []apitest.Table {
+	table := []apitest.Table{
+		{
+			Name:       "basic",
+			URL:        fmt.Sprintf("/v1/webhooks/%s/deliveries?page=1&rows=10", sd.Subscriptions[0].ID),
+			Token:      sd.Admins[0].Token,
+			StatusCode: http.StatusOK,
+			Method:     http.MethodGet,
+			GotResp:    &query.Result[webhookapp.Delivery]{},
+			ExpResp: &query.Result[webhookapp.Delivery]{
+				Page:        1,
+				RowsPerPage: 10,
+				Total:       dbtest.IntPointer(1),
+				Items: []webhookapp.Delivery{
+					{
+						Event:  webhookbus.EventProductCreated,
+						Status: webhookbus.DeliveryPending,
+					},
+				},
+			},
+			CmpFunc: func(got any, exp any) string {
+				gotResp, exists := got.(*query.Result[webhookapp.Delivery])
+				if !exists {
+					return "error occurred"
+				}
+
+				expResp := exp.(*query.Result[webhookapp.Delivery])
+
+				for i := range gotResp.Items {
+					if i < len(expResp.Items) {
+						gotResp.Items[i] = webhookapp.Delivery{
+							Event:  gotResp.Items[i].Event,
+							Status: gotResp.Items[i].Status,
+						}
+					}
+				}
+
+				return cmp.Diff(gotResp, expResp)
+			},
+		},
+	}
+
+	return table
+}
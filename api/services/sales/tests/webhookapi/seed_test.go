@@ -0,0 +1,65 @@
+package webhook_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rmsj/service/app/sdk/apitest"
+	"github.com/rmsj/service/app/sdk/auth"
+	"github.com/rmsj/service/business/domain/productbus"
+	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/domain/webhookbus"
+	"github.com/rmsj/service/business/sdk/dbtest"
+	"github.com/rmsj/service/business/types/role"
+)
+
+func insertSeedData(db *dbtest.Database, ath *auth.Auth) (apitest.SeedData, error) {
+	ctx := context.Background()
+	busDomain := db.BusDomain
+
+	subs, err := webhookbus.TestSeedSubscriptions(ctx, 2, "http://partner.example.com/hooks", []string{webhookbus.EventProductCreated}, busDomain.Webhook)
+	if err != nil {
+		return apitest.SeedData{}, fmt.Errorf("seeding subscriptions : %w", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	usrs, err := userbus.TestSeedUsers(ctx, 1, role.User, busDomain.User)
+	if err != nil {
+		return apitest.SeedData{}, fmt.Errorf("seeding users : %w", err)
+	}
+
+	// Creating the product queues a delivery for each subscription.
+	prds, err := productbus.TestGenerateSeedProducts(ctx, 1, busDomain.Product, usrs[0].ID)
+	if err != nil {
+		return apitest.SeedData{}, fmt.Errorf("seeding products : %w", err)
+	}
+
+	tu1 := apitest.User{
+		User:     usrs[0],
+		Products: prds,
+		Token:    apitest.Token(db.BusDomain.User, ath, usrs[0].Email.Address),
+	}
+
+	// -------------------------------------------------------------------------
+
+	usrs, err = userbus.TestSeedUsers(ctx, 1, role.Admin, busDomain.User)
+	if err != nil {
+		return apitest.SeedData{}, fmt.Errorf("seeding users : %w", err)
+	}
+
+	tu2 := apitest.User{
+		User:  usrs[0],
+		Token: apitest.Token(db.BusDomain.User, ath, usrs[0].Email.Address),
+	}
+
+	// -------------------------------------------------------------------------
+
+	sd := apitest.SeedData{
+		Admins:        []apitest.User{tu2},
+		Users:         []apitest.User{tu1},
+		Subscriptions: subs,
+	}
+
+	return sd, nil
+}
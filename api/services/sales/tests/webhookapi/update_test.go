@@ -0,0 +1,50 @@
+package webhook_test
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rmsj/service/app/domain/webhookapp"
+	"github.com/rmsj/service/app/sdk/apitest"
+	"github.com/rmsj/service/business/sdk/dbtest"
+)
+
+func update200(sd apitest.SeedData) []apitest.Table {
+	sub := sd.Subscriptions[1]
+
+	table := []apitest.Table{
+		{
+			Name:       "disable",
+			URL:        fmt.Sprintf("/v1/webhooks/%s", sub.ID),
+			Token:      sd.Admins[0].Token,
+			Method:     http.MethodPut,
+			StatusCode: http.StatusOK,
+			Input: &webhookapp.UpdateSubscription{
+				Enabled: dbtest.BoolPointer(false),
+			},
+			GotResp: &webhookapp.Subscription{},
+			ExpResp: &webhookapp.Subscription{
+				ID:          sub.ID.String(),
+				URL:         sub.URL,
+				Events:      sub.Events,
+				Enabled:     false,
+				DateCreated: toAppSubscription(sub).DateCreated,
+			},
+			CmpFunc: func(got any, exp any) string {
+				gotResp, exists := got.(*webhookapp.Subscription)
+				if !exists {
+					return "error occurred"
+				}
+
+				expResp := exp.(*webhookapp.Subscription)
+
+				expResp.DateUpdated = gotResp.DateUpdated
+
+				return cmp.Diff(gotResp, expResp)
+			},
+		},
+	}
+
+	return table
+}
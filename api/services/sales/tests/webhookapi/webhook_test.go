@@ -0,0 +1,34 @@
+package webhook_test
+
+import (
+	"testing"
+
+	"github.com/rmsj/service/app/sdk/apitest"
+)
+
+func Test_Webhook(t *testing.T) {
+	t.Parallel()
+
+	test := apitest.New(t, "Test_Webhook")
+
+	// -------------------------------------------------------------------------
+
+	sd, err := insertSeedData(test.DB, test.Auth)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	test.Run(t, query200(sd), "query-200")
+	test.Run(t, queryByID200(sd), "querybyid-200")
+	test.Run(t, queryByID404(sd), "querybyid-404")
+	test.Run(t, queryDeliveries200(sd), "querydeliveries-200")
+
+	test.Run(t, create200(sd), "create-200")
+	test.Run(t, create400(sd), "create-400")
+	test.Run(t, create401(sd), "create-401")
+
+	test.Run(t, update200(sd), "update-200")
+	test.Run(t, delete200(sd), "delete-200")
+}
@@ -1,19 +1,42 @@
 package commands
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
+	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/foundation/keystore"
 )
 
-// GenKey creates an x509 private/public key for auth tokens.
-func GenKey() error {
+// Set of key types that can be generated.
+const (
+	KeyTypeRSA     = "rsa"
+	KeyTypeECDSA   = "ecdsa"
+	KeyTypeEd25519 = "ed25519"
+)
+
+// GenKey creates an x509 private/public key for auth tokens. The key type
+// decides the algorithm the tokens are signed with: RS256 for rsa, ES256 for
+// ecdsa and EdDSA for ed25519.
+func GenKey(keyType string) error {
 
 	// Generate a new private key.
-	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	privateKey, err := generateKey(keyType)
 	if err != nil {
 		return fmt.Errorf("generating key: %w", err)
 	}
@@ -26,9 +49,9 @@ func GenKey() error {
 	defer privateFile.Close()
 
 	// Construct a PEM block for the private key.
-	privateBlock := pem.Block{
-		Type:  "PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	privateBlock, err := privateKeyBlock(privateKey)
+	if err != nil {
+		return fmt.Errorf("marshaling private key: %w", err)
 	}
 
 	// Write the private key to the private key file.
@@ -44,7 +67,7 @@ func GenKey() error {
 	defer publicFile.Close()
 
 	// Marshal the public key from the private key to PKIX.
-	asn1Bytes, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	asn1Bytes, err := x509.MarshalPKIXPublicKey(privateKey.Public())
 	if err != nil {
 		return fmt.Errorf("marshaling public key: %w", err)
 	}
@@ -63,3 +86,160 @@ func GenKey() error {
 	fmt.Println("private and public key files generated")
 	return nil
 }
+
+// GenKeyRotate creates a new private key in the keys folder, along with the
+// metadata that makes it the active key once activateIn has passed. The keys
+// in the folder that would still be signing by then are set to retire at that
+// moment, after which they keep verifying tokens for the grace period of the
+// auth service. The new key is published right away, so services verifying
+// tokens with the JWKS pick it up before it's used.
+func GenKeyRotate(keyPath string, activateIn time.Duration, keyType string) error {
+	if activateIn < 0 {
+		return errors.New("activation delay can't be negative")
+	}
+
+	notBefore := time.Now().UTC().Add(activateIn).Truncate(time.Second)
+
+	entries, err := os.ReadDir(keyPath)
+	if err != nil {
+		return fmt.Errorf("reading keys folder: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+			continue
+		}
+
+		kid := strings.TrimSuffix(entry.Name(), ".pem")
+		metaFile := filepath.Join(keyPath, kid+".json")
+
+		md, err := readKeyMetadata(metaFile)
+		if err != nil {
+			return fmt.Errorf("reading metadata of %s: %w", kid, err)
+		}
+
+		if md.Status != "" && md.Status != keystore.StatusActive {
+			continue
+		}
+
+		if !md.RetireAfter.IsZero() && !md.RetireAfter.After(notBefore) {
+			continue
+		}
+
+		md.Status = keystore.StatusActive
+		md.RetireAfter = notBefore
+		if !md.NotBefore.IsZero() && !md.NotBefore.Before(notBefore) {
+			md.Status = keystore.StatusRetired
+			md.RetireAfter = time.Time{}
+		}
+
+		if err := writeKeyMetadata(metaFile, md); err != nil {
+			return fmt.Errorf("writing metadata of %s: %w", kid, err)
+		}
+
+		fmt.Printf("key %s retires at %s\n", kid, notBefore.Format(time.RFC3339))
+	}
+
+	privateKey, err := generateKey(keyType)
+	if err != nil {
+		return fmt.Errorf("generating key: %w", err)
+	}
+
+	privateBlock, err := privateKeyBlock(privateKey)
+	if err != nil {
+		return fmt.Errorf("marshaling private key: %w", err)
+	}
+
+	kid := uuid.NewString()
+
+	privateFile, err := os.OpenFile(filepath.Join(keyPath, kid+".pem"), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("creating private file: %w", err)
+	}
+	defer privateFile.Close()
+
+	if err := pem.Encode(privateFile, &privateBlock); err != nil {
+		return fmt.Errorf("encoding to private file: %w", err)
+	}
+
+	md := keystore.Metadata{
+		NotBefore: notBefore,
+		Status:    keystore.StatusActive,
+	}
+
+	if err := writeKeyMetadata(filepath.Join(keyPath, kid+".json"), md); err != nil {
+		return fmt.Errorf("writing metadata of %s: %w", kid, err)
+	}
+
+	fmt.Printf("key %s generated, signs from %s\n", kid, notBefore.Format(time.RFC3339))
+	return nil
+}
+
+func generateKey(keyType string) (crypto.Signer, error) {
+	switch keyType {
+	case "", KeyTypeRSA:
+		return rsa.GenerateKey(rand.Reader, 2048)
+
+	case KeyTypeECDSA:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	case KeyTypeEd25519:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		return privateKey, err
+	}
+
+	return nil, fmt.Errorf("unknown key type %q, use %s, %s or %s", keyType, KeyTypeRSA, KeyTypeECDSA, KeyTypeEd25519)
+}
+
+// privateKeyBlock constructs the PEM block of a private key. RSA keys keep
+// using PKCS1 like they always have, the other types use PKCS8.
+func privateKeyBlock(privateKey crypto.Signer) (pem.Block, error) {
+	if pk, ok := privateKey.(*rsa.PrivateKey); ok {
+		return pem.Block{
+			Type:  "PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(pk),
+		}, nil
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return pem.Block{}, err
+	}
+
+	return pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: der,
+	}, nil
+}
+
+func readKeyMetadata(fileName string) (keystore.Metadata, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return keystore.Metadata{}, nil
+		}
+		return keystore.Metadata{}, err
+	}
+
+	var md keystore.Metadata
+	if err := json.Unmarshal(data, &md); err != nil {
+		return keystore.Metadata{}, fmt.Errorf("decoding: %w", err)
+	}
+
+	return md, nil
+}
+
+func writeKeyMetadata(fileName string, md keystore.Metadata) error {
+	data, err := json.MarshalIndent(md, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding: %w", err)
+	}
+
+	// Write to a temporary file first so a reload never sees a partial file.
+	tmp := fileName + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, fileName)
+}
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/sdk/jobqueue"
+	"github.com/rmsj/service/business/sdk/jobqueue/stores/jobdb"
+	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/foundation/logger"
+)
+
+// Jobs retrieves the jobs from the database, optionally filtered by status.
+func Jobs(log *logger.Logger, cfg sqldb.Config, status string, pageNumber string, rowsPerPage string) error {
+	db, err := sqldb.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	queue := jobqueue.New(log, jobdb.NewStore(log, db))
+
+	page, err := page.Parse(pageNumber, rowsPerPage)
+	if err != nil {
+		return fmt.Errorf("parsing page information: %w", err)
+	}
+
+	var filter jobqueue.QueryFilter
+	if status != "" {
+		filter.Status = &status
+	}
+
+	jobs, err := queue.Query(ctx, filter, jobqueue.DefaultOrderBy, page)
+	if err != nil {
+		return fmt.Errorf("retrieve jobs: %w", err)
+	}
+
+	return json.NewEncoder(os.Stdout).Encode(jobs)
+}
+
+// JobRetry queues a failed or cancelled job to run again.
+func JobRetry(log *logger.Logger, cfg sqldb.Config, jobID string) error {
+	return changeJob(log, cfg, jobID, (*jobqueue.Queue).Retry)
+}
+
+// JobCancel stops a queued job from running.
+func JobCancel(log *logger.Logger, cfg sqldb.Config, jobID string) error {
+	return changeJob(log, cfg, jobID, (*jobqueue.Queue).Cancel)
+}
+
+func changeJob(log *logger.Logger, cfg sqldb.Config, jobID string, fn func(*jobqueue.Queue, context.Context, jobqueue.Job) (jobqueue.Job, error)) error {
+	id, err := uuid.Parse(jobID)
+	if err != nil {
+		return fmt.Errorf("parsing job id: %w", err)
+	}
+
+	db, err := sqldb.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	queue := jobqueue.New(log, jobdb.NewStore(log, db))
+
+	job, err := queue.QueryByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("retrieve job: %w", err)
+	}
+
+	job, err = fn(queue, ctx, job)
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(os.Stdout).Encode(job)
+}
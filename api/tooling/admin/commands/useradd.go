@@ -38,10 +38,11 @@ func UserAdd(log *logger.Logger, cfg sqldb.Config, nme string, email string, pas
 	}
 
 	nu := userbus.NewUser{
-		Name:     name.MustParse(nme),
-		Email:    *addr,
-		Password: password,
-		Roles:    []role.Role{role.Admin, role.User},
+		Name:          name.MustParse(nme),
+		Email:         *addr,
+		Password:      password,
+		Roles:         []role.Role{role.Admin, role.User},
+		EmailVerified: true,
 	}
 
 	usr, err := userBus.Create(ctx, nu)
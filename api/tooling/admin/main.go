@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/ardanlabs/conf/v3"
 	"github.com/google/uuid"
@@ -121,8 +122,41 @@ func processCommands(args conf.Args, log *logger.Logger, cfg config) error {
 			return fmt.Errorf("getting users: %w", err)
 		}
 
+	case "jobs":
+		status := args.Num(1)
+		pageNumber := args.Num(2)
+		rowsPerPage := args.Num(3)
+		if err := commands.Jobs(log, dbConfig, status, pageNumber, rowsPerPage); err != nil {
+			return fmt.Errorf("getting jobs: %w", err)
+		}
+
+	case "jobretry":
+		if err := commands.JobRetry(log, dbConfig, args.Num(1)); err != nil {
+			return fmt.Errorf("retrying job: %w", err)
+		}
+
+	case "jobcancel":
+		if err := commands.JobCancel(log, dbConfig, args.Num(1)); err != nil {
+			return fmt.Errorf("cancelling job: %w", err)
+		}
+
 	case "genkey":
-		if err := commands.GenKey(); err != nil {
+		if args.Num(1) == "rotate" {
+			activateIn := 10 * time.Minute
+			if v := args.Num(2); v != "" {
+				d, err := time.ParseDuration(v)
+				if err != nil {
+					return fmt.Errorf("parsing activation delay: %w", err)
+				}
+				activateIn = d
+			}
+			if err := commands.GenKeyRotate(cfg.Auth.KeysFolder, activateIn, args.Num(3)); err != nil {
+				return fmt.Errorf("key rotation: %w", err)
+			}
+			return nil
+		}
+
+		if err := commands.GenKey(args.Num(1)); err != nil {
 			return fmt.Errorf("key generation: %w", err)
 		}
 
@@ -144,7 +178,11 @@ func processCommands(args conf.Args, log *logger.Logger, cfg config) error {
 		fmt.Println("seed:       add data to the database")
 		fmt.Println("useradd:    add a new user to the database")
 		fmt.Println("users:      get a list of users from the database")
-		fmt.Println("genkey:     generate a set of private/public key files")
+		fmt.Println("jobs:       get a list of jobs from the database, optionally by status")
+		fmt.Println("jobretry:   queue a failed or cancelled job to run again")
+		fmt.Println("jobcancel:  stop a queued job from running")
+		fmt.Println("genkey:     generate a set of private/public key files, genkey [rsa|ecdsa|ed25519]")
+		fmt.Println("            genkey rotate [activate-in] [type] adds a key to the keys folder that signs after the delay")
 		fmt.Println("gentoken:   generate a JWT for a user with claims")
 		fmt.Println("provide a command to get more help.")
 		return commands.ErrHelp
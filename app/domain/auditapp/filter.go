@@ -0,0 +1,121 @@
+package auditapp
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/app/sdk/query"
+	"github.com/rmsj/service/business/domain/auditbus"
+)
+
+var filterByFields = query.Fields{
+	"actor_id":   {Code: auditbus.FilterByActorID, Parse: query.UUID},
+	"action":     {Code: auditbus.FilterByAction, Parse: query.Text},
+	"domain":     {Code: auditbus.FilterByDomain, Parse: query.Text},
+	"entity_id":  {Code: auditbus.FilterByEntityID, Parse: query.Text},
+	"request_id": {Code: auditbus.FilterByRequestID, Parse: query.Text},
+	"timestamp":  {Code: auditbus.FilterByTimestamp, Parse: query.Time},
+}
+
+type queryParams struct {
+	Page      string
+	Rows      string
+	OrderBy   string
+	Filter    string
+	ActorID   string
+	Action    string
+	Domain    string
+	EntityID  string
+	RequestID string
+	StartDate string
+	EndDate   string
+}
+
+func parseQueryParams(r *http.Request) queryParams {
+	values := r.URL.Query()
+
+	filter := queryParams{
+		Page:      values.Get("page"),
+		Rows:      values.Get("rows"),
+		OrderBy:   values.Get("orderBy"),
+		Filter:    values.Get("filter"),
+		ActorID:   values.Get("actor_id"),
+		Action:    values.Get("action"),
+		Domain:    values.Get("domain"),
+		EntityID:  values.Get("entity_id"),
+		RequestID: values.Get("request_id"),
+		StartDate: values.Get("start_date"),
+		EndDate:   values.Get("end_date"),
+	}
+
+	return filter
+}
+
+func parseFilter(qp queryParams) (auditbus.QueryFilter, error) {
+	var fieldErrors errs.FieldErrors
+	var filter auditbus.QueryFilter
+
+	if qp.ActorID != "" {
+		id, err := uuid.Parse(qp.ActorID)
+		switch err {
+		case nil:
+			filter.ActorID = &id
+		default:
+			fieldErrors.Add("actor_id", err)
+		}
+	}
+
+	if qp.Action != "" {
+		filter.Action = &qp.Action
+	}
+
+	if qp.Domain != "" {
+		filter.Domain = &qp.Domain
+	}
+
+	if qp.EntityID != "" {
+		filter.EntityID = &qp.EntityID
+	}
+
+	if qp.RequestID != "" {
+		filter.RequestID = &qp.RequestID
+	}
+
+	if qp.StartDate != "" {
+		t, err := time.Parse(time.RFC3339, qp.StartDate)
+		switch err {
+		case nil:
+			filter.StartDate = &t
+		default:
+			fieldErrors.Add("start_date", err)
+		}
+	}
+
+	if qp.EndDate != "" {
+		t, err := time.Parse(time.RFC3339, qp.EndDate)
+		switch err {
+		case nil:
+			filter.EndDate = &t
+		default:
+			fieldErrors.Add("end_date", err)
+		}
+	}
+
+	if qp.Filter != "" {
+		expr, err := query.ParseFilter(qp.Filter, filterByFields)
+		switch err {
+		case nil:
+			filter.Expr = expr
+		default:
+			fieldErrors.Add("filter", err)
+		}
+	}
+
+	if fieldErrors != nil {
+		return auditbus.QueryFilter{}, fieldErrors.ToError()
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,58 @@
+package auditapp
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rmsj/service/business/domain/auditbus"
+)
+
+// Audit represents a change made to the system.
+type Audit struct {
+	ID        string          `json:"id"`
+	ActorID   string          `json:"actorID,omitempty"`
+	Action    string          `json:"action"`
+	Domain    string          `json:"domain"`
+	EntityID  string          `json:"entityID"`
+	Diff      json.RawMessage `json:"diff,omitempty"`
+	RequestID string          `json:"requestID"`
+	TraceID   string          `json:"traceID"`
+	IPAddress string          `json:"ipAddress"`
+	Timestamp string          `json:"timestamp"`
+}
+
+// Encode implements the encoder interface.
+func (app Audit) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(app)
+	return data, "application/json", err
+}
+
+func toAppAudit(aud auditbus.Audit) Audit {
+	var actorID string
+	if aud.ActorID != uuid.Nil {
+		actorID = aud.ActorID.String()
+	}
+
+	return Audit{
+		ID:        aud.ID.String(),
+		ActorID:   actorID,
+		Action:    aud.Action,
+		Domain:    aud.Domain,
+		EntityID:  aud.EntityID,
+		Diff:      aud.Diff,
+		RequestID: aud.RequestID,
+		TraceID:   aud.TraceID,
+		IPAddress: aud.IPAddress,
+		Timestamp: aud.Timestamp.Format(time.RFC3339Nano),
+	}
+}
+
+func toAppAudits(auds []auditbus.Audit) []Audit {
+	app := make([]Audit, len(auds))
+	for i, aud := range auds {
+		app[i] = toAppAudit(aud)
+	}
+
+	return app
+}
@@ -0,0 +1,13 @@
+package auditapp
+
+import (
+	"github.com/rmsj/service/business/domain/auditbus"
+)
+
+var orderByFields = map[string]string{
+	"timestamp": auditbus.OrderByTimestamp,
+	"actor_id":  auditbus.OrderByActorID,
+	"action":    auditbus.OrderByAction,
+	"domain":    auditbus.OrderByDomain,
+	"entity_id": auditbus.OrderByEntityID,
+}
@@ -0,0 +1,31 @@
+package auditapp
+
+import (
+	"net/http"
+
+	"github.com/rmsj/service/app/sdk/auth"
+	"github.com/rmsj/service/app/sdk/authclient"
+	"github.com/rmsj/service/app/sdk/mid"
+	"github.com/rmsj/service/business/domain/auditbus"
+	"github.com/rmsj/service/foundation/logger"
+	"github.com/rmsj/service/foundation/web"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Log        *logger.Logger
+	AuditBus   *auditbus.Business
+	AuthClient *authclient.Client
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	authen := mid.Authenticate(cfg.AuthClient)
+	ruleAdmin := mid.Authorize(cfg.AuthClient, auth.RuleAdminOnly)
+
+	api := newApp(cfg.AuditBus)
+
+	app.HandlerFunc(http.MethodGet, version, "/audits", api.query, authen, ruleAdmin)
+}
@@ -4,33 +4,107 @@ package authapp
 import (
 	"context"
 	"errors"
-	"fmt"
+	"net"
 	"net/http"
 	"net/mail"
+	"net/url"
+	"strings"
+	"time"
 
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 
 	"github.com/rmsj/service/app/sdk/auth"
 	"github.com/rmsj/service/app/sdk/authclient"
 	"github.com/rmsj/service/app/sdk/errs"
 	"github.com/rmsj/service/app/sdk/mid"
+	"github.com/rmsj/service/app/sdk/session"
+	"github.com/rmsj/service/business/domain/auditbus"
 	"github.com/rmsj/service/business/domain/authbus"
 	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/sdk/notify"
+	"github.com/rmsj/service/business/types/role"
+	"github.com/rmsj/service/foundation/logger"
 	"github.com/rmsj/service/foundation/web"
 )
 
+// invitationTTL is how long an invitation can be accepted for.
+const invitationTTL = 7 * 24 * time.Hour
+
+// verificationTTL is how long a user has to verify their email.
+const verificationTTL = 24 * time.Hour
+
+// auditDomain is the domain the changes made by this api are audited under.
+const auditDomain = "auth"
+
 type app struct {
-	auth    *auth.Auth
-	authBus *authbus.Business
-	userBus *userbus.Business
+	log       *logger.Logger
+	auth      *auth.Auth
+	authBus   *authbus.Business
+	auditBus  *auditbus.Business
+	userBus   *userbus.Business
+	sessions  *session.Starter
+	notifier  *notify.Notifier
+	resetURL  string
+	inviteURL string
+	verifyURL string
+	publicURL string
+	mfaIssuer string
 }
 
-func newApp(ath *auth.Auth, authBus *authbus.Business, userBus *userbus.Business) *app {
+func newApp(log *logger.Logger, ath *auth.Auth, authBus *authbus.Business, auditBus *auditbus.Business, userBus *userbus.Business, notifier *notify.Notifier, resetURL string, inviteURL string, verifyURL string, refreshTTL time.Duration, sessionTTL time.Duration, publicURL string, mfaIssuer string, mfaRoles []role.Role) *app {
 	return &app{
-		auth:    ath,
-		authBus: authBus,
-		userBus: userBus,
+		log:      log,
+		auth:     ath,
+		authBus:  authBus,
+		auditBus: auditBus,
+		userBus:  userBus,
+		sessions: session.New(session.Config{
+			Auth:       ath,
+			AuthBus:    authBus,
+			AuditBus:   auditBus,
+			RefreshTTL: refreshTTL,
+			SessionTTL: sessionTTL,
+			MFARoles:   mfaRoles,
+		}),
+		notifier:  notifier,
+		resetURL:  resetURL,
+		inviteURL: inviteURL,
+		verifyURL: verifyURL,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+		mfaIssuer: mfaIssuer,
+	}
+}
+
+// newWithTx constructs a new Handlers value with the domain apis
+// using a store transaction that was created via middleware.
+func (a *app) newWithTx(ctx context.Context) (*app, error) {
+	tx, err := mid.GetTran(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	authBus, err := a.authBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	auditBus, err := a.auditBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	userBus, err := a.userBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
 	}
+
+	app := *a
+	app.authBus = authBus
+	app.auditBus = auditBus
+	app.userBus = userBus
+
+	return &app, nil
 }
 
 func (a *app) token(ctx context.Context, r *http.Request) web.Encoder {
@@ -42,12 +116,28 @@ func (a *app) token(ctx context.Context, r *http.Request) web.Encoder {
 	// The BearerBasic middleware function generates the claims.
 	claims := mid.GetClaims(ctx)
 
-	tkn, refreshToken, err := a.auth.GenerateToken(kid, claims)
+	// Basic auth can't carry a second factor, so it's refused to the users
+	// that need one.
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.New(errs.DataLoss, err)
+	}
+
+	needed, _, err := a.mfaNeeded(ctx, userID, claims.Roles)
 	if err != nil {
 		return errs.New(errs.Internal, err)
 	}
 
-	return token{Token: tkn, RefreshToken: refreshToken}
+	if needed {
+		return errs.Newf(errs.Unauthenticated, "mfa required: use the login endpoint")
+	}
+
+	tkn, err := a.auth.GenerateToken(kid, claims)
+	if err != nil {
+		return errs.New(errs.Internal, err)
+	}
+
+	return token{Token: tkn}
 }
 
 func (a *app) authenticate(ctx context.Context, r *http.Request) web.Encoder {
@@ -73,6 +163,14 @@ func (a *app) authorize(ctx context.Context, r *http.Request) web.Encoder {
 		return errs.New(errs.InvalidArgument, err)
 	}
 
+	if auth.Permission != "" {
+		if err := a.auth.AuthorizePermission(ctx, auth.Claims, auth.UserID, auth.UserRoles, auth.Permission); err != nil {
+			return errs.Newf(errs.Unauthenticated, "authorize: you are not authorized for that action, claims[%v] permission[%v]: %s", auth.Claims.Roles, auth.Permission, err)
+		}
+
+		return nil
+	}
+
 	if err := a.auth.Authorize(ctx, auth.Claims, auth.UserID, auth.Rule); err != nil {
 		return errs.Newf(errs.Unauthenticated, "authorize: you are not authorized for that action, claims[%v] rule[%v]: %s", auth.Claims.Roles, auth.Rule, err)
 	}
@@ -80,7 +178,30 @@ func (a *app) authorize(ctx context.Context, r *http.Request) web.Encoder {
 	return nil
 }
 
-// login handles user login with username and password
+// jwks publishes the public keys used to verify the tokens issued by this
+// service so other services can verify them locally.
+func (a *app) jwks(ctx context.Context, r *http.Request) web.Encoder {
+	set, err := a.auth.JWKS()
+	if err != nil {
+		return errs.New(errs.Internal, err)
+	}
+
+	web.GetWriter(ctx).Header().Set("Cache-Control", "public, max-age=300")
+
+	return set
+}
+
+// discovery publishes an OpenID style discovery document pointing to the
+// endpoints of this service.
+func (a *app) discovery(ctx context.Context, r *http.Request) web.Encoder {
+	web.GetWriter(ctx).Header().Set("Cache-Control", "public, max-age=3600")
+
+	return toAppDiscovery(a.auth.Issuer(), a.publicURL)
+}
+
+// login handles user login with username and password. When the user needs
+// a second factor, a short-lived mfa_pending token is returned instead, to be
+// exchanged for the real tokens on the verify endpoint.
 func (a *app) login(ctx context.Context, r *http.Request) web.Encoder {
 	kid := web.Param(r, "kid")
 	if kid == "" {
@@ -92,33 +213,101 @@ func (a *app) login(ctx context.Context, r *http.Request) web.Encoder {
 
 	// if we get to this point, we already have claims as the login itself happens in the middleware
 
-	// The BearerBasic middleware function generates the claims.
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.New(errs.DataLoss, err)
+	}
+
+	// The Login middleware function generates the claims.
 	claims := mid.GetClaims(ctx)
 
-	tkn, refreshToken, err := a.auth.GenerateToken(kid, claims)
+	needed, enabled, err := a.mfaNeeded(ctx, userID, claims.Roles)
 	if err != nil {
 		return errs.New(errs.Internal, err)
 	}
 
-	// update user with new refresh token
+	if needed {
+		chl, errLogin := a.sessions.MFAChallenge(ctx, kid, claims, !enabled)
+		if errLogin != nil {
+			return errLogin
+		}
+
+		return toAppMFAChallenge(chl)
+	}
+
+	tkns, errLogin := a.sessions.Start(ctx, r, kid, userID, claims)
+	if errLogin != nil {
+		return errLogin
+	}
+
+	return toAppToken(tkns)
+}
+
+// verifyMFA exchanges the mfa_pending token and a MFA code, or a recovery
+// code, for the real tokens.
+func (a *app) verifyMFA(ctx context.Context, r *http.Request) web.Encoder {
+	kid := web.Param(r, "kid")
+	if kid == "" {
+		kid = a.auth.ActiveKID()
+		if kid == "" {
+			return errs.New(errs.FailedPrecondition, errs.NewFieldErrors("kid", errors.New("missing kid")))
+		}
+	}
+
+	var app MFACode
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
 	userID, err := mid.GetUserID(ctx)
 	if err != nil {
-		return errs.New(errs.DataLoss, err)
+		return errs.New(errs.Unauthenticated, err)
 	}
 
 	usr, err := a.userBus.QueryByID(ctx, userID)
 	if err != nil {
-		if errors.Is(err, userbus.ErrNotFound) {
-			return errs.Newf(errs.NotFound, "invalid user id: %s", userID)
+		return errs.Newf(errs.Unauthenticated, "query user: userID[%s]: %s", userID, err)
+	}
+
+	if !usr.Enabled {
+		return errs.Newf(errs.Unauthenticated, "user disabled: userID[%s]", userID)
+	}
+
+	// The codes are short, so failures count towards the same lockout as
+	// the passwords.
+	check := func(ctx context.Context) (bool, *errs.Error) {
+		if err := a.authBus.VerifyMFA(ctx, userID, app.Code); err != nil {
+			if errors.Is(err, authbus.ErrMFAInvalidCode) || errors.Is(err, authbus.ErrMFANotEnrolled) {
+				return true, errs.New(errs.Unauthenticated, err)
+			}
+			return false, errs.Newf(errs.Internal, "verify mfa: userID[%s]: %s", userID, err)
 		}
-		return errs.Newf(errs.Internal, "error getting user to login")
+
+		return false, nil
 	}
-	_, err = a.userBus.Update(ctx, usr, userbus.UpdateUser{RefreshToken: &refreshToken})
-	if err != nil {
-		return errs.Newf(errs.Internal, "error updating user refresh token: email[%s]", usr.Email)
+
+	if err := mid.GuardLogin(ctx, r, a.authBus, usr.Email, check); err != nil {
+		return err
+	}
+
+	now := mid.GetTime(ctx)
+
+	claims := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   usr.ID.String(),
+			Issuer:    a.auth.Issuer(),
+			ExpiresAt: jwt.NewNumericDate(now.UTC().Add(8 * time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(now.UTC()),
+		},
+		Roles: role.ParseToString(usr.Roles),
+	}
+
+	tkns, errLogin := a.sessions.Start(ctx, r, kid, userID, claims)
+	if errLogin != nil {
+		return errLogin
 	}
 
-	return token{Token: tkn, RefreshToken: refreshToken}
+	return toAppToken(tkns)
 }
 
 // refresh creates a new token for the logged in user, using the provided refresh token
@@ -131,31 +320,290 @@ func (a *app) refresh(ctx context.Context, r *http.Request) web.Encoder {
 		}
 	}
 
-	var app auth.RefreshToken
+	// The RefreshToken middleware function rotates the refresh token and
+	// generates the claims.
+	claims := mid.GetClaims(ctx)
+
+	tkn, err := a.auth.GenerateToken(kid, claims)
+	if err != nil {
+		return errs.New(errs.Internal, err)
+	}
+
+	refreshToken, err := mid.GetRefreshToken(ctx)
+	if err != nil {
+		return errs.New(errs.DataLoss, err)
+	}
+
+	return token{
+		Token:        tkn,
+		RefreshToken: refreshToken,
+	}
+}
+
+// querySessions lists the active sessions of the user in the path, for
+// admins, or of the logged in user.
+func (a *app) querySessions(ctx context.Context, r *http.Request) web.Encoder {
+	userID, err := sessionUserID(ctx, r)
+	if err != nil {
+		return err.(*errs.Error)
+	}
+
+	sessions, err := a.authBus.QueryActiveSessions(ctx, userID)
+	if err != nil {
+		return errs.Newf(errs.Internal, "query sessions: userID[%s]: %s", userID, err)
+	}
+
+	return toAppSessions(sessions, mid.GetClaims(ctx).SessionID)
+}
+
+// revokeSession ends one session of the user in the path, for admins, or of
+// the logged in user.
+func (a *app) revokeSession(ctx context.Context, r *http.Request) web.Encoder {
+	userID, err := sessionUserID(ctx, r)
+	if err != nil {
+		return err.(*errs.Error)
+	}
+
+	sessionID, err := uuid.Parse(web.Param(r, "session_id"))
+	if err != nil {
+		return errs.NewFieldErrors("session_id", err)
+	}
+
+	sess, err := a.authBus.QuerySessionByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, authbus.ErrNotFound) {
+			return errs.New(errs.NotFound, err)
+		}
+		return errs.Newf(errs.Internal, "query session: sessionID[%s]: %s", sessionID, err)
+	}
+
+	// sessions of other users are reported as missing
+	if sess.UserID != userID {
+		return errs.Newf(errs.NotFound, "session not found: sessionID[%s]", sessionID)
+	}
+
+	if err := a.authBus.RevokeSession(ctx, sess); err != nil {
+		return errs.Newf(errs.Internal, "revoke session: sessionID[%s]: %s", sessionID, err)
+	}
+
+	if err := a.audit(ctx, r, "session.revoke", sessionID.String()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// revokeSessions logs the user in the path, for admins, or the logged in
+// user out everywhere.
+func (a *app) revokeSessions(ctx context.Context, r *http.Request) web.Encoder {
+	userID, err := sessionUserID(ctx, r)
+	if err != nil {
+		return err.(*errs.Error)
+	}
+
+	if err := a.authBus.RevokeUserSessions(ctx, userID); err != nil {
+		return errs.Newf(errs.Internal, "revoke sessions: userID[%s]: %s", userID, err)
+	}
+
+	if err := a.audit(ctx, r, "sessions.revoke", userID.String()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// queryMFA returns the state of the MFA of the logged in user.
+func (a *app) queryMFA(ctx context.Context, r *http.Request) web.Encoder {
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, err)
+	}
+
+	status, err := a.authBus.QueryMFAStatus(ctx, userID)
+	if err != nil {
+		return errs.Newf(errs.Internal, "query mfa: userID[%s]: %s", userID, err)
+	}
+
+	return toAppMFAStatus(status, a.sessions.MFARequired(mid.GetClaims(ctx).Roles))
+}
+
+// enrollMFA starts the MFA enrollment of the logged in user. The otpauth URI
+// returned is shown as a QR code for authenticator apps to scan.
+func (a *app) enrollMFA(ctx context.Context, r *http.Request) web.Encoder {
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, err)
+	}
+
+	usr, err := a.userBus.QueryByID(ctx, userID)
+	if err != nil {
+		return errs.Newf(errs.Internal, "query user: userID[%s]: %s", userID, err)
+	}
+
+	mfa, err := a.authBus.EnrollMFA(ctx, userID)
+	if err != nil {
+		if errors.Is(err, authbus.ErrMFAEnabled) {
+			return errs.New(errs.AlreadyExists, err)
+		}
+		return errs.Newf(errs.Internal, "enroll mfa: userID[%s]: %s", userID, err)
+	}
+
+	return toAppMFAEnrollment(mfa, a.mfaIssuer, usr.Email.Address)
+}
+
+// confirmMFA enables the MFA of the logged in user with a first code and
+// returns the recovery codes, which can't be retrieved again.
+func (a *app) confirmMFA(ctx context.Context, r *http.Request) web.Encoder {
+	var app MFACode
 	if err := web.Decode(r, &app); err != nil {
 		return errs.New(errs.InvalidArgument, err)
 	}
 
-	// if we get to this point, we already have claims as the login itself happens in the middleware
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, err)
+	}
 
-	// The BearerBasic middleware function generates the claims.
-	claims := mid.GetClaims(ctx)
+	codes, err := a.authBus.ConfirmMFA(ctx, userID, app.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, authbus.ErrMFAInvalidCode):
+			return errs.New(errs.InvalidArgument, err)
+		case errors.Is(err, authbus.ErrMFANotEnrolled):
+			return errs.New(errs.FailedPrecondition, err)
+		case errors.Is(err, authbus.ErrMFAEnabled):
+			return errs.New(errs.AlreadyExists, err)
+		}
+		return errs.Newf(errs.Internal, "confirm mfa: userID[%s]: %s", userID, err)
+	}
 
-	fmt.Printf("claims: %+v \n", claims)
+	if err := a.audit(ctx, r, "mfa.enable", userID.String()); err != nil {
+		return err
+	}
 
-	tkn, refreshToken, err := a.auth.GenerateToken(kid, claims)
+	return RecoveryCodes{Codes: codes}
+}
+
+// regenerateRecoveryCodes replaces the recovery codes of the logged in user.
+// A current code is required.
+func (a *app) regenerateRecoveryCodes(ctx context.Context, r *http.Request) web.Encoder {
+	userID, err := a.verifyMFACode(ctx, r)
+	if err != nil {
+		return err.(*errs.Error)
+	}
+
+	codes, err := a.authBus.RegenerateRecoveryCodes(ctx, userID)
+	if err != nil {
+		return errs.Newf(errs.Internal, "regenerate recovery codes: userID[%s]: %s", userID, err)
+	}
+
+	if err := a.audit(ctx, r, "mfa.recovery_codes", userID.String()); err != nil {
+		return err
+	}
+
+	return RecoveryCodes{Codes: codes}
+}
+
+// disableMFA removes the MFA of the logged in user. A current code is
+// required, and users whose role requires MFA can't disable it.
+func (a *app) disableMFA(ctx context.Context, r *http.Request) web.Encoder {
+	if a.sessions.MFARequired(mid.GetClaims(ctx).Roles) {
+		return errs.Newf(errs.FailedPrecondition, "mfa is required for your role")
+	}
+
+	userID, err := a.verifyMFACode(ctx, r)
+	if err != nil {
+		return err.(*errs.Error)
+	}
+
+	if err := a.authBus.DisableMFA(ctx, userID); err != nil {
+		return errs.Newf(errs.Internal, "disable mfa: userID[%s]: %s", userID, err)
+	}
+
+	if err := a.audit(ctx, r, "mfa.disable", userID.String()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// resetMFA removes the MFA of the user in the path, for the users that lost
+// both their device and their recovery codes. They enroll again on their
+// next login if their role requires MFA.
+func (a *app) resetMFA(ctx context.Context, r *http.Request) web.Encoder {
+	a, err := a.newWithTx(ctx)
 	if err != nil {
 		return errs.New(errs.Internal, err)
 	}
 
-	if err := a.updateRefreshToken(ctx, app.Token, refreshToken); err != nil {
-		return errs.Newf(errs.FailedPrecondition, "error updating token")
+	userID, err := uuid.Parse(web.Param(r, "user_id"))
+	if err != nil {
+		return errs.NewFieldErrors("user_id", err)
 	}
 
-	return token{
-		Token:        tkn,
-		RefreshToken: refreshToken,
+	if err := a.authBus.DisableMFA(ctx, userID); err != nil {
+		return errs.Newf(errs.Internal, "reset mfa: userID[%s]: %s", userID, err)
+	}
+
+	if err := a.audit(ctx, r, "mfa.reset", userID.String()); err != nil {
+		return err
 	}
+
+	return nil
+}
+
+// unlockUser lifts the lockout of a user after too many failed logins.
+func (a *app) unlockUser(ctx context.Context, r *http.Request) web.Encoder {
+	a, err := a.newWithTx(ctx)
+	if err != nil {
+		return errs.New(errs.Internal, err)
+	}
+
+	userID, err := uuid.Parse(web.Param(r, "user_id"))
+	if err != nil {
+		return errs.NewFieldErrors("user_id", err)
+	}
+
+	usr, err := a.userBus.QueryByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, userbus.ErrNotFound) {
+			return errs.New(errs.NotFound, err)
+		}
+		return errs.Newf(errs.Internal, "query user: userID[%s]: %s", userID, err)
+	}
+
+	if err := a.authBus.UnlockAccount(ctx, usr.Email); err != nil {
+		return errs.Newf(errs.Internal, "unlock account: userID[%s]: %s", userID, err)
+	}
+
+	if err := a.audit(ctx, r, "lockout.unlock", userID.String()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// unlockIP lifts the lockout of a source IP after too many failed logins.
+func (a *app) unlockIP(ctx context.Context, r *http.Request) web.Encoder {
+	a, err := a.newWithTx(ctx)
+	if err != nil {
+		return errs.New(errs.Internal, err)
+	}
+
+	ip := net.ParseIP(web.Param(r, "ip"))
+	if ip == nil {
+		return errs.NewFieldErrors("ip", errors.New("invalid ip address"))
+	}
+
+	if err := a.authBus.UnlockIP(ctx, ip.String()); err != nil {
+		return errs.Newf(errs.Internal, "unlock ip: ip[%s]: %s", ip, err)
+	}
+
+	if err := a.audit(ctx, r, "lockout.unlock", ip.String()); err != nil {
+		return err
+	}
+
+	return nil
 }
 
 // forgotPassword creates a forgot password token and sends via email to the user, if a valid email is provided, otherwise, do nothing
@@ -169,7 +617,8 @@ func (a *app) forgotPassword(ctx context.Context, r *http.Request) web.Encoder {
 	// this creates the password reset and sends the email to the user
 	_, err := a.createPasswordReset(ctx, app)
 	if err != nil {
-		// TODO: log error, always return empty - message in FE is if it's a valid email, should receive the reset link.
+		// always return empty - message in FE is if it's a valid email, should receive the reset link.
+		a.log.Error(ctx, "authapp.forgotpassword", "email", app.Email, "err", err)
 		return nil
 	}
 
@@ -197,6 +646,10 @@ func (a *app) resetPassword(ctx context.Context, r *http.Request) web.Encoder {
 		return errs.Newf(errs.InvalidArgument, "error updating user password")
 	}
 
+	if err := a.audit(ctx, r, "password.reset", userID.String()); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -204,13 +657,340 @@ func (a *app) authenticateAPI(ctx context.Context, r *http.Request) web.Encoder
 	// The middleware is actually handling the authentication. So if the code
 	// gets to this handler, authentication passed.
 
+	return a.authenticate(ctx, r)
+}
+
+// createAPIKey issues an API key for the user in the path, for admins, or
+// for the logged in user.
+func (a *app) createAPIKey(ctx context.Context, r *http.Request) web.Encoder {
+	var app NewAPIKey
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	userID, err := sessionUserID(ctx, r)
+	if err != nil {
+		return err.(*errs.Error)
+	}
+
+	nk, err := toBusNewAPIKey(userID, app, mid.GetTime(ctx))
+	if err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	key, secret, err := a.authBus.CreateAPIKey(ctx, nk)
+	if err != nil {
+		return errs.Newf(errs.Internal, "create api key: userID[%s]: %s", userID, err)
+	}
+
+	if err := a.audit(ctx, r, "apikey.create", key.ID.String()); err != nil {
+		return err
+	}
+
+	return CreatedAPIKey{
+		APIKey: toAppAPIKey(key),
+		Key:    secret,
+	}
+}
+
+// queryAPIKeys lists the API keys of the user in the path, for admins, or of
+// the logged in user.
+func (a *app) queryAPIKeys(ctx context.Context, r *http.Request) web.Encoder {
+	userID, err := sessionUserID(ctx, r)
+	if err != nil {
+		return err.(*errs.Error)
+	}
+
+	keys, err := a.authBus.QueryAPIKeys(ctx, userID)
+	if err != nil {
+		return errs.Newf(errs.Internal, "query api keys: userID[%s]: %s", userID, err)
+	}
+
+	return toAppAPIKeys(keys)
+}
+
+// revokeAPIKey revokes one API key of the user in the path, for admins, or
+// of the logged in user.
+func (a *app) revokeAPIKey(ctx context.Context, r *http.Request) web.Encoder {
+	userID, err := sessionUserID(ctx, r)
+	if err != nil {
+		return err.(*errs.Error)
+	}
+
+	apiKeyID, err := uuid.Parse(web.Param(r, "apikey_id"))
+	if err != nil {
+		return errs.NewFieldErrors("apikey_id", err)
+	}
+
+	key, err := a.authBus.QueryAPIKeyByID(ctx, apiKeyID)
+	if err != nil {
+		if errors.Is(err, authbus.ErrNotFound) {
+			return errs.New(errs.NotFound, err)
+		}
+		return errs.Newf(errs.Internal, "query api key: apiKeyID[%s]: %s", apiKeyID, err)
+	}
+
+	// keys of other users are reported as missing
+	if key.UserID != userID {
+		return errs.Newf(errs.NotFound, "api key not found: apiKeyID[%s]", apiKeyID)
+	}
+
+	if err := a.authBus.RevokeAPIKey(ctx, key); err != nil {
+		return errs.Newf(errs.Internal, "revoke api key: apiKeyID[%s]: %s", apiKeyID, err)
+	}
+
+	if err := a.audit(ctx, r, "apikey.revoke", apiKeyID.String()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// createInvitation invites someone to create an account with the roles
+// provided, sending them the link to accept it.
+func (a *app) createInvitation(ctx context.Context, r *http.Request) web.Encoder {
+	var app NewInvitation
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return errs.New(errs.Unauthenticated, err)
+	}
+
+	ni, err := toBusNewInvitation(userID, app, invitationTTL)
+	if err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	if _, err := a.userBus.QueryByEmail(ctx, ni.Email); err == nil {
+		return errs.New(errs.AlreadyExists, userbus.ErrUniqueEmail)
+	} else if !errors.Is(err, userbus.ErrNotFound) {
+		return errs.Newf(errs.Internal, "query user: email[%s]: %s", ni.Email.Address, err)
+	}
+
+	inviter, err := a.userBus.QueryByID(ctx, userID)
+	if err != nil {
+		return errs.Newf(errs.Internal, "query inviter: userID[%s]: %s", userID, err)
+	}
+
+	ut, tkn, err := a.authBus.CreateInvitation(ctx, ni)
+	if err != nil {
+		return errs.Newf(errs.Internal, "create invitation: email[%s]: %s", ni.Email.Address, err)
+	}
+
+	// the email is sent in the background, failures are recorded by the notifier
+	err = a.notifier.Send(ctx, notify.Notification{
+		Template: notify.TmplInvitation,
+		To:       ut.Email,
+		Data: notify.InvitationData{
+			InvitedBy: inviter.Name.String(),
+			Link:      strings.TrimSuffix(a.inviteURL, "/") + "/" + url.PathEscape(tkn),
+			ExpiresAt: ut.ExpiresAt,
+		},
+	})
+	if err != nil {
+		return errs.Newf(errs.Internal, "send invitation: email[%s]: %s", ni.Email.Address, err)
+	}
+
+	if err := a.audit(ctx, r, "invite.create", ut.ID.String()); err != nil {
+		return err
+	}
+
+	return toAppInvitation(ut)
+}
+
+// queryInvitations lists the invitations that are still waiting to be
+// accepted.
+func (a *app) queryInvitations(ctx context.Context, r *http.Request) web.Encoder {
+	uts, err := a.authBus.QueryPendingInvitations(ctx)
+	if err != nil {
+		return errs.Newf(errs.Internal, "query invitations: %s", err)
+	}
+
+	return toAppInvitations(uts)
+}
+
+// revokeInvitation revokes an invitation, its link no longer works.
+func (a *app) revokeInvitation(ctx context.Context, r *http.Request) web.Encoder {
+	invitationID, err := uuid.Parse(web.Param(r, "invitation_id"))
+	if err != nil {
+		return errs.NewFieldErrors("invitation_id", err)
+	}
+
+	ut, err := a.authBus.QueryUserTokenByID(ctx, invitationID)
+	if err != nil {
+		if errors.Is(err, authbus.ErrNotFound) {
+			return errs.New(errs.NotFound, err)
+		}
+		return errs.Newf(errs.Internal, "query invitation: invitationID[%s]: %s", invitationID, err)
+	}
+
+	// email verifications are reported as missing
+	if ut.Purpose != authbus.PurposeInvitation {
+		return errs.Newf(errs.NotFound, "invitation not found: invitationID[%s]", invitationID)
+	}
+
+	if err := a.authBus.RevokeUserToken(ctx, ut); err != nil {
+		return errs.Newf(errs.Internal, "revoke invitation: invitationID[%s]: %s", invitationID, err)
+	}
+
+	if err := a.audit(ctx, r, "invite.revoke", invitationID.String()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// acceptInvitation creates the account the invitation was sent for, with the
+// password the invitee chose. The invitation is claimed in the same
+// transaction, so it can only be accepted once.
+func (a *app) acceptInvitation(ctx context.Context, r *http.Request) web.Encoder {
+	a, err := a.newWithTx(ctx)
+	if err != nil {
+		return errs.New(errs.Internal, err)
+	}
+
+	var app AcceptInvitation
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	ut, err := a.authBus.UseUserToken(ctx, authbus.PurposeInvitation, web.Param(r, "invitation_token"))
+	if err != nil {
+		return toAppUserTokenError(err)
+	}
+
+	nu, err := toBusNewUserFromInvitation(ut, app)
+	if err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	usr, err := a.userBus.Create(ctx, nu)
+	if err != nil {
+		if errors.Is(err, userbus.ErrUniqueEmail) {
+			return errs.New(errs.AlreadyExists, userbus.ErrUniqueEmail)
+		}
+		return errs.Newf(errs.Internal, "create user: email[%s]: %s", ut.Email.Address, err)
+	}
+
+	if err := a.audit(ctx, r, "invite.accept", usr.ID.String()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// register signs someone up. The account can't be used until the email is
+// verified, so the verification is sent right away. Emails that already
+// belong to a user are ignored, so the endpoint can't be used to find out
+// who has an account.
+func (a *app) register(ctx context.Context, r *http.Request) web.Encoder {
+	var app Registration
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	nu, err := toBusNewUserFromRegistration(app)
+	if err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	if _, err := a.userBus.QueryByEmail(ctx, nu.Email); err == nil {
+		return nil
+	} else if !errors.Is(err, userbus.ErrNotFound) {
+		return errs.Newf(errs.Internal, "query user: email[%s]: %s", nu.Email.Address, err)
+	}
+
+	usr, err := a.userBus.Create(ctx, nu)
+	if err != nil {
+		if errors.Is(err, userbus.ErrUniqueEmail) {
+			return nil
+		}
+		return errs.Newf(errs.Internal, "create user: email[%s]: %s", nu.Email.Address, err)
+	}
+
+	if err := a.audit(ctx, r, "user.register", usr.ID.String()); err != nil {
+		return err
+	}
+
+	if err := a.sendEmailVerification(ctx, usr); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// resendVerification sends the email verification again, if the email
+// belongs to a user that didn't verify it yet, otherwise, do nothing.
+func (a *app) resendVerification(ctx context.Context, r *http.Request) web.Encoder {
+	var app ResendVerification
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	usr, err := a.userBus.QueryByEmail(ctx, mail.Address{Address: app.Email})
+	if err != nil {
+		if !errors.Is(err, userbus.ErrNotFound) {
+			a.log.Error(ctx, "authapp.resendverification", "email", app.Email, "err", err)
+		}
+		return nil
+	}
+
+	if usr.EmailVerified || !usr.Enabled {
+		return nil
+	}
+
+	if err := a.sendEmailVerification(ctx, usr); err != nil {
+		a.log.Error(ctx, "authapp.resendverification", "email", app.Email, "err", err)
+	}
+
+	return nil
+}
+
+// verifyEmail marks the email of the user the verification was sent to as
+// verified, so they can login.
+func (a *app) verifyEmail(ctx context.Context, r *http.Request) web.Encoder {
+	a, err := a.newWithTx(ctx)
+	if err != nil {
+		return errs.New(errs.Internal, err)
+	}
+
+	ut, err := a.authBus.UseUserToken(ctx, authbus.PurposeEmailVerification, web.Param(r, "verification_token"))
+	if err != nil {
+		return toAppUserTokenError(err)
+	}
+
+	usr, err := a.userBus.QueryByID(ctx, ut.UserID)
+	if err != nil {
+		if errors.Is(err, userbus.ErrNotFound) {
+			return errs.New(errs.InvalidArgument, authbus.ErrUserTokenInvalid)
+		}
+		return errs.Newf(errs.Internal, "query user: userID[%s]: %s", ut.UserID, err)
+	}
+
+	// the user changed their email after the verification was sent
+	if usr.Email.Address != ut.Email.Address {
+		return errs.New(errs.InvalidArgument, authbus.ErrUserTokenInvalid)
+	}
+
+	verified := true
+	if _, err := a.userBus.Update(ctx, usr, userbus.UpdateUser{EmailVerified: &verified}); err != nil {
+		return errs.Newf(errs.Internal, "update user: userID[%s]: %s", usr.ID, err)
+	}
+
+	if err := a.audit(ctx, r, "email.verify", usr.ID.String()); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // createPasswordReset adds a new password reset to the system.
 func (a *app) createPasswordReset(ctx context.Context, app NewPasswordResetToken) (PasswordResetToken, error) {
 
-	_, err := a.userBus.QueryByEmail(ctx, mail.Address{Address: app.Email})
+	usr, err := a.userBus.QueryByEmail(ctx, mail.Address{Address: app.Email})
 	if err != nil {
 		if errors.Is(err, userbus.ErrNotFound) {
 			return PasswordResetToken{}, nil
@@ -223,20 +1003,22 @@ func (a *app) createPasswordReset(ctx context.Context, app NewPasswordResetToken
 		return PasswordResetToken{}, errs.Newf(errs.Internal, "create password reset token: email[%s]: %s", app.Email, err)
 	}
 
-	// send the email - TODO
-	//err = a.mailer.Send(email.Mail{
-	//	To: mail.Address{
-	//		Name:    usr.Name.String(),
-	//		Address: app.Email,
-	//	},
-	//	Subject:  "Password Reset Request",
-	//	Template: email.TmplResetPassword,
-	//	Data:     rt,
-	//})
-	//if err != nil {
-	//	fmt.Printf("error sending reset password email: %s \n", err)
-	//	return PasswordResetToken{}, err
-	//}
+	// the email is sent in the background, failures are recorded by the notifier
+	err = a.notifier.Send(ctx, notify.Notification{
+		Template: notify.TmplPasswordReset,
+		To: mail.Address{
+			Name:    usr.Name.String(),
+			Address: usr.Email.Address,
+		},
+		Data: notify.PasswordResetData{
+			Name:      usr.Name.String(),
+			Link:      strings.TrimSuffix(a.resetURL, "/") + "/" + url.PathEscape(rt.Token),
+			ExpiresAt: rt.ExpiryAt,
+		},
+	})
+	if err != nil {
+		return PasswordResetToken{}, errs.Newf(errs.Internal, "send password reset: email[%s]: %s", app.Email, err)
+	}
 
 	return toAppPasswordResetToken(rt), nil
 }
@@ -313,20 +1095,24 @@ func (a *app) queryPasswordResetByToken(ctx context.Context, token string) (Pass
 	return toAppPasswordResetToken(pr), nil
 }
 
-// updateRefreshToken updates user refresh token after a new token is generated
-func (a *app) updateRefreshToken(ctx context.Context, currentToken, newToken string) error {
+// sessionUserID returns the user whose sessions are managed: the one in the
+// path on the admin routes, the logged in user otherwise.
+func sessionUserID(ctx context.Context, r *http.Request) (uuid.UUID, error) {
+	if id := web.Param(r, "user_id"); id != "" {
+		userID, err := uuid.Parse(id)
+		if err != nil {
+			return uuid.UUID{}, errs.NewFieldErrors("user_id", err)
+		}
 
-	usr, err := a.userBus.QueryByRefreshToken(ctx, currentToken)
-	if err != nil {
-		return errs.Newf(errs.Internal, "error updating user token: %s", err)
+		return userID, nil
 	}
 
-	_, err = a.userBus.Update(ctx, usr, userbus.UpdateUser{RefreshToken: &newToken})
+	userID, err := mid.GetUserID(ctx)
 	if err != nil {
-		return errs.Newf(errs.Internal, "updating refresh token: email[%s]: %s", usr.Email, err)
+		return uuid.UUID{}, errs.New(errs.Unauthenticated, err)
 	}
 
-	return nil
+	return userID, nil
 }
 
 // updateUserPassword updates user password using the reset password flow
@@ -347,3 +1133,98 @@ func (a *app) updateUserPassword(ctx context.Context, userID uuid.UUID, up userb
 
 	return nil
 }
+
+// mfaNeeded reports if the user must provide a second factor to log in,
+// which is the case when they enabled MFA or their role requires it, and if
+// they have MFA enabled.
+func (a *app) mfaNeeded(ctx context.Context, userID uuid.UUID, roles []string) (bool, bool, error) {
+	enabled, err := a.authBus.MFAEnabled(ctx, userID)
+	if err != nil {
+		return false, false, err
+	}
+
+	return enabled || a.sessions.MFARequired(roles), enabled, nil
+}
+
+// verifyMFACode checks the code in the body for the logged in user.
+func (a *app) verifyMFACode(ctx context.Context, r *http.Request) (uuid.UUID, error) {
+	var app MFACode
+	if err := web.Decode(r, &app); err != nil {
+		return uuid.UUID{}, errs.New(errs.InvalidArgument, err)
+	}
+
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return uuid.UUID{}, errs.New(errs.Unauthenticated, err)
+	}
+
+	if err := a.authBus.VerifyMFA(ctx, userID, app.Code); err != nil {
+		switch {
+		case errors.Is(err, authbus.ErrMFAInvalidCode):
+			return uuid.UUID{}, errs.New(errs.InvalidArgument, err)
+		case errors.Is(err, authbus.ErrMFANotEnrolled):
+			return uuid.UUID{}, errs.New(errs.FailedPrecondition, err)
+		}
+		return uuid.UUID{}, errs.Newf(errs.Internal, "verify mfa: userID[%s]: %s", userID, err)
+	}
+
+	return userID, nil
+}
+
+// audit records a security relevant action on the entity. The auth domain
+// has no before and after state worth keeping, only who did what and when.
+func (a *app) audit(ctx context.Context, r *http.Request, action string, entityID string) *errs.Error {
+	if _, err := a.auditBus.Create(ctx, mid.NewAudit(ctx, r, auditDomain, action, entityID, nil, nil)); err != nil {
+		return errs.Newf(errs.Internal, "audit: action[%s] entityID[%s]: %s", action, entityID, err)
+	}
+
+	return nil
+}
+
+// sendEmailVerification issues an email verification for the user and sends
+// them the link to verify their email.
+func (a *app) sendEmailVerification(ctx context.Context, usr userbus.User) *errs.Error {
+	nv := authbus.NewEmailVerification{
+		UserID: usr.ID,
+		Email:  usr.Email,
+		TTL:    verificationTTL,
+	}
+
+	ut, tkn, err := a.authBus.CreateEmailVerification(ctx, nv)
+	if err != nil {
+		return errs.Newf(errs.Internal, "create email verification: userID[%s]: %s", usr.ID, err)
+	}
+
+	// the email is sent in the background, failures are recorded by the notifier
+	err = a.notifier.Send(ctx, notify.Notification{
+		Template: notify.TmplEmailVerification,
+		To: mail.Address{
+			Name:    usr.Name.String(),
+			Address: usr.Email.Address,
+		},
+		Data: notify.EmailVerificationData{
+			Name:      usr.Name.String(),
+			Link:      strings.TrimSuffix(a.verifyURL, "/") + "/" + url.PathEscape(tkn),
+			ExpiresAt: ut.ExpiresAt,
+		},
+	})
+	if err != nil {
+		return errs.Newf(errs.Internal, "send email verification: userID[%s]: %s", usr.ID, err)
+	}
+
+	return nil
+}
+
+// toAppUserTokenError reports the tokens that can't be used as bad requests,
+// hiding the details of the failure.
+func toAppUserTokenError(err error) *errs.Error {
+	switch {
+	case errors.Is(err, authbus.ErrUserTokenInvalid):
+		return errs.New(errs.InvalidArgument, authbus.ErrUserTokenInvalid)
+
+	case errors.Is(err, authbus.ErrUserTokenExpired):
+		return errs.New(errs.InvalidArgument, authbus.ErrUserTokenExpired)
+	}
+
+	return errs.Newf(errs.Internal, "use token: %s", err)
+}
@@ -2,15 +2,27 @@ package authapp
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"slices"
 	"time"
 
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/app/sdk/auth"
 	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/app/sdk/session"
 	"github.com/rmsj/service/business/domain/authbus"
+	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/types/name"
+	"github.com/rmsj/service/business/types/role"
+	"github.com/rmsj/service/foundation/totp"
 )
 
 type token struct {
 	Token        string `json:"token"`
-	RefreshToken string `json:"refreshToken"`
+	RefreshToken string `json:"refreshToken,omitempty"`
 }
 
 // Encode implements the encoder interface.
@@ -19,6 +31,31 @@ func (t token) Encode() ([]byte, string, error) {
 	return data, "application/json", err
 }
 
+func toAppToken(tkns session.Tokens) token {
+	return token{
+		Token:        tkns.Token,
+		RefreshToken: tkns.RefreshToken,
+	}
+}
+
+type mfaChallenge struct {
+	MFAToken           string `json:"mfaToken"`
+	EnrollmentRequired bool   `json:"enrollmentRequired"`
+}
+
+// Encode implements the encoder interface.
+func (m mfaChallenge) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(m)
+	return data, "application/json", err
+}
+
+func toAppMFAChallenge(chl session.Challenge) mfaChallenge {
+	return mfaChallenge{
+		MFAToken:           chl.MFAToken,
+		EnrollmentRequired: chl.EnrollmentRequired,
+	}
+}
+
 // PasswordResetToken represents a password reset in the system
 type PasswordResetToken struct {
 	Email  string
@@ -82,3 +119,483 @@ func (app ResetPassword) Validate() error {
 	}
 	return nil
 }
+
+// =============================================================================
+
+// Session represents an active session of a user.
+type Session struct {
+	ID          string `json:"id"`
+	UserID      string `json:"userID"`
+	UserAgent   string `json:"userAgent"`
+	IPAddress   string `json:"ipAddress"`
+	Current     bool   `json:"current"`
+	DateCreated string `json:"dateCreated"`
+	LastSeenAt  string `json:"lastSeenAt"`
+	ExpiresAt   string `json:"expiresAt"`
+}
+
+func toAppSession(bus authbus.Session, currentID string) Session {
+	return Session{
+		ID:          bus.ID.String(),
+		UserID:      bus.UserID.String(),
+		UserAgent:   bus.UserAgent,
+		IPAddress:   bus.IPAddress,
+		Current:     bus.ID.String() == currentID,
+		DateCreated: bus.DateCreated.Format(time.RFC3339),
+		LastSeenAt:  bus.LastSeenAt.Format(time.RFC3339),
+		ExpiresAt:   bus.ExpiresAt.Format(time.RFC3339),
+	}
+}
+
+// Sessions represents the list of active sessions of a user.
+type Sessions []Session
+
+// Encode implements the encoder interface.
+func (app Sessions) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(app)
+	return data, "application/json", err
+}
+
+func toAppSessions(sessions []authbus.Session, currentID string) Sessions {
+	app := make(Sessions, len(sessions))
+	for i, sess := range sessions {
+		app[i] = toAppSession(sess, currentID)
+	}
+
+	return app
+}
+
+// =============================================================================
+
+// NewAPIKey contains the information needed to issue an API key. A key
+// without scopes has all the permissions of its owner.
+type NewAPIKey struct {
+	Name      string   `json:"name" validate:"required,max=100"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt string   `json:"expiresAt"`
+}
+
+// Decode implements the decoder interface.
+func (app *NewAPIKey) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app NewAPIKey) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.Newf(errs.InvalidArgument, "validate: %s", err)
+	}
+
+	for _, scope := range app.Scopes {
+		if !auth.IsPermission(scope) {
+			return errs.NewFieldErrors("scopes", fmt.Errorf("unknown permission %q", scope))
+		}
+	}
+
+	return nil
+}
+
+func toBusNewAPIKey(userID uuid.UUID, app NewAPIKey, now time.Time) (authbus.NewAPIKey, error) {
+	var expiresAt time.Time
+
+	if app.ExpiresAt != "" {
+		t, err := time.Parse(time.RFC3339, app.ExpiresAt)
+		if err != nil {
+			return authbus.NewAPIKey{}, fmt.Errorf("parse expiresAt: %w", err)
+		}
+
+		if !t.After(now) {
+			return authbus.NewAPIKey{}, errors.New("expiresAt must be in the future")
+		}
+
+		expiresAt = t
+	}
+
+	bus := authbus.NewAPIKey{
+		UserID:    userID,
+		Name:      app.Name,
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(app.Scopes))),
+		ExpiresAt: expiresAt,
+	}
+
+	return bus, nil
+}
+
+// APIKey represents an API key of a user. The key itself isn't part of it,
+// only its prefix.
+type APIKey struct {
+	ID          string   `json:"id"`
+	UserID      string   `json:"userID"`
+	Name        string   `json:"name"`
+	Prefix      string   `json:"prefix"`
+	Scopes      []string `json:"scopes"`
+	ExpiresAt   string   `json:"expiresAt,omitempty"`
+	LastUsedAt  string   `json:"lastUsedAt,omitempty"`
+	DateCreated string   `json:"dateCreated"`
+}
+
+// Encode implements the encoder interface.
+func (app APIKey) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(app)
+	return data, "application/json", err
+}
+
+func toAppAPIKey(bus authbus.APIKey) APIKey {
+	app := APIKey{
+		ID:          bus.ID.String(),
+		UserID:      bus.UserID.String(),
+		Name:        bus.Name,
+		Prefix:      bus.Prefix,
+		Scopes:      bus.Scopes,
+		DateCreated: bus.DateCreated.Format(time.RFC3339),
+	}
+
+	if app.Scopes == nil {
+		app.Scopes = []string{}
+	}
+
+	if !bus.ExpiresAt.IsZero() {
+		app.ExpiresAt = bus.ExpiresAt.Format(time.RFC3339)
+	}
+
+	if !bus.LastUsedAt.IsZero() {
+		app.LastUsedAt = bus.LastUsedAt.Format(time.RFC3339)
+	}
+
+	return app
+}
+
+// APIKeys represents the list of API keys of a user.
+type APIKeys []APIKey
+
+// Encode implements the encoder interface.
+func (app APIKeys) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(app)
+	return data, "application/json", err
+}
+
+func toAppAPIKeys(keys []authbus.APIKey) APIKeys {
+	app := make(APIKeys, len(keys))
+	for i, key := range keys {
+		app[i] = toAppAPIKey(key)
+	}
+
+	return app
+}
+
+// CreatedAPIKey represents a newly issued API key, the only time the key
+// itself is available.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// Encode implements the encoder interface.
+func (app CreatedAPIKey) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(app)
+	return data, "application/json", err
+}
+
+// =============================================================================
+
+// MFACode contains the code of an authenticator app, or a recovery code.
+type MFACode struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// Decode implements the decoder interface.
+func (app *MFACode) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app MFACode) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.Newf(errs.InvalidArgument, "validate: %s", err)
+	}
+	return nil
+}
+
+// MFAEnrollment represents a started MFA enrollment.
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// Encode implements the encoder interface.
+func (app MFAEnrollment) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(app)
+	return data, "application/json", err
+}
+
+func toAppMFAEnrollment(bus authbus.MFA, issuer string, account string) MFAEnrollment {
+	return MFAEnrollment{
+		Secret: bus.Secret,
+		URI:    totp.URI(issuer, account, bus.Secret),
+	}
+}
+
+// RecoveryCodes represents the recovery codes of a user.
+type RecoveryCodes struct {
+	Codes []string `json:"codes"`
+}
+
+// Encode implements the encoder interface.
+func (app RecoveryCodes) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(app)
+	return data, "application/json", err
+}
+
+// MFAStatus represents the state of the MFA of a user.
+type MFAStatus struct {
+	Enabled           bool `json:"enabled"`
+	Required          bool `json:"required"`
+	RecoveryCodesLeft int  `json:"recoveryCodesLeft"`
+}
+
+// Encode implements the encoder interface.
+func (app MFAStatus) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(app)
+	return data, "application/json", err
+}
+
+func toAppMFAStatus(bus authbus.MFAStatus, required bool) MFAStatus {
+	return MFAStatus{
+		Enabled:           bus.Enabled,
+		Required:          required,
+		RecoveryCodesLeft: bus.RecoveryCodesLeft,
+	}
+}
+
+// =============================================================================
+
+// NewInvitation contains the information needed to invite someone to create
+// an account.
+type NewInvitation struct {
+	Email string   `json:"email" validate:"required,email"`
+	Roles []string `json:"roles" validate:"required"`
+}
+
+// Decode implements the decoder interface.
+func (app *NewInvitation) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app NewInvitation) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.Newf(errs.InvalidArgument, "validate: %s", err)
+	}
+
+	return nil
+}
+
+func toBusNewInvitation(invitedBy uuid.UUID, app NewInvitation, ttl time.Duration) (authbus.NewInvitation, error) {
+	roles, err := role.ParseMany(app.Roles)
+	if err != nil {
+		return authbus.NewInvitation{}, fmt.Errorf("parse: %w", err)
+	}
+
+	addr, err := mail.ParseAddress(app.Email)
+	if err != nil {
+		return authbus.NewInvitation{}, fmt.Errorf("parse: %w", err)
+	}
+
+	bus := authbus.NewInvitation{
+		Email:     *addr,
+		Roles:     roles,
+		InvitedBy: invitedBy,
+		TTL:       ttl,
+	}
+
+	return bus, nil
+}
+
+// Invitation represents a pending invitation to create an account.
+type Invitation struct {
+	ID          string   `json:"id"`
+	Email       string   `json:"email"`
+	Roles       []string `json:"roles"`
+	InvitedBy   string   `json:"invitedBy"`
+	ExpiresAt   string   `json:"expiresAt"`
+	DateCreated string   `json:"dateCreated"`
+}
+
+// Encode implements the encoder interface.
+func (app Invitation) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(app)
+	return data, "application/json", err
+}
+
+func toAppInvitation(bus authbus.UserToken) Invitation {
+	var invitedBy string
+	if bus.CreatedBy != uuid.Nil {
+		invitedBy = bus.CreatedBy.String()
+	}
+
+	return Invitation{
+		ID:          bus.ID.String(),
+		Email:       bus.Email.Address,
+		Roles:       role.ParseToString(bus.Roles),
+		InvitedBy:   invitedBy,
+		ExpiresAt:   bus.ExpiresAt.Format(time.RFC3339),
+		DateCreated: bus.DateCreated.Format(time.RFC3339),
+	}
+}
+
+// Invitations is a collection of invitations.
+type Invitations []Invitation
+
+// Encode implements the encoder interface.
+func (app Invitations) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(app)
+	return data, "application/json", err
+}
+
+func toAppInvitations(uts []authbus.UserToken) Invitations {
+	app := make(Invitations, len(uts))
+	for i, ut := range uts {
+		app[i] = toAppInvitation(ut)
+	}
+
+	return app
+}
+
+// AcceptInvitation contains the information needed to create the account an
+// invitation was sent for.
+type AcceptInvitation struct {
+	Name            string `json:"name" validate:"required"`
+	Password        string `json:"password" validate:"required,min=6"`
+	PasswordConfirm string `json:"passwordConfirm" validate:"eqfield=Password"`
+}
+
+// Decode implements the decoder interface.
+func (app *AcceptInvitation) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AcceptInvitation) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.Newf(errs.InvalidArgument, "validate: %s", err)
+	}
+
+	return nil
+}
+
+func toBusNewUserFromInvitation(ut authbus.UserToken, app AcceptInvitation) (userbus.NewUser, error) {
+	nme, err := name.Parse(app.Name)
+	if err != nil {
+		return userbus.NewUser{}, fmt.Errorf("parse: %w", err)
+	}
+
+	// the invitation was sent to the email, so following its link proves
+	// the email belongs to the user
+	bus := userbus.NewUser{
+		Name:          nme,
+		Email:         ut.Email,
+		Roles:         ut.Roles,
+		Password:      app.Password,
+		EmailVerified: true,
+	}
+
+	return bus, nil
+}
+
+// Registration contains the information needed for someone to sign up.
+type Registration struct {
+	Name            string `json:"name" validate:"required"`
+	Email           string `json:"email" validate:"required,email"`
+	Password        string `json:"password" validate:"required,min=6"`
+	PasswordConfirm string `json:"passwordConfirm" validate:"eqfield=Password"`
+}
+
+// Decode implements the decoder interface.
+func (app *Registration) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app Registration) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.Newf(errs.InvalidArgument, "validate: %s", err)
+	}
+
+	return nil
+}
+
+func toBusNewUserFromRegistration(app Registration) (userbus.NewUser, error) {
+	addr, err := mail.ParseAddress(app.Email)
+	if err != nil {
+		return userbus.NewUser{}, fmt.Errorf("parse: %w", err)
+	}
+
+	nme, err := name.Parse(app.Name)
+	if err != nil {
+		return userbus.NewUser{}, fmt.Errorf("parse: %w", err)
+	}
+
+	// people signing up get the least privileged role and can't login
+	// until they verify their email
+	bus := userbus.NewUser{
+		Name:     nme,
+		Email:    *addr,
+		Roles:    []role.Role{role.User},
+		Password: app.Password,
+	}
+
+	return bus, nil
+}
+
+// ResendVerification contains the information needed to send the email
+// verification again.
+type ResendVerification struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// Decode implements the decoder interface.
+func (app *ResendVerification) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app ResendVerification) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return errs.Newf(errs.InvalidArgument, "validate: %s", err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// Discovery represents an OpenID style discovery document.
+type Discovery struct {
+	Issuer                           string   `json:"issuer"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	TokenEndpoint                    string   `json:"token_endpoint"`
+	RefreshEndpoint                  string   `json:"refresh_endpoint"`
+	ResponseTypesSupported           []string `json:"response_types_supported"`
+	SubjectTypesSupported            []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+	ClaimsSupported                  []string `json:"claims_supported"`
+}
+
+// Encode implements the encoder interface.
+func (app Discovery) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(app)
+	return data, "application/json", err
+}
+
+func toAppDiscovery(issuer string, publicURL string) Discovery {
+	return Discovery{
+		Issuer:                           issuer,
+		JWKSURI:                          publicURL + "/.well-known/jwks.json",
+		TokenEndpoint:                    publicURL + "/v1/auth/login",
+		RefreshEndpoint:                  publicURL + "/v1/auth/refresh",
+		ResponseTypesSupported:           []string{"token"},
+		SubjectTypesSupported:            []string{"public"},
+		IDTokenSigningAlgValuesSupported: auth.SigningMethods(),
+		ClaimsSupported:                  []string{"iss", "sub", "exp", "iat", "roles", "sid"},
+	}
+}
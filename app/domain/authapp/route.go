@@ -2,19 +2,42 @@ package authapp
 
 import (
 	"net/http"
+	"time"
+
+	"github.com/jmoiron/sqlx"
 
 	"github.com/rmsj/service/app/sdk/auth"
 	"github.com/rmsj/service/app/sdk/mid"
+	"github.com/rmsj/service/business/domain/auditbus"
 	"github.com/rmsj/service/business/domain/authbus"
 	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/sdk/notify"
+	"github.com/rmsj/service/business/sdk/ratelimit"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/business/types/role"
+	"github.com/rmsj/service/foundation/logger"
 	"github.com/rmsj/service/foundation/web"
 )
 
 // Config contains all the mandatory systems required by handlers.
 type Config struct {
-	AuthBus *authbus.Business
-	UserBus *userbus.Business
-	Auth    *auth.Auth
+	Log               *logger.Logger
+	DB                *sqlx.DB
+	AuthBus           *authbus.Business
+	AuditBus          *auditbus.Business
+	UserBus           *userbus.Business
+	Auth              *auth.Auth
+	Notifier          *notify.Notifier
+	ResetURL          string
+	InviteURL         string
+	VerifyURL         string
+	AllowRegistration bool
+	RefreshTTL        time.Duration
+	SessionTTL        time.Duration
+	PublicURL         string
+	MFAIssuer         string
+	MFARoles          []role.Role
+	RateLimiter       *ratelimit.Limiter
 }
 
 // Routes adds specific routes for this group.
@@ -22,20 +45,70 @@ func Routes(app *web.App, cfg Config) {
 	const version = "v1"
 
 	bearer := mid.Bearer(cfg.Auth)
-	apiKey := mid.APIKey(cfg.Auth)
-	basic := mid.Basic(cfg.Auth, cfg.UserBus)
-	login := mid.Login(cfg.Auth, cfg.UserBus)
-	refresh := mid.RefreshToken(cfg.Auth, cfg.UserBus)
+	mfaPending := mid.MFAPending(cfg.Auth)
+	mfaBearer := mid.MFABearer(cfg.Auth)
+	apiKey := mid.APIKey(cfg.Auth, cfg.AuthBus, cfg.UserBus)
+	basic := mid.Basic(cfg.Auth, cfg.AuthBus, cfg.UserBus)
+	login := mid.Login(cfg.Auth, cfg.AuthBus, cfg.UserBus)
+	refresh := mid.RefreshToken(cfg.Auth, cfg.AuthBus, cfg.UserBus)
 	resetPass := mid.ResetToken(cfg.AuthBus, cfg.UserBus)
+	ruleAdmin := mid.AuthorizeClaims(cfg.Auth, auth.RuleAdminOnly)
+	transaction := mid.BeginCommitRollback(cfg.Log, sqldb.NewBeginner(cfg.DB))
+
+	// The routes anyone can call to send emails are limited by the address
+	// of the client.
+	rateLimit := mid.RateLimit(cfg.RateLimiter, "accounts")
+
+	api := newApp(cfg.Log, cfg.Auth, cfg.AuthBus, cfg.AuditBus, cfg.UserBus, cfg.Notifier, cfg.ResetURL, cfg.InviteURL, cfg.VerifyURL, cfg.RefreshTTL, cfg.SessionTTL, cfg.PublicURL, cfg.MFAIssuer, cfg.MFARoles)
 
-	api := newApp(cfg.Auth, cfg.AuthBus, cfg.UserBus)
+	app.HandlerFunc(http.MethodGet, "", "/.well-known/jwks.json", api.jwks)
+	app.HandlerFunc(http.MethodGet, "", "/.well-known/openid-configuration", api.discovery)
 
 	app.HandlerFunc(http.MethodGet, version, "/auth/token/{kid}", api.token, basic)
 	app.HandlerFunc(http.MethodPost, version, "/auth/login", api.login, login)
+	app.HandlerFunc(http.MethodPost, version, "/auth/mfa/verify", api.verifyMFA, mfaPending)
 	app.HandlerFunc(http.MethodPost, version, "/auth/refresh", api.refresh, refresh)
-	app.HandlerFunc(http.MethodPost, version, "/auth/forgot", api.forgotPassword)
+	app.HandlerFunc(http.MethodPost, version, "/auth/forgot", api.forgotPassword, rateLimit)
 	app.HandlerFunc(http.MethodPost, version, "/auth/reset-password/{reset_token}", api.resetPassword, resetPass)
 	app.HandlerFunc(http.MethodGet, version, "/auth/authenticate", api.authenticate, bearer)
 	app.HandlerFunc(http.MethodGet, version, "/auth/authenticate-api", api.authenticateAPI, apiKey)
 	app.HandlerFunc(http.MethodPost, version, "/auth/authorize", api.authorize)
+
+	app.HandlerFunc(http.MethodGet, version, "/auth/mfa", api.queryMFA, bearer)
+	app.HandlerFunc(http.MethodPost, version, "/auth/mfa/enroll", api.enrollMFA, mfaBearer)
+	app.HandlerFunc(http.MethodPost, version, "/auth/mfa/confirm", api.confirmMFA, mfaBearer)
+	app.HandlerFunc(http.MethodPost, version, "/auth/mfa/recovery-codes", api.regenerateRecoveryCodes, bearer)
+	app.HandlerFunc(http.MethodDelete, version, "/auth/mfa", api.disableMFA, bearer)
+	app.HandlerFunc(http.MethodDelete, version, "/auth/users/{user_id}/mfa", api.resetMFA, bearer, ruleAdmin, transaction)
+
+	app.HandlerFunc(http.MethodDelete, version, "/auth/users/{user_id}/lockout", api.unlockUser, bearer, ruleAdmin, transaction)
+	app.HandlerFunc(http.MethodDelete, version, "/auth/lockouts/ips/{ip}", api.unlockIP, bearer, ruleAdmin, transaction)
+
+	app.HandlerFunc(http.MethodPost, version, "/auth/apikeys", api.createAPIKey, bearer)
+	app.HandlerFunc(http.MethodGet, version, "/auth/apikeys", api.queryAPIKeys, bearer)
+	app.HandlerFunc(http.MethodDelete, version, "/auth/apikeys/{apikey_id}", api.revokeAPIKey, bearer)
+	app.HandlerFunc(http.MethodPost, version, "/auth/users/{user_id}/apikeys", api.createAPIKey, bearer, ruleAdmin)
+	app.HandlerFunc(http.MethodGet, version, "/auth/users/{user_id}/apikeys", api.queryAPIKeys, bearer, ruleAdmin)
+	app.HandlerFunc(http.MethodDelete, version, "/auth/users/{user_id}/apikeys/{apikey_id}", api.revokeAPIKey, bearer, ruleAdmin)
+
+	app.HandlerFunc(http.MethodPost, version, "/auth/invitations", api.createInvitation, bearer, ruleAdmin)
+	app.HandlerFunc(http.MethodGet, version, "/auth/invitations", api.queryInvitations, bearer, ruleAdmin)
+	app.HandlerFunc(http.MethodDelete, version, "/auth/invitations/{invitation_id}", api.revokeInvitation, bearer, ruleAdmin)
+	app.HandlerFunc(http.MethodPost, version, "/auth/invitations/{invitation_token}/accept", api.acceptInvitation, transaction)
+
+	// Self registration is only bound when it's allowed, the email
+	// verification is kept for the users that signed up before.
+
+	if cfg.AllowRegistration {
+		app.HandlerFunc(http.MethodPost, version, "/auth/register", api.register, rateLimit)
+	}
+	app.HandlerFunc(http.MethodPost, version, "/auth/resend-verification", api.resendVerification, rateLimit)
+	app.HandlerFunc(http.MethodPost, version, "/auth/verify-email/{verification_token}", api.verifyEmail, transaction)
+
+	app.HandlerFunc(http.MethodGet, version, "/auth/sessions", api.querySessions, bearer)
+	app.HandlerFunc(http.MethodDelete, version, "/auth/sessions", api.revokeSessions, bearer)
+	app.HandlerFunc(http.MethodDelete, version, "/auth/sessions/{session_id}", api.revokeSession, bearer)
+	app.HandlerFunc(http.MethodGet, version, "/auth/users/{user_id}/sessions", api.querySessions, bearer, ruleAdmin)
+	app.HandlerFunc(http.MethodDelete, version, "/auth/users/{user_id}/sessions", api.revokeSessions, bearer, ruleAdmin)
+	app.HandlerFunc(http.MethodDelete, version, "/auth/users/{user_id}/sessions/{session_id}", api.revokeSession, bearer, ruleAdmin)
 }
@@ -3,44 +3,64 @@ package oauthapp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/mail"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/markbates/goth"
 	"github.com/markbates/goth/gothic"
-	"github.com/markbates/goth/providers/google"
 
 	"github.com/rmsj/service/app/sdk/auth"
 	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/app/sdk/mid"
+	"github.com/rmsj/service/app/sdk/session"
+	"github.com/rmsj/service/business/domain/authbus"
+	"github.com/rmsj/service/business/domain/identitybus"
+	"github.com/rmsj/service/business/domain/userbus"
 	"github.com/rmsj/service/business/types/role"
 	"github.com/rmsj/service/foundation/logger"
 	"github.com/rmsj/service/foundation/web"
 )
 
 type app struct {
-	log      *logger.Logger
-	auth     *auth.Auth
-	tokenKey string
-	uiURL    string
-	apiHost  string
+	log         *logger.Logger
+	auth        *auth.Auth
+	authBus     *authbus.Business
+	identityBus *identitybus.Business
+	sessions    *session.Starter
+	tokenKey    string
+	uiURL       string
+	provision   bool
 }
 
 func newApp(cfg Config) *app {
-	goth.UseProviders(
-		google.New(cfg.GoogleKey, cfg.GoogleSecret, fmt.Sprintf("%s/api/auth/google/callback", cfg.GoogleCallBackURL)),
-	)
+	goth.UseProviders(cfg.Providers...)
 
 	gothic.GetProviderName = func(r *http.Request) (string, error) {
 		return web.Param(r, "provider"), nil
 	}
 
 	return &app{
-		auth:    cfg.Auth,
-		log:     cfg.Log,
-		uiURL:   cfg.GoogleUIURL,
-		apiHost: cfg.APIHost,
+		log:         cfg.Log,
+		auth:        cfg.Auth,
+		authBus:     cfg.AuthBus,
+		identityBus: cfg.IdentityBus,
+		sessions: session.New(session.Config{
+			Auth:       cfg.Auth,
+			AuthBus:    cfg.AuthBus,
+			AuditBus:   cfg.AuditBus,
+			RefreshTTL: cfg.RefreshTTL,
+			SessionTTL: cfg.SessionTTL,
+			MFARoles:   cfg.MFARoles,
+		}),
+		tokenKey:  cfg.TokenKey,
+		uiURL:     cfg.UIURL,
+		provision: cfg.Provision,
 	}
 }
 
@@ -50,33 +70,75 @@ func (a *app) authenticate(ctx context.Context, r *http.Request) web.Encoder {
 	return web.NewNoResponse()
 }
 
+// authCallback completes the login with the provider, links the identity to
+// a user and redirects to the ui with the tokens of a new session. The tokens
+// are passed in the fragment so they never reach a server log. When the user
+// needs a second factor, the mfa_pending token is passed instead.
 func (a *app) authCallback(ctx context.Context, r *http.Request) web.Encoder {
 	w := web.GetWriter(ctx)
 
-	user, err := gothic.CompleteUserAuth(w, r)
+	gu, err := gothic.CompleteUserAuth(w, r)
 	if err != nil {
-		return errs.New(errs.Internal, err)
+		return errs.New(errs.Unauthenticated, err)
+	}
+
+	usr, errLink := a.link(ctx, gu)
+	if errLink != nil {
+		return errLink
+	}
+
+	kid := a.tokenKey
+	if kid == "" {
+		kid = a.auth.ActiveKID()
+		if kid == "" {
+			return errs.Newf(errs.FailedPrecondition, "missing kid")
+		}
 	}
 
-	clms := auth.Claims{
+	now := mid.GetTime(ctx)
+
+	claims := auth.Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			Subject:   user.UserID,
+			Subject:   usr.ID.String(),
 			Issuer:    a.auth.Issuer(),
-			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(2 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+			ExpiresAt: jwt.NewNumericDate(now.UTC().Add(8 * time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(now.UTC()),
 		},
-		Roles: []string{role.Admin.String()},
+		Roles: role.ParseToString(usr.Roles),
 	}
 
-	token, _, err := a.auth.GenerateToken(a.tokenKey, clms)
+	enabled, err := a.authBus.MFAEnabled(ctx, usr.ID)
 	if err != nil {
 		return errs.New(errs.Internal, err)
 	}
 
-	redirect := fmt.Sprintf("%s/app/admin?token=%s", a.uiURL, token)
-	a.log.Info(r.Context(), "REDIRECT", "redirect", redirect)
+	var fragment url.Values
+
+	switch {
+	case enabled || a.sessions.MFARequired(claims.Roles):
+		chl, errTkn := a.sessions.MFAChallenge(ctx, kid, claims, !enabled)
+		if errTkn != nil {
+			return errTkn
+		}
+
+		fragment = url.Values{
+			"mfa_token":           {chl.MFAToken},
+			"enrollment_required": {strconv.FormatBool(chl.EnrollmentRequired)},
+		}
+
+	default:
+		tkns, errTkn := a.sessions.Start(ctx, r, kid, usr.ID, claims)
+		if errTkn != nil {
+			return errTkn
+		}
+
+		fragment = url.Values{
+			"token":         {tkns.Token},
+			"refresh_token": {tkns.RefreshToken},
+		}
+	}
 
-	http.Redirect(w, r, redirect, http.StatusFound)
+	http.Redirect(w, r, fmt.Sprintf("%s/app/admin#%s", a.uiURL, fragment.Encode()), http.StatusFound)
 
 	return web.NewNoResponse()
 }
@@ -93,3 +155,37 @@ func (a *app) logout(ctx context.Context, r *http.Request) web.Encoder {
 
 	return web.NewNoResponse()
 }
+
+// =============================================================================
+
+// link finds the user the identity belongs to, linking it to the user with
+// the same email, or a new one, on the first login.
+func (a *app) link(ctx context.Context, gu goth.User) (userbus.User, *errs.Error) {
+	eu := identitybus.ExternalUser{
+		Provider:      gu.Provider,
+		Subject:       gu.UserID,
+		Name:          gu.Name,
+		EmailVerified: emailVerified(gu),
+	}
+
+	if gu.Email != "" {
+		addr, err := mail.ParseAddress(gu.Email)
+		if err != nil {
+			return userbus.User{}, errs.Newf(errs.Unauthenticated, "parse email: %s", err)
+		}
+		eu.Email = *addr
+	}
+
+	usr, err := a.identityBus.Link(ctx, eu, a.provision)
+	if err != nil {
+		switch {
+		case errors.Is(err, identitybus.ErrEmailNotVerified),
+			errors.Is(err, identitybus.ErrNoUser),
+			errors.Is(err, identitybus.ErrUserDisabled):
+			return userbus.User{}, errs.New(errs.Unauthenticated, err)
+		}
+		return userbus.User{}, errs.Newf(errs.Internal, "link: provider[%s] subject[%s]: %s", eu.Provider, eu.Subject, err)
+	}
+
+	return usr, nil
+}
@@ -0,0 +1,81 @@
+package oauthapp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/providers/faux"
+	"github.com/markbates/goth/providers/github"
+	"github.com/markbates/goth/providers/gitlab"
+	"github.com/markbates/goth/providers/google"
+	"github.com/markbates/goth/providers/microsoftonline"
+)
+
+// ProviderConfig contains the configuration of an identity provider.
+type ProviderConfig struct {
+	Name   string
+	Key    string
+	Secret string
+	Scopes []string
+}
+
+// NewProviders constructs the identity providers. The callback of each one
+// is rooted at the specified url. The faux provider is only meant for tests,
+// it returns whatever user the test stored in the session.
+func NewProviders(callbackURL string, cfgs []ProviderConfig) ([]goth.Provider, error) {
+	callbackURL = strings.TrimSuffix(callbackURL, "/")
+
+	providers := make([]goth.Provider, 0, len(cfgs))
+
+	for _, cfg := range cfgs {
+		callback := fmt.Sprintf("%s/api/auth/%s/callback", callbackURL, cfg.Name)
+
+		var provider goth.Provider
+
+		switch cfg.Name {
+		case "google":
+			provider = google.New(cfg.Key, cfg.Secret, callback, cfg.Scopes...)
+		case "github":
+			provider = github.New(cfg.Key, cfg.Secret, callback, append([]string{"user:email"}, cfg.Scopes...)...)
+		case "gitlab":
+			provider = gitlab.New(cfg.Key, cfg.Secret, callback, cfg.Scopes...)
+		case "microsoftonline":
+			provider = microsoftonline.New(cfg.Key, cfg.Secret, callback, cfg.Scopes...)
+		case "faux":
+			provider = &faux.Provider{}
+		default:
+			return nil, fmt.Errorf("unknown provider %q", cfg.Name)
+		}
+
+		providers = append(providers, provider)
+	}
+
+	return providers, nil
+}
+
+// emailVerified reports if the provider verified the email of the user.
+// GitHub and GitLab only expose emails their users confirmed, the others say
+// so in the profile, and the ones that don't aren't trusted with it. The faux
+// provider vouches for any email the test gives it.
+func emailVerified(user goth.User) bool {
+	switch user.Provider {
+	case "github", "gitlab", "faux":
+		return user.Email != ""
+	}
+
+	for _, key := range []string{"email_verified", "verified_email"} {
+		switch v := user.RawData[key].(type) {
+		case bool:
+			if v {
+				return true
+			}
+		case string:
+			if v == "true" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -2,22 +2,35 @@ package oauthapp
 
 import (
 	"net/http"
+	"time"
+
+	"github.com/markbates/goth"
 
 	"github.com/rmsj/service/app/sdk/auth"
+	"github.com/rmsj/service/business/domain/auditbus"
+	"github.com/rmsj/service/business/domain/authbus"
+	"github.com/rmsj/service/business/domain/identitybus"
+	"github.com/rmsj/service/business/types/role"
 	"github.com/rmsj/service/foundation/logger"
 	"github.com/rmsj/service/foundation/web"
 )
 
-// Config contains all the configuration for the auth app.
+// Config contains all the configuration for the auth app. When Provision is
+// set, users logging in for the first time with an email no user has are
+// created with the user role.
 type Config struct {
-	Auth              *auth.Auth
-	Log               *logger.Logger
-	TokenKey          string
-	GoogleKey         string
-	GoogleSecret      string
-	GoogleUIURL       string
-	GoogleCallBackURL string
-	APIHost           string
+	Log         *logger.Logger
+	Auth        *auth.Auth
+	AuthBus     *authbus.Business
+	AuditBus    *auditbus.Business
+	IdentityBus *identitybus.Business
+	TokenKey    string
+	UIURL       string
+	Providers   []goth.Provider
+	Provision   bool
+	RefreshTTL  time.Duration
+	SessionTTL  time.Duration
+	MFARoles    []role.Role
 }
 
 // Routes adds the routes for the auth app.
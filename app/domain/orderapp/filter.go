@@ -0,0 +1,131 @@
+package orderapp
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/app/sdk/query"
+	"github.com/rmsj/service/business/domain/orderbus"
+	"github.com/rmsj/service/business/types/orderstatus"
+)
+
+var filterByFields = query.Fields{
+	"order_id":   {Code: orderbus.FilterByOrderID, Parse: query.UUID},
+	"user_id":    {Code: orderbus.FilterByUserID, Parse: query.UUID},
+	"status":     {Code: orderbus.FilterByStatus, Parse: parseStatus},
+	"created_at": {Code: orderbus.FilterByDateCreated, Parse: query.Time},
+	"updated_at": {Code: orderbus.FilterByDateUpdated, Parse: query.Time},
+}
+
+type queryParams struct {
+	Page             string
+	Rows             string
+	OrderBy          string
+	Filter           string
+	ID               string
+	UserID           string
+	Status           string
+	StartCreatedDate string
+	EndCreatedDate   string
+}
+
+func parseQueryParams(r *http.Request) queryParams {
+	values := r.URL.Query()
+
+	filter := queryParams{
+		Page:             values.Get("page"),
+		Rows:             values.Get("rows"),
+		OrderBy:          values.Get("orderBy"),
+		Filter:           values.Get("filter"),
+		ID:               values.Get("order_id"),
+		UserID:           values.Get("user_id"),
+		Status:           values.Get("status"),
+		StartCreatedDate: values.Get("start_created_date"),
+		EndCreatedDate:   values.Get("end_created_date"),
+	}
+
+	return filter
+}
+
+func parseFilter(qp queryParams) (orderbus.QueryFilter, error) {
+	var fieldErrors errs.FieldErrors
+	var filter orderbus.QueryFilter
+
+	if qp.ID != "" {
+		id, err := uuid.Parse(qp.ID)
+		switch err {
+		case nil:
+			filter.ID = &id
+		default:
+			fieldErrors.Add("order_id", err)
+		}
+	}
+
+	if qp.UserID != "" {
+		id, err := uuid.Parse(qp.UserID)
+		switch err {
+		case nil:
+			filter.UserID = &id
+		default:
+			fieldErrors.Add("user_id", err)
+		}
+	}
+
+	if qp.Status != "" {
+		status, err := orderstatus.Parse(qp.Status)
+		switch err {
+		case nil:
+			filter.Status = &status
+		default:
+			fieldErrors.Add("status", err)
+		}
+	}
+
+	if qp.StartCreatedDate != "" {
+		t, err := time.Parse(time.RFC3339, qp.StartCreatedDate)
+		switch err {
+		case nil:
+			filter.StartCreatedDate = &t
+		default:
+			fieldErrors.Add("start_created_date", err)
+		}
+	}
+
+	if qp.EndCreatedDate != "" {
+		t, err := time.Parse(time.RFC3339, qp.EndCreatedDate)
+		switch err {
+		case nil:
+			filter.EndCreatedDate = &t
+		default:
+			fieldErrors.Add("end_created_date", err)
+		}
+	}
+
+	if qp.Filter != "" {
+		expr, err := query.ParseFilter(qp.Filter, filterByFields)
+		switch err {
+		case nil:
+			filter.Expr = expr
+		default:
+			fieldErrors.Add("filter", err)
+		}
+	}
+
+	if fieldErrors != nil {
+		return orderbus.QueryFilter{}, fieldErrors.ToError()
+	}
+
+	return filter, nil
+}
+
+// parseStatus parses the status values of the filter.
+func parseStatus(value string) (any, error) {
+	status, err := orderstatus.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return status.String(), nil
+}
@@ -0,0 +1,219 @@
+package orderapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/app/sdk/mid"
+	"github.com/rmsj/service/business/domain/orderbus"
+	"github.com/rmsj/service/business/types/orderstatus"
+	"github.com/rmsj/service/business/types/quantity"
+)
+
+// Order represents information about an individual order.
+type Order struct {
+	ID          string `json:"id"`
+	UserID      string `json:"userID"`
+	Status      string `json:"status"`
+	Items       []Item `json:"items"`
+	Total       string `json:"total"`
+	Currency    string `json:"currency"`
+	DateCreated string `json:"dateCreated"`
+	DateUpdated string `json:"dateUpdated"`
+}
+
+// Item represents information about a line item inside an order.
+type Item struct {
+	ProductID string `json:"productID"`
+	Quantity  int    `json:"quantity"`
+	Price     string `json:"price"`
+	Currency  string `json:"currency"`
+}
+
+// Encode implements the encoder interface.
+func (app Order) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(app)
+	return data, "application/json", err
+}
+
+func toAppOrder(ord orderbus.Order) (Order, error) {
+	items := make([]Item, len(ord.Items))
+	for i, itm := range ord.Items {
+		items[i] = Item{
+			ProductID: itm.ProductID.String(),
+			Quantity:  itm.Quantity.Value(),
+			Price:     itm.Price.String(),
+			Currency:  itm.Price.Currency().Code(),
+		}
+	}
+
+	total, err := ord.Total()
+	if err != nil {
+		return Order{}, fmt.Errorf("total: %w", err)
+	}
+
+	app := Order{
+		ID:          ord.ID.String(),
+		UserID:      ord.UserID.String(),
+		Status:      ord.Status.String(),
+		Items:       items,
+		Total:       total.String(),
+		Currency:    total.Currency().Code(),
+		DateCreated: ord.DateCreated.Format(time.RFC3339),
+		DateUpdated: ord.DateUpdated.Format(time.RFC3339),
+	}
+
+	return app, nil
+}
+
+func toAppOrders(ords []orderbus.Order) ([]Order, error) {
+	app := make([]Order, len(ords))
+	for i, ord := range ords {
+		var err error
+		app[i], err = toAppOrder(ord)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return app, nil
+}
+
+// =============================================================================
+
+// NewItem defines the data needed to add a line item to an order.
+type NewItem struct {
+	ProductID string `json:"productID" validate:"required,uuid"`
+	Quantity  int    `json:"quantity" validate:"required,gte=1"`
+}
+
+func toBusNewItems(app []NewItem) ([]orderbus.NewItem, error) {
+	bus := make([]orderbus.NewItem, len(app))
+	for i, itm := range app {
+		productID, err := uuid.Parse(itm.ProductID)
+		if err != nil {
+			return nil, fmt.Errorf("parse productID: %w", err)
+		}
+
+		qty, err := quantity.Parse(itm.Quantity)
+		if err != nil {
+			return nil, fmt.Errorf("parse quantity: %w", err)
+		}
+
+		bus[i] = orderbus.NewItem{
+			ProductID: productID,
+			Quantity:  qty,
+		}
+	}
+
+	return bus, nil
+}
+
+// =============================================================================
+
+// NewOrder defines the data needed to add a new order.
+type NewOrder struct {
+	Items []NewItem `json:"items" validate:"required,min=1,dive"`
+}
+
+// Decode implements the decoder interface.
+func (app *NewOrder) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app NewOrder) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
+
+func toBusNewOrder(ctx context.Context, app NewOrder) (orderbus.NewOrder, error) {
+	userID, err := mid.GetUserID(ctx)
+	if err != nil {
+		return orderbus.NewOrder{}, fmt.Errorf("getuserid: %w", err)
+	}
+
+	items, err := toBusNewItems(app.Items)
+	if err != nil {
+		return orderbus.NewOrder{}, err
+	}
+
+	bus := orderbus.NewOrder{
+		UserID: userID,
+		Items:  items,
+	}
+
+	return bus, nil
+}
+
+// =============================================================================
+
+// UpdateOrder defines the data needed to update a draft order.
+type UpdateOrder struct {
+	Items []NewItem `json:"items" validate:"omitempty,dive"`
+}
+
+// Decode implements the decoder interface.
+func (app *UpdateOrder) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app UpdateOrder) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
+
+func toBusUpdateOrder(app UpdateOrder) (orderbus.UpdateOrder, error) {
+	var bus orderbus.UpdateOrder
+
+	if app.Items != nil {
+		items, err := toBusNewItems(app.Items)
+		if err != nil {
+			return orderbus.UpdateOrder{}, err
+		}
+		bus.Items = items
+	}
+
+	return bus, nil
+}
+
+// =============================================================================
+
+// UpdateStatus defines the data needed to move an order to a new status.
+type UpdateStatus struct {
+	Status string `json:"status" validate:"required"`
+}
+
+// Decode implements the decoder interface.
+func (app *UpdateStatus) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app UpdateStatus) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
+
+func toBusStatus(app UpdateStatus) (orderstatus.Status, error) {
+	status, err := orderstatus.Parse(app.Status)
+	if err != nil {
+		return orderstatus.Status{}, fmt.Errorf("parse status: %w", err)
+	}
+
+	return status, nil
+}
@@ -0,0 +1,300 @@
+// Package orderapp maintains the app layer api for the order domain.
+package orderapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/app/sdk/mid"
+	"github.com/rmsj/service/app/sdk/query"
+	"github.com/rmsj/service/business/domain/auditbus"
+	"github.com/rmsj/service/business/domain/orderbus"
+	"github.com/rmsj/service/business/domain/productbus"
+	"github.com/rmsj/service/business/sdk/order"
+	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/business/types/orderstatus"
+	"github.com/rmsj/service/business/types/role"
+	"github.com/rmsj/service/foundation/web"
+)
+
+// auditDomain is the domain the changes made by this api are audited under.
+const auditDomain = "order"
+
+type app struct {
+	orderBus *orderbus.Business
+	auditBus *auditbus.Business
+}
+
+func newApp(orderBus *orderbus.Business, auditBus *auditbus.Business) *app {
+	return &app{
+		orderBus: orderBus,
+		auditBus: auditBus,
+	}
+}
+
+// newWithTx constructs a new Handlers value with the domain apis
+// using a store transaction that was created via middleware.
+func (a *app) newWithTx(ctx context.Context) (*app, error) {
+	tx, err := mid.GetTran(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orderBus, err := a.orderBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	auditBus, err := a.auditBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	app := app{
+		orderBus: orderBus,
+		auditBus: auditBus,
+	}
+
+	return &app, nil
+}
+
+func (a *app) create(ctx context.Context, r *http.Request) web.Encoder {
+	var app NewOrder
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	a, err := a.newWithTx(ctx)
+	if err != nil {
+		return errs.New(errs.Internal, err)
+	}
+
+	no, err := toBusNewOrder(ctx, app)
+	if err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	ord, err := a.orderBus.Create(ctx, no)
+	if err != nil {
+		return toAppError(err, "create: ord[%+v]: %s", app, err)
+	}
+
+	if err := a.audit(ctx, r, "create", ord.ID, nil, &ord); err != nil {
+		return err
+	}
+
+	return toAppOrderEncoder(ord)
+}
+
+func (a *app) update(ctx context.Context, r *http.Request) web.Encoder {
+	var app UpdateOrder
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	a, err := a.newWithTx(ctx)
+	if err != nil {
+		return errs.New(errs.Internal, err)
+	}
+
+	uo, err := toBusUpdateOrder(app)
+	if err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	ord, err := mid.GetOrder(ctx)
+	if err != nil {
+		return errs.Newf(errs.Internal, "order missing in context: %s", err)
+	}
+
+	updOrd, err := a.orderBus.Update(ctx, ord, uo)
+	if err != nil {
+		return toAppError(err, "update: orderID[%s] uo[%+v]: %s", ord.ID, app, err)
+	}
+
+	if err := a.audit(ctx, r, "update", ord.ID, &ord, &updOrd); err != nil {
+		return err
+	}
+
+	return toAppOrderEncoder(updOrd)
+}
+
+func (a *app) updateStatus(ctx context.Context, r *http.Request) web.Encoder {
+	var app UpdateStatus
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	a, err := a.newWithTx(ctx)
+	if err != nil {
+		return errs.New(errs.Internal, err)
+	}
+
+	status, err := toBusStatus(app)
+	if err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	// The owner of an order can place or cancel it, but only the staff can
+	// record that it was paid or shipped.
+	if status == orderstatus.Paid || status == orderstatus.Shipped {
+		roles := mid.GetClaims(ctx).Roles
+		if !slices.Contains(roles, role.Admin.String()) && !slices.Contains(roles, role.Staff.String()) {
+			return errs.Newf(errs.Unauthenticated, "only staff can set the order status to %s", status)
+		}
+	}
+
+	ord, err := mid.GetOrder(ctx)
+	if err != nil {
+		return errs.Newf(errs.Internal, "order missing in context: %s", err)
+	}
+
+	updOrd, err := a.orderBus.UpdateStatus(ctx, ord, status)
+	if err != nil {
+		return toAppError(err, "updatestatus: orderID[%s] status[%s]: %s", ord.ID, status, err)
+	}
+
+	if err := a.audit(ctx, r, "update_status", ord.ID, &ord, &updOrd); err != nil {
+		return err
+	}
+
+	return toAppOrderEncoder(updOrd)
+}
+
+func (a *app) delete(ctx context.Context, r *http.Request) web.Encoder {
+	a, err := a.newWithTx(ctx)
+	if err != nil {
+		return errs.New(errs.Internal, err)
+	}
+
+	ord, err := mid.GetOrder(ctx)
+	if err != nil {
+		return errs.Newf(errs.Internal, "orderID missing in context: %s", err)
+	}
+
+	if err := a.orderBus.Delete(ctx, ord); err != nil {
+		return toAppError(err, "delete: orderID[%s]: %s", ord.ID, err)
+	}
+
+	if err := a.audit(ctx, r, "delete", ord.ID, &ord, nil); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
+	qp := parseQueryParams(r)
+
+	page, err := page.Parse(qp.Page, qp.Rows)
+	if err != nil {
+		return errs.NewFieldErrors("page", err)
+	}
+
+	filter, err := parseFilter(qp)
+	if err != nil {
+		return err.(*errs.Error)
+	}
+
+	// Only admins can see the orders of other users.
+	if !slices.Contains(mid.GetClaims(ctx).Roles, role.Admin.String()) {
+		userID := mid.GetSubjectID(ctx)
+		filter.UserID = &userID
+	}
+
+	orderBy, err := order.Parse(orderByFields, qp.OrderBy, orderbus.DefaultOrderBy)
+	if err != nil {
+		return errs.NewFieldErrors("order", err)
+	}
+
+	ords, err := a.orderBus.Query(ctx, filter, orderBy, page)
+	if err != nil {
+		return errs.Newf(errs.Internal, "query: %s", err)
+	}
+
+	total, err := a.orderBus.Count(ctx, filter)
+	if err != nil {
+		return errs.Newf(errs.Internal, "count: %s", err)
+	}
+
+	items, err := toAppOrders(ords)
+	if err != nil {
+		return errs.Newf(errs.Internal, "toapporders: %s", err)
+	}
+
+	return query.NewResult(items, total, page)
+}
+
+func (a *app) queryByID(ctx context.Context, _ *http.Request) web.Encoder {
+	ord, err := mid.GetOrder(ctx)
+	if err != nil {
+		return errs.Newf(errs.Internal, "querybyid: %s", err)
+	}
+
+	return toAppOrderEncoder(ord)
+}
+
+// =============================================================================
+
+// toAppOrderEncoder converts the order for the response, reporting an
+// internal error if the order total can't be calculated.
+func toAppOrderEncoder(ord orderbus.Order) web.Encoder {
+	app, err := toAppOrder(ord)
+	if err != nil {
+		return errs.Newf(errs.Internal, "toapporder: orderID[%s]: %s", ord.ID, err)
+	}
+
+	return app
+}
+
+// audit records the change made to the order, in the transaction of the
+// change.
+func (a *app) audit(ctx context.Context, r *http.Request, action string, orderID uuid.UUID, before *orderbus.Order, after *orderbus.Order) *errs.Error {
+	var appBefore, appAfter any
+
+	if before != nil {
+		app, err := toAppOrder(*before)
+		if err != nil {
+			return errs.Newf(errs.Internal, "toapporder: orderID[%s]: %s", orderID, err)
+		}
+		appBefore = app
+	}
+
+	if after != nil {
+		app, err := toAppOrder(*after)
+		if err != nil {
+			return errs.Newf(errs.Internal, "toapporder: orderID[%s]: %s", orderID, err)
+		}
+		appAfter = app
+	}
+
+	if _, err := a.auditBus.Create(ctx, mid.NewAudit(ctx, r, auditDomain, action, orderID.String(), appBefore, appAfter)); err != nil {
+		return errs.Newf(errs.Internal, "audit: orderID[%s]: %s", orderID, err)
+	}
+
+	return nil
+}
+
+// toAppError maps the business errors a client can act on to the proper
+// error code, anything else is considered an internal error.
+func toAppError(err error, format string, v ...any) *errs.Error {
+	switch {
+	case errors.Is(err, productbus.ErrInsufficient),
+		errors.Is(err, orderbus.ErrInvalidTransition),
+		errors.Is(err, orderbus.ErrNotDraft),
+		errors.Is(err, orderbus.ErrNoItems),
+		errors.Is(err, orderbus.ErrCurrencyMismatch),
+		errors.Is(err, orderbus.ErrUserDisabled):
+		return errs.New(errs.FailedPrecondition, err)
+
+	case errors.Is(err, productbus.ErrNotFound):
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	return errs.Newf(errs.Internal, format, v...)
+}
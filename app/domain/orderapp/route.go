@@ -0,0 +1,48 @@
+package orderapp
+
+import (
+	"net/http"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/rmsj/service/app/sdk/auth"
+	"github.com/rmsj/service/app/sdk/authclient"
+	"github.com/rmsj/service/app/sdk/mid"
+	"github.com/rmsj/service/business/domain/auditbus"
+	"github.com/rmsj/service/business/domain/orderbus"
+	"github.com/rmsj/service/business/sdk/ratelimit"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/foundation/logger"
+	"github.com/rmsj/service/foundation/web"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Log         *logger.Logger
+	DB          *sqlx.DB
+	OrderBus    *orderbus.Business
+	AuditBus    *auditbus.Business
+	AuthClient  *authclient.Client
+	RateLimiter *ratelimit.Limiter
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	authen := mid.Authenticate(cfg.AuthClient)
+	rateLimit := mid.RateLimit(cfg.RateLimiter, "orders")
+	transaction := mid.BeginCommitRollback(cfg.Log, sqldb.NewBeginner(cfg.DB))
+	ruleAny := mid.Authorize(cfg.AuthClient, auth.RuleAny)
+	ruleUserOnly := mid.Authorize(cfg.AuthClient, auth.RuleUserOnly)
+	ruleAuthorizeOrder := mid.AuthorizeOrder(cfg.AuthClient, cfg.OrderBus)
+
+	api := newApp(cfg.OrderBus, cfg.AuditBus)
+
+	app.HandlerFunc(http.MethodGet, version, "/orders", api.query, authen, rateLimit, ruleAny)
+	app.HandlerFunc(http.MethodGet, version, "/orders/{order_id}", api.queryByID, authen, rateLimit, ruleAuthorizeOrder)
+	app.HandlerFunc(http.MethodPost, version, "/orders", api.create, authen, rateLimit, ruleUserOnly, transaction)
+	app.HandlerFunc(http.MethodPut, version, "/orders/{order_id}", api.update, authen, rateLimit, ruleAuthorizeOrder, transaction)
+	app.HandlerFunc(http.MethodPut, version, "/orders/{order_id}/status", api.updateStatus, authen, rateLimit, ruleAuthorizeOrder, transaction)
+	app.HandlerFunc(http.MethodDelete, version, "/orders/{order_id}", api.delete, authen, rateLimit, ruleAuthorizeOrder, transaction)
+}
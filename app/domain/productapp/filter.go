@@ -6,17 +6,33 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/app/sdk/query"
 	"github.com/rmsj/service/business/domain/productbus"
+	"github.com/rmsj/service/business/types/money"
 	"github.com/rmsj/service/business/types/name"
 )
 
+var filterByFields = query.Fields{
+	"product_id": {Code: productbus.FilterByProductID, Parse: query.UUID},
+	"user_id":    {Code: productbus.FilterByUserID, Parse: query.UUID},
+	"name":       {Code: productbus.FilterByName, Parse: query.Text},
+	"cost":       {Code: productbus.FilterByCost, Parse: query.Decimal},
+	"currency":   {Code: productbus.FilterByCurrency, Parse: query.Text},
+	"quantity":   {Code: productbus.FilterByQuantity, Parse: query.Int},
+	"created_at": {Code: productbus.FilterByDateCreated, Parse: query.Time},
+	"updated_at": {Code: productbus.FilterByDateUpdated, Parse: query.Time},
+}
+
 type queryParams struct {
 	Page     string
 	Rows     string
+	Cursor   string
 	OrderBy  string
+	Filter   string
 	ID       string
 	Name     string
 	Cost     string
+	Currency string
 	Quantity string
 }
 
@@ -26,10 +42,13 @@ func parseQueryParams(r *http.Request) queryParams {
 	filter := queryParams{
 		Page:     values.Get("page"),
 		Rows:     values.Get("rows"),
+		Cursor:   values.Get("cursor"),
 		OrderBy:  values.Get("orderBy"),
+		Filter:   values.Get("filter"),
 		ID:       values.Get("product_id"),
 		Name:     values.Get("name"),
 		Cost:     values.Get("cost"),
+		Currency: values.Get("currency"),
 		Quantity: values.Get("quantity"),
 	}
 
@@ -61,7 +80,12 @@ func parseFilter(qp queryParams) (productbus.QueryFilter, error) {
 	}
 
 	if qp.Cost != "" {
-		cst, err := strconv.ParseFloat(qp.Cost, 64)
+		currency := qp.Currency
+		if currency == "" {
+			currency = money.DefaultCurrency.Code()
+		}
+
+		cst, err := money.Parse(qp.Cost, currency)
 		switch err {
 		case nil:
 			filter.Cost = &cst
@@ -81,6 +105,16 @@ func parseFilter(qp queryParams) (productbus.QueryFilter, error) {
 		}
 	}
 
+	if qp.Filter != "" {
+		expr, err := query.ParseFilter(qp.Filter, filterByFields)
+		switch err {
+		case nil:
+			filter.Expr = expr
+		default:
+			fieldErrors.Add("filter", err)
+		}
+	}
+
 	if fieldErrors != nil {
 		return productbus.QueryFilter{}, fieldErrors.ToError()
 	}
@@ -3,6 +3,7 @@ package productapp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -16,13 +17,14 @@ import (
 
 // Product represents information about an individual product.
 type Product struct {
-	ID          string  `json:"id"`
-	UserID      string  `json:"userID"`
-	Name        string  `json:"name"`
-	Cost        float64 `json:"cost"`
-	Quantity    int     `json:"quantity"`
-	DateCreated string  `json:"dateCreated"`
-	DateUpdated string  `json:"dateUpdated"`
+	ID          string `json:"id"`
+	UserID      string `json:"userID"`
+	Name        string `json:"name"`
+	Cost        string `json:"cost"`
+	Currency    string `json:"currency"`
+	Quantity    int    `json:"quantity"`
+	DateCreated string `json:"dateCreated"`
+	DateUpdated string `json:"dateUpdated"`
 }
 
 // Encode implements the encoder interface.
@@ -36,7 +38,8 @@ func toAppProduct(prd productbus.Product) Product {
 		ID:          prd.ID.String(),
 		UserID:      prd.UserID.String(),
 		Name:        prd.Name.String(),
-		Cost:        prd.Cost.Value(),
+		Cost:        prd.Cost.String(),
+		Currency:    prd.Cost.Currency().Code(),
 		Quantity:    prd.Quantity.Value(),
 		DateCreated: prd.DateCreated.Format(time.RFC3339),
 		DateUpdated: prd.DateUpdated.Format(time.RFC3339),
@@ -56,9 +59,10 @@ func toAppProducts(prds []productbus.Product) []Product {
 
 // NewProduct defines the data needed to add a new product.
 type NewProduct struct {
-	Name     string  `json:"name" validate:"required"`
-	Cost     float64 `json:"cost" validate:"required,gte=0"`
-	Quantity int     `json:"quantity" validate:"required,gte=1"`
+	Name     string `json:"name" validate:"required"`
+	Cost     string `json:"cost" validate:"required,numeric"`
+	Currency string `json:"currency"`
+	Quantity int    `json:"quantity" validate:"required,gte=1"`
 }
 
 // Decode implements the decoder interface.
@@ -86,7 +90,12 @@ func toBusNewProduct(ctx context.Context, app NewProduct) (productbus.NewProduct
 		return productbus.NewProduct{}, fmt.Errorf("parse name: %w", err)
 	}
 
-	cost, err := money.Parse(app.Cost)
+	currency := app.Currency
+	if currency == "" {
+		currency = money.DefaultCurrency.Code()
+	}
+
+	cost, err := money.Parse(app.Cost, currency)
 	if err != nil {
 		return productbus.NewProduct{}, fmt.Errorf("parse cost: %w", err)
 	}
@@ -110,9 +119,10 @@ func toBusNewProduct(ctx context.Context, app NewProduct) (productbus.NewProduct
 
 // UpdateProduct defines the data needed to update a product.
 type UpdateProduct struct {
-	Name     *string  `json:"name"`
-	Cost     *float64 `json:"cost" validate:"omitempty,gte=0"`
-	Quantity *int     `json:"quantity" validate:"omitempty,gte=1"`
+	Name     *string `json:"name"`
+	Cost     *string `json:"cost" validate:"omitempty,numeric"`
+	Currency *string `json:"currency"`
+	Quantity *int    `json:"quantity" validate:"omitempty,gte=1"`
 }
 
 // Decode implements the decoder interface.
@@ -139,9 +149,18 @@ func toBusUpdateProduct(app UpdateProduct) (productbus.UpdateProduct, error) {
 		nme = &nm
 	}
 
+	if app.Currency != nil && app.Cost == nil {
+		return productbus.UpdateProduct{}, errors.New("parse: currency requires a cost")
+	}
+
 	var cost *money.Money
 	if app.Cost != nil {
-		cst, err := money.Parse(*app.Cost)
+		currency := money.DefaultCurrency.Code()
+		if app.Currency != nil {
+			currency = *app.Currency
+		}
+
+		cst, err := money.Parse(*app.Cost, currency)
 		if err != nil {
 			return productbus.UpdateProduct{}, fmt.Errorf("parse: %w", err)
 		}
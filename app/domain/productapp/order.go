@@ -11,3 +11,20 @@ var orderByFields = map[string]string{
 	"quantity":   productbus.OrderByQuantity,
 	"user_id":    productbus.OrderByUserID,
 }
+
+// cursorKey returns the value of the order field and the ID of the product,
+// which is where the product is in the ordered results.
+func cursorKey(prd productbus.Product, field string) (any, string) {
+	switch field {
+	case productbus.OrderByUserID:
+		return prd.UserID.String(), prd.ID.String()
+	case productbus.OrderByName:
+		return prd.Name.String(), prd.ID.String()
+	case productbus.OrderByCost:
+		return prd.Cost.String(), prd.ID.String()
+	case productbus.OrderByQuantity:
+		return prd.Quantity.Value(), prd.ID.String()
+	}
+
+	return prd.ID.String(), prd.ID.String()
+}
@@ -3,28 +3,65 @@ package productapp
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/rmsj/service/app/sdk/errs"
 	"github.com/rmsj/service/app/sdk/mid"
 	"github.com/rmsj/service/app/sdk/query"
+	"github.com/rmsj/service/business/domain/auditbus"
 	"github.com/rmsj/service/business/domain/productbus"
 	"github.com/rmsj/service/business/sdk/order"
-	"github.com/rmsj/service/business/sdk/page"
 	"github.com/rmsj/service/foundation/web"
 )
 
+// auditDomain is the domain the changes made by this api are audited under.
+const auditDomain = "product"
+
 type app struct {
 	productBus *productbus.Business
+	auditBus   *auditbus.Business
 }
 
-func newApp(productBus *productbus.Business) *app {
+func newApp(productBus *productbus.Business, auditBus *auditbus.Business) *app {
 	return &app{
 		productBus: productBus,
+		auditBus:   auditBus,
+	}
+}
+
+// newWithTx constructs a new Handlers value with the domain apis
+// using a store transaction that was created via middleware.
+func (a *app) newWithTx(ctx context.Context) (*app, error) {
+	tx, err := mid.GetTran(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	productBus, err := a.productBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	auditBus, err := a.auditBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	app := app{
+		productBus: productBus,
+		auditBus:   auditBus,
 	}
+
+	return &app, nil
 }
 
 func (a *app) create(ctx context.Context, r *http.Request) web.Encoder {
+	a, err := a.newWithTx(ctx)
+	if err != nil {
+		return errs.New(errs.Internal, err)
+	}
+
 	var app NewProduct
 	if err := web.Decode(r, &app); err != nil {
 		return errs.New(errs.InvalidArgument, err)
@@ -40,10 +77,21 @@ func (a *app) create(ctx context.Context, r *http.Request) web.Encoder {
 		return errs.Newf(errs.Internal, "create: prd[%+v]: %s", prd, err)
 	}
 
-	return toAppProduct(prd)
+	appPrd := toAppProduct(prd)
+
+	if _, err := a.auditBus.Create(ctx, mid.NewAudit(ctx, r, auditDomain, "create", appPrd.ID, nil, appPrd)); err != nil {
+		return errs.Newf(errs.Internal, "audit: productID[%s]: %s", prd.ID, err)
+	}
+
+	return appPrd
 }
 
 func (a *app) update(ctx context.Context, r *http.Request) web.Encoder {
+	a, err := a.newWithTx(ctx)
+	if err != nil {
+		return errs.New(errs.Internal, err)
+	}
+
 	var app UpdateProduct
 	if err := web.Decode(r, &app); err != nil {
 		return errs.New(errs.InvalidArgument, err)
@@ -59,15 +107,35 @@ func (a *app) update(ctx context.Context, r *http.Request) web.Encoder {
 		return errs.Newf(errs.Internal, "product missing in context: %s", err)
 	}
 
+	if !web.IfMatch(r, web.ETag(prd.Version)) {
+		return errs.New(errs.PreconditionFailed, productbus.ErrConflict)
+	}
+
 	updPrd, err := a.productBus.Update(ctx, prd, up)
 	if err != nil {
+		if errors.Is(err, productbus.ErrConflict) {
+			return errs.New(errs.Aborted, productbus.ErrConflict)
+		}
 		return errs.Newf(errs.Internal, "update: productID[%s] up[%+v]: %s", prd.ID, app, err)
 	}
 
-	return toAppProduct(updPrd)
+	appPrd := toAppProduct(updPrd)
+
+	if _, err := a.auditBus.Create(ctx, mid.NewAudit(ctx, r, auditDomain, "update", appPrd.ID, toAppProduct(prd), appPrd)); err != nil {
+		return errs.Newf(errs.Internal, "audit: productID[%s]: %s", prd.ID, err)
+	}
+
+	web.SetETag(ctx, web.ETag(updPrd.Version))
+
+	return appPrd
 }
 
-func (a *app) delete(ctx context.Context, _ *http.Request) web.Encoder {
+func (a *app) delete(ctx context.Context, r *http.Request) web.Encoder {
+	a, err := a.newWithTx(ctx)
+	if err != nil {
+		return errs.New(errs.Internal, err)
+	}
+
 	prd, err := mid.GetProduct(ctx)
 	if err != nil {
 		return errs.Newf(errs.Internal, "productID missing in context: %s", err)
@@ -77,13 +145,17 @@ func (a *app) delete(ctx context.Context, _ *http.Request) web.Encoder {
 		return errs.Newf(errs.Internal, "delete: productID[%s]: %s", prd.ID, err)
 	}
 
+	if _, err := a.auditBus.Create(ctx, mid.NewAudit(ctx, r, auditDomain, "delete", prd.ID.String(), toAppProduct(prd), nil)); err != nil {
+		return errs.Newf(errs.Internal, "audit: productID[%s]: %s", prd.ID, err)
+	}
+
 	return nil
 }
 
 func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
 	qp := parseQueryParams(r)
 
-	page, err := page.Parse(qp.Page, qp.Rows)
+	page, err := query.ParsePage(qp.Page, qp.Rows, qp.Cursor)
 	if err != nil {
 		return errs.NewFieldErrors("page", err)
 	}
@@ -93,22 +165,32 @@ func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
 		return err.(*errs.Error)
 	}
 
-	orderBy, err := order.Parse(orderByFields, qp.OrderBy, productbus.DefaultOrderBy)
+	orderBy, err := order.Parse(orderByFields, qp.OrderBy, page.OrderBy(productbus.DefaultOrderBy))
 	if err != nil {
 		return errs.NewFieldErrors("order", err)
 	}
 
+	if err := page.Check(orderBy); err != nil {
+		return errs.NewFieldErrors("cursor", err)
+	}
+
 	prds, err := a.productBus.Query(ctx, filter, orderBy, page)
 	if err != nil {
 		return errs.Newf(errs.Internal, "query: %s", err)
 	}
 
+	prev, next := query.Cursors(prds, page, orderBy, cursorKey)
+
+	if _, ok := page.Cursor(); ok {
+		return query.NewCursorResult(toAppProducts(prds), page).WithCursors(prev, next)
+	}
+
 	total, err := a.productBus.Count(ctx, filter)
 	if err != nil {
 		return errs.Newf(errs.Internal, "count: %s", err)
 	}
 
-	return query.NewResult(toAppProducts(prds), total, page)
+	return query.NewResult(toAppProducts(prds), total, page).WithCursors(prev, next)
 }
 
 func (a *app) queryByID(ctx context.Context, r *http.Request) web.Encoder {
@@ -117,5 +199,12 @@ func (a *app) queryByID(ctx context.Context, r *http.Request) web.Encoder {
 		return errs.Newf(errs.Internal, "querybyid: %s", err)
 	}
 
+	etag := web.ETag(prd.Version)
+	web.SetETag(ctx, etag)
+
+	if !web.IfNoneMatch(r, etag) {
+		return web.NewNotModified()
+	}
+
 	return toAppProduct(prd)
 }
@@ -2,22 +2,35 @@ package productapp
 
 import (
 	"net/http"
+	"time"
+
+	"github.com/jmoiron/sqlx"
 
 	"github.com/rmsj/service/app/sdk/auth"
 	"github.com/rmsj/service/app/sdk/authclient"
 	"github.com/rmsj/service/app/sdk/mid"
+	"github.com/rmsj/service/business/domain/auditbus"
+	"github.com/rmsj/service/business/domain/idempotencybus"
 	"github.com/rmsj/service/business/domain/productbus"
 	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/sdk/ratelimit"
+	"github.com/rmsj/service/business/sdk/sqldb"
 	"github.com/rmsj/service/foundation/logger"
 	"github.com/rmsj/service/foundation/web"
 )
 
 // Config contains all the mandatory systems required by handlers.
 type Config struct {
-	Log        *logger.Logger
-	UserBus    *userbus.Business
-	ProductBus *productbus.Business
-	AuthClient *authclient.Client
+	Log         *logger.Logger
+	DB          *sqlx.DB
+	UserBus     *userbus.Business
+	ProductBus  *productbus.Business
+	AuditBus    *auditbus.Business
+	AuthClient  *authclient.Client
+	RateLimiter *ratelimit.Limiter
+
+	IdempotencyBus *idempotencybus.Business
+	IdempotencyTTL time.Duration
 }
 
 // Routes adds specific routes for this group.
@@ -25,15 +38,21 @@ func Routes(app *web.App, cfg Config) {
 	const version = "v1"
 
 	authen := mid.Authenticate(cfg.AuthClient)
-	ruleAny := mid.Authorize(cfg.AuthClient, auth.RuleAny)
-	ruleUserOnly := mid.Authorize(cfg.AuthClient, auth.RuleUserOnly)
-	ruleAuthorizeProduct := mid.AuthorizeProduct(cfg.AuthClient, cfg.ProductBus)
+	rateLimit := mid.RateLimit(cfg.RateLimiter, "products")
+	transaction := mid.BeginCommitRollback(cfg.Log, sqldb.NewBeginner(cfg.DB))
+	idempotency := mid.Idempotency(cfg.IdempotencyBus, cfg.IdempotencyTTL)
+	loadProduct := mid.LoadProduct(cfg.ProductBus)
+	permList := mid.RequirePermission(cfg.AuthClient, auth.PermProductList)
+	permRead := mid.RequirePermission(cfg.AuthClient, auth.PermProductRead)
+	permCreate := mid.RequirePermission(cfg.AuthClient, auth.PermProductCreate)
+	permWrite := mid.RequirePermission(cfg.AuthClient, auth.PermProductWrite)
+	permDelete := mid.RequirePermission(cfg.AuthClient, auth.PermProductDelete)
 
-	api := newApp(cfg.ProductBus)
+	api := newApp(cfg.ProductBus, cfg.AuditBus)
 
-	app.HandlerFunc(http.MethodGet, version, "/products", api.query, authen, ruleAny)
-	app.HandlerFunc(http.MethodGet, version, "/products/{product_id}", api.queryByID, authen, ruleAuthorizeProduct)
-	app.HandlerFunc(http.MethodPost, version, "/products", api.create, authen, ruleUserOnly)
-	app.HandlerFunc(http.MethodPut, version, "/products/{product_id}", api.update, authen, ruleAuthorizeProduct)
-	app.HandlerFunc(http.MethodDelete, version, "/products/{product_id}", api.delete, authen, ruleAuthorizeProduct)
+	app.HandlerFunc(http.MethodGet, version, "/products", api.query, authen, rateLimit, permList)
+	app.HandlerFunc(http.MethodGet, version, "/products/{product_id}", api.queryByID, authen, rateLimit, loadProduct, permRead)
+	app.HandlerFunc(http.MethodPost, version, "/products", api.create, authen, rateLimit, permCreate, transaction, idempotency)
+	app.HandlerFunc(http.MethodPut, version, "/products/{product_id}", api.update, authen, rateLimit, loadProduct, permWrite, transaction)
+	app.HandlerFunc(http.MethodDelete, version, "/products/{product_id}", api.delete, authen, rateLimit, loadProduct, permDelete, transaction)
 }
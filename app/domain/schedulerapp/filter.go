@@ -0,0 +1,72 @@
+package schedulerapp
+
+import (
+	"net/http"
+
+	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/app/sdk/query"
+	"github.com/rmsj/service/business/sdk/scheduler"
+)
+
+var filterByFields = query.Fields{
+	"run_id":       {Code: scheduler.FilterByRunID, Parse: query.UUID},
+	"task":         {Code: scheduler.FilterByTask, Parse: query.Text},
+	"owner":        {Code: scheduler.FilterByOwner, Parse: query.Text},
+	"status":       {Code: scheduler.FilterByStatus, Parse: query.Text},
+	"scheduled_at": {Code: scheduler.FilterByScheduledAt, Parse: query.Time},
+	"started_at":   {Code: scheduler.FilterByStartedAt, Parse: query.Time},
+	"finished_at":  {Code: scheduler.FilterByFinishedAt, Parse: query.Time},
+}
+
+type queryParams struct {
+	Page    string
+	Rows    string
+	OrderBy string
+	Filter  string
+	Task    string
+	Status  string
+}
+
+func parseQueryParams(r *http.Request) queryParams {
+	values := r.URL.Query()
+
+	filter := queryParams{
+		Page:    values.Get("page"),
+		Rows:    values.Get("rows"),
+		OrderBy: values.Get("orderBy"),
+		Filter:  values.Get("filter"),
+		Task:    values.Get("task"),
+		Status:  values.Get("status"),
+	}
+
+	return filter
+}
+
+func parseFilter(qp queryParams) (scheduler.QueryFilter, error) {
+	var fieldErrors errs.FieldErrors
+	var filter scheduler.QueryFilter
+
+	if qp.Task != "" {
+		filter.Task = &qp.Task
+	}
+
+	if qp.Status != "" {
+		filter.Status = &qp.Status
+	}
+
+	if qp.Filter != "" {
+		expr, err := query.ParseFilter(qp.Filter, filterByFields)
+		switch err {
+		case nil:
+			filter.Expr = expr
+		default:
+			fieldErrors.Add("filter", err)
+		}
+	}
+
+	if fieldErrors != nil {
+		return scheduler.QueryFilter{}, fieldErrors.ToError()
+	}
+
+	return filter, nil
+}
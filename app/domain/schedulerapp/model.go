@@ -0,0 +1,76 @@
+package schedulerapp
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/rmsj/service/business/sdk/scheduler"
+)
+
+// Task represents a registered task.
+type Task struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+	NextRun  string `json:"nextRun"`
+}
+
+// Tasks represents the list of registered tasks.
+type Tasks []Task
+
+// Encode implements the encoder interface.
+func (app Tasks) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(app)
+	return data, "application/json", err
+}
+
+func toAppTasks(tasks []scheduler.Task) Tasks {
+	app := make(Tasks, len(tasks))
+	for i, t := range tasks {
+		app[i] = Task{
+			Name:     t.Name,
+			Schedule: t.Schedule,
+			NextRun:  t.Next.Format(time.RFC3339),
+		}
+	}
+
+	return app
+}
+
+// Run represents an entry in the run history of the tasks.
+type Run struct {
+	ID          string `json:"id"`
+	Task        string `json:"task"`
+	Owner       string `json:"owner"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+	ScheduledAt string `json:"scheduledAt"`
+	StartedAt   string `json:"startedAt"`
+	FinishedAt  string `json:"finishedAt,omitempty"`
+}
+
+func toAppRun(run scheduler.Run) Run {
+	app := Run{
+		ID:          run.ID.String(),
+		Task:        run.Task,
+		Owner:       run.Owner,
+		Status:      run.Status,
+		Error:       run.Error,
+		ScheduledAt: run.ScheduledAt.Format(time.RFC3339),
+		StartedAt:   run.StartedAt.Format(time.RFC3339),
+	}
+
+	if !run.FinishedAt.IsZero() {
+		app.FinishedAt = run.FinishedAt.Format(time.RFC3339)
+	}
+
+	return app
+}
+
+func toAppRuns(runs []scheduler.Run) []Run {
+	app := make([]Run, len(runs))
+	for i, run := range runs {
+		app[i] = toAppRun(run)
+	}
+
+	return app
+}
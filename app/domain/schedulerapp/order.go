@@ -0,0 +1,13 @@
+package schedulerapp
+
+import (
+	"github.com/rmsj/service/business/sdk/scheduler"
+)
+
+var orderByFields = map[string]string{
+	"run_id":       scheduler.OrderByRunID,
+	"task":         scheduler.OrderByTask,
+	"status":       scheduler.OrderByStatus,
+	"scheduled_at": scheduler.OrderByScheduledAt,
+	"started_at":   scheduler.OrderByStartedAt,
+}
@@ -0,0 +1,32 @@
+package schedulerapp
+
+import (
+	"net/http"
+
+	"github.com/rmsj/service/app/sdk/auth"
+	"github.com/rmsj/service/app/sdk/authclient"
+	"github.com/rmsj/service/app/sdk/mid"
+	"github.com/rmsj/service/business/sdk/scheduler"
+	"github.com/rmsj/service/foundation/logger"
+	"github.com/rmsj/service/foundation/web"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Log        *logger.Logger
+	Scheduler  *scheduler.Scheduler
+	AuthClient *authclient.Client
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	authen := mid.Authenticate(cfg.AuthClient)
+	ruleAdmin := mid.Authorize(cfg.AuthClient, auth.RuleAdminOnly)
+
+	api := newApp(cfg.Scheduler)
+
+	app.HandlerFunc(http.MethodGet, version, "/scheduler/tasks", api.queryTasks, authen, ruleAdmin)
+	app.HandlerFunc(http.MethodGet, version, "/scheduler/runs", api.queryRuns, authen, ruleAdmin)
+}
@@ -0,0 +1,59 @@
+// Package schedulerapp maintains the app layer api for the scheduled tasks.
+package schedulerapp
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/app/sdk/query"
+	"github.com/rmsj/service/business/sdk/order"
+	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/business/sdk/scheduler"
+	"github.com/rmsj/service/foundation/web"
+)
+
+type app struct {
+	scheduler *scheduler.Scheduler
+}
+
+func newApp(scheduler *scheduler.Scheduler) *app {
+	return &app{
+		scheduler: scheduler,
+	}
+}
+
+func (a *app) queryTasks(ctx context.Context, r *http.Request) web.Encoder {
+	return toAppTasks(a.scheduler.Tasks())
+}
+
+func (a *app) queryRuns(ctx context.Context, r *http.Request) web.Encoder {
+	qp := parseQueryParams(r)
+
+	page, err := page.Parse(qp.Page, qp.Rows)
+	if err != nil {
+		return errs.NewFieldErrors("page", err)
+	}
+
+	filter, err := parseFilter(qp)
+	if err != nil {
+		return err.(*errs.Error)
+	}
+
+	orderBy, err := order.Parse(orderByFields, qp.OrderBy, scheduler.DefaultOrderBy)
+	if err != nil {
+		return errs.NewFieldErrors("order", err)
+	}
+
+	runs, err := a.scheduler.QueryRuns(ctx, filter, orderBy, page)
+	if err != nil {
+		return errs.Newf(errs.Internal, "queryruns: %s", err)
+	}
+
+	total, err := a.scheduler.CountRuns(ctx, filter)
+	if err != nil {
+		return errs.Newf(errs.Internal, "countruns: %s", err)
+	}
+
+	return query.NewResult(toAppRuns(runs), total, page)
+}
@@ -17,13 +17,14 @@ import (
 
 // Product represents an individual product.
 type Product struct {
-	ID          string  `json:"id"`
-	UserID      string  `json:"userID"`
-	Name        string  `json:"name"`
-	Cost        float64 `json:"cost"`
-	Quantity    int     `json:"quantity"`
-	DateCreated string  `json:"dateCreated"`
-	DateUpdated string  `json:"dateUpdated"`
+	ID          string `json:"id"`
+	UserID      string `json:"userID"`
+	Name        string `json:"name"`
+	Cost        string `json:"cost"`
+	Currency    string `json:"currency"`
+	Quantity    int    `json:"quantity"`
+	DateCreated string `json:"dateCreated"`
+	DateUpdated string `json:"dateUpdated"`
 }
 
 // Encode implements the encoder interface.
@@ -37,7 +38,8 @@ func toAppProduct(prd productbus.Product) Product {
 		ID:          prd.ID.String(),
 		UserID:      prd.UserID.String(),
 		Name:        prd.Name.String(),
-		Cost:        prd.Cost.Value(),
+		Cost:        prd.Cost.String(),
+		Currency:    prd.Cost.Currency().Code(),
 		Quantity:    prd.Quantity.Value(),
 		DateCreated: prd.DateCreated.Format(time.RFC3339),
 		DateUpdated: prd.DateUpdated.Format(time.RFC3339),
@@ -109,12 +111,14 @@ func toBusNewUser(app NewUser) (userbus.NewUser, error) {
 		return userbus.NewUser{}, fmt.Errorf("parse: %w", err)
 	}
 
+	// users created by someone else are trusted with their email
 	bus := userbus.NewUser{
-		Name:       nme,
-		Email:      *addr,
-		Roles:      roles,
-		Department: department,
-		Password:   app.Password,
+		Name:          nme,
+		Email:         *addr,
+		Roles:         roles,
+		Department:    department,
+		Password:      app.Password,
+		EmailVerified: true,
 	}
 
 	return bus, nil
@@ -124,9 +128,10 @@ func toBusNewUser(app NewUser) (userbus.NewUser, error) {
 
 // NewProduct is what we require from clients when adding a Product.
 type NewProduct struct {
-	Name     string  `json:"name" validate:"required"`
-	Cost     float64 `json:"cost" validate:"required,gte=0"`
-	Quantity int     `json:"quantity" validate:"required,gte=1"`
+	Name     string `json:"name" validate:"required"`
+	Cost     string `json:"cost" validate:"required,numeric"`
+	Currency string `json:"currency"`
+	Quantity int    `json:"quantity" validate:"required,gte=1"`
 }
 
 // Validate checks the data in the model is considered clean.
@@ -144,7 +149,12 @@ func toBusNewProduct(app NewProduct) (productbus.NewProduct, error) {
 		return productbus.NewProduct{}, fmt.Errorf("parse: %w", err)
 	}
 
-	cost, err := money.Parse(app.Cost)
+	currency := app.Currency
+	if currency == "" {
+		currency = money.DefaultCurrency.Code()
+	}
+
+	cost, err := money.Parse(app.Cost, currency)
 	if err != nil {
 		return productbus.NewProduct{}, fmt.Errorf("parse cost: %w", err)
 	}
@@ -2,14 +2,18 @@ package tranapp
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 
 	"github.com/rmsj/service/app/sdk/auth"
 	"github.com/rmsj/service/app/sdk/authclient"
 	"github.com/rmsj/service/app/sdk/mid"
+	"github.com/rmsj/service/business/domain/auditbus"
+	"github.com/rmsj/service/business/domain/idempotencybus"
 	"github.com/rmsj/service/business/domain/productbus"
 	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/sdk/ratelimit"
 	"github.com/rmsj/service/business/sdk/sqldb"
 	"github.com/rmsj/service/foundation/logger"
 	"github.com/rmsj/service/foundation/web"
@@ -17,11 +21,16 @@ import (
 
 // Config contains all the mandatory systems required by handlers.
 type Config struct {
-	Log        *logger.Logger
-	DB         *sqlx.DB
-	UserBus    *userbus.Business
-	ProductBus *productbus.Business
-	AuthClient *authclient.Client
+	Log         *logger.Logger
+	DB          *sqlx.DB
+	UserBus     *userbus.Business
+	ProductBus  *productbus.Business
+	AuditBus    *auditbus.Business
+	AuthClient  *authclient.Client
+	RateLimiter *ratelimit.Limiter
+
+	IdempotencyBus *idempotencybus.Business
+	IdempotencyTTL time.Duration
 }
 
 // Routes adds specific routes for this group.
@@ -29,10 +38,12 @@ func Routes(app *web.App, cfg Config) {
 	const version = "v1"
 
 	authen := mid.Authenticate(cfg.AuthClient)
+	rateLimit := mid.RateLimit(cfg.RateLimiter, "tranexample")
 	transaction := mid.BeginCommitRollback(cfg.Log, sqldb.NewBeginner(cfg.DB))
+	idempotency := mid.Idempotency(cfg.IdempotencyBus, cfg.IdempotencyTTL)
 	ruleAdmin := mid.Authorize(cfg.AuthClient, auth.RuleAdminOnly)
 
-	api := newApp(cfg.UserBus, cfg.ProductBus)
+	api := newApp(cfg.UserBus, cfg.ProductBus, cfg.AuditBus)
 
-	app.HandlerFunc(http.MethodPost, version, "/tranexample", api.create, authen, ruleAdmin, transaction)
+	app.HandlerFunc(http.MethodPost, version, "/tranexample", api.create, authen, rateLimit, ruleAdmin, transaction, idempotency)
 }
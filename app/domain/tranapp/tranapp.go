@@ -8,6 +8,7 @@ import (
 
 	"github.com/rmsj/service/app/sdk/errs"
 	"github.com/rmsj/service/app/sdk/mid"
+	"github.com/rmsj/service/business/domain/auditbus"
 	"github.com/rmsj/service/business/domain/productbus"
 	"github.com/rmsj/service/business/domain/userbus"
 	"github.com/rmsj/service/foundation/web"
@@ -16,12 +17,14 @@ import (
 type app struct {
 	userBus    *userbus.Business
 	productBus *productbus.Business
+	auditBus   *auditbus.Business
 }
 
-func newApp(userBus *userbus.Business, productBus *productbus.Business) *app {
+func newApp(userBus *userbus.Business, productBus *productbus.Business, auditBus *auditbus.Business) *app {
 	return &app{
 		userBus:    userBus,
 		productBus: productBus,
+		auditBus:   auditBus,
 	}
 }
 
@@ -43,9 +46,15 @@ func (a *app) newWithTx(ctx context.Context) (*app, error) {
 		return nil, err
 	}
 
+	auditBus, err := a.auditBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
 	app := app{
 		userBus:    userBus,
 		productBus: productBus,
+		auditBus:   auditBus,
 	}
 
 	return &app, nil
@@ -80,6 +89,10 @@ func (a *app) create(ctx context.Context, r *http.Request) web.Encoder {
 		return errs.Newf(errs.Internal, "create: usr[%+v]: %s", usr, err)
 	}
 
+	if _, err := a.auditBus.Create(ctx, mid.NewAudit(ctx, r, "user", "create", usr.ID.String(), nil, nil)); err != nil {
+		return errs.Newf(errs.Internal, "audit: userID[%s]: %s", usr.ID, err)
+	}
+
 	np.UserID = usr.ID
 
 	prd, err := a.productBus.Create(ctx, np)
@@ -87,5 +100,11 @@ func (a *app) create(ctx context.Context, r *http.Request) web.Encoder {
 		return errs.Newf(errs.Internal, "create: prd[%+v]: %s", prd, err)
 	}
 
-	return toAppProduct(prd)
+	appPrd := toAppProduct(prd)
+
+	if _, err := a.auditBus.Create(ctx, mid.NewAudit(ctx, r, "product", "create", appPrd.ID, nil, appPrd)); err != nil {
+		return errs.Newf(errs.Internal, "audit: productID[%s]: %s", prd.ID, err)
+	}
+
+	return appPrd
 }
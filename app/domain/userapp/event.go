@@ -0,0 +1,36 @@
+package userapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rmsj/service/app/sdk/authclient"
+	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/sdk/delegate"
+)
+
+// RegisterDelegateFunctions registers the functions this api runs when the
+// user domain calls an action. The email verification is sent by the auth
+// service once the change to the email is committed, so it goes through the
+// outbox.
+func RegisterDelegateFunctions(dlg *delegate.Delegate, authClient *authclient.Client) {
+	dlg.RegisterAsync(userbus.DomainName, userbus.ActionEmailChanged, actionEmailChanged(authClient))
+}
+
+// actionEmailChanged asks the auth service to send a verification to the
+// new email of the user.
+func actionEmailChanged(authClient *authclient.Client) delegate.Func {
+	return func(ctx context.Context, data delegate.Data) error {
+		var params userbus.ActionEmailChangedParms
+		if err := json.Unmarshal(data.RawParams, &params); err != nil {
+			return fmt.Errorf("expected an encoded %T: %w", params, err)
+		}
+
+		if err := authClient.ResendVerification(ctx, params.Email); err != nil {
+			return fmt.Errorf("resend verification: userID[%s]: %w", params.UserID, err)
+		}
+
+		return nil
+	}
+}
@@ -7,14 +7,28 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/app/sdk/query"
 	"github.com/rmsj/service/business/domain/userbus"
 	"github.com/rmsj/service/business/types/name"
 )
 
+var filterByFields = query.Fields{
+	"user_id":        {Code: userbus.FilterByID, Parse: query.UUID},
+	"name":           {Code: userbus.FilterByName, Parse: query.Text},
+	"email":          {Code: userbus.FilterByEmail, Parse: query.Text},
+	"department":     {Code: userbus.FilterByDepartment, Parse: query.Text},
+	"enabled":        {Code: userbus.FilterByEnabled, Parse: query.Bool},
+	"email_verified": {Code: userbus.FilterByEmailVerified, Parse: query.Bool},
+	"created_at":     {Code: userbus.FilterByDateCreated, Parse: query.Time},
+	"updated_at":     {Code: userbus.FilterByDateUpdated, Parse: query.Time},
+}
+
 type queryParams struct {
 	Page             string
 	Rows             string
+	Cursor           string
 	OrderBy          string
+	Filter           string
 	ID               string
 	Name             string
 	Email            string
@@ -28,7 +42,9 @@ func parseQueryParams(r *http.Request) (queryParams, error) {
 	filter := queryParams{
 		Page:             values.Get("page"),
 		Rows:             values.Get("rows"),
+		Cursor:           values.Get("cursor"),
 		OrderBy:          values.Get("orderBy"),
+		Filter:           values.Get("filter"),
 		ID:               values.Get("user_id"),
 		Name:             values.Get("name"),
 		Email:            values.Get("email"),
@@ -93,6 +109,16 @@ func parseFilter(qp queryParams) (userbus.QueryFilter, error) {
 		}
 	}
 
+	if qp.Filter != "" {
+		expr, err := query.ParseFilter(qp.Filter, filterByFields)
+		switch err {
+		case nil:
+			filter.Expr = expr
+		default:
+			fieldErrors.Add("filter", err)
+		}
+	}
+
 	if fieldErrors != nil {
 		return userbus.QueryFilter{}, fieldErrors.ToError()
 	}
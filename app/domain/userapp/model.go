@@ -14,14 +14,15 @@ import (
 
 // User represents information about an individual user.
 type User struct {
-	ID          string   `json:"id"`
-	Name        string   `json:"name"`
-	Email       string   `json:"email"`
-	Roles       []string `json:"roles"`
-	Department  string   `json:"department"`
-	Enabled     bool     `json:"enabled"`
-	DateCreated string   `json:"dateCreated"`
-	DateUpdated string   `json:"dateUpdated"`
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Email         string   `json:"email"`
+	Roles         []string `json:"roles"`
+	Department    string   `json:"department"`
+	Enabled       bool     `json:"enabled"`
+	EmailVerified bool     `json:"emailVerified"`
+	DateCreated   string   `json:"dateCreated"`
+	DateUpdated   string   `json:"dateUpdated"`
 }
 
 // Encode implements the encoder interface.
@@ -32,14 +33,15 @@ func (app User) Encode() ([]byte, string, error) {
 
 func toAppUser(bus userbus.User) User {
 	return User{
-		ID:          bus.ID.String(),
-		Name:        bus.Name.String(),
-		Email:       bus.Email.Address,
-		Roles:       role.ParseToString(bus.Roles),
-		Department:  bus.Department.String(),
-		Enabled:     bus.Enabled,
-		DateCreated: bus.DateCreated.Format(time.RFC3339),
-		DateUpdated: bus.DateUpdated.Format(time.RFC3339),
+		ID:            bus.ID.String(),
+		Name:          bus.Name.String(),
+		Email:         bus.Email.Address,
+		Roles:         role.ParseToString(bus.Roles),
+		Department:    bus.Department.String(),
+		Enabled:       bus.Enabled,
+		EmailVerified: bus.EmailVerified,
+		DateCreated:   bus.DateCreated.Format(time.RFC3339),
+		DateUpdated:   bus.DateUpdated.Format(time.RFC3339),
 	}
 }
 
@@ -99,12 +101,14 @@ func toBusNewUser(app NewUser) (userbus.NewUser, error) {
 		return userbus.NewUser{}, fmt.Errorf("parse: %w", err)
 	}
 
+	// users created by someone else are trusted with their email
 	bus := userbus.NewUser{
-		Name:       nme,
-		Email:      *addr,
-		Roles:      roles,
-		Department: department,
-		Password:   app.Password,
+		Name:          nme,
+		Email:         *addr,
+		Roles:         roles,
+		Department:    department,
+		Password:      app.Password,
+		EmailVerified: true,
 	}
 
 	return bus, nil
@@ -158,6 +162,7 @@ type UpdateUser struct {
 	Password        *string `json:"password"`
 	PasswordConfirm *string `json:"passwordConfirm" validate:"omitempty,eqfield=Password"`
 	Enabled         *bool   `json:"enabled"`
+	EmailVerified   *bool   `json:"emailVerified"`
 }
 
 // Decode implements the decoder interface.
@@ -203,11 +208,12 @@ func toBusUpdateUser(app UpdateUser) (userbus.UpdateUser, error) {
 	}
 
 	bus := userbus.UpdateUser{
-		Name:       nme,
-		Email:      addr,
-		Department: department,
-		Password:   app.Password,
-		Enabled:    app.Enabled,
+		Name:          nme,
+		Email:         addr,
+		Department:    department,
+		Password:      app.Password,
+		Enabled:       app.Enabled,
+		EmailVerified: app.EmailVerified,
 	}
 
 	return bus, nil
@@ -1,7 +1,10 @@
 package userapp
 
 import (
+	"strings"
+
 	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/types/role"
 )
 
 var orderByFields = map[string]string{
@@ -11,3 +14,20 @@ var orderByFields = map[string]string{
 	"roles":   userbus.OrderByRoles,
 	"enabled": userbus.OrderByEnabled,
 }
+
+// cursorKey returns the value of the order field and the ID of the user,
+// which is where the user is in the ordered results.
+func cursorKey(usr userbus.User, field string) (any, string) {
+	switch field {
+	case userbus.OrderByName:
+		return usr.Name.String(), usr.ID.String()
+	case userbus.OrderByEmail:
+		return usr.Email.Address, usr.ID.String()
+	case userbus.OrderByRoles:
+		return strings.Join(role.ParseToString(usr.Roles), ","), usr.ID.String()
+	case userbus.OrderByEnabled:
+		return usr.Enabled, usr.ID.String()
+	}
+
+	return usr.ID.String(), usr.ID.String()
+}
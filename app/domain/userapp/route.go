@@ -3,19 +3,27 @@ package userapp
 import (
 	"net/http"
 
+	"github.com/jmoiron/sqlx"
+
 	"github.com/rmsj/service/app/sdk/auth"
 	"github.com/rmsj/service/app/sdk/authclient"
 	"github.com/rmsj/service/app/sdk/mid"
+	"github.com/rmsj/service/business/domain/auditbus"
 	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/sdk/ratelimit"
+	"github.com/rmsj/service/business/sdk/sqldb"
 	"github.com/rmsj/service/foundation/logger"
 	"github.com/rmsj/service/foundation/web"
 )
 
 // Config contains all the mandatory systems required by handlers.
 type Config struct {
-	Log        *logger.Logger
-	UserBus    *userbus.Business
-	AuthClient *authclient.Client
+	Log         *logger.Logger
+	DB          *sqlx.DB
+	UserBus     *userbus.Business
+	AuditBus    *auditbus.Business
+	AuthClient  *authclient.Client
+	RateLimiter *ratelimit.Limiter
 }
 
 // Routes adds specific routes for this group.
@@ -23,16 +31,21 @@ func Routes(app *web.App, cfg Config) {
 	const version = "v1"
 
 	authen := mid.Authenticate(cfg.AuthClient)
-	ruleAdmin := mid.Authorize(cfg.AuthClient, auth.RuleAdminOnly)
-	ruleAuthorizeUser := mid.AuthorizeUser(cfg.AuthClient, cfg.UserBus, auth.RuleAdminOrSubject)
-	ruleAuthorizeAdmin := mid.AuthorizeUser(cfg.AuthClient, cfg.UserBus, auth.RuleAdminOnly)
-
-	api := newApp(cfg.UserBus)
-
-	app.HandlerFunc(http.MethodGet, version, "/users", api.query, authen, ruleAdmin)
-	app.HandlerFunc(http.MethodGet, version, "/users/{user_id}", api.queryByID, authen, ruleAuthorizeUser)
-	app.HandlerFunc(http.MethodPost, version, "/users", api.create, authen, ruleAdmin)
-	app.HandlerFunc(http.MethodPut, version, "/users/role/{user_id}", api.updateRole, authen, ruleAuthorizeAdmin)
-	app.HandlerFunc(http.MethodPut, version, "/users/{user_id}", api.update, authen, ruleAuthorizeUser)
-	app.HandlerFunc(http.MethodDelete, version, "/users/{user_id}", api.delete, authen, ruleAuthorizeUser)
+	rateLimit := mid.RateLimit(cfg.RateLimiter, "users")
+	transaction := mid.BeginCommitRollback(cfg.Log, sqldb.NewBeginner(cfg.DB))
+	loadUser := mid.LoadUser(cfg.UserBus)
+	permRead := mid.RequirePermission(cfg.AuthClient, auth.PermUserRead)
+	permCreate := mid.RequirePermission(cfg.AuthClient, auth.PermUserCreate)
+	permWrite := mid.RequirePermission(cfg.AuthClient, auth.PermUserWrite)
+	permDelete := mid.RequirePermission(cfg.AuthClient, auth.PermUserDelete)
+	permRole := mid.RequirePermission(cfg.AuthClient, auth.PermUserRole)
+
+	api := newApp(cfg.UserBus, cfg.AuditBus)
+
+	app.HandlerFunc(http.MethodGet, version, "/users", api.query, authen, rateLimit, permRead)
+	app.HandlerFunc(http.MethodGet, version, "/users/{user_id}", api.queryByID, authen, rateLimit, loadUser, permRead)
+	app.HandlerFunc(http.MethodPost, version, "/users", api.create, authen, rateLimit, permCreate, transaction)
+	app.HandlerFunc(http.MethodPut, version, "/users/role/{user_id}", api.updateRole, authen, rateLimit, loadUser, permRole, transaction)
+	app.HandlerFunc(http.MethodPut, version, "/users/{user_id}", api.update, authen, rateLimit, loadUser, permWrite, transaction)
+	app.HandlerFunc(http.MethodDelete, version, "/users/{user_id}", api.delete, authen, rateLimit, loadUser, permDelete, transaction)
 }
@@ -5,27 +5,65 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"slices"
 
 	"github.com/rmsj/service/app/sdk/errs"
 	"github.com/rmsj/service/app/sdk/mid"
 	"github.com/rmsj/service/app/sdk/query"
+	"github.com/rmsj/service/business/domain/auditbus"
 	"github.com/rmsj/service/business/domain/userbus"
 	"github.com/rmsj/service/business/sdk/order"
-	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/business/types/role"
 	"github.com/rmsj/service/foundation/web"
 )
 
+// auditDomain is the domain the changes made by this api are audited under.
+const auditDomain = "user"
+
 type app struct {
-	userBus *userbus.Business
+	userBus  *userbus.Business
+	auditBus *auditbus.Business
 }
 
-func newApp(userBus *userbus.Business) *app {
+func newApp(userBus *userbus.Business, auditBus *auditbus.Business) *app {
 	return &app{
-		userBus: userBus,
+		userBus:  userBus,
+		auditBus: auditBus,
+	}
+}
+
+// newWithTx constructs a new Handlers value with the domain apis
+// using a store transaction that was created via middleware.
+func (a *app) newWithTx(ctx context.Context) (*app, error) {
+	tx, err := mid.GetTran(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userBus, err := a.userBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	auditBus, err := a.auditBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
 	}
+
+	app := app{
+		userBus:  userBus,
+		auditBus: auditBus,
+	}
+
+	return &app, nil
 }
 
 func (a *app) create(ctx context.Context, r *http.Request) web.Encoder {
+	a, err := a.newWithTx(ctx)
+	if err != nil {
+		return errs.New(errs.Internal, err)
+	}
+
 	var app NewUser
 	if err := web.Decode(r, &app); err != nil {
 		return errs.New(errs.InvalidArgument, err)
@@ -44,10 +82,21 @@ func (a *app) create(ctx context.Context, r *http.Request) web.Encoder {
 		return errs.Newf(errs.Internal, "create: usr[%+v]: %s", usr, err)
 	}
 
-	return toAppUser(usr)
+	appUsr := toAppUser(usr)
+
+	if _, err := a.auditBus.Create(ctx, mid.NewAudit(ctx, r, auditDomain, "create", appUsr.ID, nil, appUsr)); err != nil {
+		return errs.Newf(errs.Internal, "audit: userID[%s]: %s", usr.ID, err)
+	}
+
+	return appUsr
 }
 
 func (a *app) update(ctx context.Context, r *http.Request) web.Encoder {
+	a, err := a.newWithTx(ctx)
+	if err != nil {
+		return errs.New(errs.Internal, err)
+	}
+
 	var app UpdateUser
 	if err := web.Decode(r, &app); err != nil {
 		return errs.New(errs.InvalidArgument, err)
@@ -58,20 +107,46 @@ func (a *app) update(ctx context.Context, r *http.Request) web.Encoder {
 		return errs.New(errs.InvalidArgument, err)
 	}
 
+	// Changing the email marks it as not verified, only admins can say
+	// the email is verified without the user confirming it.
+	if uu.EmailVerified != nil && !slices.Contains(mid.GetClaims(ctx).Roles, role.Admin.String()) {
+		return errs.Newf(errs.Unauthenticated, "only admins can set if the email is verified")
+	}
+
 	usr, err := mid.GetUser(ctx)
 	if err != nil {
 		return errs.Newf(errs.Internal, "user missing in context: %s", err)
 	}
 
+	if !web.IfMatch(r, web.ETag(usr.Version)) {
+		return errs.New(errs.PreconditionFailed, userbus.ErrConflict)
+	}
+
 	updUsr, err := a.userBus.Update(ctx, usr, uu)
 	if err != nil {
+		if errors.Is(err, userbus.ErrConflict) {
+			return errs.New(errs.Aborted, userbus.ErrConflict)
+		}
 		return errs.Newf(errs.Internal, "update: userID[%s] uu[%+v]: %s", usr.ID, uu, err)
 	}
 
-	return toAppUser(updUsr)
+	appUsr := toAppUser(updUsr)
+
+	if _, err := a.auditBus.Create(ctx, mid.NewAudit(ctx, r, auditDomain, "update", appUsr.ID, toAppUser(usr), appUsr)); err != nil {
+		return errs.Newf(errs.Internal, "audit: userID[%s]: %s", usr.ID, err)
+	}
+
+	web.SetETag(ctx, web.ETag(updUsr.Version))
+
+	return appUsr
 }
 
 func (a *app) updateRole(ctx context.Context, r *http.Request) web.Encoder {
+	a, err := a.newWithTx(ctx)
+	if err != nil {
+		return errs.New(errs.Internal, err)
+	}
+
 	var app UpdateUserRole
 	if err := web.Decode(r, &app); err != nil {
 		return errs.New(errs.InvalidArgument, err)
@@ -87,15 +162,35 @@ func (a *app) updateRole(ctx context.Context, r *http.Request) web.Encoder {
 		return errs.Newf(errs.Internal, "user missing in context: %s", err)
 	}
 
+	if !web.IfMatch(r, web.ETag(usr.Version)) {
+		return errs.New(errs.PreconditionFailed, userbus.ErrConflict)
+	}
+
 	updUsr, err := a.userBus.Update(ctx, usr, uu)
 	if err != nil {
+		if errors.Is(err, userbus.ErrConflict) {
+			return errs.New(errs.Aborted, userbus.ErrConflict)
+		}
 		return errs.Newf(errs.Internal, "updaterole: userID[%s] uu[%+v]: %s", usr.ID, uu, err)
 	}
 
-	return toAppUser(updUsr)
+	appUsr := toAppUser(updUsr)
+
+	if _, err := a.auditBus.Create(ctx, mid.NewAudit(ctx, r, auditDomain, "update_role", appUsr.ID, toAppUser(usr), appUsr)); err != nil {
+		return errs.Newf(errs.Internal, "audit: userID[%s]: %s", usr.ID, err)
+	}
+
+	web.SetETag(ctx, web.ETag(updUsr.Version))
+
+	return appUsr
 }
 
-func (a *app) delete(ctx context.Context, _ *http.Request) web.Encoder {
+func (a *app) delete(ctx context.Context, r *http.Request) web.Encoder {
+	a, err := a.newWithTx(ctx)
+	if err != nil {
+		return errs.New(errs.Internal, err)
+	}
+
 	usr, err := mid.GetUser(ctx)
 	if err != nil {
 		return errs.Newf(errs.Internal, "userID missing in context: %s", err)
@@ -105,6 +200,10 @@ func (a *app) delete(ctx context.Context, _ *http.Request) web.Encoder {
 		return errs.Newf(errs.Internal, "delete: userID[%s]: %s", usr.ID, err)
 	}
 
+	if _, err := a.auditBus.Create(ctx, mid.NewAudit(ctx, r, auditDomain, "delete", usr.ID.String(), toAppUser(usr), nil)); err != nil {
+		return errs.Newf(errs.Internal, "audit: userID[%s]: %s", usr.ID, err)
+	}
+
 	return nil
 }
 
@@ -114,7 +213,7 @@ func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
 		return errs.New(errs.InvalidArgument, err)
 	}
 
-	page, err := page.Parse(qp.Page, qp.Rows)
+	page, err := query.ParsePage(qp.Page, qp.Rows, qp.Cursor)
 	if err != nil {
 		return errs.NewFieldErrors("page", err)
 	}
@@ -124,29 +223,46 @@ func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
 		return err.(*errs.Error)
 	}
 
-	orderBy, err := order.Parse(orderByFields, qp.OrderBy, userbus.DefaultOrderBy)
+	orderBy, err := order.Parse(orderByFields, qp.OrderBy, page.OrderBy(userbus.DefaultOrderBy))
 	if err != nil {
 		return errs.NewFieldErrors("order", err)
 	}
 
+	if err := page.Check(orderBy); err != nil {
+		return errs.NewFieldErrors("cursor", err)
+	}
+
 	usrs, err := a.userBus.Query(ctx, filter, orderBy, page)
 	if err != nil {
 		return errs.Newf(errs.Internal, "query: %s", err)
 	}
 
+	prev, next := query.Cursors(usrs, page, orderBy, cursorKey)
+
+	if _, ok := page.Cursor(); ok {
+		return query.NewCursorResult(toAppUsers(usrs), page).WithCursors(prev, next)
+	}
+
 	total, err := a.userBus.Count(ctx, filter)
 	if err != nil {
 		return errs.Newf(errs.Internal, "count: %s", err)
 	}
 
-	return query.NewResult(toAppUsers(usrs), total, page)
+	return query.NewResult(toAppUsers(usrs), total, page).WithCursors(prev, next)
 }
 
-func (a *app) queryByID(ctx context.Context, _ *http.Request) web.Encoder {
+func (a *app) queryByID(ctx context.Context, r *http.Request) web.Encoder {
 	usr, err := mid.GetUser(ctx)
 	if err != nil {
 		return errs.Newf(errs.Internal, "querybyid: %s", err)
 	}
 
+	etag := web.ETag(usr.Version)
+	web.SetETag(ctx, etag)
+
+	if !web.IfNoneMatch(r, etag) {
+		return web.NewNotModified()
+	}
+
 	return toAppUser(usr)
 }
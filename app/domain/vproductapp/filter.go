@@ -6,17 +6,34 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/app/sdk/query"
 	"github.com/rmsj/service/business/domain/vproductbus"
+	"github.com/rmsj/service/business/types/money"
 	"github.com/rmsj/service/business/types/name"
 )
 
+var filterByFields = query.Fields{
+	"product_id": {Code: vproductbus.FilterByProductID, Parse: query.UUID},
+	"user_id":    {Code: vproductbus.FilterByUserID, Parse: query.UUID},
+	"name":       {Code: vproductbus.FilterByName, Parse: query.Text},
+	"cost":       {Code: vproductbus.FilterByCost, Parse: query.Decimal},
+	"currency":   {Code: vproductbus.FilterByCurrency, Parse: query.Text},
+	"quantity":   {Code: vproductbus.FilterByQuantity, Parse: query.Int},
+	"created_at": {Code: vproductbus.FilterByDateCreated, Parse: query.Time},
+	"updated_at": {Code: vproductbus.FilterByDateUpdated, Parse: query.Time},
+	"user_name":  {Code: vproductbus.FilterByUserName, Parse: query.Text},
+}
+
 type queryParams struct {
 	Page     string
 	Rows     string
+	Cursor   string
 	OrderBy  string
+	Filter   string
 	ID       string
 	Name     string
 	Cost     string
+	Currency string
 	Quantity string
 	UserName string
 }
@@ -27,10 +44,13 @@ func parseQueryParams(r *http.Request) queryParams {
 	filter := queryParams{
 		Page:     values.Get("page"),
 		Rows:     values.Get("rows"),
+		Cursor:   values.Get("cursor"),
 		OrderBy:  values.Get("orderBy"),
+		Filter:   values.Get("filter"),
 		ID:       values.Get("product_id"),
 		Name:     values.Get("name"),
 		Cost:     values.Get("cost"),
+		Currency: values.Get("currency"),
 		Quantity: values.Get("quantity"),
 		UserName: values.Get("user_name"),
 	}
@@ -63,7 +83,12 @@ func parseFilter(qp queryParams) (vproductbus.QueryFilter, error) {
 	}
 
 	if qp.Cost != "" {
-		cst, err := strconv.ParseFloat(qp.Cost, 64)
+		currency := qp.Currency
+		if currency == "" {
+			currency = money.DefaultCurrency.Code()
+		}
+
+		cst, err := money.Parse(qp.Cost, currency)
 		switch err {
 		case nil:
 			filter.Cost = &cst
@@ -93,6 +118,16 @@ func parseFilter(qp queryParams) (vproductbus.QueryFilter, error) {
 		}
 	}
 
+	if qp.Filter != "" {
+		expr, err := query.ParseFilter(qp.Filter, filterByFields)
+		switch err {
+		case nil:
+			filter.Expr = expr
+		default:
+			fieldErrors.Add("filter", err)
+		}
+	}
+
 	if fieldErrors != nil {
 		return vproductbus.QueryFilter{}, fieldErrors.ToError()
 	}
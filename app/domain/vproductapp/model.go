@@ -10,14 +10,15 @@ import (
 // Product represents information about an individual product with
 // extended information.
 type Product struct {
-	ID          string  `json:"id"`
-	UserID      string  `json:"userID"`
-	Name        string  `json:"name"`
-	Cost        float64 `json:"cost"`
-	Quantity    int     `json:"quantity"`
-	DateCreated string  `json:"dateCreated"`
-	DateUpdated string  `json:"dateUpdated"`
-	UserName    string  `json:"userName"`
+	ID          string `json:"id"`
+	UserID      string `json:"userID"`
+	Name        string `json:"name"`
+	Cost        string `json:"cost"`
+	Currency    string `json:"currency"`
+	Quantity    int    `json:"quantity"`
+	DateCreated string `json:"dateCreated"`
+	DateUpdated string `json:"dateUpdated"`
+	UserName    string `json:"userName"`
 }
 
 // Encode implements the encoder interface.
@@ -31,7 +32,8 @@ func toAppProduct(prd vproductbus.Product) Product {
 		ID:          prd.ID.String(),
 		UserID:      prd.UserID.String(),
 		Name:        prd.Name.String(),
-		Cost:        prd.Cost.Value(),
+		Cost:        prd.Cost.String(),
+		Currency:    prd.Cost.Currency().Code(),
 		Quantity:    prd.Quantity.Value(),
 		DateCreated: prd.DateCreated.Format(time.RFC3339),
 		DateUpdated: prd.DateUpdated.Format(time.RFC3339),
@@ -12,3 +12,22 @@ var orderByFields = map[string]string{
 	"quantity":   vproductbus.OrderByQuantity,
 	"user_name":  vproductbus.OrderByUserName,
 }
+
+// cursorKey returns the value of the order field and the ID of the product,
+// which is where the product is in the ordered results.
+func cursorKey(prd vproductbus.Product, field string) (any, string) {
+	switch field {
+	case vproductbus.OrderByUserID:
+		return prd.UserID.String(), prd.ID.String()
+	case vproductbus.OrderByName:
+		return prd.Name.String(), prd.ID.String()
+	case vproductbus.OrderByCost:
+		return prd.Cost.String(), prd.ID.String()
+	case vproductbus.OrderByQuantity:
+		return prd.Quantity.Value(), prd.ID.String()
+	case vproductbus.OrderByUserName:
+		return prd.UserName.String(), prd.ID.String()
+	}
+
+	return prd.ID.String(), prd.ID.String()
+}
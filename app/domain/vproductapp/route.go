@@ -8,6 +8,7 @@ import (
 	"github.com/rmsj/service/app/sdk/mid"
 	"github.com/rmsj/service/business/domain/userbus"
 	"github.com/rmsj/service/business/domain/vproductbus"
+	"github.com/rmsj/service/business/sdk/ratelimit"
 	"github.com/rmsj/service/foundation/logger"
 	"github.com/rmsj/service/foundation/web"
 )
@@ -18,6 +19,7 @@ type Config struct {
 	UserBus     *userbus.Business
 	VProductBus *vproductbus.Business
 	AuthClient  *authclient.Client
+	RateLimiter *ratelimit.Limiter
 }
 
 // Routes adds specific routes for this group.
@@ -25,9 +27,10 @@ func Routes(app *web.App, cfg Config) {
 	const version = "v1"
 
 	authen := mid.Authenticate(cfg.AuthClient)
+	rateLimit := mid.RateLimit(cfg.RateLimiter, "vproducts")
 	ruleAdmin := mid.Authorize(cfg.AuthClient, auth.RuleAdminOnly)
 
 	api := newApp(cfg.VProductBus)
 
-	app.HandlerFunc(http.MethodGet, version, "/vproducts", api.query, authen, ruleAdmin)
+	app.HandlerFunc(http.MethodGet, version, "/vproducts", api.query, authen, rateLimit, ruleAdmin)
 }
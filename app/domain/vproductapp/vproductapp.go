@@ -9,7 +9,6 @@ import (
 	"github.com/rmsj/service/app/sdk/query"
 	"github.com/rmsj/service/business/domain/vproductbus"
 	"github.com/rmsj/service/business/sdk/order"
-	"github.com/rmsj/service/business/sdk/page"
 	"github.com/rmsj/service/foundation/web"
 )
 
@@ -26,7 +25,7 @@ func newApp(vproductBus *vproductbus.Business) *app {
 func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
 	qp := parseQueryParams(r)
 
-	page, err := page.Parse(qp.Page, qp.Rows)
+	page, err := query.ParsePage(qp.Page, qp.Rows, qp.Cursor)
 	if err != nil {
 		return errs.NewFieldErrors("page", err)
 	}
@@ -36,20 +35,30 @@ func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
 		return err.(*errs.Error)
 	}
 
-	orderBy, err := order.Parse(orderByFields, qp.OrderBy, vproductbus.DefaultOrderBy)
+	orderBy, err := order.Parse(orderByFields, qp.OrderBy, page.OrderBy(vproductbus.DefaultOrderBy))
 	if err != nil {
 		return errs.NewFieldErrors("order", err)
 	}
 
+	if err := page.Check(orderBy); err != nil {
+		return errs.NewFieldErrors("cursor", err)
+	}
+
 	prds, err := a.vproductBus.Query(ctx, filter, orderBy, page)
 	if err != nil {
 		return errs.Newf(errs.Internal, "query: %s", err)
 	}
 
+	prev, next := query.Cursors(prds, page, orderBy, cursorKey)
+
+	if _, ok := page.Cursor(); ok {
+		return query.NewCursorResult(toAppProducts(prds), page).WithCursors(prev, next)
+	}
+
 	total, err := a.vproductBus.Count(ctx, filter)
 	if err != nil {
 		return errs.Newf(errs.Internal, "count: %s", err)
 	}
 
-	return query.NewResult(toAppProducts(prds), total, page)
+	return query.NewResult(toAppProducts(prds), total, page).WithCursors(prev, next)
 }
@@ -0,0 +1,129 @@
+package webhookapp
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/app/sdk/query"
+	"github.com/rmsj/service/business/domain/webhookbus"
+)
+
+var filterByFields = query.Fields{
+	"subscription_id": {Code: webhookbus.FilterBySubscriptionID, Parse: query.UUID},
+	"url":             {Code: webhookbus.FilterByURL, Parse: query.Text},
+	"enabled":         {Code: webhookbus.FilterByEnabled, Parse: query.Bool},
+	"created_at":      {Code: webhookbus.FilterByDateCreated, Parse: query.Time},
+	"updated_at":      {Code: webhookbus.FilterByDateUpdated, Parse: query.Time},
+}
+
+var deliveryFilterByFields = query.Fields{
+	"subscription_id": {Code: webhookbus.FilterBySubscriptionID, Parse: query.UUID},
+	"delivery_id":     {Code: webhookbus.FilterByDeliveryID, Parse: query.UUID},
+	"event":           {Code: webhookbus.FilterByEvent, Parse: query.Text},
+	"status":          {Code: webhookbus.FilterByStatus, Parse: query.Text},
+	"attempts":        {Code: webhookbus.FilterByAttempts, Parse: query.Int},
+	"response_code":   {Code: webhookbus.FilterByResponseCode, Parse: query.Int},
+	"run_at":          {Code: webhookbus.FilterByRunAt, Parse: query.Time},
+	"created_at":      {Code: webhookbus.FilterByDateCreated, Parse: query.Time},
+	"updated_at":      {Code: webhookbus.FilterByDateUpdated, Parse: query.Time},
+}
+
+type queryParams struct {
+	Page    string
+	Rows    string
+	OrderBy string
+	Filter  string
+	ID      string
+	Event   string
+	Enabled string
+	Status  string
+}
+
+func parseQueryParams(r *http.Request) queryParams {
+	values := r.URL.Query()
+
+	filter := queryParams{
+		Page:    values.Get("page"),
+		Rows:    values.Get("rows"),
+		OrderBy: values.Get("orderBy"),
+		Filter:  values.Get("filter"),
+		ID:      values.Get("subscription_id"),
+		Event:   values.Get("event"),
+		Enabled: values.Get("enabled"),
+		Status:  values.Get("status"),
+	}
+
+	return filter
+}
+
+func parseFilter(qp queryParams) (webhookbus.QueryFilter, error) {
+	var fieldErrors errs.FieldErrors
+	var filter webhookbus.QueryFilter
+
+	if qp.ID != "" {
+		id, err := uuid.Parse(qp.ID)
+		switch err {
+		case nil:
+			filter.ID = &id
+		default:
+			fieldErrors.Add("subscription_id", err)
+		}
+	}
+
+	if qp.Event != "" {
+		filter.Event = &qp.Event
+	}
+
+	if qp.Enabled != "" {
+		enabled, err := strconv.ParseBool(qp.Enabled)
+		switch err {
+		case nil:
+			filter.Enabled = &enabled
+		default:
+			fieldErrors.Add("enabled", err)
+		}
+	}
+
+	if qp.Filter != "" {
+		expr, err := query.ParseFilter(qp.Filter, filterByFields)
+		switch err {
+		case nil:
+			filter.Expr = expr
+		default:
+			fieldErrors.Add("filter", err)
+		}
+	}
+
+	if fieldErrors != nil {
+		return webhookbus.QueryFilter{}, fieldErrors.ToError()
+	}
+
+	return filter, nil
+}
+
+func parseDeliveryFilter(qp queryParams) (webhookbus.DeliveryFilter, error) {
+	var fieldErrors errs.FieldErrors
+	var filter webhookbus.DeliveryFilter
+
+	if qp.Status != "" {
+		filter.Status = &qp.Status
+	}
+
+	if qp.Filter != "" {
+		expr, err := query.ParseFilter(qp.Filter, deliveryFilterByFields)
+		switch err {
+		case nil:
+			filter.Expr = expr
+		default:
+			fieldErrors.Add("filter", err)
+		}
+	}
+
+	if fieldErrors != nil {
+		return webhookbus.DeliveryFilter{}, fieldErrors.ToError()
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,158 @@
+package webhookapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/business/domain/webhookbus"
+)
+
+// Subscription represents information about an individual subscription. The
+// secret is never returned once the subscription is created.
+type Subscription struct {
+	ID          string   `json:"id"`
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Enabled     bool     `json:"enabled"`
+	DateCreated string   `json:"dateCreated"`
+	DateUpdated string   `json:"dateUpdated"`
+}
+
+// Encode implements the encoder interface.
+func (app Subscription) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(app)
+	return data, "application/json", err
+}
+
+func toAppSubscription(sub webhookbus.Subscription) Subscription {
+	return Subscription{
+		ID:          sub.ID.String(),
+		URL:         sub.URL,
+		Events:      sub.Events,
+		Enabled:     sub.Enabled,
+		DateCreated: sub.DateCreated.Format(time.RFC3339),
+		DateUpdated: sub.DateUpdated.Format(time.RFC3339),
+	}
+}
+
+func toAppSubscriptions(subs []webhookbus.Subscription) []Subscription {
+	app := make([]Subscription, len(subs))
+	for i, sub := range subs {
+		app[i] = toAppSubscription(sub)
+	}
+
+	return app
+}
+
+// =============================================================================
+
+// Delivery represents an entry in the delivery log of a subscription.
+type Delivery struct {
+	ID           string          `json:"id"`
+	EventID      string          `json:"eventID"`
+	Event        string          `json:"event"`
+	Payload      json.RawMessage `json:"payload"`
+	Status       string          `json:"status"`
+	Attempts     int             `json:"attempts"`
+	ResponseCode int             `json:"responseCode"`
+	LastError    string          `json:"lastError,omitempty"`
+	NextAttempt  string          `json:"nextAttempt,omitempty"`
+	DateCreated  string          `json:"dateCreated"`
+	DateUpdated  string          `json:"dateUpdated"`
+}
+
+func toAppDelivery(dlv webhookbus.Delivery) Delivery {
+	app := Delivery{
+		ID:           dlv.ID.String(),
+		EventID:      dlv.EventID.String(),
+		Event:        dlv.Event,
+		Payload:      dlv.Payload,
+		Status:       dlv.Status,
+		Attempts:     dlv.Attempts,
+		ResponseCode: dlv.ResponseCode,
+		LastError:    dlv.LastError,
+		DateCreated:  dlv.DateCreated.Format(time.RFC3339),
+		DateUpdated:  dlv.DateUpdated.Format(time.RFC3339),
+	}
+
+	if dlv.Status == webhookbus.DeliveryPending {
+		app.NextAttempt = dlv.RunAt.Format(time.RFC3339)
+	}
+
+	return app
+}
+
+func toAppDeliveries(dlvs []webhookbus.Delivery) []Delivery {
+	app := make([]Delivery, len(dlvs))
+	for i, dlv := range dlvs {
+		app[i] = toAppDelivery(dlv)
+	}
+
+	return app
+}
+
+// =============================================================================
+
+// NewSubscription defines the data needed to add a new subscription.
+type NewSubscription struct {
+	URL    string   `json:"url" validate:"required,url"`
+	Events []string `json:"events" validate:"required,min=1"`
+	Secret string   `json:"secret" validate:"required,min=16"`
+}
+
+// Decode implements the decoder interface.
+func (app *NewSubscription) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app NewSubscription) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
+
+func toBusNewSubscription(app NewSubscription) webhookbus.NewSubscription {
+	return webhookbus.NewSubscription{
+		URL:    app.URL,
+		Events: app.Events,
+		Secret: app.Secret,
+	}
+}
+
+// =============================================================================
+
+// UpdateSubscription defines the data needed to update a subscription.
+type UpdateSubscription struct {
+	URL     *string  `json:"url" validate:"omitempty,url"`
+	Events  []string `json:"events" validate:"omitempty,min=1"`
+	Secret  *string  `json:"secret" validate:"omitempty,min=16"`
+	Enabled *bool    `json:"enabled"`
+}
+
+// Decode implements the decoder interface.
+func (app *UpdateSubscription) Decode(data []byte) error {
+	return json.Unmarshal(data, app)
+}
+
+// Validate checks the data in the model is considered clean.
+func (app UpdateSubscription) Validate() error {
+	if err := errs.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
+
+func toBusUpdateSubscription(app UpdateSubscription) webhookbus.UpdateSubscription {
+	return webhookbus.UpdateSubscription{
+		URL:     app.URL,
+		Events:  app.Events,
+		Secret:  app.Secret,
+		Enabled: app.Enabled,
+	}
+}
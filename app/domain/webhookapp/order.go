@@ -0,0 +1,18 @@
+package webhookapp
+
+import (
+	"github.com/rmsj/service/business/domain/webhookbus"
+)
+
+var orderByFields = map[string]string{
+	"subscription_id": webhookbus.OrderBySubscriptionID,
+	"url":             webhookbus.OrderByURL,
+	"enabled":         webhookbus.OrderByEnabled,
+	"date_created":    webhookbus.OrderByDateCreated,
+}
+
+var deliveryOrderByFields = map[string]string{
+	"delivery_id":  webhookbus.OrderByDeliveryID,
+	"status":       webhookbus.OrderByDeliveryStatus,
+	"date_created": webhookbus.OrderByDeliveryDateCreated,
+}
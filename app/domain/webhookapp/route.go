@@ -0,0 +1,46 @@
+package webhookapp
+
+import (
+	"net/http"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/rmsj/service/app/sdk/auth"
+	"github.com/rmsj/service/app/sdk/authclient"
+	"github.com/rmsj/service/app/sdk/mid"
+	"github.com/rmsj/service/business/domain/auditbus"
+	"github.com/rmsj/service/business/domain/webhookbus"
+	"github.com/rmsj/service/business/sdk/ratelimit"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/foundation/logger"
+	"github.com/rmsj/service/foundation/web"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Log         *logger.Logger
+	DB          *sqlx.DB
+	WebhookBus  *webhookbus.Business
+	AuditBus    *auditbus.Business
+	AuthClient  *authclient.Client
+	RateLimiter *ratelimit.Limiter
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "v1"
+
+	authen := mid.Authenticate(cfg.AuthClient)
+	rateLimit := mid.RateLimit(cfg.RateLimiter, "webhooks")
+	transaction := mid.BeginCommitRollback(cfg.Log, sqldb.NewBeginner(cfg.DB))
+	ruleAdmin := mid.Authorize(cfg.AuthClient, auth.RuleAdminOnly)
+
+	api := newApp(cfg.WebhookBus, cfg.AuditBus)
+
+	app.HandlerFunc(http.MethodGet, version, "/webhooks", api.query, authen, rateLimit, ruleAdmin)
+	app.HandlerFunc(http.MethodGet, version, "/webhooks/{subscription_id}", api.queryByID, authen, rateLimit, ruleAdmin)
+	app.HandlerFunc(http.MethodGet, version, "/webhooks/{subscription_id}/deliveries", api.queryDeliveries, authen, rateLimit, ruleAdmin)
+	app.HandlerFunc(http.MethodPost, version, "/webhooks", api.create, authen, rateLimit, ruleAdmin, transaction)
+	app.HandlerFunc(http.MethodPut, version, "/webhooks/{subscription_id}", api.update, authen, rateLimit, ruleAdmin, transaction)
+	app.HandlerFunc(http.MethodDelete, version, "/webhooks/{subscription_id}", api.delete, authen, rateLimit, ruleAdmin, transaction)
+}
@@ -0,0 +1,243 @@
+// Package webhookapp maintains the app layer api for the webhook domain.
+package webhookapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/app/sdk/mid"
+	"github.com/rmsj/service/app/sdk/query"
+	"github.com/rmsj/service/business/domain/auditbus"
+	"github.com/rmsj/service/business/domain/webhookbus"
+	"github.com/rmsj/service/business/sdk/order"
+	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/foundation/web"
+)
+
+// auditDomain is the domain the changes made by this api are audited under.
+const auditDomain = "webhook"
+
+type app struct {
+	webhookBus *webhookbus.Business
+	auditBus   *auditbus.Business
+}
+
+func newApp(webhookBus *webhookbus.Business, auditBus *auditbus.Business) *app {
+	return &app{
+		webhookBus: webhookBus,
+		auditBus:   auditBus,
+	}
+}
+
+// newWithTx constructs a new Handlers value with the domain apis
+// using a store transaction that was created via middleware.
+func (a *app) newWithTx(ctx context.Context) (*app, error) {
+	tx, err := mid.GetTran(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	webhookBus, err := a.webhookBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	auditBus, err := a.auditBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	app := app{
+		webhookBus: webhookBus,
+		auditBus:   auditBus,
+	}
+
+	return &app, nil
+}
+
+func (a *app) create(ctx context.Context, r *http.Request) web.Encoder {
+	a, err := a.newWithTx(ctx)
+	if err != nil {
+		return errs.New(errs.Internal, err)
+	}
+
+	var app NewSubscription
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	sub, err := a.webhookBus.Create(ctx, toBusNewSubscription(app))
+	if err != nil {
+		return toAppError(err, "create: sub[%s]: %s", app.URL, err)
+	}
+
+	appSub := toAppSubscription(sub)
+
+	if _, err := a.auditBus.Create(ctx, mid.NewAudit(ctx, r, auditDomain, "create", appSub.ID, nil, appSub)); err != nil {
+		return errs.Newf(errs.Internal, "audit: subscriptionID[%s]: %s", sub.ID, err)
+	}
+
+	return appSub
+}
+
+func (a *app) update(ctx context.Context, r *http.Request) web.Encoder {
+	a, err := a.newWithTx(ctx)
+	if err != nil {
+		return errs.New(errs.Internal, err)
+	}
+
+	var app UpdateSubscription
+	if err := web.Decode(r, &app); err != nil {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	sub, e := a.subscription(ctx, r)
+	if e != nil {
+		return e
+	}
+
+	updSub, err := a.webhookBus.Update(ctx, sub, toBusUpdateSubscription(app))
+	if err != nil {
+		return toAppError(err, "update: subscriptionID[%s]: %s", sub.ID, err)
+	}
+
+	appSub := toAppSubscription(updSub)
+
+	if _, err := a.auditBus.Create(ctx, mid.NewAudit(ctx, r, auditDomain, "update", appSub.ID, toAppSubscription(sub), appSub)); err != nil {
+		return errs.Newf(errs.Internal, "audit: subscriptionID[%s]: %s", sub.ID, err)
+	}
+
+	return appSub
+}
+
+func (a *app) delete(ctx context.Context, r *http.Request) web.Encoder {
+	a, err := a.newWithTx(ctx)
+	if err != nil {
+		return errs.New(errs.Internal, err)
+	}
+
+	sub, e := a.subscription(ctx, r)
+	if e != nil {
+		return e
+	}
+
+	if err := a.webhookBus.Delete(ctx, sub); err != nil {
+		return errs.Newf(errs.Internal, "delete: subscriptionID[%s]: %s", sub.ID, err)
+	}
+
+	if _, err := a.auditBus.Create(ctx, mid.NewAudit(ctx, r, auditDomain, "delete", sub.ID.String(), toAppSubscription(sub), nil)); err != nil {
+		return errs.Newf(errs.Internal, "audit: subscriptionID[%s]: %s", sub.ID, err)
+	}
+
+	return nil
+}
+
+func (a *app) query(ctx context.Context, r *http.Request) web.Encoder {
+	qp := parseQueryParams(r)
+
+	page, err := page.Parse(qp.Page, qp.Rows)
+	if err != nil {
+		return errs.NewFieldErrors("page", err)
+	}
+
+	filter, err := parseFilter(qp)
+	if err != nil {
+		return err.(*errs.Error)
+	}
+
+	orderBy, err := order.Parse(orderByFields, qp.OrderBy, webhookbus.DefaultOrderBy)
+	if err != nil {
+		return errs.NewFieldErrors("order", err)
+	}
+
+	subs, err := a.webhookBus.Query(ctx, filter, orderBy, page)
+	if err != nil {
+		return errs.Newf(errs.Internal, "query: %s", err)
+	}
+
+	total, err := a.webhookBus.Count(ctx, filter)
+	if err != nil {
+		return errs.Newf(errs.Internal, "count: %s", err)
+	}
+
+	return query.NewResult(toAppSubscriptions(subs), total, page)
+}
+
+func (a *app) queryByID(ctx context.Context, r *http.Request) web.Encoder {
+	sub, e := a.subscription(ctx, r)
+	if e != nil {
+		return e
+	}
+
+	return toAppSubscription(sub)
+}
+
+func (a *app) queryDeliveries(ctx context.Context, r *http.Request) web.Encoder {
+	sub, e := a.subscription(ctx, r)
+	if e != nil {
+		return e
+	}
+
+	qp := parseQueryParams(r)
+
+	page, err := page.Parse(qp.Page, qp.Rows)
+	if err != nil {
+		return errs.NewFieldErrors("page", err)
+	}
+
+	filter, err := parseDeliveryFilter(qp)
+	if err != nil {
+		return err.(*errs.Error)
+	}
+
+	filter.SubscriptionID = &sub.ID
+
+	orderBy, err := order.Parse(deliveryOrderByFields, qp.OrderBy, webhookbus.DefaultDeliveryOrderBy)
+	if err != nil {
+		return errs.NewFieldErrors("order", err)
+	}
+
+	dlvs, err := a.webhookBus.QueryDeliveries(ctx, filter, orderBy, page)
+	if err != nil {
+		return errs.Newf(errs.Internal, "querydeliveries: %s", err)
+	}
+
+	total, err := a.webhookBus.CountDeliveries(ctx, filter)
+	if err != nil {
+		return errs.Newf(errs.Internal, "countdeliveries: %s", err)
+	}
+
+	return query.NewResult(toAppDeliveries(dlvs), total, page)
+}
+
+// =============================================================================
+
+// subscription loads the subscription specified in the route.
+func (a *app) subscription(ctx context.Context, r *http.Request) (webhookbus.Subscription, *errs.Error) {
+	subscriptionID, err := uuid.Parse(web.Param(r, "subscription_id"))
+	if err != nil {
+		return webhookbus.Subscription{}, errs.NewFieldErrors("subscription_id", err)
+	}
+
+	sub, err := a.webhookBus.QueryByID(ctx, subscriptionID)
+	if err != nil {
+		if errors.Is(err, webhookbus.ErrNotFound) {
+			return webhookbus.Subscription{}, errs.New(errs.NotFound, err)
+		}
+		return webhookbus.Subscription{}, errs.Newf(errs.Internal, "querybyid: subscriptionID[%s]: %s", subscriptionID, err)
+	}
+
+	return sub, nil
+}
+
+func toAppError(err error, format string, v ...any) *errs.Error {
+	if errors.Is(err, webhookbus.ErrUnknownEvent) {
+		return errs.New(errs.InvalidArgument, err)
+	}
+
+	return errs.Newf(errs.Internal, format, v...)
+}
@@ -43,13 +43,17 @@ func (at *Test) Run(t *testing.T, table []Table, testName string) {
 			}
 
 			r.Header.Set("Authorization", "Bearer "+tt.Token)
+			for key, value := range tt.Headers {
+				r.Header.Set(key, value)
+			}
+
 			at.mux.ServeHTTP(w, r)
 
 			if w.Code != tt.StatusCode {
 				t.Fatalf("%s: Should receive a status code of %d for the response : %d", tt.Name, tt.StatusCode, w.Code)
 			}
 
-			if tt.StatusCode == http.StatusNoContent {
+			if tt.StatusCode == http.StatusNoContent || tt.StatusCode == http.StatusNotModified {
 				return
 			}
 
@@ -94,7 +98,7 @@ func Token(userBus *userbus.Business, ath *auth.Auth, email string) string {
 		Roles: role.ParseToString(dbUsr.Roles),
 	}
 
-	token, _, err := ath.GenerateToken(kid, claims)
+	token, err := ath.GenerateToken(kid, claims)
 	if err != nil {
 		return ""
 	}
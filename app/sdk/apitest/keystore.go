@@ -13,6 +13,11 @@ func (ks *KeyStore) PublicKey(kid string) (string, error) {
 	return publicKeyPEM, nil
 }
 
+// PublicKeys implements the auth interface.
+func (ks *KeyStore) PublicKeys() map[string]string {
+	return map[string]string{kid: publicKeyPEM}
+}
+
 const (
 	kid = "s4sKIjD9kIRjxs2tulPqGLdxSfgPErRN1Mu3Hd9k9NQ"
 
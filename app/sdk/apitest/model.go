@@ -1,21 +1,27 @@
 package apitest
 
 import (
+	"github.com/rmsj/service/business/domain/orderbus"
 	"github.com/rmsj/service/business/domain/productbus"
 	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/domain/webhookbus"
+	"github.com/rmsj/service/business/sdk/scheduler"
 )
 
 // User extends the dbtest user for api test support.
 type User struct {
 	userbus.User
 	Products []productbus.Product
+	Orders   []orderbus.Order
 	Token    string
 }
 
 // SeedData represents users for api tests.
 type SeedData struct {
-	Users  []User
-	Admins []User
+	Users         []User
+	Admins        []User
+	Subscriptions []webhookbus.Subscription
+	Runs          []scheduler.Run
 }
 
 // Table represent fields needed for running an api test.
@@ -24,6 +30,7 @@ type Table struct {
 	URL        string
 	Token      string
 	Method     string
+	Headers    map[string]string
 	StatusCode int
 	Input      any
 	GotResp    any
@@ -2,7 +2,9 @@ package apitest
 
 import (
 	"net/http/httptest"
+	"net/mail"
 	"testing"
+	"time"
 
 	authbuild "github.com/rmsj/service/api/services/auth/build/all"
 	salesbuild "github.com/rmsj/service/api/services/sales/build/all"
@@ -10,6 +12,10 @@ import (
 	"github.com/rmsj/service/app/sdk/authclient"
 	"github.com/rmsj/service/app/sdk/mux"
 	"github.com/rmsj/service/business/sdk/dbtest"
+	"github.com/rmsj/service/business/sdk/notify"
+	"github.com/rmsj/service/business/sdk/notify/stores/notifydb"
+	mailer "github.com/rmsj/service/foundation/mail"
+	"github.com/rmsj/service/foundation/worker"
 )
 
 // New initialized the system to run a test.
@@ -21,8 +27,8 @@ func New(t *testing.T, testName string) *Test {
 	ath, err := auth.New(auth.Config{
 		Log:       db.Log,
 		UserBus:   db.BusDomain.User,
+		AuthBus:   db.BusDomain.Auth,
 		KeyLookup: &KeyStore{},
-		APIKey:    "api_key",
 		ActiveKID: "54bb2165-71e1-41a6-af3e-7da4a0e1e2c1",
 	})
 	if err != nil {
@@ -31,14 +37,42 @@ func New(t *testing.T, testName string) *Test {
 
 	// -------------------------------------------------------------------------
 
+	mailWorker, err := worker.New(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notifier, err := notify.New(notify.Config{
+		Log:    db.Log,
+		Sender: mailer.NewMemory(),
+		Storer: notifydb.NewStore(db.Log, db.DB),
+		Worker: mailWorker,
+		From:   mail.Address{Address: "no-reply@example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// -------------------------------------------------------------------------
+
 	server := httptest.NewServer(mux.WebAPI(mux.Config{
 		Log: db.Log,
 		DB:  db.DB,
 		BusConfig: mux.BusConfig{
-			UserBus: db.BusDomain.User,
+			AuditBus: db.BusDomain.Audit,
+			AuthBus:  db.BusDomain.Auth,
+			UserBus:  db.BusDomain.User,
 		},
 		AuthConfig: mux.AuthConfig{
-			Auth: ath,
+			Auth:       ath,
+			Notifier:   notifier,
+			ResetURL:   "http://localhost:3000/reset-password",
+			InviteURL:  "http://localhost:3000/accept-invitation",
+			VerifyURL:  "http://localhost:3000/verify-email",
+			RefreshTTL: time.Hour,
+			SessionTTL: 24 * time.Hour,
+
+			AllowRegistration: true,
 		},
 	}, authbuild.Routes()))
 
@@ -50,13 +84,19 @@ func New(t *testing.T, testName string) *Test {
 		Log: db.Log,
 		DB:  db.DB,
 		BusConfig: mux.BusConfig{
-			AuthBus:     db.BusDomain.Auth,
-			UserBus:     db.BusDomain.User,
-			ProductBus:  db.BusDomain.Product,
-			VProductBus: db.BusDomain.VProduct,
+			AuditBus:       db.BusDomain.Audit,
+			AuthBus:        db.BusDomain.Auth,
+			IdempotencyBus: db.BusDomain.Idempotency,
+			UserBus:        db.BusDomain.User,
+			ProductBus:     db.BusDomain.Product,
+			OrderBus:       db.BusDomain.Order,
+			VProductBus:    db.BusDomain.VProduct,
+			WebhookBus:     db.BusDomain.Webhook,
 		},
 		SalesConfig: mux.SalesConfig{
-			AuthClient: authClient,
+			AuthClient:     authClient,
+			Scheduler:      db.BusDomain.Scheduler,
+			IdempotencyTTL: time.Hour,
 		},
 	}, salesbuild.Routes())
 
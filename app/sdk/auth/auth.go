@@ -7,24 +7,35 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 	"github.com/open-policy-agent/opa/v1/rego"
 
+	"github.com/rmsj/service/business/domain/authbus"
 	"github.com/rmsj/service/business/domain/userbus"
-	"github.com/rmsj/service/business/sdk/id"
 	"github.com/rmsj/service/foundation/logger"
 )
 
 // ErrForbidden is returned when a auth issue is identified.
 var ErrForbidden = errors.New("attempted action is not allowed")
 
+// PurposeMFAPending marks the short-lived token issued after the password
+// was verified, which can only be exchanged for an access token with a MFA
+// code.
+const PurposeMFAPending = "mfa_pending"
+
 // Claims represents the authorization claims transmitted via a JWT.
 type Claims struct {
 	jwt.RegisteredClaims
-	Roles []string `json:"roles"`
+	Roles     []string `json:"roles"`
+	SessionID string   `json:"sid,omitempty"`
+	Purpose   string   `json:"pur,omitempty"`
+	Scopes    []string `json:"scp,omitempty"`
 }
 
 // KeyLookup declares a method set of behavior for looking up
@@ -35,14 +46,25 @@ type KeyLookup interface {
 	PublicKey(kid string) (key string, err error)
 }
 
+// ActiveKeyLookup declares the behavior of a KeyLookup that knows which key
+// should sign new tokens, which lets keys be rotated without a restart.
+type ActiveKeyLookup interface {
+	ActiveKID() (kid string, err error)
+}
+
 // Config represents information required to initialize auth.
+// DecisionLog receives the authorization decisions, and DecisionSampleRate
+// is the fraction of the allowed ones written to it, from 0 to 1. Denied
+// decisions are always written.
 type Config struct {
-	Log       *logger.Logger
-	UserBus   *userbus.Business
-	KeyLookup KeyLookup
-	Issuer    string
-	APIKey    string
-	ActiveKID string
+	Log                *logger.Logger
+	UserBus            *userbus.Business
+	AuthBus            *authbus.Business
+	KeyLookup          KeyLookup
+	Issuer             string
+	ActiveKID          string
+	DecisionLog        *logger.Logger
+	DecisionSampleRate float64
 }
 
 // Auth is used to authenticate clients. It can generate a token for a
@@ -51,11 +73,14 @@ type Auth struct {
 	log       *logger.Logger
 	keyLookup KeyLookup
 	userBus   *userbus.Business
-	method    jwt.SigningMethod
+	authBus   *authbus.Business
 	parser    *jwt.Parser
 	issuer    string
-	apiKey    string
 	activeKID string
+	policies  atomic.Pointer[policySet]
+
+	decisionLog        *logger.Logger
+	decisionSampleRate float64
 }
 
 // New creates an Auth to support authentication/authorization.
@@ -64,11 +89,17 @@ func New(cfg Config) (*Auth, error) {
 		log:       cfg.Log,
 		keyLookup: cfg.KeyLookup,
 		userBus:   cfg.UserBus,
-		method:    jwt.GetSigningMethod(jwt.SigningMethodRS256.Name),
-		parser:    jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Name})),
+		authBus:   cfg.AuthBus,
+		parser:    jwt.NewParser(jwt.WithValidMethods(signingMethods)),
 		issuer:    cfg.Issuer,
-		apiKey:    cfg.APIKey,
 		activeKID: cfg.ActiveKID,
+
+		decisionLog:        cfg.DecisionLog,
+		decisionSampleRate: cfg.DecisionSampleRate,
+	}
+
+	if _, err := a.LoadPolicies(context.Background(), DefaultPolicies()); err != nil {
+		return nil, fmt.Errorf("loading default policies: %w", err)
 	}
 
 	return &a, nil
@@ -79,54 +110,96 @@ func (a *Auth) Issuer() string {
 	return a.issuer
 }
 
-// APIKey provides the configured API Key used to authenticate certain endpoints.
-func (a *Auth) APIKey() string {
-	return a.apiKey
-}
-
-// ActiveKID provides the configured active key ID, if not present in the path.
+// ActiveKID provides the active key ID, if not present in the path. A
+// configured key ID takes precedence, otherwise the KeyLookup is asked for
+// it when it implements ActiveKeyLookup.
 func (a *Auth) ActiveKID() string {
-	return a.activeKID
-}
+	if a.activeKID != "" {
+		return a.activeKID
+	}
 
-// GenerateToken generates a signed JWT token string representing the user Claims.
-func (a *Auth) GenerateToken(kid string, claims Claims) (string, string, error) {
-	token := jwt.NewWithClaims(a.method, claims)
-	token.Header["kid"] = kid
+	akl, ok := a.keyLookup.(ActiveKeyLookup)
+	if !ok {
+		return ""
+	}
+
+	kid, err := akl.ActiveKID()
+	if err != nil {
+		a.log.Error(context.Background(), "auth.activekid", "error", err)
+		return ""
+	}
 
+	return kid
+}
+
+// GenerateToken generates a signed JWT token string representing the user
+// Claims. The signing algorithm follows the type of the key of the kid.
+func (a *Auth) GenerateToken(kid string, claims Claims) (string, error) {
 	privateKeyPEM, err := a.keyLookup.PrivateKey(kid)
 	if err != nil {
-		return "", "", fmt.Errorf("private key: %w", err)
+		return "", fmt.Errorf("private key: %w", err)
 	}
 
-	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privateKeyPEM))
+	privateKey, err := parsePrivateKey(privateKeyPEM)
 	if err != nil {
-		return "", "", fmt.Errorf("parsing private pem: %w", err)
+		return "", fmt.Errorf("parsing private pem: %w", err)
 	}
 
-	str, err := token.SignedString(privateKey)
+	method, err := signingMethod(privateKey)
 	if err != nil {
-		return "", "", fmt.Errorf("signing token: %w", err)
+		return "", fmt.Errorf("signing method: %w", err)
 	}
 
-	refreshToken, err := id.NewRandomString(64)
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+
+	str, err := token.SignedString(privateKey)
 	if err != nil {
-		return "", "", fmt.Errorf("refresh token: %w", err)
+		return "", fmt.Errorf("signing token: %w", err)
 	}
 
-	return str, refreshToken, nil
+	return str, nil
 }
 
 // Authenticate processes the token to validate the sender's token is valid.
+// Only access tokens are accepted.
 func (a *Auth) Authenticate(ctx context.Context, bearerToken string) (Claims, error) {
+	claims, err := a.authenticate(ctx, bearerToken)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	if claims.Purpose != "" {
+		return Claims{}, fmt.Errorf("token issued for %q can't be used for access", claims.Purpose)
+	}
+
+	return claims, nil
+}
+
+// AuthenticateMFAPending processes the token to validate it's a valid
+// mfa_pending token.
+func (a *Auth) AuthenticateMFAPending(ctx context.Context, bearerToken string) (Claims, error) {
+	claims, err := a.authenticate(ctx, bearerToken)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	if claims.Purpose != PurposeMFAPending {
+		return Claims{}, errors.New("expected a mfa_pending token")
+	}
+
+	return claims, nil
+}
+
+func (a *Auth) authenticate(ctx context.Context, bearerToken string) (Claims, error) {
 	if !strings.HasPrefix(bearerToken, "Bearer ") {
 		return Claims{}, errors.New("expected authorization header format: Bearer <token>")
 	}
 
-	jwt := bearerToken[7:]
+	tokenString := bearerToken[7:]
 
 	var claims Claims
-	token, _, err := a.parser.ParseUnverified(jwt, &claims)
+	token, _, err := a.parser.ParseUnverified(tokenString, &claims)
 	if err != nil {
 		return Claims{}, fmt.Errorf("error parsing token: %w", err)
 	}
@@ -146,14 +219,44 @@ func (a *Auth) Authenticate(ctx context.Context, bearerToken string) (Claims, er
 		return Claims{}, fmt.Errorf("failed to fetch public key: %w", err)
 	}
 
+	publicKey, err := parsePublicKey(pem)
+	if err != nil {
+		return Claims{}, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	method, err := signingMethod(publicKey)
+	if err != nil {
+		return Claims{}, fmt.Errorf("signing method: %w", err)
+	}
+
+	// The algorithm is dictated by the key, never by the token, otherwise a
+	// token could pick the algorithm it's verified with.
+
+	if token.Method.Alg() != method.Alg() {
+		return Claims{}, fmt.Errorf("token alg %q doesn't match the key alg %q", token.Method.Alg(), method.Alg())
+	}
+
 	input := map[string]any{
 		"Key":   pem,
-		"Token": jwt,
+		"Token": tokenString,
 		"ISS":   a.issuer,
+		"Alg":   method.Alg(),
 	}
 
-	if err := a.opaPolicyEvaluation(ctx, regoAuthentication, RuleAuthenticate, input); err != nil {
-		a.log.Info(ctx, "**Authenticate-FAILED**", "token", jwt)
+	// OPA can't verify EdDSA signatures, so these are verified here and the
+	// policy only checks the claims.
+
+	if method == jwt.SigningMethodEdDSA {
+		keyFunc := func(*jwt.Token) (any, error) { return publicKey, nil }
+		if _, err := a.parser.ParseWithClaims(tokenString, &Claims{}, keyFunc); err != nil {
+			a.log.Info(ctx, "**Authenticate-FAILED**", "token", tokenString)
+			return Claims{}, fmt.Errorf("authentication failed : %w", err)
+		}
+		input["Verified"] = true
+	}
+
+	if err := a.opaPolicyEvaluation(ctx, RuleAuthenticate, input); err != nil {
+		a.log.Info(ctx, "**Authenticate-FAILED**", "token", tokenString)
 		return Claims{}, fmt.Errorf("authentication failed : %w", err)
 	}
 
@@ -163,54 +266,103 @@ func (a *Auth) Authenticate(ctx context.Context, bearerToken string) (Claims, er
 		return Claims{}, fmt.Errorf("user not enabled : %w", err)
 	}
 
+	// Check the database for the session of this token to verify it was not
+	// revoked.
+
+	if err := a.isSessionActive(ctx, claims); err != nil {
+		return Claims{}, fmt.Errorf("session not active : %w", err)
+	}
+
 	return claims, nil
 }
 
 // Authorize attempts to authorize the user with the provided input roles, if
 // none of the input roles are within the user's claims, we return an error
-// otherwise the user is authorized.
+// otherwise the user is authorized. Claims limited to scopes are only ever
+// authorized by permission.
 func (a *Auth) Authorize(ctx context.Context, claims Claims, userID uuid.UUID, rule string) error {
+	if len(claims.Scopes) > 0 {
+		return fmt.Errorf("rule %q can't be satisfied by scoped claims", rule)
+	}
+
 	input := map[string]any{
 		"Roles":   claims.Roles,
 		"Subject": claims.Subject,
 		"UserID":  userID,
 	}
 
-	if err := a.opaPolicyEvaluation(ctx, regoAuthorization, rule, input); err != nil {
+	if err := a.opaPolicyEvaluation(ctx, rule, input); err != nil {
 		return fmt.Errorf("rego evaluation failed : %w", err)
 	}
 
 	return nil
 }
 
-// opaPolicyEvaluation asks opa to evaluate the token against the specified token
-// policy and public key.
-func (a *Auth) opaPolicyEvaluation(ctx context.Context, regoScript string, rule string, input any) error {
-	query := fmt.Sprintf("x = data.%s.%s", opaPackage, rule)
+// AuthorizePermission attempts to authorize the user with the provided
+// permission. The roles in the claims, and the roles below them, must grant
+// it. A permission granted only on owned resources requires the subject of
+// the claims to be the specified user, the owner of the resource. When the
+// resource is a user, its roles are specified too, and the permissions
+// managing users are only granted on users below the roles in the claims.
+// Claims limited to scopes must include the permission in them as well.
+func (a *Auth) AuthorizePermission(ctx context.Context, claims Claims, userID uuid.UUID, userRoles []string, permission string) error {
+	if len(claims.Scopes) > 0 && !slices.Contains(claims.Scopes, permission) {
+		return fmt.Errorf("permission %q is not in the scopes %v", permission, claims.Scopes)
+	}
 
-	q, err := rego.New(
-		rego.Query(query),
-		rego.Module("policy.rego", regoScript),
-	).PrepareForEval(ctx)
-	if err != nil {
-		return err
+	input := map[string]any{
+		"Roles":      claims.Roles,
+		"Subject":    claims.Subject,
+		"UserID":     userID,
+		"UserRoles":  userRoles,
+		"Permission": permission,
+	}
+
+	if err := a.opaPolicyEvaluation(ctx, RulePermission, input); err != nil {
+		return fmt.Errorf("rego evaluation failed : %w", err)
+	}
+
+	return nil
+}
+
+// opaPolicyEvaluation asks opa to evaluate the input against the specified
+// rule of the policies in use, and records the decision.
+func (a *Auth) opaPolicyEvaluation(ctx context.Context, rule string, input map[string]any) error {
+	start := time.Now()
+
+	allowed, err := a.evaluate(ctx, rule, input)
+
+	a.logDecision(ctx, rule, input, allowed, time.Since(start))
+
+	return err
+}
+
+func (a *Auth) evaluate(ctx context.Context, rule string, input map[string]any) (bool, error) {
+	set := a.policies.Load()
+	if set == nil {
+		return false, errors.New("no policies loaded")
+	}
+
+	q, exists := set.queries[rule]
+	if !exists {
+		return false, fmt.Errorf("unknown rule %q", rule)
 	}
 
 	results, err := q.Eval(ctx, rego.EvalInput(input))
 	if err != nil {
-		return fmt.Errorf("query: %w", err)
+		return false, fmt.Errorf("query: %w", err)
 	}
 
 	if len(results) == 0 {
-		return errors.New("no results")
+		return false, errors.New("no results")
 	}
 
 	result, ok := results[0].Bindings["x"].(bool)
 	if !ok || !result {
-		return fmt.Errorf("bindings results[%v] ok[%v]", results, ok)
+		return false, fmt.Errorf("bindings results[%v] ok[%v]", results, ok)
 	}
 
-	return nil
+	return true, nil
 }
 
 // isUserEnabled hits the database and checks the user is not disabled. If the
@@ -236,3 +388,24 @@ func (a *Auth) isUserEnabled(ctx context.Context, claims Claims) error {
 
 	return nil
 }
+
+// isSessionActive hits the database and checks the session the token was
+// issued for was not revoked. Tokens that don't belong to a session, like the
+// ones issued with basic auth, and the case where no database connection was
+// provided skip this check.
+func (a *Auth) isSessionActive(ctx context.Context, claims Claims) error {
+	if a.authBus == nil || claims.SessionID == "" {
+		return nil
+	}
+
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return fmt.Errorf("parse session: %w", err)
+	}
+
+	if err := a.authBus.CheckSession(ctx, sessionID); err != nil {
+		return fmt.Errorf("check session: %w", err)
+	}
+
+	return nil
+}
@@ -34,6 +34,8 @@ func Test_Auth(t *testing.T) {
 	t.Run("test4", test4(ath))
 	t.Run("test5", test5(ath))
 	t.Run("test6", test6(ath))
+	t.Run("permissions", permissions(ath))
+	t.Run("scopes", scopes(ath))
 }
 
 func test1(ath *auth.Auth) func(t *testing.T) {
@@ -48,13 +50,10 @@ func test1(ath *auth.Auth) func(t *testing.T) {
 			Roles: []string{role.Admin.String()},
 		}
 
-		token, refresh, err := ath.GenerateToken(kid, claims)
+		token, err := ath.GenerateToken(kid, claims)
 		if err != nil {
 			t.Fatalf("Should be able to generate a JWT : %s", err)
 		}
-		if refresh == "" {
-			t.Fatal("Should be able to generate a refresh token")
-		}
 
 		parsedClaims, err := ath.Authenticate(context.Background(), "Bearer "+token)
 		if err != nil {
@@ -94,7 +93,7 @@ func test2(ath *auth.Auth) func(t *testing.T) {
 			Roles: []string{role.User.String()},
 		}
 
-		token, _, err := ath.GenerateToken(kid, claims)
+		token, err := ath.GenerateToken(kid, claims)
 		if err != nil {
 			t.Fatalf("Should be able to generate a JWT : %v", err)
 		}
@@ -142,7 +141,7 @@ func test3(ath *auth.Auth) func(t *testing.T) {
 			Roles: []string{role.User.String()},
 		}
 
-		token, _, err := ath.GenerateToken(kid, claims)
+		token, err := ath.GenerateToken(kid, claims)
 		if err != nil {
 			t.Fatalf("Should be able to generate a JWT : %s", err)
 		}
@@ -176,7 +175,7 @@ func test4(ath *auth.Auth) func(t *testing.T) {
 		}
 		userID := uuid.MustParse("9e979baa-61c9-4b50-81f2-f216d53f5c15")
 
-		token, _, err := ath.GenerateToken(kid, claims)
+		token, err := ath.GenerateToken(kid, claims)
 		if err != nil {
 			t.Fatalf("Should be able to generate a JWT : %s", err)
 		}
@@ -208,7 +207,7 @@ func test5(ath *auth.Auth) func(t *testing.T) {
 		}
 		userID := uuid.MustParse("9e979baa-61c9-4b50-81f2-f216d53f5c15")
 
-		token, _, err := ath.GenerateToken(kid, claims)
+		token, err := ath.GenerateToken(kid, claims)
 		if err != nil {
 			t.Fatalf("Should be able to generate a JWT : %s", err)
 		}
@@ -240,7 +239,7 @@ func test6(ath *auth.Auth) func(t *testing.T) {
 		}
 		userID := uuid.MustParse("9e979baa-61c9-4b50-81f2-f216d53f5c15")
 
-		token, _, err := ath.GenerateToken(kid, claims)
+		token, err := ath.GenerateToken(kid, claims)
 		if err != nil {
 			t.Fatalf("Should be able to generate a JWT : %s", err)
 		}
@@ -259,6 +258,104 @@ func test6(ath *auth.Auth) func(t *testing.T) {
 	return f
 }
 
+func permissions(ath *auth.Auth) func(t *testing.T) {
+	f := func(t *testing.T) {
+		subject := uuid.MustParse("5cf37266-3473-4006-984f-9325122678b7")
+		other := uuid.MustParse("9e979baa-61c9-4b50-81f2-f216d53f5c15")
+
+		tests := []struct {
+			role        role.Role
+			permission  string
+			owner       uuid.UUID
+			targetRoles []role.Role
+			allowed     bool
+		}{
+			{role.User, auth.PermProductCreate, uuid.UUID{}, nil, true},
+			{role.User, auth.PermProductList, uuid.UUID{}, nil, true},
+			{role.User, auth.PermProductRead, subject, nil, true},
+			{role.User, auth.PermProductRead, other, nil, false},
+			{role.Staff, auth.PermProductRead, other, nil, true},
+			{role.User, auth.PermProductWrite, subject, nil, true},
+			{role.User, auth.PermProductWrite, other, nil, false},
+			{role.User, auth.PermUserRead, subject, []role.Role{role.User}, true},
+			{role.User, auth.PermUserRead, other, []role.Role{role.User}, false},
+			{role.User, auth.PermUserWrite, subject, []role.Role{role.User}, true},
+			{role.User, auth.PermUserRole, subject, []role.Role{role.User}, false},
+			{role.Staff, auth.PermUserRead, other, []role.Role{role.Admin}, true},
+			{role.Staff, auth.PermProductWrite, subject, nil, true},
+			{role.Staff, auth.PermProductWrite, other, nil, false},
+			{role.Manager, auth.PermProductDelete, other, nil, true},
+			{role.Manager, auth.PermUserWrite, other, []role.Role{role.User}, false},
+			{role.Support, auth.PermUserWrite, other, []role.Role{role.User}, true},
+			{role.Support, auth.PermUserWrite, other, []role.Role{role.Manager, role.User}, true},
+			{role.Support, auth.PermUserWrite, other, []role.Role{role.Support}, false},
+			{role.Support, auth.PermUserWrite, other, []role.Role{role.Admin}, false},
+			{role.Support, auth.PermUserWrite, other, []role.Role{role.User, role.Admin}, false},
+			{role.Support, auth.PermUserDelete, other, []role.Role{role.User}, false},
+			{role.Admin, auth.PermUserWrite, other, []role.Role{role.Admin}, true},
+			{role.Admin, auth.PermUserRole, other, []role.Role{role.User}, true},
+			{role.Admin, auth.PermProductCreate, uuid.UUID{}, nil, true},
+			{role.Admin, "unknown:permission", other, nil, false},
+		}
+
+		for _, tt := range tests {
+			claims := auth.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Subject: subject.String(),
+				},
+				Roles: []string{tt.role.String()},
+			}
+
+			err := ath.AuthorizePermission(context.Background(), claims, tt.owner, role.ParseToString(tt.targetRoles), tt.permission)
+			if tt.allowed && err != nil {
+				t.Errorf("Should be able to authorize %s with Roles.%s on %s%v : %s", tt.permission, tt.role, tt.owner, tt.targetRoles, err)
+			}
+			if !tt.allowed && err == nil {
+				t.Errorf("Should NOT be able to authorize %s with Roles.%s on %s%v", tt.permission, tt.role, tt.owner, tt.targetRoles)
+			}
+		}
+	}
+
+	return f
+}
+
+func scopes(ath *auth.Auth) func(t *testing.T) {
+	f := func(t *testing.T) {
+		subject := uuid.MustParse("5cf37266-3473-4006-984f-9325122678b7")
+
+		claims := auth.Claims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject: subject.String(),
+			},
+			Roles:  []string{role.Admin.String()},
+			Scopes: []string{auth.PermProductRead},
+		}
+
+		ctx := context.Background()
+
+		if err := ath.AuthorizePermission(ctx, claims, subject, nil, auth.PermProductRead); err != nil {
+			t.Errorf("Should be able to authorize a permission in the scopes : %s", err)
+		}
+
+		if err := ath.AuthorizePermission(ctx, claims, subject, nil, auth.PermProductCreate); err == nil {
+			t.Error("Should NOT be able to authorize a permission outside the scopes")
+		}
+
+		if err := ath.Authorize(ctx, claims, subject, auth.RuleAdminOnly); err == nil {
+			t.Error("Should NOT be able to authorize a rule with scoped claims")
+		}
+
+		claims.Roles = []string{role.User.String()}
+		claims.Scopes = []string{auth.PermUserRole}
+
+		if err := ath.AuthorizePermission(ctx, claims, subject, nil, auth.PermUserRole); err == nil {
+			t.Error("Should NOT be able to authorize a permission in the scopes the roles don't grant")
+		}
+	}
+
+	return f
+}
+
 // =============================================================================
 
 func newUnit(t *testing.T) *logger.Logger {
@@ -288,6 +385,10 @@ func (ks *keyStore) PublicKey(kid string) (string, error) {
 	return publicKeyPEM, nil
 }
 
+func (ks *keyStore) PublicKeys() map[string]string {
+	return map[string]string{kid: publicKeyPEM}
+}
+
 const (
 	kid = "s4sKIjD9kIRjxs2tulPqGLdxSfgPErRN1Mu3Hd9k9NQ"
 
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// redacted are the input fields never written to the decision log.
+var redacted = map[string]bool{
+	"Token": true,
+	"Key":   true,
+}
+
+// logDecision writes the decision to the decision log. Every denied decision
+// is written, the allowed ones are sampled.
+func (a *Auth) logDecision(ctx context.Context, rule string, input map[string]any, allowed bool, latency time.Duration) {
+	if a.decisionLog == nil {
+		return
+	}
+
+	if allowed && rand.Float64() >= a.decisionSampleRate {
+		return
+	}
+
+	logged := make(map[string]any, len(input))
+	for k, v := range input {
+		if redacted[k] {
+			continue
+		}
+		logged[k] = v
+	}
+
+	a.decisionLog.Info(ctx, "decision", "rule", rule, "result", allowed, "latency", latency, "revision", a.PolicyRevision(), "input", logged)
+}
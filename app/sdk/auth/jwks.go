@@ -0,0 +1,385 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rmsj/service/foundation/logger"
+)
+
+// PublicKeySet declares the behavior of a KeyLookup that can list every public
+// key it holds, which is required to publish a JWKS.
+type PublicKeySet interface {
+	PublicKeys() map[string]string
+}
+
+// JWK represents a single public key as a JSON Web Key (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JWKS represents a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Encode implements the encoder interface.
+func (s JWKS) Encode() ([]byte, string, error) {
+	data, err := json.Marshal(s)
+	return data, "application/json", err
+}
+
+// NewJWK converts a PEM encoded public key into a JWK.
+func NewJWK(kid string, publicPEM string) (JWK, error) {
+	parsedKey, err := parsePublicKey(publicPEM)
+	if err != nil {
+		return JWK{}, err
+	}
+
+	method, err := signingMethod(parsedKey)
+	if err != nil {
+		return JWK{}, err
+	}
+
+	jwk := JWK{
+		Use:       "sig",
+		Algorithm: method.Alg(),
+		KeyID:     kid,
+	}
+
+	switch pk := parsedKey.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pk.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pk.E)).Bytes())
+
+	case *ecdsa.PublicKey:
+		ecdhKey, err := pk.ECDH()
+		if err != nil {
+			return JWK{}, fmt.Errorf("converting ecdsa key: %w", err)
+		}
+
+		// The uncompressed point is 0x04 followed by X and Y, each the size
+		// of the curve.
+		point := ecdhKey.Bytes()
+		size := (len(point) - 1) / 2
+
+		jwk.KeyType = "EC"
+		jwk.Curve = pk.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(point[1 : 1+size])
+		jwk.Y = base64.RawURLEncoding.EncodeToString(point[1+size:])
+
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pk)
+	}
+
+	return jwk, nil
+}
+
+// PEM converts the JWK back into a PEM encoded public key.
+func (k JWK) PEM() (string, error) {
+	var pub any
+
+	switch k.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return "", fmt.Errorf("decoding modulus: %w", err)
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return "", fmt.Errorf("decoding exponent: %w", err)
+		}
+
+		exp := new(big.Int).SetBytes(e)
+		if !exp.IsInt64() || exp.Int64() > 1<<31-1 {
+			return "", errors.New("exponent out of range")
+		}
+
+		pub = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(exp.Int64()),
+		}
+
+	case "EC":
+		if k.Curve != "P-256" {
+			return "", fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return "", fmt.Errorf("decoding x: %w", err)
+		}
+
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return "", fmt.Errorf("decoding y: %w", err)
+		}
+
+		if len(x) != 32 || len(y) != 32 {
+			return "", errors.New("invalid P-256 coordinates")
+		}
+
+		// Going through ecdh validates the point is on the curve.
+		point := append(append([]byte{4}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return "", fmt.Errorf("invalid P-256 point: %w", err)
+		}
+
+		pub = &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+
+	case "OKP":
+		if k.Curve != "Ed25519" {
+			return "", fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return "", fmt.Errorf("decoding x: %w", err)
+		}
+
+		if len(x) != ed25519.PublicKeySize {
+			return "", errors.New("invalid Ed25519 key size")
+		}
+
+		pub = ed25519.PublicKey(x)
+
+	default:
+		return "", fmt.Errorf("unsupported key type %q", k.KeyType)
+	}
+
+	asn1Bytes, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("marshaling public key: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := pem.Encode(&buf, &pem.Block{Type: "PUBLIC KEY", Bytes: asn1Bytes}); err != nil {
+		return "", fmt.Errorf("encoding to public PEM: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// JWKS builds the set of public keys used to verify the tokens issued by
+// this Auth. The configured KeyLookup must implement PublicKeySet.
+func (a *Auth) JWKS() (JWKS, error) {
+	ks, ok := a.keyLookup.(PublicKeySet)
+	if !ok {
+		return JWKS{}, errors.New("key lookup can't list public keys")
+	}
+
+	publicKeys := ks.PublicKeys()
+
+	kids := make([]string, 0, len(publicKeys))
+	for kid := range publicKeys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	set := JWKS{
+		Keys: make([]JWK, 0, len(kids)),
+	}
+
+	for _, kid := range kids {
+		jwk, err := NewJWK(kid, publicKeys[kid])
+		if err != nil {
+			return JWKS{}, fmt.Errorf("kid[%s]: %w", kid, err)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set, nil
+}
+
+// =============================================================================
+
+// JWKSConfig represents information required to initialize a JWKSLookup.
+type JWKSConfig struct {
+	Log    *logger.Logger
+	URL    string
+	Client *http.Client
+
+	// TTL is how long a fetched key set is used before it's fetched again.
+	TTL time.Duration
+
+	// MinRefresh is the minimum time between two fetches, which stops tokens
+	// with unknown kids from hammering the auth service.
+	MinRefresh time.Duration
+}
+
+// JWKSLookup implements the KeyLookup interface using the JWKS published by
+// a remote auth service, so tokens can be verified without calling it on
+// every request. It can only provide public keys.
+type JWKSLookup struct {
+	log        *logger.Logger
+	url        string
+	client     *http.Client
+	ttl        time.Duration
+	minRefresh time.Duration
+
+	mu        sync.RWMutex
+	keys      map[string]string
+	fetchedAt time.Time
+	triedAt   time.Time
+}
+
+// NewJWKSLookup constructs a JWKSLookup. No keys are fetched until the first
+// lookup.
+func NewJWKSLookup(cfg JWKSConfig) *JWKSLookup {
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+
+	minRefresh := cfg.MinRefresh
+	if minRefresh <= 0 {
+		minRefresh = 30 * time.Second
+	}
+
+	return &JWKSLookup{
+		log:        cfg.Log,
+		url:        cfg.URL,
+		client:     client,
+		ttl:        ttl,
+		minRefresh: minRefresh,
+		keys:       make(map[string]string),
+	}
+}
+
+// PrivateKey implements the KeyLookup interface. A remote key set never
+// contains private keys.
+func (jl *JWKSLookup) PrivateKey(kid string) (string, error) {
+	return "", errors.New("private keys are not available from a JWKS")
+}
+
+// PublicKey implements the KeyLookup interface. The key set is fetched again
+// when it's older than the TTL, or when the kid is unknown, since that is
+// what happens right after the auth service rotates its keys. If a fetch
+// fails, the cached keys keep being used.
+func (jl *JWKSLookup) PublicKey(kid string) (string, error) {
+	now := time.Now()
+
+	jl.mu.RLock()
+	key, found := jl.keys[kid]
+	fresh := now.Sub(jl.fetchedAt) < jl.ttl
+	jl.mu.RUnlock()
+
+	if found && fresh {
+		return key, nil
+	}
+
+	if err := jl.refresh(now); err != nil && jl.log != nil {
+		jl.log.Error(context.Background(), "auth.jwks.refresh", "url", jl.url, "error", err)
+	}
+
+	jl.mu.RLock()
+	defer jl.mu.RUnlock()
+
+	key, found = jl.keys[kid]
+	if !found {
+		return "", errors.New("kid lookup failed")
+	}
+
+	return key, nil
+}
+
+func (jl *JWKSLookup) refresh(now time.Time) error {
+	jl.mu.Lock()
+	if now.Sub(jl.triedAt) < jl.minRefresh {
+		jl.mu.Unlock()
+		return nil
+	}
+	jl.triedAt = now
+	jl.mu.Unlock()
+
+	keys, err := jl.fetch()
+	if err != nil {
+		return err
+	}
+
+	jl.mu.Lock()
+	jl.keys = keys
+	jl.fetchedAt = now
+	jl.mu.Unlock()
+
+	return nil
+}
+
+func (jl *JWKSLookup) fetch() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jl.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := jl.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+
+	var set JWKS
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1024*1024)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decoding: %w", err)
+	}
+
+	keys := make(map[string]string, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.PEM()
+		if err != nil {
+			return nil, fmt.Errorf("kid[%s]: %w", jwk.KeyID, err)
+		}
+		keys[jwk.KeyID] = key
+	}
+
+	return keys, nil
+}
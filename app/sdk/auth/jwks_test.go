@@ -0,0 +1,123 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/rmsj/service/app/sdk/auth"
+	"github.com/rmsj/service/business/types/role"
+)
+
+func Test_JWKS(t *testing.T) {
+	log := newUnit(t)
+
+	ath, err := auth.New(auth.Config{
+		Log:       log,
+		KeyLookup: &keyStore{},
+		Issuer:    "service project",
+	})
+	if err != nil {
+		t.Fatalf("Should be able to create an authenticator: %s", err)
+	}
+
+	set, err := ath.JWKS()
+	if err != nil {
+		t.Fatalf("Should be able to build the JWKS: %s", err)
+	}
+
+	if len(set.Keys) != 1 || set.Keys[0].KeyID != kid || set.Keys[0].KeyType != "RSA" {
+		t.Fatalf("Should get back the one RSA key: %+v", set.Keys)
+	}
+
+	pem, err := set.Keys[0].PEM()
+	if err != nil {
+		t.Fatalf("Should be able to convert the JWK back to PEM: %s", err)
+	}
+
+	if pem != publicKeyPEM {
+		t.Fatalf("Should get back the same public key:\ngot: %s\nexp: %s", pem, publicKeyPEM)
+	}
+
+	// -------------------------------------------------------------------------
+
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+
+		data, _, err := set.Encode()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	remote, err := auth.New(auth.Config{
+		Log: log,
+		KeyLookup: auth.NewJWKSLookup(auth.JWKSConfig{
+			Log:        log,
+			URL:        server.URL,
+			TTL:        time.Hour,
+			MinRefresh: time.Hour,
+		}),
+		Issuer: "service project",
+	})
+	if err != nil {
+		t.Fatalf("Should be able to create a remote authenticator: %s", err)
+	}
+
+	claims := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    ath.Issuer(),
+			Subject:   "5cf37266-3473-4006-984f-9325122678b7",
+			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+		},
+		Roles: []string{role.User.String()},
+	}
+
+	token, err := ath.GenerateToken(kid, claims)
+	if err != nil {
+		t.Fatalf("Should be able to generate a JWT : %s", err)
+	}
+
+	for range 2 {
+		if _, err := remote.Authenticate(context.Background(), "Bearer "+token); err != nil {
+			t.Fatalf("Should be able to authenticate with the remote keys : %s", err)
+		}
+	}
+
+	if _, err := remote.GenerateToken(kid, claims); err == nil {
+		t.Fatal("Should NOT be able to sign with the remote keys")
+	}
+
+	// An unknown kid must not trigger a fetch inside the min refresh window.
+
+	tkn := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tkn.Header["kid"] = "unknown"
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privateKeyPEM))
+	if err != nil {
+		t.Fatalf("Should be able to parse the private key : %s", err)
+	}
+	unknown, err := tkn.SignedString(privateKey)
+	if err != nil {
+		t.Fatalf("Should be able to sign the token : %s", err)
+	}
+
+	if _, err := remote.Authenticate(context.Background(), "Bearer "+unknown); err == nil {
+		t.Fatal("Should NOT be able to authenticate a token with an unknown kid")
+	}
+
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("Should have fetched the JWKS once, got %d", n)
+	}
+}
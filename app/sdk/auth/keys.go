@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// signingMethods lists the algorithms tokens can be signed with. The one used
+// for a token follows the type of the key of its kid.
+var signingMethods = []string{
+	jwt.SigningMethodRS256.Alg(),
+	jwt.SigningMethodES256.Alg(),
+	jwt.SigningMethodEdDSA.Alg(),
+}
+
+// SigningMethods returns the algorithms tokens can be signed with.
+func SigningMethods() []string {
+	methods := make([]string, len(signingMethods))
+	copy(methods, signingMethods)
+	return methods
+}
+
+// signingMethod returns the signing method that goes with the type of the
+// specified private or public key.
+func signingMethod(key any) (jwt.SigningMethod, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey, *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+
+	case *ecdsa.PrivateKey:
+		return ecdsaSigningMethod(k.Curve)
+
+	case *ecdsa.PublicKey:
+		return ecdsaSigningMethod(k.Curve)
+
+	case ed25519.PrivateKey, ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %T", key)
+}
+
+func ecdsaSigningMethod(curve elliptic.Curve) (jwt.SigningMethod, error) {
+	if curve != elliptic.P256() {
+		return nil, fmt.Errorf("unsupported curve %s", curve.Params().Name)
+	}
+
+	return jwt.SigningMethodES256, nil
+}
+
+// parsePrivateKey parses a PEM encoded RSA, ECDSA or Ed25519 private key in
+// PKCS1, PKCS8 or SEC1 form.
+func parsePrivateKey(privatePEM string) (any, error) {
+	block, _ := pem.Decode([]byte(privatePEM))
+	if block == nil {
+		return nil, errors.New("invalid key: key must be a PEM encoded private key")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New("invalid key: key must be a PKCS1, PKCS8 or SEC1 private key")
+	}
+
+	return key, nil
+}
+
+// parsePublicKey parses a PEM encoded PKIX public key.
+func parsePublicKey(publicPEM string) (any, error) {
+	block, _ := pem.Decode([]byte(publicPEM))
+	if block == nil {
+		return nil, errors.New("invalid key: key must be a PEM encoded public key")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key: %w", err)
+	}
+
+	return key, nil
+}
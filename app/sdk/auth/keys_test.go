@@ -0,0 +1,183 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/rmsj/service/app/sdk/auth"
+	"github.com/rmsj/service/business/types/role"
+	"github.com/rmsj/service/foundation/keystore"
+)
+
+func Test_Algorithms(t *testing.T) {
+	log := newUnit(t)
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Should be able to generate an ecdsa key: %s", err)
+	}
+
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Should be able to generate an ed25519 key: %s", err)
+	}
+
+	ks := keystore.New()
+	loadKey(t, ks, "rsa", privateKeyPEM)
+	loadKey(t, ks, "ecdsa", toPrivatePEM(t, ecdsaKey))
+	loadKey(t, ks, "ed25519", toPrivatePEM(t, ed25519Key))
+
+	ath, err := auth.New(auth.Config{
+		Log:       log,
+		KeyLookup: ks,
+		Issuer:    "service project",
+	})
+	if err != nil {
+		t.Fatalf("Should be able to create an authenticator: %s", err)
+	}
+
+	claims := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    ath.Issuer(),
+			Subject:   "5cf37266-3473-4006-984f-9325122678b7",
+			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+		},
+		Roles: []string{role.User.String()},
+	}
+
+	set, err := ath.JWKS()
+	if err != nil {
+		t.Fatalf("Should be able to build the JWKS: %s", err)
+	}
+
+	algs := make(map[string]string)
+	for _, jwk := range set.Keys {
+		algs[jwk.KeyID] = jwk.Algorithm
+
+		pem, err := jwk.PEM()
+		if err != nil {
+			t.Fatalf("kid[%s]: Should be able to convert the JWK back to PEM: %s", jwk.KeyID, err)
+		}
+
+		exp, err := ks.PublicKey(jwk.KeyID)
+		if err != nil {
+			t.Fatalf("kid[%s]: Should be able to get the public key: %s", jwk.KeyID, err)
+		}
+
+		if pem != exp {
+			t.Errorf("kid[%s]: Should get back the same public key", jwk.KeyID)
+		}
+	}
+
+	tests := []struct {
+		kid string
+		alg string
+	}{
+		{kid: "rsa", alg: "RS256"},
+		{kid: "ecdsa", alg: "ES256"},
+		{kid: "ed25519", alg: "EdDSA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kid, func(t *testing.T) {
+			if algs[tt.kid] != tt.alg {
+				t.Errorf("Should publish the key with alg %s, got %s", tt.alg, algs[tt.kid])
+			}
+
+			token, err := ath.GenerateToken(tt.kid, claims)
+			if err != nil {
+				t.Fatalf("Should be able to generate a JWT : %s", err)
+			}
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &auth.Claims{})
+			if err != nil {
+				t.Fatalf("Should be able to parse the JWT : %s", err)
+			}
+
+			if parsed.Method.Alg() != tt.alg {
+				t.Errorf("Should sign with %s, got %s", tt.alg, parsed.Method.Alg())
+			}
+
+			if _, err := ath.Authenticate(context.Background(), "Bearer "+token); err != nil {
+				t.Fatalf("Should be able to authenticate the claims : %s", err)
+			}
+
+			// Tampering with the payload must break the signature.
+
+			tampered := token[:len(token)-4] + "AAAA"
+			if tampered == token {
+				tampered = token[:len(token)-4] + "BBBB"
+			}
+
+			if _, err := ath.Authenticate(context.Background(), "Bearer "+tampered); err == nil {
+				t.Error("Should NOT be able to authenticate a tampered token")
+			}
+
+			// An expired token must be rejected, whatever verifies the signature.
+
+			expired := claims
+			expired.ExpiresAt = jwt.NewNumericDate(time.Now().UTC().Add(-time.Minute))
+
+			token, err = ath.GenerateToken(tt.kid, expired)
+			if err != nil {
+				t.Fatalf("Should be able to generate a JWT : %s", err)
+			}
+
+			if _, err := ath.Authenticate(context.Background(), "Bearer "+token); err == nil {
+				t.Error("Should NOT be able to authenticate an expired token")
+			}
+		})
+	}
+
+	// A token can't pick an algorithm other than the one of its key.
+
+	tkn := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	tkn.Header["kid"] = "ecdsa"
+
+	token, err := tkn.SignedString(ed25519Key)
+	if err != nil {
+		t.Fatalf("Should be able to sign the token : %s", err)
+	}
+
+	if _, err := ath.Authenticate(context.Background(), "Bearer "+token); err == nil {
+		t.Error("Should NOT be able to authenticate a token with the wrong alg for its key")
+	}
+}
+
+func loadKey(t *testing.T, ks *keystore.KeyStore, kid string, privatePEM string) {
+	doc, err := json.Marshal(struct {
+		Key string `json:"key"`
+		PEM string `json:"pem"`
+	}{
+		Key: kid,
+		PEM: privatePEM,
+	})
+	if err != nil {
+		t.Fatalf("encoding key document: %s", err)
+	}
+
+	if _, err := ks.LoadByJSON(string(doc)); err != nil {
+		t.Fatalf("kid[%s]: Should be able to load the key: %s", kid, err)
+	}
+}
+
+func toPrivatePEM(t *testing.T, privateKey crypto.Signer) string {
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("marshaling private key: %s", err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
@@ -0,0 +1,44 @@
+package auth
+
+import "slices"
+
+// These are the permissions routes can require. The roles granting them,
+// and the role hierarchy, are defined in the authorization policy.
+const (
+	PermProductList   = "product:list"
+	PermProductRead   = "product:read"
+	PermProductCreate = "product:create"
+	PermProductWrite  = "product:write"
+	PermProductDelete = "product:delete"
+
+	PermUserRead   = "user:read"
+	PermUserCreate = "user:create"
+	PermUserWrite  = "user:write"
+	PermUserDelete = "user:delete"
+	PermUserRole   = "user:role"
+)
+
+// permissions is the catalogue of the permissions above.
+var permissions = []string{
+	PermProductList,
+	PermProductRead,
+	PermProductCreate,
+	PermProductWrite,
+	PermProductDelete,
+	PermUserRead,
+	PermUserCreate,
+	PermUserWrite,
+	PermUserDelete,
+	PermUserRole,
+}
+
+// Permissions returns the catalogue of permissions, the scopes an API key
+// can be limited to.
+func Permissions() []string {
+	return slices.Clone(permissions)
+}
+
+// IsPermission reports if the permission is in the catalogue.
+func IsPermission(permission string) bool {
+	return slices.Contains(permissions, permission)
+}
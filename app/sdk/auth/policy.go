@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/bundle"
+	"github.com/open-policy-agent/opa/v1/rego"
+)
+
+// policySet is a compiled version of the policies, with a prepared query for
+// each of the rules. It's never modified once built, it's replaced.
+type policySet struct {
+	revision string
+	queries  map[string]rego.PreparedEvalQuery
+}
+
+// LoadPolicies loads the rego policies rooted inside of a directory. Every
+// .rego file is a module, and every .tar.gz file is an OPA bundle whose
+// modules are loaded too. The policies are compiled and must define all the
+// rules before they replace the current ones, so a bad change never takes
+// effect. It returns the number of modules loaded.
+func (a *Auth) LoadPolicies(ctx context.Context, fsys fs.FS) (int, error) {
+	modules, err := readModules(fsys)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(modules) == 0 {
+		return 0, fmt.Errorf("no policies found")
+	}
+
+	revision := policyRevision(modules)
+
+	if current := a.policies.Load(); current != nil && current.revision == revision {
+		return len(modules), nil
+	}
+
+	set, err := compilePolicies(ctx, modules, revision)
+	if err != nil {
+		return 0, err
+	}
+
+	a.policies.Store(set)
+
+	if a.log != nil {
+		a.log.Info(ctx, "auth", "status", "policies loaded", "revision", revision, "modules", len(modules))
+	}
+
+	return len(modules), nil
+}
+
+// ReloadPolicies reloads the policies from the file system on the specified
+// interval until the context is cancelled. Errors are reported to the error
+// function and the previous policies are kept.
+func (a *Auth) ReloadPolicies(ctx context.Context, fsys fs.FS, interval time.Duration, errFn func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if _, err := a.LoadPolicies(ctx, fsys); err != nil && errFn != nil {
+				errFn(err)
+			}
+		}
+	}
+}
+
+// PolicyRevision returns the revision of the policies in use, a hash of
+// their source.
+func (a *Auth) PolicyRevision() string {
+	set := a.policies.Load()
+	if set == nil {
+		return ""
+	}
+
+	return set.revision
+}
+
+// =============================================================================
+
+// compilePolicies compiles the modules and prepares a query for each of the
+// rules, failing if any of them isn't defined.
+func compilePolicies(ctx context.Context, modules map[string]string, revision string) (*policySet, error) {
+	parsed := make(map[string]*ast.Module, len(modules))
+	for name, src := range modules {
+		module, err := ast.ParseModule(name, src)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", name, err)
+		}
+		parsed[name] = module
+	}
+
+	compiler := ast.NewCompiler()
+	if compiler.Compile(parsed); compiler.Failed() {
+		return nil, fmt.Errorf("compiling: %w", compiler.Errors)
+	}
+
+	set := policySet{
+		revision: revision,
+		queries:  make(map[string]rego.PreparedEvalQuery, len(rules)),
+	}
+
+	for _, rule := range rules {
+		ref := fmt.Sprintf("data.%s.%s", opaPackage, rule)
+
+		if len(compiler.GetRulesExact(ast.MustParseRef(ref))) == 0 {
+			return nil, fmt.Errorf("rule %q is not defined", rule)
+		}
+
+		q, err := rego.New(
+			rego.Query("x = "+ref),
+			rego.Compiler(compiler),
+		).PrepareForEval(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("preparing %s: %w", rule, err)
+		}
+
+		set.queries[rule] = q
+	}
+
+	return &set, nil
+}
+
+// readModules reads the source of the rego modules in the file system, keyed
+// by their path.
+func readModules(fsys fs.FS) (map[string]string, error) {
+	modules := make(map[string]string)
+
+	fn := func(fileName string, dirEntry fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("walkdir failure: %w", err)
+		}
+
+		if dirEntry.IsDir() {
+			return nil
+		}
+
+		switch {
+		case path.Ext(fileName) == ".rego":
+			data, err := fs.ReadFile(fsys, fileName)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", fileName, err)
+			}
+			modules[fileName] = string(data)
+
+		case strings.HasSuffix(fileName, ".tar.gz"):
+			data, err := fs.ReadFile(fsys, fileName)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", fileName, err)
+			}
+
+			b, err := bundle.NewReader(bytes.NewReader(data)).Read()
+			if err != nil {
+				return fmt.Errorf("reading bundle %s: %w", fileName, err)
+			}
+
+			for _, mf := range b.Modules {
+				modules[fileName+"/"+strings.TrimPrefix(mf.Path, "/")] = string(mf.Raw)
+			}
+		}
+
+		return nil
+	}
+
+	if err := fs.WalkDir(fsys, ".", fn); err != nil {
+		return nil, fmt.Errorf("walking directory: %w", err)
+	}
+
+	return modules, nil
+}
+
+// policyRevision hashes the modules, so unchanged policies aren't compiled
+// again on every reload.
+func policyRevision(modules map[string]string) string {
+	h := sha256.New()
+
+	for _, name := range slices.Sorted(maps.Keys(modules)) {
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+		h.Write([]byte(modules[name]))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
@@ -0,0 +1,143 @@
+package auth_test
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/uuid"
+	"github.com/open-policy-agent/opa/v1/bundle"
+
+	"github.com/rmsj/service/app/sdk/auth"
+	"github.com/rmsj/service/business/types/role"
+	"github.com/rmsj/service/foundation/logger"
+)
+
+func Test_Policies(t *testing.T) {
+	var decisions bytes.Buffer
+	decisionLog := logger.New(&decisions, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	ath, err := auth.New(auth.Config{
+		Log:                newUnit(t),
+		KeyLookup:          &keyStore{},
+		Issuer:             "service project",
+		DecisionLog:        decisionLog,
+		DecisionSampleRate: 0,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to create an authenticator: %s", err)
+	}
+
+	ctx := context.Background()
+	userID := uuid.MustParse("5cf37266-3473-4006-984f-9325122678b7")
+	admin := auth.Claims{Roles: []string{role.Admin.String()}}
+
+	defaultRevision := ath.PolicyRevision()
+	if defaultRevision == "" {
+		t.Fatal("Should have the default policies loaded")
+	}
+
+	if err := ath.Authorize(ctx, admin, userID, auth.RuleAdminOnly); err != nil {
+		t.Fatalf("Should be able to authorize an admin with the default policies: %s", err)
+	}
+
+	if decisions.Len() != 0 {
+		t.Errorf("Should NOT log allowed decisions when the sample rate is 0, got %q", decisions.String())
+	}
+
+	// -------------------------------------------------------------------------
+	// A policy denying everything to admins replaces the default ones.
+
+	denyAdmins := fstest.MapFS{
+		"authentication.rego": defaultPolicy(t, "authentication.rego"),
+		"authorization.rego": &fstest.MapFile{
+			Data: []byte(strings.Replace(string(defaultPolicy(t, "authorization.rego").Data), "input_admin := {role_admin} & claim_roles", "input_admin := set()", -1)),
+		},
+	}
+
+	if _, err := ath.LoadPolicies(ctx, denyAdmins); err != nil {
+		t.Fatalf("Should be able to load the policies: %s", err)
+	}
+
+	if ath.PolicyRevision() == defaultRevision {
+		t.Error("Should have a new revision after the policies changed")
+	}
+
+	if err := ath.Authorize(ctx, admin, userID, auth.RuleAdminOnly); err == nil {
+		t.Error("Should NOT be able to authorize an admin with the new policies")
+	}
+
+	if !strings.Contains(decisions.String(), `"rule":"rule_admin_only"`) || !strings.Contains(decisions.String(), `"result":false`) {
+		t.Errorf("Should log the denied decision, got %q", decisions.String())
+	}
+
+	// -------------------------------------------------------------------------
+	// Invalid policies are rejected and the current ones are kept.
+
+	revision := ath.PolicyRevision()
+
+	invalid := map[string]fstest.MapFS{
+		"syntax": {
+			"authorization.rego": &fstest.MapFile{Data: []byte("package ardan.rego\n\nrule_any if {")},
+		},
+		"missing-rule": {
+			"authorization.rego": defaultPolicy(t, "authorization.rego"),
+		},
+		"empty": {},
+	}
+
+	for name, fsys := range invalid {
+		if _, err := ath.LoadPolicies(ctx, fsys); err == nil {
+			t.Errorf("%s: Should NOT be able to load the policies", name)
+		}
+
+		if ath.PolicyRevision() != revision {
+			t.Errorf("%s: Should keep the current policies", name)
+		}
+	}
+
+	// -------------------------------------------------------------------------
+	// Policies can be loaded from a bundle.
+
+	if _, err := ath.LoadPolicies(ctx, fstest.MapFS{"policies.tar.gz": defaultBundle(t)}); err != nil {
+		t.Fatalf("Should be able to load the bundle: %s", err)
+	}
+
+	if err := ath.Authorize(ctx, admin, userID, auth.RuleAdminOnly); err != nil {
+		t.Errorf("Should be able to authorize an admin with the bundle policies: %s", err)
+	}
+}
+
+func defaultPolicy(t *testing.T, name string) *fstest.MapFile {
+	data, err := fs.ReadFile(auth.DefaultPolicies(), name)
+	if err != nil {
+		t.Fatalf("Should be able to read the default policy %s: %s", name, err)
+	}
+
+	return &fstest.MapFile{Data: data}
+}
+
+func defaultBundle(t *testing.T) *fstest.MapFile {
+	b := bundle.Bundle{
+		Manifest: bundle.Manifest{Revision: "test"},
+		Data:     map[string]any{},
+	}
+
+	for _, name := range []string{"authentication.rego", "authorization.rego"} {
+		b.Modules = append(b.Modules, bundle.ModuleFile{
+			URL:  "/" + name,
+			Path: "/" + name,
+			Raw:  defaultPolicy(t, name).Data,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := bundle.NewWriter(&buf).Write(b); err != nil {
+		t.Fatalf("Should be able to write the bundle: %s", err)
+	}
+
+	return &fstest.MapFile{Data: buf.Bytes()}
+}
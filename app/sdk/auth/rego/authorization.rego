@@ -4,9 +4,15 @@ import rego.v1
 
 role_user := "user"
 
+role_staff := "staff"
+
+role_manager := "manager"
+
+role_support := "support"
+
 role_admin := "admin"
 
-role_all := {role_admin, role_user}
+role_all := {role_admin, role_support, role_manager, role_staff, role_user}
 
 default rule_any := false
 
@@ -44,3 +50,73 @@ rule_admin_or_subject if {
 	count(input_user) > 0
 	input.UserID == input.Subject
 }
+
+# =============================================================================
+# Permissions
+
+# Every role inherits the permissions of the roles it's above.
+role_inherits := {
+	role_admin: {role_support},
+	role_support: {role_manager},
+	role_manager: {role_staff},
+	role_staff: {role_user},
+	role_user: set(),
+}
+
+# The permissions granted to each role on top of the inherited ones. A
+# permission ending in ":own" is only granted on the resources owned by the
+# subject.
+role_permissions := {
+	role_user: {
+		"product:list",
+		"product:read:own",
+		"product:create",
+		"product:write:own",
+		"product:delete:own",
+		"user:read:own",
+		"user:write:own",
+		"user:delete:own",
+	},
+	role_staff: {"product:read", "user:read"},
+	role_manager: {"product:write", "product:delete"},
+	role_support: {"user:write"},
+	role_admin: {"user:create", "user:delete", "user:role"},
+}
+
+claimed_roles := role_all & {role | some role in input.Roles}
+
+effective_roles := graph.reachable(role_inherits, claimed_roles)
+
+permissions contains permission if {
+	some role in effective_roles
+	some permission in role_permissions[role]
+}
+
+# The permissions managing users are only granted on the users below the
+# subject, so nobody can take over an account of the same or a higher role.
+# Admins manage everyone.
+user_management := {"user:write", "user:delete", "user:role"}
+
+roles_below := graph.reachable(role_inherits, {role | some claimed in claimed_roles; some role in role_inherits[claimed]})
+
+target_roles := role_all & {role | some role in object.get(input, "UserRoles", [])}
+
+default target_allowed := false
+
+target_allowed if {
+	not input.Permission in user_management
+} else if {
+	role_admin in claimed_roles
+} else if {
+	count(target_roles - roles_below) == 0
+}
+
+default rule_permission := false
+
+rule_permission if {
+	input.Permission in permissions
+	target_allowed
+} else if {
+	concat(":", [input.Permission, "own"]) in permissions
+	input.UserID == input.Subject
+}
@@ -1,7 +1,8 @@
 package auth
 
 import (
-	_ "embed"
+	"embed"
+	"io/fs"
 )
 
 // These are the current set of rules we have for auth.
@@ -11,18 +12,32 @@ const (
 	RuleAdminOnly      = "rule_admin_only"
 	RuleUserOnly       = "rule_user_only"
 	RuleAdminOrSubject = "rule_admin_or_subject"
+	RulePermission     = "rule_permission"
 )
 
+// rules are the rules every set of policies must define.
+var rules = []string{
+	RuleAuthenticate,
+	RuleAny,
+	RuleAdminOnly,
+	RuleUserOnly,
+	RuleAdminOrSubject,
+	RulePermission,
+}
+
 // Package name of our rego code.
 const (
 	opaPackage string = "ardan.rego"
 )
 
-// Core OPA policies.
-var (
-	//go:embed rego/authentication.rego
-	regoAuthentication string
+// Core OPA policies, used until policies are loaded from elsewhere.
+//
+//go:embed rego/*.rego
+var regoPolicies embed.FS
 
-	//go:embed rego/authorization.rego
-	regoAuthorization string
-)
+// DefaultPolicies returns the core policies embedded in the binary, as a
+// starting point for the policies loaded from a directory.
+func DefaultPolicies() fs.FS {
+	fsys, _ := fs.Sub(regoPolicies, "rego")
+	return fsys
+}
@@ -11,8 +11,12 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/app/sdk/auth"
 	"github.com/rmsj/service/app/sdk/errs"
 	"github.com/rmsj/service/foundation/logger"
 	"github.com/rmsj/service/foundation/otel"
@@ -43,6 +47,7 @@ type Client struct {
 	log  *logger.Logger
 	url  string
 	http *http.Client
+	auth *auth.Auth
 }
 
 // New constructs an Auth that can be used to talk with the auth service.
@@ -68,8 +73,26 @@ func WithClient(http *http.Client) func(cln *Client) {
 	}
 }
 
+// WithLocalAuth makes the client verify tokens and evaluate rules locally
+// with the provided Auth, usually built with a JWKSLookup, instead of calling
+// the auth service on every request.
+func WithLocalAuth(ath *auth.Auth) func(cln *Client) {
+	return func(cln *Client) {
+		cln.auth = ath
+	}
+}
+
+// JWKSURL returns the url of the key set published by the auth service.
+func JWKSURL(authURL string) string {
+	return fmt.Sprintf("%s/.well-known/jwks.json", strings.TrimSuffix(authURL, "/"))
+}
+
 // Authenticate calls the auth service to authenticate the user.
 func (cln *Client) Authenticate(ctx context.Context, authorization string) (AuthenticateResp, error) {
+	if cln.auth != nil {
+		return cln.authenticateLocal(ctx, authorization)
+	}
+
 	endpoint := fmt.Sprintf("%s/v1/auth/authenticate", cln.url)
 
 	headers := map[string]string{
@@ -84,8 +107,33 @@ func (cln *Client) Authenticate(ctx context.Context, authorization string) (Auth
 	return resp, nil
 }
 
+// AuthenticateAPIKey calls the auth service to authenticate the owner of the
+// API key. Keys are only known to the auth service, so they are never
+// checked locally.
+func (cln *Client) AuthenticateAPIKey(ctx context.Context, key string) (AuthenticateResp, error) {
+	endpoint := fmt.Sprintf("%s/v1/auth/authenticate-api", cln.url)
+
+	headers := map[string]string{
+		"hg-api-key": key,
+	}
+
+	var resp AuthenticateResp
+	if err := cln.do(ctx, http.MethodGet, endpoint, headers, nil, &resp); err != nil {
+		return AuthenticateResp{}, err
+	}
+
+	return resp, nil
+}
+
 // Authorize calls the auth service to authorize the user.
 func (cln *Client) Authorize(ctx context.Context, auth Authorize) error {
+	if cln.auth != nil {
+		if auth.Permission != "" {
+			return cln.auth.AuthorizePermission(ctx, auth.Claims, auth.UserID, auth.UserRoles, auth.Permission)
+		}
+		return cln.auth.Authorize(ctx, auth.Claims, auth.UserID, auth.Rule)
+	}
+
 	endpoint := fmt.Sprintf("%s/v1/auth/authorize", cln.url)
 
 	if err := cln.do(ctx, http.MethodPost, endpoint, nil, auth, nil); err != nil {
@@ -95,6 +143,43 @@ func (cln *Client) Authorize(ctx context.Context, auth Authorize) error {
 	return nil
 }
 
+// ResendVerification calls the auth service to send the email verification
+// to the user with the email, which it only does when the email of the user
+// isn't verified. Emails are only sent by the auth service, so this is never
+// done locally.
+func (cln *Client) ResendVerification(ctx context.Context, email string) error {
+	endpoint := fmt.Sprintf("%s/v1/auth/resend-verification", cln.url)
+
+	body := ResendVerification{
+		Email: email,
+	}
+
+	if err := cln.do(ctx, http.MethodPost, endpoint, nil, body, nil); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (cln *Client) authenticateLocal(ctx context.Context, authorization string) (AuthenticateResp, error) {
+	claims, err := cln.auth.Authenticate(ctx, authorization)
+	if err != nil {
+		return AuthenticateResp{}, err
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return AuthenticateResp{}, fmt.Errorf("parsing subject: %w", err)
+	}
+
+	resp := AuthenticateResp{
+		UserID: userID,
+		Claims: claims,
+	}
+
+	return resp, nil
+}
+
 func (cln *Client) do(ctx context.Context, method string, endpoint string, headers map[string]string, body any, v any) error {
 	var statusCode int
 
@@ -8,10 +8,14 @@ import (
 )
 
 // Authorize defines the information required to perform an authorization.
+// When a Permission is specified it is checked instead of the Rule. The
+// UserRoles are the roles of the user the permission is checked on, if any.
 type Authorize struct {
-	UserID uuid.UUID
-	Claims auth.Claims
-	Rule   string
+	UserID     uuid.UUID
+	Claims     auth.Claims
+	Rule       string
+	Permission string   `json:",omitempty"`
+	UserRoles  []string `json:",omitempty"`
 }
 
 // Decode implements the decoder interface.
@@ -19,6 +23,12 @@ func (a *Authorize) Decode(data []byte) error {
 	return json.Unmarshal(data, a)
 }
 
+// ResendVerification defines the information required to send the email
+// verification again.
+type ResendVerification struct {
+	Email string `json:"email"`
+}
+
 // AuthenticateResp defines the information that will be received on authenticate.
 type AuthenticateResp struct {
 	UserID uuid.UUID
@@ -116,6 +116,17 @@ var (
 	// system has been broken. If you see one of these errors,
 	// something is very broken. The error message is not sent to the client.
 	InternalOnlyLog = ErrCode{value: 19}
+
+	// Unprocessable indicates the request is well formed but can't be
+	// processed as it is, for example, an idempotency key reused with a
+	// different request.
+	Unprocessable = ErrCode{value: 20}
+
+	// PreconditionFailed indicates a conditional request was rejected because
+	// the resource doesn't match the precondition of the request, like an
+	// If-Match header with an old entity tag. Use FailedPrecondition for
+	// requests that can't run in the current state of the system.
+	PreconditionFailed = ErrCode{value: 21}
 )
 
 var codeNumbers = map[string]ErrCode{
@@ -139,6 +150,8 @@ var codeNumbers = map[string]ErrCode{
 	"unauthenticated":     Unauthenticated,
 	"too_many_requests":   TooManyRequests,
 	"internal_only_log":   InternalOnlyLog,
+	"unprocessable":       Unprocessable,
+	"precondition_failed": PreconditionFailed,
 }
 
 var codeNames = map[ErrCode]string{
@@ -162,6 +175,8 @@ var codeNames = map[ErrCode]string{
 	Unauthenticated:    "unauthenticated",
 	TooManyRequests:    "too_many_requests",
 	InternalOnlyLog:    "internal_only_log",
+	Unprocessable:      "unprocessable",
+	PreconditionFailed: "precondition_failed",
 }
 
 var httpStatus = map[ErrCode]int{
@@ -185,4 +200,6 @@ var httpStatus = map[ErrCode]int{
 	Unauthenticated:    http.StatusUnauthorized,
 	TooManyRequests:    http.StatusTooManyRequests,
 	InternalOnlyLog:    http.StatusInternalServerError,
+	Unprocessable:      http.StatusUnprocessableEntity,
+	PreconditionFailed: http.StatusPreconditionFailed,
 }
@@ -16,10 +16,11 @@ var m metrics
 // metrics represents the set of metrics we gather. These fields are
 // safe to be accessed concurrently thanks to expvar. No extra abstraction is required.
 type metrics struct {
-	goroutines *expvar.Int
-	requests   *expvar.Int
-	errors     *expvar.Int
-	panics     *expvar.Int
+	goroutines  *expvar.Int
+	requests    *expvar.Int
+	errors      *expvar.Int
+	panics      *expvar.Int
+	rateLimited *expvar.Map
 }
 
 // init constructs the metrics value that will be used to capture metrics.
@@ -28,10 +29,11 @@ type metrics struct {
 // sure this initialization only happens once.
 func init() {
 	m = metrics{
-		goroutines: expvar.NewInt("goroutines"),
-		requests:   expvar.NewInt("requests"),
-		errors:     expvar.NewInt("errors"),
-		panics:     expvar.NewInt("panics"),
+		goroutines:  expvar.NewInt("goroutines"),
+		requests:    expvar.NewInt("requests"),
+		errors:      expvar.NewInt("errors"),
+		panics:      expvar.NewInt("panics"),
+		rateLimited: expvar.NewMap("rate_limited"),
 	}
 }
 
@@ -85,3 +87,11 @@ func AddPanics(ctx context.Context) int64 {
 
 	return 0
 }
+
+// AddRateLimited increments the metric of the requests rejected by the rate
+// limiter for the route group by 1.
+func AddRateLimited(ctx context.Context, group string) {
+	if v, ok := ctx.Value(key).(*metrics); ok {
+		v.rateLimited.Add(group, 1)
+	}
+}
@@ -0,0 +1,37 @@
+package mid
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/domain/auditbus"
+	"github.com/rmsj/service/foundation/otel"
+	"github.com/rmsj/service/foundation/web"
+)
+
+// NewAudit builds the record of a change made by the request. The actor is
+// the subject of the claims or, on the routes without claims, the user the
+// request was authenticated as, if any. Before and after are the app models of
+// the entity, since those never carry secrets.
+func NewAudit(ctx context.Context, r *http.Request, domain string, action string, entityID string, before any, after any) auditbus.NewAudit {
+	actorID := GetSubjectID(ctx)
+	if actorID == uuid.Nil {
+		if userID, err := GetUserID(ctx); err == nil {
+			actorID = userID
+		}
+	}
+
+	return auditbus.NewAudit{
+		ActorID:   actorID,
+		Action:    action,
+		Domain:    domain,
+		EntityID:  entityID,
+		Before:    before,
+		After:     after,
+		RequestID: web.GetRequestID(ctx),
+		TraceID:   otel.GetTraceID(ctx),
+		IPAddress: web.RemoteIP(r),
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"net/http"
 	"net/mail"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,15 +22,28 @@ import (
 	"github.com/rmsj/service/foundation/web"
 )
 
+// APIKeyHeader is the header clients send their API key in.
+const APIKeyHeader = "hg-api-key"
+
 // Authenticate is a middleware function that integrates with an authentication client
 // to validate user credentials and attach user data to the request context.
+// Requests carrying an API key, and no token, are authenticated by the key.
 func Authenticate(client *authclient.Client) web.MidFunc {
 	m := func(next web.HandlerFunc) web.HandlerFunc {
 		h := func(ctx context.Context, r *http.Request) web.Encoder {
 			ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 			defer cancel()
 
-			resp, err := client.Authenticate(ctx, r.Header.Get("authorization"))
+			var resp authclient.AuthenticateResp
+			var err error
+
+			switch key := r.Header.Get(APIKeyHeader); {
+			case key != "" && r.Header.Get("authorization") == "":
+				resp, err = client.AuthenticateAPIKey(ctx, key)
+			default:
+				resp, err = client.Authenticate(ctx, r.Header.Get("authorization"))
+			}
+
 			if err != nil {
 				return errs.New(errs.Unauthenticated, err)
 			}
@@ -48,9 +62,35 @@ func Authenticate(client *authclient.Client) web.MidFunc {
 
 // Bearer processes JWT authentication logic.
 func Bearer(ath *auth.Auth) web.MidFunc {
+	return bearer(ath.Authenticate)
+}
+
+// MFAPending processes JWT authentication logic for the mfa_pending tokens
+// issued by login when a second factor is needed.
+func MFAPending(ath *auth.Auth) web.MidFunc {
+	return bearer(ath.AuthenticateMFAPending)
+}
+
+// MFABearer processes JWT authentication logic accepting both access tokens
+// and mfa_pending tokens, so users that must use MFA can enroll before they
+// are able to finish their login.
+func MFABearer(ath *auth.Auth) web.MidFunc {
+	authenticate := func(ctx context.Context, bearerToken string) (auth.Claims, error) {
+		claims, err := ath.AuthenticateMFAPending(ctx, bearerToken)
+		if err == nil {
+			return claims, nil
+		}
+
+		return ath.Authenticate(ctx, bearerToken)
+	}
+
+	return bearer(authenticate)
+}
+
+func bearer(authenticate func(ctx context.Context, bearerToken string) (auth.Claims, error)) web.MidFunc {
 	m := func(next web.HandlerFunc) web.HandlerFunc {
 		h := func(ctx context.Context, r *http.Request) web.Encoder {
-			claims, err := ath.Authenticate(ctx, r.Header.Get("authorization"))
+			claims, err := authenticate(ctx, r.Header.Get("authorization"))
 			if err != nil {
 				return errs.New(errs.Unauthenticated, err)
 			}
@@ -77,7 +117,7 @@ func Bearer(ath *auth.Auth) web.MidFunc {
 }
 
 // Basic processes basic authentication logic.
-func Basic(ath *auth.Auth, userBus *userbus.Business) web.MidFunc {
+func Basic(ath *auth.Auth, authBus *authbus.Business, userBus *userbus.Business) web.MidFunc {
 	m := func(next web.HandlerFunc) web.HandlerFunc {
 		h := func(ctx context.Context, r *http.Request) web.Encoder {
 			email, pass, ok := parseBasicAuth(r.Header.Get("authorization"))
@@ -90,9 +130,9 @@ func Basic(ath *auth.Auth, userBus *userbus.Business) web.MidFunc {
 				return errs.New(errs.Unauthenticated, err)
 			}
 
-			usr, err := userBus.Authenticate(ctx, *addr, pass)
-			if err != nil {
-				return errs.New(errs.Unauthenticated, err)
+			usr, errE := authenticateUser(ctx, r, authBus, userBus, *addr, pass)
+			if errE != nil {
+				return errE
 			}
 
 			claims := auth.Claims{
@@ -123,7 +163,7 @@ func Basic(ath *auth.Auth, userBus *userbus.Business) web.MidFunc {
 }
 
 // Login processes username/password auth logic
-func Login(ath *auth.Auth, userBus *userbus.Business) web.MidFunc {
+func Login(ath *auth.Auth, authBus *authbus.Business, userBus *userbus.Business) web.MidFunc {
 
 	m := func(next web.HandlerFunc) web.HandlerFunc {
 		h := func(ctx context.Context, r *http.Request) web.Encoder {
@@ -138,9 +178,9 @@ func Login(ath *auth.Auth, userBus *userbus.Business) web.MidFunc {
 				return errs.New(errs.Unauthenticated, err)
 			}
 
-			usr, err := userBus.Authenticate(ctx, *addr, app.Password)
-			if err != nil {
-				return errs.New(errs.Unauthenticated, err)
+			usr, errE := authenticateUser(ctx, r, authBus, userBus, *addr, app.Password)
+			if errE != nil {
+				return errE
 			}
 
 			now := GetTime(ctx)
@@ -171,45 +211,47 @@ func Login(ath *auth.Auth, userBus *userbus.Business) web.MidFunc {
 	return m
 }
 
-// RefreshToken processes refresh token auth logic
-func RefreshToken(ath *auth.Auth, userBus *userbus.Business) web.MidFunc {
+// RefreshToken processes refresh token auth logic. The refresh token is
+// rotated: the claims for a new access token and the refresh token that
+// replaces the one presented are set on the context.
+func RefreshToken(ath *auth.Auth, authBus *authbus.Business, userBus *userbus.Business) web.MidFunc {
 	m := func(next web.HandlerFunc) web.HandlerFunc {
 		h := func(ctx context.Context, r *http.Request) web.Encoder {
-			// to refresh the token, the current one must still be valid
-			claims, err := ath.Authenticate(ctx, r.Header.Get("authorization"))
-			if err != nil {
-				return errs.New(errs.Unauthenticated, err)
-			}
-
-			if claims.Subject == "" {
-				return errs.Newf(errs.Unauthenticated, "authorize: you are not authorized for that action, no claims")
-			}
-
-			subjectID, err := uuid.Parse(claims.Subject)
-			if err != nil {
-				return errs.Newf(errs.Unauthenticated, "parsing subject: %s", err)
-			}
-
 			var app auth.RefreshToken
 			if err := web.Decode(r, &app); err != nil {
 				return errs.New(errs.InvalidArgument, err)
 			}
 
-			usr, err := userBus.QueryByRefreshToken(ctx, app.Token)
+			rt, token, err := authBus.RotateRefreshToken(ctx, app.Token, r.UserAgent(), web.RemoteIP(r))
+			if err != nil {
+				return errs.New(errs.Unauthenticated, err)
+			}
+
+			usr, err := userBus.QueryByID(ctx, rt.UserID)
 			if err != nil {
 				return errs.New(errs.Unauthenticated, err)
 			}
 
-			if usr.ID != subjectID {
-				return errs.New(errs.Unauthenticated, errors.New("invalid token"))
+			if !usr.Enabled {
+				return errs.New(errs.Unauthenticated, errors.New("user disabled"))
 			}
-			ctx = setUserID(ctx, usr.ID)
 
 			now := GetTime(ctx)
-			claims.ExpiresAt = jwt.NewNumericDate(now.UTC().Add(8 * time.Hour))
-			claims.IssuedAt = jwt.NewNumericDate(now.UTC())
 
+			claims := auth.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Subject:   usr.ID.String(),
+					Issuer:    ath.Issuer(),
+					ExpiresAt: jwt.NewNumericDate(now.UTC().Add(8 * time.Hour)),
+					IssuedAt:  jwt.NewNumericDate(now.UTC()),
+				},
+				Roles:     role.ParseToString(usr.Roles),
+				SessionID: rt.FamilyID.String(),
+			}
+
+			ctx = setUserID(ctx, usr.ID)
 			ctx = setClaims(ctx, claims)
+			ctx = setRefreshToken(ctx, token)
 
 			return next(ctx, r)
 		}
@@ -249,15 +291,48 @@ func ResetToken(authBus *authbus.Business, userBus *userbus.Business) web.MidFun
 	return m
 }
 
-// APIKey processes API key authentication logic.
-func APIKey(ath *auth.Auth) web.MidFunc {
+// APIKey processes API key authentication logic. The key is checked against
+// the store and the claims of its owner, limited to the scopes of the key,
+// are put in the context, so the authorization middleware works the same as
+// with a token.
+func APIKey(ath *auth.Auth, authBus *authbus.Business, userBus *userbus.Business) web.MidFunc {
 	m := func(next web.HandlerFunc) web.HandlerFunc {
 		h := func(ctx context.Context, r *http.Request) web.Encoder {
-			authKey := r.Header.Get("hg-api-key")
-			if authKey != ath.APIKey() {
-				return errs.New(errs.Unauthenticated, errs.Newf(errs.Unauthenticated, "invalid API Key"))
+			key, err := authBus.AuthenticateAPIKey(ctx, r.Header.Get(APIKeyHeader))
+			if err != nil {
+				return errs.New(errs.Unauthenticated, err)
 			}
 
+			usr, err := userBus.QueryByID(ctx, key.UserID)
+			if err != nil {
+				return errs.New(errs.Unauthenticated, err)
+			}
+
+			if !usr.Enabled {
+				return errs.New(errs.Unauthenticated, errors.New("user disabled"))
+			}
+
+			now := GetTime(ctx)
+
+			expiresAt := now.Add(8 * time.Hour)
+			if !key.ExpiresAt.IsZero() && key.ExpiresAt.Before(expiresAt) {
+				expiresAt = key.ExpiresAt
+			}
+
+			claims := auth.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Subject:   usr.ID.String(),
+					Issuer:    ath.Issuer(),
+					ExpiresAt: jwt.NewNumericDate(expiresAt.UTC()),
+					IssuedAt:  jwt.NewNumericDate(now.UTC()),
+				},
+				Roles:  role.ParseToString(usr.Roles),
+				Scopes: key.Scopes,
+			}
+
+			ctx = setUserID(ctx, usr.ID)
+			ctx = setClaims(ctx, claims)
+
 			return next(ctx, r)
 		}
 
@@ -267,6 +342,76 @@ func APIKey(ath *auth.Auth) web.MidFunc {
 	return m
 }
 
+// LoginCheck checks a credential presented to log in. It reports if the
+// credential was wrong, which counts as a failed login, along with the error
+// to return.
+type LoginCheck func(ctx context.Context) (bool, *errs.Error)
+
+// GuardLogin runs the check of a login credential of the account under its
+// lockout. Failed logins are tracked per account and per source IP, and once
+// there are too many the attempts are refused without running the check.
+func GuardLogin(ctx context.Context, r *http.Request, authBus *authbus.Business, addr mail.Address, check LoginCheck) *errs.Error {
+	ip := web.RemoteIP(r)
+
+	retryAfter, err := authBus.CheckLogin(ctx, addr, ip)
+	if err != nil {
+		if errors.Is(err, authbus.ErrLoginBlocked) {
+			SetRetryAfter(ctx, retryAfter)
+			return errs.New(errs.TooManyRequests, err)
+		}
+		return errs.Newf(errs.Internal, "check login: %s", err)
+	}
+
+	if wrong, errCheck := check(ctx); errCheck != nil {
+		if wrong {
+			if _, err := authBus.LoginFailed(ctx, addr, ip); err != nil {
+				return errs.Newf(errs.Internal, "login failed: %s", err)
+			}
+		}
+		return errCheck
+	}
+
+	if err := authBus.LoginSucceeded(ctx, addr); err != nil {
+		return errs.Newf(errs.Internal, "login succeeded: %s", err)
+	}
+
+	return nil
+}
+
+// SetRetryAfter tells the client how long to wait, in whole seconds, before
+// trying again.
+func SetRetryAfter(ctx context.Context, d time.Duration) {
+	web.GetWriter(ctx).Header().Set("Retry-After", ceilSeconds(d))
+}
+
+// authenticateUser checks the credentials of the user under the lockout of
+// the account.
+func authenticateUser(ctx context.Context, r *http.Request, authBus *authbus.Business, userBus *userbus.Business, addr mail.Address, password string) (userbus.User, *errs.Error) {
+	var usr userbus.User
+
+	check := func(ctx context.Context) (bool, *errs.Error) {
+		var err error
+		if usr, err = userBus.Authenticate(ctx, addr, password); err != nil {
+			wrong := errors.Is(err, userbus.ErrNotFound) || errors.Is(err, userbus.ErrAuthenticationFailure)
+			return wrong, errs.New(errs.Unauthenticated, err)
+		}
+
+		return false, nil
+	}
+
+	if err := GuardLogin(ctx, r, authBus, addr, check); err != nil {
+		return userbus.User{}, err
+	}
+
+	return usr, nil
+}
+
+// ceilSeconds formats the duration in whole seconds, rounded up.
+func ceilSeconds(d time.Duration) string {
+	secs := int64((d + time.Second - 1) / time.Second)
+	return strconv.FormatInt(secs, 10)
+}
+
 func parseBasicAuth(auth string) (string, string, bool) {
 	parts := strings.Split(auth, " ")
 	if len(parts) != 2 || parts[0] != "Basic" {
@@ -11,8 +11,10 @@ import (
 	"github.com/rmsj/service/app/sdk/auth"
 	"github.com/rmsj/service/app/sdk/authclient"
 	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/business/domain/orderbus"
 	"github.com/rmsj/service/business/domain/productbus"
 	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/types/role"
 	"github.com/rmsj/service/foundation/web"
 )
 
@@ -50,20 +52,71 @@ func Authorize(client *authclient.Client, rule string) web.MidFunc {
 	return m
 }
 
-// AuthorizeUser executes the specified role and extracts the specified
-// user from the DB if a user id is specified in the call. Depending on the rule
-// specified, the userid from the claims may be compared with the specified
-// user id.
-func AuthorizeUser(client *authclient.Client, userBus *userbus.Business, rule string) web.MidFunc {
+// AuthorizeClaims validates authorization of the claims on the context with
+// the auth package directly, for the routes of the auth service itself.
+func AuthorizeClaims(ath *auth.Auth, rule string) web.MidFunc {
 	m := func(next web.HandlerFunc) web.HandlerFunc {
 		h := func(ctx context.Context, r *http.Request) web.Encoder {
-			id := web.Param(r, "user_id")
+			userID, err := GetUserID(ctx)
+			if err != nil {
+				return errs.New(errs.Unauthenticated, err)
+			}
 
-			var userID uuid.UUID
+			if err := ath.Authorize(ctx, GetClaims(ctx), userID, rule); err != nil {
+				return errs.New(errs.Unauthenticated, err)
+			}
+
+			return next(ctx, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// RequirePermission validates the claims grant the permission via the auth
+// service. When a resource was loaded by LoadUser or LoadProduct, its owner is
+// checked against the subject for the permissions granted on owned resources
+// only, and a loaded user's roles against the roles of the claims.
+func RequirePermission(client *authclient.Client, permission string) web.MidFunc {
+	m := func(next web.HandlerFunc) web.HandlerFunc {
+		h := func(ctx context.Context, r *http.Request) web.Encoder {
+			ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+
+			auth := authclient.Authorize{
+				UserID:     resourceOwner(ctx),
+				Claims:     GetClaims(ctx),
+				Permission: permission,
+			}
+
+			if usr, err := GetUser(ctx); err == nil {
+				auth.UserRoles = role.ParseToString(usr.Roles)
+			}
+
+			if err := client.Authorize(ctx, auth); err != nil {
+				return errs.New(errs.Unauthenticated, err)
+			}
+
+			return next(ctx, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// LoadUser extracts the user specified in the path from the DB, if any, for
+// the next middleware and the handler.
+func LoadUser(userBus *userbus.Business) web.MidFunc {
+	m := func(next web.HandlerFunc) web.HandlerFunc {
+		h := func(ctx context.Context, r *http.Request) web.Encoder {
+			id := web.Param(r, "user_id")
 
 			if id != "" {
-				var err error
-				userID, err = uuid.Parse(id)
+				userID, err := uuid.Parse(id)
 				if err != nil {
 					return errs.New(errs.Unauthenticated, ErrInvalidID)
 				}
@@ -81,19 +134,6 @@ func AuthorizeUser(client *authclient.Client, userBus *userbus.Business, rule st
 				ctx = setUser(ctx, usr)
 			}
 
-			ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-			defer cancel()
-
-			auth := authclient.Authorize{
-				Claims: GetClaims(ctx),
-				UserID: userID,
-				Rule:   rule,
-			}
-
-			if err := client.Authorize(ctx, auth); err != nil {
-				return errs.New(errs.Unauthenticated, err)
-			}
-
 			return next(ctx, r)
 		}
 
@@ -103,19 +143,14 @@ func AuthorizeUser(client *authclient.Client, userBus *userbus.Business, rule st
 	return m
 }
 
-// AuthorizeProduct executes the specified role and extracts the specified
-// product from the DB if a product id is specified in the call. Depending on
-// the rule specified, the userid from the claims may be compared with the
-// specified user id from the product.
-func AuthorizeProduct(client *authclient.Client, productBus *productbus.Business) web.MidFunc {
+// LoadProduct extracts the product specified in the path from the DB, if
+// any, for the next middleware and the handler.
+func LoadProduct(productBus *productbus.Business) web.MidFunc {
 	m := func(next web.HandlerFunc) web.HandlerFunc {
 		h := func(ctx context.Context, r *http.Request) web.Encoder {
 			id := web.Param(r, "product_id")
 
-			var userID uuid.UUID
-
 			if id != "" {
-				var err error
 				productID, err := uuid.Parse(id)
 				if err != nil {
 					return errs.New(errs.Unauthenticated, ErrInvalidID)
@@ -131,10 +166,49 @@ func AuthorizeProduct(client *authclient.Client, productBus *productbus.Business
 					}
 				}
 
-				userID = prd.UserID
 				ctx = setProduct(ctx, prd)
 			}
 
+			return next(ctx, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// AuthorizeOrder executes the specified role and extracts the specified
+// order from the DB if an order id is specified in the call. The userid from
+// the claims is compared with the user id that owns the order unless the
+// caller is an admin.
+func AuthorizeOrder(client *authclient.Client, orderBus *orderbus.Business) web.MidFunc {
+	m := func(next web.HandlerFunc) web.HandlerFunc {
+		h := func(ctx context.Context, r *http.Request) web.Encoder {
+			id := web.Param(r, "order_id")
+
+			var userID uuid.UUID
+
+			if id != "" {
+				orderID, err := uuid.Parse(id)
+				if err != nil {
+					return errs.New(errs.Unauthenticated, ErrInvalidID)
+				}
+
+				ord, err := orderBus.QueryByID(ctx, orderID)
+				if err != nil {
+					switch {
+					case errors.Is(err, orderbus.ErrNotFound):
+						return errs.New(errs.Unauthenticated, err)
+					default:
+						return errs.Newf(errs.Internal, "querybyid: orderID[%s]: %s", orderID, err)
+					}
+				}
+
+				userID = ord.UserID
+				ctx = setOrder(ctx, ord)
+			}
+
 			ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 			defer cancel()
 
@@ -156,3 +230,17 @@ func AuthorizeProduct(client *authclient.Client, productBus *productbus.Business
 
 	return m
 }
+
+// resourceOwner returns the owner of the resource loaded for the request, or
+// the zero id when there is none.
+func resourceOwner(ctx context.Context) uuid.UUID {
+	if usr, err := GetUser(ctx); err == nil {
+		return usr.ID
+	}
+
+	if prd, err := GetProduct(ctx); err == nil {
+		return prd.UserID
+	}
+
+	return uuid.UUID{}
+}
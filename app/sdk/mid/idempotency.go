@@ -0,0 +1,147 @@
+package mid
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/business/domain/idempotencybus"
+	"github.com/rmsj/service/foundation/web"
+)
+
+// idempotencyLease is how long an idempotency key is held while its request
+// is in progress. A key held by a request that never completed, because the
+// service stopped, can be used again once it passes.
+const idempotencyLease = time.Minute
+
+// maxIdempotencyKey is the longest idempotency key accepted.
+const maxIdempotencyKey = 255
+
+// Idempotency makes it safe for clients to retry the requests they send with
+// an Idempotency-Key header. The response of a request is stored with its
+// key and replayed to the retries until the TTL expires. A retry with a
+// different request is rejected, and so is one sent while the request is
+// still in progress. Keys are scoped to the subject of the claims. It must
+// come after the transaction middleware, so the response is stored in the
+// transaction of the request.
+func Idempotency(idempotencyBus *idempotencybus.Business, ttl time.Duration) web.MidFunc {
+	m := func(next web.HandlerFunc) web.HandlerFunc {
+		h := func(ctx context.Context, r *http.Request) web.Encoder {
+			key := r.Header.Get("Idempotency-Key")
+			if key == "" {
+				return next(ctx, r)
+			}
+
+			if len(key) > maxIdempotencyKey {
+				return errs.Newf(errs.InvalidArgument, "idempotency key longer than %d characters", maxIdempotencyKey)
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				return errs.Newf(errs.InvalidArgument, "read body: %s", err)
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			nr := idempotencybus.NewRecord{
+				UserID:      GetSubjectID(ctx),
+				Key:         key,
+				RequestHash: requestHash(r, body),
+				Lease:       idempotencyLease,
+			}
+
+			rec, err := idempotencyBus.Acquire(ctx, nr)
+			if err != nil {
+				switch {
+				case errors.Is(err, idempotencybus.ErrMismatch):
+					return errs.New(errs.Unprocessable, err)
+				case errors.Is(err, idempotencybus.ErrInProgress):
+					return errs.New(errs.Aborted, err)
+				}
+				return errs.Newf(errs.Internal, "acquire: key[%s]: %s", key, err)
+			}
+
+			if rec.Completed() {
+				web.GetWriter(ctx).Header().Set("Idempotent-Replayed", "true")
+				return idempotentResponse{rec: rec}
+			}
+
+			resp := next(ctx, r)
+
+			if isError(resp) != nil {
+				if err := idempotencyBus.Release(ctx, rec); err != nil {
+					return errs.Newf(errs.Internal, "release: key[%s]: %s", key, err)
+				}
+				return resp
+			}
+
+			cr := idempotencybus.CompleteRecord{
+				Status: web.StatusCode(resp),
+				TTL:    ttl,
+			}
+
+			if resp != nil {
+				cr.Response, cr.ContentType, err = resp.Encode()
+				if err != nil {
+					return errs.Newf(errs.Internal, "encode: %s", err)
+				}
+			}
+
+			// Without a transaction the response is stored on its own. If it
+			// fails to be stored, the key is held until the lease passes.
+			bus := idempotencyBus
+			if tx, err := GetTran(ctx); err == nil {
+				bus, err = idempotencyBus.NewWithTx(tx)
+				if err != nil {
+					return errs.New(errs.Internal, err)
+				}
+			}
+
+			if _, err := bus.Complete(ctx, rec, cr); err != nil {
+				return errs.Newf(errs.Internal, "complete: key[%s]: %s", key, err)
+			}
+
+			return resp
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// =============================================================================
+
+// idempotentResponse replays the response stored for an idempotency key.
+type idempotentResponse struct {
+	rec idempotencybus.Record
+}
+
+// Encode implements the encoder interface.
+func (ir idempotentResponse) Encode() ([]byte, string, error) {
+	return ir.rec.Response, ir.rec.ContentType, nil
+}
+
+// HTTPStatus implements the web package httpStatus interface.
+func (ir idempotentResponse) HTTPStatus() int {
+	return ir.rec.Status
+}
+
+// requestHash identifies a request by its method, path and body, so a retry
+// can be told apart from a different request sent with the same key.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.Path))
+	h.Write([]byte{0})
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
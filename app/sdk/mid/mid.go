@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/rmsj/service/app/sdk/auth"
+	"github.com/rmsj/service/business/domain/orderbus"
 	"github.com/rmsj/service/business/domain/productbus"
 	"github.com/rmsj/service/business/domain/userbus"
 	"github.com/rmsj/service/business/sdk/sqldb"
@@ -34,7 +35,9 @@ const (
 	userIDKey
 	userKey
 	productKey
+	orderKey
 	trKey
+	refreshTokenKey
 	timeKey ctxStringKey = "time"
 )
 
@@ -105,6 +108,20 @@ func GetProduct(ctx context.Context) (productbus.Product, error) {
 	return v, nil
 }
 
+func setOrder(ctx context.Context, ord orderbus.Order) context.Context {
+	return context.WithValue(ctx, orderKey, ord)
+}
+
+// GetOrder returns the order from the context.
+func GetOrder(ctx context.Context) (orderbus.Order, error) {
+	v, ok := ctx.Value(orderKey).(orderbus.Order)
+	if !ok {
+		return orderbus.Order{}, errors.New("order not found in context")
+	}
+
+	return v, nil
+}
+
 func setTran(ctx context.Context, tx sqldb.CommitRollbacker) context.Context {
 	return context.WithValue(ctx, trKey, tx)
 }
@@ -119,6 +136,21 @@ func GetTran(ctx context.Context) (sqldb.CommitRollbacker, error) {
 	return v, nil
 }
 
+func setRefreshToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, refreshTokenKey, token)
+}
+
+// GetRefreshToken returns the refresh token issued by the RefreshToken
+// middleware from the context.
+func GetRefreshToken(ctx context.Context) (string, error) {
+	v, ok := ctx.Value(refreshTokenKey).(string)
+	if !ok {
+		return "", errors.New("refresh token not found in context")
+	}
+
+	return v, nil
+}
+
 func SetTime(ctx context.Context, now time.Time) context.Context {
 	return context.WithValue(ctx, timeKey, now)
 }
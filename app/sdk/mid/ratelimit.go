@@ -0,0 +1,96 @@
+package mid
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/app/sdk/metrics"
+	"github.com/rmsj/service/business/sdk/ratelimit"
+	"github.com/rmsj/service/business/types/role"
+	"github.com/rmsj/service/foundation/web"
+)
+
+// RateLimit limits the rate of the requests made to the route group. Clients
+// are identified by the subject of the claims, then by their API key, and
+// then by their IP address, so it must come after the authentication
+// middleware to limit users instead of addresses. The quota of the group is
+// picked by the roles of the claims. The limits are reported in the
+// RateLimit headers and the requests over them are rejected with the time to
+// wait in the Retry-After header. Without a limiter, nothing is limited.
+func RateLimit(limiter *ratelimit.Limiter, group string) web.MidFunc {
+	m := func(next web.HandlerFunc) web.HandlerFunc {
+		h := func(ctx context.Context, r *http.Request) web.Encoder {
+			if limiter == nil {
+				return next(ctx, r)
+			}
+
+			res, err := limiter.Allow(ctx, group, rateLimitKey(ctx, r), claimedRoles(ctx))
+			if err != nil {
+				// The limiter logged the error. Requests aren't refused
+				// because the limits can't be checked.
+				return next(ctx, r)
+			}
+
+			if res.Quota.Limit > 0 {
+				setRateLimit(ctx, res)
+			}
+
+			if !res.Allowed {
+				metrics.AddRateLimited(ctx, group)
+				SetRetryAfter(ctx, res.RetryAfter)
+				return errs.Newf(errs.TooManyRequests, "rate limit of %s exceeded", res.Quota)
+			}
+
+			return next(ctx, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// =============================================================================
+
+// rateLimitKey identifies the client making the request.
+func rateLimitKey(ctx context.Context, r *http.Request) string {
+	if subjectID := GetSubjectID(ctx); subjectID != uuid.Nil {
+		return "user:" + subjectID.String()
+	}
+
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		sum := sha256.Sum256([]byte(key))
+		return "apikey:" + hex.EncodeToString(sum[:])
+	}
+
+	return "ip:" + web.RemoteIP(r)
+}
+
+// claimedRoles returns the known roles of the claims.
+func claimedRoles(ctx context.Context) []role.Role {
+	var roles []role.Role
+
+	for _, name := range GetClaims(ctx).Roles {
+		if r, err := role.Parse(name); err == nil {
+			roles = append(roles, r)
+		}
+	}
+
+	return roles
+}
+
+// setRateLimit reports the quota and what's left of it in the response.
+func setRateLimit(ctx context.Context, res ratelimit.Result) {
+	h := web.GetWriter(ctx).Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(res.Quota.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%s", res.Quota.Limit, ceilSeconds(res.Quota.Period)))
+}
@@ -5,17 +5,28 @@ package mux
 import (
 	"embed"
 	"net/http"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/markbates/goth"
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/rmsj/service/app/sdk/auth"
 	"github.com/rmsj/service/app/sdk/authclient"
 	"github.com/rmsj/service/app/sdk/mid"
+	"github.com/rmsj/service/business/domain/auditbus"
 	"github.com/rmsj/service/business/domain/authbus"
+	"github.com/rmsj/service/business/domain/idempotencybus"
+	"github.com/rmsj/service/business/domain/identitybus"
+	"github.com/rmsj/service/business/domain/orderbus"
 	"github.com/rmsj/service/business/domain/productbus"
 	"github.com/rmsj/service/business/domain/userbus"
 	"github.com/rmsj/service/business/domain/vproductbus"
+	"github.com/rmsj/service/business/domain/webhookbus"
+	"github.com/rmsj/service/business/sdk/notify"
+	"github.com/rmsj/service/business/sdk/ratelimit"
+	"github.com/rmsj/service/business/sdk/scheduler"
+	"github.com/rmsj/service/business/types/role"
 	"github.com/rmsj/service/foundation/logger"
 	"github.com/rmsj/service/foundation/web"
 )
@@ -55,19 +66,44 @@ func WithFileServer(react bool, static embed.FS, dir string, path string) func(o
 
 // SalesConfig contains sales service specific config.
 type SalesConfig struct {
-	AuthClient *authclient.Client
+	AuthClient     *authclient.Client
+	Scheduler      *scheduler.Scheduler
+	RateLimiter    *ratelimit.Limiter
+	IdempotencyTTL time.Duration
 }
 
 // AuthConfig contains auth service specific config.
 type AuthConfig struct {
-	Auth *auth.Auth
+	Auth       *auth.Auth
+	Notifier   *notify.Notifier
+	ResetURL   string
+	InviteURL  string
+	VerifyURL  string
+	RefreshTTL time.Duration
+	SessionTTL time.Duration
+	PublicURL  string
+	MFAIssuer  string
+	MFARoles   []role.Role
+
+	RateLimiter       *ratelimit.Limiter
+	AllowRegistration bool
+
+	OAuthProviders []goth.Provider
+	OAuthUIURL     string
+	OAuthProvision bool
+	OAuthTokenKey  string
 }
 
 type BusConfig struct {
-	UserBus     *userbus.Business
-	AuthBus     *authbus.Business
-	ProductBus  *productbus.Business
-	VProductBus *vproductbus.Business
+	AuditBus       *auditbus.Business
+	UserBus        *userbus.Business
+	AuthBus        *authbus.Business
+	IdempotencyBus *idempotencybus.Business
+	IdentityBus    *identitybus.Business
+	OrderBus       *orderbus.Business
+	ProductBus     *productbus.Business
+	VProductBus    *vproductbus.Business
+	WebhookBus     *webhookbus.Business
 }
 
 // Config contains all the mandatory systems required by handlers.
@@ -0,0 +1,427 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/sdk/filter"
+)
+
+// Set of limits on a filter, so a client can't make parsing it or running
+// the query it builds arbitrarily expensive.
+const (
+	maxLength     = 4096
+	maxTokens     = 500
+	maxConditions = 50
+	maxDepth      = 10
+	maxValues     = 100
+)
+
+// Field describes a field clients can filter on, which is the code of the
+// field in the business package and how its values are parsed.
+type Field struct {
+	Code  string
+	Parse func(value string) (any, error)
+}
+
+// Fields is the whitelist of the fields clients can filter on by name.
+type Fields map[string]Field
+
+// ParseFilter parses a filter expression on the fields. Conditions are a
+// field, an operator and its values:
+//
+//	name eq "Guitar"              eq, ne, gt, gte, lt, lte
+//	quantity in (1, 2, 3)
+//	name like "Gui*"              * matches anything
+//	created_at between 2024-01-01 and 2024-02-01
+//	department is null            is not null
+//
+// Conditions are joined with and, which binds tighter, and or, and can be
+// grouped with parentheses. Values with spaces or symbols are quoted. The
+// length of the filter, its conditions, nesting and values are limited.
+func ParseFilter(expr string, fields Fields) (filter.Expr, error) {
+	if strings.TrimSpace(expr) == "" {
+		return filter.Expr{}, nil
+	}
+
+	if len(expr) > maxLength {
+		return filter.Expr{}, fmt.Errorf("filter too long, must be at most %d characters", maxLength)
+	}
+
+	tokens, err := lex(expr)
+	if err != nil {
+		return filter.Expr{}, err
+	}
+
+	p := parser{
+		tokens: tokens,
+		fields: fields,
+	}
+
+	e, err := p.parseOr()
+	if err != nil {
+		return filter.Expr{}, err
+	}
+
+	if tok, ok := p.peek(); ok {
+		return filter.Expr{}, fmt.Errorf("unexpected %q", tok.text)
+	}
+
+	return e, nil
+}
+
+// =============================================================================
+// Value parsers for the fields.
+
+// Text parses a text value.
+func Text(value string) (any, error) {
+	return value, nil
+}
+
+// Int parses an integer value.
+func Int(value string) (any, error) {
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid integer %q", value)
+	}
+
+	return i, nil
+}
+
+// Decimal parses a decimal value, which is kept as text to be exact.
+func Decimal(value string) (any, error) {
+	if _, err := strconv.ParseFloat(value, 64); err != nil {
+		return nil, fmt.Errorf("invalid decimal %q", value)
+	}
+
+	return value, nil
+}
+
+// Bool parses a boolean value.
+func Bool(value string) (any, error) {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid boolean %q", value)
+	}
+
+	return b, nil
+}
+
+// UUID parses an ID value.
+func UUID(value string) (any, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id %q", value)
+	}
+
+	return id, nil
+}
+
+// Time parses a time in RFC3339 or a date, which is the start of the day in
+// UTC.
+func Time(value string) (any, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time %q", value)
+	}
+
+	return t, nil
+}
+
+// =============================================================================
+
+type token struct {
+	text   string
+	quoted bool
+}
+
+// is reports if the token is the keyword or symbol.
+func (t token) is(word string) bool {
+	return !t.quoted && strings.EqualFold(t.text, word)
+}
+
+func lex(expr string) ([]token, error) {
+	var tokens []token
+
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		if len(tokens) > maxTokens {
+			return nil, fmt.Errorf("filter too long, must be at most %d tokens", maxTokens)
+		}
+
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(' || r == ')' || r == ',':
+			tokens = append(tokens, token{text: string(r)})
+			i++
+
+		case r == '"' || r == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				b.WriteRune(runes[j])
+			}
+			if j == len(runes) {
+				return nil, errors.New("unterminated quoted value")
+			}
+			tokens = append(tokens, token{text: b.String(), quoted: true})
+			i = j + 1
+
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && !strings.ContainsRune(`(),"'`, runes[j]) {
+				j++
+			}
+			tokens = append(tokens, token{text: string(runes[i:j])})
+			i = j
+		}
+	}
+
+	return tokens, nil
+}
+
+type parser struct {
+	tokens     []token
+	pos        int
+	fields     Fields
+	conditions int
+	depth      int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) next() (token, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return token{}, errors.New("unexpected end of filter")
+	}
+
+	p.pos++
+
+	return tok, nil
+}
+
+func (p *parser) expect(word string) error {
+	tok, err := p.next()
+	if err != nil {
+		return fmt.Errorf("expected %q: %w", word, err)
+	}
+
+	if !tok.is(word) {
+		return fmt.Errorf("expected %q, got %q", word, tok.text)
+	}
+
+	return nil
+}
+
+// accept consumes the next token if it's the keyword or symbol.
+func (p *parser) accept(word string) bool {
+	if tok, ok := p.peek(); ok && tok.is(word) {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) parseOr() (filter.Expr, error) {
+	return p.parseJoin(filter.OR, "or", p.parseAnd)
+}
+
+func (p *parser) parseAnd() (filter.Expr, error) {
+	return p.parseJoin(filter.AND, "and", p.parseFactor)
+}
+
+func (p *parser) parseJoin(join string, word string, parse func() (filter.Expr, error)) (filter.Expr, error) {
+	e, err := parse()
+	if err != nil {
+		return filter.Expr{}, err
+	}
+
+	exprs := []filter.Expr{e}
+	for p.accept(word) {
+		e, err := parse()
+		if err != nil {
+			return filter.Expr{}, err
+		}
+
+		exprs = append(exprs, e)
+	}
+
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+
+	return filter.Expr{Join: join, Exprs: exprs}, nil
+}
+
+func (p *parser) parseFactor() (filter.Expr, error) {
+	if p.accept("(") {
+		p.depth++
+		if p.depth > maxDepth {
+			return filter.Expr{}, fmt.Errorf("too deeply nested, must be at most %d levels", maxDepth)
+		}
+
+		e, err := p.parseOr()
+		if err != nil {
+			return filter.Expr{}, err
+		}
+
+		if err := p.expect(")"); err != nil {
+			return filter.Expr{}, err
+		}
+
+		p.depth--
+
+		return e, nil
+	}
+
+	return p.parseCond()
+}
+
+func (p *parser) parseCond() (filter.Expr, error) {
+	p.conditions++
+	if p.conditions > maxConditions {
+		return filter.Expr{}, fmt.Errorf("too many conditions, must be at most %d", maxConditions)
+	}
+
+	tok, err := p.next()
+	if err != nil {
+		return filter.Expr{}, err
+	}
+
+	field, exists := p.fields[tok.text]
+	if tok.quoted || !exists {
+		return filter.Expr{}, fmt.Errorf("unknown field %q", tok.text)
+	}
+
+	opTok, err := p.next()
+	if err != nil {
+		return filter.Expr{}, err
+	}
+
+	op := strings.ToLower(opTok.text)
+	if opTok.quoted {
+		op = ""
+	}
+
+	switch op {
+	case filter.EQ, filter.NE, filter.GT, filter.GTE, filter.LT, filter.LTE:
+		v, err := p.parseValue(field)
+		if err != nil {
+			return filter.Expr{}, err
+		}
+
+		return filter.NewCond(field.Code, op, v), nil
+
+	case filter.LIKE:
+		tok, err := p.next()
+		if err != nil {
+			return filter.Expr{}, err
+		}
+
+		return filter.NewCond(field.Code, op, likePattern(tok.text)), nil
+
+	case filter.IN:
+		if err := p.expect("("); err != nil {
+			return filter.Expr{}, err
+		}
+
+		var values []any
+		for {
+			v, err := p.parseValue(field)
+			if err != nil {
+				return filter.Expr{}, err
+			}
+
+			values = append(values, v)
+			if len(values) > maxValues {
+				return filter.Expr{}, fmt.Errorf("too many values for %q, must be at most %d", tok.text, maxValues)
+			}
+
+			if !p.accept(",") {
+				break
+			}
+		}
+
+		if err := p.expect(")"); err != nil {
+			return filter.Expr{}, err
+		}
+
+		return filter.NewCond(field.Code, op, values...), nil
+
+	case filter.BETWEEN:
+		from, err := p.parseValue(field)
+		if err != nil {
+			return filter.Expr{}, err
+		}
+
+		if err := p.expect("and"); err != nil {
+			return filter.Expr{}, err
+		}
+
+		to, err := p.parseValue(field)
+		if err != nil {
+			return filter.Expr{}, err
+		}
+
+		return filter.NewCond(field.Code, op, from, to), nil
+
+	case "is":
+		op := filter.NULL
+		if p.accept("not") {
+			op = filter.NOTNULL
+		}
+
+		if err := p.expect("null"); err != nil {
+			return filter.Expr{}, err
+		}
+
+		return filter.NewCond(field.Code, op), nil
+	}
+
+	return filter.Expr{}, fmt.Errorf("unknown operator %q on field %q", opTok.text, tok.text)
+}
+
+func (p *parser) parseValue(field Field) (any, error) {
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	if !tok.quoted && (tok.text == "(" || tok.text == ")" || tok.text == ",") {
+		return nil, fmt.Errorf("expected a value, got %q", tok.text)
+	}
+
+	return field.Parse(tok.text)
+}
+
+// likePattern turns the value into a LIKE pattern, where * matches anything
+// and the wildcards of SQL match themselves.
+func likePattern(value string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`, `*`, `%`)
+	return r.Replace(value)
+}
@@ -0,0 +1,143 @@
+package query_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rmsj/service/app/sdk/query"
+	"github.com/rmsj/service/business/sdk/sqldb"
+)
+
+var fields = query.Fields{
+	"name":       {Code: "a", Parse: query.Text},
+	"cost":       {Code: "b", Parse: query.Decimal},
+	"quantity":   {Code: "c", Parse: query.Int},
+	"department": {Code: "d", Parse: query.Text},
+	"created_at": {Code: "e", Parse: query.Time},
+}
+
+var columns = map[string]string{
+	"a": "name",
+	"b": "cost",
+	"c": "quantity",
+	"d": "department",
+	"e": "created_at",
+}
+
+func Test_ParseFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		exp  string
+		data map[string]any
+	}{
+		{
+			name: "empty",
+			expr: " ",
+			exp:  "",
+			data: map[string]any{},
+		},
+		{
+			name: "condition",
+			expr: `name eq "Guitar Hero"`,
+			exp:  " WHERE name = :w1",
+			data: map[string]any{"w1": "Guitar Hero"},
+		},
+		{
+			name: "precedence",
+			expr: "cost gte 10.5 AND quantity lt 3 or department is null",
+			exp:  " WHERE ((cost >= :w1 AND quantity < :w2) OR department IS NULL)",
+			data: map[string]any{"w1": "10.5", "w2": 3},
+		},
+		{
+			name: "grouping",
+			expr: "quantity in (1, 2) and (name like 'Gui*' or department is not null)",
+			exp:  " WHERE (quantity IN (:w1, :w2) AND (name LIKE :w3 OR department IS NOT NULL))",
+			data: map[string]any{"w1": 1, "w2": 2, "w3": "Gui%"},
+		},
+		{
+			name: "range",
+			expr: "created_at between 2024-01-01 and 2024-02-01T10:00:00Z and name ne x",
+			exp:  " WHERE (created_at BETWEEN :w1 AND :w2 AND name <> :w3)",
+			data: map[string]any{
+				"w1": time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+				"w2": time.Date(2024, time.February, 1, 10, 0, 0, 0, time.UTC),
+				"w3": "x",
+			},
+		},
+		{
+			name: "like escapes",
+			expr: `name like "50%_off*"`,
+			exp:  " WHERE name LIKE :w1",
+			data: map[string]any{"w1": `50\%\_off%`},
+		},
+	}
+
+	for _, tt := range tests {
+		expr, err := query.ParseFilter(tt.expr, fields)
+		if err != nil {
+			t.Errorf("%s: Should be able to parse the filter: %s", tt.name, err)
+			continue
+		}
+
+		data := map[string]any{}
+		w := sqldb.NewWhere(data)
+		if err := w.AddExpr(expr, columns); err != nil {
+			t.Errorf("%s: Should be able to build the predicate: %s", tt.name, err)
+			continue
+		}
+
+		if got := w.String(); got != tt.exp {
+			t.Errorf("%s: Should get the clause %q, got %q", tt.name, tt.exp, got)
+		}
+
+		if len(data) != len(tt.data) {
+			t.Errorf("%s: Should bind %v, got %v", tt.name, tt.data, data)
+			continue
+		}
+
+		for k, v := range tt.data {
+			if data[k] != v {
+				t.Errorf("%s: Should bind %s to %v, got %v", tt.name, k, v, data[k])
+			}
+		}
+	}
+}
+
+func Test_ParseFilterNesting(t *testing.T) {
+	expr := strings.Repeat("(", 10) + "name eq x" + strings.Repeat(")", 10)
+
+	if _, err := query.ParseFilter(expr, fields); err != nil {
+		t.Errorf("Should be able to parse a filter nested 10 levels: %s", err)
+	}
+}
+
+func Test_ParseFilterErrors(t *testing.T) {
+	exprs := []string{
+		"password eq x",
+		"name",
+		"name is",
+		"name matches x",
+		"quantity eq many",
+		"quantity in ()",
+		"quantity between 1",
+		"(name eq x",
+		"name eq x)",
+		`name eq "x`,
+		"name eq x and",
+		`"name" eq x`,
+		strings.Repeat("(", 11) + "name eq x" + strings.Repeat(")", 11),
+		strings.Repeat("(", 100_000) + "name eq x" + strings.Repeat(")", 100_000),
+		strings.Repeat("name eq x and ", 50) + "name eq x",
+		strings.Repeat("quantity in (1"+strings.Repeat(", 1", 60)+") and ", 5) + "name eq x",
+		`name eq "` + strings.Repeat("x", 5000) + `"`,
+		"quantity in (1" + strings.Repeat(", 1", 100) + ")",
+	}
+
+	for _, expr := range exprs {
+		if _, err := query.ParseFilter(expr, fields); err == nil {
+			t.Errorf("Should NOT be able to parse the filter %q", expr)
+		}
+	}
+}
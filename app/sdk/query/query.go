@@ -3,30 +3,119 @@ package query
 
 import (
 	"encoding/json"
+	"errors"
 
+	"github.com/rmsj/service/business/sdk/order"
 	"github.com/rmsj/service/business/sdk/page"
 )
 
-// Result is the data model used when returning a query result.
+// Result is the data model used when returning a query result. The total is
+// only counted for numbered pages. The next and prev cursors are set when
+// there are pages after and before the items.
 type Result[T any] struct {
-	Items       []T `json:"items"`
-	Total       int `json:"total"`
-	Page        int `json:"page"`
-	RowsPerPage int `json:"rowsPerPage"`
+	Items       []T    `json:"items"`
+	Total       *int   `json:"total,omitempty"`
+	Page        int    `json:"page,omitempty"`
+	RowsPerPage int    `json:"rowsPerPage"`
+	Next        string `json:"next,omitempty"`
+	Prev        string `json:"prev,omitempty"`
 }
 
 // NewResult constructs a result value to return query results.
 func NewResult[T any](items []T, total int, page page.Page) Result[T] {
 	return Result[T]{
 		Items:       items,
-		Total:       total,
+		Total:       &total,
 		Page:        page.Number(),
 		RowsPerPage: page.RowsPerPage(),
 	}
 }
 
+// NewCursorResult constructs a result value to return the query results of a
+// page at a cursor, which are returned without counting the total.
+func NewCursorResult[T any](items []T, page page.Page) Result[T] {
+	return Result[T]{
+		Items:       items,
+		RowsPerPage: page.RowsPerPage(),
+	}
+}
+
+// WithCursors returns the result with the cursors of the pages before and
+// after the items.
+func (r Result[T]) WithCursors(prev string, next string) Result[T] {
+	r.Prev = prev
+	r.Next = next
+	return r
+}
+
 // Encode implements the encoder interface.
 func (r Result[T]) Encode() ([]byte, string, error) {
 	data, err := json.Marshal(r)
 	return data, "application/json", err
 }
+
+// =============================================================================
+
+// ParsePage parses the requested page, which is either numbered or at a
+// cursor but not both.
+func ParsePage(number string, rowsPerPage string, cursor string) (page.Page, error) {
+	if cursor == "" {
+		return page.Parse(number, rowsPerPage)
+	}
+
+	if number != "" {
+		return page.Page{}, errors.New("page and cursor can't be used together")
+	}
+
+	return page.ParseCursor(cursor, rowsPerPage)
+}
+
+// Cursors returns the cursors of the pages before and after the items, which
+// were read from the page in the specified order. The key function returns
+// the value of the order field and the ID of an item. A full page always has
+// a next cursor, so the last page can be empty.
+func Cursors[T any](items []T, pg page.Page, orderBy order.By, key func(item T, field string) (any, string)) (prev string, next string) {
+	if len(items) == 0 {
+		return "", ""
+	}
+
+	cursorAt := func(item T, backward bool) string {
+		value, id := key(item, orderBy.Field)
+
+		c := page.Cursor{
+			OrderBy:  orderBy,
+			Value:    value,
+			ID:       id,
+			Backward: backward,
+		}
+
+		return c.String()
+	}
+
+	full := len(items) == pg.RowsPerPage()
+
+	cursor, ok := pg.Cursor()
+	switch {
+	case !ok:
+		if pg.Number() > 1 {
+			prev = cursorAt(items[0], true)
+		}
+		if full {
+			next = cursorAt(items[len(items)-1], false)
+		}
+
+	case cursor.Backward:
+		if full {
+			prev = cursorAt(items[0], true)
+		}
+		next = cursorAt(items[len(items)-1], false)
+
+	default:
+		prev = cursorAt(items[0], true)
+		if full {
+			next = cursorAt(items[len(items)-1], false)
+		}
+	}
+
+	return prev, next
+}
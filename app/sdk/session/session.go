@@ -0,0 +1,148 @@
+// Package session provides support for starting the session of a user that
+// logged in, shared by the apis a user can log in with.
+package session
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/app/sdk/auth"
+	"github.com/rmsj/service/app/sdk/errs"
+	"github.com/rmsj/service/app/sdk/mid"
+	"github.com/rmsj/service/business/domain/auditbus"
+	"github.com/rmsj/service/business/domain/authbus"
+	"github.com/rmsj/service/business/types/role"
+	"github.com/rmsj/service/foundation/web"
+)
+
+// mfaPendingTTL is how long a user has to provide the second factor after
+// logging in.
+const mfaPendingTTL = 5 * time.Minute
+
+// auditDomain is the domain the logins are audited under.
+const auditDomain = "auth"
+
+// Config represents the settings and the apis the logins are completed with.
+type Config struct {
+	Auth       *auth.Auth
+	AuthBus    *authbus.Business
+	AuditBus   *auditbus.Business
+	RefreshTTL time.Duration
+	SessionTTL time.Duration
+	MFARoles   []role.Role
+}
+
+// Starter completes the logins of the users, starting a session or asking
+// for a second factor.
+type Starter struct {
+	auth       *auth.Auth
+	authBus    *authbus.Business
+	auditBus   *auditbus.Business
+	refreshTTL time.Duration
+	sessionTTL time.Duration
+	mfaRoles   []role.Role
+}
+
+// New constructs a Starter for use.
+func New(cfg Config) *Starter {
+	return &Starter{
+		auth:       cfg.Auth,
+		authBus:    cfg.AuthBus,
+		auditBus:   cfg.AuditBus,
+		refreshTTL: cfg.RefreshTTL,
+		sessionTTL: cfg.SessionTTL,
+		mfaRoles:   cfg.MFARoles,
+	}
+}
+
+// Tokens are the tokens issued when a session starts.
+type Tokens struct {
+	Token        string
+	RefreshToken string
+}
+
+// Challenge is the mfa_pending token issued when the user must provide a
+// second factor, and if they must enroll one first.
+type Challenge struct {
+	MFAToken           string
+	EnrollmentRequired bool
+}
+
+// Start starts a session for the user and issues the access token,
+// bound to the session, and the first refresh token of the session.
+func (s *Starter) Start(ctx context.Context, r *http.Request, kid string, userID uuid.UUID, claims auth.Claims) (Tokens, *errs.Error) {
+	// every login starts a new session, with its own family of refresh tokens
+	ns := authbus.NewSession{
+		UserID:    userID,
+		UserAgent: r.UserAgent(),
+		IPAddress: web.RemoteIP(r),
+		TTL:       s.refreshTTL,
+		MaxTTL:    s.sessionTTL,
+	}
+
+	sess, refreshToken, err := s.authBus.CreateSession(ctx, ns)
+	if err != nil {
+		return Tokens{}, errs.Newf(errs.Internal, "create session: userID[%s]: %s", userID, err)
+	}
+
+	claims.SessionID = sess.ID.String()
+
+	na := mid.NewAudit(ctx, r, auditDomain, "login", userID.String(), nil, nil)
+	na.ActorID = userID
+
+	if _, err := s.auditBus.Create(ctx, na); err != nil {
+		return Tokens{}, errs.Newf(errs.Internal, "audit: action[login] entityID[%s]: %s", userID, err)
+	}
+
+	tkn, err := s.auth.GenerateToken(kid, claims)
+	if err != nil {
+		return Tokens{}, errs.New(errs.Internal, err)
+	}
+
+	return Tokens{Token: tkn, RefreshToken: refreshToken}, nil
+}
+
+// MFAChallenge issues the mfa_pending token for the user in the claims. It
+// carries no roles, so it can't be used for anything but the MFA endpoints.
+func (s *Starter) MFAChallenge(ctx context.Context, kid string, claims auth.Claims, enrollmentRequired bool) (Challenge, *errs.Error) {
+	now := mid.GetTime(ctx)
+
+	pending := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   claims.Subject,
+			Issuer:    claims.Issuer,
+			ExpiresAt: jwt.NewNumericDate(now.UTC().Add(mfaPendingTTL)),
+			IssuedAt:  jwt.NewNumericDate(now.UTC()),
+		},
+		Roles:   []string{},
+		Purpose: auth.PurposeMFAPending,
+	}
+
+	tkn, err := s.auth.GenerateToken(kid, pending)
+	if err != nil {
+		return Challenge{}, errs.New(errs.Internal, err)
+	}
+
+	return Challenge{MFAToken: tkn, EnrollmentRequired: enrollmentRequired}, nil
+}
+
+// MFARequired reports if one of the roles, as carried by the claims,
+// requires MFA.
+func (s *Starter) MFARequired(roles []string) bool {
+	usrRoles, err := role.ParseMany(roles)
+	if err != nil {
+		return false
+	}
+
+	for _, r := range s.mfaRoles {
+		if role.HasRole(usrRoles, r) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,109 @@
+// Package auditbus provides business access to audit domain.
+package auditbus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/sdk/ctxval"
+	"github.com/rmsj/service/business/sdk/order"
+	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/foundation/logger"
+	"github.com/rmsj/service/foundation/otel"
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	NewWithTx(tx sqldb.CommitRollbacker) (Storer, error)
+	Create(ctx context.Context, adt Audit) error
+	Query(ctx context.Context, filter QueryFilter, orderBy order.By, page page.Page) ([]Audit, error)
+	Count(ctx context.Context, filter QueryFilter) (int, error)
+}
+
+// Business manages the set of APIs for audit access.
+type Business struct {
+	log    *logger.Logger
+	storer Storer
+}
+
+// NewBusiness constructs an audit business API for use.
+func NewBusiness(log *logger.Logger, storer Storer) *Business {
+	return &Business{
+		log:    log,
+		storer: storer,
+	}
+}
+
+// NewWithTx constructs a new business value that will use the
+// specified transaction in any store related calls. Audits are written in
+// the transaction of the change they record, so they are never kept for a
+// change that was rolled back.
+func (b *Business) NewWithTx(tx sqldb.CommitRollbacker) (*Business, error) {
+	storer, err := b.storer.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	bus := Business{
+		log:    b.log,
+		storer: storer,
+	}
+
+	return &bus, nil
+}
+
+// Create records a change.
+func (b *Business) Create(ctx context.Context, na NewAudit) (Audit, error) {
+	ctx, span := otel.AddSpan(ctx, "business.auditbus.create")
+	defer span.End()
+
+	d, err := diff(na.Before, na.After)
+	if err != nil {
+		return Audit{}, fmt.Errorf("diff: %w", err)
+	}
+
+	adt := Audit{
+		ID:        uuid.New(),
+		ActorID:   na.ActorID,
+		Action:    na.Action,
+		Domain:    na.Domain,
+		EntityID:  na.EntityID,
+		Diff:      d,
+		RequestID: na.RequestID,
+		TraceID:   na.TraceID,
+		IPAddress: na.IPAddress,
+		Timestamp: ctxval.GetTime(ctx),
+	}
+
+	if err := b.storer.Create(ctx, adt); err != nil {
+		b.log.Error(ctx, "business.auditbus.create", "error", err)
+		return Audit{}, fmt.Errorf("create: %w", err)
+	}
+
+	return adt, nil
+}
+
+// Query retrieves a list of existing audits.
+func (b *Business) Query(ctx context.Context, filter QueryFilter, orderBy order.By, page page.Page) ([]Audit, error) {
+	ctx, span := otel.AddSpan(ctx, "business.auditbus.query")
+	defer span.End()
+
+	adts, err := b.storer.Query(ctx, filter, orderBy, page)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return adts, nil
+}
+
+// Count returns the total number of audits.
+func (b *Business) Count(ctx context.Context, filter QueryFilter) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.auditbus.count")
+	defer span.End()
+
+	return b.storer.Count(ctx, filter)
+}
@@ -0,0 +1,219 @@
+package auditbus_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rmsj/service/business/domain/auditbus"
+	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/sdk/dbtest"
+	"github.com/rmsj/service/business/sdk/order"
+	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/business/sdk/unitest"
+	"github.com/rmsj/service/business/types/role"
+)
+
+func Test_Audit(t *testing.T) {
+	t.Parallel()
+
+	db := dbtest.New(t, "Test_Audit")
+
+	sd, err := insertSeedData(db.BusDomain)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	unitest.Run(t, create(db.BusDomain, sd), "create")
+	unitest.Run(t, query(db.BusDomain, sd), "query")
+}
+
+// =============================================================================
+
+func insertSeedData(busDomain dbtest.BusDomain) (unitest.SeedData, error) {
+	ctx := context.Background()
+
+	usrs, err := userbus.TestSeedUsers(ctx, 1, role.Admin, busDomain.User)
+	if err != nil {
+		return unitest.SeedData{}, fmt.Errorf("seeding users : %w", err)
+	}
+
+	sd := unitest.SeedData{
+		Admins: []unitest.User{
+			{User: usrs[0]},
+		},
+	}
+
+	return sd, nil
+}
+
+// =============================================================================
+
+type entity struct {
+	Name  string `json:"name"`
+	Roles string `json:"roles"`
+}
+
+func create(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
+	table := []unitest.Table{
+		{
+			Name: "update",
+			ExpResp: auditbus.Audit{
+				ActorID:   sd.Admins[0].ID,
+				Action:    "update_role",
+				Domain:    "user",
+				EntityID:  "1234",
+				Diff:      []byte(`{"roles":{"before":"USER","after":"ADMIN"}}`),
+				RequestID: "req-1",
+				IPAddress: "10.0.0.1",
+			},
+			ExcFunc: func(ctx context.Context) any {
+				na := auditbus.NewAudit{
+					ActorID:   sd.Admins[0].ID,
+					Action:    "update_role",
+					Domain:    "user",
+					EntityID:  "1234",
+					Before:    entity{Name: "Bill", Roles: "USER"},
+					After:     entity{Name: "Bill", Roles: "ADMIN"},
+					RequestID: "req-1",
+					IPAddress: "10.0.0.1",
+				}
+
+				resp, err := busDomain.Audit.Create(ctx, na)
+				if err != nil {
+					return err
+				}
+
+				return resp
+			},
+			CmpFunc: func(got any, exp any) string {
+				gotResp, exists := got.(auditbus.Audit)
+				if !exists {
+					return "error occurred"
+				}
+
+				expResp := exp.(auditbus.Audit)
+
+				expResp.ID = gotResp.ID
+				expResp.Timestamp = gotResp.Timestamp
+
+				return cmp.Diff(gotResp, expResp)
+			},
+		},
+		{
+			Name: "delete",
+			ExpResp: auditbus.Audit{
+				Action:   "delete",
+				Domain:   "product",
+				EntityID: "5678",
+				Diff:     []byte(`{"name":{"before":"Toy"},"roles":{"before":""}}`),
+			},
+			ExcFunc: func(ctx context.Context) any {
+				na := auditbus.NewAudit{
+					Action:   "delete",
+					Domain:   "product",
+					EntityID: "5678",
+					Before:   entity{Name: "Toy"},
+				}
+
+				resp, err := busDomain.Audit.Create(ctx, na)
+				if err != nil {
+					return err
+				}
+
+				return resp
+			},
+			CmpFunc: func(got any, exp any) string {
+				gotResp, exists := got.(auditbus.Audit)
+				if !exists {
+					return "error occurred"
+				}
+
+				expResp := exp.(auditbus.Audit)
+
+				expResp.ID = gotResp.ID
+				expResp.Timestamp = gotResp.Timestamp
+
+				return cmp.Diff(gotResp, expResp)
+			},
+		},
+	}
+
+	return table
+}
+
+func query(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
+	table := []unitest.Table{
+		{
+			Name:    "byactor",
+			ExpResp: []string{"update_role"},
+			ExcFunc: func(ctx context.Context) any {
+				filter := auditbus.QueryFilter{
+					ActorID: &sd.Admins[0].ID,
+				}
+
+				resp, err := busDomain.Audit.Query(ctx, filter, auditbus.DefaultOrderBy, page.MustParse("1", "10"))
+				if err != nil {
+					return err
+				}
+
+				actions := make([]string, len(resp))
+				for i, aud := range resp {
+					actions[i] = aud.Action
+				}
+
+				return actions
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:    "bydomain",
+			ExpResp: 1,
+			ExcFunc: func(ctx context.Context) any {
+				filter := auditbus.QueryFilter{
+					Domain:   dbtest.StringPointer("product"),
+					EntityID: dbtest.StringPointer("5678"),
+				}
+
+				resp, err := busDomain.Audit.Count(ctx, filter)
+				if err != nil {
+					return err
+				}
+
+				return resp
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:    "ordered",
+			ExpResp: []string{"delete", "update_role"},
+			ExcFunc: func(ctx context.Context) any {
+				orderBy := order.NewBy(auditbus.OrderByAction, order.ASC)
+
+				resp, err := busDomain.Audit.Query(ctx, auditbus.QueryFilter{}, orderBy, page.MustParse("1", "10"))
+				if err != nil {
+					return err
+				}
+
+				actions := make([]string, len(resp))
+				for i, aud := range resp {
+					actions[i] = aud.Action
+				}
+
+				return actions
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
@@ -0,0 +1,68 @@
+package auditbus
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// change represents the values of a field before and after a change.
+type change struct {
+	Before any `json:"before,omitempty"`
+	After  any `json:"after,omitempty"`
+}
+
+// diff compares the JSON encoding of the two values and returns the fields
+// that changed. A nil value has no fields, so every field of the other one
+// is reported.
+func diff(before any, after any) ([]byte, error) {
+	b, err := toFields(before)
+	if err != nil {
+		return nil, fmt.Errorf("before: %w", err)
+	}
+
+	a, err := toFields(after)
+	if err != nil {
+		return nil, fmt.Errorf("after: %w", err)
+	}
+
+	changes := make(map[string]change)
+
+	for field, bv := range b {
+		av, exists := a[field]
+		if !exists || !reflect.DeepEqual(bv, av) {
+			changes[field] = change{Before: bv, After: av}
+		}
+	}
+
+	for field, av := range a {
+		if _, exists := b[field]; !exists {
+			changes[field] = change{After: av}
+		}
+	}
+
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+
+	return data, nil
+}
+
+func toFields(v any) (map[string]any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("value must encode as a JSON object: %w", err)
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,33 @@
+package auditbus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/sdk/filter"
+)
+
+// Set of fields that the filter expressions can use.
+const (
+	FilterByActorID   = "a"
+	FilterByAction    = "b"
+	FilterByDomain    = "c"
+	FilterByEntityID  = "d"
+	FilterByRequestID = "e"
+	FilterByTimestamp = "f"
+)
+
+// QueryFilter holds the available fields a query can be filtered on.
+// We are using pointer semantics because the With API mutates the value.
+// The expression is on the FilterBy fields.
+type QueryFilter struct {
+	ActorID   *uuid.UUID
+	Action    *string
+	Domain    *string
+	EntityID  *string
+	RequestID *string
+	StartDate *time.Time
+	EndDate   *time.Time
+	Expr      filter.Expr
+}
@@ -0,0 +1,37 @@
+package auditbus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit represents a change made to the system, who made it and from where.
+type Audit struct {
+	ID        uuid.UUID
+	ActorID   uuid.UUID
+	Action    string
+	Domain    string
+	EntityID  string
+	Diff      []byte
+	RequestID string
+	TraceID   string
+	IPAddress string
+	Timestamp time.Time
+}
+
+// NewAudit contains information needed to record a change. Before and After
+// hold the entity before and after the change, and are compared as JSON to
+// build the diff. Either can be nil when the entity is created or deleted.
+// They must not hold secrets, since they are stored as they are encoded.
+type NewAudit struct {
+	ActorID   uuid.UUID
+	Action    string
+	Domain    string
+	EntityID  string
+	Before    any
+	After     any
+	RequestID string
+	TraceID   string
+	IPAddress string
+}
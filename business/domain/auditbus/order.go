@@ -0,0 +1,16 @@
+package auditbus
+
+import "github.com/rmsj/service/business/sdk/order"
+
+// DefaultOrderBy represents the default way we sort, with the most recent
+// first.
+var DefaultOrderBy = order.NewBy(OrderByTimestamp, order.DESC)
+
+// Set of fields that the results can be ordered by.
+const (
+	OrderByTimestamp = "a"
+	OrderByActorID   = "b"
+	OrderByAction    = "c"
+	OrderByDomain    = "d"
+	OrderByEntityID  = "e"
+)
@@ -0,0 +1,57 @@
+package auditdb
+
+import (
+	"bytes"
+
+	"github.com/rmsj/service/business/domain/auditbus"
+	"github.com/rmsj/service/business/sdk/sqldb"
+)
+
+var filterByFields = map[string]string{
+	auditbus.FilterByActorID:   "actor_id",
+	auditbus.FilterByAction:    "action",
+	auditbus.FilterByDomain:    "domain",
+	auditbus.FilterByEntityID:  "entity_id",
+	auditbus.FilterByRequestID: "request_id",
+	auditbus.FilterByTimestamp: "timestamp",
+}
+
+func applyFilter(filter auditbus.QueryFilter, data map[string]any, buf *bytes.Buffer) error {
+	w := sqldb.NewWhere(data)
+
+	if filter.ActorID != nil {
+		w.Add("actor_id = " + w.Bind(filter.ActorID))
+	}
+
+	if filter.Action != nil {
+		w.Add("action = " + w.Bind(*filter.Action))
+	}
+
+	if filter.Domain != nil {
+		w.Add("domain = " + w.Bind(*filter.Domain))
+	}
+
+	if filter.EntityID != nil {
+		w.Add("entity_id = " + w.Bind(*filter.EntityID))
+	}
+
+	if filter.RequestID != nil {
+		w.Add("request_id = " + w.Bind(*filter.RequestID))
+	}
+
+	if filter.StartDate != nil {
+		w.Add("timestamp >= " + w.Bind(*filter.StartDate))
+	}
+
+	if filter.EndDate != nil {
+		w.Add("timestamp <= " + w.Bind(*filter.EndDate))
+	}
+
+	if err := w.AddExpr(filter.Expr, filterByFields); err != nil {
+		return err
+	}
+
+	buf.WriteString(w.String())
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package auditdb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/domain/auditbus"
+)
+
+type audit struct {
+	ID        uuid.UUID      `db:"audit_id"`
+	ActorID   sql.NullString `db:"actor_id"`
+	Action    string         `db:"action"`
+	Domain    string         `db:"domain"`
+	EntityID  string         `db:"entity_id"`
+	Diff      string         `db:"diff"`
+	RequestID string         `db:"request_id"`
+	TraceID   string         `db:"trace_id"`
+	IPAddress string         `db:"ip_address"`
+	Timestamp time.Time      `db:"timestamp"`
+}
+
+func toDBAudit(bus auditbus.Audit) audit {
+	var actorID sql.NullString
+	if bus.ActorID != uuid.Nil {
+		actorID = sql.NullString{String: bus.ActorID.String(), Valid: true}
+	}
+
+	return audit{
+		ID:        bus.ID,
+		ActorID:   actorID,
+		Action:    bus.Action,
+		Domain:    bus.Domain,
+		EntityID:  bus.EntityID,
+		Diff:      string(bus.Diff),
+		RequestID: bus.RequestID,
+		TraceID:   bus.TraceID,
+		IPAddress: bus.IPAddress,
+		Timestamp: bus.Timestamp.UTC(),
+	}
+}
+
+func toBusAudit(db audit) auditbus.Audit {
+	var actorID uuid.UUID
+	if db.ActorID.Valid {
+		actorID, _ = uuid.Parse(db.ActorID.String)
+	}
+
+	return auditbus.Audit{
+		ID:        db.ID,
+		ActorID:   actorID,
+		Action:    db.Action,
+		Domain:    db.Domain,
+		EntityID:  db.EntityID,
+		Diff:      []byte(db.Diff),
+		RequestID: db.RequestID,
+		TraceID:   db.TraceID,
+		IPAddress: db.IPAddress,
+		Timestamp: db.Timestamp.In(time.Local),
+	}
+}
+
+func toBusAudits(dbAdts []audit) []auditbus.Audit {
+	bus := make([]auditbus.Audit, len(dbAdts))
+	for i, dbAdt := range dbAdts {
+		bus[i] = toBusAudit(dbAdt)
+	}
+
+	return bus
+}
@@ -0,0 +1,25 @@
+package auditdb
+
+import (
+	"fmt"
+
+	"github.com/rmsj/service/business/domain/auditbus"
+	"github.com/rmsj/service/business/sdk/order"
+)
+
+var orderByFields = map[string]string{
+	auditbus.OrderByTimestamp: "timestamp",
+	auditbus.OrderByActorID:   "actor_id",
+	auditbus.OrderByAction:    "action",
+	auditbus.OrderByDomain:    "domain",
+	auditbus.OrderByEntityID:  "entity_id",
+}
+
+func orderByClause(orderBy order.By) (string, error) {
+	by, exists := orderByFields[orderBy.Field]
+	if !exists {
+		return "", fmt.Errorf("field %q does not exist", orderBy.Field)
+	}
+
+	return " ORDER BY " + by + " " + orderBy.Direction, nil
+}
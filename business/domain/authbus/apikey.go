@@ -0,0 +1,165 @@
+package authbus
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/sdk/ctxval"
+	"github.com/rmsj/service/business/sdk/id"
+	"github.com/rmsj/service/foundation/otel"
+)
+
+// Set of error variables for API keys.
+var (
+	ErrAPIKeyInvalid = errors.New("api key invalid")
+	ErrAPIKeyExpired = errors.New("api key expired")
+	ErrAPIKeyRevoked = errors.New("api key revoked")
+)
+
+const (
+	// apiKeyPrefix starts every key, so keys are easy to spot in logs and
+	// by secret scanners.
+	apiKeyPrefix = "sk"
+
+	// apiKeyPrefixBytes is the size of the random prefix a key is looked up
+	// by. It's big enough that two keys never share one in practice.
+	apiKeyPrefixBytes = 8
+
+	// apiKeyTouchInterval is how often the last use of a key is recorded, so
+	// a busy key doesn't cost a write on every request.
+	apiKeyTouchInterval = time.Minute
+)
+
+// CreateAPIKey issues a new API key for the user. The key is returned along
+// with it, as only its hash is stored. It's shown once, and can't be
+// recovered afterwards.
+func (b *Business) CreateAPIKey(ctx context.Context, nk NewAPIKey) (APIKey, string, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.createapikey")
+	defer span.End()
+
+	prefix := make([]byte, apiKeyPrefixBytes)
+	if _, err := rand.Read(prefix); err != nil {
+		return APIKey{}, "", fmt.Errorf("generate: %w", err)
+	}
+
+	secret, err := id.NewRandomString(48)
+	if err != nil {
+		return APIKey{}, "", fmt.Errorf("generate: %w", err)
+	}
+
+	now := ctxval.GetTime(ctx)
+
+	key := APIKey{
+		ID:          uuid.New(),
+		UserID:      nk.UserID,
+		Name:        nk.Name,
+		Prefix:      hex.EncodeToString(prefix),
+		Scopes:      nk.Scopes,
+		ExpiresAt:   nk.ExpiresAt,
+		DateCreated: now,
+	}
+
+	token := fmt.Sprintf("%s_%s_%s", apiKeyPrefix, key.Prefix, secret)
+	key.KeyHash = hashToken(token)
+
+	if err := b.storer.CreateAPIKey(ctx, key); err != nil {
+		b.log.Error(ctx, "business.authbus.createapikey", "error", err)
+		return APIKey{}, "", fmt.Errorf("createAPIKey: userID[%s]: %w", nk.UserID, err)
+	}
+
+	return key, token, nil
+}
+
+// AuthenticateAPIKey finds the API key presented by a client, checking it's
+// still valid, and records its use.
+func (b *Business) AuthenticateAPIKey(ctx context.Context, token string) (APIKey, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.authenticateapikey")
+	defer span.End()
+
+	parts := strings.SplitN(token, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyPrefix {
+		return APIKey{}, ErrAPIKeyInvalid
+	}
+
+	key, err := b.storer.QueryAPIKeyByPrefix(ctx, parts[1])
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return APIKey{}, ErrAPIKeyInvalid
+		}
+		return APIKey{}, fmt.Errorf("query: prefix[%s]: %w", parts[1], err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(hashToken(token))) != 1 {
+		return APIKey{}, ErrAPIKeyInvalid
+	}
+
+	now := ctxval.GetTime(ctx)
+
+	switch {
+	case !key.RevokedAt.IsZero():
+		return APIKey{}, fmt.Errorf("authenticate: apiKeyID[%s]: %w", key.ID, ErrAPIKeyRevoked)
+
+	case !key.ExpiresAt.IsZero() && !key.ExpiresAt.After(now):
+		return APIKey{}, fmt.Errorf("authenticate: apiKeyID[%s]: %w", key.ID, ErrAPIKeyExpired)
+	}
+
+	if now.Sub(key.LastUsedAt) >= apiKeyTouchInterval {
+		key.LastUsedAt = now
+
+		if err := b.storer.TouchAPIKey(ctx, key.ID, now); err != nil {
+			b.log.Error(ctx, "business.authbus.authenticateapikey", "error", err)
+		}
+	}
+
+	return key, nil
+}
+
+// QueryAPIKeyByID finds the API key by the specified ID.
+func (b *Business) QueryAPIKeyByID(ctx context.Context, apiKeyID uuid.UUID) (APIKey, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.queryapikeybyid")
+	defer span.End()
+
+	key, err := b.storer.QueryAPIKeyByID(ctx, apiKeyID)
+	if err != nil {
+		return APIKey{}, fmt.Errorf("query: apiKeyID[%s]: %w", apiKeyID, err)
+	}
+
+	return key, nil
+}
+
+// QueryAPIKeys retrieves the API keys of the user that were not revoked, the
+// most recently created first. Expired keys are included, so their owner
+// knows to replace them.
+func (b *Business) QueryAPIKeys(ctx context.Context, userID uuid.UUID) ([]APIKey, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.queryapikeys")
+	defer span.End()
+
+	keys, err := b.storer.QueryAPIKeysByUserID(ctx, userID)
+	if err != nil {
+		b.log.Error(ctx, "business.authbus.queryapikeys", "error", err)
+		return nil, fmt.Errorf("query: userID[%s]: %w", userID, err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey revokes the API key, it's no longer accepted.
+func (b *Business) RevokeAPIKey(ctx context.Context, key APIKey) error {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.revokeapikey")
+	defer span.End()
+
+	if err := b.storer.RevokeAPIKey(ctx, key.ID, ctxval.GetTime(ctx)); err != nil {
+		b.log.Error(ctx, "business.authbus.revokeapikey", "error", err)
+		return fmt.Errorf("revokeAPIKey: apiKeyID[%s]: %w", key.ID, err)
+	}
+
+	return nil
+}
@@ -3,10 +3,15 @@ package authbus
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/domain/auditbus"
 	"github.com/rmsj/service/business/sdk/ctxval"
 	"github.com/rmsj/service/business/sdk/id"
 	"github.com/rmsj/service/business/sdk/sqldb"
@@ -18,6 +23,10 @@ import (
 var (
 	ErrNotFound      = errors.New("key not found")
 	ErrEmailRequired = errors.New("email required to createPasswordReset token")
+	ErrTokenExpired  = errors.New("refresh token expired")
+	ErrTokenRevoked  = errors.New("refresh token revoked")
+	ErrTokenReused   = errors.New("refresh token reused")
+	ErrSessionEnded  = errors.New("session ended")
 )
 
 // Storer interface declares the behavior this package needs to persist and
@@ -28,19 +37,79 @@ type Storer interface {
 	DeletePasswordReset(ctx context.Context, token PasswordResetToken) error
 	QueryPasswordResetByEmail(ctx context.Context, email string) (PasswordResetToken, error)
 	QueryPasswordResetByToken(ctx context.Context, token string) (PasswordResetToken, error)
+	DeleteExpiredPasswordResets(ctx context.Context, now time.Time) (int, error)
+	CreateRefreshToken(ctx context.Context, token RefreshToken) error
+	RotateRefreshToken(ctx context.Context, token RefreshToken) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID, now time.Time) error
+	RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID, now time.Time) error
+	QueryRefreshTokenByHash(ctx context.Context, tokenHash string) (RefreshToken, error)
+	DeleteExpiredRefreshTokens(ctx context.Context, now time.Time) (int, error)
+	CreateSession(ctx context.Context, sess Session) error
+	UpdateSession(ctx context.Context, sess Session) error
+	RevokeSession(ctx context.Context, sessionID uuid.UUID, now time.Time) error
+	RevokeUserSessions(ctx context.Context, userID uuid.UUID, now time.Time) error
+	QuerySessionByID(ctx context.Context, sessionID uuid.UUID) (Session, error)
+	QuerySessionsByUserID(ctx context.Context, userID uuid.UUID, now time.Time) ([]Session, error)
+	DeleteExpiredSessions(ctx context.Context, now time.Time) (int, error)
+	SaveMFA(ctx context.Context, mfa MFA) error
+	EnableMFA(ctx context.Context, mfa MFA) (bool, error)
+	UseMFAStep(ctx context.Context, userID uuid.UUID, step int64, now time.Time) (bool, error)
+	DeleteMFA(ctx context.Context, userID uuid.UUID) error
+	QueryMFAByUserID(ctx context.Context, userID uuid.UUID) (MFA, error)
+	CreateRecoveryCode(ctx context.Context, code RecoveryCode) error
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, now time.Time) (bool, error)
+	DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error
+	CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error)
+	AddLoginFailure(ctx context.Context, la LoginAttempts, windowStart time.Time) (LoginAttempts, error)
+	BlockLogin(ctx context.Context, scope string, key string, until time.Time) error
+	QueryLoginAttempts(ctx context.Context, scope string, key string) (LoginAttempts, error)
+	DeleteLoginAttempts(ctx context.Context, scope string, key string) error
+	DeleteExpiredLoginAttempts(ctx context.Context, before time.Time) (int, error)
+	CreateAPIKey(ctx context.Context, key APIKey) error
+	TouchAPIKey(ctx context.Context, apiKeyID uuid.UUID, now time.Time) error
+	RevokeAPIKey(ctx context.Context, apiKeyID uuid.UUID, now time.Time) error
+	QueryAPIKeyByID(ctx context.Context, apiKeyID uuid.UUID) (APIKey, error)
+	QueryAPIKeyByPrefix(ctx context.Context, prefix string) (APIKey, error)
+	QueryAPIKeysByUserID(ctx context.Context, userID uuid.UUID) ([]APIKey, error)
+	CreateUserToken(ctx context.Context, ut UserToken) error
+	UseUserToken(ctx context.Context, tokenID uuid.UUID, now time.Time) (bool, error)
+	RevokeUserToken(ctx context.Context, tokenID uuid.UUID, now time.Time) error
+	RevokePendingUserTokens(ctx context.Context, purpose string, email string, now time.Time) error
+	QueryUserTokenByID(ctx context.Context, tokenID uuid.UUID) (UserToken, error)
+	QueryUserTokenByHash(ctx context.Context, tokenHash string) (UserToken, error)
+	QueryPendingUserTokens(ctx context.Context, purpose string, now time.Time) ([]UserToken, error)
+	DeleteExpiredUserTokens(ctx context.Context, before time.Time) (int, error)
 }
 
 // Business manages the set of APIs for key access.mi
 type Business struct {
-	log    *logger.Logger
-	storer Storer
+	log      *logger.Logger
+	auditBus *auditbus.Business
+	storer   Storer
+	lockout  LockoutConfig
+}
+
+// NewBusiness constructs a key business API for use. The lockouts of the
+// logins are audited with the audit business API.
+func NewBusiness(log *logger.Logger, auditBus *auditbus.Business, storer Storer, options ...func(b *Business)) *Business {
+	b := Business{
+		log:      log,
+		auditBus: auditBus,
+		storer:   storer,
+		lockout:  DefaultLockout,
+	}
+
+	for _, option := range options {
+		option(&b)
+	}
+
+	return &b
 }
 
-// NewBusiness constructs a key business API for use.
-func NewBusiness(log *logger.Logger, storer Storer) *Business {
-	return &Business{
-		log:    log,
-		storer: storer,
+// WithLockout sets the policy used to throttle failed logins.
+func WithLockout(cfg LockoutConfig) func(b *Business) {
+	return func(b *Business) {
+		b.lockout = cfg
 	}
 }
 
@@ -53,9 +122,17 @@ func (b *Business) NewWithTx(tx sqldb.CommitRollbacker) (*Business, error) {
 		return nil, err
 	}
 
+	auditBus, err := b.auditBus.NewWithTx(tx)
+	if err != nil {
+		b.log.Error(context.Background(), "business.authbus.newwithtx", "error", err)
+		return nil, err
+	}
+
 	bus := Business{
-		log:    b.log,
-		storer: storer,
+		log:      b.log,
+		auditBus: auditBus,
+		storer:   storer,
+		lockout:  b.lockout,
 	}
 
 	return &bus, nil
@@ -128,3 +205,311 @@ func (b *Business) QueryPasswordResetByToken(ctx context.Context, token string)
 
 	return prt, nil
 }
+
+// DeleteExpiredPasswordResets removes the password reset tokens that expired
+// and returns how many were removed.
+func (b *Business) DeleteExpiredPasswordResets(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.deleteexpiredpasswordresets")
+	defer span.End()
+
+	count, err := b.storer.DeleteExpiredPasswordResets(ctx, now)
+	if err != nil {
+		b.log.Error(ctx, "business.authbus.deleteexpiredpasswordresets", "error", err)
+		return 0, fmt.Errorf("deleteExpiredPasswordResets: %w", err)
+	}
+
+	return count, nil
+}
+
+// CreateSession starts a session for the user, issuing the refresh token that
+// starts its family. The token is returned along with the session, as only
+// its hash is stored.
+func (b *Business) CreateSession(ctx context.Context, ns NewSession) (Session, string, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.createsession")
+	defer span.End()
+
+	now := ctxval.GetTime(ctx)
+
+	sess := Session{
+		ID:          uuid.New(),
+		UserID:      ns.UserID,
+		UserAgent:   ns.UserAgent,
+		IPAddress:   ns.IPAddress,
+		ExpiresAt:   now.Add(ns.TTL),
+		LastSeenAt:  now,
+		DateCreated: now,
+	}
+
+	if err := b.storer.CreateSession(ctx, sess); err != nil {
+		b.log.Error(ctx, "business.authbus.createsession", "error", err)
+		return Session{}, "", fmt.Errorf("createSession: userID[%s]: %w", ns.UserID, err)
+	}
+
+	familyExpiresAt := now.Add(ns.MaxTTL)
+	if familyExpiresAt.Before(sess.ExpiresAt) {
+		familyExpiresAt = sess.ExpiresAt
+	}
+
+	rt := RefreshToken{
+		ID:              uuid.New(),
+		FamilyID:        sess.ID,
+		UserID:          sess.UserID,
+		UserAgent:       sess.UserAgent,
+		ExpiresAt:       sess.ExpiresAt,
+		FamilyExpiresAt: familyExpiresAt,
+		DateCreated:     now,
+	}
+
+	_, token, err := b.issueRefreshToken(ctx, rt)
+	if err != nil {
+		b.log.Error(ctx, "business.authbus.createsession", "error", err)
+
+		// A session without a refresh token can't be used, it's ended so it
+		// doesn't show up as active.
+		if err := b.storer.RevokeSession(ctx, sess.ID, now); err != nil {
+			b.log.Error(ctx, "business.authbus.createsession", "error", err)
+		}
+
+		return Session{}, "", fmt.Errorf("createSession: userID[%s]: %w", ns.UserID, err)
+	}
+
+	return sess, token, nil
+}
+
+// RotateRefreshToken exchanges a refresh token for a new one in the same
+// family. A token can only be used once: when a token that was already
+// rotated is presented again, it was either stolen or replayed, so the whole
+// session is revoked and ErrTokenReused is returned.
+func (b *Business) RotateRefreshToken(ctx context.Context, token string, userAgent string, ipAddress string) (RefreshToken, string, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.rotaterefreshtoken")
+	defer span.End()
+
+	now := ctxval.GetTime(ctx)
+
+	cur, err := b.storer.QueryRefreshTokenByHash(ctx, hashToken(token))
+	if err != nil {
+		return RefreshToken{}, "", fmt.Errorf("query: %w", err)
+	}
+
+	switch {
+	case !cur.RotatedAt.IsZero():
+		return RefreshToken{}, "", b.revokeReused(ctx, cur, now)
+
+	case !cur.RevokedAt.IsZero():
+		return RefreshToken{}, "", fmt.Errorf("rotate: familyID[%s]: %w", cur.FamilyID, ErrTokenRevoked)
+
+	case !cur.ExpiresAt.After(now):
+		return RefreshToken{}, "", fmt.Errorf("rotate: familyID[%s]: %w", cur.FamilyID, ErrTokenExpired)
+	}
+
+	if userAgent == "" {
+		userAgent = cur.UserAgent
+	}
+
+	// The new token gets the same lifetime the family was issued with, but
+	// it can't outlive the family.
+	expiresAt := now.Add(cur.ExpiresAt.Sub(cur.DateCreated))
+	if expiresAt.After(cur.FamilyExpiresAt) {
+		expiresAt = cur.FamilyExpiresAt
+	}
+
+	next := RefreshToken{
+		ID:              uuid.New(),
+		FamilyID:        cur.FamilyID,
+		UserID:          cur.UserID,
+		UserAgent:       userAgent,
+		ExpiresAt:       expiresAt,
+		FamilyExpiresAt: cur.FamilyExpiresAt,
+		DateCreated:     now,
+	}
+
+	// The new token is stored before the current one is marked as rotated, so
+	// a failure leaves the current token usable. If another request rotated
+	// the current token in the meantime, revoking the family takes the new
+	// token with it.
+	next, tkn, err := b.issueRefreshToken(ctx, next)
+	if err != nil {
+		b.log.Error(ctx, "business.authbus.rotaterefreshtoken", "error", err)
+		return RefreshToken{}, "", fmt.Errorf("rotate: familyID[%s]: %w", cur.FamilyID, err)
+	}
+
+	cur.LastUsedAt = now
+	cur.RotatedAt = now
+
+	rotated, err := b.storer.RotateRefreshToken(ctx, cur)
+	if err != nil {
+		b.log.Error(ctx, "business.authbus.rotaterefreshtoken", "error", err)
+		return RefreshToken{}, "", fmt.Errorf("rotate: familyID[%s]: %w", cur.FamilyID, err)
+	}
+
+	if !rotated {
+		return RefreshToken{}, "", b.revokeReused(ctx, cur, now)
+	}
+
+	sess := Session{
+		ID:         next.FamilyID,
+		UserAgent:  next.UserAgent,
+		IPAddress:  ipAddress,
+		ExpiresAt:  next.ExpiresAt,
+		LastSeenAt: now,
+	}
+
+	if err := b.storer.UpdateSession(ctx, sess); err != nil {
+		b.log.Error(ctx, "business.authbus.rotaterefreshtoken", "error", err)
+		return RefreshToken{}, "", fmt.Errorf("update session: sessionID[%s]: %w", sess.ID, err)
+	}
+
+	return next, tkn, nil
+}
+
+// QuerySessionByID finds the session by the specified ID.
+func (b *Business) QuerySessionByID(ctx context.Context, sessionID uuid.UUID) (Session, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.querysessionbyid")
+	defer span.End()
+
+	sess, err := b.storer.QuerySessionByID(ctx, sessionID)
+	if err != nil {
+		return Session{}, fmt.Errorf("query: sessionID[%s]: %w", sessionID, err)
+	}
+
+	return sess, nil
+}
+
+// QueryActiveSessions retrieves the sessions of the user that were not
+// revoked and have not expired, the most recently seen first.
+func (b *Business) QueryActiveSessions(ctx context.Context, userID uuid.UUID) ([]Session, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.queryactivesessions")
+	defer span.End()
+
+	sessions, err := b.storer.QuerySessionsByUserID(ctx, userID, ctxval.GetTime(ctx))
+	if err != nil {
+		b.log.Error(ctx, "business.authbus.queryactivesessions", "error", err)
+		return nil, fmt.Errorf("query: userID[%s]: %w", userID, err)
+	}
+
+	return sessions, nil
+}
+
+// CheckSession returns ErrSessionEnded when the session was revoked or has
+// expired, so the access tokens issued for it are no longer accepted.
+func (b *Business) CheckSession(ctx context.Context, sessionID uuid.UUID) error {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.checksession")
+	defer span.End()
+
+	sess, err := b.storer.QuerySessionByID(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("query: sessionID[%s]: %w", sessionID, err)
+	}
+
+	if !sess.RevokedAt.IsZero() || !sess.ExpiresAt.After(ctxval.GetTime(ctx)) {
+		return fmt.Errorf("check: sessionID[%s]: %w", sessionID, ErrSessionEnded)
+	}
+
+	return nil
+}
+
+// RevokeSession ends the session, revoking its refresh tokens.
+func (b *Business) RevokeSession(ctx context.Context, sess Session) error {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.revokesession")
+	defer span.End()
+
+	if err := b.revokeSession(ctx, sess.ID, ctxval.GetTime(ctx)); err != nil {
+		b.log.Error(ctx, "business.authbus.revokesession", "error", err)
+		return fmt.Errorf("revokeSession: sessionID[%s]: %w", sess.ID, err)
+	}
+
+	return nil
+}
+
+// RevokeUserSessions ends all the sessions of the user, revoking their
+// refresh tokens.
+func (b *Business) RevokeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.revokeusersessions")
+	defer span.End()
+
+	now := ctxval.GetTime(ctx)
+
+	if err := b.storer.RevokeUserSessions(ctx, userID, now); err != nil {
+		b.log.Error(ctx, "business.authbus.revokeusersessions", "error", err)
+		return fmt.Errorf("revokeUserSessions: userID[%s]: %w", userID, err)
+	}
+
+	if err := b.storer.RevokeUserRefreshTokens(ctx, userID, now); err != nil {
+		b.log.Error(ctx, "business.authbus.revokeusersessions", "error", err)
+		return fmt.Errorf("revokeUserSessions: userID[%s]: %w", userID, err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredSessions removes the sessions and the refresh tokens that
+// expired and returns how many of each were removed.
+func (b *Business) DeleteExpiredSessions(ctx context.Context, now time.Time) (int, int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.deleteexpiredsessions")
+	defer span.End()
+
+	sessions, err := b.storer.DeleteExpiredSessions(ctx, now)
+	if err != nil {
+		b.log.Error(ctx, "business.authbus.deleteexpiredsessions", "error", err)
+		return 0, 0, fmt.Errorf("deleteExpiredSessions: %w", err)
+	}
+
+	tokens, err := b.storer.DeleteExpiredRefreshTokens(ctx, now)
+	if err != nil {
+		b.log.Error(ctx, "business.authbus.deleteexpiredsessions", "error", err)
+		return sessions, 0, fmt.Errorf("deleteExpiredRefreshTokens: %w", err)
+	}
+
+	return sessions, tokens, nil
+}
+
+// =============================================================================
+
+// issueRefreshToken generates the token and stores its hash.
+func (b *Business) issueRefreshToken(ctx context.Context, rt RefreshToken) (RefreshToken, string, error) {
+	token, err := id.NewRandomString(64)
+	if err != nil {
+		return RefreshToken{}, "", fmt.Errorf("generate: %w", err)
+	}
+
+	rt.TokenHash = hashToken(token)
+
+	if err := b.storer.CreateRefreshToken(ctx, rt); err != nil {
+		return RefreshToken{}, "", fmt.Errorf("create: %w", err)
+	}
+
+	return rt, token, nil
+}
+
+// revokeSession ends the session first, so its access tokens are rejected
+// even if revoking the refresh tokens fails.
+func (b *Business) revokeSession(ctx context.Context, sessionID uuid.UUID, now time.Time) error {
+	if err := b.storer.RevokeSession(ctx, sessionID, now); err != nil {
+		return fmt.Errorf("session: %w", err)
+	}
+
+	if err := b.storer.RevokeRefreshTokenFamily(ctx, sessionID, now); err != nil {
+		return fmt.Errorf("refresh tokens: %w", err)
+	}
+
+	return nil
+}
+
+// revokeReused revokes the session of a refresh token that was used more
+// than once.
+func (b *Business) revokeReused(ctx context.Context, rt RefreshToken, now time.Time) error {
+	b.log.Warn(ctx, "business.authbus.rotaterefreshtoken", "status", "refresh token reused, revoking session", "userID", rt.UserID, "sessionID", rt.FamilyID)
+
+	if err := b.revokeSession(ctx, rt.FamilyID, now); err != nil {
+		b.log.Error(ctx, "business.authbus.rotaterefreshtoken", "error", err)
+		return fmt.Errorf("revoke: familyID[%s]: %w", rt.FamilyID, err)
+	}
+
+	return fmt.Errorf("rotate: familyID[%s]: %w", rt.FamilyID, ErrTokenReused)
+}
+
+// hashToken returns the hash a token is stored and looked up by.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
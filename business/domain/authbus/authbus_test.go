@@ -2,15 +2,22 @@ package authbus_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/mail"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/rmsj/fake"
 
+	"github.com/rmsj/service/business/domain/auditbus"
 	"github.com/rmsj/service/business/domain/authbus"
+	"github.com/rmsj/service/business/domain/userbus"
 	"github.com/rmsj/service/business/sdk/dbtest"
 	"github.com/rmsj/service/business/sdk/unitest"
+	"github.com/rmsj/service/business/types/role"
+	"github.com/rmsj/service/foundation/totp"
 )
 
 func Test_Auth(t *testing.T) {
@@ -28,6 +35,14 @@ func Test_Auth(t *testing.T) {
 	unitest.Run(t, queryPasswordReset(db.BusDomain, sd), "queryPasswordReset")
 	unitest.Run(t, createPasswordReset(db.BusDomain), "createPasswordReset")
 	unitest.Run(t, deletePasswordReset(db.BusDomain, sd), "deletePasswordReset")
+	unitest.Run(t, deleteExpiredPasswordResets(db.BusDomain, sd), "deleteExpiredPasswordResets")
+	unitest.Run(t, rotateRefreshToken(db.BusDomain, sd), "rotateRefreshToken")
+	unitest.Run(t, sessions(db.BusDomain, sd), "sessions")
+	unitest.Run(t, deleteExpiredSessions(db.BusDomain, sd), "deleteExpiredSessions")
+	unitest.Run(t, mfa(db.BusDomain, sd), "mfa")
+	unitest.Run(t, lockout(db.BusDomain, sd), "lockout")
+	unitest.Run(t, apiKeys(db.BusDomain, sd), "apiKeys")
+	unitest.Run(t, userTokens(db.BusDomain, sd), "userTokens")
 }
 
 // =============================================================================
@@ -50,9 +65,15 @@ func insertSeedData(busDomain dbtest.BusDomain) (unitest.SeedData, error) {
 		return unitest.SeedData{}, fmt.Errorf("seeding keys : %w", err)
 	}
 
+	usrs, err := userbus.TestSeedUsers(ctx, 1, role.User, busDomain.User)
+	if err != nil {
+		return unitest.SeedData{}, fmt.Errorf("seeding users : %w", err)
+	}
+
 	// -------------------------------------------------------------------------
 
 	sd := unitest.SeedData{
+		Users:           []unitest.User{{User: usrs[0]}},
 		PassResetTokens: []authbus.PasswordResetToken{tokenA, tokenB},
 	}
 
@@ -181,3 +202,715 @@ func deletePasswordReset(busDomain dbtest.BusDomain, sd unitest.SeedData) []unit
 
 	return table
 }
+
+func deleteExpiredPasswordResets(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
+	table := []unitest.Table{
+		{
+			Name:    "expired",
+			ExpResp: nil,
+			ExcFunc: func(ctx context.Context) any {
+				// The tokens expire in an hour.
+				if _, err := busDomain.Auth.DeleteExpiredPasswordResets(ctx, time.Now().Add(2*time.Hour)); err != nil {
+					return err
+				}
+
+				_, err := busDomain.Auth.QueryPasswordResetByToken(ctx, sd.PassResetTokens[0].Token)
+				if !errors.Is(err, authbus.ErrNotFound) {
+					return fmt.Errorf("should not find the expired token: %w", err)
+				}
+
+				return nil
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+func rotateRefreshToken(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
+	newSession := func(ctx context.Context) (authbus.Session, string, error) {
+		ns := authbus.NewSession{
+			UserID:    sd.Users[0].ID,
+			UserAgent: "test",
+			IPAddress: "127.0.0.1",
+			TTL:       time.Hour,
+		}
+
+		return busDomain.Auth.CreateSession(ctx, ns)
+	}
+
+	table := []unitest.Table{
+		{
+			Name:    "rotate",
+			ExpResp: nil,
+			ExcFunc: func(ctx context.Context) any {
+				sess, token, err := newSession(ctx)
+				if err != nil {
+					return err
+				}
+
+				next, nextToken, err := busDomain.Auth.RotateRefreshToken(ctx, token, "", "")
+				if err != nil {
+					return err
+				}
+
+				if next.FamilyID != sess.ID || nextToken == token {
+					return errors.New("should have rotated the token within the family")
+				}
+
+				if next.UserAgent != "test" {
+					return fmt.Errorf("should have kept the user agent, got %q", next.UserAgent)
+				}
+
+				if _, _, err := busDomain.Auth.RotateRefreshToken(ctx, nextToken, "test", "127.0.0.1"); err != nil {
+					return err
+				}
+
+				return nil
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:    "reuse",
+			ExpResp: nil,
+			ExcFunc: func(ctx context.Context) any {
+				sess, token, err := newSession(ctx)
+				if err != nil {
+					return err
+				}
+
+				_, nextToken, err := busDomain.Auth.RotateRefreshToken(ctx, token, "test", "127.0.0.1")
+				if err != nil {
+					return err
+				}
+
+				// Replaying the rotated token revokes the whole family.
+				if _, _, err := busDomain.Auth.RotateRefreshToken(ctx, token, "test", "127.0.0.1"); !errors.Is(err, authbus.ErrTokenReused) {
+					return fmt.Errorf("should detect the reuse: %w", err)
+				}
+
+				if _, _, err := busDomain.Auth.RotateRefreshToken(ctx, nextToken, "test", "127.0.0.1"); !errors.Is(err, authbus.ErrTokenRevoked) {
+					return fmt.Errorf("should have revoked the family: %w", err)
+				}
+
+				if err := busDomain.Auth.CheckSession(ctx, sess.ID); !errors.Is(err, authbus.ErrSessionEnded) {
+					return fmt.Errorf("should have ended the session: %w", err)
+				}
+
+				return nil
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:    "family-expiry",
+			ExpResp: nil,
+			ExcFunc: func(ctx context.Context) any {
+				sess, token, err := newSession(ctx)
+				if err != nil {
+					return err
+				}
+
+				// The session can't last longer than its first token, so the
+				// rotated token expires with it instead of an hour from now.
+				time.Sleep(10 * time.Millisecond)
+
+				next, _, err := busDomain.Auth.RotateRefreshToken(ctx, token, "test", "127.0.0.1")
+				if err != nil {
+					return err
+				}
+
+				if next.ExpiresAt.After(sess.ExpiresAt) {
+					return fmt.Errorf("should not outlive the family, expires at %v after %v", next.ExpiresAt, sess.ExpiresAt)
+				}
+
+				if !next.ExpiresAt.Equal(next.FamilyExpiresAt) {
+					return fmt.Errorf("should expire with the family at %v, got %v", next.FamilyExpiresAt, next.ExpiresAt)
+				}
+
+				return nil
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:    "unknown",
+			ExpResp: authbus.ErrNotFound,
+			ExcFunc: func(ctx context.Context) any {
+				_, _, err := busDomain.Auth.RotateRefreshToken(ctx, "unknown", "test", "127.0.0.1")
+				if errors.Is(err, authbus.ErrNotFound) {
+					return authbus.ErrNotFound
+				}
+
+				return err
+			},
+			CmpFunc: func(got any, exp any) string {
+				if got != exp {
+					return fmt.Sprintf("got %v, exp %v", got, exp)
+				}
+
+				return ""
+			},
+		},
+	}
+
+	return table
+}
+
+func sessions(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
+	table := []unitest.Table{
+		{
+			Name:    "revoke",
+			ExpResp: nil,
+			ExcFunc: func(ctx context.Context) any {
+				usrs, err := userbus.TestSeedUsers(ctx, 1, role.User, busDomain.User)
+				if err != nil {
+					return err
+				}
+
+				ns := authbus.NewSession{
+					UserID:    usrs[0].ID,
+					UserAgent: "test",
+					IPAddress: "127.0.0.1",
+					TTL:       time.Hour,
+				}
+
+				sessA, tokenA, err := busDomain.Auth.CreateSession(ctx, ns)
+				if err != nil {
+					return err
+				}
+
+				sessB, tokenB, err := busDomain.Auth.CreateSession(ctx, ns)
+				if err != nil {
+					return err
+				}
+
+				active, err := busDomain.Auth.QueryActiveSessions(ctx, usrs[0].ID)
+				if err != nil {
+					return err
+				}
+
+				if len(active) != 2 {
+					return fmt.Errorf("should have two active sessions, got %d", len(active))
+				}
+
+				if err := busDomain.Auth.RevokeSession(ctx, sessA); err != nil {
+					return err
+				}
+
+				if err := busDomain.Auth.CheckSession(ctx, sessA.ID); !errors.Is(err, authbus.ErrSessionEnded) {
+					return fmt.Errorf("should have ended the session: %w", err)
+				}
+
+				if _, _, err := busDomain.Auth.RotateRefreshToken(ctx, tokenA, "test", "127.0.0.1"); !errors.Is(err, authbus.ErrTokenRevoked) {
+					return fmt.Errorf("should have revoked the refresh token: %w", err)
+				}
+
+				if err := busDomain.Auth.CheckSession(ctx, sessB.ID); err != nil {
+					return fmt.Errorf("should keep the other session: %w", err)
+				}
+
+				if err := busDomain.Auth.RevokeUserSessions(ctx, usrs[0].ID); err != nil {
+					return err
+				}
+
+				if _, _, err := busDomain.Auth.RotateRefreshToken(ctx, tokenB, "test", "127.0.0.1"); !errors.Is(err, authbus.ErrTokenRevoked) {
+					return fmt.Errorf("should have revoked the refresh token: %w", err)
+				}
+
+				active, err = busDomain.Auth.QueryActiveSessions(ctx, usrs[0].ID)
+				if err != nil {
+					return err
+				}
+
+				if len(active) != 0 {
+					return fmt.Errorf("should have no active sessions, got %d", len(active))
+				}
+
+				return nil
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+func deleteExpiredSessions(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
+	table := []unitest.Table{
+		{
+			Name:    "expired",
+			ExpResp: nil,
+			ExcFunc: func(ctx context.Context) any {
+				ns := authbus.NewSession{
+					UserID:    sd.Users[0].ID,
+					UserAgent: "test",
+					TTL:       time.Hour,
+				}
+
+				sess, token, err := busDomain.Auth.CreateSession(ctx, ns)
+				if err != nil {
+					return err
+				}
+
+				if _, _, err := busDomain.Auth.DeleteExpiredSessions(ctx, time.Now().Add(2*time.Hour)); err != nil {
+					return err
+				}
+
+				if _, err := busDomain.Auth.QuerySessionByID(ctx, sess.ID); !errors.Is(err, authbus.ErrNotFound) {
+					return fmt.Errorf("should not find the expired session: %w", err)
+				}
+
+				if _, _, err := busDomain.Auth.RotateRefreshToken(ctx, token, "test", "127.0.0.1"); !errors.Is(err, authbus.ErrNotFound) {
+					return fmt.Errorf("should not find the expired token: %w", err)
+				}
+
+				return nil
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+func mfa(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
+	table := []unitest.Table{
+		{
+			Name:    "enroll-verify-disable",
+			ExpResp: nil,
+			ExcFunc: func(ctx context.Context) any {
+				usrs, err := userbus.TestSeedUsers(ctx, 1, role.User, busDomain.User)
+				if err != nil {
+					return err
+				}
+				userID := usrs[0].ID
+
+				enrolled, err := busDomain.Auth.EnrollMFA(ctx, userID)
+				if err != nil {
+					return err
+				}
+
+				if err := busDomain.Auth.VerifyMFA(ctx, userID, "000000"); !errors.Is(err, authbus.ErrMFANotEnrolled) {
+					return fmt.Errorf("should not verify before confirming: %w", err)
+				}
+
+				now := time.Now()
+
+				code, err := totp.Code(enrolled.Secret, now)
+				if err != nil {
+					return err
+				}
+
+				codes, err := busDomain.Auth.ConfirmMFA(ctx, userID, code)
+				if err != nil {
+					return err
+				}
+
+				if len(codes) != 10 {
+					return fmt.Errorf("should have ten recovery codes, got %d", len(codes))
+				}
+
+				if err := busDomain.Auth.VerifyMFA(ctx, userID, code); !errors.Is(err, authbus.ErrMFAInvalidCode) {
+					return fmt.Errorf("should reject a replayed code: %w", err)
+				}
+
+				next, err := totp.Code(enrolled.Secret, now.Add(totp.Period))
+				if err != nil {
+					return err
+				}
+
+				if err := busDomain.Auth.VerifyMFA(ctx, userID, next); err != nil {
+					return fmt.Errorf("should accept the next code: %w", err)
+				}
+
+				if err := busDomain.Auth.VerifyMFA(ctx, userID, codes[0]); err != nil {
+					return fmt.Errorf("should accept a recovery code: %w", err)
+				}
+
+				if err := busDomain.Auth.VerifyMFA(ctx, userID, codes[0]); !errors.Is(err, authbus.ErrMFAInvalidCode) {
+					return fmt.Errorf("should reject a used recovery code: %w", err)
+				}
+
+				status, err := busDomain.Auth.QueryMFAStatus(ctx, userID)
+				if err != nil {
+					return err
+				}
+
+				if !status.Enabled || status.RecoveryCodesLeft != 9 {
+					return fmt.Errorf("unexpected status: %+v", status)
+				}
+
+				if err := busDomain.Auth.DisableMFA(ctx, userID); err != nil {
+					return err
+				}
+
+				enabled, err := busDomain.Auth.MFAEnabled(ctx, userID)
+				if err != nil {
+					return err
+				}
+
+				if enabled {
+					return errors.New("should have disabled mfa")
+				}
+
+				return nil
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+func lockout(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
+	table := []unitest.Table{
+		{
+			Name:    "account",
+			ExpResp: nil,
+			ExcFunc: func(ctx context.Context) any {
+				email := mail.Address{Address: "Lockout@Example.com"}
+
+				if _, err := busDomain.Auth.CheckLogin(ctx, email, ""); err != nil {
+					return fmt.Errorf("should allow the first attempt: %w", err)
+				}
+
+				var retryAfter time.Duration
+				for range authbus.DefaultLockout.MaxAccountFailures {
+					var err error
+					if retryAfter, err = busDomain.Auth.LoginFailed(ctx, email, ""); err != nil {
+						return err
+					}
+				}
+
+				if retryAfter != authbus.DefaultLockout.Duration {
+					return fmt.Errorf("should lock for %s, got %s", authbus.DefaultLockout.Duration, retryAfter)
+				}
+
+				lower := mail.Address{Address: "lockout@example.com"}
+
+				if _, err := busDomain.Auth.CheckLogin(ctx, lower, ""); !errors.Is(err, authbus.ErrLoginBlocked) {
+					return fmt.Errorf("should block the account whatever the case: %w", err)
+				}
+
+				if err := busDomain.Auth.UnlockAccount(ctx, email); err != nil {
+					return err
+				}
+
+				if _, err := busDomain.Auth.CheckLogin(ctx, email, ""); err != nil {
+					return fmt.Errorf("should allow attempts once unlocked: %w", err)
+				}
+
+				entityID := authbus.ScopeAccount + ":" + lower.Address
+				n, err := busDomain.Audit.Count(ctx, auditbus.QueryFilter{EntityID: &entityID})
+				if err != nil {
+					return err
+				}
+
+				if n != 2 {
+					return fmt.Errorf("should audit the lock and the unlock, got %d audits", n)
+				}
+
+				return nil
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:    "delay",
+			ExpResp: nil,
+			ExcFunc: func(ctx context.Context) any {
+				email := mail.Address{Address: "delay@example.com"}
+				const ip = "192.0.2.10"
+
+				first, err := busDomain.Auth.LoginFailed(ctx, email, ip)
+				if err != nil {
+					return err
+				}
+
+				second, err := busDomain.Auth.LoginFailed(ctx, email, ip)
+				if err != nil {
+					return err
+				}
+
+				if first <= 0 || second <= first {
+					return fmt.Errorf("should increase the delay: %s then %s", first, second)
+				}
+
+				if _, err := busDomain.Auth.CheckLogin(ctx, mail.Address{Address: "other@example.com"}, ip); !errors.Is(err, authbus.ErrLoginBlocked) {
+					return fmt.Errorf("should delay other accounts from the same ip: %w", err)
+				}
+
+				if err := busDomain.Auth.LoginSucceeded(ctx, email); err != nil {
+					return err
+				}
+
+				if err := busDomain.Auth.UnlockIP(ctx, ip); err != nil {
+					return err
+				}
+
+				if _, err := busDomain.Auth.CheckLogin(ctx, email, ip); err != nil {
+					return fmt.Errorf("should allow attempts once cleared: %w", err)
+				}
+
+				return nil
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+func apiKeys(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
+	table := []unitest.Table{
+		{
+			Name:    "authenticate",
+			ExpResp: []string{"product:read"},
+			ExcFunc: func(ctx context.Context) any {
+				nk := authbus.NewAPIKey{
+					UserID: sd.Users[0].ID,
+					Name:   "ci",
+					Scopes: []string{"product:read"},
+				}
+
+				key, token, err := busDomain.Auth.CreateAPIKey(ctx, nk)
+				if err != nil {
+					return err
+				}
+
+				got, err := busDomain.Auth.AuthenticateAPIKey(ctx, token)
+				if err != nil {
+					return err
+				}
+
+				if got.ID != key.ID || got.UserID != sd.Users[0].ID {
+					return fmt.Errorf("should find the key, got %s", got.ID)
+				}
+
+				if _, err := busDomain.Auth.AuthenticateAPIKey(ctx, token+"x"); !errors.Is(err, authbus.ErrAPIKeyInvalid) {
+					return fmt.Errorf("should reject a wrong key: %w", err)
+				}
+
+				stored, err := busDomain.Auth.QueryAPIKeyByID(ctx, key.ID)
+				if err != nil {
+					return err
+				}
+
+				if stored.LastUsedAt.IsZero() {
+					return errors.New("should record the use of the key")
+				}
+
+				return stored.Scopes
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:    "expired",
+			ExpResp: authbus.ErrAPIKeyExpired,
+			ExcFunc: func(ctx context.Context) any {
+				nk := authbus.NewAPIKey{
+					UserID:    sd.Users[0].ID,
+					Name:      "expired",
+					ExpiresAt: time.Now().Add(-time.Minute),
+				}
+
+				_, token, err := busDomain.Auth.CreateAPIKey(ctx, nk)
+				if err != nil {
+					return err
+				}
+
+				_, err = busDomain.Auth.AuthenticateAPIKey(ctx, token)
+				return err
+			},
+			CmpFunc: func(got any, exp any) string {
+				if !errors.Is(got.(error), exp.(error)) {
+					return fmt.Sprintf("got %v, exp %v", got, exp)
+				}
+				return ""
+			},
+		},
+		{
+			Name:    "revoke",
+			ExpResp: authbus.ErrAPIKeyRevoked,
+			ExcFunc: func(ctx context.Context) any {
+				nk := authbus.NewAPIKey{
+					UserID: sd.Users[0].ID,
+					Name:   "revoked",
+				}
+
+				key, token, err := busDomain.Auth.CreateAPIKey(ctx, nk)
+				if err != nil {
+					return err
+				}
+
+				if err := busDomain.Auth.RevokeAPIKey(ctx, key); err != nil {
+					return err
+				}
+
+				keys, err := busDomain.Auth.QueryAPIKeys(ctx, sd.Users[0].ID)
+				if err != nil {
+					return err
+				}
+
+				for _, k := range keys {
+					if k.ID == key.ID {
+						return errors.New("should not list the revoked key")
+					}
+				}
+
+				_, err = busDomain.Auth.AuthenticateAPIKey(ctx, token)
+				return err
+			},
+			CmpFunc: func(got any, exp any) string {
+				if !errors.Is(got.(error), exp.(error)) {
+					return fmt.Sprintf("got %v, exp %v", got, exp)
+				}
+				return ""
+			},
+		},
+	}
+
+	return table
+}
+
+func userTokens(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
+	table := []unitest.Table{
+		{
+			Name:    "invitation",
+			ExpResp: authbus.ErrUserTokenInvalid,
+			ExcFunc: func(ctx context.Context) any {
+				ni := authbus.NewInvitation{
+					Email:     mail.Address{Address: "invited@example.com"},
+					Roles:     []role.Role{role.Staff},
+					InvitedBy: sd.Users[0].ID,
+					TTL:       time.Hour,
+				}
+
+				first, _, err := busDomain.Auth.CreateInvitation(ctx, ni)
+				if err != nil {
+					return err
+				}
+
+				ut, token, err := busDomain.Auth.CreateInvitation(ctx, ni)
+				if err != nil {
+					return err
+				}
+
+				pending, err := busDomain.Auth.QueryPendingInvitations(ctx)
+				if err != nil {
+					return err
+				}
+
+				var found bool
+				for _, p := range pending {
+					if p.ID == first.ID {
+						return errors.New("should revoke the previous invitation for the same email")
+					}
+					found = found || p.ID == ut.ID
+				}
+
+				if !found {
+					return errors.New("should list the pending invitation")
+				}
+
+				if _, err := busDomain.Auth.UseUserToken(ctx, authbus.PurposeEmailVerification, token); !errors.Is(err, authbus.ErrUserTokenInvalid) {
+					return fmt.Errorf("should reject the token for another purpose: %w", err)
+				}
+
+				used, err := busDomain.Auth.UseUserToken(ctx, authbus.PurposeInvitation, token)
+				if err != nil {
+					return err
+				}
+
+				if used.ID != ut.ID || !role.HasRole(used.Roles, role.Staff) {
+					return fmt.Errorf("should use the invitation, got %s %v", used.ID, used.Roles)
+				}
+
+				_, err = busDomain.Auth.UseUserToken(ctx, authbus.PurposeInvitation, token)
+				return err
+			},
+			CmpFunc: func(got any, exp any) string {
+				if !errors.Is(got.(error), exp.(error)) {
+					return fmt.Sprintf("got %v, exp %v", got, exp)
+				}
+				return ""
+			},
+		},
+		{
+			Name:    "revoked",
+			ExpResp: authbus.ErrUserTokenInvalid,
+			ExcFunc: func(ctx context.Context) any {
+				ni := authbus.NewInvitation{
+					Email:     mail.Address{Address: "revoked@example.com"},
+					Roles:     []role.Role{role.User},
+					InvitedBy: sd.Users[0].ID,
+					TTL:       time.Hour,
+				}
+
+				ut, token, err := busDomain.Auth.CreateInvitation(ctx, ni)
+				if err != nil {
+					return err
+				}
+
+				if err := busDomain.Auth.RevokeUserToken(ctx, ut); err != nil {
+					return err
+				}
+
+				_, err = busDomain.Auth.UseUserToken(ctx, authbus.PurposeInvitation, token)
+				return err
+			},
+			CmpFunc: func(got any, exp any) string {
+				if !errors.Is(got.(error), exp.(error)) {
+					return fmt.Sprintf("got %v, exp %v", got, exp)
+				}
+				return ""
+			},
+		},
+		{
+			Name:    "expired",
+			ExpResp: authbus.ErrUserTokenExpired,
+			ExcFunc: func(ctx context.Context) any {
+				nv := authbus.NewEmailVerification{
+					UserID: sd.Users[0].ID,
+					Email:  sd.Users[0].Email,
+					TTL:    -time.Minute,
+				}
+
+				_, token, err := busDomain.Auth.CreateEmailVerification(ctx, nv)
+				if err != nil {
+					return err
+				}
+
+				_, err = busDomain.Auth.UseUserToken(ctx, authbus.PurposeEmailVerification, token)
+				return err
+			},
+			CmpFunc: func(got any, exp any) string {
+				if !errors.Is(got.(error), exp.(error)) {
+					return fmt.Sprintf("got %v, exp %v", got, exp)
+				}
+				return ""
+			},
+		},
+	}
+
+	return table
+}
@@ -0,0 +1,254 @@
+package authbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/rmsj/service/business/domain/auditbus"
+	"github.com/rmsj/service/business/sdk/ctxval"
+	"github.com/rmsj/service/foundation/otel"
+)
+
+// ErrLoginBlocked is returned when there were too many failed logins for the
+// account or from the source IP.
+var ErrLoginBlocked = errors.New("login blocked")
+
+// CheckLogin reports if a login for the email can be attempted from the IP.
+// When it can't, ErrLoginBlocked is returned along with how long the caller
+// must wait before trying again.
+func (b *Business) CheckLogin(ctx context.Context, email mail.Address, ip string) (time.Duration, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.checklogin")
+	defer span.End()
+
+	now := ctxval.GetTime(ctx)
+
+	var retryAfter time.Duration
+
+	for _, lim := range b.loginLimits(email, ip) {
+		la, err := b.storer.QueryLoginAttempts(ctx, lim.scope, lim.key)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			b.log.Error(ctx, "business.authbus.checklogin", "error", err)
+			return 0, fmt.Errorf("query: scope[%s]: %w", lim.scope, err)
+		}
+
+		if wait := la.BlockedUntil.Sub(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	if retryAfter > 0 {
+		return retryAfter, fmt.Errorf("check: email[%s]: %w", email.Address, ErrLoginBlocked)
+	}
+
+	return 0, nil
+}
+
+// LoginFailed records a failed login for the email from the IP. Every failure
+// delays the next attempt a bit more, until there are too many and the
+// account or the IP is locked. It returns how long the next attempt must
+// wait.
+func (b *Business) LoginFailed(ctx context.Context, email mail.Address, ip string) (time.Duration, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.loginfailed")
+	defer span.End()
+
+	now := ctxval.GetTime(ctx)
+
+	var retryAfter time.Duration
+
+	for _, lim := range b.loginLimits(email, ip) {
+		la := LoginAttempts{
+			Scope:         lim.scope,
+			Key:           lim.key,
+			LastFailureAt: now,
+			DateCreated:   now,
+			DateUpdated:   now,
+		}
+
+		la, err := b.storer.AddLoginFailure(ctx, la, now.Add(-b.lockout.Window))
+		if err != nil {
+			b.log.Error(ctx, "business.authbus.loginfailed", "error", err)
+			return 0, fmt.Errorf("add failure: scope[%s]: %w", lim.scope, err)
+		}
+
+		wait := b.loginDelay(la.Failures)
+		locked := la.Failures >= lim.max
+		if locked {
+			wait = b.lockout.Duration
+		}
+
+		if wait <= 0 {
+			continue
+		}
+
+		if err := b.storer.BlockLogin(ctx, lim.scope, lim.key, now.Add(wait)); err != nil {
+			b.log.Error(ctx, "business.authbus.loginfailed", "error", err)
+			return 0, fmt.Errorf("block: scope[%s]: %w", lim.scope, err)
+		}
+
+		if locked {
+			adt := lockoutAudit{Scope: lim.scope, Key: lim.key, Failures: la.Failures, BlockedUntil: now.Add(wait)}
+			if err := b.audit(ctx, "login.locked", adt); err != nil {
+				return 0, fmt.Errorf("audit: scope[%s]: %w", lim.scope, err)
+			}
+		}
+
+		if wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	return retryAfter, nil
+}
+
+// LoginSucceeded clears the failed logins of the account. The failures of
+// the IP are kept, otherwise a single valid account would let an attacker
+// keep trying others from the same IP.
+func (b *Business) LoginSucceeded(ctx context.Context, email mail.Address) error {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.loginsucceeded")
+	defer span.End()
+
+	if b.lockout.MaxAccountFailures <= 0 {
+		return nil
+	}
+
+	if err := b.storer.DeleteLoginAttempts(ctx, ScopeAccount, accountKey(email)); err != nil {
+		b.log.Error(ctx, "business.authbus.loginsucceeded", "error", err)
+		return fmt.Errorf("delete: email[%s]: %w", email.Address, err)
+	}
+
+	return nil
+}
+
+// UnlockAccount clears the failed logins of the account, lifting any lockout.
+func (b *Business) UnlockAccount(ctx context.Context, email mail.Address) error {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.unlockaccount")
+	defer span.End()
+
+	if err := b.storer.DeleteLoginAttempts(ctx, ScopeAccount, accountKey(email)); err != nil {
+		b.log.Error(ctx, "business.authbus.unlockaccount", "error", err)
+		return fmt.Errorf("delete: email[%s]: %w", email.Address, err)
+	}
+
+	if err := b.audit(ctx, "login.unlocked", lockoutAudit{Scope: ScopeAccount, Key: accountKey(email)}); err != nil {
+		return fmt.Errorf("audit: email[%s]: %w", email.Address, err)
+	}
+
+	return nil
+}
+
+// UnlockIP clears the failed logins from the IP, lifting any lockout.
+func (b *Business) UnlockIP(ctx context.Context, ip string) error {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.unlockip")
+	defer span.End()
+
+	if err := b.storer.DeleteLoginAttempts(ctx, ScopeIP, ip); err != nil {
+		b.log.Error(ctx, "business.authbus.unlockip", "error", err)
+		return fmt.Errorf("delete: ip[%s]: %w", ip, err)
+	}
+
+	if err := b.audit(ctx, "login.unlocked", lockoutAudit{Scope: ScopeIP, Key: ip}); err != nil {
+		return fmt.Errorf("audit: ip[%s]: %w", ip, err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredLoginAttempts removes the failed logins that last happened
+// before the specified time and no longer block anything. It returns how
+// many were removed.
+func (b *Business) DeleteExpiredLoginAttempts(ctx context.Context, before time.Time) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.deleteexpiredloginattempts")
+	defer span.End()
+
+	count, err := b.storer.DeleteExpiredLoginAttempts(ctx, before)
+	if err != nil {
+		b.log.Error(ctx, "business.authbus.deleteexpiredloginattempts", "error", err)
+		return 0, fmt.Errorf("deleteExpiredLoginAttempts: %w", err)
+	}
+
+	return count, nil
+}
+
+// =============================================================================
+
+type loginLimit struct {
+	scope string
+	key   string
+	max   int
+}
+
+// loginLimits returns the scopes a login is tracked by, skipping the ones
+// that are disabled.
+func (b *Business) loginLimits(email mail.Address, ip string) []loginLimit {
+	var limits []loginLimit
+
+	if b.lockout.MaxAccountFailures > 0 {
+		limits = append(limits, loginLimit{scope: ScopeAccount, key: accountKey(email), max: b.lockout.MaxAccountFailures})
+	}
+
+	if b.lockout.MaxIPFailures > 0 && ip != "" {
+		limits = append(limits, loginLimit{scope: ScopeIP, key: ip, max: b.lockout.MaxIPFailures})
+	}
+
+	return limits
+}
+
+// loginDelay returns how long the next attempt must wait after the specified
+// number of failures.
+func (b *Business) loginDelay(failures int) time.Duration {
+	if b.lockout.BaseDelay <= 0 {
+		return 0
+	}
+
+	maxDelay := b.lockout.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = b.lockout.BaseDelay
+	}
+
+	delay := b.lockout.BaseDelay
+	for i := 1; i < failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxDelay)
+}
+
+// lockoutAudit is the state of a lockout recorded in the audit log.
+type lockoutAudit struct {
+	Scope        string    `json:"scope"`
+	Key          string    `json:"key"`
+	Failures     int       `json:"failures,omitempty"`
+	BlockedUntil time.Time `json:"blockedUntil,omitzero"`
+}
+
+// audit records a change to a lockout in the audit log. The lockouts are
+// keyed by the email or the IP, which is recorded as the entity.
+func (b *Business) audit(ctx context.Context, action string, la lockoutAudit) error {
+	na := auditbus.NewAudit{
+		Action:   action,
+		Domain:   "auth",
+		EntityID: la.Scope + ":" + la.Key,
+		After:    la,
+		TraceID:  otel.GetTraceID(ctx),
+	}
+
+	if _, err := b.auditBus.Create(ctx, na); err != nil {
+		b.log.Error(ctx, "business.authbus.audit", "error", err)
+		return fmt.Errorf("create: action[%s]: %w", action, err)
+	}
+
+	return nil
+}
+
+// accountKey normalizes the email so the failures of an account are counted
+// together, whatever the case it's typed with.
+func accountKey(email mail.Address) string {
+	return strings.ToLower(email.Address)
+}
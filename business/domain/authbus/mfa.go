@@ -0,0 +1,319 @@
+package authbus
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/sdk/ctxval"
+	"github.com/rmsj/service/foundation/otel"
+	"github.com/rmsj/service/foundation/totp"
+)
+
+// Set of error variables for multi-factor authentication.
+var (
+	ErrMFANotEnrolled = errors.New("mfa not enrolled")
+	ErrMFAEnabled     = errors.New("mfa already enabled")
+	ErrMFAInvalidCode = errors.New("mfa code invalid")
+)
+
+const (
+	// recoveryCodes is how many recovery codes a user gets.
+	recoveryCodes = 10
+
+	// totpSkew is how many steps the clock of a device can be off.
+	totpSkew = 1
+)
+
+// EnrollMFA starts the enrollment of the user, generating a new secret. An
+// enrollment that wasn't confirmed is replaced.
+func (b *Business) EnrollMFA(ctx context.Context, userID uuid.UUID) (MFA, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.enrollmfa")
+	defer span.End()
+
+	cur, err := b.storer.QueryMFAByUserID(ctx, userID)
+	switch {
+	case err == nil:
+		if cur.Enabled() {
+			return MFA{}, fmt.Errorf("enroll: userID[%s]: %w", userID, ErrMFAEnabled)
+		}
+
+	case !errors.Is(err, ErrNotFound):
+		b.log.Error(ctx, "business.authbus.enrollmfa", "error", err)
+		return MFA{}, fmt.Errorf("query: userID[%s]: %w", userID, err)
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		b.log.Error(ctx, "business.authbus.enrollmfa", "error", err)
+		return MFA{}, fmt.Errorf("enroll: userID[%s]: %w", userID, err)
+	}
+
+	now := ctxval.GetTime(ctx)
+
+	mfa := MFA{
+		UserID:      userID,
+		Secret:      secret,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if err := b.storer.SaveMFA(ctx, mfa); err != nil {
+		b.log.Error(ctx, "business.authbus.enrollmfa", "error", err)
+		return MFA{}, fmt.Errorf("save: userID[%s]: %w", userID, err)
+	}
+
+	return mfa, nil
+}
+
+// ConfirmMFA enables the pending enrollment of the user once the code proves
+// the device was set up, and returns the recovery codes of the user. They
+// are only available now, as only their hashes are stored.
+func (b *Business) ConfirmMFA(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.confirmmfa")
+	defer span.End()
+
+	mfa, err := b.storer.QueryMFAByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("confirm: userID[%s]: %w", userID, ErrMFANotEnrolled)
+		}
+		b.log.Error(ctx, "business.authbus.confirmmfa", "error", err)
+		return nil, fmt.Errorf("query: userID[%s]: %w", userID, err)
+	}
+
+	if mfa.Enabled() {
+		return nil, fmt.Errorf("confirm: userID[%s]: %w", userID, ErrMFAEnabled)
+	}
+
+	now := ctxval.GetTime(ctx)
+
+	step, err := totp.Validate(mfa.Secret, code, now, totpSkew)
+	if err != nil {
+		return nil, fmt.Errorf("confirm: userID[%s]: %w", userID, ErrMFAInvalidCode)
+	}
+
+	mfa.LastUsedStep = step
+	mfa.EnabledAt = now
+	mfa.DateUpdated = now
+
+	enabled, err := b.storer.EnableMFA(ctx, mfa)
+	if err != nil {
+		b.log.Error(ctx, "business.authbus.confirmmfa", "error", err)
+		return nil, fmt.Errorf("enable: userID[%s]: %w", userID, err)
+	}
+
+	// Another request confirmed or replaced the enrollment in the meantime.
+	if !enabled {
+		return nil, fmt.Errorf("confirm: userID[%s]: %w", userID, ErrMFAInvalidCode)
+	}
+
+	codes, err := b.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		b.log.Error(ctx, "business.authbus.confirmmfa", "error", err)
+		return nil, fmt.Errorf("recovery codes: userID[%s]: %w", userID, err)
+	}
+
+	return codes, nil
+}
+
+// VerifyMFA checks the code of the user, which is either a TOTP code or one
+// of the recovery codes. Each code can only be used once.
+func (b *Business) VerifyMFA(ctx context.Context, userID uuid.UUID, code string) error {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.verifymfa")
+	defer span.End()
+
+	mfa, err := b.storer.QueryMFAByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("verify: userID[%s]: %w", userID, ErrMFANotEnrolled)
+		}
+		b.log.Error(ctx, "business.authbus.verifymfa", "error", err)
+		return fmt.Errorf("query: userID[%s]: %w", userID, err)
+	}
+
+	if !mfa.Enabled() {
+		return fmt.Errorf("verify: userID[%s]: %w", userID, ErrMFANotEnrolled)
+	}
+
+	now := ctxval.GetTime(ctx)
+
+	if step, err := totp.Validate(mfa.Secret, code, now, totpSkew); err == nil {
+		used, err := b.storer.UseMFAStep(ctx, userID, step, now)
+		if err != nil {
+			b.log.Error(ctx, "business.authbus.verifymfa", "error", err)
+			return fmt.Errorf("use step: userID[%s]: %w", userID, err)
+		}
+
+		// The code, or a later one, was already used.
+		if !used {
+			return fmt.Errorf("verify: userID[%s]: %w", userID, ErrMFAInvalidCode)
+		}
+
+		return nil
+	}
+
+	used, err := b.storer.UseRecoveryCode(ctx, userID, hashRecoveryCode(code), now)
+	if err != nil {
+		b.log.Error(ctx, "business.authbus.verifymfa", "error", err)
+		return fmt.Errorf("use recovery code: userID[%s]: %w", userID, err)
+	}
+
+	if !used {
+		return fmt.Errorf("verify: userID[%s]: %w", userID, ErrMFAInvalidCode)
+	}
+
+	return nil
+}
+
+// MFAEnabled reports if the user confirmed an enrollment.
+func (b *Business) MFAEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.mfaenabled")
+	defer span.End()
+
+	mfa, err := b.storer.QueryMFAByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		b.log.Error(ctx, "business.authbus.mfaenabled", "error", err)
+		return false, fmt.Errorf("query: userID[%s]: %w", userID, err)
+	}
+
+	return mfa.Enabled(), nil
+}
+
+// QueryMFAStatus returns the state of the multi-factor authentication of the
+// user.
+func (b *Business) QueryMFAStatus(ctx context.Context, userID uuid.UUID) (MFAStatus, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.querymfastatus")
+	defer span.End()
+
+	enabled, err := b.MFAEnabled(ctx, userID)
+	if err != nil {
+		return MFAStatus{}, err
+	}
+
+	if !enabled {
+		return MFAStatus{}, nil
+	}
+
+	left, err := b.storer.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		b.log.Error(ctx, "business.authbus.querymfastatus", "error", err)
+		return MFAStatus{}, fmt.Errorf("count recovery codes: userID[%s]: %w", userID, err)
+	}
+
+	status := MFAStatus{
+		Enabled:           true,
+		RecoveryCodesLeft: left,
+	}
+
+	return status, nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user.
+func (b *Business) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.regeneraterecoverycodes")
+	defer span.End()
+
+	enabled, err := b.MFAEnabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !enabled {
+		return nil, fmt.Errorf("regenerate: userID[%s]: %w", userID, ErrMFANotEnrolled)
+	}
+
+	codes, err := b.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		b.log.Error(ctx, "business.authbus.regeneraterecoverycodes", "error", err)
+		return nil, fmt.Errorf("regenerate: userID[%s]: %w", userID, err)
+	}
+
+	return codes, nil
+}
+
+// DisableMFA removes the multi-factor authentication of the user, along with
+// the recovery codes.
+func (b *Business) DisableMFA(ctx context.Context, userID uuid.UUID) error {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.disablemfa")
+	defer span.End()
+
+	if err := b.storer.DeleteMFA(ctx, userID); err != nil {
+		b.log.Error(ctx, "business.authbus.disablemfa", "error", err)
+		return fmt.Errorf("delete: userID[%s]: %w", userID, err)
+	}
+
+	if err := b.storer.DeleteRecoveryCodes(ctx, userID); err != nil {
+		b.log.Error(ctx, "business.authbus.disablemfa", "error", err)
+		return fmt.Errorf("delete recovery codes: userID[%s]: %w", userID, err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// replaceRecoveryCodes generates a new set of recovery codes for the user,
+// removing the previous ones.
+func (b *Business) replaceRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	if err := b.storer.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return nil, fmt.Errorf("delete: %w", err)
+	}
+
+	now := ctxval.GetTime(ctx)
+
+	codes := make([]string, recoveryCodes)
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("generate: %w", err)
+		}
+
+		rc := RecoveryCode{
+			ID:          uuid.New(),
+			UserID:      userID,
+			CodeHash:    hashRecoveryCode(code),
+			DateCreated: now,
+		}
+
+		if err := b.storer.CreateRecoveryCode(ctx, rc); err != nil {
+			return nil, fmt.Errorf("create: %w", err)
+		}
+
+		codes[i] = code
+	}
+
+	return codes, nil
+}
+
+var recoveryEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// newRecoveryCode generates a code of 10 characters, 50 random bits, split in
+// two groups so it's easy to type.
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	code := recoveryEncoding.EncodeToString(b)[:10]
+
+	return code[:5] + "-" + code[5:], nil
+}
+
+// hashRecoveryCode hashes the code ignoring case and separators, the way the
+// user may type it.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+
+	return hashToken(code)
+}
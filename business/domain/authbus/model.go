@@ -1,7 +1,12 @@
 package authbus
 
 import (
+	"net/mail"
 	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/types/role"
 )
 
 // PasswordResetToken represents information about an individual key.
@@ -21,3 +26,199 @@ type Login struct {
 	Email    string
 	Password string
 }
+
+// RefreshToken represents a refresh token issued to a user. Only the hash of
+// the token is stored. Every use rotates the token, and the tokens that come
+// from the same login share a family, identified by the ID of the session.
+// No token of a family expires after the family does.
+type RefreshToken struct {
+	ID              uuid.UUID
+	FamilyID        uuid.UUID
+	UserID          uuid.UUID
+	TokenHash       string
+	UserAgent       string
+	ExpiresAt       time.Time
+	FamilyExpiresAt time.Time
+	LastUsedAt      time.Time
+	RotatedAt       time.Time
+	RevokedAt       time.Time
+	DateCreated     time.Time
+}
+
+// Session represents a login of a user on a device. It lasts as long as its
+// refresh tokens keep being rotated, up to the expiry of their family, or
+// until it's revoked.
+type Session struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	UserAgent   string
+	IPAddress   string
+	ExpiresAt   time.Time
+	LastSeenAt  time.Time
+	RevokedAt   time.Time
+	DateCreated time.Time
+}
+
+// NewSession contains information needed to start a session. The TTL is the
+// lifetime of every refresh token and the MaxTTL is the longest the session
+// can last, however often its tokens are rotated. A MaxTTL shorter than the
+// TTL ends the session when its first token expires.
+type NewSession struct {
+	UserID    uuid.UUID
+	UserAgent string
+	IPAddress string
+	TTL       time.Duration
+	MaxTTL    time.Duration
+}
+
+// APIKey represents a key a user issued for a client to call the api on
+// their behalf. Only the hash of the key is stored, the prefix is the part
+// of the key it's looked up by. A key with scopes is limited to those
+// permissions, one without them has all the permissions of the user.
+type APIKey struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Name        string
+	Prefix      string
+	KeyHash     string
+	Scopes      []string
+	ExpiresAt   time.Time
+	LastUsedAt  time.Time
+	RevokedAt   time.Time
+	DateCreated time.Time
+}
+
+// NewAPIKey contains information needed to issue an API key. A zero
+// ExpiresAt means the key never expires.
+type NewAPIKey struct {
+	UserID    uuid.UUID
+	Name      string
+	Scopes    []string
+	ExpiresAt time.Time
+}
+
+// Set of purposes a UserToken is issued for.
+const (
+	PurposeInvitation        = "invitation"
+	PurposeEmailVerification = "email_verification"
+)
+
+// UserToken represents a single use token sent by email, either to invite
+// someone to create an account or to verify the email of a user. Only the
+// hash of the token is stored. Invitations carry the roles the account is
+// created with, verifications the user the email belongs to.
+type UserToken struct {
+	ID          uuid.UUID
+	Purpose     string
+	TokenHash   string
+	Email       mail.Address
+	UserID      uuid.UUID
+	Roles       []role.Role
+	CreatedBy   uuid.UUID
+	ExpiresAt   time.Time
+	UsedAt      time.Time
+	RevokedAt   time.Time
+	DateCreated time.Time
+}
+
+// NewInvitation contains information needed to invite someone to create an
+// account.
+type NewInvitation struct {
+	Email     mail.Address
+	Roles     []role.Role
+	InvitedBy uuid.UUID
+	TTL       time.Duration
+}
+
+// NewEmailVerification contains information needed to ask a user to verify
+// their email.
+type NewEmailVerification struct {
+	UserID uuid.UUID
+	Email  mail.Address
+	TTL    time.Duration
+}
+
+// MFA represents the TOTP multi-factor authentication of a user. It's
+// pending until the user confirms the enrollment with a first code.
+type MFA struct {
+	UserID       uuid.UUID
+	Secret       string
+	LastUsedStep int64
+	EnabledAt    time.Time
+	DateCreated  time.Time
+	DateUpdated  time.Time
+}
+
+// Enabled reports if the enrollment was confirmed.
+func (m MFA) Enabled() bool {
+	return !m.EnabledAt.IsZero()
+}
+
+// RecoveryCode represents a single use code that replaces a TOTP code when
+// the user lost their device. Only the hash of the code is stored.
+type RecoveryCode struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	CodeHash    string
+	UsedAt      time.Time
+	DateCreated time.Time
+}
+
+// MFAStatus represents the state of the multi-factor authentication of a
+// user.
+type MFAStatus struct {
+	Enabled           bool
+	RecoveryCodesLeft int
+}
+
+// Set of scopes failed logins are tracked by.
+const (
+	ScopeAccount = "account"
+	ScopeIP      = "ip"
+)
+
+// LoginAttempts represents the failed logins of an account or of a source
+// IP. Once there are too many, logins are blocked until BlockedUntil.
+type LoginAttempts struct {
+	Scope         string
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	BlockedUntil  time.Time
+	DateCreated   time.Time
+	DateUpdated   time.Time
+}
+
+// LockoutConfig represents the policy used to throttle failed logins. A max
+// of zero disables the tracking of that scope.
+type LockoutConfig struct {
+	// MaxAccountFailures is how many failures in a row lock an account.
+	MaxAccountFailures int
+
+	// MaxIPFailures is how many failures in a row lock a source IP, whatever
+	// the accounts tried.
+	MaxIPFailures int
+
+	// Window is how long a failure is remembered. Once it passes without
+	// another failure, the count starts over.
+	Window time.Duration
+
+	// Duration is how long a lockout lasts.
+	Duration time.Duration
+
+	// BaseDelay is how long the next attempt must wait after the first
+	// failure. The delay doubles with every failure, up to MaxDelay. When
+	// MaxDelay is zero the delay stays the same.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// DefaultLockout is the lockout policy used when none is provided.
+var DefaultLockout = LockoutConfig{
+	MaxAccountFailures: 5,
+	MaxIPFailures:      50,
+	Window:             15 * time.Minute,
+	Duration:           15 * time.Minute,
+	BaseDelay:          time.Second,
+	MaxDelay:           30 * time.Second,
+}
@@ -1,11 +1,14 @@
-// Package authdb contains PasswordResetToken related CRUD functionality.
+// Package authdb contains PasswordResetToken, RefreshToken, Session, MFA,
+// LoginAttempts, APIKey and UserToken related CRUD functionality.
 package authdb
 
 import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 
 	"github.com/rmsj/service/business/domain/authbus"
@@ -73,6 +76,29 @@ func (s *Store) DeletePasswordReset(ctx context.Context, prt authbus.PasswordRes
 	return nil
 }
 
+// DeleteExpiredPasswordResets removes the PasswordResetTokens that expired
+// before the specified time.
+func (s *Store) DeleteExpiredPasswordResets(ctx context.Context, now time.Time) (int, error) {
+	data := struct {
+		Now time.Time `db:"now"`
+	}{
+		Now: now.UTC(),
+	}
+
+	const q = `
+	DELETE FROM
+		password_reset_tokens
+	WHERE
+		expiry_at < :now`
+
+	count, err := sqldb.NamedExecContextWithCount(ctx, s.log, s.db, q, data)
+	if err != nil {
+		return 0, fmt.Errorf("namedexeccontextwithcount: %w", err)
+	}
+
+	return int(count), nil
+}
+
 // QueryPasswordResetByEmail gets the specified PasswordResetToken from the database.
 func (s *Store) QueryPasswordResetByEmail(ctx context.Context, email string) (authbus.PasswordResetToken, error) {
 	data := struct {
@@ -114,3 +140,1022 @@ func (s *Store) QueryPasswordResetByToken(ctx context.Context, token string) (au
 
 	return toBusPasswordResetToken(dbKey), nil
 }
+
+// CreateRefreshToken inserts a new RefreshToken into the database.
+func (s *Store) CreateRefreshToken(ctx context.Context, token authbus.RefreshToken) error {
+	const q = `
+	INSERT INTO refresh_tokens
+		(token_id, family_id, user_id, token_hash, user_agent, expires_at, family_expires_at, last_used_at, rotated_at, revoked_at, created_at)
+	VALUES
+		(:token_id, :family_id, :user_id, :token_hash, :user_agent, :expires_at, :family_expires_at, :last_used_at, :rotated_at, :revoked_at, :created_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBRefreshToken(token)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// RotateRefreshToken marks a RefreshToken as rotated. It reports false when
+// the token was already rotated or revoked.
+func (s *Store) RotateRefreshToken(ctx context.Context, token authbus.RefreshToken) (bool, error) {
+	const q = `
+	UPDATE
+		refresh_tokens
+	SET
+		last_used_at = :last_used_at,
+		rotated_at = :rotated_at
+	WHERE
+		token_id = :token_id AND
+		rotated_at IS NULL AND
+		revoked_at IS NULL`
+
+	count, err := sqldb.NamedExecContextWithCount(ctx, s.log, s.db, q, toDBRefreshToken(token))
+	if err != nil {
+		return false, fmt.Errorf("namedexeccontextwithcount: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// RevokeRefreshTokenFamily revokes the RefreshTokens of a family that are not
+// revoked yet.
+func (s *Store) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID, now time.Time) error {
+	data := struct {
+		FamilyID string    `db:"family_id"`
+		Now      time.Time `db:"now"`
+	}{
+		FamilyID: familyID.String(),
+		Now:      now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		refresh_tokens
+	SET
+		revoked_at = :now
+	WHERE
+		family_id = :family_id AND
+		revoked_at IS NULL`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeUserRefreshTokens revokes the RefreshTokens of a user that are not
+// revoked yet.
+func (s *Store) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID, now time.Time) error {
+	data := struct {
+		UserID string    `db:"user_id"`
+		Now    time.Time `db:"now"`
+	}{
+		UserID: userID.String(),
+		Now:    now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		refresh_tokens
+	SET
+		revoked_at = :now
+	WHERE
+		user_id = :user_id AND
+		revoked_at IS NULL`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryRefreshTokenByHash gets the specified RefreshToken from the database.
+func (s *Store) QueryRefreshTokenByHash(ctx context.Context, tokenHash string) (authbus.RefreshToken, error) {
+	data := struct {
+		TokenHash string `db:"token_hash"`
+	}{
+		TokenHash: tokenHash,
+	}
+
+	const q = `
+	SELECT
+		token_id, family_id, user_id, token_hash, user_agent, expires_at, family_expires_at, last_used_at, rotated_at, revoked_at, created_at
+	FROM
+		refresh_tokens
+	WHERE
+		token_hash = :token_hash`
+
+	var dbToken refreshToken
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbToken); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return authbus.RefreshToken{}, fmt.Errorf("namedquerystruct: %w", authbus.ErrNotFound)
+		}
+		return authbus.RefreshToken{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusRefreshToken(dbToken), nil
+}
+
+// DeleteExpiredRefreshTokens removes the RefreshTokens that expired before
+// the specified time.
+func (s *Store) DeleteExpiredRefreshTokens(ctx context.Context, now time.Time) (int, error) {
+	data := struct {
+		Now time.Time `db:"now"`
+	}{
+		Now: now.UTC(),
+	}
+
+	const q = `
+	DELETE FROM
+		refresh_tokens
+	WHERE
+		expires_at < :now`
+
+	count, err := sqldb.NamedExecContextWithCount(ctx, s.log, s.db, q, data)
+	if err != nil {
+		return 0, fmt.Errorf("namedexeccontextwithcount: %w", err)
+	}
+
+	return int(count), nil
+}
+
+// CreateSession inserts a new Session into the database.
+func (s *Store) CreateSession(ctx context.Context, sess authbus.Session) error {
+	const q = `
+	INSERT INTO sessions
+		(session_id, user_id, user_agent, ip_address, expires_at, last_seen_at, revoked_at, created_at)
+	VALUES
+		(:session_id, :user_id, :user_agent, :ip_address, :expires_at, :last_seen_at, :revoked_at, :created_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBSession(sess)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateSession records the use of a Session. The IP address is kept when
+// the new one is unknown.
+func (s *Store) UpdateSession(ctx context.Context, sess authbus.Session) error {
+	const q = `
+	UPDATE
+		sessions
+	SET
+		user_agent = :user_agent,
+		ip_address = COALESCE(NULLIF(:ip_address, ''), ip_address),
+		expires_at = :expires_at,
+		last_seen_at = :last_seen_at
+	WHERE
+		session_id = :session_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBSession(sess)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeSession revokes a Session, unless it's revoked already.
+func (s *Store) RevokeSession(ctx context.Context, sessionID uuid.UUID, now time.Time) error {
+	data := struct {
+		SessionID string    `db:"session_id"`
+		Now       time.Time `db:"now"`
+	}{
+		SessionID: sessionID.String(),
+		Now:       now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		sessions
+	SET
+		revoked_at = :now
+	WHERE
+		session_id = :session_id AND
+		revoked_at IS NULL`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeUserSessions revokes the Sessions of a user that are not revoked yet.
+func (s *Store) RevokeUserSessions(ctx context.Context, userID uuid.UUID, now time.Time) error {
+	data := struct {
+		UserID string    `db:"user_id"`
+		Now    time.Time `db:"now"`
+	}{
+		UserID: userID.String(),
+		Now:    now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		sessions
+	SET
+		revoked_at = :now
+	WHERE
+		user_id = :user_id AND
+		revoked_at IS NULL`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QuerySessionByID gets the specified Session from the database.
+func (s *Store) QuerySessionByID(ctx context.Context, sessionID uuid.UUID) (authbus.Session, error) {
+	data := struct {
+		SessionID string `db:"session_id"`
+	}{
+		SessionID: sessionID.String(),
+	}
+
+	const q = `
+	SELECT
+		session_id, user_id, user_agent, ip_address, expires_at, last_seen_at, revoked_at, created_at
+	FROM
+		sessions
+	WHERE
+		session_id = :session_id`
+
+	var dbSess session
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbSess); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return authbus.Session{}, fmt.Errorf("namedquerystruct: %w", authbus.ErrNotFound)
+		}
+		return authbus.Session{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusSession(dbSess), nil
+}
+
+// QuerySessionsByUserID retrieves the Sessions of a user that were not
+// revoked and have not expired at the specified time.
+func (s *Store) QuerySessionsByUserID(ctx context.Context, userID uuid.UUID, now time.Time) ([]authbus.Session, error) {
+	data := struct {
+		UserID string    `db:"user_id"`
+		Now    time.Time `db:"now"`
+	}{
+		UserID: userID.String(),
+		Now:    now.UTC(),
+	}
+
+	const q = `
+	SELECT
+		session_id, user_id, user_agent, ip_address, expires_at, last_seen_at, revoked_at, created_at
+	FROM
+		sessions
+	WHERE
+		user_id = :user_id AND
+		revoked_at IS NULL AND
+		expires_at > :now
+	ORDER BY
+		last_seen_at DESC`
+
+	var dbSessions []session
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbSessions); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusSessions(dbSessions), nil
+}
+
+// DeleteExpiredSessions removes the Sessions that expired before the
+// specified time.
+func (s *Store) DeleteExpiredSessions(ctx context.Context, now time.Time) (int, error) {
+	data := struct {
+		Now time.Time `db:"now"`
+	}{
+		Now: now.UTC(),
+	}
+
+	const q = `
+	DELETE FROM
+		sessions
+	WHERE
+		expires_at < :now`
+
+	count, err := sqldb.NamedExecContextWithCount(ctx, s.log, s.db, q, data)
+	if err != nil {
+		return 0, fmt.Errorf("namedexeccontextwithcount: %w", err)
+	}
+
+	return int(count), nil
+}
+
+// SaveMFA inserts the MFA of a user, replacing the existing one.
+func (s *Store) SaveMFA(ctx context.Context, m authbus.MFA) error {
+	const q = `
+	INSERT INTO user_mfa
+		(user_id, secret, last_used_step, enabled_at, created_at, updated_at)
+	VALUES
+		(:user_id, :secret, :last_used_step, :enabled_at, :created_at, :updated_at)
+	ON DUPLICATE KEY UPDATE
+		secret = VALUES(secret),
+		last_used_step = VALUES(last_used_step),
+		enabled_at = VALUES(enabled_at),
+		created_at = VALUES(created_at),
+		updated_at = VALUES(updated_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBMFA(m)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// EnableMFA enables a pending MFA, as long as its secret was not replaced
+// in the meantime. It reports if the MFA was enabled.
+func (s *Store) EnableMFA(ctx context.Context, m authbus.MFA) (bool, error) {
+	const q = `
+	UPDATE
+		user_mfa
+	SET
+		last_used_step = :last_used_step,
+		enabled_at = :enabled_at,
+		updated_at = :updated_at
+	WHERE
+		user_id = :user_id AND
+		secret = :secret AND
+		enabled_at IS NULL`
+
+	count, err := sqldb.NamedExecContextWithCount(ctx, s.log, s.db, q, toDBMFA(m))
+	if err != nil {
+		return false, fmt.Errorf("namedexeccontextwithcount: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// UseMFAStep records the time step of a TOTP code that was used. It reports
+// false when the step, or a later one, was already used.
+func (s *Store) UseMFAStep(ctx context.Context, userID uuid.UUID, step int64, now time.Time) (bool, error) {
+	data := struct {
+		UserID string    `db:"user_id"`
+		Step   int64     `db:"step"`
+		Now    time.Time `db:"now"`
+	}{
+		UserID: userID.String(),
+		Step:   step,
+		Now:    now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		user_mfa
+	SET
+		last_used_step = :step,
+		updated_at = :now
+	WHERE
+		user_id = :user_id AND
+		enabled_at IS NOT NULL AND
+		last_used_step < :step`
+
+	count, err := sqldb.NamedExecContextWithCount(ctx, s.log, s.db, q, data)
+	if err != nil {
+		return false, fmt.Errorf("namedexeccontextwithcount: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// DeleteMFA removes the MFA of a user.
+func (s *Store) DeleteMFA(ctx context.Context, userID uuid.UUID) error {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID.String(),
+	}
+
+	const q = `
+	DELETE FROM
+		user_mfa
+	WHERE
+		user_id = :user_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryMFAByUserID gets the MFA of a user.
+func (s *Store) QueryMFAByUserID(ctx context.Context, userID uuid.UUID) (authbus.MFA, error) {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID.String(),
+	}
+
+	const q = `
+	SELECT
+		user_id, secret, last_used_step, enabled_at, created_at, updated_at
+	FROM
+		user_mfa
+	WHERE
+		user_id = :user_id`
+
+	var dbMFA mfa
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbMFA); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return authbus.MFA{}, fmt.Errorf("namedquerystruct: %w", authbus.ErrNotFound)
+		}
+		return authbus.MFA{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusMFA(dbMFA), nil
+}
+
+// CreateRecoveryCode inserts a new RecoveryCode into the database.
+func (s *Store) CreateRecoveryCode(ctx context.Context, code authbus.RecoveryCode) error {
+	const q = `
+	INSERT INTO mfa_recovery_codes
+		(code_id, user_id, code_hash, used_at, created_at)
+	VALUES
+		(:code_id, :user_id, :code_hash, :used_at, :created_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBRecoveryCode(code)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks an unused RecoveryCode of the user as used. It
+// reports if a code was found.
+func (s *Store) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, now time.Time) (bool, error) {
+	data := struct {
+		UserID   string    `db:"user_id"`
+		CodeHash string    `db:"code_hash"`
+		Now      time.Time `db:"now"`
+	}{
+		UserID:   userID.String(),
+		CodeHash: codeHash,
+		Now:      now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		mfa_recovery_codes
+	SET
+		used_at = :now
+	WHERE
+		user_id = :user_id AND
+		code_hash = :code_hash AND
+		used_at IS NULL`
+
+	count, err := sqldb.NamedExecContextWithCount(ctx, s.log, s.db, q, data)
+	if err != nil {
+		return false, fmt.Errorf("namedexeccontextwithcount: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// DeleteRecoveryCodes removes the RecoveryCodes of a user.
+func (s *Store) DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID.String(),
+	}
+
+	const q = `
+	DELETE FROM
+		mfa_recovery_codes
+	WHERE
+		user_id = :user_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// CountRecoveryCodes returns how many RecoveryCodes of a user are unused.
+func (s *Store) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID.String(),
+	}
+
+	const q = "SELECT COUNT(code_id) AS `count` FROM mfa_recovery_codes WHERE user_id = :user_id AND used_at IS NULL"
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// AddLoginFailure counts a failed login against the scope and key. The count
+// starts over when the last failure happened before the window start.
+func (s *Store) AddLoginFailure(ctx context.Context, la authbus.LoginAttempts, windowStart time.Time) (authbus.LoginAttempts, error) {
+	data := struct {
+		loginAttempts
+		WindowStart time.Time `db:"window_start"`
+	}{
+		loginAttempts: toDBLoginAttempts(la),
+		WindowStart:   windowStart.UTC(),
+	}
+
+	// The failures are updated before last_failure_at, since MySQL applies
+	// the assignments in order.
+	const q = `
+	INSERT INTO login_attempts
+		(scope, attempt_key, failures, last_failure_at, blocked_until, created_at, updated_at)
+	VALUES
+		(:scope, :attempt_key, 1, :last_failure_at, NULL, :created_at, :updated_at)
+	ON DUPLICATE KEY UPDATE
+		failures = IF(last_failure_at < :window_start, 1, failures + 1),
+		last_failure_at = VALUES(last_failure_at),
+		updated_at = VALUES(updated_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return authbus.LoginAttempts{}, fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return s.QueryLoginAttempts(ctx, la.Scope, la.Key)
+}
+
+// BlockLogin blocks the logins of the scope and key until the specified time.
+func (s *Store) BlockLogin(ctx context.Context, scope string, key string, until time.Time) error {
+	data := struct {
+		Scope string    `db:"scope"`
+		Key   string    `db:"attempt_key"`
+		Until time.Time `db:"until"`
+	}{
+		Scope: scope,
+		Key:   key,
+		Until: until.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		login_attempts
+	SET
+		blocked_until = :until
+	WHERE
+		scope = :scope AND
+		attempt_key = :attempt_key`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryLoginAttempts gets the failed logins of the scope and key.
+func (s *Store) QueryLoginAttempts(ctx context.Context, scope string, key string) (authbus.LoginAttempts, error) {
+	data := struct {
+		Scope string `db:"scope"`
+		Key   string `db:"attempt_key"`
+	}{
+		Scope: scope,
+		Key:   key,
+	}
+
+	const q = `
+	SELECT
+		scope, attempt_key, failures, last_failure_at, blocked_until, created_at, updated_at
+	FROM
+		login_attempts
+	WHERE
+		scope = :scope AND
+		attempt_key = :attempt_key`
+
+	var dbLA loginAttempts
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbLA); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return authbus.LoginAttempts{}, fmt.Errorf("namedquerystruct: %w", authbus.ErrNotFound)
+		}
+		return authbus.LoginAttempts{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusLoginAttempts(dbLA), nil
+}
+
+// DeleteLoginAttempts removes the failed logins of the scope and key.
+func (s *Store) DeleteLoginAttempts(ctx context.Context, scope string, key string) error {
+	data := struct {
+		Scope string `db:"scope"`
+		Key   string `db:"attempt_key"`
+	}{
+		Scope: scope,
+		Key:   key,
+	}
+
+	const q = `
+	DELETE FROM
+		login_attempts
+	WHERE
+		scope = :scope AND
+		attempt_key = :attempt_key`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredLoginAttempts removes the failed logins that last happened
+// before the specified time and no longer block anything.
+func (s *Store) DeleteExpiredLoginAttempts(ctx context.Context, before time.Time) (int, error) {
+	data := struct {
+		Before time.Time `db:"before"`
+	}{
+		Before: before.UTC(),
+	}
+
+	const q = `
+	DELETE FROM
+		login_attempts
+	WHERE
+		last_failure_at < :before AND
+		(blocked_until IS NULL OR blocked_until < :before)`
+
+	count, err := sqldb.NamedExecContextWithCount(ctx, s.log, s.db, q, data)
+	if err != nil {
+		return 0, fmt.Errorf("namedexeccontextwithcount: %w", err)
+	}
+
+	return int(count), nil
+}
+
+// CreateAPIKey inserts a new APIKey into the database.
+func (s *Store) CreateAPIKey(ctx context.Context, key authbus.APIKey) error {
+	const q = `
+	INSERT INTO api_keys
+		(apikey_id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at)
+	VALUES
+		(:apikey_id, :user_id, :name, :prefix, :key_hash, :scopes, :expires_at, :last_used_at, :revoked_at, :created_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBAPIKey(key)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// TouchAPIKey records the use of an APIKey.
+func (s *Store) TouchAPIKey(ctx context.Context, apiKeyID uuid.UUID, now time.Time) error {
+	data := struct {
+		APIKeyID string    `db:"apikey_id"`
+		Now      time.Time `db:"now"`
+	}{
+		APIKeyID: apiKeyID.String(),
+		Now:      now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		api_keys
+	SET
+		last_used_at = :now
+	WHERE
+		apikey_id = :apikey_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAPIKey revokes an APIKey, unless it's revoked already.
+func (s *Store) RevokeAPIKey(ctx context.Context, apiKeyID uuid.UUID, now time.Time) error {
+	data := struct {
+		APIKeyID string    `db:"apikey_id"`
+		Now      time.Time `db:"now"`
+	}{
+		APIKeyID: apiKeyID.String(),
+		Now:      now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		api_keys
+	SET
+		revoked_at = :now
+	WHERE
+		apikey_id = :apikey_id AND
+		revoked_at IS NULL`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryAPIKeyByID gets the specified APIKey from the database.
+func (s *Store) QueryAPIKeyByID(ctx context.Context, apiKeyID uuid.UUID) (authbus.APIKey, error) {
+	data := struct {
+		APIKeyID string `db:"apikey_id"`
+	}{
+		APIKeyID: apiKeyID.String(),
+	}
+
+	const q = `
+	SELECT
+		apikey_id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at
+	FROM
+		api_keys
+	WHERE
+		apikey_id = :apikey_id`
+
+	var dbKey apiKey
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbKey); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return authbus.APIKey{}, fmt.Errorf("namedquerystruct: %w", authbus.ErrNotFound)
+		}
+		return authbus.APIKey{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusAPIKey(dbKey), nil
+}
+
+// QueryAPIKeyByPrefix gets the APIKey with the specified prefix from the
+// database.
+func (s *Store) QueryAPIKeyByPrefix(ctx context.Context, prefix string) (authbus.APIKey, error) {
+	data := struct {
+		Prefix string `db:"prefix"`
+	}{
+		Prefix: prefix,
+	}
+
+	const q = `
+	SELECT
+		apikey_id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at
+	FROM
+		api_keys
+	WHERE
+		prefix = :prefix`
+
+	var dbKey apiKey
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbKey); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return authbus.APIKey{}, fmt.Errorf("namedquerystruct: %w", authbus.ErrNotFound)
+		}
+		return authbus.APIKey{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusAPIKey(dbKey), nil
+}
+
+// QueryAPIKeysByUserID retrieves the APIKeys of a user that were not
+// revoked.
+func (s *Store) QueryAPIKeysByUserID(ctx context.Context, userID uuid.UUID) ([]authbus.APIKey, error) {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID.String(),
+	}
+
+	const q = `
+	SELECT
+		apikey_id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at
+	FROM
+		api_keys
+	WHERE
+		user_id = :user_id AND
+		revoked_at IS NULL
+	ORDER BY
+		created_at DESC`
+
+	var dbKeys []apiKey
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbKeys); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusAPIKeys(dbKeys), nil
+}
+
+// CreateUserToken inserts a new UserToken into the database.
+func (s *Store) CreateUserToken(ctx context.Context, ut authbus.UserToken) error {
+	const q = `
+	INSERT INTO user_tokens
+		(token_id, purpose, token_hash, email, user_id, roles, created_by, expires_at, used_at, revoked_at, created_at)
+	VALUES
+		(:token_id, :purpose, :token_hash, :email, :user_id, :roles, :created_by, :expires_at, :used_at, :revoked_at, :created_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBUserToken(ut)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// UseUserToken marks a UserToken as used, as long as it wasn't used or
+// revoked already. It reports if the token was marked.
+func (s *Store) UseUserToken(ctx context.Context, tokenID uuid.UUID, now time.Time) (bool, error) {
+	data := struct {
+		TokenID string    `db:"token_id"`
+		Now     time.Time `db:"now"`
+	}{
+		TokenID: tokenID.String(),
+		Now:     now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		user_tokens
+	SET
+		used_at = :now
+	WHERE
+		token_id = :token_id AND
+		used_at IS NULL AND
+		revoked_at IS NULL`
+
+	count, err := sqldb.NamedExecContextWithCount(ctx, s.log, s.db, q, data)
+	if err != nil {
+		return false, fmt.Errorf("namedexeccontextwithcount: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// RevokeUserToken revokes a UserToken, unless it was used or revoked
+// already.
+func (s *Store) RevokeUserToken(ctx context.Context, tokenID uuid.UUID, now time.Time) error {
+	data := struct {
+		TokenID string    `db:"token_id"`
+		Now     time.Time `db:"now"`
+	}{
+		TokenID: tokenID.String(),
+		Now:     now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		user_tokens
+	SET
+		revoked_at = :now
+	WHERE
+		token_id = :token_id AND
+		used_at IS NULL AND
+		revoked_at IS NULL`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// RevokePendingUserTokens revokes the UserTokens issued for the purpose and
+// email that were not used or revoked yet.
+func (s *Store) RevokePendingUserTokens(ctx context.Context, purpose string, email string, now time.Time) error {
+	data := struct {
+		Purpose string    `db:"purpose"`
+		Email   string    `db:"email"`
+		Now     time.Time `db:"now"`
+	}{
+		Purpose: purpose,
+		Email:   email,
+		Now:     now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		user_tokens
+	SET
+		revoked_at = :now
+	WHERE
+		purpose = :purpose AND
+		email = :email AND
+		used_at IS NULL AND
+		revoked_at IS NULL`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryUserTokenByID gets the specified UserToken from the database.
+func (s *Store) QueryUserTokenByID(ctx context.Context, tokenID uuid.UUID) (authbus.UserToken, error) {
+	data := struct {
+		TokenID string `db:"token_id"`
+	}{
+		TokenID: tokenID.String(),
+	}
+
+	const q = `
+	SELECT
+		token_id, purpose, token_hash, email, user_id, roles, created_by, expires_at, used_at, revoked_at, created_at
+	FROM
+		user_tokens
+	WHERE
+		token_id = :token_id`
+
+	var dbToken userToken
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbToken); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return authbus.UserToken{}, fmt.Errorf("namedquerystruct: %w", authbus.ErrNotFound)
+		}
+		return authbus.UserToken{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusUserToken(dbToken)
+}
+
+// QueryUserTokenByHash gets the UserToken with the specified hash from the
+// database.
+func (s *Store) QueryUserTokenByHash(ctx context.Context, tokenHash string) (authbus.UserToken, error) {
+	data := struct {
+		TokenHash string `db:"token_hash"`
+	}{
+		TokenHash: tokenHash,
+	}
+
+	const q = `
+	SELECT
+		token_id, purpose, token_hash, email, user_id, roles, created_by, expires_at, used_at, revoked_at, created_at
+	FROM
+		user_tokens
+	WHERE
+		token_hash = :token_hash`
+
+	var dbToken userToken
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbToken); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return authbus.UserToken{}, fmt.Errorf("namedquerystruct: %w", authbus.ErrNotFound)
+		}
+		return authbus.UserToken{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusUserToken(dbToken)
+}
+
+// QueryPendingUserTokens retrieves the UserTokens issued for the purpose that
+// were not used, revoked or have expired.
+func (s *Store) QueryPendingUserTokens(ctx context.Context, purpose string, now time.Time) ([]authbus.UserToken, error) {
+	data := struct {
+		Purpose string    `db:"purpose"`
+		Now     time.Time `db:"now"`
+	}{
+		Purpose: purpose,
+		Now:     now.UTC(),
+	}
+
+	const q = `
+	SELECT
+		token_id, purpose, token_hash, email, user_id, roles, created_by, expires_at, used_at, revoked_at, created_at
+	FROM
+		user_tokens
+	WHERE
+		purpose = :purpose AND
+		used_at IS NULL AND
+		revoked_at IS NULL AND
+		expires_at > :now
+	ORDER BY
+		created_at DESC`
+
+	var dbTokens []userToken
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbTokens); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusUserTokens(dbTokens)
+}
+
+// DeleteExpiredUserTokens removes the UserTokens that expired before the
+// specified time.
+func (s *Store) DeleteExpiredUserTokens(ctx context.Context, before time.Time) (int, error) {
+	data := struct {
+		Before time.Time `db:"before"`
+	}{
+		Before: before.UTC(),
+	}
+
+	const q = `
+	DELETE FROM
+		user_tokens
+	WHERE
+		expires_at < :before`
+
+	count, err := sqldb.NamedExecContextWithCount(ctx, s.log, s.db, q, data)
+	if err != nil {
+		return 0, fmt.Errorf("namedexeccontextwithcount: %w", err)
+	}
+
+	return int(count), nil
+}
@@ -1,9 +1,16 @@
 package authdb
 
 import (
+	"database/sql"
+	"fmt"
+	"net/mail"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/rmsj/service/business/domain/authbus"
+	"github.com/rmsj/service/business/types/role"
 )
 
 type PasswordResetToken struct {
@@ -28,3 +35,363 @@ func toBusPasswordResetToken(db PasswordResetToken) authbus.PasswordResetToken {
 		ExpiryAt: db.ExpiryAt,
 	}
 }
+
+// =============================================================================
+
+type refreshToken struct {
+	ID              uuid.UUID    `db:"token_id"`
+	FamilyID        uuid.UUID    `db:"family_id"`
+	UserID          uuid.UUID    `db:"user_id"`
+	TokenHash       string       `db:"token_hash"`
+	UserAgent       string       `db:"user_agent"`
+	ExpiresAt       time.Time    `db:"expires_at"`
+	FamilyExpiresAt time.Time    `db:"family_expires_at"`
+	LastUsedAt      sql.NullTime `db:"last_used_at"`
+	RotatedAt       sql.NullTime `db:"rotated_at"`
+	RevokedAt       sql.NullTime `db:"revoked_at"`
+	DateCreated     time.Time    `db:"created_at"`
+}
+
+func toDBRefreshToken(bus authbus.RefreshToken) refreshToken {
+	return refreshToken{
+		ID:              bus.ID,
+		FamilyID:        bus.FamilyID,
+		UserID:          bus.UserID,
+		TokenHash:       bus.TokenHash,
+		UserAgent:       truncate(bus.UserAgent, 255),
+		ExpiresAt:       bus.ExpiresAt.UTC(),
+		FamilyExpiresAt: bus.FamilyExpiresAt.UTC(),
+		LastUsedAt:      toDBNullTime(bus.LastUsedAt),
+		RotatedAt:       toDBNullTime(bus.RotatedAt),
+		RevokedAt:       toDBNullTime(bus.RevokedAt),
+		DateCreated:     bus.DateCreated.UTC(),
+	}
+}
+
+func toBusRefreshToken(db refreshToken) authbus.RefreshToken {
+	return authbus.RefreshToken{
+		ID:              db.ID,
+		FamilyID:        db.FamilyID,
+		UserID:          db.UserID,
+		TokenHash:       db.TokenHash,
+		UserAgent:       db.UserAgent,
+		ExpiresAt:       db.ExpiresAt.In(time.Local),
+		FamilyExpiresAt: db.FamilyExpiresAt.In(time.Local),
+		LastUsedAt:      toBusTime(db.LastUsedAt),
+		RotatedAt:       toBusTime(db.RotatedAt),
+		RevokedAt:       toBusTime(db.RevokedAt),
+		DateCreated:     db.DateCreated.In(time.Local),
+	}
+}
+
+// =============================================================================
+
+type session struct {
+	ID          uuid.UUID    `db:"session_id"`
+	UserID      uuid.UUID    `db:"user_id"`
+	UserAgent   string       `db:"user_agent"`
+	IPAddress   string       `db:"ip_address"`
+	ExpiresAt   time.Time    `db:"expires_at"`
+	LastSeenAt  time.Time    `db:"last_seen_at"`
+	RevokedAt   sql.NullTime `db:"revoked_at"`
+	DateCreated time.Time    `db:"created_at"`
+}
+
+func toDBSession(bus authbus.Session) session {
+	return session{
+		ID:          bus.ID,
+		UserID:      bus.UserID,
+		UserAgent:   truncate(bus.UserAgent, 255),
+		IPAddress:   truncate(bus.IPAddress, 45),
+		ExpiresAt:   bus.ExpiresAt.UTC(),
+		LastSeenAt:  bus.LastSeenAt.UTC(),
+		RevokedAt:   toDBNullTime(bus.RevokedAt),
+		DateCreated: bus.DateCreated.UTC(),
+	}
+}
+
+func toBusSession(db session) authbus.Session {
+	return authbus.Session{
+		ID:          db.ID,
+		UserID:      db.UserID,
+		UserAgent:   db.UserAgent,
+		IPAddress:   db.IPAddress,
+		ExpiresAt:   db.ExpiresAt.In(time.Local),
+		LastSeenAt:  db.LastSeenAt.In(time.Local),
+		RevokedAt:   toBusTime(db.RevokedAt),
+		DateCreated: db.DateCreated.In(time.Local),
+	}
+}
+
+func toBusSessions(dbs []session) []authbus.Session {
+	bus := make([]authbus.Session, len(dbs))
+
+	for i, db := range dbs {
+		bus[i] = toBusSession(db)
+	}
+
+	return bus
+}
+
+// =============================================================================
+
+// truncate cuts informational values, like the user agent, to fit their
+// column.
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+
+	return s
+}
+
+func toDBNullTime(t time.Time) sql.NullTime {
+	if t.IsZero() {
+		return sql.NullTime{}
+	}
+
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+func toBusTime(t sql.NullTime) time.Time {
+	if !t.Valid {
+		return time.Time{}
+	}
+
+	return t.Time.In(time.Local)
+}
+
+// =============================================================================
+
+type mfa struct {
+	UserID       uuid.UUID    `db:"user_id"`
+	Secret       string       `db:"secret"`
+	LastUsedStep int64        `db:"last_used_step"`
+	EnabledAt    sql.NullTime `db:"enabled_at"`
+	DateCreated  time.Time    `db:"created_at"`
+	DateUpdated  time.Time    `db:"updated_at"`
+}
+
+func toDBMFA(bus authbus.MFA) mfa {
+	return mfa{
+		UserID:       bus.UserID,
+		Secret:       bus.Secret,
+		LastUsedStep: bus.LastUsedStep,
+		EnabledAt:    toDBNullTime(bus.EnabledAt),
+		DateCreated:  bus.DateCreated.UTC(),
+		DateUpdated:  bus.DateUpdated.UTC(),
+	}
+}
+
+func toBusMFA(db mfa) authbus.MFA {
+	return authbus.MFA{
+		UserID:       db.UserID,
+		Secret:       db.Secret,
+		LastUsedStep: db.LastUsedStep,
+		EnabledAt:    toBusTime(db.EnabledAt),
+		DateCreated:  db.DateCreated.In(time.Local),
+		DateUpdated:  db.DateUpdated.In(time.Local),
+	}
+}
+
+type recoveryCode struct {
+	ID          uuid.UUID    `db:"code_id"`
+	UserID      uuid.UUID    `db:"user_id"`
+	CodeHash    string       `db:"code_hash"`
+	UsedAt      sql.NullTime `db:"used_at"`
+	DateCreated time.Time    `db:"created_at"`
+}
+
+func toDBRecoveryCode(bus authbus.RecoveryCode) recoveryCode {
+	return recoveryCode{
+		ID:          bus.ID,
+		UserID:      bus.UserID,
+		CodeHash:    bus.CodeHash,
+		UsedAt:      toDBNullTime(bus.UsedAt),
+		DateCreated: bus.DateCreated.UTC(),
+	}
+}
+
+type loginAttempts struct {
+	Scope         string       `db:"scope"`
+	Key           string       `db:"attempt_key"`
+	Failures      int          `db:"failures"`
+	LastFailureAt time.Time    `db:"last_failure_at"`
+	BlockedUntil  sql.NullTime `db:"blocked_until"`
+	DateCreated   time.Time    `db:"created_at"`
+	DateUpdated   time.Time    `db:"updated_at"`
+}
+
+func toDBLoginAttempts(bus authbus.LoginAttempts) loginAttempts {
+	return loginAttempts{
+		Scope:         bus.Scope,
+		Key:           bus.Key,
+		Failures:      bus.Failures,
+		LastFailureAt: bus.LastFailureAt.UTC(),
+		BlockedUntil:  toDBNullTime(bus.BlockedUntil),
+		DateCreated:   bus.DateCreated.UTC(),
+		DateUpdated:   bus.DateUpdated.UTC(),
+	}
+}
+
+func toBusLoginAttempts(db loginAttempts) authbus.LoginAttempts {
+	return authbus.LoginAttempts{
+		Scope:         db.Scope,
+		Key:           db.Key,
+		Failures:      db.Failures,
+		LastFailureAt: db.LastFailureAt.In(time.Local),
+		BlockedUntil:  toBusTime(db.BlockedUntil),
+		DateCreated:   db.DateCreated.In(time.Local),
+		DateUpdated:   db.DateUpdated.In(time.Local),
+	}
+}
+
+// =============================================================================
+
+type apiKey struct {
+	ID          uuid.UUID    `db:"apikey_id"`
+	UserID      uuid.UUID    `db:"user_id"`
+	Name        string       `db:"name"`
+	Prefix      string       `db:"prefix"`
+	KeyHash     string       `db:"key_hash"`
+	Scopes      string       `db:"scopes"`
+	ExpiresAt   sql.NullTime `db:"expires_at"`
+	LastUsedAt  sql.NullTime `db:"last_used_at"`
+	RevokedAt   sql.NullTime `db:"revoked_at"`
+	DateCreated time.Time    `db:"created_at"`
+}
+
+func toDBAPIKey(bus authbus.APIKey) apiKey {
+	return apiKey{
+		ID:          bus.ID,
+		UserID:      bus.UserID,
+		Name:        bus.Name,
+		Prefix:      bus.Prefix,
+		KeyHash:     bus.KeyHash,
+		Scopes:      strings.Join(bus.Scopes, ","),
+		ExpiresAt:   toDBNullTime(bus.ExpiresAt),
+		LastUsedAt:  toDBNullTime(bus.LastUsedAt),
+		RevokedAt:   toDBNullTime(bus.RevokedAt),
+		DateCreated: bus.DateCreated.UTC(),
+	}
+}
+
+func toBusAPIKey(db apiKey) authbus.APIKey {
+	var scopes []string
+	if db.Scopes != "" {
+		scopes = strings.Split(db.Scopes, ",")
+	}
+
+	return authbus.APIKey{
+		ID:          db.ID,
+		UserID:      db.UserID,
+		Name:        db.Name,
+		Prefix:      db.Prefix,
+		KeyHash:     db.KeyHash,
+		Scopes:      scopes,
+		ExpiresAt:   toBusTime(db.ExpiresAt),
+		LastUsedAt:  toBusTime(db.LastUsedAt),
+		RevokedAt:   toBusTime(db.RevokedAt),
+		DateCreated: db.DateCreated.In(time.Local),
+	}
+}
+
+func toBusAPIKeys(dbs []apiKey) []authbus.APIKey {
+	bus := make([]authbus.APIKey, len(dbs))
+
+	for i, db := range dbs {
+		bus[i] = toBusAPIKey(db)
+	}
+
+	return bus
+}
+
+// =============================================================================
+
+type userToken struct {
+	ID          uuid.UUID      `db:"token_id"`
+	Purpose     string         `db:"purpose"`
+	TokenHash   string         `db:"token_hash"`
+	Email       string         `db:"email"`
+	UserID      sql.NullString `db:"user_id"`
+	Roles       string         `db:"roles"`
+	CreatedBy   sql.NullString `db:"created_by"`
+	ExpiresAt   time.Time      `db:"expires_at"`
+	UsedAt      sql.NullTime   `db:"used_at"`
+	RevokedAt   sql.NullTime   `db:"revoked_at"`
+	DateCreated time.Time      `db:"created_at"`
+}
+
+func toDBUserToken(bus authbus.UserToken) userToken {
+	return userToken{
+		ID:          bus.ID,
+		Purpose:     bus.Purpose,
+		TokenHash:   bus.TokenHash,
+		Email:       bus.Email.Address,
+		UserID:      toDBNullUUID(bus.UserID),
+		Roles:       strings.Join(role.ParseToString(bus.Roles), ","),
+		CreatedBy:   toDBNullUUID(bus.CreatedBy),
+		ExpiresAt:   bus.ExpiresAt.UTC(),
+		UsedAt:      toDBNullTime(bus.UsedAt),
+		RevokedAt:   toDBNullTime(bus.RevokedAt),
+		DateCreated: bus.DateCreated.UTC(),
+	}
+}
+
+func toBusUserToken(db userToken) (authbus.UserToken, error) {
+	var roles []role.Role
+	if db.Roles != "" {
+		var err error
+		roles, err = role.ParseMany(strings.Split(db.Roles, ","))
+		if err != nil {
+			return authbus.UserToken{}, fmt.Errorf("parse roles: %w", err)
+		}
+	}
+
+	bus := authbus.UserToken{
+		ID:          db.ID,
+		Purpose:     db.Purpose,
+		TokenHash:   db.TokenHash,
+		Email:       mail.Address{Address: db.Email},
+		UserID:      toBusUUID(db.UserID),
+		Roles:       roles,
+		CreatedBy:   toBusUUID(db.CreatedBy),
+		ExpiresAt:   db.ExpiresAt.In(time.Local),
+		UsedAt:      toBusTime(db.UsedAt),
+		RevokedAt:   toBusTime(db.RevokedAt),
+		DateCreated: db.DateCreated.In(time.Local),
+	}
+
+	return bus, nil
+}
+
+func toBusUserTokens(dbs []userToken) ([]authbus.UserToken, error) {
+	bus := make([]authbus.UserToken, len(dbs))
+
+	for i, db := range dbs {
+		var err error
+		bus[i], err = toBusUserToken(db)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bus, nil
+}
+
+func toDBNullUUID(id uuid.UUID) sql.NullString {
+	if id == uuid.Nil {
+		return sql.NullString{}
+	}
+
+	return sql.NullString{String: id.String(), Valid: true}
+}
+
+func toBusUUID(id sql.NullString) uuid.UUID {
+	if !id.Valid {
+		return uuid.Nil
+	}
+
+	parsed, _ := uuid.Parse(id.String)
+	return parsed
+}
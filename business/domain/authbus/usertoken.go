@@ -0,0 +1,193 @@
+package authbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/sdk/ctxval"
+	"github.com/rmsj/service/business/sdk/id"
+	"github.com/rmsj/service/foundation/otel"
+)
+
+// Set of error variables for user tokens.
+var (
+	ErrUserTokenInvalid = errors.New("token invalid")
+	ErrUserTokenExpired = errors.New("token expired")
+)
+
+// CreateInvitation invites someone to create an account with the roles
+// provided. The pending invitations for the same email are revoked, so only
+// the last one sent works. The token is returned along with the invitation,
+// as only its hash is stored.
+func (b *Business) CreateInvitation(ctx context.Context, ni NewInvitation) (UserToken, string, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.createinvitation")
+	defer span.End()
+
+	ut := UserToken{
+		Purpose:   PurposeInvitation,
+		Email:     ni.Email,
+		Roles:     ni.Roles,
+		CreatedBy: ni.InvitedBy,
+	}
+
+	ut, token, err := b.issueUserToken(ctx, ut, ni.TTL)
+	if err != nil {
+		b.log.Error(ctx, "business.authbus.createinvitation", "error", err)
+		return UserToken{}, "", fmt.Errorf("createInvitation: email[%s]: %w", ni.Email.Address, err)
+	}
+
+	return ut, token, nil
+}
+
+// CreateEmailVerification asks the user to verify their email. The pending
+// verifications for the same email are revoked, so only the last one sent
+// works. The token is returned along with the verification, as only its
+// hash is stored.
+func (b *Business) CreateEmailVerification(ctx context.Context, nv NewEmailVerification) (UserToken, string, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.createemailverification")
+	defer span.End()
+
+	ut := UserToken{
+		Purpose:   PurposeEmailVerification,
+		Email:     nv.Email,
+		UserID:    nv.UserID,
+		CreatedBy: nv.UserID,
+	}
+
+	ut, token, err := b.issueUserToken(ctx, ut, nv.TTL)
+	if err != nil {
+		b.log.Error(ctx, "business.authbus.createemailverification", "error", err)
+		return UserToken{}, "", fmt.Errorf("createEmailVerification: userID[%s]: %w", nv.UserID, err)
+	}
+
+	return ut, token, nil
+}
+
+// UseUserToken claims a token issued for the purpose specified, so it can't
+// be used again. Unknown, used and revoked tokens are all reported as
+// ErrUserTokenInvalid, expired ones as ErrUserTokenExpired. When two requests
+// race for the same token only one of them gets it.
+func (b *Business) UseUserToken(ctx context.Context, purpose string, token string) (UserToken, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.useusertoken")
+	defer span.End()
+
+	ut, err := b.storer.QueryUserTokenByHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return UserToken{}, ErrUserTokenInvalid
+		}
+		return UserToken{}, fmt.Errorf("query: %w", err)
+	}
+
+	now := ctxval.GetTime(ctx)
+
+	switch {
+	case ut.Purpose != purpose || !ut.UsedAt.IsZero() || !ut.RevokedAt.IsZero():
+		return UserToken{}, fmt.Errorf("use: tokenID[%s]: %w", ut.ID, ErrUserTokenInvalid)
+
+	case !ut.ExpiresAt.After(now):
+		return UserToken{}, fmt.Errorf("use: tokenID[%s]: %w", ut.ID, ErrUserTokenExpired)
+	}
+
+	used, err := b.storer.UseUserToken(ctx, ut.ID, now)
+	if err != nil {
+		b.log.Error(ctx, "business.authbus.useusertoken", "error", err)
+		return UserToken{}, fmt.Errorf("use: tokenID[%s]: %w", ut.ID, err)
+	}
+
+	if !used {
+		return UserToken{}, fmt.Errorf("use: tokenID[%s]: %w", ut.ID, ErrUserTokenInvalid)
+	}
+
+	ut.UsedAt = now
+
+	return ut, nil
+}
+
+// QueryUserTokenByID finds the user token by the specified ID.
+func (b *Business) QueryUserTokenByID(ctx context.Context, tokenID uuid.UUID) (UserToken, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.queryusertokenbyid")
+	defer span.End()
+
+	ut, err := b.storer.QueryUserTokenByID(ctx, tokenID)
+	if err != nil {
+		return UserToken{}, fmt.Errorf("query: tokenID[%s]: %w", tokenID, err)
+	}
+
+	return ut, nil
+}
+
+// QueryPendingInvitations retrieves the invitations that were not accepted,
+// revoked or have expired, the most recently sent first.
+func (b *Business) QueryPendingInvitations(ctx context.Context) ([]UserToken, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.querypendinginvitations")
+	defer span.End()
+
+	uts, err := b.storer.QueryPendingUserTokens(ctx, PurposeInvitation, ctxval.GetTime(ctx))
+	if err != nil {
+		b.log.Error(ctx, "business.authbus.querypendinginvitations", "error", err)
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return uts, nil
+}
+
+// RevokeUserToken revokes the user token, it can no longer be used.
+func (b *Business) RevokeUserToken(ctx context.Context, ut UserToken) error {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.revokeusertoken")
+	defer span.End()
+
+	if err := b.storer.RevokeUserToken(ctx, ut.ID, ctxval.GetTime(ctx)); err != nil {
+		b.log.Error(ctx, "business.authbus.revokeusertoken", "error", err)
+		return fmt.Errorf("revokeUserToken: tokenID[%s]: %w", ut.ID, err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredUserTokens removes the user tokens that expired before the
+// time specified and returns how many were removed.
+func (b *Business) DeleteExpiredUserTokens(ctx context.Context, before time.Time) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.authbus.deleteexpiredusertokens")
+	defer span.End()
+
+	count, err := b.storer.DeleteExpiredUserTokens(ctx, before)
+	if err != nil {
+		b.log.Error(ctx, "business.authbus.deleteexpiredusertokens", "error", err)
+		return 0, fmt.Errorf("deleteExpiredUserTokens: %w", err)
+	}
+
+	return count, nil
+}
+
+// =============================================================================
+
+// issueUserToken generates the token, revokes the pending ones for the same
+// purpose and email, and stores the new one.
+func (b *Business) issueUserToken(ctx context.Context, ut UserToken, ttl time.Duration) (UserToken, string, error) {
+	token, err := id.NewRandomString(32)
+	if err != nil {
+		return UserToken{}, "", fmt.Errorf("generate: %w", err)
+	}
+
+	now := ctxval.GetTime(ctx)
+
+	ut.ID = uuid.New()
+	ut.TokenHash = hashToken(token)
+	ut.ExpiresAt = now.Add(ttl)
+	ut.DateCreated = now
+
+	if err := b.storer.RevokePendingUserTokens(ctx, ut.Purpose, ut.Email.Address, now); err != nil {
+		return UserToken{}, "", fmt.Errorf("revoke pending: %w", err)
+	}
+
+	if err := b.storer.CreateUserToken(ctx, ut); err != nil {
+		return UserToken{}, "", fmt.Errorf("create: %w", err)
+	}
+
+	return ut, token, nil
+}
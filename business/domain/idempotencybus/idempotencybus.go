@@ -0,0 +1,182 @@
+// Package idempotencybus provides business access to the idempotency keys
+// clients send to retry requests safely.
+package idempotencybus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/sdk/ctxval"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/foundation/logger"
+	"github.com/rmsj/service/foundation/otel"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound   = errors.New("idempotency key not found")
+	ErrMismatch   = errors.New("idempotency key used with a different request")
+	ErrInProgress = errors.New("request with the idempotency key in progress")
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	NewWithTx(tx sqldb.CommitRollbacker) (Storer, error)
+	Acquire(ctx context.Context, rec Record, now time.Time) (bool, error)
+	Complete(ctx context.Context, rec Record) error
+	Release(ctx context.Context, rec Record) error
+	QueryByKey(ctx context.Context, userID uuid.UUID, key string) (Record, error)
+	DeleteExpired(ctx context.Context, before time.Time) (int, error)
+}
+
+// Business manages the set of APIs for idempotency key access.
+type Business struct {
+	log    *logger.Logger
+	storer Storer
+}
+
+// NewBusiness constructs an idempotency business API for use.
+func NewBusiness(log *logger.Logger, storer Storer) *Business {
+	return &Business{
+		log:    log,
+		storer: storer,
+	}
+}
+
+// NewWithTx constructs a new business value that will use the
+// specified transaction in any store related calls. Responses are stored in
+// the transaction of the request they belong to, so they are never kept for
+// a change that was rolled back.
+func (b *Business) NewWithTx(tx sqldb.CommitRollbacker) (*Business, error) {
+	storer, err := b.storer.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	bus := Business{
+		log:    b.log,
+		storer: storer,
+	}
+
+	return &bus, nil
+}
+
+// Acquire starts a request with an idempotency key, holding the key for the
+// lease. The key is taken over when the record for it expired. When the key
+// is in use, the record is returned if the request completed, so its response
+// can be replayed, and an error if the request doesn't match or is still in
+// progress.
+func (b *Business) Acquire(ctx context.Context, nr NewRecord) (Record, error) {
+	ctx, span := otel.AddSpan(ctx, "business.idempotencybus.acquire")
+	defer span.End()
+
+	now := ctxval.GetTime(ctx)
+
+	rec := Record{
+		UserID:      nr.UserID,
+		Key:         nr.Key,
+		RequestHash: nr.RequestHash,
+		DateCreated: now,
+		ExpiresAt:   now.Add(nr.Lease),
+	}
+
+	acquired, err := b.storer.Acquire(ctx, rec, now)
+	if err != nil {
+		b.log.Error(ctx, "business.idempotencybus.acquire", "error", err)
+		return Record{}, fmt.Errorf("acquire: %w", err)
+	}
+
+	if acquired {
+		return rec, nil
+	}
+
+	cur, err := b.storer.QueryByKey(ctx, nr.UserID, nr.Key)
+	if err != nil {
+		// The record expired and was removed after it was acquired by
+		// another request, which is still in progress.
+		if errors.Is(err, ErrNotFound) {
+			return Record{}, ErrInProgress
+		}
+		b.log.Error(ctx, "business.idempotencybus.acquire", "error", err)
+		return Record{}, fmt.Errorf("query: userID[%s] key[%s]: %w", nr.UserID, nr.Key, err)
+	}
+
+	switch {
+	case cur.RequestHash != nr.RequestHash:
+		return Record{}, ErrMismatch
+
+	case !cur.Completed():
+		return Record{}, ErrInProgress
+	}
+
+	return cur, nil
+}
+
+// Complete stores the response of the request, replayed for the retries made
+// with the idempotency key until the TTL expires.
+func (b *Business) Complete(ctx context.Context, rec Record, cr CompleteRecord) (Record, error) {
+	ctx, span := otel.AddSpan(ctx, "business.idempotencybus.complete")
+	defer span.End()
+
+	now := ctxval.GetTime(ctx)
+
+	rec.Status = cr.Status
+	rec.ContentType = cr.ContentType
+	rec.Response = cr.Response
+	rec.ExpiresAt = now.Add(cr.TTL)
+	rec.DateCompleted = now
+
+	if err := b.storer.Complete(ctx, rec); err != nil {
+		b.log.Error(ctx, "business.idempotencybus.complete", "error", err)
+		return Record{}, fmt.Errorf("complete: %w", err)
+	}
+
+	return rec, nil
+}
+
+// Release frees an idempotency key whose request didn't complete, so it can
+// be retried.
+func (b *Business) Release(ctx context.Context, rec Record) error {
+	ctx, span := otel.AddSpan(ctx, "business.idempotencybus.release")
+	defer span.End()
+
+	if err := b.storer.Release(ctx, rec); err != nil {
+		b.log.Error(ctx, "business.idempotencybus.release", "error", err)
+		return fmt.Errorf("release: %w", err)
+	}
+
+	return nil
+}
+
+// QueryByKey finds the record of the idempotency key sent by the user.
+func (b *Business) QueryByKey(ctx context.Context, userID uuid.UUID, key string) (Record, error) {
+	ctx, span := otel.AddSpan(ctx, "business.idempotencybus.querybykey")
+	defer span.End()
+
+	rec, err := b.storer.QueryByKey(ctx, userID, key)
+	if err != nil {
+		return Record{}, fmt.Errorf("query: userID[%s] key[%s]: %w", userID, key, err)
+	}
+
+	return rec, nil
+}
+
+// DeleteExpired removes the records that expired before the time specified
+// and returns how many were removed.
+func (b *Business) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.idempotencybus.deleteexpired")
+	defer span.End()
+
+	count, err := b.storer.DeleteExpired(ctx, before)
+	if err != nil {
+		b.log.Error(ctx, "business.idempotencybus.deleteexpired", "error", err)
+		return 0, fmt.Errorf("deleteExpired: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,236 @@
+package idempotencybus_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/domain/idempotencybus"
+	"github.com/rmsj/service/business/sdk/dbtest"
+	"github.com/rmsj/service/business/sdk/unitest"
+)
+
+func Test_Idempotency(t *testing.T) {
+	t.Parallel()
+
+	db := dbtest.New(t, "Test_Idempotency")
+
+	// -------------------------------------------------------------------------
+
+	unitest.Run(t, acquire(db.BusDomain), "acquire")
+	unitest.Run(t, release(db.BusDomain), "release")
+	unitest.Run(t, expired(db.BusDomain), "expired")
+}
+
+// =============================================================================
+
+func acquire(busDomain dbtest.BusDomain) []unitest.Table {
+	userID := uuid.New()
+
+	nr := idempotencybus.NewRecord{
+		UserID:      userID,
+		Key:         "acquire",
+		RequestHash: "hash",
+		Lease:       time.Minute,
+	}
+
+	table := []unitest.Table{
+		{
+			Name:    "new",
+			ExpResp: false,
+			ExcFunc: func(ctx context.Context) any {
+				rec, err := busDomain.Idempotency.Acquire(ctx, nr)
+				if err != nil {
+					return err
+				}
+
+				return rec.Completed()
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:    "in-progress",
+			ExpResp: idempotencybus.ErrInProgress,
+			ExcFunc: func(ctx context.Context) any {
+				_, err := busDomain.Idempotency.Acquire(ctx, nr)
+				return err
+			},
+			CmpFunc: cmpError,
+		},
+		{
+			Name:    "mismatch",
+			ExpResp: idempotencybus.ErrMismatch,
+			ExcFunc: func(ctx context.Context) any {
+				other := nr
+				other.RequestHash = "other"
+
+				_, err := busDomain.Idempotency.Acquire(ctx, other)
+				return err
+			},
+			CmpFunc: cmpError,
+		},
+		{
+			Name: "replay",
+			ExpResp: idempotencybus.Record{
+				UserID:      userID,
+				Key:         "acquire",
+				RequestHash: "hash",
+				Status:      201,
+				ContentType: "application/json",
+				Response:    []byte(`{"id":"1"}`),
+			},
+			ExcFunc: func(ctx context.Context) any {
+				rec, err := busDomain.Idempotency.QueryByKey(ctx, userID, "acquire")
+				if err != nil {
+					return err
+				}
+
+				cr := idempotencybus.CompleteRecord{
+					Status:      201,
+					ContentType: "application/json",
+					Response:    []byte(`{"id":"1"}`),
+					TTL:         time.Hour,
+				}
+
+				if _, err := busDomain.Idempotency.Complete(ctx, rec, cr); err != nil {
+					return err
+				}
+
+				rec, err = busDomain.Idempotency.Acquire(ctx, nr)
+				if err != nil {
+					return err
+				}
+
+				return rec
+			},
+			CmpFunc: func(got any, exp any) string {
+				gotResp, exists := got.(idempotencybus.Record)
+				if !exists {
+					return "error occurred"
+				}
+
+				if !gotResp.Completed() {
+					return "should be completed"
+				}
+
+				expResp := exp.(idempotencybus.Record)
+				expResp.DateCreated = gotResp.DateCreated
+				expResp.ExpiresAt = gotResp.ExpiresAt
+				expResp.DateCompleted = gotResp.DateCompleted
+
+				return cmp.Diff(gotResp, expResp)
+			},
+		},
+	}
+
+	return table
+}
+
+func release(busDomain dbtest.BusDomain) []unitest.Table {
+	nr := idempotencybus.NewRecord{
+		UserID:      uuid.New(),
+		Key:         "release",
+		RequestHash: "hash",
+		Lease:       time.Minute,
+	}
+
+	table := []unitest.Table{
+		{
+			Name:    "retry",
+			ExpResp: false,
+			ExcFunc: func(ctx context.Context) any {
+				rec, err := busDomain.Idempotency.Acquire(ctx, nr)
+				if err != nil {
+					return err
+				}
+
+				if err := busDomain.Idempotency.Release(ctx, rec); err != nil {
+					return err
+				}
+
+				rec, err = busDomain.Idempotency.Acquire(ctx, nr)
+				if err != nil {
+					return err
+				}
+
+				return rec.Completed()
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+func expired(busDomain dbtest.BusDomain) []unitest.Table {
+	nr := idempotencybus.NewRecord{
+		UserID:      uuid.New(),
+		Key:         "expired",
+		RequestHash: "hash",
+		Lease:       -time.Minute,
+	}
+
+	table := []unitest.Table{
+		{
+			Name:    "takeover",
+			ExpResp: false,
+			ExcFunc: func(ctx context.Context) any {
+				if _, err := busDomain.Idempotency.Acquire(ctx, nr); err != nil {
+					return err
+				}
+
+				// The lease of the first request passed, so the key can be
+				// used with a different request.
+				other := nr
+				other.RequestHash = "other"
+				other.Lease = time.Minute
+
+				rec, err := busDomain.Idempotency.Acquire(ctx, other)
+				if err != nil {
+					return err
+				}
+
+				return rec.Completed()
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:    "purge",
+			ExpResp: idempotencybus.ErrNotFound,
+			ExcFunc: func(ctx context.Context) any {
+				if _, err := busDomain.Idempotency.DeleteExpired(ctx, time.Now().Add(2*time.Minute)); err != nil {
+					return err
+				}
+
+				_, err := busDomain.Idempotency.QueryByKey(ctx, nr.UserID, nr.Key)
+				return err
+			},
+			CmpFunc: cmpError,
+		},
+	}
+
+	return table
+}
+
+func cmpError(got any, exp any) string {
+	gotErr, exists := got.(error)
+	if !exists {
+		return "expected an error"
+	}
+
+	if !errors.Is(gotErr, exp.(error)) {
+		return gotErr.Error()
+	}
+
+	return ""
+}
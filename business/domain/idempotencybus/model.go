@@ -0,0 +1,46 @@
+package idempotencybus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Record represents a request made with an idempotency key and, once it
+// completed, the response that was sent for it.
+type Record struct {
+	UserID        uuid.UUID
+	Key           string
+	RequestHash   string
+	Status        int
+	ContentType   string
+	Response      []byte
+	DateCreated   time.Time
+	ExpiresAt     time.Time
+	DateCompleted time.Time
+}
+
+// Completed reports if the request completed, so its response can be
+// replayed.
+func (r Record) Completed() bool {
+	return !r.DateCompleted.IsZero()
+}
+
+// NewRecord contains information needed to start a request with an
+// idempotency key. The key is held for the lease while the request is in
+// progress, and can be taken over when the lease expires.
+type NewRecord struct {
+	UserID      uuid.UUID
+	Key         string
+	RequestHash string
+	Lease       time.Duration
+}
+
+// CompleteRecord contains the response of a request, which is kept for the
+// TTL.
+type CompleteRecord struct {
+	Status      int
+	ContentType string
+	Response    []byte
+	TTL         time.Duration
+}
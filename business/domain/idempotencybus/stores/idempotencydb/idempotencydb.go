@@ -0,0 +1,196 @@
+// Package idempotencydb contains idempotency key related CRUD functionality.
+package idempotencydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/rmsj/service/business/domain/idempotencybus"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/foundation/logger"
+)
+
+// Store manages the set of APIs for idempotency key database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// NewWithTx constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (idempotencybus.Storer, error) {
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return &store, nil
+}
+
+// Acquire adds the record of an idempotency key, taking over the existing
+// one if it expired. It reports false when the key is in use.
+func (s *Store) Acquire(ctx context.Context, rec idempotencybus.Record, now time.Time) (bool, error) {
+	dbRec := toDBRecord(rec)
+
+	data := map[string]any{
+		"user_id":         dbRec.UserID,
+		"idempotency_key": dbRec.Key,
+		"request_hash":    dbRec.RequestHash,
+		"created_at":      dbRec.DateCreated,
+		"expires_at":      dbRec.ExpiresAt,
+		"now":             now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		idempotency_keys
+	SET
+		request_hash = :request_hash,
+		status = 0,
+		content_type = '',
+		response = NULL,
+		created_at = :created_at,
+		expires_at = :expires_at,
+		completed_at = NULL
+	WHERE
+		user_id = :user_id AND
+		idempotency_key = :idempotency_key AND
+		expires_at <= :now`
+
+	count, err := sqldb.NamedExecContextWithCount(ctx, s.log, s.db, q, data)
+	if err != nil {
+		return false, fmt.Errorf("namedexeccontextwithcount: %w", err)
+	}
+
+	if count > 0 {
+		return true, nil
+	}
+
+	// There is no record for the key yet, unless another request holds it.
+	const ins = `
+	INSERT INTO idempotency_keys
+		(user_id, idempotency_key, request_hash, status, content_type, response, created_at, expires_at, completed_at)
+	VALUES
+		(:user_id, :idempotency_key, :request_hash, :status, :content_type, :response, :created_at, :expires_at, :completed_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, ins, dbRec); err != nil {
+		if errors.Is(err, sqldb.ErrDBDuplicatedEntry) {
+			return false, nil
+		}
+		return false, fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return true, nil
+}
+
+// Complete stores the response of the request the record was acquired for.
+func (s *Store) Complete(ctx context.Context, rec idempotencybus.Record) error {
+	const q = `
+	UPDATE
+		idempotency_keys
+	SET
+		status = :status,
+		content_type = :content_type,
+		response = :response,
+		expires_at = :expires_at,
+		completed_at = :completed_at
+	WHERE
+		user_id = :user_id AND
+		idempotency_key = :idempotency_key AND
+		request_hash = :request_hash AND
+		completed_at IS NULL`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBRecord(rec)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Release removes the record of a request that didn't complete.
+func (s *Store) Release(ctx context.Context, rec idempotencybus.Record) error {
+	const q = `
+	DELETE FROM
+		idempotency_keys
+	WHERE
+		user_id = :user_id AND
+		idempotency_key = :idempotency_key AND
+		request_hash = :request_hash AND
+		completed_at IS NULL`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBRecord(rec)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryByKey gets the record of the idempotency key sent by the user.
+func (s *Store) QueryByKey(ctx context.Context, userID uuid.UUID, key string) (idempotencybus.Record, error) {
+	data := struct {
+		UserID string `db:"user_id"`
+		Key    string `db:"idempotency_key"`
+	}{
+		UserID: userID.String(),
+		Key:    key,
+	}
+
+	const q = `
+	SELECT
+		user_id, idempotency_key, request_hash, status, content_type, response, created_at, expires_at, completed_at
+	FROM
+		idempotency_keys
+	WHERE
+		user_id = :user_id AND
+		idempotency_key = :idempotency_key`
+
+	var dbRec record
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbRec); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return idempotencybus.Record{}, fmt.Errorf("namedquerystruct: %w", idempotencybus.ErrNotFound)
+		}
+		return idempotencybus.Record{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusRecord(dbRec), nil
+}
+
+// DeleteExpired removes the records that expired before the specified time.
+func (s *Store) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	data := struct {
+		Before time.Time `db:"before"`
+	}{
+		Before: before.UTC(),
+	}
+
+	const q = `
+	DELETE FROM
+		idempotency_keys
+	WHERE
+		expires_at < :before`
+
+	count, err := sqldb.NamedExecContextWithCount(ctx, s.log, s.db, q, data)
+	if err != nil {
+		return 0, fmt.Errorf("namedexeccontextwithcount: %w", err)
+	}
+
+	return int(count), nil
+}
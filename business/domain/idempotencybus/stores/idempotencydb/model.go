@@ -0,0 +1,60 @@
+package idempotencydb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/domain/idempotencybus"
+)
+
+type record struct {
+	UserID        uuid.UUID    `db:"user_id"`
+	Key           string       `db:"idempotency_key"`
+	RequestHash   string       `db:"request_hash"`
+	Status        int          `db:"status"`
+	ContentType   string       `db:"content_type"`
+	Response      []byte       `db:"response"`
+	DateCreated   time.Time    `db:"created_at"`
+	ExpiresAt     time.Time    `db:"expires_at"`
+	DateCompleted sql.NullTime `db:"completed_at"`
+}
+
+func toDBRecord(bus idempotencybus.Record) record {
+	var completed sql.NullTime
+	if !bus.DateCompleted.IsZero() {
+		completed = sql.NullTime{Time: bus.DateCompleted.UTC(), Valid: true}
+	}
+
+	return record{
+		UserID:        bus.UserID,
+		Key:           bus.Key,
+		RequestHash:   bus.RequestHash,
+		Status:        bus.Status,
+		ContentType:   bus.ContentType,
+		Response:      bus.Response,
+		DateCreated:   bus.DateCreated.UTC(),
+		ExpiresAt:     bus.ExpiresAt.UTC(),
+		DateCompleted: completed,
+	}
+}
+
+func toBusRecord(db record) idempotencybus.Record {
+	var completed time.Time
+	if db.DateCompleted.Valid {
+		completed = db.DateCompleted.Time.In(time.Local)
+	}
+
+	return idempotencybus.Record{
+		UserID:        db.UserID,
+		Key:           db.Key,
+		RequestHash:   db.RequestHash,
+		Status:        db.Status,
+		ContentType:   db.ContentType,
+		Response:      db.Response,
+		DateCreated:   db.DateCreated.In(time.Local),
+		ExpiresAt:     db.ExpiresAt.In(time.Local),
+		DateCompleted: completed,
+	}
+}
@@ -0,0 +1,277 @@
+// Package identitybus provides business access to the identities users have
+// at external identity providers.
+package identitybus
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/sdk/ctxval"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/business/types/name"
+	"github.com/rmsj/service/business/types/role"
+	"github.com/rmsj/service/foundation/logger"
+	"github.com/rmsj/service/foundation/otel"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound         = errors.New("identity not found")
+	ErrEmailNotVerified = errors.New("email not verified by the provider")
+	ErrNoUser           = errors.New("no user for the email")
+	ErrUserDisabled     = errors.New("user disabled")
+	ErrAlreadyLinked    = errors.New("identity already linked")
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	NewWithTx(tx sqldb.CommitRollbacker) (Storer, error)
+	Create(ctx context.Context, idn Identity) error
+	Update(ctx context.Context, idn Identity) error
+	Delete(ctx context.Context, idn Identity) error
+	QueryBySubject(ctx context.Context, provider string, subject string) (Identity, error)
+	QueryByUserID(ctx context.Context, userID uuid.UUID) ([]Identity, error)
+}
+
+// Business manages the set of APIs for identity access.
+type Business struct {
+	log     *logger.Logger
+	userBus *userbus.Business
+	storer  Storer
+}
+
+// NewBusiness constructs an identity business API for use.
+func NewBusiness(log *logger.Logger, userBus *userbus.Business, storer Storer) *Business {
+	return &Business{
+		log:     log,
+		userBus: userBus,
+		storer:  storer,
+	}
+}
+
+// NewWithTx constructs a new business value that will use the
+// specified transaction in any store related calls.
+func (b *Business) NewWithTx(tx sqldb.CommitRollbacker) (*Business, error) {
+	storer, err := b.storer.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	userBus, err := b.userBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	bus := Business{
+		log:     b.log,
+		userBus: userBus,
+		storer:  storer,
+	}
+
+	return &bus, nil
+}
+
+// Create links an external account to a user.
+func (b *Business) Create(ctx context.Context, ni NewIdentity) (Identity, error) {
+	ctx, span := otel.AddSpan(ctx, "business.identitybus.create")
+	defer span.End()
+
+	now := ctxval.GetTime(ctx)
+
+	idn := Identity{
+		Provider:    ni.Provider,
+		Subject:     ni.Subject,
+		UserID:      ni.UserID,
+		Email:       ni.Email,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if err := b.storer.Create(ctx, idn); err != nil {
+		b.log.Error(ctx, "business.identitybus.create", "error", err)
+		return Identity{}, fmt.Errorf("create: %w", err)
+	}
+
+	return idn, nil
+}
+
+// Delete unlinks an external account from its user.
+func (b *Business) Delete(ctx context.Context, idn Identity) error {
+	ctx, span := otel.AddSpan(ctx, "business.identitybus.delete")
+	defer span.End()
+
+	if err := b.storer.Delete(ctx, idn); err != nil {
+		b.log.Error(ctx, "business.identitybus.delete", "error", err)
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	return nil
+}
+
+// QueryBySubject finds the identity of the account at the provider.
+func (b *Business) QueryBySubject(ctx context.Context, provider string, subject string) (Identity, error) {
+	ctx, span := otel.AddSpan(ctx, "business.identitybus.querybysubject")
+	defer span.End()
+
+	idn, err := b.storer.QueryBySubject(ctx, provider, subject)
+	if err != nil {
+		return Identity{}, fmt.Errorf("query: provider[%s] subject[%s]: %w", provider, subject, err)
+	}
+
+	return idn, nil
+}
+
+// QueryByUserID finds the identities linked to the user.
+func (b *Business) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]Identity, error) {
+	ctx, span := otel.AddSpan(ctx, "business.identitybus.querybyuserid")
+	defer span.End()
+
+	idns, err := b.storer.QueryByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query: userID[%s]: %w", userID, err)
+	}
+
+	return idns, nil
+}
+
+// Link returns the user the external account belongs to. An account seen
+// for the first time is linked to the user with the same email, which the
+// provider must have verified, otherwise anyone could claim the account of
+// a user by registering their email at a provider. When no user has the
+// email, one is provisioned with the user role if provision is set.
+func (b *Business) Link(ctx context.Context, eu ExternalUser, provision bool) (userbus.User, error) {
+	ctx, span := otel.AddSpan(ctx, "business.identitybus.link")
+	defer span.End()
+
+	idn, err := b.storer.QueryBySubject(ctx, eu.Provider, eu.Subject)
+	switch {
+	case err == nil:
+		usr, err := b.userBus.QueryByID(ctx, idn.UserID)
+		if err != nil {
+			return userbus.User{}, fmt.Errorf("query user: userID[%s]: %w", idn.UserID, err)
+		}
+
+		if !usr.Enabled {
+			return userbus.User{}, ErrUserDisabled
+		}
+
+		if eu.EmailVerified && eu.Email.Address != "" && eu.Email.Address != idn.Email.Address {
+			idn.Email = eu.Email
+			idn.DateUpdated = ctxval.GetTime(ctx)
+
+			if err := b.storer.Update(ctx, idn); err != nil {
+				b.log.Error(ctx, "business.identitybus.link", "error", err)
+				return userbus.User{}, fmt.Errorf("update: %w", err)
+			}
+		}
+
+		return usr, nil
+
+	case !errors.Is(err, ErrNotFound):
+		b.log.Error(ctx, "business.identitybus.link", "error", err)
+		return userbus.User{}, fmt.Errorf("query: provider[%s] subject[%s]: %w", eu.Provider, eu.Subject, err)
+	}
+
+	if eu.Email.Address == "" || !eu.EmailVerified {
+		return userbus.User{}, ErrEmailNotVerified
+	}
+
+	usr, err := b.userBus.QueryByEmail(ctx, eu.Email)
+	switch {
+	case err == nil:
+		if !usr.Enabled {
+			return userbus.User{}, ErrUserDisabled
+		}
+
+		// the provider verified the user owns the email
+		if !usr.EmailVerified {
+			verified := true
+
+			usr, err = b.userBus.Update(ctx, usr, userbus.UpdateUser{EmailVerified: &verified})
+			if err != nil {
+				return userbus.User{}, fmt.Errorf("verify email: userID[%s]: %w", usr.ID, err)
+			}
+		}
+
+	case errors.Is(err, userbus.ErrNotFound):
+		if !provision {
+			return userbus.User{}, ErrNoUser
+		}
+
+		usr, err = b.provision(ctx, eu)
+		if err != nil {
+			return userbus.User{}, err
+		}
+
+	default:
+		return userbus.User{}, fmt.Errorf("query user: email[%s]: %w", eu.Email.Address, err)
+	}
+
+	ni := NewIdentity{
+		Provider: eu.Provider,
+		Subject:  eu.Subject,
+		UserID:   usr.ID,
+		Email:    eu.Email,
+	}
+
+	if _, err := b.Create(ctx, ni); err != nil {
+		return userbus.User{}, err
+	}
+
+	return usr, nil
+}
+
+// provision creates the user for the external account. The password is
+// random, the user can set one with the forgot password flow.
+func (b *Business) provision(ctx context.Context, eu ExternalUser) (userbus.User, error) {
+	password := make([]byte, 32)
+	if _, err := rand.Read(password); err != nil {
+		return userbus.User{}, fmt.Errorf("password: %w", err)
+	}
+
+	nu := userbus.NewUser{
+		Name:          provisionName(eu),
+		Email:         eu.Email,
+		Roles:         []role.Role{role.User},
+		Password:      hex.EncodeToString(password),
+		EmailVerified: true,
+	}
+
+	usr, err := b.userBus.Create(ctx, nu)
+	if err != nil {
+		return userbus.User{}, fmt.Errorf("create user: email[%s]: %w", eu.Email.Address, err)
+	}
+
+	return usr, nil
+}
+
+// invalidNameChars matches what a name can't hold.
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9' -]`)
+
+// provisionName makes a valid name out of the name at the provider, or of
+// the email when that isn't enough.
+func provisionName(eu ExternalUser) name.Name {
+	local, _, _ := strings.Cut(eu.Email.Address, "@")
+
+	for _, value := range []string{eu.Name, eu.Email.Name, local} {
+		value = strings.TrimSpace(invalidNameChars.ReplaceAllString(value, ""))
+		if len(value) > 20 {
+			value = strings.TrimSpace(value[:20])
+		}
+
+		if n, err := name.Parse(value); err == nil {
+			return n
+		}
+	}
+
+	return name.MustParse("New User")
+}
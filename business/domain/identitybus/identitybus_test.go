@@ -0,0 +1,220 @@
+package identitybus_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rmsj/service/business/domain/identitybus"
+	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/sdk/dbtest"
+	"github.com/rmsj/service/business/sdk/unitest"
+	"github.com/rmsj/service/business/types/role"
+)
+
+func Test_Identity(t *testing.T) {
+	t.Parallel()
+
+	db := dbtest.New(t, "Test_Identity")
+
+	sd, err := insertSeedData(db.BusDomain)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	unitest.Run(t, link(db.BusDomain, sd), "link")
+	unitest.Run(t, provision(db.BusDomain), "provision")
+}
+
+// =============================================================================
+
+func insertSeedData(busDomain dbtest.BusDomain) (unitest.SeedData, error) {
+	ctx := context.Background()
+
+	usrs, err := userbus.TestSeedUsers(ctx, 1, role.User, busDomain.User)
+	if err != nil {
+		return unitest.SeedData{}, fmt.Errorf("seeding users : %w", err)
+	}
+
+	sd := unitest.SeedData{
+		Users: []unitest.User{
+			{User: usrs[0]},
+		},
+	}
+
+	return sd, nil
+}
+
+// =============================================================================
+
+func link(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
+	eu := identitybus.ExternalUser{
+		Provider:      "faux",
+		Subject:       "faux-1",
+		Name:          sd.Users[0].Name.String(),
+		Email:         sd.Users[0].Email,
+		EmailVerified: true,
+	}
+
+	table := []unitest.Table{
+		{
+			Name:    "unverified",
+			ExpResp: identitybus.ErrEmailNotVerified,
+			ExcFunc: func(ctx context.Context) any {
+				unverified := eu
+				unverified.EmailVerified = false
+
+				_, err := busDomain.Identity.Link(ctx, unverified, true)
+				return err
+			},
+			CmpFunc: func(got any, exp any) string {
+				if !errors.Is(got.(error), exp.(error)) {
+					return fmt.Sprintf("got %v, exp %v", got, exp)
+				}
+				return ""
+			},
+		},
+		{
+			Name:    "byemail",
+			ExpResp: sd.Users[0].ID,
+			ExcFunc: func(ctx context.Context) any {
+				usr, err := busDomain.Identity.Link(ctx, eu, false)
+				if err != nil {
+					return err
+				}
+
+				return usr.ID
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:    "bysubject",
+			ExpResp: sd.Users[0].ID,
+			ExcFunc: func(ctx context.Context) any {
+				// The provider no longer vouches for the email, but the
+				// account is already linked.
+				linked := eu
+				linked.EmailVerified = false
+
+				usr, err := busDomain.Identity.Link(ctx, linked, false)
+				if err != nil {
+					return err
+				}
+
+				return usr.ID
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:    "identities",
+			ExpResp: []string{"faux-1"},
+			ExcFunc: func(ctx context.Context) any {
+				idns, err := busDomain.Identity.QueryByUserID(ctx, sd.Users[0].ID)
+				if err != nil {
+					return err
+				}
+
+				subjects := make([]string, len(idns))
+				for i, idn := range idns {
+					subjects[i] = idn.Subject
+				}
+
+				return subjects
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+func provision(busDomain dbtest.BusDomain) []unitest.Table {
+	eu := identitybus.ExternalUser{
+		Provider:      "faux",
+		Subject:       "faux-2",
+		Name:          "Jane Doe!",
+		Email:         mail.Address{Address: "jane.doe@example.com"},
+		EmailVerified: true,
+	}
+
+	table := []unitest.Table{
+		{
+			Name:    "disabled",
+			ExpResp: identitybus.ErrNoUser,
+			ExcFunc: func(ctx context.Context) any {
+				_, err := busDomain.Identity.Link(ctx, eu, false)
+				return err
+			},
+			CmpFunc: func(got any, exp any) string {
+				if !errors.Is(got.(error), exp.(error)) {
+					return fmt.Sprintf("got %v, exp %v", got, exp)
+				}
+				return ""
+			},
+		},
+		{
+			Name: "enabled",
+			ExpResp: userbus.User{
+				Email:   eu.Email,
+				Roles:   []role.Role{role.User},
+				Enabled: true,
+			},
+			ExcFunc: func(ctx context.Context) any {
+				usr, err := busDomain.Identity.Link(ctx, eu, true)
+				if err != nil {
+					return err
+				}
+
+				return usr
+			},
+			CmpFunc: func(got any, exp any) string {
+				gotResp, exists := got.(userbus.User)
+				if !exists {
+					return "error occurred"
+				}
+
+				if gotResp.Name.String() != "Jane Doe" {
+					return fmt.Sprintf("got name %q, exp %q", gotResp.Name, "Jane Doe")
+				}
+
+				return cmp.Diff(
+					userbus.User{Email: gotResp.Email, Roles: gotResp.Roles, Enabled: gotResp.Enabled},
+					exp.(userbus.User),
+				)
+			},
+		},
+		{
+			Name:    "again",
+			ExpResp: 1,
+			ExcFunc: func(ctx context.Context) any {
+				usr, err := busDomain.Identity.Link(ctx, eu, true)
+				if err != nil {
+					return err
+				}
+
+				idns, err := busDomain.Identity.QueryByUserID(ctx, usr.ID)
+				if err != nil {
+					return err
+				}
+
+				return len(idns)
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
@@ -0,0 +1,38 @@
+package identitybus
+
+import (
+	"net/mail"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Identity links an account of an external identity provider to a user.
+type Identity struct {
+	Provider    string
+	Subject     string
+	UserID      uuid.UUID
+	Email       mail.Address
+	DateCreated time.Time
+	DateUpdated time.Time
+}
+
+// NewIdentity contains information needed to link an external account to a
+// user.
+type NewIdentity struct {
+	Provider string
+	Subject  string
+	UserID   uuid.UUID
+	Email    mail.Address
+}
+
+// ExternalUser represents the user an identity provider authenticated.
+// Subject is the id of the user at the provider, which never changes, unlike
+// the email.
+type ExternalUser struct {
+	Provider      string
+	Subject       string
+	Name          string
+	Email         mail.Address
+	EmailVerified bool
+}
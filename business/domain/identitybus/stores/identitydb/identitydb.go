@@ -0,0 +1,151 @@
+// Package identitydb contains identity related CRUD functionality.
+package identitydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/rmsj/service/business/domain/identitybus"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/foundation/logger"
+)
+
+// Store manages the set of APIs for identity database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// NewWithTx constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (identitybus.Storer, error) {
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return &store, nil
+}
+
+// Create adds an identity to the sqldb.
+func (s *Store) Create(ctx context.Context, idn identitybus.Identity) error {
+	const q = `
+	INSERT INTO user_identities
+		(provider, subject, user_id, email, created_at, updated_at)
+	VALUES
+		(:provider, :subject, :user_id, :email, :created_at, :updated_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBIdentity(idn)); err != nil {
+		if errors.Is(err, sqldb.ErrDBDuplicatedEntry) {
+			return fmt.Errorf("namedexeccontext: %w", identitybus.ErrAlreadyLinked)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces an identity in the sqldb.
+func (s *Store) Update(ctx context.Context, idn identitybus.Identity) error {
+	const q = `
+	UPDATE
+		user_identities
+	SET
+		email = :email,
+		updated_at = :updated_at
+	WHERE
+		provider = :provider AND subject = :subject`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBIdentity(idn)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes an identity from the sqldb.
+func (s *Store) Delete(ctx context.Context, idn identitybus.Identity) error {
+	const q = `
+	DELETE FROM
+		user_identities
+	WHERE
+		provider = :provider AND subject = :subject`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBIdentity(idn)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryBySubject gets the identity of the account at the provider.
+func (s *Store) QueryBySubject(ctx context.Context, provider string, subject string) (identitybus.Identity, error) {
+	data := struct {
+		Provider string `db:"provider"`
+		Subject  string `db:"subject"`
+	}{
+		Provider: provider,
+		Subject:  subject,
+	}
+
+	const q = `
+	SELECT
+		provider, subject, user_id, email, created_at, updated_at
+	FROM
+		user_identities
+	WHERE
+		provider = :provider AND subject = :subject`
+
+	var dbIdn identity
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbIdn); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return identitybus.Identity{}, fmt.Errorf("db: %w", identitybus.ErrNotFound)
+		}
+		return identitybus.Identity{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusIdentity(dbIdn)
+}
+
+// QueryByUserID gets the identities linked to the user.
+func (s *Store) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]identitybus.Identity, error) {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID.String(),
+	}
+
+	const q = `
+	SELECT
+		provider, subject, user_id, email, created_at, updated_at
+	FROM
+		user_identities
+	WHERE
+		user_id = :user_id
+	ORDER BY
+		provider, subject`
+
+	var dbIdns []identity
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbIdns); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusIdentities(dbIdns)
+}
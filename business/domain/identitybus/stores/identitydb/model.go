@@ -0,0 +1,67 @@
+package identitydb
+
+import (
+	"fmt"
+	"net/mail"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/domain/identitybus"
+)
+
+type identity struct {
+	Provider    string    `db:"provider"`
+	Subject     string    `db:"subject"`
+	UserID      uuid.UUID `db:"user_id"`
+	Email       string    `db:"email"`
+	DateCreated time.Time `db:"created_at"`
+	DateUpdated time.Time `db:"updated_at"`
+}
+
+func toDBIdentity(bus identitybus.Identity) identity {
+	return identity{
+		Provider:    bus.Provider,
+		Subject:     bus.Subject,
+		UserID:      bus.UserID,
+		Email:       bus.Email.Address,
+		DateCreated: bus.DateCreated.UTC(),
+		DateUpdated: bus.DateUpdated.UTC(),
+	}
+}
+
+func toBusIdentity(db identity) (identitybus.Identity, error) {
+	var email mail.Address
+	if db.Email != "" {
+		addr, err := mail.ParseAddress(db.Email)
+		if err != nil {
+			return identitybus.Identity{}, fmt.Errorf("parse email: %w", err)
+		}
+		email = *addr
+	}
+
+	bus := identitybus.Identity{
+		Provider:    db.Provider,
+		Subject:     db.Subject,
+		UserID:      db.UserID,
+		Email:       email,
+		DateCreated: db.DateCreated.In(time.Local),
+		DateUpdated: db.DateUpdated.In(time.Local),
+	}
+
+	return bus, nil
+}
+
+func toBusIdentities(dbs []identity) ([]identitybus.Identity, error) {
+	bus := make([]identitybus.Identity, len(dbs))
+
+	for i, db := range dbs {
+		var err error
+		bus[i], err = toBusIdentity(db)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bus, nil
+}
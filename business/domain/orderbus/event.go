@@ -0,0 +1,56 @@
+package orderbus
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rmsj/service/business/sdk/delegate"
+	"github.com/rmsj/service/business/types/orderstatus"
+)
+
+// DomainName represents the name of this domain.
+const DomainName = "order"
+
+// Set of delegate actions.
+const (
+	ActionStatusChanged = "statuschanged"
+)
+
+// ActionStatusChangedParms represents the parameters for the status changed
+// action.
+type ActionStatusChangedParms struct {
+	OrderID uuid.UUID
+	From    orderstatus.Status
+	To      orderstatus.Status
+}
+
+// String returns a string representation of the action parameters.
+func (act *ActionStatusChangedParms) String() string {
+	return fmt.Sprintf("&EventParamsStatusChanged{OrderID:%v, From:%s, To:%s}", act.OrderID, act.From, act.To)
+}
+
+// Marshal returns the event parameters encoded as JSON.
+func (act *ActionStatusChangedParms) Marshal() ([]byte, error) {
+	return json.Marshal(act)
+}
+
+// ActionStatusChangedData constructs the data for the status changed action.
+func ActionStatusChangedData(orderID uuid.UUID, from orderstatus.Status, to orderstatus.Status) delegate.Data {
+	params := ActionStatusChangedParms{
+		OrderID: orderID,
+		From:    from,
+		To:      to,
+	}
+
+	rawParams, err := params.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return delegate.Data{
+		Domain:    DomainName,
+		Action:    ActionStatusChanged,
+		RawParams: rawParams,
+	}
+}
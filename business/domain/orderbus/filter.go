@@ -0,0 +1,30 @@
+package orderbus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rmsj/service/business/sdk/filter"
+	"github.com/rmsj/service/business/types/orderstatus"
+)
+
+// Set of fields that the filter expressions can use.
+const (
+	FilterByOrderID     = "a"
+	FilterByUserID      = "b"
+	FilterByStatus      = "c"
+	FilterByDateCreated = "d"
+	FilterByDateUpdated = "e"
+)
+
+// QueryFilter holds the available fields a query can be filtered on.
+// We are using pointer semantics because the With API mutates the value.
+// The expression is on the FilterBy fields.
+type QueryFilter struct {
+	ID               *uuid.UUID
+	UserID           *uuid.UUID
+	Status           *orderstatus.Status
+	StartCreatedDate *time.Time
+	EndCreatedDate   *time.Time
+	Expr             filter.Expr
+}
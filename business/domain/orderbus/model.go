@@ -0,0 +1,69 @@
+package orderbus
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rmsj/service/business/types/money"
+	"github.com/rmsj/service/business/types/orderstatus"
+	"github.com/rmsj/service/business/types/quantity"
+)
+
+// Order represents an individual order.
+type Order struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Status      orderstatus.Status
+	Items       []Item
+	DateCreated time.Time
+	DateUpdated time.Time
+}
+
+// Total returns the sum of the line items of the order. An order without
+// items has a zero total in the default currency.
+func (o Order) Total() (money.Money, error) {
+	if len(o.Items) == 0 {
+		return money.Zero(money.DefaultCurrency), nil
+	}
+
+	total := money.Zero(o.Items[0].Price.Currency())
+	for _, itm := range o.Items {
+		sub, err := itm.Price.Mul(itm.Quantity.Value())
+		if err != nil {
+			return money.Money{}, fmt.Errorf("item: productID[%s]: %w", itm.ProductID, err)
+		}
+
+		if total, err = total.Add(sub); err != nil {
+			return money.Money{}, fmt.Errorf("item: productID[%s]: %w", itm.ProductID, err)
+		}
+	}
+
+	return total, nil
+}
+
+// Item represents a line item inside an order. The price is captured from
+// the product at the time the item is added to the order.
+type Item struct {
+	ProductID uuid.UUID
+	Quantity  quantity.Quantity
+	Price     money.Money
+}
+
+// NewOrder is what we require from clients when adding an Order.
+type NewOrder struct {
+	UserID uuid.UUID
+	Items  []NewItem
+}
+
+// NewItem is what we require from clients when adding a line item to an Order.
+type NewItem struct {
+	ProductID uuid.UUID
+	Quantity  quantity.Quantity
+}
+
+// UpdateOrder defines what information may be provided to modify an existing
+// Order. The line items can only be changed while the order is a draft.
+type UpdateOrder struct {
+	Items []NewItem
+}
@@ -0,0 +1,14 @@
+package orderbus
+
+import "github.com/rmsj/service/business/sdk/order"
+
+// DefaultOrderBy represents the default way we sort.
+var DefaultOrderBy = order.NewBy(OrderByOrderID, order.ASC)
+
+// Set of fields that the results can be ordered by.
+const (
+	OrderByOrderID     = "a"
+	OrderByUserID      = "b"
+	OrderByStatus      = "c"
+	OrderByDateCreated = "d"
+)
@@ -0,0 +1,342 @@
+// Package orderbus provides business access to order domain.
+package orderbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/domain/productbus"
+	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/sdk/delegate"
+	"github.com/rmsj/service/business/sdk/order"
+	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/business/types/orderstatus"
+	"github.com/rmsj/service/business/types/quantity"
+	"github.com/rmsj/service/foundation/logger"
+	"github.com/rmsj/service/foundation/otel"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound          = errors.New("order not found")
+	ErrUserDisabled      = errors.New("user disabled")
+	ErrNoItems           = errors.New("order has no items")
+	ErrNotDraft          = errors.New("order is not a draft")
+	ErrInvalidTransition = errors.New("invalid order status transition")
+	ErrCurrencyMismatch  = errors.New("order items must share the same currency")
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	NewWithTx(tx sqldb.CommitRollbacker) (Storer, error)
+	Create(ctx context.Context, ord Order) error
+	Update(ctx context.Context, ord Order) error
+	Delete(ctx context.Context, ord Order) error
+	Query(ctx context.Context, filter QueryFilter, orderBy order.By, page page.Page) ([]Order, error)
+	Count(ctx context.Context, filter QueryFilter) (int, error)
+	QueryByID(ctx context.Context, orderID uuid.UUID) (Order, error)
+	QueryByIDForUpdate(ctx context.Context, orderID uuid.UUID) (Order, error)
+	QueryByUserID(ctx context.Context, userID uuid.UUID) ([]Order, error)
+}
+
+// Business manages the set of APIs for order access.
+type Business struct {
+	log        *logger.Logger
+	userBus    *userbus.Business
+	productBus *productbus.Business
+	delegate   *delegate.Delegate
+	storer     Storer
+}
+
+// NewBusiness constructs an order business API for use.
+func NewBusiness(log *logger.Logger, userBus *userbus.Business, productBus *productbus.Business, delegate *delegate.Delegate, storer Storer) *Business {
+	return &Business{
+		log:        log,
+		userBus:    userBus,
+		productBus: productBus,
+		delegate:   delegate,
+		storer:     storer,
+	}
+}
+
+// NewWithTx constructs a new business value that will use the
+// specified transaction in any store related calls.
+func (b *Business) NewWithTx(tx sqldb.CommitRollbacker) (*Business, error) {
+	storer, err := b.storer.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	userBus, err := b.userBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	productBus, err := b.productBus.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	dlg, err := b.delegate.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	bus := Business{
+		log:        b.log,
+		userBus:    userBus,
+		productBus: productBus,
+		delegate:   dlg,
+		storer:     storer,
+	}
+
+	return &bus, nil
+}
+
+// Create adds a new draft order to the system.
+func (b *Business) Create(ctx context.Context, no NewOrder) (Order, error) {
+	ctx, span := otel.AddSpan(ctx, "business.orderbus.create")
+	defer span.End()
+
+	usr, err := b.userBus.QueryByID(ctx, no.UserID)
+	if err != nil {
+		return Order{}, fmt.Errorf("user.querybyid: %s: %w", no.UserID, err)
+	}
+
+	if !usr.Enabled {
+		return Order{}, ErrUserDisabled
+	}
+
+	items, err := b.toItems(ctx, no.Items)
+	if err != nil {
+		return Order{}, err
+	}
+
+	now := time.Now()
+
+	ord := Order{
+		ID:          uuid.New(),
+		UserID:      no.UserID,
+		Status:      orderstatus.Draft,
+		Items:       items,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if err := b.storer.Create(ctx, ord); err != nil {
+		return Order{}, fmt.Errorf("create: %w", err)
+	}
+
+	return ord, nil
+}
+
+// Update replaces the line items of a draft order. The order is read again
+// and locked, so the items can't change while the order is being placed.
+func (b *Business) Update(ctx context.Context, ord Order, uo UpdateOrder) (Order, error) {
+	ctx, span := otel.AddSpan(ctx, "business.orderbus.update")
+	defer span.End()
+
+	ord, err := b.storer.QueryByIDForUpdate(ctx, ord.ID)
+	if err != nil {
+		return Order{}, fmt.Errorf("querybyidforupdate: %w", err)
+	}
+
+	if !ord.Status.Equal(orderstatus.Draft) {
+		return Order{}, ErrNotDraft
+	}
+
+	if uo.Items != nil {
+		items, err := b.toItems(ctx, uo.Items)
+		if err != nil {
+			return Order{}, err
+		}
+
+		ord.Items = items
+	}
+
+	ord.DateUpdated = time.Now()
+
+	if err := b.storer.Update(ctx, ord); err != nil {
+		return Order{}, fmt.Errorf("update: %w", err)
+	}
+
+	return ord, nil
+}
+
+// UpdateStatus moves the order to the specified status if the transition is
+// allowed. Placing an order removes the ordered quantities from the product
+// stock and cancelling a placed or paid order returns them. The stock changes
+// must run inside the same transaction as the order update, so this call is
+// expected to be made with a business value constructed by NewWithTx. The
+// order is read again and locked before the transition is checked, so the
+// same transition made concurrently only changes the stock once.
+func (b *Business) UpdateStatus(ctx context.Context, ord Order, status orderstatus.Status) (Order, error) {
+	ctx, span := otel.AddSpan(ctx, "business.orderbus.updatestatus")
+	defer span.End()
+
+	ord, err := b.storer.QueryByIDForUpdate(ctx, ord.ID)
+	if err != nil {
+		return Order{}, fmt.Errorf("querybyidforupdate: %w", err)
+	}
+
+	if !ord.Status.CanTransition(status) {
+		return Order{}, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, ord.Status, status)
+	}
+
+	switch {
+	case status.Equal(orderstatus.Placed):
+		if len(ord.Items) == 0 {
+			return Order{}, ErrNoItems
+		}
+
+		for _, itm := range ord.Items {
+			if err := b.productBus.DecreaseQuantity(ctx, itm.ProductID, itm.Quantity); err != nil {
+				return Order{}, fmt.Errorf("decreasequantity: %w", err)
+			}
+		}
+
+	case status.Equal(orderstatus.Cancelled) && !ord.Status.Equal(orderstatus.Draft):
+		for _, itm := range ord.Items {
+			if err := b.productBus.IncreaseQuantity(ctx, itm.ProductID, itm.Quantity); err != nil {
+				return Order{}, fmt.Errorf("increasequantity: %w", err)
+			}
+		}
+	}
+
+	from := ord.Status
+
+	ord.Status = status
+	ord.DateUpdated = time.Now()
+
+	if err := b.storer.Update(ctx, ord); err != nil {
+		return Order{}, fmt.Errorf("update: %w", err)
+	}
+
+	if b.delegate != nil {
+		if err := b.delegate.Call(ctx, ActionStatusChangedData(ord.ID, from, status)); err != nil {
+			return Order{}, fmt.Errorf("failed to execute `%s` action: %w", ActionStatusChanged, err)
+		}
+	}
+
+	return ord, nil
+}
+
+// Delete removes the specified order. Only draft orders can be removed.
+func (b *Business) Delete(ctx context.Context, ord Order) error {
+	ctx, span := otel.AddSpan(ctx, "business.orderbus.delete")
+	defer span.End()
+
+	ord, err := b.storer.QueryByIDForUpdate(ctx, ord.ID)
+	if err != nil {
+		return fmt.Errorf("querybyidforupdate: %w", err)
+	}
+
+	if !ord.Status.Equal(orderstatus.Draft) {
+		return ErrNotDraft
+	}
+
+	if err := b.storer.Delete(ctx, ord); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of existing orders.
+func (b *Business) Query(ctx context.Context, filter QueryFilter, orderBy order.By, page page.Page) ([]Order, error) {
+	ctx, span := otel.AddSpan(ctx, "business.orderbus.query")
+	defer span.End()
+
+	ords, err := b.storer.Query(ctx, filter, orderBy, page)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return ords, nil
+}
+
+// Count returns the total number of orders.
+func (b *Business) Count(ctx context.Context, filter QueryFilter) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.orderbus.count")
+	defer span.End()
+
+	return b.storer.Count(ctx, filter)
+}
+
+// QueryByID finds the order by the specified ID.
+func (b *Business) QueryByID(ctx context.Context, orderID uuid.UUID) (Order, error) {
+	ctx, span := otel.AddSpan(ctx, "business.orderbus.querybyid")
+	defer span.End()
+
+	ord, err := b.storer.QueryByID(ctx, orderID)
+	if err != nil {
+		return Order{}, fmt.Errorf("query: orderID[%s]: %w", orderID, err)
+	}
+
+	return ord, nil
+}
+
+// QueryByUserID finds the orders by a specified User ID.
+func (b *Business) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]Order, error) {
+	ctx, span := otel.AddSpan(ctx, "business.orderbus.querybyuserid")
+	defer span.End()
+
+	ords, err := b.storer.QueryByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return ords, nil
+}
+
+// =============================================================================
+
+// toItems converts the requested line items into order items, capturing the
+// current cost of each product as the item price. Repeated products are
+// merged into a single line item and the items are kept ordered by product id.
+// All the items in an order must be priced in the same currency.
+func (b *Business) toItems(ctx context.Context, nis []NewItem) ([]Item, error) {
+	items := make([]Item, 0, len(nis))
+	idx := make(map[uuid.UUID]int, len(nis))
+
+	for _, ni := range nis {
+		if i, exists := idx[ni.ProductID]; exists {
+			qty, err := quantity.Parse(items[i].Quantity.Value() + ni.Quantity.Value())
+			if err != nil {
+				return nil, fmt.Errorf("item: productID[%s]: %w", ni.ProductID, err)
+			}
+			items[i].Quantity = qty
+			continue
+		}
+
+		prd, err := b.productBus.QueryByID(ctx, ni.ProductID)
+		if err != nil {
+			return nil, fmt.Errorf("product.querybyid: %s: %w", ni.ProductID, err)
+		}
+
+		if len(items) > 0 && !items[0].Price.Currency().Equal(prd.Cost.Currency()) {
+			return nil, fmt.Errorf("item: productID[%s]: %w", ni.ProductID, ErrCurrencyMismatch)
+		}
+
+		idx[ni.ProductID] = len(items)
+		items = append(items, Item{
+			ProductID: prd.ID,
+			Quantity:  ni.Quantity,
+			Price:     prd.Cost,
+		})
+	}
+
+	slices.SortFunc(items, func(a, b Item) int {
+		return strings.Compare(a.ProductID.String(), b.ProductID.String())
+	})
+
+	return items, nil
+}
@@ -0,0 +1,391 @@
+package orderbus_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rmsj/service/business/domain/orderbus"
+	"github.com/rmsj/service/business/domain/productbus"
+	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/sdk/dbtest"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/business/sdk/unitest"
+	"github.com/rmsj/service/business/types/money"
+	"github.com/rmsj/service/business/types/name"
+	"github.com/rmsj/service/business/types/orderstatus"
+	"github.com/rmsj/service/business/types/quantity"
+	"github.com/rmsj/service/business/types/role"
+)
+
+func Test_Order(t *testing.T) {
+	t.Parallel()
+
+	db := dbtest.New(t, "Test_Order")
+
+	sd, err := insertSeedData(db.BusDomain)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	unitest.Run(t, query(db.BusDomain, sd), "query")
+	unitest.Run(t, create(db.BusDomain, sd), "create")
+	unitest.Run(t, updateStatus(db.BusDomain, sd), "updatestatus")
+	unitest.Run(t, delete(db.BusDomain, sd), "delete")
+	unitest.Run(t, concurrentStatus(db, sd), "concurrentstatus")
+}
+
+// =============================================================================
+
+func insertSeedData(busDomain dbtest.BusDomain) (unitest.SeedData, error) {
+	ctx := context.Background()
+
+	usrs, err := userbus.TestSeedUsers(ctx, 1, role.User, busDomain.User)
+	if err != nil {
+		return unitest.SeedData{}, fmt.Errorf("seeding users : %w", err)
+	}
+
+	np := productbus.NewProduct{
+		UserID:   usrs[0].ID,
+		Name:     name.MustParse("Guitar"),
+		Cost:     money.MustParse("10.50", "USD"),
+		Quantity: quantity.MustParse(5),
+	}
+
+	prd, err := busDomain.Product.Create(ctx, np)
+	if err != nil {
+		return unitest.SeedData{}, fmt.Errorf("seeding products : %w", err)
+	}
+
+	prds := []productbus.Product{prd}
+
+	ords, err := orderbus.TestGenerateSeedOrders(ctx, 4, busDomain.Order, usrs[0].ID, prds)
+	if err != nil {
+		return unitest.SeedData{}, fmt.Errorf("seeding orders : %w", err)
+	}
+
+	tu1 := unitest.User{
+		User:     usrs[0],
+		Products: prds,
+		Orders:   ords,
+	}
+
+	// -------------------------------------------------------------------------
+
+	sd := unitest.SeedData{
+		Users: []unitest.User{tu1},
+	}
+
+	return sd, nil
+}
+
+// =============================================================================
+
+func query(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
+	table := []unitest.Table{
+		{
+			Name:    "byid",
+			ExpResp: sd.Users[0].Orders[0],
+			ExcFunc: func(ctx context.Context) any {
+				resp, err := busDomain.Order.QueryByID(ctx, sd.Users[0].Orders[0].ID)
+				if err != nil {
+					return err
+				}
+
+				return resp
+			},
+			CmpFunc: func(got any, exp any) string {
+				gotResp, exists := got.(orderbus.Order)
+				if !exists {
+					return "error occurred"
+				}
+
+				expResp := exp.(orderbus.Order)
+
+				if gotResp.DateCreated.Format(time.RFC3339) == expResp.DateCreated.Format(time.RFC3339) {
+					expResp.DateCreated = gotResp.DateCreated
+				}
+
+				if gotResp.DateUpdated.Format(time.RFC3339) == expResp.DateUpdated.Format(time.RFC3339) {
+					expResp.DateUpdated = gotResp.DateUpdated
+				}
+
+				return cmp.Diff(gotResp, expResp)
+			},
+		},
+	}
+
+	return table
+}
+
+func create(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
+	prd := sd.Users[0].Products[0]
+
+	table := []unitest.Table{
+		{
+			Name: "basic",
+			ExpResp: orderbus.Order{
+				UserID: sd.Users[0].ID,
+				Status: orderstatus.Draft,
+				Items: []orderbus.Item{
+					{
+						ProductID: prd.ID,
+						Quantity:  quantity.MustParse(3),
+						Price:     prd.Cost,
+					},
+				},
+			},
+			ExcFunc: func(ctx context.Context) any {
+				no := orderbus.NewOrder{
+					UserID: sd.Users[0].ID,
+					Items: []orderbus.NewItem{
+						{ProductID: prd.ID, Quantity: quantity.MustParse(1)},
+						{ProductID: prd.ID, Quantity: quantity.MustParse(2)},
+					},
+				}
+
+				resp, err := busDomain.Order.Create(ctx, no)
+				if err != nil {
+					return err
+				}
+
+				return resp
+			},
+			CmpFunc: func(got any, exp any) string {
+				gotResp, exists := got.(orderbus.Order)
+				if !exists {
+					return "error occurred"
+				}
+
+				expResp := exp.(orderbus.Order)
+
+				expResp.ID = gotResp.ID
+				expResp.DateCreated = gotResp.DateCreated
+				expResp.DateUpdated = gotResp.DateUpdated
+
+				return cmp.Diff(gotResp, expResp)
+			},
+		},
+	}
+
+	return table
+}
+
+func updateStatus(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
+	prd := sd.Users[0].Products[0]
+
+	table := []unitest.Table{
+		{
+			Name:    "place",
+			ExpResp: prd.Quantity.Value() - 1,
+			ExcFunc: func(ctx context.Context) any {
+				if _, err := busDomain.Order.UpdateStatus(ctx, sd.Users[0].Orders[0], orderstatus.Placed); err != nil {
+					return err
+				}
+
+				resp, err := busDomain.Product.QueryByID(ctx, prd.ID)
+				if err != nil {
+					return err
+				}
+
+				return resp.Quantity.Value()
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:    "invalid-transition",
+			ExpResp: orderbus.ErrInvalidTransition,
+			ExcFunc: func(ctx context.Context) any {
+				if _, err := busDomain.Order.UpdateStatus(ctx, sd.Users[0].Orders[1], orderstatus.Shipped); err != nil {
+					return err
+				}
+
+				return nil
+			},
+			CmpFunc: func(got any, exp any) string {
+				if err, ok := got.(error); ok && errors.Is(err, exp.(error)) {
+					return ""
+				}
+
+				return fmt.Sprintf("expected error %v, got %v", exp, got)
+			},
+		},
+		{
+			Name:    "insufficient",
+			ExpResp: productbus.ErrInsufficient,
+			ExcFunc: func(ctx context.Context) any {
+				uo := orderbus.UpdateOrder{
+					Items: []orderbus.NewItem{
+						{ProductID: prd.ID, Quantity: quantity.MustParse(100)},
+					},
+				}
+
+				ord, err := busDomain.Order.Update(ctx, sd.Users[0].Orders[1], uo)
+				if err != nil {
+					return err
+				}
+
+				if _, err := busDomain.Order.UpdateStatus(ctx, ord, orderstatus.Placed); err != nil {
+					return err
+				}
+
+				return nil
+			},
+			CmpFunc: func(got any, exp any) string {
+				if err, ok := got.(error); ok && errors.Is(err, exp.(error)) {
+					return ""
+				}
+
+				return fmt.Sprintf("expected error %v, got %v", exp, got)
+			},
+		},
+	}
+
+	return table
+}
+
+func delete(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
+	table := []unitest.Table{
+		{
+			Name:    "draft",
+			ExpResp: nil,
+			ExcFunc: func(ctx context.Context) any {
+				if err := busDomain.Order.Delete(ctx, sd.Users[0].Orders[2]); err != nil {
+					return err
+				}
+
+				return nil
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+func concurrentStatus(db *dbtest.Database, sd unitest.SeedData) []unitest.Table {
+	prd := sd.Users[0].Products[0]
+	ord := sd.Users[0].Orders[3]
+
+	// moveAll makes the same transition from several transactions at once
+	// and returns how many of them succeeded.
+	moveAll := func(ctx context.Context, status orderstatus.Status) (int, error) {
+		const n = 5
+
+		var wg sync.WaitGroup
+		errCh := make(chan error, n)
+
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				tx, err := sqldb.NewBeginner(db.DB).Begin()
+				if err != nil {
+					errCh <- err
+					return
+				}
+
+				bus, err := db.BusDomain.Order.NewWithTx(tx)
+				if err != nil {
+					tx.Rollback()
+					errCh <- err
+					return
+				}
+
+				if _, err := bus.UpdateStatus(ctx, ord, status); err != nil {
+					tx.Rollback()
+					errCh <- err
+					return
+				}
+
+				errCh <- tx.Commit()
+			}()
+		}
+
+		wg.Wait()
+		close(errCh)
+
+		var moved int
+		for err := range errCh {
+			switch {
+			case err == nil:
+				moved++
+			case !errors.Is(err, orderbus.ErrInvalidTransition):
+				return 0, err
+			}
+		}
+
+		return moved, nil
+	}
+
+	type result struct {
+		Moved    int
+		Quantity int
+	}
+
+	table := []unitest.Table{
+		{
+			Name:    "place",
+			ExpResp: result{Moved: 1, Quantity: -1},
+			ExcFunc: func(ctx context.Context) any {
+				before, err := db.BusDomain.Product.QueryByID(ctx, prd.ID)
+				if err != nil {
+					return err
+				}
+
+				moved, err := moveAll(ctx, orderstatus.Placed)
+				if err != nil {
+					return err
+				}
+
+				after, err := db.BusDomain.Product.QueryByID(ctx, prd.ID)
+				if err != nil {
+					return err
+				}
+
+				return result{Moved: moved, Quantity: after.Quantity.Value() - before.Quantity.Value()}
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+		{
+			Name:    "cancel",
+			ExpResp: result{Moved: 1, Quantity: 1},
+			ExcFunc: func(ctx context.Context) any {
+				before, err := db.BusDomain.Product.QueryByID(ctx, prd.ID)
+				if err != nil {
+					return err
+				}
+
+				moved, err := moveAll(ctx, orderstatus.Cancelled)
+				if err != nil {
+					return err
+				}
+
+				after, err := db.BusDomain.Product.QueryByID(ctx, prd.ID)
+				if err != nil {
+					return err
+				}
+
+				return result{Moved: moved, Quantity: after.Quantity.Value() - before.Quantity.Value()}
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
@@ -0,0 +1,48 @@
+package orderdb
+
+import (
+	"bytes"
+
+	"github.com/rmsj/service/business/domain/orderbus"
+	"github.com/rmsj/service/business/sdk/sqldb"
+)
+
+var filterByFields = map[string]string{
+	orderbus.FilterByOrderID:     "order_id",
+	orderbus.FilterByUserID:      "user_id",
+	orderbus.FilterByStatus:      "status",
+	orderbus.FilterByDateCreated: "created_at",
+	orderbus.FilterByDateUpdated: "updated_at",
+}
+
+func applyFilter(filter orderbus.QueryFilter, data map[string]any, buf *bytes.Buffer) error {
+	w := sqldb.NewWhere(data)
+
+	if filter.ID != nil {
+		w.Add("order_id = " + w.Bind(filter.ID))
+	}
+
+	if filter.UserID != nil {
+		w.Add("user_id = " + w.Bind(filter.UserID))
+	}
+
+	if filter.Status != nil {
+		w.Add("status = " + w.Bind(filter.Status.String()))
+	}
+
+	if filter.StartCreatedDate != nil {
+		w.Add("created_at >= " + w.Bind(*filter.StartCreatedDate))
+	}
+
+	if filter.EndCreatedDate != nil {
+		w.Add("created_at <= " + w.Bind(*filter.EndCreatedDate))
+	}
+
+	if err := w.AddExpr(filter.Expr, filterByFields); err != nil {
+		return err
+	}
+
+	buf.WriteString(w.String())
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package orderdb
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rmsj/service/business/domain/orderbus"
+	"github.com/rmsj/service/business/types/money"
+	"github.com/rmsj/service/business/types/orderstatus"
+	"github.com/rmsj/service/business/types/quantity"
+)
+
+type dbOrder struct {
+	ID          uuid.UUID `db:"order_id"`
+	UserID      uuid.UUID `db:"user_id"`
+	Status      string    `db:"status"`
+	DateCreated time.Time `db:"created_at"`
+	DateUpdated time.Time `db:"updated_at"`
+}
+
+type dbItem struct {
+	OrderID   uuid.UUID `db:"order_id"`
+	ProductID uuid.UUID `db:"product_id"`
+	Quantity  int       `db:"quantity"`
+	Price     string    `db:"price"`
+	Currency  string    `db:"currency"`
+}
+
+func toDBOrder(bus orderbus.Order) dbOrder {
+	db := dbOrder{
+		ID:          bus.ID,
+		UserID:      bus.UserID,
+		Status:      bus.Status.String(),
+		DateCreated: bus.DateCreated.UTC(),
+		DateUpdated: bus.DateUpdated.UTC(),
+	}
+
+	return db
+}
+
+func toDBItems(bus orderbus.Order) []dbItem {
+	db := make([]dbItem, len(bus.Items))
+	for i, itm := range bus.Items {
+		db[i] = dbItem{
+			OrderID:   bus.ID,
+			ProductID: itm.ProductID,
+			Quantity:  itm.Quantity.Value(),
+			Price:     itm.Price.String(),
+			Currency:  itm.Price.Currency().Code(),
+		}
+	}
+
+	return db
+}
+
+func toBusOrder(db dbOrder, dbItems []dbItem) (orderbus.Order, error) {
+	status, err := orderstatus.Parse(db.Status)
+	if err != nil {
+		return orderbus.Order{}, fmt.Errorf("parse status: %w", err)
+	}
+
+	items := make([]orderbus.Item, len(dbItems))
+	for i, dbItm := range dbItems {
+		qty, err := quantity.Parse(dbItm.Quantity)
+		if err != nil {
+			return orderbus.Order{}, fmt.Errorf("parse quantity: %w", err)
+		}
+
+		price, err := money.Parse(dbItm.Price, dbItm.Currency)
+		if err != nil {
+			return orderbus.Order{}, fmt.Errorf("parse price: %w", err)
+		}
+
+		items[i] = orderbus.Item{
+			ProductID: dbItm.ProductID,
+			Quantity:  qty,
+			Price:     price,
+		}
+	}
+
+	bus := orderbus.Order{
+		ID:          db.ID,
+		UserID:      db.UserID,
+		Status:      status,
+		Items:       items,
+		DateCreated: db.DateCreated.In(time.Local),
+		DateUpdated: db.DateUpdated.In(time.Local),
+	}
+
+	return bus, nil
+}
+
+func toBusOrders(dbs []dbOrder, dbItems []dbItem) ([]orderbus.Order, error) {
+	byOrder := make(map[uuid.UUID][]dbItem, len(dbs))
+	for _, dbItm := range dbItems {
+		byOrder[dbItm.OrderID] = append(byOrder[dbItm.OrderID], dbItm)
+	}
+
+	bus := make([]orderbus.Order, len(dbs))
+
+	for i, db := range dbs {
+		var err error
+		bus[i], err = toBusOrder(db, byOrder[db.ID])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bus, nil
+}
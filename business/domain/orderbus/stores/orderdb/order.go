@@ -0,0 +1,24 @@
+package orderdb
+
+import (
+	"fmt"
+
+	"github.com/rmsj/service/business/domain/orderbus"
+	"github.com/rmsj/service/business/sdk/order"
+)
+
+var orderByFields = map[string]string{
+	orderbus.OrderByOrderID:     "order_id",
+	orderbus.OrderByUserID:      "user_id",
+	orderbus.OrderByStatus:      "status",
+	orderbus.OrderByDateCreated: "created_at",
+}
+
+func orderByClause(orderBy order.By) (string, error) {
+	by, exists := orderByFields[orderBy.Field]
+	if !exists {
+		return "", fmt.Errorf("field %q does not exist", orderBy.Field)
+	}
+
+	return " ORDER BY " + by + " " + orderBy.Direction, nil
+}
@@ -0,0 +1,320 @@
+// Package orderdb contains order related CRUD functionality.
+package orderdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/rmsj/service/business/domain/orderbus"
+	"github.com/rmsj/service/business/sdk/order"
+	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/foundation/logger"
+)
+
+// Store manages the set of APIs for order database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// NewWithTx constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (orderbus.Storer, error) {
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return &store, nil
+}
+
+// Create adds an Order and its line items to the sqldb.
+func (s *Store) Create(ctx context.Context, ord orderbus.Order) error {
+	const q = `
+	INSERT INTO orders
+		(order_id, user_id, status, created_at, updated_at)
+	VALUES
+		(:order_id, :user_id, :status, :created_at, :updated_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBOrder(ord)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	if err := s.createItems(ctx, ord); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Update modifies data about an order, replacing its line items. It will
+// error if the specified ID is invalid or does not reference an existing order.
+func (s *Store) Update(ctx context.Context, ord orderbus.Order) error {
+	const q = `
+	UPDATE
+		orders
+	SET
+		status = :status,
+		updated_at = :updated_at
+	WHERE
+		order_id = :order_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBOrder(ord)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	if err := s.deleteItems(ctx, ord.ID); err != nil {
+		return err
+	}
+
+	if err := s.createItems(ctx, ord); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Delete removes the order identified by a given ID. The line items are
+// removed by the foreign key cascade.
+func (s *Store) Delete(ctx context.Context, ord orderbus.Order) error {
+	data := struct {
+		ID string `db:"order_id"`
+	}{
+		ID: ord.ID.String(),
+	}
+
+	const q = `
+	DELETE FROM
+		orders
+	WHERE
+		order_id = :order_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Query gets all Orders from the database.
+func (s *Store) Query(ctx context.Context, filter orderbus.QueryFilter, orderBy order.By, page page.Page) ([]orderbus.Order, error) {
+	data := map[string]any{
+		"offset":        (page.Number() - 1) * page.RowsPerPage(),
+		"rows_per_page": page.RowsPerPage(),
+	}
+
+	const q = `
+	SELECT
+	    order_id, user_id, status, created_at, updated_at
+	FROM
+		orders`
+
+	buf := bytes.NewBufferString(q)
+	if err := applyFilter(filter, data, buf); err != nil {
+		return nil, err
+	}
+
+	orderByClause, err := orderByClause(orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" LIMIT :rows_per_page OFFSET :offset")
+
+	var dbOrds []dbOrder
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbOrds); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	dbItems, err := s.queryItems(ctx, dbOrds)
+	if err != nil {
+		return nil, err
+	}
+
+	return toBusOrders(dbOrds, dbItems)
+}
+
+// Count returns the total number of orders in the DB.
+func (s *Store) Count(ctx context.Context, filter orderbus.QueryFilter) (int, error) {
+	data := map[string]any{}
+
+	const q = "SELECT COUNT(order_id) AS `count` FROM orders"
+
+	buf := bytes.NewBufferString(q)
+	if err := applyFilter(filter, data, buf); err != nil {
+		return 0, err
+	}
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// QueryByID finds the order identified by a given ID.
+func (s *Store) QueryByID(ctx context.Context, orderID uuid.UUID) (orderbus.Order, error) {
+	return s.queryByID(ctx, orderID, "")
+}
+
+// QueryByIDForUpdate finds the order identified by a given ID and locks it
+// until the transaction the store is in ends, so concurrent changes to the
+// order are made one after the other.
+func (s *Store) QueryByIDForUpdate(ctx context.Context, orderID uuid.UUID) (orderbus.Order, error) {
+	return s.queryByID(ctx, orderID, " FOR UPDATE")
+}
+
+// QueryByUserID finds the orders identified by a given User ID.
+func (s *Store) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]orderbus.Order, error) {
+	data := struct {
+		ID string `db:"user_id"`
+	}{
+		ID: userID.String(),
+	}
+
+	const q = `
+	SELECT
+	    order_id, user_id, status, created_at, updated_at
+	FROM
+		orders
+	WHERE
+		user_id = :user_id`
+
+	var dbOrds []dbOrder
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbOrds); err != nil {
+		return nil, fmt.Errorf("db: %w", err)
+	}
+
+	dbItems, err := s.queryItems(ctx, dbOrds)
+	if err != nil {
+		return nil, err
+	}
+
+	return toBusOrders(dbOrds, dbItems)
+}
+
+// =============================================================================
+
+func (s *Store) queryByID(ctx context.Context, orderID uuid.UUID, lock string) (orderbus.Order, error) {
+	data := struct {
+		ID string `db:"order_id"`
+	}{
+		ID: orderID.String(),
+	}
+
+	const q = `
+	SELECT
+	    order_id, user_id, status, created_at, updated_at
+	FROM
+		orders
+	WHERE
+		order_id = :order_id`
+
+	var dbOrd dbOrder
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q+lock, data, &dbOrd); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return orderbus.Order{}, fmt.Errorf("db: %w", orderbus.ErrNotFound)
+		}
+		return orderbus.Order{}, fmt.Errorf("db: %w", err)
+	}
+
+	dbItems, err := s.queryItems(ctx, []dbOrder{dbOrd})
+	if err != nil {
+		return orderbus.Order{}, err
+	}
+
+	return toBusOrder(dbOrd, dbItems)
+}
+
+func (s *Store) createItems(ctx context.Context, ord orderbus.Order) error {
+	const q = `
+	INSERT INTO order_items
+		(order_id, product_id, quantity, price, currency)
+	VALUES
+		(:order_id, :product_id, :quantity, :price, :currency)`
+
+	for _, dbItm := range toDBItems(ord) {
+		if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, dbItm); err != nil {
+			return fmt.Errorf("namedexeccontext: item: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) deleteItems(ctx context.Context, orderID uuid.UUID) error {
+	data := struct {
+		ID string `db:"order_id"`
+	}{
+		ID: orderID.String(),
+	}
+
+	const q = `
+	DELETE FROM
+		order_items
+	WHERE
+		order_id = :order_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: items: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) queryItems(ctx context.Context, dbOrds []dbOrder) ([]dbItem, error) {
+	if len(dbOrds) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, len(dbOrds))
+	for i, dbOrd := range dbOrds {
+		ids[i] = dbOrd.ID.String()
+	}
+
+	data := struct {
+		IDs []string `db:"order_ids"`
+	}{
+		IDs: ids,
+	}
+
+	const q = `
+	SELECT
+	    order_id, product_id, quantity, price, currency
+	FROM
+		order_items
+	WHERE
+		order_id IN (:order_ids)
+	ORDER BY
+		product_id`
+
+	var dbItems []dbItem
+	if err := sqldb.NamedQuerySliceUsingIn(ctx, s.log, s.db, q, data, &dbItems); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: items: %w", err)
+	}
+
+	return dbItems, nil
+}
@@ -0,0 +1,51 @@
+package orderbus
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+
+	"github.com/google/uuid"
+	"github.com/rmsj/service/business/domain/productbus"
+	"github.com/rmsj/service/business/types/quantity"
+)
+
+// TestGenerateNewOrders is a helper method for testing.
+func TestGenerateNewOrders(n int, userID uuid.UUID, prds []productbus.Product) []NewOrder {
+	newOrds := make([]NewOrder, n)
+
+	for i := range n {
+		prd := prds[rand.Intn(len(prds))]
+
+		no := NewOrder{
+			UserID: userID,
+			Items: []NewItem{
+				{
+					ProductID: prd.ID,
+					Quantity:  quantity.MustParse(1),
+				},
+			},
+		}
+
+		newOrds[i] = no
+	}
+
+	return newOrds
+}
+
+// TestGenerateSeedOrders is a helper method for testing.
+func TestGenerateSeedOrders(ctx context.Context, n int, api *Business, userID uuid.UUID, prds []productbus.Product) ([]Order, error) {
+	newOrds := TestGenerateNewOrders(n, userID, prds)
+
+	ords := make([]Order, len(newOrds))
+	for i, no := range newOrds {
+		ord, err := api.Create(ctx, no)
+		if err != nil {
+			return nil, fmt.Errorf("seeding order: idx: %d : %w", i, err)
+		}
+
+		ords[i] = ord
+	}
+
+	return ords, nil
+}
@@ -5,16 +5,19 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/google/uuid"
+
 	"github.com/rmsj/service/business/domain/userbus"
 	"github.com/rmsj/service/business/sdk/delegate"
 )
 
 // registerDelegateFunctions will register action functions with the delegate
 // system. If the business was constructed for query only, there won't be a
-// delegate provided.
+// delegate provided. The user deleted action goes through the outbox so it
+// isn't lost if the process dies after the user is removed.
 func (b *Business) registerDelegateFunctions() {
 	if b.delegate != nil {
-		b.delegate.Register(userbus.DomainName, userbus.ActionDeleted, b.actionUserDeleted)
+		b.delegate.RegisterAsync(userbus.DomainName, userbus.ActionDeleted, b.actionUserDeleted)
 	}
 }
 
@@ -32,3 +35,64 @@ func (b *Business) actionUserDeleted(ctx context.Context, data delegate.Data) er
 
 	return nil
 }
+
+// =============================================================================
+
+// DomainName represents the name of this domain.
+const DomainName = "product"
+
+// Set of delegate actions.
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
+)
+
+// ActionParms represents the parameters for the product actions.
+type ActionParms struct {
+	ProductID uuid.UUID
+	UserID    uuid.UUID
+}
+
+// String returns a string representation of the action parameters.
+func (act *ActionParms) String() string {
+	return fmt.Sprintf("&EventParams{ProductID:%v, UserID:%v}", act.ProductID, act.UserID)
+}
+
+// Marshal returns the event parameters encoded as JSON.
+func (act *ActionParms) Marshal() ([]byte, error) {
+	return json.Marshal(act)
+}
+
+// ActionCreatedData constructs the data for the created action.
+func ActionCreatedData(prd Product) delegate.Data {
+	return actionData(ActionCreated, prd)
+}
+
+// ActionUpdatedData constructs the data for the updated action.
+func ActionUpdatedData(prd Product) delegate.Data {
+	return actionData(ActionUpdated, prd)
+}
+
+// ActionDeletedData constructs the data for the deleted action.
+func ActionDeletedData(prd Product) delegate.Data {
+	return actionData(ActionDeleted, prd)
+}
+
+func actionData(action string, prd Product) delegate.Data {
+	params := ActionParms{
+		ProductID: prd.ID,
+		UserID:    prd.UserID,
+	}
+
+	rawParams, err := params.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return delegate.Data{
+		Domain:    DomainName,
+		Action:    action,
+		RawParams: rawParams,
+	}
+}
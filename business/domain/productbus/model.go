@@ -16,6 +16,7 @@ type Product struct {
 	Name        name.Name
 	Cost        money.Money
 	Quantity    quantity.Quantity
+	Version     int
 	DateCreated time.Time
 	DateUpdated time.Time
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/rmsj/service/business/sdk/order"
 	"github.com/rmsj/service/business/sdk/page"
 	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/business/types/quantity"
 	"github.com/rmsj/service/foundation/logger"
 	"github.com/rmsj/service/foundation/otel"
 )
@@ -23,6 +24,8 @@ var (
 	ErrNotFound     = errors.New("product not found")
 	ErrUserDisabled = errors.New("user disabled")
 	ErrInvalidCost  = errors.New("cost not valid")
+	ErrInsufficient = errors.New("insufficient quantity")
+	ErrConflict     = errors.New("product changed since it was loaded")
 )
 
 // Storer interface declares the behavior this package needs to persist and
@@ -36,6 +39,8 @@ type Storer interface {
 	Count(ctx context.Context, filter QueryFilter) (int, error)
 	QueryByID(ctx context.Context, productID uuid.UUID) (Product, error)
 	QueryByUserID(ctx context.Context, userID uuid.UUID) ([]Product, error)
+	DecreaseQuantity(ctx context.Context, productID uuid.UUID, qty quantity.Quantity, now time.Time) error
+	IncreaseQuantity(ctx context.Context, productID uuid.UUID, qty quantity.Quantity, now time.Time) error
 }
 
 // Business manages the set of APIs for product access.
@@ -73,10 +78,15 @@ func (b *Business) NewWithTx(tx sqldb.CommitRollbacker) (*Business, error) {
 		return nil, err
 	}
 
+	dlg, err := b.delegate.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
 	bus := Business{
 		log:      b.log,
 		userBus:  userBus,
-		delegate: b.delegate,
+		delegate: dlg,
 		storer:   storer,
 	}
 
@@ -105,6 +115,7 @@ func (b *Business) Create(ctx context.Context, np NewProduct) (Product, error) {
 		Cost:        np.Cost,
 		Quantity:    np.Quantity,
 		UserID:      np.UserID,
+		Version:     1,
 		DateCreated: now,
 		DateUpdated: now,
 	}
@@ -113,10 +124,18 @@ func (b *Business) Create(ctx context.Context, np NewProduct) (Product, error) {
 		return Product{}, fmt.Errorf("create: %w", err)
 	}
 
+	if b.delegate != nil {
+		if err := b.delegate.Call(ctx, ActionCreatedData(prd)); err != nil {
+			return Product{}, fmt.Errorf("failed to execute `%s` action: %w", ActionCreated, err)
+		}
+	}
+
 	return prd, nil
 }
 
-// Update modifies information about a product.
+// Update modifies information about a product. Every update moves the
+// product to a new version, and ErrConflict is returned when the product
+// changed since it was loaded.
 func (b *Business) Update(ctx context.Context, prd Product, up UpdateProduct) (Product, error) {
 	ctx, span := otel.AddSpan(ctx, "business.productbus.update")
 	defer span.End()
@@ -133,12 +152,19 @@ func (b *Business) Update(ctx context.Context, prd Product, up UpdateProduct) (P
 		prd.Quantity = *up.Quantity
 	}
 
+	prd.Version++
 	prd.DateUpdated = time.Now()
 
 	if err := b.storer.Update(ctx, prd); err != nil {
 		return Product{}, fmt.Errorf("update: %w", err)
 	}
 
+	if b.delegate != nil {
+		if err := b.delegate.Call(ctx, ActionUpdatedData(prd)); err != nil {
+			return Product{}, fmt.Errorf("failed to execute `%s` action: %w", ActionUpdated, err)
+		}
+	}
+
 	return prd, nil
 }
 
@@ -151,6 +177,12 @@ func (b *Business) Delete(ctx context.Context, prd Product) error {
 		return fmt.Errorf("delete: %w", err)
 	}
 
+	if b.delegate != nil {
+		if err := b.delegate.Call(ctx, ActionDeletedData(prd)); err != nil {
+			return fmt.Errorf("failed to execute `%s` action: %w", ActionDeleted, err)
+		}
+	}
+
 	return nil
 }
 
@@ -200,3 +232,29 @@ func (b *Business) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]Produ
 
 	return prds, nil
 }
+
+// DecreaseQuantity removes the specified quantity from the stock of a product.
+// If the product doesn't have enough stock, ErrInsufficient is returned and
+// the stock is left untouched.
+func (b *Business) DecreaseQuantity(ctx context.Context, productID uuid.UUID, qty quantity.Quantity) error {
+	ctx, span := otel.AddSpan(ctx, "business.productbus.decreasequantity")
+	defer span.End()
+
+	if err := b.storer.DecreaseQuantity(ctx, productID, qty, time.Now()); err != nil {
+		return fmt.Errorf("decreasequantity: productID[%s] qty[%s]: %w", productID, qty, err)
+	}
+
+	return nil
+}
+
+// IncreaseQuantity returns the specified quantity to the stock of a product.
+func (b *Business) IncreaseQuantity(ctx context.Context, productID uuid.UUID, qty quantity.Quantity) error {
+	ctx, span := otel.AddSpan(ctx, "business.productbus.increasequantity")
+	defer span.End()
+
+	if err := b.storer.IncreaseQuantity(ctx, productID, qty, time.Now()); err != nil {
+		return fmt.Errorf("increasequantity: productID[%s] qty[%s]: %w", productID, qty, err)
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package productbus_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"testing"
@@ -174,14 +175,15 @@ func create(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
 			ExpResp: productbus.Product{
 				UserID:   sd.Users[0].ID,
 				Name:     name.MustParse("Guitar"),
-				Cost:     money.MustParse(10.34),
+				Cost:     money.MustParse("10.34", "USD"),
 				Quantity: quantity.MustParse(10),
+				Version:  1,
 			},
 			ExcFunc: func(ctx context.Context) any {
 				np := productbus.NewProduct{
 					UserID:   sd.Users[0].ID,
 					Name:     name.MustParse("Guitar"),
-					Cost:     money.MustParse(10.34),
+					Cost:     money.MustParse("10.34", "USD"),
 					Quantity: quantity.MustParse(10),
 				}
 
@@ -220,15 +222,16 @@ func update(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
 				ID:          sd.Users[0].Products[0].ID,
 				UserID:      sd.Users[0].ID,
 				Name:        name.MustParse("Guitar"),
-				Cost:        money.MustParse(10.34),
+				Cost:        money.MustParse("10.34", "USD"),
 				Quantity:    quantity.MustParse(10),
+				Version:     sd.Users[0].Products[0].Version + 1,
 				DateCreated: sd.Users[0].Products[0].DateCreated,
 				DateUpdated: sd.Users[0].Products[0].DateCreated,
 			},
 			ExcFunc: func(ctx context.Context) any {
 				up := productbus.UpdateProduct{
 					Name:     dbtest.NamePointer("Guitar"),
-					Cost:     dbtest.MoneyPointer(10.34),
+					Cost:     dbtest.MoneyPointer("10.34", "USD"),
 					Quantity: dbtest.QuantityPointer(10),
 				}
 
@@ -252,6 +255,27 @@ func update(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
 				return cmp.Diff(gotResp, expResp)
 			},
 		},
+		{
+			Name:    "stale",
+			ExpResp: productbus.ErrConflict,
+			ExcFunc: func(ctx context.Context) any {
+				up := productbus.UpdateProduct{
+					Name: dbtest.NamePointer("Drums"),
+				}
+
+				// the product was updated by the previous test, so the copy
+				// in the seed data is on an old version
+				_, err := busDomain.Product.Update(ctx, sd.Users[0].Products[0], up)
+				return err
+			},
+			CmpFunc: func(got any, exp any) string {
+				err, ok := got.(error)
+				if !ok || !errors.Is(err, exp.(error)) {
+					return fmt.Sprintf("got %v, exp %v", got, exp)
+				}
+				return ""
+			},
+		},
 	}
 
 	return table
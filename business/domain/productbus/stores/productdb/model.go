@@ -15,8 +15,10 @@ type product struct {
 	ID          uuid.UUID `db:"product_id"`
 	UserID      uuid.UUID `db:"user_id"`
 	Name        string    `db:"name"`
-	Cost        float64   `db:"cost"`
+	Cost        string    `db:"cost"`
+	Currency    string    `db:"currency"`
 	Quantity    int       `db:"quantity"`
+	Version     int       `db:"version"`
 	DateCreated time.Time `db:"created_at"`
 	DateUpdated time.Time `db:"updated_at"`
 }
@@ -26,8 +28,10 @@ func toDBProduct(bus productbus.Product) product {
 		ID:          bus.ID,
 		UserID:      bus.UserID,
 		Name:        bus.Name.String(),
-		Cost:        bus.Cost.Value(),
+		Cost:        bus.Cost.String(),
+		Currency:    bus.Cost.Currency().Code(),
 		Quantity:    bus.Quantity.Value(),
+		Version:     bus.Version,
 		DateCreated: bus.DateCreated.UTC(),
 		DateUpdated: bus.DateUpdated.UTC(),
 	}
@@ -41,7 +45,7 @@ func toBusProduct(db product) (productbus.Product, error) {
 		return productbus.Product{}, fmt.Errorf("parse name: %w", err)
 	}
 
-	cost, err := money.Parse(db.Cost)
+	cost, err := money.Parse(db.Cost, db.Currency)
 	if err != nil {
 		return productbus.Product{}, fmt.Errorf("parse cost: %w", err)
 	}
@@ -57,6 +61,7 @@ func toBusProduct(db product) (productbus.Product, error) {
 		Name:        name,
 		Cost:        cost,
 		Quantity:    quantity,
+		Version:     db.Version,
 		DateCreated: db.DateCreated.In(time.Local),
 		DateUpdated: db.DateUpdated.In(time.Local),
 	}
@@ -6,6 +6,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -14,6 +16,7 @@ import (
 	"github.com/rmsj/service/business/sdk/order"
 	"github.com/rmsj/service/business/sdk/page"
 	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/business/types/quantity"
 	"github.com/rmsj/service/foundation/logger"
 )
 
@@ -52,9 +55,9 @@ func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (productbus.Storer, error)
 func (s *Store) Create(ctx context.Context, prd productbus.Product) error {
 	const q = `
 	INSERT INTO products
-		(product_id, user_id, name, cost, quantity, created_at, updated_at)
+		(product_id, user_id, name, cost, currency, quantity, version, created_at, updated_at)
 	VALUES
-		(:product_id, :user_id, :name, :cost, :quantity, :created_at, :updated_at)`
+		(:product_id, :user_id, :name, :cost, :currency, :quantity, :version, :created_at, :updated_at)`
 
 	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBProduct(prd)); err != nil {
 		return fmt.Errorf("namedexeccontext: %w", err)
@@ -63,8 +66,9 @@ func (s *Store) Create(ctx context.Context, prd productbus.Product) error {
 	return nil
 }
 
-// Update modifies data about a productbus. It will error if the specified ID is
-// invalid or does not reference an existing productbus.
+// Update modifies data about a productbus. The product is moved to its new
+// version only if it's still on the one before, otherwise ErrConflict is
+// returned.
 func (s *Store) Update(ctx context.Context, prd productbus.Product) error {
 	const q = `
 	UPDATE
@@ -72,13 +76,21 @@ func (s *Store) Update(ctx context.Context, prd productbus.Product) error {
 	SET
 		name = :name,
 		cost = :cost,
+		currency = :currency,
 		quantity = :quantity,
+		version = :version,
 		updated_at = :updated_at
 	WHERE
-		product_id = :product_id`
+		product_id = :product_id AND
+		version = :version - 1`
 
-	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBProduct(prd)); err != nil {
-		return fmt.Errorf("namedexeccontext: %w", err)
+	count, err := sqldb.NamedExecContextWithCount(ctx, s.log, s.db, q, toDBProduct(prd))
+	if err != nil {
+		return fmt.Errorf("namedexeccontextwithcount: %w", err)
+	}
+
+	if count == 0 {
+		return fmt.Errorf("update: %w", productbus.ErrConflict)
 	}
 
 	return nil
@@ -108,24 +120,39 @@ func (s *Store) Delete(ctx context.Context, prd productbus.Product) error {
 // Query gets all Products from the database.
 func (s *Store) Query(ctx context.Context, filter productbus.QueryFilter, orderBy order.By, page page.Page) ([]productbus.Product, error) {
 	data := map[string]any{
-		"offset":        (page.Number() - 1) * page.RowsPerPage(),
+		"offset":        page.Offset(),
 		"rows_per_page": page.RowsPerPage(),
 	}
 
 	const q = `
 	SELECT
-	    product_id, user_id, name, cost, quantity, created_at, updated_at
+	    product_id, user_id, name, cost, currency, quantity, version, created_at, updated_at
 	FROM
 		products`
 
-	buf := bytes.NewBufferString(q)
-	s.applyFilter(filter, data, buf)
-
 	orderByClause, err := orderByClause(orderBy)
 	if err != nil {
 		return nil, err
 	}
 
+	var seek []string
+
+	cursor, ok := page.Cursor()
+	if ok {
+		var where string
+		where, orderByClause, err = seekClause(cursor, data)
+		if err != nil {
+			return nil, err
+		}
+
+		seek = append(seek, where)
+	}
+
+	buf := bytes.NewBufferString(q)
+	if err := s.applyFilter(filter, data, buf, seek...); err != nil {
+		return nil, err
+	}
+
 	buf.WriteString(orderByClause)
 	buf.WriteString(" LIMIT :rows_per_page OFFSET :offset")
 
@@ -134,6 +161,11 @@ func (s *Store) Query(ctx context.Context, filter productbus.QueryFilter, orderB
 		return nil, fmt.Errorf("namedqueryslice: %w", err)
 	}
 
+	// The rows before the cursor are read backward, nearest first.
+	if cursor.Backward {
+		slices.Reverse(dbPrds)
+	}
+
 	return toBusProducts(dbPrds)
 }
 
@@ -144,7 +176,9 @@ func (s *Store) Count(ctx context.Context, filter productbus.QueryFilter) (int,
 	const q = "SELECT COUNT(product_id) AS `count` FROM products"
 
 	buf := bytes.NewBufferString(q)
-	s.applyFilter(filter, data, buf)
+	if err := s.applyFilter(filter, data, buf); err != nil {
+		return 0, err
+	}
 
 	var count struct {
 		Count   int `db:"count"`
@@ -168,7 +202,7 @@ func (s *Store) QueryByID(ctx context.Context, productID uuid.UUID) (productbus.
 
 	const q = `
 	SELECT
-	    product_id, user_id, name, cost, quantity, created_at, updated_at
+	    product_id, user_id, name, cost, currency, quantity, version, created_at, updated_at
 	FROM
 		products
 	WHERE
@@ -195,7 +229,7 @@ func (s *Store) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]productb
 
 	const q = `
 	SELECT
-	    product_id, user_id, name, cost, quantity, created_at, updated_at
+	    product_id, user_id, name, cost, currency, quantity, version, created_at, updated_at
 	FROM
 		products
 	WHERE
@@ -208,3 +242,73 @@ func (s *Store) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]productb
 
 	return toBusProducts(dbPrds)
 }
+
+// DecreaseQuantity removes stock from the product identified by a given ID. The
+// update only happens if the product has enough stock to cover the request.
+func (s *Store) DecreaseQuantity(ctx context.Context, productID uuid.UUID, qty quantity.Quantity, now time.Time) error {
+	data := struct {
+		ID        string    `db:"product_id"`
+		Quantity  int       `db:"quantity"`
+		UpdatedAt time.Time `db:"updated_at"`
+	}{
+		ID:        productID.String(),
+		Quantity:  qty.Value(),
+		UpdatedAt: now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		products
+	SET
+		quantity = quantity - :quantity,
+		version = version + 1,
+		updated_at = :updated_at
+	WHERE
+		product_id = :product_id AND
+		quantity >= :quantity`
+
+	count, err := sqldb.NamedExecContextWithCount(ctx, s.log, s.db, q, data)
+	if err != nil {
+		return fmt.Errorf("namedexeccontextwithcount: %w", err)
+	}
+
+	if count == 0 {
+		return fmt.Errorf("db: %w", productbus.ErrInsufficient)
+	}
+
+	return nil
+}
+
+// IncreaseQuantity adds stock to the product identified by a given ID.
+func (s *Store) IncreaseQuantity(ctx context.Context, productID uuid.UUID, qty quantity.Quantity, now time.Time) error {
+	data := struct {
+		ID        string    `db:"product_id"`
+		Quantity  int       `db:"quantity"`
+		UpdatedAt time.Time `db:"updated_at"`
+	}{
+		ID:        productID.String(),
+		Quantity:  qty.Value(),
+		UpdatedAt: now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		products
+	SET
+		quantity = quantity + :quantity,
+		version = version + 1,
+		updated_at = :updated_at
+	WHERE
+		product_id = :product_id`
+
+	count, err := sqldb.NamedExecContextWithCount(ctx, s.log, s.db, q, data)
+	if err != nil {
+		return fmt.Errorf("namedexeccontextwithcount: %w", err)
+	}
+
+	if count == 0 {
+		return fmt.Errorf("db: %w", productbus.ErrNotFound)
+	}
+
+	return nil
+}
@@ -21,7 +21,7 @@ func TestGenerateNewProducts(n int, userID uuid.UUID) []NewProduct {
 
 		np := NewProduct{
 			Name:     name.MustParse(fmt.Sprintf("Name%d", idx)),
-			Cost:     money.MustParse(float64(rand.Intn(500))),
+			Cost:     money.MustParse(fmt.Sprintf("%d.%02d", rand.Intn(500), rand.Intn(100)), money.DefaultCurrency.Code()),
 			Quantity: quantity.MustParse(rand.Intn(50)),
 			UserID:   userID,
 		}
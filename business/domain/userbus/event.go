@@ -3,6 +3,7 @@ package userbus
 import (
 	"encoding/json"
 	"fmt"
+	"net/mail"
 
 	"github.com/google/uuid"
 	"github.com/rmsj/service/business/sdk/delegate"
@@ -13,7 +14,8 @@ const DomainName = "user"
 
 // Set of delegate actions.
 const (
-	ActionDeleted = "deleted"
+	ActionDeleted      = "deleted"
+	ActionEmailChanged = "emailchanged"
 )
 
 // ActionDeletedParms represents the parameters for the deleted action.
@@ -48,3 +50,41 @@ func ActionDeletedData(userID uuid.UUID) delegate.Data {
 		RawParams: rawParams,
 	}
 }
+
+// =============================================================================
+
+// ActionEmailChangedParms represents the parameters for the email changed
+// action.
+type ActionEmailChangedParms struct {
+	UserID uuid.UUID
+	Email  string
+}
+
+// String returns a string representation of the action parameters.
+func (act *ActionEmailChangedParms) String() string {
+	return fmt.Sprintf("&EventParamsEmailChanged{UserID:%v, Email:%s}", act.UserID, act.Email)
+}
+
+// Marshal returns the event parameters encoded as JSON.
+func (act *ActionEmailChangedParms) Marshal() ([]byte, error) {
+	return json.Marshal(act)
+}
+
+// ActionEmailChangedData constructs the data for the email changed action.
+func ActionEmailChangedData(userID uuid.UUID, email mail.Address) delegate.Data {
+	params := ActionEmailChangedParms{
+		UserID: userID,
+		Email:  email.Address,
+	}
+
+	rawParams, err := params.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return delegate.Data{
+		Domain:    DomainName,
+		Action:    ActionEmailChanged,
+		RawParams: rawParams,
+	}
+}
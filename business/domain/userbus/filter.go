@@ -5,15 +5,30 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/rmsj/service/business/sdk/filter"
 	"github.com/rmsj/service/business/types/name"
 )
 
+// Set of fields that the filter expressions can use.
+const (
+	FilterByID            = "a"
+	FilterByName          = "b"
+	FilterByEmail         = "c"
+	FilterByDepartment    = "d"
+	FilterByEnabled       = "e"
+	FilterByEmailVerified = "f"
+	FilterByDateCreated   = "g"
+	FilterByDateUpdated   = "h"
+)
+
 // QueryFilter holds the available fields a query can be filtered on.
 // We are using pointer semantics because the With API mutates the value.
+// The expression is on the FilterBy fields.
 type QueryFilter struct {
 	ID               *uuid.UUID
 	Name             *name.Name
 	Email            *mail.Address
 	StartCreatedDate *time.Time
 	EndCreatedDate   *time.Time
+	Expr             filter.Expr
 }
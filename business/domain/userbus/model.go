@@ -12,29 +12,32 @@ import (
 
 // User represents information about an individual user.
 type User struct {
-	ID           uuid.UUID
-	Name         name.Name
-	Email        mail.Address
-	Mobile       string
-	ProfileImage string
-	Roles        []role.Role
-	PasswordHash []byte
-	Department   name.Null
-	Enabled      bool
-	RefreshToken string
-	DateCreated  time.Time
-	DateUpdated  time.Time
+	ID            uuid.UUID
+	Name          name.Name
+	Email         mail.Address
+	Mobile        string
+	ProfileImage  string
+	Roles         []role.Role
+	PasswordHash  []byte
+	Department    name.Null
+	Enabled       bool
+	EmailVerified bool
+	Version       int
+	DateCreated   time.Time
+	DateUpdated   time.Time
 }
 
-// NewUser contains information needed to create a new user.
+// NewUser contains information needed to create a new user. A user whose
+// email isn't verified can't log in until it is.
 type NewUser struct {
-	Name         name.Name
-	Email        mail.Address
-	Mobile       *string
-	ProfileImage *string
-	Roles        []role.Role
-	Department   name.Null
-	Password     string
+	Name          name.Name
+	Email         mail.Address
+	Mobile        *string
+	ProfileImage  *string
+	Roles         []role.Role
+	Department    name.Null
+	Password      string
+	EmailVerified bool
 }
 
 // UpdateUser contains information needed to update a user.
@@ -45,8 +48,8 @@ type UpdateUser struct {
 	ProfileImage    *string
 	Roles           []role.Role
 	Department      *name.Null
-	RefreshToken    *string
 	Password        *string
 	PasswordConfirm *string
 	Enabled         *bool
+	EmailVerified   *bool
 }
@@ -3,41 +3,52 @@ package userdb
 import (
 	"bytes"
 	"fmt"
-	"strings"
 
 	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/sdk/sqldb"
 )
 
-func applyFilter(filter userbus.QueryFilter, data map[string]any, buf *bytes.Buffer) {
-	var wc []string
+var filterByFields = map[string]string{
+	userbus.FilterByID:            "user_id",
+	userbus.FilterByName:          "name",
+	userbus.FilterByEmail:         "email",
+	userbus.FilterByDepartment:    "department",
+	userbus.FilterByEnabled:       "enabled",
+	userbus.FilterByEmailVerified: "email_verified",
+	userbus.FilterByDateCreated:   "created_at",
+	userbus.FilterByDateUpdated:   "updated_at",
+}
+
+func applyFilter(filter userbus.QueryFilter, data map[string]any, buf *bytes.Buffer, seek ...string) error {
+	w := sqldb.NewWhere(data)
 
 	if filter.ID != nil {
-		data["user_id"] = filter.ID
-		wc = append(wc, "user_id = :user_id")
+		w.Add("user_id = " + w.Bind(filter.ID))
 	}
 
 	if filter.Name != nil {
-		data["name"] = fmt.Sprintf("%%%s%%", filter.Name)
-		wc = append(wc, "name LIKE :name")
+		w.Add("name LIKE " + w.Bind(fmt.Sprintf("%%%s%%", filter.Name)))
 	}
 
 	if filter.Email != nil {
-		data["email"] = filter.Email.String()
-		wc = append(wc, "email = :email")
+		w.Add("email = " + w.Bind(filter.Email.String()))
 	}
 
 	if filter.StartCreatedDate != nil {
-		data["start_created_at"] = filter.StartCreatedDate.UTC()
-		wc = append(wc, "created_at >= :start_created_at")
+		w.Add("created_at >= " + w.Bind(*filter.StartCreatedDate))
 	}
 
 	if filter.EndCreatedDate != nil {
-		data["end_created_at"] = filter.EndCreatedDate.UTC()
-		wc = append(wc, "created_at <= :end_created_at")
+		w.Add("created_at <= " + w.Bind(*filter.EndCreatedDate))
 	}
 
-	if len(wc) > 0 {
-		buf.WriteString(" WHERE ")
-		buf.WriteString(strings.Join(wc, " AND "))
+	if err := w.AddExpr(filter.Expr, filterByFields); err != nil {
+		return err
 	}
+
+	w.Add(seek...)
+
+	buf.WriteString(w.String())
+
+	return nil
 }
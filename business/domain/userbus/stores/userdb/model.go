@@ -15,18 +15,19 @@ import (
 )
 
 type user struct {
-	ID           uuid.UUID      `db:"user_id"`
-	Name         string         `db:"name"`
-	Email        string         `db:"email"`
-	Mobile       sql.NullString `db:"mobile"`
-	ProfileImage sql.NullString `db:"profile_image"`
-	Roles        string         `db:"roles"`
-	PasswordHash []byte         `db:"password_hash"`
-	Department   sql.NullString `db:"department"`
-	Enabled      bool           `db:"enabled"`
-	RefreshToken sql.NullString `db:"refresh_token"`
-	DateCreated  time.Time      `db:"created_at"`
-	DateUpdated  time.Time      `db:"updated_at"`
+	ID            uuid.UUID      `db:"user_id"`
+	Name          string         `db:"name"`
+	Email         string         `db:"email"`
+	Mobile        sql.NullString `db:"mobile"`
+	ProfileImage  sql.NullString `db:"profile_image"`
+	Roles         string         `db:"roles"`
+	PasswordHash  []byte         `db:"password_hash"`
+	Department    sql.NullString `db:"department"`
+	Enabled       bool           `db:"enabled"`
+	EmailVerified bool           `db:"email_verified"`
+	Version       int            `db:"version"`
+	DateCreated   time.Time      `db:"created_at"`
+	DateUpdated   time.Time      `db:"updated_at"`
 }
 
 func toDBUser(bus userbus.User) user {
@@ -40,9 +41,11 @@ func toDBUser(bus userbus.User) user {
 			String: bus.Department.String(),
 			Valid:  bus.Department.Valid(),
 		},
-		Enabled:     bus.Enabled,
-		DateCreated: bus.DateCreated.UTC(),
-		DateUpdated: bus.DateUpdated.UTC(),
+		Enabled:       bus.Enabled,
+		EmailVerified: bus.EmailVerified,
+		Version:       bus.Version,
+		DateCreated:   bus.DateCreated.UTC(),
+		DateUpdated:   bus.DateUpdated.UTC(),
 	}
 }
 
@@ -67,15 +70,17 @@ func toBusUser(db user) (userbus.User, error) {
 	}
 
 	bus := userbus.User{
-		ID:           db.ID,
-		Name:         nme,
-		Email:        addr,
-		Roles:        roles,
-		PasswordHash: db.PasswordHash,
-		Enabled:      db.Enabled,
-		Department:   department,
-		DateCreated:  db.DateCreated.In(time.Local),
-		DateUpdated:  db.DateUpdated.In(time.Local),
+		ID:            db.ID,
+		Name:          nme,
+		Email:         addr,
+		Roles:         roles,
+		PasswordHash:  db.PasswordHash,
+		Enabled:       db.Enabled,
+		EmailVerified: db.EmailVerified,
+		Version:       db.Version,
+		Department:    department,
+		DateCreated:   db.DateCreated.In(time.Local),
+		DateUpdated:   db.DateUpdated.In(time.Local),
 	}
 
 	return bus, nil
@@ -2,9 +2,12 @@ package userdb
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/rmsj/service/business/domain/userbus"
 	"github.com/rmsj/service/business/sdk/order"
+	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/business/sdk/sqldb"
 )
 
 var orderByFields = map[string]string{
@@ -23,3 +26,58 @@ func orderByClause(orderBy order.By) (string, error) {
 
 	return " ORDER BY " + by + " " + orderBy.Direction, nil
 }
+
+// roleBits are the values of the roles SET column, in the order the column
+// declares them. A SET sorts by the sum of the values of its members.
+var roleBits = map[string]int{
+	"user":    1,
+	"staff":   2,
+	"manager": 4,
+	"support": 8,
+	"admin":   16,
+}
+
+// seekClause returns the predicate of the rows after the cursor and the
+// ordering to read them in.
+func seekClause(cursor page.Cursor, data map[string]any) (string, string, error) {
+	by, exists := orderByFields[cursor.OrderBy.Field]
+	if !exists {
+		return "", "", fmt.Errorf("field %q does not exist", cursor.OrderBy.Field)
+	}
+
+	// The roles sort by their numeric value, while comparing them as a
+	// string would skip or repeat rows, so they are sought by that value.
+	if cursor.OrderBy.Field == userbus.OrderByRoles {
+		value, err := rolesValue(cursor.Value)
+		if err != nil {
+			return "", "", err
+		}
+
+		by = "roles+0"
+		cursor.Value = value
+	}
+
+	where, orderBy := sqldb.Seek(cursor, by, "user_id", data)
+
+	return where, orderBy, nil
+}
+
+// rolesValue returns the numeric value of the roles in the cursor, as the
+// SET column stores them.
+func rolesValue(value any) (int, error) {
+	roles, ok := value.(string)
+	if !ok {
+		return 0, fmt.Errorf("roles %v are invalid", value)
+	}
+
+	var bits int
+	for r := range strings.SplitSeq(roles, ",") {
+		bit, exists := roleBits[r]
+		if !exists {
+			return 0, fmt.Errorf("role %q does not exist", r)
+		}
+		bits |= bit
+	}
+
+	return bits, nil
+}
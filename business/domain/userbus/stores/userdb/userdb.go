@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"net/mail"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -61,9 +62,9 @@ func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (userbus.Storer, error) {
 func (s *Store) Create(ctx context.Context, usr userbus.User) error {
 	const q = `
 	INSERT INTO users
-		(user_id, name, email, mobile, profile_image, password_hash, roles, department, enabled, created_at, updated_at)
+		(user_id, name, email, mobile, profile_image, password_hash, roles, department, enabled, email_verified, version, created_at, updated_at)
 	VALUES
-		(:user_id, :name, :email, :mobile, :profile_image, :password_hash, :roles, :department, :enabled, :created_at, :updated_at)`
+		(:user_id, :name, :email, :mobile, :profile_image, :password_hash, :roles, :department, :enabled, :email_verified, :version, :created_at, :updated_at)`
 
 	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBUser(usr)); err != nil {
 		if errors.Is(err, sqldb.ErrDBDuplicatedEntry) {
@@ -77,7 +78,9 @@ func (s *Store) Create(ctx context.Context, usr userbus.User) error {
 	return nil
 }
 
-// Update replaces a user document in the database.
+// Update replaces a user document in the database. The user is moved to its
+// new version only if it's still on the one before, otherwise ErrConflict is
+// returned.
 func (s *Store) Update(ctx context.Context, usr userbus.User) error {
 	const q = `
 	UPDATE
@@ -91,16 +94,27 @@ func (s *Store) Update(ctx context.Context, usr userbus.User) error {
 		password_hash = :password_hash,
 		department = :department,
 		enabled = :enabled,
+		email_verified = :email_verified,
+		version = :version,
 		updated_at = :updated_at
 	WHERE
-		user_id = :user_id`
+		user_id = :user_id AND
+		version = :version - 1`
 
-	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBUser(usr)); err != nil {
+	count, err := sqldb.NamedExecContextWithCount(ctx, s.log, s.db, q, toDBUser(usr))
+	if err != nil {
 		if errors.Is(err, sqldb.ErrDBDuplicatedEntry) {
 			return userbus.ErrUniqueEmail
 		}
-		return fmt.Errorf("namedexeccontext: %w", err)
+		return fmt.Errorf("namedexeccontextwithcount: %w", err)
 	}
+
+	// the cached user may be stale, it's dropped so the next read reloads it
+	if count == 0 {
+		s.deleteCache(usr)
+		return fmt.Errorf("update: %w", userbus.ErrConflict)
+	}
+
 	s.writeCache(usr)
 
 	return nil
@@ -125,24 +139,39 @@ func (s *Store) Delete(ctx context.Context, usr userbus.User) error {
 // Query retrieves a list of existing users from the database.
 func (s *Store) Query(ctx context.Context, filter userbus.QueryFilter, orderBy order.By, page page.Page) ([]userbus.User, error) {
 	data := map[string]any{
-		"offset":        (page.Number() - 1) * page.RowsPerPage(),
+		"offset":        page.Offset(),
 		"rows_per_page": page.RowsPerPage(),
 	}
 
 	const q = `
 	SELECT
-		user_id, name, email, password_hash, roles, department, enabled, created_at, updated_at
+		user_id, name, email, password_hash, roles, department, enabled, email_verified, version, created_at, updated_at
 	FROM
 		users`
 
-	buf := bytes.NewBufferString(q)
-	applyFilter(filter, data, buf)
-
 	orderByClause, err := orderByClause(orderBy)
 	if err != nil {
 		return nil, err
 	}
 
+	var seek []string
+
+	cursor, ok := page.Cursor()
+	if ok {
+		var where string
+		where, orderByClause, err = seekClause(cursor, data)
+		if err != nil {
+			return nil, err
+		}
+
+		seek = append(seek, where)
+	}
+
+	buf := bytes.NewBufferString(q)
+	if err := applyFilter(filter, data, buf, seek...); err != nil {
+		return nil, err
+	}
+
 	buf.WriteString(orderByClause)
 	buf.WriteString(" LIMIT :rows_per_page OFFSET :offset")
 
@@ -151,6 +180,11 @@ func (s *Store) Query(ctx context.Context, filter userbus.QueryFilter, orderBy o
 		return nil, fmt.Errorf("namedqueryslice: %w", err)
 	}
 
+	// The rows before the cursor are read backward, nearest first.
+	if cursor.Backward {
+		slices.Reverse(dbUsrs)
+	}
+
 	return toBusUsers(dbUsrs)
 }
 
@@ -161,7 +195,9 @@ func (s *Store) Count(ctx context.Context, filter userbus.QueryFilter) (int, err
 	const q = "SELECT COUNT(user_id) AS `count` FROM users"
 
 	buf := bytes.NewBufferString(q)
-	applyFilter(filter, data, buf)
+	if err := applyFilter(filter, data, buf); err != nil {
+		return 0, err
+	}
 
 	var count struct {
 		Count int `db:"count"`
@@ -188,7 +224,7 @@ func (s *Store) QueryByID(ctx context.Context, userID uuid.UUID) (userbus.User,
 
 	const q = `
 	SELECT
-        user_id, name, email, password_hash, roles, department, enabled, created_at, updated_at
+        user_id, name, email, password_hash, roles, department, enabled, email_verified, version, created_at, updated_at
 	FROM
 		users
 	WHERE 
@@ -225,7 +261,7 @@ func (s *Store) QueryByEmail(ctx context.Context, email mail.Address) (userbus.U
 
 	const q = `
 	SELECT
-        user_id, name, email, password_hash, roles, department, enabled, created_at, updated_at
+        user_id, name, email, password_hash, roles, department, enabled, email_verified, version, created_at, updated_at
 	FROM
 		users
 	WHERE
@@ -246,41 +282,3 @@ func (s *Store) QueryByEmail(ctx context.Context, email mail.Address) (userbus.U
 
 	return bus, nil
 }
-
-// QueryByRefreshToken gets the specified user from the database by refresh token.
-func (s *Store) QueryByRefreshToken(ctx context.Context, refreshToken string) (userbus.User, error) {
-	cached, ok := s.readCache(refreshToken)
-	if ok {
-		return cached, nil
-	}
-
-	data := struct {
-		Email string `db:"refresh_token"`
-	}{
-		Email: refreshToken,
-	}
-
-	const q = `
-	SELECT
-        *
-	FROM
-		users
-	WHERE
-		refresh_token = :refresh_token`
-
-	var dbUsr user
-	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbUsr); err != nil {
-		if errors.Is(err, sqldb.ErrDBNotFound) {
-			return userbus.User{}, fmt.Errorf("db: %w", userbus.ErrNotFound)
-		}
-		return userbus.User{}, fmt.Errorf("db: %w", err)
-	}
-
-	bus, err := toBusUser(dbUsr)
-	if err != nil {
-		return userbus.User{}, err
-	}
-	s.writeCache(bus)
-
-	return bus, nil
-}
@@ -24,6 +24,8 @@ func TestNewUsers(n int, rle role.Role) []NewUser {
 			Roles:      []role.Role{rle},
 			Department: name.MustParseNull(fmt.Sprintf("Department%d", idx)),
 			Password:   fmt.Sprintf("Password%d", idx),
+
+			EmailVerified: true,
 		}
 
 		newUsrs[i] = nu
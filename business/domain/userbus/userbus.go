@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/mail"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -23,6 +24,8 @@ var (
 	ErrNotFound              = errors.New("user not found")
 	ErrUniqueEmail           = errors.New("email is not unique")
 	ErrAuthenticationFailure = errors.New("authentication failed")
+	ErrEmailNotVerified      = errors.New("email not verified")
+	ErrConflict              = errors.New("user changed since it was loaded")
 )
 
 // Storer interface declares the behavior this package needs to persist and
@@ -36,7 +39,6 @@ type Storer interface {
 	Count(ctx context.Context, filter QueryFilter) (int, error)
 	QueryByID(ctx context.Context, userID uuid.UUID) (User, error)
 	QueryByEmail(ctx context.Context, email mail.Address) (User, error)
-	QueryByRefreshToken(ctx context.Context, refreshToken string) (User, error)
 }
 
 // Business manages the set of APIs for user access.
@@ -63,9 +65,14 @@ func (b *Business) NewWithTx(tx sqldb.CommitRollbacker) (*Business, error) {
 		return nil, err
 	}
 
+	dlg, err := b.delegate.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
 	bus := Business{
 		log:      b.log,
-		delegate: b.delegate,
+		delegate: dlg,
 		storer:   storer,
 	}
 
@@ -82,15 +89,17 @@ func (b *Business) Create(ctx context.Context, nu NewUser) (User, error) {
 	now := time.Now()
 
 	usr := User{
-		ID:           uuid.New(),
-		Name:         nu.Name,
-		Email:        nu.Email,
-		PasswordHash: hash,
-		Roles:        nu.Roles,
-		Department:   nu.Department,
-		Enabled:      true,
-		DateCreated:  now,
-		DateUpdated:  now,
+		ID:            uuid.New(),
+		Name:          nu.Name,
+		Email:         nu.Email,
+		PasswordHash:  hash,
+		Roles:         nu.Roles,
+		Department:    nu.Department,
+		Enabled:       true,
+		EmailVerified: nu.EmailVerified,
+		Version:       1,
+		DateCreated:   now,
+		DateUpdated:   now,
 	}
 
 	if err := b.storer.Create(ctx, usr); err != nil {
@@ -100,13 +109,22 @@ func (b *Business) Create(ctx context.Context, nu NewUser) (User, error) {
 	return usr, nil
 }
 
-// Update modifies information about a user.
+// Update modifies information about a user. Every update moves the user to
+// a new version, and ErrConflict is returned when the user changed since it
+// was loaded. Changing the email marks it as not verified, unless the update
+// sets it as verified, and the email changed action is called so a new
+// verification can be sent.
 func (b *Business) Update(ctx context.Context, usr User, uu UpdateUser) (User, error) {
 	if uu.Name != nil {
 		usr.Name = *uu.Name
 	}
 
+	var emailChanged bool
 	if uu.Email != nil {
+		emailChanged = !strings.EqualFold(uu.Email.Address, usr.Email.Address)
+		if emailChanged {
+			usr.EmailVerified = false
+		}
 		usr.Email = *uu.Email
 	}
 
@@ -130,12 +148,23 @@ func (b *Business) Update(ctx context.Context, usr User, uu UpdateUser) (User, e
 		usr.Enabled = *uu.Enabled
 	}
 
+	if uu.EmailVerified != nil {
+		usr.EmailVerified = *uu.EmailVerified
+	}
+
+	usr.Version++
 	usr.DateUpdated = time.Now()
 
 	if err := b.storer.Update(ctx, usr); err != nil {
 		return User{}, fmt.Errorf("update: %w", err)
 	}
 
+	if emailChanged && !usr.EmailVerified && b.delegate != nil {
+		if err := b.delegate.Call(ctx, ActionEmailChangedData(usr.ID, usr.Email)); err != nil {
+			return User{}, fmt.Errorf("failed to execute `%s` action: %w", ActionEmailChanged, err)
+		}
+	}
+
 	return usr, nil
 }
 
@@ -189,17 +218,6 @@ func (b *Business) QueryByEmail(ctx context.Context, email mail.Address) (User,
 	return user, nil
 }
 
-// QueryByRefreshToken finds the user by the specified refresh token
-func (b *Business) QueryByRefreshToken(ctx context.Context, refreshToken string) (User, error) {
-	user, err := b.storer.QueryByRefreshToken(ctx, refreshToken)
-	if err != nil {
-		b.log.Error(ctx, "business.userbus.querybyrefreshtoken", "error", err)
-		return User{}, fmt.Errorf("query: refreshToken[%s]: %w", refreshToken, err)
-	}
-
-	return user, nil
-}
-
 // Authenticate finds a user by their email and verifies their password. On
 // success it returns a Claims User representing this user. The claims can be
 // used to generate a token for future authentication.
@@ -213,5 +231,10 @@ func (b *Business) Authenticate(ctx context.Context, email mail.Address, passwor
 		return User{}, fmt.Errorf("comparehashandpassword: %w", ErrAuthenticationFailure)
 	}
 
+	// only someone who knows the password learns the email isn't verified
+	if !usr.EmailVerified {
+		return User{}, fmt.Errorf("authenticate: email[%s]: %w", email, ErrEmailNotVerified)
+	}
+
 	return usr, nil
 }
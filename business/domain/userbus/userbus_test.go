@@ -2,6 +2,7 @@ package userbus_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/mail"
 	"sort"
@@ -180,6 +181,7 @@ func create(busDomain dbtest.BusDomain) []unitest.Table {
 				Roles:      []role.Role{role.Admin},
 				Department: name.MustParseNull("ITO"),
 				Enabled:    true,
+				Version:    1,
 			},
 			ExcFunc: func(ctx context.Context) any {
 				nu := userbus.NewUser{
@@ -217,6 +219,34 @@ func create(busDomain dbtest.BusDomain) []unitest.Table {
 				return cmp.Diff(gotResp, expResp)
 			},
 		},
+		{
+			Name:    "unverified",
+			ExpResp: userbus.ErrEmailNotVerified,
+			ExcFunc: func(ctx context.Context) any {
+				addr := mail.Address{Address: "unverified@ardanlabs.com"}
+
+				nu := userbus.NewUser{
+					Name:     name.MustParse("Ed Unverified"),
+					Email:    addr,
+					Roles:    []role.Role{role.User},
+					Password: "123",
+				}
+
+				if _, err := busDomain.User.Create(ctx, nu); err != nil {
+					return err
+				}
+
+				_, err := busDomain.User.Authenticate(ctx, addr, "123")
+				return err
+			},
+			CmpFunc: func(got any, exp any) string {
+				err, ok := got.(error)
+				if !ok || !errors.Is(err, exp.(error)) {
+					return fmt.Sprintf("got %v, exp %v", got, exp)
+				}
+				return ""
+			},
+		},
 	}
 
 	return table
@@ -229,13 +259,15 @@ func update(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
 		{
 			Name: "basic",
 			ExpResp: userbus.User{
-				ID:          sd.Users[0].ID,
-				Name:        name.MustParse("Jack Kennedy"),
-				Email:       *email,
-				Roles:       []role.Role{role.Admin},
-				Department:  name.MustParseNull("ITO"),
-				Enabled:     true,
-				DateCreated: sd.Users[0].DateCreated,
+				ID:            sd.Users[0].ID,
+				Name:          name.MustParse("Jack Kennedy"),
+				Email:         *email,
+				Roles:         []role.Role{role.Admin},
+				Department:    name.MustParseNull("ITO"),
+				Enabled:       true,
+				EmailVerified: false,
+				Version:       sd.Users[0].Version + 1,
+				DateCreated:   sd.Users[0].DateCreated,
 			},
 			ExcFunc: func(ctx context.Context) any {
 				uu := userbus.UpdateUser{
@@ -271,6 +303,28 @@ func update(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
 				return cmp.Diff(gotResp, expResp)
 			},
 		},
+		{
+			Name:    "email-set-verified",
+			ExpResp: true,
+			ExcFunc: func(ctx context.Context) any {
+				email, _ := mail.ParseAddress("jill@ardanlabs.com")
+
+				uu := userbus.UpdateUser{
+					Email:         email,
+					EmailVerified: dbtest.BoolPointer(true),
+				}
+
+				resp, err := busDomain.User.Update(ctx, sd.Admins[0].User, uu)
+				if err != nil {
+					return err
+				}
+
+				return resp.EmailVerified
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
 	}
 
 	return table
@@ -2,15 +2,32 @@ package vproductbus
 
 import (
 	"github.com/google/uuid"
+	"github.com/rmsj/service/business/sdk/filter"
+	"github.com/rmsj/service/business/types/money"
 	"github.com/rmsj/service/business/types/name"
 )
 
+// Set of fields that the filter expressions can use.
+const (
+	FilterByProductID   = "a"
+	FilterByUserID      = "b"
+	FilterByName        = "c"
+	FilterByCost        = "d"
+	FilterByCurrency    = "e"
+	FilterByQuantity    = "f"
+	FilterByDateCreated = "g"
+	FilterByDateUpdated = "h"
+	FilterByUserName    = "i"
+)
+
 // QueryFilter holds the available fields a query can be filtered on.
 // We are using pointer semantics because the With API mutates the value.
+// The expression is on the FilterBy fields.
 type QueryFilter struct {
 	ID       *uuid.UUID
 	Name     *name.Name
-	Cost     *float64
+	Cost     *money.Money
 	Quantity *int
 	UserName *name.Name
+	Expr     filter.Expr
 }
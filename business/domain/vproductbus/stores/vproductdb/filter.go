@@ -3,41 +3,53 @@ package vproductdb
 import (
 	"bytes"
 	"fmt"
-	"strings"
 
 	"github.com/rmsj/service/business/domain/vproductbus"
+	"github.com/rmsj/service/business/sdk/sqldb"
 )
 
-func (s *Store) applyFilter(filter vproductbus.QueryFilter, data map[string]any, buf *bytes.Buffer) {
-	var wc []string
+var filterByFields = map[string]string{
+	vproductbus.FilterByProductID:   "product_id",
+	vproductbus.FilterByUserID:      "user_id",
+	vproductbus.FilterByName:        "name",
+	vproductbus.FilterByCost:        "cost",
+	vproductbus.FilterByCurrency:    "currency",
+	vproductbus.FilterByQuantity:    "quantity",
+	vproductbus.FilterByDateCreated: "created_at",
+	vproductbus.FilterByDateUpdated: "updated_at",
+	vproductbus.FilterByUserName:    "user_name",
+}
+
+func (s *Store) applyFilter(filter vproductbus.QueryFilter, data map[string]any, buf *bytes.Buffer, seek ...string) error {
+	w := sqldb.NewWhere(data)
 
 	if filter.ID != nil {
-		data["product_id"] = filter.ID
-		wc = append(wc, "product_id = :product_id")
+		w.Add("product_id = " + w.Bind(filter.ID))
 	}
 
 	if filter.Name != nil {
-		data["name"] = fmt.Sprintf("%%%s%%", filter.Name)
-		wc = append(wc, "name LIKE :name")
+		w.Add("name LIKE " + w.Bind(fmt.Sprintf("%%%s%%", filter.Name)))
 	}
 
 	if filter.Cost != nil {
-		data["cost"] = filter.Cost
-		wc = append(wc, "cost = :cost")
+		w.Add("cost = "+w.Bind(filter.Cost.String()), "currency = "+w.Bind(filter.Cost.Currency().Code()))
 	}
 
 	if filter.Quantity != nil {
-		data["quantity"] = filter.Quantity
-		wc = append(wc, "quantity = :quantity")
+		w.Add("quantity = " + w.Bind(filter.Quantity))
 	}
 
 	if filter.UserName != nil {
-		data["user_name"] = fmt.Sprintf("%%%s%%", filter.Name)
-		wc = append(wc, "user_name LIKE :user_name")
+		w.Add("user_name LIKE " + w.Bind(fmt.Sprintf("%%%s%%", filter.UserName)))
 	}
 
-	if len(wc) > 0 {
-		buf.WriteString(" WHERE ")
-		buf.WriteString(strings.Join(wc, " AND "))
+	if err := w.AddExpr(filter.Expr, filterByFields); err != nil {
+		return err
 	}
+
+	w.Add(seek...)
+
+	buf.WriteString(w.String())
+
+	return nil
 }
@@ -15,7 +15,8 @@ type product struct {
 	ID          uuid.UUID `db:"product_id"`
 	UserID      uuid.UUID `db:"user_id"`
 	Name        string    `db:"name"`
-	Cost        float64   `db:"cost"`
+	Cost        string    `db:"cost"`
+	Currency    string    `db:"currency"`
 	Quantity    int       `db:"quantity"`
 	DateCreated time.Time `db:"created_at"`
 	DateUpdated time.Time `db:"updated_at"`
@@ -33,7 +34,7 @@ func toBusProduct(db product) (vproductbus.Product, error) {
 		return vproductbus.Product{}, fmt.Errorf("parse name: %w", err)
 	}
 
-	cost, err := money.Parse(db.Cost)
+	cost, err := money.Parse(db.Cost, db.Currency)
 	if err != nil {
 		return vproductbus.Product{}, fmt.Errorf("parse cost: %w", err)
 	}
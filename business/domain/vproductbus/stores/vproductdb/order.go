@@ -5,6 +5,8 @@ import (
 
 	"github.com/rmsj/service/business/domain/vproductbus"
 	"github.com/rmsj/service/business/sdk/order"
+	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/business/sdk/sqldb"
 )
 
 var orderByFields = map[string]string{
@@ -24,3 +26,16 @@ func orderByClause(orderBy order.By) (string, error) {
 
 	return " ORDER BY " + by + " " + orderBy.Direction, nil
 }
+
+// seekClause returns the predicate of the rows after the cursor and the
+// ordering to read them in.
+func seekClause(cursor page.Cursor, data map[string]any) (string, string, error) {
+	by, exists := orderByFields[cursor.OrderBy.Field]
+	if !exists {
+		return "", "", fmt.Errorf("field %q does not exist", cursor.OrderBy.Field)
+	}
+
+	where, orderBy := sqldb.Seek(cursor, by, "product_id", data)
+
+	return where, orderBy, nil
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/jmoiron/sqlx"
 
@@ -32,7 +33,7 @@ func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
 // Query retrieves a list of existing products from the database.
 func (s *Store) Query(ctx context.Context, filter vproductbus.QueryFilter, orderBy order.By, page page.Page) ([]vproductbus.Product, error) {
 	data := map[string]any{
-		"offset":        (page.Number() - 1) * page.RowsPerPage(),
+		"offset":        page.Offset(),
 		"rows_per_page": page.RowsPerPage(),
 	}
 
@@ -42,6 +43,7 @@ func (s *Store) Query(ctx context.Context, filter vproductbus.QueryFilter, order
 		user_id,
 		name,
 		cost,
+		currency,
 		quantity,
 		created_at,
 		updated_at,
@@ -49,14 +51,29 @@ func (s *Store) Query(ctx context.Context, filter vproductbus.QueryFilter, order
 	FROM
 		view_products`
 
-	buf := bytes.NewBufferString(q)
-	s.applyFilter(filter, data, buf)
-
 	orderByClause, err := orderByClause(orderBy)
 	if err != nil {
 		return nil, err
 	}
 
+	var seek []string
+
+	cursor, ok := page.Cursor()
+	if ok {
+		var where string
+		where, orderByClause, err = seekClause(cursor, data)
+		if err != nil {
+			return nil, err
+		}
+
+		seek = append(seek, where)
+	}
+
+	buf := bytes.NewBufferString(q)
+	if err := s.applyFilter(filter, data, buf, seek...); err != nil {
+		return nil, err
+	}
+
 	buf.WriteString(orderByClause)
 	buf.WriteString(" LIMIT :rows_per_page OFFSET :offset")
 
@@ -65,6 +82,11 @@ func (s *Store) Query(ctx context.Context, filter vproductbus.QueryFilter, order
 		return nil, fmt.Errorf("namedqueryslice: %w", err)
 	}
 
+	// The rows before the cursor are read backward, nearest first.
+	if cursor.Backward {
+		slices.Reverse(dnPrd)
+	}
+
 	prd, err := toBusProducts(dnPrd)
 	if err != nil {
 		return nil, err
@@ -80,7 +102,9 @@ func (s *Store) Count(ctx context.Context, filter vproductbus.QueryFilter) (int,
 	const q = "SELECT COUNT(product_id) AS `count` FROM view_products"
 
 	buf := bytes.NewBufferString(q)
-	s.applyFilter(filter, data, buf)
+	if err := s.applyFilter(filter, data, buf); err != nil {
+		return 0, err
+	}
 
 	var count struct {
 		Count int `db:"count"`
@@ -0,0 +1,130 @@
+package webhookbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/domain/productbus"
+	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/sdk/delegate"
+)
+
+// Set of events a subscription can receive.
+const (
+	EventUserDeleted    = "user.deleted"
+	EventProductCreated = "product.created"
+	EventProductUpdated = "product.updated"
+	EventProductDeleted = "product.deleted"
+)
+
+type source struct {
+	domain string
+	action string
+}
+
+// eventSources maps each event to the delegate domain and action raising it.
+var eventSources = map[string]source{
+	EventUserDeleted:    {userbus.DomainName, userbus.ActionDeleted},
+	EventProductCreated: {productbus.DomainName, productbus.ActionCreated},
+	EventProductUpdated: {productbus.DomainName, productbus.ActionUpdated},
+	EventProductDeleted: {productbus.DomainName, productbus.ActionDeleted},
+}
+
+// Events returns the set of events a subscription can receive.
+func Events() []string {
+	events := make([]string, 0, len(eventSources))
+	for evt := range eventSources {
+		events = append(events, evt)
+	}
+
+	sort.Strings(events)
+
+	return events
+}
+
+// Payload represents the body sent to a subscription for an event.
+type Payload struct {
+	ID        uuid.UUID       `json:"id"`
+	Event     string          `json:"event"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// =============================================================================
+
+// registerDelegateFunctions will register action functions with the delegate
+// system. If the business was constructed for query only, there won't be a
+// delegate provided. The actions go through the outbox so the deliveries are
+// queued once the change that raised them is committed.
+func (b *Business) registerDelegateFunctions() {
+	if b.delegate != nil {
+		for _, src := range eventSources {
+			b.delegate.RegisterAsync(src.domain, src.action, b.actionQueueDeliveries)
+		}
+	}
+}
+
+// actionQueueDeliveries is executed indirectly by the user and product
+// domains, queueing a delivery for every enabled subscription to the event.
+func (b *Business) actionQueueDeliveries(ctx context.Context, data delegate.Data) error {
+	event := data.Domain + "." + data.Action
+
+	subs, err := b.storer.QueryByEvent(ctx, event)
+	if err != nil {
+		return fmt.Errorf("querybyevent: event[%s]: %w", event, err)
+	}
+
+	if len(subs) == 0 {
+		return nil
+	}
+
+	// The outbox can deliver the same event more than once. Reusing its ID
+	// means a delivery that was already queued is detected and skipped.
+	eventID, ok := delegate.GetEventID(ctx)
+	if !ok {
+		eventID = uuid.New()
+	}
+
+	now := time.Now()
+
+	payload, err := json.Marshal(Payload{
+		ID:        eventID,
+		Event:     event,
+		CreatedAt: now,
+		Data:      data.RawParams,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	b.log.Info(ctx, "action-queuedeliveries", "event", event, "event_id", eventID, "subscriptions", len(subs))
+
+	for _, sub := range subs {
+		dlv := Delivery{
+			ID:             uuid.New(),
+			SubscriptionID: sub.ID,
+			EventID:        eventID,
+			Event:          event,
+			Payload:        payload,
+			Status:         DeliveryPending,
+			RunAt:          now,
+			DateCreated:    now,
+			DateUpdated:    now,
+		}
+
+		if err := b.storer.CreateDelivery(ctx, dlv); err != nil {
+			if errors.Is(err, ErrDuplicateDelivery) {
+				continue
+			}
+			return fmt.Errorf("createdelivery: subscriptionID[%s]: %w", sub.ID, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package webhookbus
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/sdk/filter"
+)
+
+// Set of fields that the filter expressions can use. Subscriptions and
+// deliveries can each use the fields they have.
+const (
+	FilterBySubscriptionID = "a"
+	FilterByURL            = "b"
+	FilterByEnabled        = "c"
+	FilterByDateCreated    = "d"
+	FilterByDateUpdated    = "e"
+	FilterByDeliveryID     = "f"
+	FilterByEvent          = "g"
+	FilterByStatus         = "h"
+	FilterByAttempts       = "i"
+	FilterByResponseCode   = "j"
+	FilterByRunAt          = "k"
+)
+
+// QueryFilter holds the available fields a query can be filtered on.
+// We are using pointer semantics because the With API mutates the value.
+// The expression is on the FilterBy fields.
+type QueryFilter struct {
+	ID      *uuid.UUID
+	Event   *string
+	Enabled *bool
+	Expr    filter.Expr
+}
+
+// DeliveryFilter holds the available fields a delivery query can be
+// filtered on. The expression is on the FilterBy fields.
+type DeliveryFilter struct {
+	SubscriptionID *uuid.UUID
+	Status         *string
+	Expr           filter.Expr
+}
@@ -0,0 +1,62 @@
+package webhookbus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Subscription represents a partner endpoint that receives the events it
+// subscribed to.
+type Subscription struct {
+	ID          uuid.UUID
+	URL         string
+	Events      []string
+	Secret      string
+	Enabled     bool
+	DateCreated time.Time
+	DateUpdated time.Time
+}
+
+// NewSubscription is what we require from clients when adding a Subscription.
+type NewSubscription struct {
+	URL    string
+	Events []string
+	Secret string
+}
+
+// UpdateSubscription defines what information may be provided to modify an
+// existing Subscription. All fields are optional so clients can send just the
+// fields they want changed. It uses pointer fields so we can differentiate
+// between a field that was not provided and a field that was provided as
+// explicitly blank.
+type UpdateSubscription struct {
+	URL     *string
+	Events  []string
+	Secret  *string
+	Enabled *bool
+}
+
+// Set of statuses a delivery can be in.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Delivery represents an attempt to send an event to a subscription. The
+// deliveries of a subscription make up its delivery log.
+type Delivery struct {
+	ID             uuid.UUID
+	SubscriptionID uuid.UUID
+	EventID        uuid.UUID
+	Event          string
+	Payload        []byte
+	Status         string
+	Attempts       int
+	ResponseCode   int
+	LastError      string
+	RunAt          time.Time
+	DateCreated    time.Time
+	DateUpdated    time.Time
+}
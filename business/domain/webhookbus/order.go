@@ -0,0 +1,25 @@
+package webhookbus
+
+import "github.com/rmsj/service/business/sdk/order"
+
+// DefaultOrderBy represents the default way we sort.
+var DefaultOrderBy = order.NewBy(OrderBySubscriptionID, order.ASC)
+
+// Set of fields that the results can be ordered by.
+const (
+	OrderBySubscriptionID = "a"
+	OrderByURL            = "b"
+	OrderByEnabled        = "c"
+	OrderByDateCreated    = "d"
+)
+
+// DefaultDeliveryOrderBy represents the default way we sort deliveries, with
+// the most recent first.
+var DefaultDeliveryOrderBy = order.NewBy(OrderByDeliveryDateCreated, order.DESC)
+
+// Set of fields that the delivery results can be ordered by.
+const (
+	OrderByDeliveryID          = "a"
+	OrderByDeliveryStatus      = "b"
+	OrderByDeliveryDateCreated = "c"
+)
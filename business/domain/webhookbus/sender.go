@@ -0,0 +1,251 @@
+package webhookbus
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/foundation/logger"
+	"github.com/rmsj/service/foundation/worker"
+)
+
+// Set of headers sent with every delivery.
+const (
+	HeaderID        = "Webhook-Id"
+	HeaderEvent     = "Webhook-Event"
+	HeaderTimestamp = "Webhook-Timestamp"
+	HeaderSignature = "Webhook-Signature"
+)
+
+// Sign returns the signature of a delivery: the HMAC-SHA256 of the timestamp
+// and body joined by a dot, keyed with the subscription secret. Including the
+// timestamp lets receivers reject replayed deliveries.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether the signature matches the timestamp and body for
+// the specified secret.
+func Verify(secret string, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// =============================================================================
+
+// SenderConfig contains the settings for the delivery sender.
+type SenderConfig struct {
+	Log         *logger.Logger
+	WebhookBus  *Business
+	Beginner    sqldb.Beginner
+	Client      *http.Client
+	Interval    time.Duration
+	BatchSize   int
+	Lease       time.Duration
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Sender posts the queued deliveries to the subscriptions. A delivery that
+// fails is retried with exponential backoff until it runs out of attempts.
+type Sender struct {
+	cfg SenderConfig
+}
+
+// NewSender constructs a sender for the queued deliveries.
+func NewSender(cfg SenderConfig) *Sender {
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{
+			Timeout: 10 * time.Second,
+		}
+	}
+
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 20
+	}
+
+	if cfg.Lease <= 0 {
+		cfg.Lease = time.Minute
+	}
+
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 8
+	}
+
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = 10 * time.Second
+	}
+
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 6 * time.Hour
+	}
+
+	return &Sender{
+		cfg: cfg,
+	}
+}
+
+// Run sends the due deliveries on every interval until the context is
+// cancelled.
+func (s *Sender) Run(ctx context.Context) {
+	s.cfg.Log.Info(ctx, "webhook sender", "status", "started", "interval", s.cfg.Interval)
+	defer s.cfg.Log.Info(ctx, "webhook sender", "status", "stopped")
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if _, err := s.SendOnce(ctx); err != nil {
+				s.cfg.Log.Error(ctx, "webhook sender", "status", "send failed", "err", err)
+			}
+		}
+	}
+}
+
+// SendOnce claims a batch of due deliveries and posts them. It returns the
+// number of deliveries that were accepted by the subscriptions.
+func (s *Sender) SendOnce(ctx context.Context) (int, error) {
+	dlvs, err := s.claim(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("claim: %w", err)
+	}
+
+	storer := s.cfg.WebhookBus.storer
+
+	var delivered int
+
+	for _, dlv := range dlvs {
+		code, err := s.send(ctx, dlv)
+
+		now := time.Now()
+
+		dlv.ResponseCode = code
+
+		switch {
+		case err == nil:
+			dlv.Status = DeliveryDelivered
+			dlv.LastError = ""
+			delivered++
+
+		case dlv.Attempts >= s.cfg.MaxAttempts:
+			dlv.Status = DeliveryFailed
+			dlv.LastError = err.Error()
+			s.cfg.Log.Error(ctx, "webhook sender", "status", "delivery failed", "delivery_id", dlv.ID, "attempts", dlv.Attempts, "err", err)
+
+		default:
+			dlv.RunAt = now.Add(worker.Backoff(dlv.Attempts, s.cfg.MinBackoff, s.cfg.MaxBackoff))
+			dlv.LastError = err.Error()
+			s.cfg.Log.Info(ctx, "webhook sender", "status", "delivery attempt failed", "delivery_id", dlv.ID, "attempts", dlv.Attempts, "retry_at", dlv.RunAt, "err", err)
+		}
+
+		dlv.DateUpdated = now
+
+		if err := storer.UpdateDelivery(ctx, dlv); err != nil {
+			return delivered, fmt.Errorf("updatedelivery: deliveryID[%s]: %w", dlv.ID, err)
+		}
+	}
+
+	return delivered, nil
+}
+
+// claim locks the due deliveries, counts the attempt and hides them from
+// other senders for the duration of the lease.
+func (s *Sender) claim(ctx context.Context) ([]Delivery, error) {
+	tx, err := s.cfg.Beginner.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	storer, err := s.cfg.WebhookBus.storer.NewWithTx(tx)
+	if err != nil {
+		return nil, fmt.Errorf("newwithtx: %w", err)
+	}
+
+	now := time.Now()
+
+	dlvs, err := storer.QueryDueDeliveries(ctx, now, s.cfg.BatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("queryduedeliveries: %w", err)
+	}
+
+	for i := range dlvs {
+		dlvs[i].Attempts++
+		dlvs[i].RunAt = now.Add(s.cfg.Lease)
+		dlvs[i].DateUpdated = now
+
+		if err := storer.UpdateDelivery(ctx, dlvs[i]); err != nil {
+			return nil, fmt.Errorf("updatedelivery: deliveryID[%s]: %w", dlvs[i].ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return dlvs, nil
+}
+
+// send posts the delivery to its subscription, returning the response code.
+// Any response outside the 2xx range is considered a failure.
+func (s *Sender) send(ctx context.Context, dlv Delivery) (int, error) {
+	sub, err := s.cfg.WebhookBus.QueryByID(ctx, dlv.SubscriptionID)
+	if err != nil {
+		return 0, err
+	}
+
+	if !sub.Enabled {
+		return 0, errors.New("subscription disabled")
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(dlv.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("newrequest: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, dlv.EventID.String())
+	req.Header.Set(HeaderEvent, dlv.Event)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, timestamp, dlv.Payload))
+
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Drain the body so the connection can be reused.
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
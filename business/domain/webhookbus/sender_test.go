@@ -0,0 +1,37 @@
+package webhookbus_test
+
+import (
+	"testing"
+
+	"github.com/rmsj/service/business/domain/webhookbus"
+)
+
+func Test_Sign(t *testing.T) {
+	const secret = "0123456789abcdef"
+	const timestamp = "1700000000"
+	body := []byte(`{"event":"product.created"}`)
+
+	// Computed with: printf '1700000000.{"event":"product.created"}' | openssl dgst -sha256 -hmac 0123456789abcdef
+	const exp = "sha256=1e800d412646840fe19bc4ce1f687e93eaebcf61fcb10e4e2ba9ce0355b7818c"
+
+	sig := webhookbus.Sign(secret, timestamp, body)
+	if sig != exp {
+		t.Fatalf("Should get the expected signature : got %s, exp %s", sig, exp)
+	}
+
+	if !webhookbus.Verify(secret, timestamp, body, sig) {
+		t.Fatalf("Should be able to verify the signature : %s", sig)
+	}
+
+	if webhookbus.Verify("another-secret-value", timestamp, body, sig) {
+		t.Errorf("Should NOT verify the signature with a different secret")
+	}
+
+	if webhookbus.Verify(secret, "1700000001", body, sig) {
+		t.Errorf("Should NOT verify the signature with a different timestamp")
+	}
+
+	if webhookbus.Verify(secret, timestamp, []byte(`{"event":"product.deleted"}`), sig) {
+		t.Errorf("Should NOT verify the signature with a different body")
+	}
+}
@@ -0,0 +1,72 @@
+package webhookdb
+
+import (
+	"bytes"
+
+	"github.com/rmsj/service/business/domain/webhookbus"
+	"github.com/rmsj/service/business/sdk/sqldb"
+)
+
+var filterByFields = map[string]string{
+	webhookbus.FilterBySubscriptionID: "subscription_id",
+	webhookbus.FilterByURL:            "url",
+	webhookbus.FilterByEnabled:        "enabled",
+	webhookbus.FilterByDateCreated:    "created_at",
+	webhookbus.FilterByDateUpdated:    "updated_at",
+}
+
+var deliveryFilterByFields = map[string]string{
+	webhookbus.FilterBySubscriptionID: "subscription_id",
+	webhookbus.FilterByDeliveryID:     "delivery_id",
+	webhookbus.FilterByEvent:          "event",
+	webhookbus.FilterByStatus:         "status",
+	webhookbus.FilterByAttempts:       "attempts",
+	webhookbus.FilterByResponseCode:   "response_code",
+	webhookbus.FilterByRunAt:          "run_at",
+	webhookbus.FilterByDateCreated:    "created_at",
+	webhookbus.FilterByDateUpdated:    "updated_at",
+}
+
+func applyFilter(filter webhookbus.QueryFilter, data map[string]any, buf *bytes.Buffer) error {
+	w := sqldb.NewWhere(data)
+
+	if filter.ID != nil {
+		w.Add("subscription_id = " + w.Bind(filter.ID))
+	}
+
+	if filter.Event != nil {
+		w.Add("FIND_IN_SET(" + w.Bind(*filter.Event) + ", events) > 0")
+	}
+
+	if filter.Enabled != nil {
+		w.Add("enabled = " + w.Bind(*filter.Enabled))
+	}
+
+	if err := w.AddExpr(filter.Expr, filterByFields); err != nil {
+		return err
+	}
+
+	buf.WriteString(w.String())
+
+	return nil
+}
+
+func applyDeliveryFilter(filter webhookbus.DeliveryFilter, data map[string]any, buf *bytes.Buffer) error {
+	w := sqldb.NewWhere(data)
+
+	if filter.SubscriptionID != nil {
+		w.Add("subscription_id = " + w.Bind(filter.SubscriptionID))
+	}
+
+	if filter.Status != nil {
+		w.Add("status = " + w.Bind(*filter.Status))
+	}
+
+	if err := w.AddExpr(filter.Expr, deliveryFilterByFields); err != nil {
+		return err
+	}
+
+	buf.WriteString(w.String())
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package webhookdb
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/domain/webhookbus"
+)
+
+type subscription struct {
+	ID          uuid.UUID `db:"subscription_id"`
+	URL         string    `db:"url"`
+	Events      string    `db:"events"`
+	Secret      string    `db:"secret"`
+	Enabled     bool      `db:"enabled"`
+	DateCreated time.Time `db:"created_at"`
+	DateUpdated time.Time `db:"updated_at"`
+}
+
+func toDBSubscription(bus webhookbus.Subscription) subscription {
+	db := subscription{
+		ID:          bus.ID,
+		URL:         bus.URL,
+		Events:      strings.Join(bus.Events, ","),
+		Secret:      bus.Secret,
+		Enabled:     bus.Enabled,
+		DateCreated: bus.DateCreated.UTC(),
+		DateUpdated: bus.DateUpdated.UTC(),
+	}
+
+	return db
+}
+
+func toBusSubscription(db subscription) webhookbus.Subscription {
+	var events []string
+	if db.Events != "" {
+		events = strings.Split(db.Events, ",")
+	}
+
+	bus := webhookbus.Subscription{
+		ID:          db.ID,
+		URL:         db.URL,
+		Events:      events,
+		Secret:      db.Secret,
+		Enabled:     db.Enabled,
+		DateCreated: db.DateCreated.In(time.Local),
+		DateUpdated: db.DateUpdated.In(time.Local),
+	}
+
+	return bus
+}
+
+func toBusSubscriptions(dbs []subscription) []webhookbus.Subscription {
+	bus := make([]webhookbus.Subscription, len(dbs))
+	for i, db := range dbs {
+		bus[i] = toBusSubscription(db)
+	}
+
+	return bus
+}
+
+// =============================================================================
+
+type delivery struct {
+	ID             uuid.UUID      `db:"delivery_id"`
+	SubscriptionID uuid.UUID      `db:"subscription_id"`
+	EventID        uuid.UUID      `db:"event_id"`
+	Event          string         `db:"event"`
+	Payload        string         `db:"payload"`
+	Status         string         `db:"status"`
+	Attempts       int            `db:"attempts"`
+	ResponseCode   int            `db:"response_code"`
+	LastError      sql.NullString `db:"last_error"`
+	RunAt          time.Time      `db:"run_at"`
+	DateCreated    time.Time      `db:"created_at"`
+	DateUpdated    time.Time      `db:"updated_at"`
+}
+
+func toDBDelivery(bus webhookbus.Delivery) delivery {
+	db := delivery{
+		ID:             bus.ID,
+		SubscriptionID: bus.SubscriptionID,
+		EventID:        bus.EventID,
+		Event:          bus.Event,
+		Payload:        string(bus.Payload),
+		Status:         bus.Status,
+		Attempts:       bus.Attempts,
+		ResponseCode:   bus.ResponseCode,
+		LastError: sql.NullString{
+			String: bus.LastError,
+			Valid:  bus.LastError != "",
+		},
+		RunAt:       bus.RunAt.UTC(),
+		DateCreated: bus.DateCreated.UTC(),
+		DateUpdated: bus.DateUpdated.UTC(),
+	}
+
+	return db
+}
+
+func toBusDelivery(db delivery) webhookbus.Delivery {
+	bus := webhookbus.Delivery{
+		ID:             db.ID,
+		SubscriptionID: db.SubscriptionID,
+		EventID:        db.EventID,
+		Event:          db.Event,
+		Payload:        []byte(db.Payload),
+		Status:         db.Status,
+		Attempts:       db.Attempts,
+		ResponseCode:   db.ResponseCode,
+		LastError:      db.LastError.String,
+		RunAt:          db.RunAt.In(time.Local),
+		DateCreated:    db.DateCreated.In(time.Local),
+		DateUpdated:    db.DateUpdated.In(time.Local),
+	}
+
+	return bus
+}
+
+func toBusDeliveries(dbs []delivery) []webhookbus.Delivery {
+	bus := make([]webhookbus.Delivery, len(dbs))
+	for i, db := range dbs {
+		bus[i] = toBusDelivery(db)
+	}
+
+	return bus
+}
@@ -0,0 +1,30 @@
+package webhookdb
+
+import (
+	"fmt"
+
+	"github.com/rmsj/service/business/domain/webhookbus"
+	"github.com/rmsj/service/business/sdk/order"
+)
+
+var orderByFields = map[string]string{
+	webhookbus.OrderBySubscriptionID: "subscription_id",
+	webhookbus.OrderByURL:            "url",
+	webhookbus.OrderByEnabled:        "enabled",
+	webhookbus.OrderByDateCreated:    "created_at",
+}
+
+var deliveryOrderByFields = map[string]string{
+	webhookbus.OrderByDeliveryID:          "delivery_id",
+	webhookbus.OrderByDeliveryStatus:      "status",
+	webhookbus.OrderByDeliveryDateCreated: "created_at",
+}
+
+func orderByClause(fields map[string]string, orderBy order.By) (string, error) {
+	by, exists := fields[orderBy.Field]
+	if !exists {
+		return "", fmt.Errorf("field %q does not exist", orderBy.Field)
+	}
+
+	return " ORDER BY " + by + " " + orderBy.Direction, nil
+}
@@ -0,0 +1,343 @@
+// Package webhookdb contains webhook related CRUD functionality.
+package webhookdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/rmsj/service/business/domain/webhookbus"
+	"github.com/rmsj/service/business/sdk/order"
+	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/foundation/logger"
+)
+
+// Store manages the set of APIs for webhook database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// NewWithTx constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (webhookbus.Storer, error) {
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return &store, nil
+}
+
+// Create adds a subscription to the sqldb.
+func (s *Store) Create(ctx context.Context, sub webhookbus.Subscription) error {
+	const q = `
+	INSERT INTO webhook_subscriptions
+		(subscription_id, url, events, secret, enabled, created_at, updated_at)
+	VALUES
+		(:subscription_id, :url, :events, :secret, :enabled, :created_at, :updated_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBSubscription(sub)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces a subscription document in the database.
+func (s *Store) Update(ctx context.Context, sub webhookbus.Subscription) error {
+	const q = `
+	UPDATE
+		webhook_subscriptions
+	SET
+		url = :url,
+		events = :events,
+		secret = :secret,
+		enabled = :enabled,
+		updated_at = :updated_at
+	WHERE
+		subscription_id = :subscription_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBSubscription(sub)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the subscription identified by a given ID. The deliveries
+// of the subscription are removed by the foreign key.
+func (s *Store) Delete(ctx context.Context, sub webhookbus.Subscription) error {
+	data := struct {
+		ID string `db:"subscription_id"`
+	}{
+		ID: sub.ID.String(),
+	}
+
+	const q = `
+	DELETE FROM
+		webhook_subscriptions
+	WHERE
+		subscription_id = :subscription_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of existing subscriptions from the database.
+func (s *Store) Query(ctx context.Context, filter webhookbus.QueryFilter, orderBy order.By, page page.Page) ([]webhookbus.Subscription, error) {
+	data := map[string]any{
+		"offset":        (page.Number() - 1) * page.RowsPerPage(),
+		"rows_per_page": page.RowsPerPage(),
+	}
+
+	const q = `
+	SELECT
+		subscription_id, url, events, secret, enabled, created_at, updated_at
+	FROM
+		webhook_subscriptions`
+
+	buf := bytes.NewBufferString(q)
+	if err := applyFilter(filter, data, buf); err != nil {
+		return nil, err
+	}
+
+	orderByClause, err := orderByClause(orderByFields, orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" LIMIT :rows_per_page OFFSET :offset")
+
+	var dbSubs []subscription
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbSubs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusSubscriptions(dbSubs), nil
+}
+
+// Count returns the total number of subscriptions in the DB.
+func (s *Store) Count(ctx context.Context, filter webhookbus.QueryFilter) (int, error) {
+	data := map[string]any{}
+
+	const q = "SELECT COUNT(subscription_id) AS `count` FROM webhook_subscriptions"
+
+	buf := bytes.NewBufferString(q)
+	if err := applyFilter(filter, data, buf); err != nil {
+		return 0, err
+	}
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// QueryByID gets the specified subscription from the database.
+func (s *Store) QueryByID(ctx context.Context, subscriptionID uuid.UUID) (webhookbus.Subscription, error) {
+	data := struct {
+		ID string `db:"subscription_id"`
+	}{
+		ID: subscriptionID.String(),
+	}
+
+	const q = `
+	SELECT
+		subscription_id, url, events, secret, enabled, created_at, updated_at
+	FROM
+		webhook_subscriptions
+	WHERE
+		subscription_id = :subscription_id`
+
+	var dbSub subscription
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbSub); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return webhookbus.Subscription{}, fmt.Errorf("db: %w", webhookbus.ErrNotFound)
+		}
+		return webhookbus.Subscription{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusSubscription(dbSub), nil
+}
+
+// QueryByEvent gets the enabled subscriptions to the specified event.
+func (s *Store) QueryByEvent(ctx context.Context, event string) ([]webhookbus.Subscription, error) {
+	data := struct {
+		Event string `db:"event"`
+	}{
+		Event: event,
+	}
+
+	const q = `
+	SELECT
+		subscription_id, url, events, secret, enabled, created_at, updated_at
+	FROM
+		webhook_subscriptions
+	WHERE
+		enabled = TRUE AND
+		FIND_IN_SET(:event, events) > 0`
+
+	var dbSubs []subscription
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbSubs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusSubscriptions(dbSubs), nil
+}
+
+// =============================================================================
+
+// CreateDelivery adds a delivery to the sqldb. Only one delivery can exist
+// for an event and subscription.
+func (s *Store) CreateDelivery(ctx context.Context, dlv webhookbus.Delivery) error {
+	const q = `
+	INSERT INTO webhook_deliveries
+		(delivery_id, subscription_id, event_id, event, payload, status, attempts, response_code, last_error, run_at, created_at, updated_at)
+	VALUES
+		(:delivery_id, :subscription_id, :event_id, :event, :payload, :status, :attempts, :response_code, :last_error, :run_at, :created_at, :updated_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBDelivery(dlv)); err != nil {
+		if errors.Is(err, sqldb.ErrDBDuplicatedEntry) {
+			return fmt.Errorf("namedexeccontext: %w", webhookbus.ErrDuplicateDelivery)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateDelivery records the state of a delivery.
+func (s *Store) UpdateDelivery(ctx context.Context, dlv webhookbus.Delivery) error {
+	const q = `
+	UPDATE
+		webhook_deliveries
+	SET
+		status = :status,
+		attempts = :attempts,
+		response_code = :response_code,
+		last_error = :last_error,
+		run_at = :run_at,
+		updated_at = :updated_at
+	WHERE
+		delivery_id = :delivery_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBDelivery(dlv)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryDeliveries retrieves a list of existing deliveries from the database.
+func (s *Store) QueryDeliveries(ctx context.Context, filter webhookbus.DeliveryFilter, orderBy order.By, page page.Page) ([]webhookbus.Delivery, error) {
+	data := map[string]any{
+		"offset":        (page.Number() - 1) * page.RowsPerPage(),
+		"rows_per_page": page.RowsPerPage(),
+	}
+
+	const q = `
+	SELECT
+		delivery_id, subscription_id, event_id, event, payload, status, attempts, response_code, last_error, run_at, created_at, updated_at
+	FROM
+		webhook_deliveries`
+
+	buf := bytes.NewBufferString(q)
+	if err := applyDeliveryFilter(filter, data, buf); err != nil {
+		return nil, err
+	}
+
+	orderByClause, err := orderByClause(deliveryOrderByFields, orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" LIMIT :rows_per_page OFFSET :offset")
+
+	var dbDlvs []delivery
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbDlvs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusDeliveries(dbDlvs), nil
+}
+
+// CountDeliveries returns the total number of deliveries in the DB.
+func (s *Store) CountDeliveries(ctx context.Context, filter webhookbus.DeliveryFilter) (int, error) {
+	data := map[string]any{}
+
+	const q = "SELECT COUNT(delivery_id) AS `count` FROM webhook_deliveries"
+
+	buf := bytes.NewBufferString(q)
+	if err := applyDeliveryFilter(filter, data, buf); err != nil {
+		return 0, err
+	}
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// QueryDueDeliveries retrieves the pending deliveries that are due to be
+// sent. The rows are locked for the remainder of the transaction, skipping
+// the ones already locked by another sender.
+func (s *Store) QueryDueDeliveries(ctx context.Context, now time.Time, limit int) ([]webhookbus.Delivery, error) {
+	data := map[string]any{
+		"status": webhookbus.DeliveryPending,
+		"now":    now.UTC(),
+		"limit":  limit,
+	}
+
+	const q = `
+	SELECT
+		delivery_id, subscription_id, event_id, event, payload, status, attempts, response_code, last_error, run_at, created_at, updated_at
+	FROM
+		webhook_deliveries
+	WHERE
+		status = :status AND
+		run_at <= :now
+	ORDER BY
+		run_at
+	LIMIT :limit
+	FOR UPDATE SKIP LOCKED`
+
+	var dbDlvs []delivery
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbDlvs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusDeliveries(dbDlvs), nil
+}
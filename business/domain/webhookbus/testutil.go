@@ -0,0 +1,40 @@
+package webhookbus
+
+import (
+	"context"
+	"fmt"
+)
+
+// TestGenerateNewSubscriptions is a helper method for testing.
+func TestGenerateNewSubscriptions(n int, url string, events []string) []NewSubscription {
+	newSubs := make([]NewSubscription, n)
+
+	for i := range n {
+		ns := NewSubscription{
+			URL:    url,
+			Events: events,
+			Secret: fmt.Sprintf("secret-%d-0123456789abcdef", i+1),
+		}
+
+		newSubs[i] = ns
+	}
+
+	return newSubs
+}
+
+// TestSeedSubscriptions is a helper method for testing.
+func TestSeedSubscriptions(ctx context.Context, n int, url string, events []string, api *Business) ([]Subscription, error) {
+	newSubs := TestGenerateNewSubscriptions(n, url, events)
+
+	subs := make([]Subscription, len(newSubs))
+	for i, ns := range newSubs {
+		sub, err := api.Create(ctx, ns)
+		if err != nil {
+			return nil, fmt.Errorf("seeding subscription: idx: %d : %w", i, err)
+		}
+
+		subs[i] = sub
+	}
+
+	return subs, nil
+}
@@ -0,0 +1,221 @@
+// Package webhookbus provides business access to webhook domain.
+package webhookbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/sdk/delegate"
+	"github.com/rmsj/service/business/sdk/order"
+	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/foundation/logger"
+	"github.com/rmsj/service/foundation/otel"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound          = errors.New("subscription not found")
+	ErrUnknownEvent      = errors.New("unknown event")
+	ErrDuplicateDelivery = errors.New("delivery already exists")
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	NewWithTx(tx sqldb.CommitRollbacker) (Storer, error)
+	Create(ctx context.Context, sub Subscription) error
+	Update(ctx context.Context, sub Subscription) error
+	Delete(ctx context.Context, sub Subscription) error
+	Query(ctx context.Context, filter QueryFilter, orderBy order.By, page page.Page) ([]Subscription, error)
+	Count(ctx context.Context, filter QueryFilter) (int, error)
+	QueryByID(ctx context.Context, subscriptionID uuid.UUID) (Subscription, error)
+	QueryByEvent(ctx context.Context, event string) ([]Subscription, error)
+	CreateDelivery(ctx context.Context, dlv Delivery) error
+	UpdateDelivery(ctx context.Context, dlv Delivery) error
+	QueryDeliveries(ctx context.Context, filter DeliveryFilter, orderBy order.By, page page.Page) ([]Delivery, error)
+	CountDeliveries(ctx context.Context, filter DeliveryFilter) (int, error)
+	QueryDueDeliveries(ctx context.Context, now time.Time, limit int) ([]Delivery, error)
+}
+
+// Business manages the set of APIs for webhook access.
+type Business struct {
+	log      *logger.Logger
+	delegate *delegate.Delegate
+	storer   Storer
+}
+
+// NewBusiness constructs a webhook business API for use.
+func NewBusiness(log *logger.Logger, delegate *delegate.Delegate, storer Storer) *Business {
+	b := Business{
+		log:      log,
+		delegate: delegate,
+		storer:   storer,
+	}
+
+	b.registerDelegateFunctions()
+
+	return &b
+}
+
+// NewWithTx constructs a new business value that will use the
+// specified transaction in any store related calls.
+func (b *Business) NewWithTx(tx sqldb.CommitRollbacker) (*Business, error) {
+	storer, err := b.storer.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	bus := Business{
+		log:      b.log,
+		delegate: b.delegate,
+		storer:   storer,
+	}
+
+	return &bus, nil
+}
+
+// Create adds a new subscription to the system.
+func (b *Business) Create(ctx context.Context, ns NewSubscription) (Subscription, error) {
+	ctx, span := otel.AddSpan(ctx, "business.webhookbus.create")
+	defer span.End()
+
+	if err := checkEvents(ns.Events); err != nil {
+		return Subscription{}, err
+	}
+
+	now := time.Now()
+
+	sub := Subscription{
+		ID:          uuid.New(),
+		URL:         ns.URL,
+		Events:      ns.Events,
+		Secret:      ns.Secret,
+		Enabled:     true,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if err := b.storer.Create(ctx, sub); err != nil {
+		return Subscription{}, fmt.Errorf("create: %w", err)
+	}
+
+	return sub, nil
+}
+
+// Update modifies information about a subscription.
+func (b *Business) Update(ctx context.Context, sub Subscription, us UpdateSubscription) (Subscription, error) {
+	ctx, span := otel.AddSpan(ctx, "business.webhookbus.update")
+	defer span.End()
+
+	if us.URL != nil {
+		sub.URL = *us.URL
+	}
+
+	if us.Events != nil {
+		if err := checkEvents(us.Events); err != nil {
+			return Subscription{}, err
+		}
+		sub.Events = us.Events
+	}
+
+	if us.Secret != nil {
+		sub.Secret = *us.Secret
+	}
+
+	if us.Enabled != nil {
+		sub.Enabled = *us.Enabled
+	}
+
+	sub.DateUpdated = time.Now()
+
+	if err := b.storer.Update(ctx, sub); err != nil {
+		return Subscription{}, fmt.Errorf("update: %w", err)
+	}
+
+	return sub, nil
+}
+
+// Delete removes the specified subscription and its delivery log.
+func (b *Business) Delete(ctx context.Context, sub Subscription) error {
+	ctx, span := otel.AddSpan(ctx, "business.webhookbus.delete")
+	defer span.End()
+
+	if err := b.storer.Delete(ctx, sub); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of existing subscriptions.
+func (b *Business) Query(ctx context.Context, filter QueryFilter, orderBy order.By, page page.Page) ([]Subscription, error) {
+	ctx, span := otel.AddSpan(ctx, "business.webhookbus.query")
+	defer span.End()
+
+	subs, err := b.storer.Query(ctx, filter, orderBy, page)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return subs, nil
+}
+
+// Count returns the total number of subscriptions.
+func (b *Business) Count(ctx context.Context, filter QueryFilter) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.webhookbus.count")
+	defer span.End()
+
+	return b.storer.Count(ctx, filter)
+}
+
+// QueryByID finds the subscription by the specified ID.
+func (b *Business) QueryByID(ctx context.Context, subscriptionID uuid.UUID) (Subscription, error) {
+	ctx, span := otel.AddSpan(ctx, "business.webhookbus.querybyid")
+	defer span.End()
+
+	sub, err := b.storer.QueryByID(ctx, subscriptionID)
+	if err != nil {
+		return Subscription{}, fmt.Errorf("query: subscriptionID[%s]: %w", subscriptionID, err)
+	}
+
+	return sub, nil
+}
+
+// QueryDeliveries retrieves a list of deliveries, which makes up the
+// delivery log of a subscription when filtered by subscription.
+func (b *Business) QueryDeliveries(ctx context.Context, filter DeliveryFilter, orderBy order.By, page page.Page) ([]Delivery, error) {
+	ctx, span := otel.AddSpan(ctx, "business.webhookbus.querydeliveries")
+	defer span.End()
+
+	dlvs, err := b.storer.QueryDeliveries(ctx, filter, orderBy, page)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return dlvs, nil
+}
+
+// CountDeliveries returns the total number of deliveries.
+func (b *Business) CountDeliveries(ctx context.Context, filter DeliveryFilter) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.webhookbus.countdeliveries")
+	defer span.End()
+
+	return b.storer.CountDeliveries(ctx, filter)
+}
+
+// =============================================================================
+
+func checkEvents(events []string) error {
+	for _, evt := range events {
+		if _, exists := eventSources[evt]; !exists {
+			return fmt.Errorf("%q: %w", evt, ErrUnknownEvent)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,478 @@
+package webhookbus_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/rmsj/service/business/domain/productbus"
+	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/domain/webhookbus"
+	"github.com/rmsj/service/business/sdk/dbtest"
+	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/business/sdk/unitest"
+	"github.com/rmsj/service/business/types/role"
+)
+
+func Test_Webhook(t *testing.T) {
+	t.Parallel()
+
+	db := dbtest.New(t, "Test_Webhook")
+
+	rcv := newReceiver()
+	defer rcv.server.Close()
+
+	sd, err := insertSeedData(db.BusDomain, rcv.server.URL)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	sender := webhookbus.NewSender(webhookbus.SenderConfig{
+		Log:        db.Log,
+		WebhookBus: db.BusDomain.Webhook,
+		Beginner:   sqldb.NewBeginner(db.DB),
+		Client:     rcv.server.Client(),
+		MinBackoff: time.Nanosecond,
+	})
+
+	// -------------------------------------------------------------------------
+
+	unitest.Run(t, query(db.BusDomain, sd), "query")
+	unitest.Run(t, create(db.BusDomain), "create")
+	unitest.Run(t, update(db.BusDomain, sd), "update")
+	unitest.Run(t, deliver(db.BusDomain, sd, sender, rcv), "deliver")
+	unitest.Run(t, retry(db.BusDomain, sd, sender, rcv), "retry")
+	unitest.Run(t, delete(db.BusDomain, sd), "delete")
+}
+
+// =============================================================================
+
+// receiver records the deliveries posted by the sender and answers with the
+// configured status code.
+type receiver struct {
+	server *httptest.Server
+	mu     sync.Mutex
+	status int
+	reqs   []receivedRequest
+}
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func newReceiver() *receiver {
+	rcv := receiver{
+		status: http.StatusOK,
+	}
+
+	rcv.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		rcv.mu.Lock()
+		defer rcv.mu.Unlock()
+
+		rcv.reqs = append(rcv.reqs, receivedRequest{header: r.Header, body: body})
+		w.WriteHeader(rcv.status)
+	}))
+
+	return &rcv
+}
+
+func (rcv *receiver) reset(status int) {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	rcv.status = status
+	rcv.reqs = nil
+}
+
+func (rcv *receiver) requests() []receivedRequest {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	return rcv.reqs
+}
+
+// =============================================================================
+
+func insertSeedData(busDomain dbtest.BusDomain, url string) (unitest.SeedData, error) {
+	ctx := context.Background()
+
+	usrs, err := userbus.TestSeedUsers(ctx, 1, role.User, busDomain.User)
+	if err != nil {
+		return unitest.SeedData{}, fmt.Errorf("seeding users : %w", err)
+	}
+
+	subs, err := webhookbus.TestSeedSubscriptions(ctx, 2, url, []string{webhookbus.EventProductCreated}, busDomain.Webhook)
+	if err != nil {
+		return unitest.SeedData{}, fmt.Errorf("seeding subscriptions : %w", err)
+	}
+
+	sd := unitest.SeedData{
+		Users: []unitest.User{
+			{User: usrs[0]},
+		},
+		Subscriptions: subs,
+	}
+
+	return sd, nil
+}
+
+// =============================================================================
+
+func query(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
+	table := []unitest.Table{
+		{
+			Name:    "byid",
+			ExpResp: sd.Subscriptions[0],
+			ExcFunc: func(ctx context.Context) any {
+				resp, err := busDomain.Webhook.QueryByID(ctx, sd.Subscriptions[0].ID)
+				if err != nil {
+					return err
+				}
+
+				return resp
+			},
+			CmpFunc: func(got any, exp any) string {
+				gotResp, exists := got.(webhookbus.Subscription)
+				if !exists {
+					return "error occurred"
+				}
+
+				expResp := exp.(webhookbus.Subscription)
+
+				if gotResp.DateCreated.Format(time.RFC3339) == expResp.DateCreated.Format(time.RFC3339) {
+					expResp.DateCreated = gotResp.DateCreated
+				}
+
+				if gotResp.DateUpdated.Format(time.RFC3339) == expResp.DateUpdated.Format(time.RFC3339) {
+					expResp.DateUpdated = gotResp.DateUpdated
+				}
+
+				return cmp.Diff(gotResp, expResp)
+			},
+		},
+		{
+			Name:    "byevent",
+			ExpResp: len(sd.Subscriptions),
+			ExcFunc: func(ctx context.Context) any {
+				filter := webhookbus.QueryFilter{
+					Event: dbtest.StringPointer(webhookbus.EventProductCreated),
+				}
+
+				resp, err := busDomain.Webhook.Count(ctx, filter)
+				if err != nil {
+					return err
+				}
+
+				return resp
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+func create(busDomain dbtest.BusDomain) []unitest.Table {
+	table := []unitest.Table{
+		{
+			Name: "basic",
+			ExpResp: webhookbus.Subscription{
+				URL:     "https://partner.example.com/hooks",
+				Events:  []string{webhookbus.EventProductUpdated, webhookbus.EventUserDeleted},
+				Secret:  "0123456789abcdef0123456789abcdef",
+				Enabled: true,
+			},
+			ExcFunc: func(ctx context.Context) any {
+				ns := webhookbus.NewSubscription{
+					URL:    "https://partner.example.com/hooks",
+					Events: []string{webhookbus.EventProductUpdated, webhookbus.EventUserDeleted},
+					Secret: "0123456789abcdef0123456789abcdef",
+				}
+
+				resp, err := busDomain.Webhook.Create(ctx, ns)
+				if err != nil {
+					return err
+				}
+
+				return resp
+			},
+			CmpFunc: func(got any, exp any) string {
+				gotResp, exists := got.(webhookbus.Subscription)
+				if !exists {
+					return "error occurred"
+				}
+
+				expResp := exp.(webhookbus.Subscription)
+
+				expResp.ID = gotResp.ID
+				expResp.DateCreated = gotResp.DateCreated
+				expResp.DateUpdated = gotResp.DateUpdated
+
+				return cmp.Diff(gotResp, expResp)
+			},
+		},
+		{
+			Name:    "unknown-event",
+			ExpResp: true,
+			ExcFunc: func(ctx context.Context) any {
+				ns := webhookbus.NewSubscription{
+					URL:    "https://partner.example.com/hooks",
+					Events: []string{"order.created"},
+					Secret: "0123456789abcdef0123456789abcdef",
+				}
+
+				_, err := busDomain.Webhook.Create(ctx, ns)
+
+				return errors.Is(err, webhookbus.ErrUnknownEvent)
+			},
+			CmpFunc: func(got any, exp any) string {
+				return cmp.Diff(got, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+func update(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
+	table := []unitest.Table{
+		{
+			Name: "basic",
+			ExpResp: webhookbus.Subscription{
+				ID:          sd.Subscriptions[1].ID,
+				URL:         sd.Subscriptions[1].URL,
+				Events:      sd.Subscriptions[1].Events,
+				Secret:      sd.Subscriptions[1].Secret,
+				Enabled:     false,
+				DateCreated: sd.Subscriptions[1].DateCreated,
+			},
+			ExcFunc: func(ctx context.Context) any {
+				us := webhookbus.UpdateSubscription{
+					Enabled: dbtest.BoolPointer(false),
+				}
+
+				resp, err := busDomain.Webhook.Update(ctx, sd.Subscriptions[1], us)
+				if err != nil {
+					return err
+				}
+
+				return resp
+			},
+			CmpFunc: func(got any, exp any) string {
+				gotResp, exists := got.(webhookbus.Subscription)
+				if !exists {
+					return "error occurred"
+				}
+
+				expResp := exp.(webhookbus.Subscription)
+
+				expResp.DateUpdated = gotResp.DateUpdated
+
+				return cmp.Diff(gotResp, expResp)
+			},
+		},
+	}
+
+	return table
+}
+
+func deliver(busDomain dbtest.BusDomain, sd unitest.SeedData, sender *webhookbus.Sender, rcv *receiver) []unitest.Table {
+	sub := sd.Subscriptions[0]
+
+	table := []unitest.Table{
+		{
+			Name:    "signed",
+			ExpResp: webhookbus.DeliveryDelivered,
+			ExcFunc: func(ctx context.Context) any {
+				rcv.reset(http.StatusOK)
+
+				prd, err := productbus.TestGenerateSeedProducts(ctx, 1, busDomain.Product, sd.Users[0].ID)
+				if err != nil {
+					return err
+				}
+
+				if _, err := sender.SendOnce(ctx); err != nil {
+					return err
+				}
+
+				// The second subscription was disabled by the update test, so
+				// only the first one receives the event.
+				reqs := rcv.requests()
+				if len(reqs) != 1 {
+					return fmt.Errorf("expected 1 request, got %d", len(reqs))
+				}
+
+				req := reqs[0]
+				if !webhookbus.Verify(sub.Secret, req.header.Get(webhookbus.HeaderTimestamp), req.body, req.header.Get(webhookbus.HeaderSignature)) {
+					return errors.New("signature doesn't match")
+				}
+
+				var payload webhookbus.Payload
+				if err := json.Unmarshal(req.body, &payload); err != nil {
+					return err
+				}
+
+				var params productbus.ActionParms
+				if err := json.Unmarshal(payload.Data, &params); err != nil {
+					return err
+				}
+
+				if payload.Event != webhookbus.EventProductCreated || params.ProductID != prd[0].ID {
+					return fmt.Errorf("unexpected payload: %s", req.body)
+				}
+
+				return lastDelivery(ctx, busDomain, sub.ID)
+			},
+			CmpFunc: func(got any, exp any) string {
+				gotResp, exists := got.(webhookbus.Delivery)
+				if !exists {
+					return fmt.Sprintf("error occurred: %v", got)
+				}
+
+				return cmp.Diff(gotResp.Status, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+func retry(busDomain dbtest.BusDomain, sd unitest.SeedData, sender *webhookbus.Sender, rcv *receiver) []unitest.Table {
+	sub := sd.Subscriptions[0]
+
+	table := []unitest.Table{
+		{
+			Name: "server-error",
+			ExpResp: webhookbus.Delivery{
+				Status:       webhookbus.DeliveryPending,
+				Attempts:     1,
+				ResponseCode: http.StatusInternalServerError,
+				LastError:    "unexpected status code: 500",
+			},
+			ExcFunc: func(ctx context.Context) any {
+				rcv.reset(http.StatusInternalServerError)
+
+				if _, err := productbus.TestGenerateSeedProducts(ctx, 1, busDomain.Product, sd.Users[0].ID); err != nil {
+					return err
+				}
+
+				if _, err := sender.SendOnce(ctx); err != nil {
+					return err
+				}
+
+				return lastDelivery(ctx, busDomain, sub.ID)
+			},
+			CmpFunc: func(got any, exp any) string {
+				gotResp, exists := got.(webhookbus.Delivery)
+				if !exists {
+					return fmt.Sprintf("error occurred: %v", got)
+				}
+
+				gotResp = webhookbus.Delivery{
+					Status:       gotResp.Status,
+					Attempts:     gotResp.Attempts,
+					ResponseCode: gotResp.ResponseCode,
+					LastError:    gotResp.LastError,
+				}
+
+				return cmp.Diff(gotResp, exp)
+			},
+		},
+		{
+			Name: "recovered",
+			ExpResp: webhookbus.Delivery{
+				Status:       webhookbus.DeliveryDelivered,
+				Attempts:     2,
+				ResponseCode: http.StatusOK,
+			},
+			ExcFunc: func(ctx context.Context) any {
+				rcv.reset(http.StatusOK)
+
+				if _, err := sender.SendOnce(ctx); err != nil {
+					return err
+				}
+
+				return lastDelivery(ctx, busDomain, sub.ID)
+			},
+			CmpFunc: func(got any, exp any) string {
+				gotResp, exists := got.(webhookbus.Delivery)
+				if !exists {
+					return fmt.Sprintf("error occurred: %v", got)
+				}
+
+				gotResp = webhookbus.Delivery{
+					Status:       gotResp.Status,
+					Attempts:     gotResp.Attempts,
+					ResponseCode: gotResp.ResponseCode,
+					LastError:    gotResp.LastError,
+				}
+
+				return cmp.Diff(gotResp, exp)
+			},
+		},
+	}
+
+	return table
+}
+
+func delete(busDomain dbtest.BusDomain, sd unitest.SeedData) []unitest.Table {
+	table := []unitest.Table{
+		{
+			Name:    "basic",
+			ExpResp: webhookbus.ErrNotFound,
+			ExcFunc: func(ctx context.Context) any {
+				if err := busDomain.Webhook.Delete(ctx, sd.Subscriptions[1]); err != nil {
+					return err
+				}
+
+				_, err := busDomain.Webhook.QueryByID(ctx, sd.Subscriptions[1].ID)
+
+				return err
+			},
+			CmpFunc: func(got any, exp any) string {
+				gotErr, exists := got.(error)
+				if !exists || !errors.Is(gotErr, exp.(error)) {
+					return fmt.Sprintf("expected %v, got %v", exp, got)
+				}
+
+				return ""
+			},
+		},
+	}
+
+	return table
+}
+
+// =============================================================================
+
+func lastDelivery(ctx context.Context, busDomain dbtest.BusDomain, subscriptionID uuid.UUID) any {
+	filter := webhookbus.DeliveryFilter{
+		SubscriptionID: &subscriptionID,
+	}
+
+	dlvs, err := busDomain.Webhook.QueryDeliveries(ctx, filter, webhookbus.DefaultDeliveryOrderBy, page.MustParse("1", "1"))
+	if err != nil {
+		return err
+	}
+
+	if len(dlvs) != 1 {
+		return fmt.Errorf("expected 1 delivery, got %d", len(dlvs))
+	}
+
+	return dlvs[0]
+}
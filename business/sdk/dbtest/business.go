@@ -5,39 +5,76 @@ import (
 
 	"github.com/jmoiron/sqlx"
 
+	"github.com/rmsj/service/business/domain/auditbus"
+	"github.com/rmsj/service/business/domain/auditbus/stores/auditdb"
 	"github.com/rmsj/service/business/domain/authbus"
 	"github.com/rmsj/service/business/domain/authbus/stores/authdb"
+	"github.com/rmsj/service/business/domain/idempotencybus"
+	"github.com/rmsj/service/business/domain/idempotencybus/stores/idempotencydb"
+	"github.com/rmsj/service/business/domain/identitybus"
+	"github.com/rmsj/service/business/domain/identitybus/stores/identitydb"
+	"github.com/rmsj/service/business/domain/orderbus"
+	"github.com/rmsj/service/business/domain/orderbus/stores/orderdb"
 	"github.com/rmsj/service/business/domain/productbus"
 	"github.com/rmsj/service/business/domain/productbus/stores/productdb"
 	"github.com/rmsj/service/business/domain/userbus"
 	"github.com/rmsj/service/business/domain/userbus/stores/userdb"
 	"github.com/rmsj/service/business/domain/vproductbus"
 	"github.com/rmsj/service/business/domain/vproductbus/stores/vproductdb"
+	"github.com/rmsj/service/business/domain/webhookbus"
+	"github.com/rmsj/service/business/domain/webhookbus/stores/webhookdb"
 	"github.com/rmsj/service/business/sdk/delegate"
+	"github.com/rmsj/service/business/sdk/scheduler"
+	"github.com/rmsj/service/business/sdk/scheduler/stores/schedulerdb"
 	"github.com/rmsj/service/foundation/logger"
 )
 
 // BusDomain represents all the business domain apis needed for testing.
 type BusDomain struct {
-	Delegate *delegate.Delegate
-	Auth     *authbus.Business
-	Product  *productbus.Business
-	User     *userbus.Business
-	VProduct *vproductbus.Business
+	Delegate    *delegate.Delegate
+	Audit       *auditbus.Business
+	Auth        *authbus.Business
+	Idempotency *idempotencybus.Business
+	Identity    *identitybus.Business
+	Order       *orderbus.Business
+	Product     *productbus.Business
+	User        *userbus.Business
+	VProduct    *vproductbus.Business
+	Webhook     *webhookbus.Business
+
+	// The scheduler has no worker, tasks can't be run.
+	Scheduler *scheduler.Scheduler
 }
 
 func newBusDomains(log *logger.Logger, db *sqlx.DB) BusDomain {
 	dlg := delegate.New(log)
-	authBus := authbus.NewBusiness(log, authdb.NewStore(log, db))
+	auditBus := auditbus.NewBusiness(log, auditdb.NewStore(log, db))
+	authBus := authbus.NewBusiness(log, auditBus, authdb.NewStore(log, db))
+	idempotencyBus := idempotencybus.NewBusiness(log, idempotencydb.NewStore(log, db))
 	userBus := userbus.NewBusiness(log, dlg, userdb.NewStore(log, db, time.Hour))
+	identityBus := identitybus.NewBusiness(log, userBus, identitydb.NewStore(log, db))
 	productBus := productbus.NewBusiness(log, userBus, dlg, productdb.NewStore(log, db))
+	orderBus := orderbus.NewBusiness(log, userBus, productBus, dlg, orderdb.NewStore(log, db))
 	vproductBus := vproductbus.NewBusiness(vproductdb.NewStore(log, db))
+	webhookBus := webhookbus.NewBusiness(log, dlg, webhookdb.NewStore(log, db))
+
+	sch := scheduler.New(scheduler.Config{
+		Log:    log,
+		Storer: schedulerdb.NewStore(log, db),
+	})
 
 	return BusDomain{
-		Delegate: dlg,
-		Auth:     authBus,
-		Product:  productBus,
-		User:     userBus,
-		VProduct: vproductBus,
+		Delegate:    dlg,
+		Audit:       auditBus,
+		Auth:        authBus,
+		Idempotency: idempotencyBus,
+		Identity:    identityBus,
+		Order:       orderBus,
+		Product:     productBus,
+		User:        userBus,
+		VProduct:    vproductBus,
+		Webhook:     webhookBus,
+
+		Scheduler: sch,
 	}
 }
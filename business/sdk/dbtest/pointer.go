@@ -50,11 +50,11 @@ func NameNullPointer(value string) *name.Null {
 	return &name
 }
 
-// MoneyPointer is a helper to get a *Money from a float. It's in the tests
-// package because we normally don't want to deal with pointers to basic types
-// but it's useful in some tests.
-func MoneyPointer(value float64) *money.Money {
-	money := money.MustParse(value)
+// MoneyPointer is a helper to get a *Money from an amount and currency. It's in
+// the tests package because we normally don't want to deal with pointers to
+// basic types but it's useful in some tests.
+func MoneyPointer(amount string, currency string) *money.Money {
+	money := money.MustParse(amount, currency)
 	return &money
 }
 
@@ -4,7 +4,13 @@ package delegate
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/sdk/sqldb"
 	"github.com/rmsj/service/foundation/logger"
 )
 
@@ -18,48 +24,141 @@ type (
 // Delegate manages the set of functions to be called by domain
 // packages when an import is not possible.
 type Delegate struct {
-	log   *logger.Logger
-	funcs map[domain]map[action][]Func
+	log        *logger.Logger
+	storer     Storer
+	funcs      map[domain]map[action][]Func
+	asyncFuncs map[domain]map[action][]Func
 }
 
-// New constructs a delegate for indirect api access.
+// New constructs a delegate for indirect api access. Functions registered
+// with RegisterAsync are executed on the G making the call since there is
+// no outbox to persist the events.
 func New(log *logger.Logger) *Delegate {
 	return &Delegate{
-		log:   log,
-		funcs: make(map[domain]map[action][]Func),
+		log:        log,
+		funcs:      make(map[domain]map[action][]Func),
+		asyncFuncs: make(map[domain]map[action][]Func),
 	}
 }
 
-// Register adds a function to be called for a specified domain and action.
-func (d *Delegate) Register(domainType string, actionType string, fn Func) {
-	aMap, ok := d.funcs[domain(domainType)]
-	if !ok {
-		aMap = make(map[action][]Func)
-		d.funcs[domain(domainType)] = aMap
+// NewWithOutbox constructs a delegate that writes the events for functions
+// registered with RegisterAsync to the outbox. A Dispatcher is required to
+// deliver those events.
+func NewWithOutbox(log *logger.Logger, storer Storer) *Delegate {
+	d := New(log)
+	d.storer = storer
+
+	return d
+}
+
+// NewWithTx constructs a new delegate value that will write outbox events
+// using the specified transaction, so the events are only persisted if the
+// business change is committed. It's safe to call on a nil delegate.
+func (d *Delegate) NewWithTx(tx sqldb.CommitRollbacker) (*Delegate, error) {
+	if d == nil || d.storer == nil {
+		return d, nil
+	}
+
+	storer, err := d.storer.NewWithTx(tx)
+	if err != nil {
+		return nil, err
 	}
 
-	funcs := aMap[action(actionType)]
-	funcs = append(funcs, fn)
-	aMap[action(actionType)] = funcs
+	dlg := *d
+	dlg.storer = storer
+
+	return &dlg, nil
+}
+
+// Register adds a function to be called synchronously for a specified domain
+// and action. The function runs on the G making the call, inside the same
+// transaction as the caller if there is one.
+func (d *Delegate) Register(domainType string, actionType string, fn Func) {
+	register(d.funcs, domainType, actionType, fn)
+}
+
+// RegisterAsync adds a function to be called for a specified domain and
+// action once the event is dispatched from the outbox. The function can be
+// called more than once for the same event, so it must be idempotent.
+func (d *Delegate) RegisterAsync(domainType string, actionType string, fn Func) {
+	register(d.asyncFuncs, domainType, actionType, fn)
 }
 
-// Call executes all functions registered for the specified domain and
-// action. These functions are executed synchronously on the G making the call.
+// Call executes all functions registered synchronously for the specified
+// domain and action and writes an outbox event if asynchronous functions are
+// registered for it.
 func (d *Delegate) Call(ctx context.Context, data Data) error {
 	d.log.Info(ctx, "delegate call", "status", "started", "domain", data.Domain, "action", data.Action, "params", data.RawParams)
 	defer d.log.Info(ctx, "delegate call", "status", "completed")
 
-	if dMap, ok := d.funcs[domain(data.Domain)]; ok {
-		if funcs, ok := dMap[action(data.Action)]; ok {
-			for _, fn := range funcs {
-				d.log.Info(ctx, "delegate call", "status", "sending")
+	if err := d.execute(ctx, d.funcs, data); err != nil {
+		return err
+	}
+
+	if len(lookup(d.asyncFuncs, data)) == 0 {
+		return nil
+	}
+
+	if d.storer == nil {
+		return d.execute(ctx, d.asyncFuncs, data)
+	}
+
+	now := time.Now()
+
+	evt := Event{
+		ID:          uuid.New(),
+		Data:        data,
+		Status:      StatusPending,
+		RunAt:       now,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if err := d.storer.Create(ctx, evt); err != nil {
+		return fmt.Errorf("outbox: create: %w", err)
+	}
+
+	d.log.Info(ctx, "delegate call", "status", "event stored in outbox", "event_id", evt.ID)
+
+	return nil
+}
+
+// deliver executes all functions registered asynchronously for the event.
+func (d *Delegate) deliver(ctx context.Context, data Data) error {
+	return d.execute(ctx, d.asyncFuncs, data)
+}
+
+func (d *Delegate) execute(ctx context.Context, funcs map[domain]map[action][]Func, data Data) error {
+	var errs []error
+
+	for _, fn := range lookup(funcs, data) {
+		d.log.Info(ctx, "delegate call", "status", "sending")
 
-				if err := fn(ctx, data); err != nil {
-					d.log.Error(ctx, "delegate call", "err", err)
-				}
-			}
+		if err := fn(ctx, data); err != nil {
+			d.log.Error(ctx, "delegate call", "err", err)
+			errs = append(errs, err)
 		}
 	}
 
+	return errors.Join(errs...)
+}
+
+// =============================================================================
+
+func register(funcs map[domain]map[action][]Func, domainType string, actionType string, fn Func) {
+	aMap, ok := funcs[domain(domainType)]
+	if !ok {
+		aMap = make(map[action][]Func)
+		funcs[domain(domainType)] = aMap
+	}
+
+	aMap[action(actionType)] = append(aMap[action(actionType)], fn)
+}
+
+func lookup(funcs map[domain]map[action][]Func, data Data) []Func {
+	if dMap, ok := funcs[domain(data.Domain)]; ok {
+		return dMap[action(data.Action)]
+	}
+
 	return nil
 }
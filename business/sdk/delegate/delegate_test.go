@@ -0,0 +1,160 @@
+package delegate_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/sdk/delegate"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/foundation/logger"
+)
+
+func Test_Call(t *testing.T) {
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	store := newMemStore()
+	dlg := delegate.NewWithOutbox(log, store)
+
+	syncErr := errors.New("sync failed")
+	dlg.Register("user", "deleted", func(context.Context, delegate.Data) error {
+		return syncErr
+	})
+
+	dlg.RegisterAsync("user", "updated", func(context.Context, delegate.Data) error {
+		return nil
+	})
+
+	ctx := context.Background()
+
+	if err := dlg.Call(ctx, delegate.Data{Domain: "user", Action: "deleted"}); !errors.Is(err, syncErr) {
+		t.Fatalf("Should return the error from a sync function : got %v", err)
+	}
+
+	if err := dlg.Call(ctx, delegate.Data{Domain: "user", Action: "updated"}); err != nil {
+		t.Fatalf("Should be able to call the delegate : %s", err)
+	}
+
+	if len(store.events) != 1 {
+		t.Fatalf("Should have stored one outbox event : got %d", len(store.events))
+	}
+
+	for _, evt := range store.events {
+		if evt.Status != delegate.StatusPending {
+			t.Errorf("Should store the event as pending : got %s", evt.Status)
+		}
+	}
+}
+
+func Test_Dispatcher(t *testing.T) {
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	store := newMemStore()
+	dlg := delegate.NewWithOutbox(log, store)
+
+	var calls int
+	dlg.RegisterAsync("user", "deleted", func(context.Context, delegate.Data) error {
+		calls++
+		return errors.New("delivery failed")
+	})
+
+	dispatcher := delegate.NewDispatcher(delegate.DispatcherConfig{
+		Log:         log,
+		Delegate:    dlg,
+		Beginner:    store,
+		Storer:      store,
+		MaxAttempts: 2,
+		MinBackoff:  time.Nanosecond,
+		MaxBackoff:  time.Nanosecond,
+	})
+
+	ctx := context.Background()
+
+	if err := dlg.Call(ctx, delegate.Data{Domain: "user", Action: "deleted"}); err != nil {
+		t.Fatalf("Should be able to call the delegate : %s", err)
+	}
+
+	for range 3 {
+		time.Sleep(time.Millisecond)
+
+		if _, err := dispatcher.DispatchOnce(ctx); err != nil {
+			t.Fatalf("Should be able to dispatch : %s", err)
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("Should have attempted delivery twice : got %d", calls)
+	}
+
+	for _, evt := range store.events {
+		if evt.Status != delegate.StatusDead {
+			t.Errorf("Should have moved the event to dead letter : got %s", evt.Status)
+		}
+
+		if evt.Attempts != 2 {
+			t.Errorf("Should have recorded two attempts : got %d", evt.Attempts)
+		}
+	}
+}
+
+// =============================================================================
+
+type memStore struct {
+	mu     *sync.Mutex
+	events map[uuid.UUID]delegate.Event
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		mu:     &sync.Mutex{},
+		events: make(map[uuid.UUID]delegate.Event),
+	}
+}
+
+func (s *memStore) Begin() (sqldb.CommitRollbacker, error) {
+	return memTx{}, nil
+}
+
+func (s *memStore) NewWithTx(tx sqldb.CommitRollbacker) (delegate.Storer, error) {
+	return s, nil
+}
+
+func (s *memStore) Create(ctx context.Context, evt delegate.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events[evt.ID] = evt
+	return nil
+}
+
+func (s *memStore) Update(ctx context.Context, evt delegate.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events[evt.ID] = evt
+	return nil
+}
+
+func (s *memStore) QueryDue(ctx context.Context, now time.Time, limit int) ([]delegate.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var evts []delegate.Event
+	for _, evt := range s.events {
+		if evt.Status == delegate.StatusPending && !evt.RunAt.After(now) && len(evts) < limit {
+			evts = append(evts, evt)
+		}
+	}
+
+	return evts, nil
+}
+
+type memTx struct{}
+
+func (memTx) Commit() error   { return nil }
+func (memTx) Rollback() error { return nil }
@@ -3,6 +3,9 @@ package delegate
 import (
 	"context"
 	"fmt"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 // Func represents a function that is registered and called by the system.
@@ -22,3 +25,41 @@ func (d Data) String() string {
 		d.Domain, d.Action, string(d.RawParams),
 	)
 }
+
+// Set of statuses an outbox event can be in.
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead"
+)
+
+// Event represents an event stored in the outbox waiting to be delivered.
+type Event struct {
+	ID          uuid.UUID
+	Data        Data
+	Status      string
+	Attempts    int
+	LastError   string
+	RunAt       time.Time
+	DateCreated time.Time
+	DateUpdated time.Time
+}
+
+// =============================================================================
+
+type ctxKey int
+
+const eventIDKey ctxKey = 1
+
+func setEventID(ctx context.Context, eventID uuid.UUID) context.Context {
+	return context.WithValue(ctx, eventIDKey, eventID)
+}
+
+// GetEventID returns the ID of the outbox event being delivered. Functions
+// registered with RegisterAsync can use it to detect an event that is
+// delivered more than once. It's not available when the function is
+// executed inline because there is no outbox.
+func GetEventID(ctx context.Context) (uuid.UUID, bool) {
+	v, ok := ctx.Value(eventIDKey).(uuid.UUID)
+	return v, ok
+}
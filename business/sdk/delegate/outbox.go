@@ -0,0 +1,175 @@
+package delegate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/foundation/logger"
+	"github.com/rmsj/service/foundation/worker"
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve outbox events.
+type Storer interface {
+	NewWithTx(tx sqldb.CommitRollbacker) (Storer, error)
+	Create(ctx context.Context, evt Event) error
+	Update(ctx context.Context, evt Event) error
+	QueryDue(ctx context.Context, now time.Time, limit int) ([]Event, error)
+}
+
+// DispatcherConfig contains the settings for the outbox dispatcher.
+type DispatcherConfig struct {
+	Log         *logger.Logger
+	Delegate    *Delegate
+	Beginner    sqldb.Beginner
+	Storer      Storer
+	Interval    time.Duration
+	BatchSize   int
+	Lease       time.Duration
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Dispatcher delivers the events stored in the outbox to the functions
+// registered with RegisterAsync. Events are delivered at least once: an
+// event claimed by a dispatcher that dies before recording the result is
+// delivered again once the lease expires.
+type Dispatcher struct {
+	cfg DispatcherConfig
+}
+
+// NewDispatcher constructs a dispatcher for the outbox.
+func NewDispatcher(cfg DispatcherConfig) *Dispatcher {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+
+	if cfg.Lease <= 0 {
+		cfg.Lease = time.Minute
+	}
+
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 10
+	}
+
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = time.Second
+	}
+
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = time.Hour
+	}
+
+	return &Dispatcher{
+		cfg: cfg,
+	}
+}
+
+// Run dispatches the due events on every interval until the context is
+// cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	d.cfg.Log.Info(ctx, "outbox dispatcher", "status", "started", "interval", d.cfg.Interval)
+	defer d.cfg.Log.Info(ctx, "outbox dispatcher", "status", "stopped")
+
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if _, err := d.DispatchOnce(ctx); err != nil {
+				d.cfg.Log.Error(ctx, "outbox dispatcher", "status", "dispatch failed", "err", err)
+			}
+		}
+	}
+}
+
+// DispatchOnce claims a batch of due events and delivers them. It returns the
+// number of events that were delivered successfully.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	evts, err := d.claim(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("claim: %w", err)
+	}
+
+	var delivered int
+
+	for _, evt := range evts {
+		err := d.cfg.Delegate.deliver(setEventID(ctx, evt.ID), evt.Data)
+
+		now := time.Now()
+
+		switch {
+		case err == nil:
+			evt.Status = StatusDelivered
+			evt.LastError = ""
+			delivered++
+
+		case evt.Attempts >= d.cfg.MaxAttempts:
+			evt.Status = StatusDead
+			evt.LastError = err.Error()
+			d.cfg.Log.Error(ctx, "outbox dispatcher", "status", "event moved to dead letter", "event_id", evt.ID, "attempts", evt.Attempts, "err", err)
+
+		default:
+			evt.RunAt = now.Add(worker.Backoff(evt.Attempts, d.cfg.MinBackoff, d.cfg.MaxBackoff))
+			evt.LastError = err.Error()
+			d.cfg.Log.Info(ctx, "outbox dispatcher", "status", "event delivery failed", "event_id", evt.ID, "attempts", evt.Attempts, "retry_at", evt.RunAt, "err", err)
+		}
+
+		evt.DateUpdated = now
+
+		if err := d.cfg.Storer.Update(ctx, evt); err != nil {
+			return delivered, fmt.Errorf("update: eventID[%s]: %w", evt.ID, err)
+		}
+	}
+
+	return delivered, nil
+}
+
+// claim locks the due events, counts the delivery attempt and hides them from
+// other dispatchers for the duration of the lease.
+func (d *Dispatcher) claim(ctx context.Context) ([]Event, error) {
+	tx, err := d.cfg.Beginner.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	storer, err := d.cfg.Storer.NewWithTx(tx)
+	if err != nil {
+		return nil, fmt.Errorf("newwithtx: %w", err)
+	}
+
+	now := time.Now()
+
+	evts, err := storer.QueryDue(ctx, now, d.cfg.BatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("querydue: %w", err)
+	}
+
+	for i := range evts {
+		evts[i].Attempts++
+		evts[i].RunAt = now.Add(d.cfg.Lease)
+		evts[i].DateUpdated = now
+
+		if err := storer.Update(ctx, evts[i]); err != nil {
+			return nil, fmt.Errorf("update: eventID[%s]: %w", evts[i].ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return evts, nil
+}
@@ -0,0 +1,70 @@
+package outboxdb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rmsj/service/business/sdk/delegate"
+)
+
+type event struct {
+	ID          uuid.UUID      `db:"event_id"`
+	Domain      string         `db:"domain"`
+	Action      string         `db:"action"`
+	Params      string         `db:"params"`
+	Status      string         `db:"status"`
+	Attempts    int            `db:"attempts"`
+	LastError   sql.NullString `db:"last_error"`
+	RunAt       time.Time      `db:"run_at"`
+	DateCreated time.Time      `db:"created_at"`
+	DateUpdated time.Time      `db:"updated_at"`
+}
+
+func toDBEvent(bus delegate.Event) event {
+	db := event{
+		ID:       bus.ID,
+		Domain:   bus.Data.Domain,
+		Action:   bus.Data.Action,
+		Params:   string(bus.Data.RawParams),
+		Status:   bus.Status,
+		Attempts: bus.Attempts,
+		LastError: sql.NullString{
+			String: bus.LastError,
+			Valid:  bus.LastError != "",
+		},
+		RunAt:       bus.RunAt.UTC(),
+		DateCreated: bus.DateCreated.UTC(),
+		DateUpdated: bus.DateUpdated.UTC(),
+	}
+
+	return db
+}
+
+func toBusEvent(db event) delegate.Event {
+	bus := delegate.Event{
+		ID: db.ID,
+		Data: delegate.Data{
+			Domain:    db.Domain,
+			Action:    db.Action,
+			RawParams: []byte(db.Params),
+		},
+		Status:      db.Status,
+		Attempts:    db.Attempts,
+		LastError:   db.LastError.String,
+		RunAt:       db.RunAt.In(time.Local),
+		DateCreated: db.DateCreated.In(time.Local),
+		DateUpdated: db.DateUpdated.In(time.Local),
+	}
+
+	return bus
+}
+
+func toBusEvents(dbs []event) []delegate.Event {
+	bus := make([]delegate.Event, len(dbs))
+	for i, db := range dbs {
+		bus[i] = toBusEvent(db)
+	}
+
+	return bus
+}
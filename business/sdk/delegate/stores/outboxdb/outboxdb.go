@@ -0,0 +1,111 @@
+// Package outboxdb contains outbox related CRUD functionality.
+package outboxdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/rmsj/service/business/sdk/delegate"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/foundation/logger"
+)
+
+// Store manages the set of APIs for outbox database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// NewWithTx constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (delegate.Storer, error) {
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return &store, nil
+}
+
+// Create adds an event to the outbox.
+func (s *Store) Create(ctx context.Context, evt delegate.Event) error {
+	const q = `
+	INSERT INTO outbox
+		(event_id, domain, action, params, status, attempts, last_error, run_at, created_at, updated_at)
+	VALUES
+		(:event_id, :domain, :action, :params, :status, :attempts, :last_error, :run_at, :created_at, :updated_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBEvent(evt)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update records the delivery state of an event.
+func (s *Store) Update(ctx context.Context, evt delegate.Event) error {
+	const q = `
+	UPDATE
+		outbox
+	SET
+		status = :status,
+		attempts = :attempts,
+		last_error = :last_error,
+		run_at = :run_at,
+		updated_at = :updated_at
+	WHERE
+		event_id = :event_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBEvent(evt)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryDue retrieves the pending events that are due for delivery. The rows
+// are locked for the remainder of the transaction, skipping the ones already
+// locked by another dispatcher.
+func (s *Store) QueryDue(ctx context.Context, now time.Time, limit int) ([]delegate.Event, error) {
+	data := map[string]any{
+		"status": delegate.StatusPending,
+		"now":    now.UTC(),
+		"limit":  limit,
+	}
+
+	const q = `
+	SELECT
+		event_id, domain, action, params, status, attempts, last_error, run_at, created_at, updated_at
+	FROM
+		outbox
+	WHERE
+		status = :status AND
+		run_at <= :now
+	ORDER BY
+		run_at
+	LIMIT :limit
+	FOR UPDATE SKIP LOCKED`
+
+	var dbEvts []event
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbEvts); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusEvents(dbEvts), nil
+}
@@ -0,0 +1,127 @@
+// Package filter provides support for describing the filtering of data with
+// conditions on fields, grouped with AND and OR.
+package filter
+
+import (
+	"fmt"
+)
+
+// Set of operators for the conditions on a field.
+const (
+	EQ      = "eq"
+	NE      = "ne"
+	GT      = "gt"
+	GTE     = "gte"
+	LT      = "lt"
+	LTE     = "lte"
+	IN      = "in"
+	LIKE    = "like"
+	BETWEEN = "between"
+	NULL    = "null"
+	NOTNULL = "notnull"
+)
+
+// Set of logical operators for joining the expressions of a group.
+const (
+	AND = "AND"
+	OR  = "OR"
+)
+
+// Expr represents a filter expression, which is either a condition on a field
+// or a group of expressions joined by AND or OR. The zero value filters
+// nothing.
+type Expr struct {
+	Field  string
+	Op     string
+	Values []any
+	Join   string
+	Exprs  []Expr
+}
+
+// NewCond constructs a condition on a field. The field is one of the codes
+// the business package of the data declares.
+func NewCond(field string, op string, values ...any) Expr {
+	return Expr{
+		Field:  field,
+		Op:     op,
+		Values: values,
+	}
+}
+
+// NewAnd constructs a group of expressions that must all be true.
+func NewAnd(exprs ...Expr) Expr {
+	return Expr{
+		Join:  AND,
+		Exprs: exprs,
+	}
+}
+
+// NewOr constructs a group of expressions where any must be true.
+func NewOr(exprs ...Expr) Expr {
+	return Expr{
+		Join:  OR,
+		Exprs: exprs,
+	}
+}
+
+// IsZero reports if the expression filters nothing.
+func (e Expr) IsZero() bool {
+	return e.Field == "" && len(e.Exprs) == 0
+}
+
+// IsGroup reports if the expression is a group of expressions.
+func (e Expr) IsGroup() bool {
+	return e.Join != ""
+}
+
+// Validate checks the expression is well formed, with known operators and
+// the number of values they take.
+func (e Expr) Validate() error {
+	if e.IsGroup() {
+		if e.Join != AND && e.Join != OR {
+			return fmt.Errorf("unknown join %q", e.Join)
+		}
+
+		for _, expr := range e.Exprs {
+			if err := expr.Validate(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if e.Field == "" {
+		return fmt.Errorf("condition without a field")
+	}
+
+	want, exists := arity[e.Op]
+	if !exists {
+		return fmt.Errorf("unknown operator %q", e.Op)
+	}
+
+	switch {
+	case want < 0 && len(e.Values) == 0:
+		return fmt.Errorf("operator %q takes values", e.Op)
+	case want >= 0 && len(e.Values) != want:
+		return fmt.Errorf("operator %q takes %d values", e.Op, want)
+	}
+
+	return nil
+}
+
+// arity is the number of values an operator takes, where -1 means one or
+// more.
+var arity = map[string]int{
+	EQ:      1,
+	NE:      1,
+	GT:      1,
+	GTE:     1,
+	LT:      1,
+	LTE:     1,
+	IN:      -1,
+	LIKE:    1,
+	BETWEEN: 2,
+	NULL:    0,
+	NOTNULL: 0,
+}
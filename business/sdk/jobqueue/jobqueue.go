@@ -0,0 +1,235 @@
+// Package jobqueue provides a persistent queue of jobs that are executed in
+// the background by a Runner.
+package jobqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/sdk/order"
+	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/foundation/logger"
+	"github.com/rmsj/service/foundation/otel"
+)
+
+// DefaultMaxAttempts is the number of attempts used when a job doesn't
+// specify one.
+const DefaultMaxAttempts = 5
+
+// Set of error variables for queue operations.
+var (
+	ErrNotFound       = errors.New("job not found")
+	ErrInvalidType    = errors.New("job type is required")
+	ErrInvalidPayload = errors.New("job payload must be valid JSON")
+	ErrInvalidStatus  = errors.New("operation not allowed in the job status")
+	ErrNoHandler      = errors.New("no handler registered")
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve jobs.
+type Storer interface {
+	NewWithTx(tx sqldb.CommitRollbacker) (Storer, error)
+	Create(ctx context.Context, job Job) error
+	Update(ctx context.Context, job Job, status string) (bool, error)
+	Query(ctx context.Context, filter QueryFilter, orderBy order.By, page page.Page) ([]Job, error)
+	Count(ctx context.Context, filter QueryFilter) (int, error)
+	QueryByID(ctx context.Context, jobID uuid.UUID) (Job, error)
+	QueryDue(ctx context.Context, now time.Time, limit int) ([]Job, error)
+}
+
+// Queue manages the set of APIs for job access.
+type Queue struct {
+	log      *logger.Logger
+	storer   Storer
+	mu       *sync.RWMutex
+	handlers map[string]HandlerFunc
+}
+
+// New constructs a job queue for use.
+func New(log *logger.Logger, storer Storer) *Queue {
+	return &Queue{
+		log:      log,
+		storer:   storer,
+		mu:       &sync.RWMutex{},
+		handlers: make(map[string]HandlerFunc),
+	}
+}
+
+// NewWithTx constructs a new queue value that will use the specified
+// transaction in any store related calls, so a job is only enqueued if the
+// change that produced it is committed.
+func (q *Queue) NewWithTx(tx sqldb.CommitRollbacker) (*Queue, error) {
+	storer, err := q.storer.NewWithTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	queue := *q
+	queue.storer = storer
+
+	return &queue, nil
+}
+
+// Register adds the function that executes the jobs of the specified type.
+func (q *Queue) Register(jobType string, fn HandlerFunc) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.handlers[jobType] = fn
+}
+
+// Enqueue adds a new job to the queue.
+func (q *Queue) Enqueue(ctx context.Context, nj NewJob) (Job, error) {
+	ctx, span := otel.AddSpan(ctx, "business.jobqueue.enqueue")
+	defer span.End()
+
+	if nj.Type == "" {
+		return Job{}, ErrInvalidType
+	}
+
+	payload := nj.Payload
+	if len(payload) == 0 {
+		payload = []byte("{}")
+	}
+
+	if !json.Valid(payload) {
+		return Job{}, ErrInvalidPayload
+	}
+
+	maxAttempts := nj.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+
+	now := time.Now()
+
+	runAt := nj.RunAt
+	if runAt.IsZero() {
+		runAt = now
+	}
+
+	job := Job{
+		ID:          uuid.New(),
+		Type:        nj.Type,
+		Payload:     payload,
+		Status:      StatusQueued,
+		MaxAttempts: maxAttempts,
+		RunAt:       runAt.Add(nj.Delay),
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if err := q.storer.Create(ctx, job); err != nil {
+		return Job{}, fmt.Errorf("create: %w", err)
+	}
+
+	return job, nil
+}
+
+// Retry queues a failed or cancelled job to run again with a fresh set of
+// attempts. The job must still be in the status it was read with.
+func (q *Queue) Retry(ctx context.Context, job Job) (Job, error) {
+	ctx, span := otel.AddSpan(ctx, "business.jobqueue.retry")
+	defer span.End()
+
+	if job.Status != StatusFailed && job.Status != StatusCancelled {
+		return Job{}, fmt.Errorf("retry: status[%s]: %w", job.Status, ErrInvalidStatus)
+	}
+
+	now := time.Now()
+
+	status := job.Status
+
+	job.Status = StatusQueued
+	job.Attempts = 0
+	job.LastError = ""
+	job.RunAt = now
+	job.DateUpdated = now
+
+	updated, err := q.storer.Update(ctx, job, status)
+	if err != nil {
+		return Job{}, fmt.Errorf("update: %w", err)
+	}
+
+	if !updated {
+		return Job{}, fmt.Errorf("retry: status[%s] changed: %w", status, ErrInvalidStatus)
+	}
+
+	return job, nil
+}
+
+// Cancel stops a queued job from running. Jobs that are already running
+// can't be cancelled, even when a runner claimed them after they were read.
+func (q *Queue) Cancel(ctx context.Context, job Job) (Job, error) {
+	ctx, span := otel.AddSpan(ctx, "business.jobqueue.cancel")
+	defer span.End()
+
+	if job.Status != StatusQueued {
+		return Job{}, fmt.Errorf("cancel: status[%s]: %w", job.Status, ErrInvalidStatus)
+	}
+
+	job.Status = StatusCancelled
+	job.DateUpdated = time.Now()
+
+	updated, err := q.storer.Update(ctx, job, StatusQueued)
+	if err != nil {
+		return Job{}, fmt.Errorf("update: %w", err)
+	}
+
+	if !updated {
+		return Job{}, fmt.Errorf("cancel: status[%s] changed: %w", StatusQueued, ErrInvalidStatus)
+	}
+
+	return job, nil
+}
+
+// Query retrieves a list of existing jobs.
+func (q *Queue) Query(ctx context.Context, filter QueryFilter, orderBy order.By, page page.Page) ([]Job, error) {
+	ctx, span := otel.AddSpan(ctx, "business.jobqueue.query")
+	defer span.End()
+
+	jobs, err := q.storer.Query(ctx, filter, orderBy, page)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// Count returns the total number of jobs.
+func (q *Queue) Count(ctx context.Context, filter QueryFilter) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.jobqueue.count")
+	defer span.End()
+
+	return q.storer.Count(ctx, filter)
+}
+
+// QueryByID finds the job by the specified ID.
+func (q *Queue) QueryByID(ctx context.Context, jobID uuid.UUID) (Job, error) {
+	ctx, span := otel.AddSpan(ctx, "business.jobqueue.querybyid")
+	defer span.End()
+
+	job, err := q.storer.QueryByID(ctx, jobID)
+	if err != nil {
+		return Job{}, fmt.Errorf("query: jobID[%s]: %w", jobID, err)
+	}
+
+	return job, nil
+}
+
+// =============================================================================
+
+func (q *Queue) handler(jobType string) (HandlerFunc, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	fn, exists := q.handlers[jobType]
+	return fn, exists
+}
@@ -0,0 +1,313 @@
+package jobqueue_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/sdk/jobqueue"
+	"github.com/rmsj/service/business/sdk/order"
+	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/foundation/logger"
+	"github.com/rmsj/service/foundation/worker"
+)
+
+func Test_Enqueue(t *testing.T) {
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	queue := jobqueue.New(log, newMemStore())
+
+	ctx := context.Background()
+
+	if _, err := queue.Enqueue(ctx, jobqueue.NewJob{}); !errors.Is(err, jobqueue.ErrInvalidType) {
+		t.Errorf("Should not enqueue a job without a type : got %v", err)
+	}
+
+	if _, err := queue.Enqueue(ctx, jobqueue.NewJob{Type: "report", Payload: []byte("{")}); !errors.Is(err, jobqueue.ErrInvalidPayload) {
+		t.Errorf("Should not enqueue a job with an invalid payload : got %v", err)
+	}
+
+	job, err := queue.Enqueue(ctx, jobqueue.NewJob{Type: "report", Delay: time.Hour})
+	if err != nil {
+		t.Fatalf("Should be able to enqueue a job : %s", err)
+	}
+
+	if job.Status != jobqueue.StatusQueued {
+		t.Errorf("Should queue the job : got %s", job.Status)
+	}
+
+	if job.MaxAttempts != jobqueue.DefaultMaxAttempts {
+		t.Errorf("Should use the default max attempts : got %d", job.MaxAttempts)
+	}
+
+	if string(job.Payload) != "{}" {
+		t.Errorf("Should default to an empty payload : got %s", job.Payload)
+	}
+
+	if job.RunAt.Before(time.Now().Add(59 * time.Minute)) {
+		t.Errorf("Should delay the job : got %s", job.RunAt)
+	}
+
+	if _, err := queue.Retry(ctx, job); !errors.Is(err, jobqueue.ErrInvalidStatus) {
+		t.Errorf("Should not retry a queued job : got %v", err)
+	}
+
+	job, err = queue.Cancel(ctx, job)
+	if err != nil {
+		t.Fatalf("Should be able to cancel a queued job : %s", err)
+	}
+
+	if _, err := queue.Cancel(ctx, job); !errors.Is(err, jobqueue.ErrInvalidStatus) {
+		t.Errorf("Should not cancel a cancelled job : got %v", err)
+	}
+
+	job, err = queue.Retry(ctx, job)
+	if err != nil {
+		t.Fatalf("Should be able to retry a cancelled job : %s", err)
+	}
+
+	if job.Status != jobqueue.StatusQueued {
+		t.Errorf("Should queue the retried job : got %s", job.Status)
+	}
+}
+
+func Test_CancelClaimed(t *testing.T) {
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	store := newMemStore()
+	queue := jobqueue.New(log, store)
+
+	ctx := context.Background()
+
+	job, err := queue.Enqueue(ctx, jobqueue.NewJob{Type: "report"})
+	if err != nil {
+		t.Fatalf("Should be able to enqueue a job : %s", err)
+	}
+
+	// A runner claims the job after it was read to be cancelled.
+	claimed := job
+	claimed.Status = jobqueue.StatusRunning
+
+	if _, err := store.Update(ctx, claimed, jobqueue.StatusQueued); err != nil {
+		t.Fatalf("Should be able to claim the job : %s", err)
+	}
+
+	if _, err := queue.Cancel(ctx, job); !errors.Is(err, jobqueue.ErrInvalidStatus) {
+		t.Errorf("Should not cancel a job a runner claimed : got %v", err)
+	}
+
+	got, err := queue.QueryByID(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the job : %s", err)
+	}
+
+	if got.Status != jobqueue.StatusRunning {
+		t.Errorf("Should keep the job running : got %s", got.Status)
+	}
+}
+
+func Test_Runner(t *testing.T) {
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	store := newMemStore()
+	queue := jobqueue.New(log, store)
+
+	var mu sync.Mutex
+	calls := make(map[string]int)
+
+	queue.Register("ok", func(ctx context.Context, job jobqueue.Job) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		calls[job.Type]++
+		return nil
+	})
+
+	queue.Register("fail", func(ctx context.Context, job jobqueue.Job) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		calls[job.Type]++
+		return errors.New("job failed")
+	})
+
+	wrk, err := worker.New(2)
+	if err != nil {
+		t.Fatalf("Should be able to construct the worker : %s", err)
+	}
+
+	runner := jobqueue.NewRunner(jobqueue.RunnerConfig{
+		Log:        log,
+		Queue:      queue,
+		Beginner:   store,
+		Worker:     wrk,
+		MinBackoff: time.Nanosecond,
+		MaxBackoff: time.Nanosecond,
+	})
+
+	ctx := context.Background()
+
+	okJob, err := queue.Enqueue(ctx, jobqueue.NewJob{Type: "ok"})
+	if err != nil {
+		t.Fatalf("Should be able to enqueue a job : %s", err)
+	}
+
+	failJob, err := queue.Enqueue(ctx, jobqueue.NewJob{Type: "fail", MaxAttempts: 2})
+	if err != nil {
+		t.Fatalf("Should be able to enqueue a job : %s", err)
+	}
+
+	unknownJob, err := queue.Enqueue(ctx, jobqueue.NewJob{Type: "unknown", MaxAttempts: 1})
+	if err != nil {
+		t.Fatalf("Should be able to enqueue a job : %s", err)
+	}
+
+	for range 4 {
+		time.Sleep(time.Millisecond)
+
+		if _, err := runner.RunOnce(ctx); err != nil {
+			t.Fatalf("Should be able to run the jobs : %s", err)
+		}
+
+		// Wait for the worker to finish the jobs it started.
+		for wrk.Available() != 2 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	if calls["ok"] != 1 {
+		t.Errorf("Should have run the job once : got %d", calls["ok"])
+	}
+
+	if calls["fail"] != 2 {
+		t.Errorf("Should have attempted the failing job twice : got %d", calls["fail"])
+	}
+
+	tests := []struct {
+		name   string
+		jobID  uuid.UUID
+		status string
+	}{
+		{"ok", okJob.ID, jobqueue.StatusSucceeded},
+		{"fail", failJob.ID, jobqueue.StatusFailed},
+		{"unknown", unknownJob.ID, jobqueue.StatusFailed},
+	}
+
+	for _, tt := range tests {
+		job, err := queue.QueryByID(ctx, tt.jobID)
+		if err != nil {
+			t.Fatalf("%s: Should be able to retrieve the job : %s", tt.name, err)
+		}
+
+		if job.Status != tt.status {
+			t.Errorf("%s: Should have status %s : got %s", tt.name, tt.status, job.Status)
+		}
+	}
+
+	job, err := queue.QueryByID(ctx, unknownJob.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the job : %s", err)
+	}
+
+	if job.LastError == "" {
+		t.Errorf("Should have recorded the missing handler")
+	}
+}
+
+// =============================================================================
+
+type memStore struct {
+	mu   *sync.Mutex
+	jobs map[uuid.UUID]jobqueue.Job
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		mu:   &sync.Mutex{},
+		jobs: make(map[uuid.UUID]jobqueue.Job),
+	}
+}
+
+func (s *memStore) Begin() (sqldb.CommitRollbacker, error) {
+	return memTx{}, nil
+}
+
+func (s *memStore) NewWithTx(tx sqldb.CommitRollbacker) (jobqueue.Storer, error) {
+	return s, nil
+}
+
+func (s *memStore) Create(ctx context.Context, job jobqueue.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs[job.ID] = job
+	return nil
+}
+
+func (s *memStore) Update(ctx context.Context, job jobqueue.Job, status string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.jobs[job.ID].Status != status {
+		return false, nil
+	}
+
+	s.jobs[job.ID] = job
+	return true, nil
+}
+
+func (s *memStore) Query(ctx context.Context, filter jobqueue.QueryFilter, orderBy order.By, page page.Page) ([]jobqueue.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var jobs []jobqueue.Job
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+func (s *memStore) Count(ctx context.Context, filter jobqueue.QueryFilter) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.jobs), nil
+}
+
+func (s *memStore) QueryByID(ctx context.Context, jobID uuid.UUID) (jobqueue.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, exists := s.jobs[jobID]
+	if !exists {
+		return jobqueue.Job{}, jobqueue.ErrNotFound
+	}
+
+	return job, nil
+}
+
+func (s *memStore) QueryDue(ctx context.Context, now time.Time, limit int) ([]jobqueue.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var jobs []jobqueue.Job
+	for _, job := range s.jobs {
+		if (job.Status == jobqueue.StatusQueued || job.Status == jobqueue.StatusRunning) && !job.RunAt.After(now) && len(jobs) < limit {
+			jobs = append(jobs, job)
+		}
+	}
+
+	return jobs, nil
+}
+
+type memTx struct{}
+
+func (memTx) Commit() error   { return nil }
+func (memTx) Rollback() error { return nil }
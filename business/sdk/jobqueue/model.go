@@ -0,0 +1,46 @@
+package jobqueue
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HandlerFunc represents a function that executes the jobs of a type.
+type HandlerFunc func(ctx context.Context, job Job) error
+
+// Set of statuses a job can be in.
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Job represents a unit of work stored in the queue. While the job is running,
+// RunAt marks the end of the visibility timeout; once it expires the job can
+// be claimed again.
+type Job struct {
+	ID          uuid.UUID
+	Type        string
+	Payload     []byte
+	Status      string
+	Attempts    int
+	MaxAttempts int
+	LastError   string
+	RunAt       time.Time
+	DateCreated time.Time
+	DateUpdated time.Time
+}
+
+// NewJob is what we require to add a job to the queue. The job runs as soon
+// as possible unless RunAt or Delay are provided.
+type NewJob struct {
+	Type        string
+	Payload     []byte
+	RunAt       time.Time
+	Delay       time.Duration
+	MaxAttempts int
+}
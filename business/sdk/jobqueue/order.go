@@ -0,0 +1,16 @@
+package jobqueue
+
+import "github.com/rmsj/service/business/sdk/order"
+
+// DefaultOrderBy represents the default way we sort, with the most recent
+// jobs first.
+var DefaultOrderBy = order.NewBy(OrderByDateCreated, order.DESC)
+
+// Set of fields that the results can be ordered by.
+const (
+	OrderByJobID       = "a"
+	OrderByType        = "b"
+	OrderByStatus      = "c"
+	OrderByRunAt       = "d"
+	OrderByDateCreated = "e"
+)
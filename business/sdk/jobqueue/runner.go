@@ -0,0 +1,226 @@
+package jobqueue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/foundation/logger"
+	"github.com/rmsj/service/foundation/worker"
+)
+
+// RunnerConfig contains the settings for the job runner.
+type RunnerConfig struct {
+	Log               *logger.Logger
+	Queue             *Queue
+	Beginner          sqldb.Beginner
+	Worker            *worker.Worker
+	Interval          time.Duration
+	VisibilityTimeout time.Duration
+	MinBackoff        time.Duration
+	MaxBackoff        time.Duration
+}
+
+// Runner polls the queue and executes the due jobs on the worker. Several
+// runners can poll the same queue safely: a claimed job is hidden from the
+// other runners until its visibility timeout expires, which is also the
+// deadline given to the job.
+type Runner struct {
+	cfg RunnerConfig
+}
+
+// NewRunner constructs a runner for the queue.
+func NewRunner(cfg RunnerConfig) *Runner {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+
+	if cfg.VisibilityTimeout <= 0 {
+		cfg.VisibilityTimeout = 5 * time.Minute
+	}
+
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = 5 * time.Second
+	}
+
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = time.Hour
+	}
+
+	return &Runner{
+		cfg: cfg,
+	}
+}
+
+// Run starts the due jobs on every interval until the context is cancelled.
+// The jobs already started keep running; use the worker Shutdown to wait for
+// them.
+func (r *Runner) Run(ctx context.Context) {
+	r.cfg.Log.Info(ctx, "job runner", "status", "started", "interval", r.cfg.Interval)
+	defer r.cfg.Log.Info(ctx, "job runner", "status", "stopped")
+
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if _, err := r.RunOnce(ctx); err != nil {
+				r.cfg.Log.Error(ctx, "job runner", "status", "run failed", "err", err)
+			}
+		}
+	}
+}
+
+// RunOnce claims as many due jobs as the worker has capacity for and starts
+// them. It returns the number of jobs started.
+func (r *Runner) RunOnce(ctx context.Context) (int, error) {
+	limit := r.cfg.Worker.Available()
+	if limit == 0 {
+		return 0, nil
+	}
+
+	jobs, err := r.claim(ctx, limit)
+	if err != nil {
+		return 0, fmt.Errorf("claim: %w", err)
+	}
+
+	var started int
+
+	for _, job := range jobs {
+		jobCtx, cancel := context.WithDeadline(ctx, job.RunAt)
+
+		_, err := r.cfg.Worker.Start(jobCtx, func(ctx context.Context) {
+			r.execute(ctx, job)
+		})
+
+		cancel()
+
+		// The job stays claimed and is picked up again once the visibility
+		// timeout expires.
+		if err != nil {
+			return started, fmt.Errorf("start: jobID[%s]: %w", job.ID, err)
+		}
+
+		started++
+	}
+
+	return started, nil
+}
+
+// claim locks the due jobs, counts the attempt and hides them from the other
+// runners for the duration of the visibility timeout. Jobs that were claimed
+// by a runner that died are claimed again once their timeout expires, and are
+// failed if they have no attempts left.
+func (r *Runner) claim(ctx context.Context, limit int) ([]Job, error) {
+	tx, err := r.cfg.Beginner.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	storer, err := r.cfg.Queue.storer.NewWithTx(tx)
+	if err != nil {
+		return nil, fmt.Errorf("newwithtx: %w", err)
+	}
+
+	now := time.Now()
+
+	due, err := storer.QueryDue(ctx, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("querydue: %w", err)
+	}
+
+	jobs := make([]Job, 0, len(due))
+
+	for _, job := range due {
+		status := job.Status
+		job.DateUpdated = now
+
+		switch {
+		case job.Attempts >= job.MaxAttempts:
+			job.Status = StatusFailed
+			job.LastError = "visibility timeout expired"
+			r.cfg.Log.Error(ctx, "job runner", "status", "job failed", "job_id", job.ID, "type", job.Type, "attempts", job.Attempts, "err", job.LastError)
+
+		default:
+			job.Status = StatusRunning
+			job.Attempts++
+			job.RunAt = now.Add(r.cfg.VisibilityTimeout)
+			jobs = append(jobs, job)
+		}
+
+		if _, err := storer.Update(ctx, job, status); err != nil {
+			return nil, fmt.Errorf("update: jobID[%s]: %w", job.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// execute runs the handler for the job and records the result.
+func (r *Runner) execute(ctx context.Context, job Job) {
+	err := r.handle(ctx, job)
+
+	now := time.Now()
+
+	switch {
+	case err == nil:
+		job.Status = StatusSucceeded
+		job.LastError = ""
+
+	case job.Attempts >= job.MaxAttempts:
+		job.Status = StatusFailed
+		job.LastError = err.Error()
+		r.cfg.Log.Error(ctx, "job runner", "status", "job failed", "job_id", job.ID, "type", job.Type, "attempts", job.Attempts, "err", err)
+
+	default:
+		job.Status = StatusQueued
+		job.RunAt = now.Add(worker.Backoff(job.Attempts, r.cfg.MinBackoff, r.cfg.MaxBackoff))
+		job.LastError = err.Error()
+		r.cfg.Log.Info(ctx, "job runner", "status", "job attempt failed", "job_id", job.ID, "type", job.Type, "attempts", job.Attempts, "retry_at", job.RunAt, "err", err)
+	}
+
+	job.DateUpdated = now
+
+	// The job context can be done by now, so the result is recorded with a
+	// context of its own.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	// The result is only recorded while the job is still running, so it
+	// can't overwrite a job that changed status in the meantime.
+	updated, err := r.cfg.Queue.storer.Update(ctx, job, StatusRunning)
+	switch {
+	case err != nil:
+		r.cfg.Log.Error(ctx, "job runner", "status", "recording result", "job_id", job.ID, "err", err)
+
+	case !updated:
+		r.cfg.Log.Info(ctx, "job runner", "status", "result discarded, job no longer running", "job_id", job.ID)
+	}
+}
+
+// handle executes the handler registered for the job type, turning a panic
+// into an error so the job is retried.
+func (r *Runner) handle(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("panic: %v", rec)
+		}
+	}()
+
+	fn, exists := r.cfg.Queue.handler(job.Type)
+	if !exists {
+		return fmt.Errorf("type[%s]: %w", job.Type, ErrNoHandler)
+	}
+
+	return fn(ctx, job)
+}
@@ -0,0 +1,26 @@
+package jobdb
+
+import (
+	"bytes"
+
+	"github.com/rmsj/service/business/sdk/jobqueue"
+	"github.com/rmsj/service/business/sdk/sqldb"
+)
+
+func applyFilter(filter jobqueue.QueryFilter, data map[string]any, buf *bytes.Buffer) {
+	w := sqldb.NewWhere(data)
+
+	if filter.ID != nil {
+		w.Add("job_id = " + w.Bind(filter.ID))
+	}
+
+	if filter.Type != nil {
+		w.Add("type = " + w.Bind(*filter.Type))
+	}
+
+	if filter.Status != nil {
+		w.Add("status = " + w.Bind(*filter.Status))
+	}
+
+	buf.WriteString(w.String())
+}
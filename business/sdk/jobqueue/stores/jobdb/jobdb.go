@@ -0,0 +1,207 @@
+// Package jobdb contains job queue related CRUD functionality.
+package jobdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/rmsj/service/business/sdk/jobqueue"
+	"github.com/rmsj/service/business/sdk/order"
+	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/foundation/logger"
+)
+
+// Store manages the set of APIs for job database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// NewWithTx constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) NewWithTx(tx sqldb.CommitRollbacker) (jobqueue.Storer, error) {
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return &store, nil
+}
+
+// Create adds a job to the queue.
+func (s *Store) Create(ctx context.Context, j jobqueue.Job) error {
+	const q = `
+	INSERT INTO jobs
+		(job_id, type, payload, status, attempts, max_attempts, last_error, run_at, created_at, updated_at)
+	VALUES
+		(:job_id, :type, :payload, :status, :attempts, :max_attempts, :last_error, :run_at, :created_at, :updated_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBJob(j)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update records the state of a job, if it's still in the specified status.
+// It reports if the job was updated.
+func (s *Store) Update(ctx context.Context, j jobqueue.Job, status string) (bool, error) {
+	data := struct {
+		job
+		ExpectedStatus string `db:"expected_status"`
+	}{
+		job:            toDBJob(j),
+		ExpectedStatus: status,
+	}
+
+	const q = `
+	UPDATE
+		jobs
+	SET
+		status = :status,
+		attempts = :attempts,
+		last_error = :last_error,
+		run_at = :run_at,
+		updated_at = :updated_at
+	WHERE
+		job_id = :job_id AND
+		status = :expected_status`
+
+	count, err := sqldb.NamedExecContextWithCount(ctx, s.log, s.db, q, data)
+	if err != nil {
+		return false, fmt.Errorf("namedexeccontextwithcount: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// Query retrieves a list of existing jobs from the database.
+func (s *Store) Query(ctx context.Context, filter jobqueue.QueryFilter, orderBy order.By, page page.Page) ([]jobqueue.Job, error) {
+	data := map[string]any{
+		"offset":        (page.Number() - 1) * page.RowsPerPage(),
+		"rows_per_page": page.RowsPerPage(),
+	}
+
+	const q = `
+	SELECT
+		job_id, type, payload, status, attempts, max_attempts, last_error, run_at, created_at, updated_at
+	FROM
+		jobs`
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(filter, data, buf)
+
+	orderByClause, err := orderByClause(orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" LIMIT :rows_per_page OFFSET :offset")
+
+	var dbJobs []job
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbJobs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusJobs(dbJobs), nil
+}
+
+// Count returns the total number of jobs in the DB.
+func (s *Store) Count(ctx context.Context, filter jobqueue.QueryFilter) (int, error) {
+	data := map[string]any{}
+
+	const q = "SELECT COUNT(job_id) AS `count` FROM jobs"
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(filter, data, buf)
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// QueryByID gets the specified job from the database.
+func (s *Store) QueryByID(ctx context.Context, jobID uuid.UUID) (jobqueue.Job, error) {
+	data := struct {
+		ID string `db:"job_id"`
+	}{
+		ID: jobID.String(),
+	}
+
+	const q = `
+	SELECT
+		job_id, type, payload, status, attempts, max_attempts, last_error, run_at, created_at, updated_at
+	FROM
+		jobs
+	WHERE
+		job_id = :job_id`
+
+	var dbJob job
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbJob); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return jobqueue.Job{}, fmt.Errorf("db: %w", jobqueue.ErrNotFound)
+		}
+		return jobqueue.Job{}, fmt.Errorf("db: %w", err)
+	}
+
+	return toBusJob(dbJob), nil
+}
+
+// QueryDue retrieves the jobs that are due to run: the queued jobs whose time
+// has come and the running jobs whose visibility timeout expired. The rows
+// are locked for the remainder of the transaction, skipping the ones already
+// locked by another runner.
+func (s *Store) QueryDue(ctx context.Context, now time.Time, limit int) ([]jobqueue.Job, error) {
+	data := map[string]any{
+		"queued":  jobqueue.StatusQueued,
+		"running": jobqueue.StatusRunning,
+		"now":     now.UTC(),
+		"limit":   limit,
+	}
+
+	const q = `
+	SELECT
+		job_id, type, payload, status, attempts, max_attempts, last_error, run_at, created_at, updated_at
+	FROM
+		jobs
+	WHERE
+		status IN (:queued, :running) AND
+		run_at <= :now
+	ORDER BY
+		run_at
+	LIMIT :limit
+	FOR UPDATE SKIP LOCKED`
+
+	var dbJobs []job
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbJobs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusJobs(dbJobs), nil
+}
@@ -0,0 +1,69 @@
+package jobdb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/sdk/jobqueue"
+)
+
+type job struct {
+	ID          uuid.UUID      `db:"job_id"`
+	Type        string         `db:"type"`
+	Payload     string         `db:"payload"`
+	Status      string         `db:"status"`
+	Attempts    int            `db:"attempts"`
+	MaxAttempts int            `db:"max_attempts"`
+	LastError   sql.NullString `db:"last_error"`
+	RunAt       time.Time      `db:"run_at"`
+	DateCreated time.Time      `db:"created_at"`
+	DateUpdated time.Time      `db:"updated_at"`
+}
+
+func toDBJob(bus jobqueue.Job) job {
+	db := job{
+		ID:          bus.ID,
+		Type:        bus.Type,
+		Payload:     string(bus.Payload),
+		Status:      bus.Status,
+		Attempts:    bus.Attempts,
+		MaxAttempts: bus.MaxAttempts,
+		LastError: sql.NullString{
+			String: bus.LastError,
+			Valid:  bus.LastError != "",
+		},
+		RunAt:       bus.RunAt.UTC(),
+		DateCreated: bus.DateCreated.UTC(),
+		DateUpdated: bus.DateUpdated.UTC(),
+	}
+
+	return db
+}
+
+func toBusJob(db job) jobqueue.Job {
+	bus := jobqueue.Job{
+		ID:          db.ID,
+		Type:        db.Type,
+		Payload:     []byte(db.Payload),
+		Status:      db.Status,
+		Attempts:    db.Attempts,
+		MaxAttempts: db.MaxAttempts,
+		LastError:   db.LastError.String,
+		RunAt:       db.RunAt.In(time.Local),
+		DateCreated: db.DateCreated.In(time.Local),
+		DateUpdated: db.DateUpdated.In(time.Local),
+	}
+
+	return bus
+}
+
+func toBusJobs(dbs []job) []jobqueue.Job {
+	bus := make([]jobqueue.Job, len(dbs))
+	for i, db := range dbs {
+		bus[i] = toBusJob(db)
+	}
+
+	return bus
+}
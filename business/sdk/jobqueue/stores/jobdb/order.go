@@ -0,0 +1,25 @@
+package jobdb
+
+import (
+	"fmt"
+
+	"github.com/rmsj/service/business/sdk/jobqueue"
+	"github.com/rmsj/service/business/sdk/order"
+)
+
+var orderByFields = map[string]string{
+	jobqueue.OrderByJobID:       "job_id",
+	jobqueue.OrderByType:        "type",
+	jobqueue.OrderByStatus:      "status",
+	jobqueue.OrderByRunAt:       "run_at",
+	jobqueue.OrderByDateCreated: "created_at",
+}
+
+func orderByClause(orderBy order.By) (string, error) {
+	by, exists := orderByFields[orderBy.Field]
+	if !exists {
+		return "", fmt.Errorf("field %q does not exist", orderBy.Field)
+	}
+
+	return " ORDER BY " + by + " " + orderBy.Direction, nil
+}
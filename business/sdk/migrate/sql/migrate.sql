@@ -62,4 +62,465 @@ CREATE TABLE password_reset_tokens
     KEY (email)
 ) ENGINE = InnoDB
   DEFAULT CHARSET = latin1
-  COLLATE = latin1_general_ci;
\ No newline at end of file
+  COLLATE = latin1_general_ci;
+
+-- Version: 1.05
+-- Description: Create table orders
+CREATE TABLE orders
+(
+    order_id   CHAR(36)     NOT NULL,
+    user_id    CHAR(36)     NOT NULL,
+    status     VARCHAR(20)  NOT NULL,
+    updated_at TIMESTAMP(6) NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL,
+
+    PRIMARY KEY (order_id),
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = latin1
+  COLLATE = latin1_general_ci;
+
+-- Version: 1.06
+-- Description: Create table order_items
+CREATE TABLE order_items
+(
+    order_id   CHAR(36)       NOT NULL,
+    product_id CHAR(36)       NOT NULL,
+    quantity   INT            NOT NULL,
+    price      NUMERIC(10, 2) NOT NULL,
+
+    PRIMARY KEY (order_id, product_id),
+    FOREIGN KEY (order_id) REFERENCES orders (order_id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products (product_id) ON DELETE CASCADE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = latin1
+  COLLATE = latin1_general_ci;
+
+-- Version: 1.07
+-- Description: Store product cost as an exact amount with a currency
+ALTER TABLE products
+    MODIFY cost NUMERIC(19, 4) NOT NULL,
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD' AFTER cost;
+
+-- Version: 1.08
+-- Description: Store order item price as an exact amount with a currency
+ALTER TABLE order_items
+    MODIFY price NUMERIC(19, 4) NOT NULL,
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD' AFTER price;
+
+-- Version: 1.09
+-- Description: Add currency to the products view.
+CREATE OR REPLACE VIEW view_products AS
+SELECT p.product_id,
+       p.user_id,
+       p.name,
+       p.cost,
+       p.currency,
+       p.quantity,
+       p.created_at,
+       p.updated_at,
+       u.name AS user_name
+FROM products AS p
+         LEFT JOIN users AS u ON u.user_id = p.user_id
+
+-- Version: 1.10
+-- Description: Create table outbox
+CREATE TABLE outbox
+(
+    event_id   CHAR(36)     NOT NULL,
+    domain     VARCHAR(100) NOT NULL,
+    action     VARCHAR(100) NOT NULL,
+    params     JSON         NOT NULL,
+    status     VARCHAR(20)  NOT NULL,
+    attempts   INT          NOT NULL,
+    last_error TEXT         NULL,
+    run_at     TIMESTAMP(6) NOT NULL,
+    updated_at TIMESTAMP(6) NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL,
+
+    PRIMARY KEY (event_id),
+    KEY (status, run_at)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = latin1
+  COLLATE = latin1_general_ci;
+
+-- Version: 1.11
+-- Description: Create table webhook_subscriptions
+CREATE TABLE webhook_subscriptions
+(
+    subscription_id CHAR(36)      NOT NULL,
+    url             VARCHAR(2048) NOT NULL,
+    events          VARCHAR(1024) NOT NULL,
+    secret          VARCHAR(255)  NOT NULL,
+    enabled         BOOLEAN       NOT NULL,
+    updated_at      TIMESTAMP(6)  NOT NULL,
+    created_at      TIMESTAMP(6)  NOT NULL,
+
+    PRIMARY KEY (subscription_id)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = latin1
+  COLLATE = latin1_general_ci;
+
+-- Version: 1.12
+-- Description: Create table webhook_deliveries
+CREATE TABLE webhook_deliveries
+(
+    delivery_id     CHAR(36)     NOT NULL,
+    subscription_id CHAR(36)     NOT NULL,
+    event_id        CHAR(36)     NOT NULL,
+    event           VARCHAR(100) NOT NULL,
+    payload         JSON         NOT NULL,
+    status          VARCHAR(20)  NOT NULL,
+    attempts        INT          NOT NULL,
+    response_code   INT          NOT NULL,
+    last_error      TEXT         NULL,
+    run_at          TIMESTAMP(6) NOT NULL,
+    updated_at      TIMESTAMP(6) NOT NULL,
+    created_at      TIMESTAMP(6) NOT NULL,
+
+    PRIMARY KEY (delivery_id),
+    UNIQUE KEY (subscription_id, event_id),
+    KEY (status, run_at),
+    FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions (subscription_id) ON DELETE CASCADE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = latin1
+  COLLATE = latin1_general_ci;
+
+-- Version: 1.13
+-- Description: Create table jobs
+CREATE TABLE jobs
+(
+    job_id       CHAR(36)     NOT NULL,
+    type         VARCHAR(100) NOT NULL,
+    payload      JSON         NOT NULL,
+    status       VARCHAR(20)  NOT NULL,
+    attempts     INT          NOT NULL,
+    max_attempts INT          NOT NULL,
+    last_error   TEXT         NULL,
+    run_at       TIMESTAMP(6) NOT NULL,
+    updated_at   TIMESTAMP(6) NOT NULL,
+    created_at   TIMESTAMP(6) NOT NULL,
+
+    PRIMARY KEY (job_id),
+    KEY (status, run_at),
+    KEY (type, created_at)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = latin1
+  COLLATE = latin1_general_ci;
+
+-- Version: 1.14
+-- Description: Create table scheduler_leases
+CREATE TABLE scheduler_leases
+(
+    task       VARCHAR(100) NOT NULL,
+    tick       TIMESTAMP(6) NOT NULL,
+    owner      VARCHAR(100) NOT NULL,
+    expires_at TIMESTAMP(6) NOT NULL,
+
+    PRIMARY KEY (task)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = latin1
+  COLLATE = latin1_general_ci;
+
+-- Version: 1.15
+-- Description: Create table scheduler_runs
+CREATE TABLE scheduler_runs
+(
+    run_id       CHAR(36)     NOT NULL,
+    task         VARCHAR(100) NOT NULL,
+    owner        VARCHAR(100) NOT NULL,
+    status       VARCHAR(20)  NOT NULL,
+    error        TEXT         NULL,
+    scheduled_at TIMESTAMP(6) NOT NULL,
+    started_at   TIMESTAMP(6) NOT NULL,
+    finished_at  TIMESTAMP(6) NULL,
+
+    PRIMARY KEY (run_id),
+    KEY (task, started_at),
+    KEY (started_at)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = latin1
+  COLLATE = latin1_general_ci;
+
+-- Version: 1.16
+-- Description: Create table notification_failures
+CREATE TABLE notification_failures
+(
+    failure_id CHAR(36)     NOT NULL,
+    template   VARCHAR(100) NOT NULL,
+    recipient  VARCHAR(150) NOT NULL,
+    subject    VARCHAR(250) NOT NULL,
+    error      TEXT         NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL,
+
+    PRIMARY KEY (failure_id),
+    KEY (recipient)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = latin1
+  COLLATE = latin1_general_ci;
+
+-- Version: 1.17
+-- Description: Create table refresh_tokens
+CREATE TABLE refresh_tokens
+(
+    token_id     CHAR(36)     NOT NULL,
+    family_id    CHAR(36)     NOT NULL,
+    user_id      CHAR(36)     NOT NULL,
+    token_hash   CHAR(64)     NOT NULL,
+    user_agent   VARCHAR(255) NOT NULL,
+    expires_at   TIMESTAMP(6) NOT NULL,
+    last_used_at TIMESTAMP(6) NULL,
+    rotated_at   TIMESTAMP(6) NULL,
+    revoked_at   TIMESTAMP(6) NULL,
+    created_at   TIMESTAMP(6) NOT NULL,
+
+    PRIMARY KEY (token_id),
+    UNIQUE KEY (token_hash),
+    KEY (family_id),
+    KEY (user_id),
+    KEY (expires_at),
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = latin1
+  COLLATE = latin1_general_ci;
+
+-- Version: 1.18
+-- Description: Drop the refresh token of the users, they are kept in refresh_tokens
+ALTER TABLE users DROP COLUMN refresh_token;
+
+-- Version: 1.19
+-- Description: Create table sessions
+CREATE TABLE sessions
+(
+    session_id   CHAR(36)     NOT NULL,
+    user_id      CHAR(36)     NOT NULL,
+    user_agent   VARCHAR(255) NOT NULL,
+    ip_address   VARCHAR(45)  NOT NULL,
+    expires_at   TIMESTAMP(6) NOT NULL,
+    last_seen_at TIMESTAMP(6) NOT NULL,
+    revoked_at   TIMESTAMP(6) NULL,
+    created_at   TIMESTAMP(6) NOT NULL,
+
+    PRIMARY KEY (session_id),
+    KEY (user_id, last_seen_at),
+    KEY (expires_at),
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = latin1
+  COLLATE = latin1_general_ci;
+
+-- Version: 1.20
+-- Description: Create table user_mfa
+CREATE TABLE user_mfa
+(
+    user_id        CHAR(36)     NOT NULL,
+    secret         VARCHAR(64)  NOT NULL,
+    last_used_step BIGINT       NOT NULL DEFAULT 0,
+    enabled_at     TIMESTAMP(6) NULL,
+    created_at     TIMESTAMP(6) NOT NULL,
+    updated_at     TIMESTAMP(6) NOT NULL,
+
+    PRIMARY KEY (user_id),
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = latin1
+  COLLATE = latin1_general_ci;
+
+-- Version: 1.21
+-- Description: Create table mfa_recovery_codes
+CREATE TABLE mfa_recovery_codes
+(
+    code_id    CHAR(36)     NOT NULL,
+    user_id    CHAR(36)     NOT NULL,
+    code_hash  CHAR(64)     NOT NULL,
+    used_at    TIMESTAMP(6) NULL,
+    created_at TIMESTAMP(6) NOT NULL,
+
+    PRIMARY KEY (code_id),
+    KEY (user_id, code_hash),
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = latin1
+  COLLATE = latin1_general_ci;
+
+-- Version: 1.22
+-- Description: Create table login_attempts
+CREATE TABLE login_attempts
+(
+    scope           VARCHAR(16)  NOT NULL,
+    attempt_key     VARCHAR(255) NOT NULL,
+    failures        INT          NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP(6) NOT NULL,
+    blocked_until   TIMESTAMP(6) NULL,
+    created_at      TIMESTAMP(6) NOT NULL,
+    updated_at      TIMESTAMP(6) NOT NULL,
+
+    PRIMARY KEY (scope, attempt_key),
+    KEY (last_failure_at)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = latin1
+  COLLATE = latin1_general_ci;
+
+-- Version: 1.23
+-- Description: Create table audits
+CREATE TABLE audits
+(
+    audit_id   CHAR(36)     NOT NULL,
+    actor_id   CHAR(36)     NULL,
+    action     VARCHAR(64)  NOT NULL,
+    domain     VARCHAR(32)  NOT NULL,
+    entity_id  VARCHAR(255) NOT NULL,
+    diff       JSON         NOT NULL,
+    request_id VARCHAR(128) NOT NULL,
+    trace_id   CHAR(32)     NOT NULL,
+    ip_address VARCHAR(45)  NOT NULL,
+    timestamp  TIMESTAMP(6) NOT NULL,
+
+    PRIMARY KEY (audit_id),
+    KEY (timestamp),
+    KEY (actor_id, timestamp),
+    KEY (domain, entity_id, timestamp)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = latin1
+  COLLATE = latin1_general_ci;
+
+-- Version: 1.24
+-- Description: Create table user_identities
+CREATE TABLE user_identities
+(
+    provider   VARCHAR(32)  NOT NULL,
+    subject    VARCHAR(255) NOT NULL,
+    user_id    CHAR(36)     NOT NULL,
+    email      VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP(6) NOT NULL,
+    updated_at TIMESTAMP(6) NOT NULL,
+
+    PRIMARY KEY (provider, subject),
+    KEY (user_id),
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = latin1
+  COLLATE = latin1_general_ci;
+
+-- Version: 1.25
+-- Description: Create table api_keys
+CREATE TABLE api_keys
+(
+    apikey_id    CHAR(36)      NOT NULL,
+    user_id      CHAR(36)      NOT NULL,
+    name         VARCHAR(100)  NOT NULL,
+    prefix       CHAR(8)       NOT NULL,
+    key_hash     CHAR(64)      NOT NULL,
+    scopes       VARCHAR(1024) NOT NULL,
+    expires_at   TIMESTAMP(6)  NULL,
+    last_used_at TIMESTAMP(6)  NULL,
+    revoked_at   TIMESTAMP(6)  NULL,
+    created_at   TIMESTAMP(6)  NOT NULL,
+
+    PRIMARY KEY (apikey_id),
+    UNIQUE KEY (prefix),
+    KEY (user_id),
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = latin1
+  COLLATE = latin1_general_ci;
+
+-- Version: 1.26
+-- Description: Add the email verification state of the users, the existing ones count as verified
+ALTER TABLE users
+    ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT TRUE AFTER enabled;
+
+-- Version: 1.27
+-- Description: New users must verify their email
+ALTER TABLE users
+    ALTER COLUMN email_verified SET DEFAULT FALSE;
+
+-- Version: 1.28
+-- Description: Create table user_tokens
+CREATE TABLE user_tokens
+(
+    token_id    CHAR(36)     NOT NULL,
+    purpose     VARCHAR(32)  NOT NULL,
+    token_hash  CHAR(64)     NOT NULL,
+    email       VARCHAR(150) NOT NULL,
+    user_id     CHAR(36)     NULL,
+    roles       VARCHAR(100) NOT NULL,
+    created_by  CHAR(36)     NULL,
+    expires_at  TIMESTAMP(6) NOT NULL,
+    used_at     TIMESTAMP(6) NULL,
+    revoked_at  TIMESTAMP(6) NULL,
+    created_at  TIMESTAMP(6) NOT NULL,
+
+    PRIMARY KEY (token_id),
+    UNIQUE KEY (token_hash),
+    KEY (purpose, email),
+    KEY (expires_at),
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = latin1
+  COLLATE = latin1_general_ci;
+
+-- Version: 1.29
+-- Description: Add the version of the users, it changes on every update
+ALTER TABLE users
+    ADD COLUMN version INT UNSIGNED NOT NULL DEFAULT 1 AFTER email_verified;
+
+-- Version: 1.30
+-- Description: Add the version of the products, it changes on every update
+ALTER TABLE products
+    ADD COLUMN version INT UNSIGNED NOT NULL DEFAULT 1 AFTER quantity;
+
+-- Version: 1.31
+-- Description: Create table idempotency_keys
+CREATE TABLE idempotency_keys
+(
+    user_id         CHAR(36)          NOT NULL,
+    idempotency_key VARCHAR(255)      NOT NULL,
+    request_hash    CHAR(64)          NOT NULL,
+    status          SMALLINT UNSIGNED NOT NULL DEFAULT 0,
+    content_type    VARCHAR(100)      NOT NULL DEFAULT '',
+    response        MEDIUMBLOB        NULL,
+    created_at      TIMESTAMP(6)      NOT NULL,
+    expires_at      TIMESTAMP(6)      NOT NULL,
+    completed_at    TIMESTAMP(6)      NULL,
+
+    PRIMARY KEY (user_id, idempotency_key),
+    KEY (expires_at)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = latin1
+  COLLATE = latin1_general_ci;
+
+-- Version: 1.32
+-- Description: Create table rate_limits
+CREATE TABLE rate_limits
+(
+    bucket_key VARCHAR(255) NOT NULL,
+    tokens     DOUBLE       NOT NULL,
+    updated_at TIMESTAMP(6) NOT NULL,
+    expires_at TIMESTAMP(6) NOT NULL,
+
+    PRIMARY KEY (bucket_key),
+    KEY (expires_at)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = latin1
+  COLLATE = latin1_general_ci;
+
+-- Version: 1.33
+-- Description: Add the absolute expiry of the refresh token families
+ALTER TABLE refresh_tokens
+    ADD COLUMN family_expires_at TIMESTAMP(6) NULL AFTER expires_at;
+
+-- Version: 1.34
+-- Description: The existing families end when their current tokens expire
+UPDATE refresh_tokens
+SET family_expires_at = expires_at;
+
+-- Version: 1.35
+-- Description: Every refresh token carries the expiry of its family
+ALTER TABLE refresh_tokens
+    MODIFY family_expires_at TIMESTAMP(6) NOT NULL;
+
+-- Version: 1.36
+-- Description: Widen the prefix of the api keys so they don't collide
+ALTER TABLE api_keys
+    MODIFY prefix CHAR(16) NOT NULL;
@@ -1,6 +1,6 @@
-INSERT INTO users (user_id, name, email, roles, password_hash, department, enabled, created_at, updated_at) VALUES
-	('5cf37266-3473-4006-984f-9325122678b7', 'Admin Gopher', 'admin@example.com', 'admin', '$2a$10$1ggfMVZV6Js0ybvJufLRUOWHS5f6KneuP0XwwHpJ8L8ipdry9f2/a', NULL, true, NOW(), NOW()),
-	('45b5fbd3-755f-4379-8f07-a58d4a30fa2f', 'User Gopher', 'user@example.com', 'user', '$2a$10$9/XASPKBbJKVfCAZKDH.UuhsuALDr5vVm6VrYA9VFR8rccK86C1hW', NULL, true, NOW(), NOW())
+INSERT INTO users (user_id, name, email, roles, password_hash, department, enabled, email_verified, created_at, updated_at) VALUES
+	('5cf37266-3473-4006-984f-9325122678b7', 'Admin Gopher', 'admin@example.com', 'admin', '$2a$10$1ggfMVZV6Js0ybvJufLRUOWHS5f6KneuP0XwwHpJ8L8ipdry9f2/a', NULL, true, true, NOW(), NOW()),
+	('45b5fbd3-755f-4379-8f07-a58d4a30fa2f', 'User Gopher', 'user@example.com', 'user', '$2a$10$9/XASPKBbJKVfCAZKDH.UuhsuALDr5vVm6VrYA9VFR8rccK86C1hW', NULL, true, true, NOW(), NOW())
 ON DUPLICATE KEY UPDATE
      name = VALUES(name),
      email = VALUES(email),
@@ -8,5 +8,6 @@ ON DUPLICATE KEY UPDATE
      password_hash = VALUES(password_hash),
      department = VALUES(department),
      enabled = VALUES(enabled),
+     email_verified = VALUES(email_verified),
      updated_at = VALUES(updated_at),
      created_at = VALUES(created_at);
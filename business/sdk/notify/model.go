@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"net/mail"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Set of templates that can be sent.
+const (
+	TmplPasswordReset     = "password_reset"
+	TmplInvitation        = "invitation"
+	TmplEmailVerification = "email_verification"
+)
+
+// Notification represents a message to send to a recipient, rendered from
+// one of the templates with the data provided.
+type Notification struct {
+	Template string
+	To       mail.Address
+	Data     any
+}
+
+// PasswordResetData is the data the password reset template expects.
+type PasswordResetData struct {
+	Name      string
+	Link      string
+	ExpiresAt time.Time
+}
+
+// InvitationData is the data the invitation template expects.
+type InvitationData struct {
+	InvitedBy string
+	Link      string
+	ExpiresAt time.Time
+}
+
+// EmailVerificationData is the data the email verification template expects.
+type EmailVerificationData struct {
+	Name      string
+	Link      string
+	ExpiresAt time.Time
+}
+
+// Failure represents a notification that could not be sent.
+type Failure struct {
+	ID          uuid.UUID
+	Template    string
+	Recipient   string
+	Subject     string
+	Error       string
+	DateCreated time.Time
+}
@@ -0,0 +1,130 @@
+// Package notify sends templated email notifications in the background,
+// recording the ones that fail.
+package notify
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"net/mail"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/foundation/logger"
+	mailer "github.com/rmsj/service/foundation/mail"
+	"github.com/rmsj/service/foundation/otel"
+	"github.com/rmsj/service/foundation/worker"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// Storer interface declares the behavior this package needs to record the
+// notifications that failed.
+type Storer interface {
+	CreateFailure(ctx context.Context, failure Failure) error
+}
+
+// Config contains the settings for the notifier.
+type Config struct {
+	Log     *logger.Logger
+	Sender  mailer.Sender
+	Storer  Storer
+	Worker  *worker.Worker
+	From    mail.Address
+	Timeout time.Duration
+}
+
+// Notifier renders and sends notifications.
+type Notifier struct {
+	cfg       Config
+	templates *mailer.Templates
+}
+
+// New constructs a notifier for use.
+func New(cfg Config) (*Notifier, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+
+	fsys, err := fs.Sub(templates, "templates")
+	if err != nil {
+		return nil, fmt.Errorf("templates: %w", err)
+	}
+
+	tmpls, err := mailer.ParseTemplates(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("parse templates: %w", err)
+	}
+
+	n := Notifier{
+		cfg:       cfg,
+		templates: tmpls,
+	}
+
+	return &n, nil
+}
+
+// Send renders the notification and sends it in the background. Errors
+// rendering the notification are returned; errors sending it are logged and
+// recorded as a failure.
+func (n *Notifier) Send(ctx context.Context, nt Notification) error {
+	ctx, span := otel.AddSpan(ctx, "business.notify.send")
+	defer span.End()
+
+	content, err := n.templates.Render(nt.Template, nt.Data)
+	if err != nil {
+		return fmt.Errorf("render: %w", err)
+	}
+
+	msg := mailer.Message{
+		From:    n.cfg.From,
+		To:      []mail.Address{nt.To},
+		Subject: content.Subject,
+		Text:    content.Text,
+		HTML:    content.HTML,
+	}
+
+	// The message is sent after the request completes, so it gets a deadline
+	// of its own.
+	sendCtx, cancel := context.WithDeadline(context.WithoutCancel(ctx), time.Now().Add(n.cfg.Timeout))
+	defer cancel()
+
+	_, err = n.cfg.Worker.Start(sendCtx, func(ctx context.Context) {
+		n.deliver(ctx, nt.Template, msg)
+	})
+	if err != nil {
+		return fmt.Errorf("start: %w", err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+func (n *Notifier) deliver(ctx context.Context, template string, msg mailer.Message) {
+	err := n.cfg.Sender.Send(ctx, msg)
+	if err == nil {
+		return
+	}
+
+	n.cfg.Log.Error(ctx, "notify", "status", "send failed", "template", template, "to", msg.To[0].Address, "err", err)
+
+	failure := Failure{
+		ID:          uuid.New(),
+		Template:    template,
+		Recipient:   msg.To[0].Address,
+		Subject:     msg.Subject,
+		Error:       err.Error(),
+		DateCreated: time.Now(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	if err := n.cfg.Storer.CreateFailure(ctx, failure); err != nil {
+		n.cfg.Log.Error(ctx, "notify", "status", "recording failure", "template", template, "err", err)
+	}
+}
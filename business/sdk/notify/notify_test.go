@@ -0,0 +1,128 @@
+package notify_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rmsj/service/business/sdk/notify"
+	"github.com/rmsj/service/foundation/logger"
+	mailer "github.com/rmsj/service/foundation/mail"
+	"github.com/rmsj/service/foundation/worker"
+)
+
+func Test_Send(t *testing.T) {
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	wrk, err := worker.New(1)
+	if err != nil {
+		t.Fatalf("Should be able to construct the worker : %s", err)
+	}
+
+	sender := &bounceSender{Memory: mailer.NewMemory()}
+	store := &memStore{}
+
+	notifier, err := notify.New(notify.Config{
+		Log:    log,
+		Sender: sender,
+		Storer: store,
+		Worker: wrk,
+		From:   mail.Address{Address: "no-reply@example.com"},
+	})
+	if err != nil {
+		t.Fatalf("Should be able to construct the notifier : %s", err)
+	}
+
+	ctx := context.Background()
+
+	nt := notify.Notification{
+		Template: notify.TmplPasswordReset,
+		To:       mail.Address{Name: "Bill", Address: "bill@example.com"},
+		Data: notify.PasswordResetData{
+			Name:      "Bill",
+			Link:      "http://localhost:3000/reset-password/abc",
+			ExpiresAt: time.Now().Add(time.Hour),
+		},
+	}
+
+	if err := notifier.Send(ctx, nt); err != nil {
+		t.Fatalf("Should be able to send the notification : %s", err)
+	}
+
+	if err := notifier.Send(ctx, notify.Notification{Template: "unknown"}); !errors.Is(err, mailer.ErrUnknownTemplate) {
+		t.Errorf("Should not send an unknown template : got %v", err)
+	}
+
+	// A message the sender can't deliver is recorded as a failure.
+	bounce := nt
+	bounce.To = mail.Address{Address: "bill@bounce.example.com"}
+
+	if err := notifier.Send(ctx, bounce); err != nil {
+		t.Fatalf("Should be able to queue the notification : %s", err)
+	}
+
+	if err := wrk.Shutdown(ctx); err != nil {
+		t.Fatalf("Should be able to wait for the notifications : %s", err)
+	}
+
+	msgs := sender.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("Should have sent one message : got %d", len(msgs))
+	}
+
+	if msgs[0].Subject != "Reset your password" {
+		t.Errorf("Should render the subject : got %q", msgs[0].Subject)
+	}
+
+	if !strings.Contains(msgs[0].Text, nt.Data.(notify.PasswordResetData).Link) || !strings.Contains(msgs[0].HTML, nt.Data.(notify.PasswordResetData).Link) {
+		t.Errorf("Should include the link in both bodies")
+	}
+
+	failures := store.all()
+	if len(failures) != 1 {
+		t.Fatalf("Should have recorded one failure : got %d", len(failures))
+	}
+
+	if failures[0].Template != notify.TmplPasswordReset || failures[0].Recipient != bounce.To.Address || failures[0].Error == "" {
+		t.Errorf("Should record the template and the error : got %+v", failures[0])
+	}
+}
+
+// =============================================================================
+
+type bounceSender struct {
+	*mailer.Memory
+}
+
+func (s *bounceSender) Send(ctx context.Context, msg mailer.Message) error {
+	if strings.HasSuffix(msg.To[0].Address, "@bounce.example.com") {
+		return errors.New("mailbox unavailable")
+	}
+
+	return s.Memory.Send(ctx, msg)
+}
+
+type memStore struct {
+	mu       sync.Mutex
+	failures []notify.Failure
+}
+
+func (s *memStore) CreateFailure(ctx context.Context, failure notify.Failure) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, failure)
+	return nil
+}
+
+func (s *memStore) all() []notify.Failure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]notify.Failure(nil), s.failures...)
+}
@@ -0,0 +1,31 @@
+package notifydb
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/sdk/notify"
+)
+
+type failure struct {
+	ID          uuid.UUID `db:"failure_id"`
+	Template    string    `db:"template"`
+	Recipient   string    `db:"recipient"`
+	Subject     string    `db:"subject"`
+	Error       string    `db:"error"`
+	DateCreated time.Time `db:"created_at"`
+}
+
+func toDBFailure(bus notify.Failure) failure {
+	db := failure{
+		ID:          bus.ID,
+		Template:    bus.Template,
+		Recipient:   bus.Recipient,
+		Subject:     bus.Subject,
+		Error:       bus.Error,
+		DateCreated: bus.DateCreated.UTC(),
+	}
+
+	return db
+}
@@ -0,0 +1,42 @@
+// Package notifydb contains notification failure related CRUD functionality.
+package notifydb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/rmsj/service/business/sdk/notify"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/foundation/logger"
+)
+
+// Store manages the set of APIs for notification database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// CreateFailure records a notification that could not be sent.
+func (s *Store) CreateFailure(ctx context.Context, f notify.Failure) error {
+	const q = `
+	INSERT INTO notification_failures
+		(failure_id, template, recipient, subject, error, created_at)
+	VALUES
+		(:failure_id, :template, :recipient, :subject, :error, :created_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBFailure(f)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
@@ -2,14 +2,21 @@
 package page
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
+
+	"github.com/rmsj/service/business/sdk/order"
 )
 
-// Page represents the requested page and rows per page.
+// Page represents the requested page and rows per page. A page is either
+// numbered, for offset paging, or at a cursor, for keyset paging.
 type Page struct {
 	number int
 	rows   int
+	cursor *Cursor
 }
 
 // Parse parses the strings and validates the values are in reason.
@@ -23,33 +30,63 @@ func Parse(page string, rowsPerPage string) (Page, error) {
 		}
 	}
 
+	if number <= 0 {
+		return Page{}, fmt.Errorf("page value too small, must be larger than 0")
+	}
+
+	rows, err := parseRows(rowsPerPage)
+	if err != nil {
+		return Page{}, err
+	}
+
+	p := Page{
+		number: number,
+		rows:   rows,
+	}
+
+	return p, nil
+}
+
+// ParseCursor parses the strings into a page at the cursor and validates the
+// values are in reason.
+func ParseCursor(cursor string, rowsPerPage string) (Page, error) {
+	c, err := decodeCursor(cursor)
+	if err != nil {
+		return Page{}, err
+	}
+
+	rows, err := parseRows(rowsPerPage)
+	if err != nil {
+		return Page{}, err
+	}
+
+	p := Page{
+		rows:   rows,
+		cursor: &c,
+	}
+
+	return p, nil
+}
+
+func parseRows(rowsPerPage string) (int, error) {
 	rows := 10
 	if rowsPerPage != "" {
 		var err error
 		rows, err = strconv.Atoi(rowsPerPage)
 		if err != nil {
-			return Page{}, fmt.Errorf("rows conversion: %w", err)
+			return 0, fmt.Errorf("rows conversion: %w", err)
 		}
 	}
 
-	if number <= 0 {
-		return Page{}, fmt.Errorf("page value too small, must be larger than 0")
-	}
-
 	if rows <= 0 {
-		return Page{}, fmt.Errorf("rows value too small, must be larger than 0")
+		return 0, fmt.Errorf("rows value too small, must be larger than 0")
 	}
 
 	if rows > 100 {
-		return Page{}, fmt.Errorf("rows value too large, must be less than 100")
-	}
-
-	p := Page{
-		number: number,
-		rows:   rows,
+		return 0, fmt.Errorf("rows value too large, must be less than 100")
 	}
 
-	return p, nil
+	return rows, nil
 }
 
 // MustParse creates a paging value for testing.
@@ -64,15 +101,125 @@ func MustParse(page string, rowsPerPage string) Page {
 
 // String implements the stringer interface.
 func (p Page) String() string {
+	if p.cursor != nil {
+		return fmt.Sprintf("cursor: %s rows: %d", p.cursor, p.rows)
+	}
+
 	return fmt.Sprintf("page: %d rows: %d", p.number, p.rows)
 }
 
-// Number returns the page number.
+// Number returns the page number, which is zero for a page at a cursor.
 func (p Page) Number() int {
 	return p.number
 }
 
+// Offset returns the number of rows before the page, which is zero for a
+// page at a cursor since the rows are sought from it.
+func (p Page) Offset() int {
+	if p.cursor != nil {
+		return 0
+	}
+
+	return (p.number - 1) * p.rows
+}
+
 // RowsPerPage returns the rows per page.
 func (p Page) RowsPerPage() int {
 	return p.rows
 }
+
+// Cursor returns the cursor of the page, if it's at one.
+func (p Page) Cursor() (Cursor, bool) {
+	if p.cursor == nil {
+		return Cursor{}, false
+	}
+
+	return *p.cursor, true
+}
+
+// OrderBy returns the order of the cursor of the page, or the default when
+// the page isn't at a cursor.
+func (p Page) OrderBy(defaultOrder order.By) order.By {
+	if p.cursor == nil {
+		return defaultOrder
+	}
+
+	return p.cursor.OrderBy
+}
+
+// Check validates the page can be read in the specified order. The rows of a
+// page at a cursor must be read in the order the cursor was made for.
+func (p Page) Check(orderBy order.By) error {
+	if p.cursor != nil && p.cursor.OrderBy != orderBy {
+		return errors.New("cursor was made for another order")
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// Cursor is the position of a row in results ordered by a field, which is
+// the value of the field and the ID of the row to break ties. The rows are
+// sought after the cursor, or before it when going backward.
+type Cursor struct {
+	OrderBy  order.By
+	Value    any
+	ID       string
+	Backward bool
+}
+
+type cursor struct {
+	Field     string `json:"f"`
+	Direction string `json:"d"`
+	Value     any    `json:"v"`
+	ID        string `json:"i"`
+	Backward  bool   `json:"b,omitempty"`
+}
+
+// String returns the cursor as the opaque value handed to clients.
+func (c Cursor) String() string {
+	data, err := json.Marshal(cursor{
+		Field:     c.OrderBy.Field,
+		Direction: c.OrderBy.Direction,
+		Value:     c.Value,
+		ID:        c.ID,
+		Backward:  c.Backward,
+	})
+	if err != nil {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("cursor conversion: %w", err)
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Cursor{}, fmt.Errorf("cursor conversion: %w", err)
+	}
+
+	if c.Field == "" || c.ID == "" || (c.Direction != order.ASC && c.Direction != order.DESC) {
+		return Cursor{}, errors.New("cursor value is invalid")
+	}
+
+	switch c.Value.(type) {
+	case string, float64, bool:
+	default:
+		return Cursor{}, errors.New("cursor value is invalid")
+	}
+
+	cur := Cursor{
+		OrderBy:  order.NewBy(c.Field, c.Direction),
+		Value:    c.Value,
+		ID:       c.ID,
+		Backward: c.Backward,
+	}
+
+	return cur, nil
+}
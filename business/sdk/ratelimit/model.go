@@ -0,0 +1,187 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rmsj/service/business/types/role"
+)
+
+// Quota is the number of requests allowed in a period. Bursts of up to the
+// limit are allowed, and the allowance is refilled evenly over the period.
+type Quota struct {
+	Limit  int
+	Period time.Duration
+}
+
+// ParseQuota parses a quota in the "limit/period" format, like "100/1m".
+func ParseQuota(value string) (Quota, error) {
+	limit, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return Quota{}, fmt.Errorf("invalid quota %q", value)
+	}
+
+	n, err := strconv.Atoi(limit)
+	if err != nil || n <= 0 {
+		return Quota{}, fmt.Errorf("invalid quota limit %q", value)
+	}
+
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Quota{}, fmt.Errorf("invalid quota period %q", value)
+	}
+
+	return Quota{Limit: n, Period: d}, nil
+}
+
+// String returns the quota in the format it's parsed from.
+func (q Quota) String() string {
+	return fmt.Sprintf("%d/%s", q.Limit, q.Period)
+}
+
+// rate returns the number of requests refilled per second.
+func (q Quota) rate() float64 {
+	return float64(q.Limit) / q.Period.Seconds()
+}
+
+// =============================================================================
+
+// Bucket holds the requests still allowed for a key, as of when it was last
+// updated.
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// Result reports the outcome of taking a request from a bucket. Reset is how
+// long until the bucket is full again and RetryAfter, for the requests that
+// aren't allowed, how long until one is.
+type Result struct {
+	Allowed    bool
+	Quota      Quota
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Take refills the bucket for the time since it was last updated and takes a
+// request from it, if there's one left. A bucket that was never updated is
+// full. It returns the bucket to store.
+func Take(b Bucket, q Quota, now time.Time) (Bucket, Result) {
+	rate := q.rate()
+
+	tokens := float64(q.Limit)
+	if !b.UpdatedAt.IsZero() {
+		elapsed := max(now.Sub(b.UpdatedAt).Seconds(), 0)
+		tokens = math.Min(float64(q.Limit), b.Tokens+elapsed*rate)
+	}
+
+	res := Result{
+		Quota: q,
+	}
+
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - tokens) / rate)
+	}
+
+	res.Remaining = int(tokens)
+	res.Reset = seconds((float64(q.Limit) - tokens) / rate)
+
+	return Bucket{Tokens: tokens, UpdatedAt: now}, res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
+
+// =============================================================================
+
+// wildcard is the group of the quotas that apply to every group.
+const wildcard = "*"
+
+// Policies holds the quotas of the route groups, keyed by "group" for the
+// default quota of a group and "group:role" for the quota of a role in it.
+// The "*" group applies to the groups without a quota of their own.
+type Policies map[string]Quota
+
+// ParsePolicies parses the quotas in the "group[:role]=limit/period" format,
+// like "products:admin=1000/1m".
+func ParsePolicies(values []string) (Policies, error) {
+	policies := make(Policies, len(values))
+
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		name, quota, ok := strings.Cut(value, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid policy %q", value)
+		}
+
+		group, roleName, hasRole := strings.Cut(name, ":")
+		if group == "" {
+			return nil, fmt.Errorf("invalid policy %q: missing group", value)
+		}
+
+		if hasRole {
+			if _, err := role.Parse(roleName); err != nil {
+				return nil, fmt.Errorf("invalid policy %q: %w", value, err)
+			}
+		}
+
+		q, err := ParseQuota(quota)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy %q: %w", value, err)
+		}
+
+		policies[name] = q
+	}
+
+	return policies, nil
+}
+
+// Quota returns the quota of the group for a client with the roles. The
+// quota of a role, set for the group or for every group, comes before the
+// default quota of the group, and when the roles have different quotas the
+// most generous one applies. It reports false when the group isn't limited.
+func (p Policies) Quota(group string, roles []role.Role) (Quota, bool) {
+	var best Quota
+	var found bool
+
+	for _, r := range roles {
+		q, ok := p.lookup(group, r.String())
+		if ok && (!found || q.rate() > best.rate()) {
+			best, found = q, true
+		}
+	}
+
+	if found {
+		return best, true
+	}
+
+	return p.lookup(group, "")
+}
+
+func (p Policies) lookup(group string, roleName string) (Quota, bool) {
+	key := func(group string) string {
+		if roleName == "" {
+			return group
+		}
+		return group + ":" + roleName
+	}
+
+	if q, ok := p[key(group)]; ok {
+		return q, true
+	}
+
+	q, ok := p[key(wildcard)]
+	return q, ok
+}
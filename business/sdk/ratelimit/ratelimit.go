@@ -0,0 +1,79 @@
+// Package ratelimit limits the rate of the requests made by clients using
+// token buckets. The buckets are kept by a store, in process for a single
+// instance of a service or in the database to share them between replicas.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rmsj/service/business/sdk/ctxval"
+	"github.com/rmsj/service/business/types/role"
+	"github.com/rmsj/service/foundation/logger"
+	"github.com/rmsj/service/foundation/otel"
+)
+
+// Storer interface declares the behavior this package needs to keep the
+// buckets. Taking a request from a bucket must be atomic, since the same
+// client can make requests concurrently.
+type Storer interface {
+	Take(ctx context.Context, key string, q Quota, now time.Time) (Result, error)
+	DeleteExpired(ctx context.Context, before time.Time) (int, error)
+}
+
+// Config contains the settings for the limiter.
+type Config struct {
+	Log      *logger.Logger
+	Storer   Storer
+	Policies Policies
+}
+
+// Limiter decides if the requests of a client are allowed by the quota of the
+// route group they are made to.
+type Limiter struct {
+	cfg Config
+}
+
+// New constructs a limiter for use.
+func New(cfg Config) *Limiter {
+	return &Limiter{
+		cfg: cfg,
+	}
+}
+
+// Allow takes a request from the bucket the client has for the group. The
+// key identifies the client and the roles pick the quota. Requests to groups
+// without a quota are always allowed, with a zero quota in the result.
+func (l *Limiter) Allow(ctx context.Context, group string, key string, roles []role.Role) (Result, error) {
+	ctx, span := otel.AddSpan(ctx, "business.ratelimit.allow")
+	defer span.End()
+
+	q, ok := l.cfg.Policies.Quota(group, roles)
+	if !ok {
+		return Result{Allowed: true}, nil
+	}
+
+	res, err := l.cfg.Storer.Take(ctx, group+"|"+key, q, ctxval.GetTime(ctx))
+	if err != nil {
+		l.cfg.Log.Error(ctx, "business.ratelimit.allow", "error", err)
+		return Result{}, fmt.Errorf("take: group[%s]: %w", group, err)
+	}
+
+	return res, nil
+}
+
+// DeleteExpired removes the buckets that were full before the time specified,
+// since those are the same as no bucket, and returns how many were removed.
+func (l *Limiter) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.ratelimit.deleteexpired")
+	defer span.End()
+
+	count, err := l.cfg.Storer.DeleteExpired(ctx, before)
+	if err != nil {
+		l.cfg.Log.Error(ctx, "business.ratelimit.deleteexpired", "error", err)
+		return 0, fmt.Errorf("deleteExpired: %w", err)
+	}
+
+	return count, nil
+}
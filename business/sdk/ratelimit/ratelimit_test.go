@@ -0,0 +1,120 @@
+package ratelimit_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/rmsj/service/business/sdk/ratelimit"
+	"github.com/rmsj/service/business/sdk/ratelimit/stores/ratelimitmem"
+	"github.com/rmsj/service/business/types/role"
+	"github.com/rmsj/service/foundation/logger"
+)
+
+func Test_Take(t *testing.T) {
+	q := ratelimit.Quota{Limit: 2, Period: 2 * time.Second}
+	now := time.Date(2024, time.January, 31, 10, 20, 30, 0, time.UTC)
+
+	b, res := ratelimit.Take(ratelimit.Bucket{}, q, now)
+	if !res.Allowed || res.Remaining != 1 || res.Reset != time.Second {
+		t.Fatalf("Should allow the first request from a full bucket: %+v", res)
+	}
+
+	b, res = ratelimit.Take(b, q, now)
+	if !res.Allowed || res.Remaining != 0 || res.Reset != 2*time.Second {
+		t.Fatalf("Should allow a burst up to the limit: %+v", res)
+	}
+
+	b, res = ratelimit.Take(b, q, now.Add(500*time.Millisecond))
+	if res.Allowed || res.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("Should NOT allow a request over the limit: %+v", res)
+	}
+
+	_, res = ratelimit.Take(b, q, now.Add(time.Second))
+	if !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("Should allow a request once a token is refilled: %+v", res)
+	}
+
+	_, res = ratelimit.Take(b, q, now.Add(time.Hour))
+	if !res.Allowed || res.Remaining != 1 {
+		t.Fatalf("Should refill the bucket up to the limit only: %+v", res)
+	}
+}
+
+func Test_Policies(t *testing.T) {
+	policies, err := ratelimit.ParsePolicies([]string{
+		"*=100/1m",
+		"*:admin=1000/1m",
+		"products=10/1m",
+		"products:manager=50/1m",
+	})
+	if err != nil {
+		t.Fatalf("Should be able to parse the policies: %s", err)
+	}
+
+	tests := []struct {
+		name  string
+		group string
+		roles []role.Role
+		exp   ratelimit.Quota
+	}{
+		{"default", "users", nil, ratelimit.Quota{Limit: 100, Period: time.Minute}},
+		{"group", "products", []role.Role{role.User}, ratelimit.Quota{Limit: 10, Period: time.Minute}},
+		{"group role", "products", []role.Role{role.Manager}, ratelimit.Quota{Limit: 50, Period: time.Minute}},
+		{"any group role", "products", []role.Role{role.Admin}, ratelimit.Quota{Limit: 1000, Period: time.Minute}},
+		{"most generous", "products", []role.Role{role.Manager, role.Admin}, ratelimit.Quota{Limit: 1000, Period: time.Minute}},
+	}
+
+	for _, tt := range tests {
+		got, ok := policies.Quota(tt.group, tt.roles)
+		if !ok || got != tt.exp {
+			t.Errorf("%s: Should get quota %s, got %s", tt.name, tt.exp, got)
+		}
+	}
+
+	if _, ok := (ratelimit.Policies{"products=10/1m": {}}).Quota("users", nil); ok {
+		t.Error("Should NOT limit a group without a quota")
+	}
+
+	for _, value := range []string{"products", "products=10", "products=0/1m", "products=10/0s", "products:nobody=10/1m", "=10/1m"} {
+		if _, err := ratelimit.ParsePolicies([]string{value}); err == nil {
+			t.Errorf("Should NOT be able to parse the policy %q", value)
+		}
+	}
+}
+
+func Test_Limiter(t *testing.T) {
+	store := ratelimitmem.NewStore()
+
+	limiter := ratelimit.New(ratelimit.Config{
+		Log:    logger.New(io.Discard, logger.LevelInfo, "TEST", func(context.Context) string { return "" }),
+		Storer: store,
+		Policies: ratelimit.Policies{
+			"products": {Limit: 1, Period: time.Minute},
+		},
+	})
+
+	ctx := context.Background()
+
+	if res, err := limiter.Allow(ctx, "products", "user:1", nil); err != nil || !res.Allowed {
+		t.Fatalf("Should allow the first request: %+v %v", res, err)
+	}
+
+	if res, err := limiter.Allow(ctx, "products", "user:1", nil); err != nil || res.Allowed {
+		t.Fatalf("Should NOT allow the second request: %+v %v", res, err)
+	}
+
+	if res, err := limiter.Allow(ctx, "products", "user:2", nil); err != nil || !res.Allowed {
+		t.Fatalf("Should keep a bucket for each client: %+v %v", res, err)
+	}
+
+	if res, err := limiter.Allow(ctx, "users", "user:1", nil); err != nil || !res.Allowed || res.Quota.Limit != 0 {
+		t.Fatalf("Should always allow the groups without a quota: %+v %v", res, err)
+	}
+
+	count, err := limiter.DeleteExpired(ctx, time.Now().Add(2*time.Minute))
+	if err != nil || count != 2 {
+		t.Fatalf("Should remove the buckets that are full again: %d %v", count, err)
+	}
+}
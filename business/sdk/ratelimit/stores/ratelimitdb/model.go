@@ -0,0 +1,30 @@
+package ratelimitdb
+
+import (
+	"time"
+
+	"github.com/rmsj/service/business/sdk/ratelimit"
+)
+
+type bucket struct {
+	Key       string    `db:"bucket_key"`
+	Tokens    float64   `db:"tokens"`
+	UpdatedAt time.Time `db:"updated_at"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+func toDBBucket(key string, bus ratelimit.Bucket, expiresAt time.Time) bucket {
+	return bucket{
+		Key:       key,
+		Tokens:    bus.Tokens,
+		UpdatedAt: bus.UpdatedAt.UTC(),
+		ExpiresAt: expiresAt.UTC(),
+	}
+}
+
+func toBusBucket(db bucket) ratelimit.Bucket {
+	return ratelimit.Bucket{
+		Tokens:    db.Tokens,
+		UpdatedAt: db.UpdatedAt.In(time.Local),
+	}
+}
@@ -0,0 +1,125 @@
+// Package ratelimitdb keeps the rate limit buckets in the database, so they
+// are shared between the replicas of a service.
+package ratelimitdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/rmsj/service/business/sdk/ratelimit"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/foundation/logger"
+)
+
+// Store manages the set of APIs for bucket database access.
+type Store struct {
+	log *logger.Logger
+	db  *sqlx.DB
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Take takes a request from the bucket of the key. The bucket is locked for
+// the duration of a transaction, so the concurrent requests of a client are
+// taken one after the other.
+func (s *Store) Take(ctx context.Context, key string, q ratelimit.Quota, now time.Time) (ratelimit.Result, error) {
+	tx, err := sqldb.NewBeginner(s.db).Begin()
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+
+	// A new bucket is added full. The update of an existing one changes
+	// nothing, but locks it before it's read.
+	const ins = `
+	INSERT INTO rate_limits
+		(bucket_key, tokens, updated_at, expires_at)
+	VALUES
+		(:bucket_key, :tokens, :updated_at, :expires_at)
+	ON DUPLICATE KEY UPDATE
+		tokens = tokens`
+
+	full := ratelimit.Bucket{Tokens: float64(q.Limit), UpdatedAt: now}
+
+	if err := sqldb.NamedExecContext(ctx, s.log, ec, ins, toDBBucket(key, full, now)); err != nil {
+		return ratelimit.Result{}, fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	data := struct {
+		Key string `db:"bucket_key"`
+	}{
+		Key: key,
+	}
+
+	const sel = `
+	SELECT
+		bucket_key, tokens, updated_at, expires_at
+	FROM
+		rate_limits
+	WHERE
+		bucket_key = :bucket_key
+	FOR UPDATE`
+
+	var dbBucket bucket
+	if err := sqldb.NamedQueryStruct(ctx, s.log, ec, sel, data, &dbBucket); err != nil {
+		return ratelimit.Result{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	b, res := ratelimit.Take(toBusBucket(dbBucket), q, now)
+
+	const upd = `
+	UPDATE
+		rate_limits
+	SET
+		tokens = :tokens,
+		updated_at = :updated_at,
+		expires_at = :expires_at
+	WHERE
+		bucket_key = :bucket_key`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, ec, upd, toDBBucket(key, b, now.Add(res.Reset))); err != nil {
+		return ratelimit.Result{}, fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ratelimit.Result{}, fmt.Errorf("commit: %w", err)
+	}
+
+	return res, nil
+}
+
+// DeleteExpired removes the buckets that were full before the specified time.
+func (s *Store) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	data := struct {
+		Before time.Time `db:"before"`
+	}{
+		Before: before.UTC(),
+	}
+
+	const q = `
+	DELETE FROM
+		rate_limits
+	WHERE
+		expires_at < :before`
+
+	count, err := sqldb.NamedExecContextWithCount(ctx, s.log, s.db, q, data)
+	if err != nil {
+		return 0, fmt.Errorf("namedexeccontextwithcount: %w", err)
+	}
+
+	return int(count), nil
+}
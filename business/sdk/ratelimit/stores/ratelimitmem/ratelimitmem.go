@@ -0,0 +1,74 @@
+// Package ratelimitmem keeps the rate limit buckets in process, for a single
+// instance of a service.
+package ratelimitmem
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rmsj/service/business/sdk/ratelimit"
+)
+
+// sweepInterval is how often the buckets that are full again are removed.
+const sweepInterval = time.Minute
+
+type bucket struct {
+	ratelimit.Bucket
+	expiresAt time.Time
+}
+
+// Store manages the set of APIs for in process bucket access.
+type Store struct {
+	mu        sync.Mutex
+	buckets   map[string]bucket
+	nextSweep time.Time
+}
+
+// NewStore constructs the api for data access.
+func NewStore() *Store {
+	return &Store{
+		buckets: make(map[string]bucket),
+	}
+}
+
+// Take takes a request from the bucket of the key.
+func (s *Store) Take(ctx context.Context, key string, q ratelimit.Quota, now time.Time) (ratelimit.Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.After(s.nextSweep) {
+		s.sweep(now)
+		s.nextSweep = now.Add(sweepInterval)
+	}
+
+	b, res := ratelimit.Take(s.buckets[key].Bucket, q, now)
+
+	s.buckets[key] = bucket{
+		Bucket:    b,
+		expiresAt: now.Add(res.Reset),
+	}
+
+	return res, nil
+}
+
+// DeleteExpired removes the buckets that were full before the specified time.
+func (s *Store) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sweep(before), nil
+}
+
+func (s *Store) sweep(before time.Time) int {
+	var count int
+
+	for key, b := range s.buckets {
+		if b.expiresAt.Before(before) {
+			delete(s.buckets, key)
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,235 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSchedule is returned when a cron expression can't be parsed.
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// descriptors maps the supported shorthand expressions to their cron form.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var months = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var weekdays = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// field describes the range and names of a cron field.
+type field struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var fields = [5]field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: months},
+	{name: "day of week", min: 0, max: 7, names: weekdays},
+}
+
+// Schedule represents a parsed cron expression.
+type Schedule struct {
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+
+	// The day of month and day of week fields match a day when either of
+	// them does, unless one of them is unrestricted.
+	anyDOM bool
+	anyDOW bool
+}
+
+// ParseSchedule parses a standard five field cron expression (minute, hour,
+// day of month, month and day of week) or one of the @yearly, @monthly,
+// @weekly, @daily and @hourly shorthands. Fields accept *, lists, ranges,
+// steps and, for months and weekdays, three letter names.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if strings.HasPrefix(spec, "@") {
+		expr, exists := descriptors[strings.ToLower(spec)]
+		if !exists {
+			return Schedule{}, fmt.Errorf("descriptor[%s]: %w", spec, ErrInvalidSchedule)
+		}
+		spec = expr
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return Schedule{}, fmt.Errorf("expected %d fields, got %d: %w", len(fields), len(parts), ErrInvalidSchedule)
+	}
+
+	var bits [5]uint64
+	for i, part := range parts {
+		b, err := parseField(part, fields[i])
+		if err != nil {
+			return Schedule{}, err
+		}
+		bits[i] = b
+	}
+
+	// Sunday can be written as 0 or 7.
+	if bits[4]&(1<<7) != 0 {
+		bits[4] = bits[4]&^(1<<7) | 1
+	}
+
+	sch := Schedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		anyDOM: parts[2] == "*",
+		anyDOW: parts[4] == "*",
+	}
+
+	return sch, nil
+}
+
+// Next returns the first time after t that matches the schedule, in the
+// location of t. The zero time is returned if there is no match within the
+// next five years, which only happens with dates like the 30th of February.
+func (s Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (s Schedule) matchDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case s.anyDOM && s.anyDOW:
+		return true
+	case s.anyDOM:
+		return dow
+	case s.anyDOW:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// =============================================================================
+
+func parseField(expr string, f field) (uint64, error) {
+	var bits uint64
+
+	for _, item := range strings.Split(expr, ",") {
+		b, err := parseItem(item, f)
+		if err != nil {
+			return 0, err
+		}
+		bits |= b
+	}
+
+	return bits, nil
+}
+
+func parseItem(item string, f field) (uint64, error) {
+	rng, stepStr, hasStep := strings.Cut(item, "/")
+
+	step := 1
+	if hasStep {
+		n, err := strconv.Atoi(stepStr)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("%s: step[%s]: %w", f.name, stepStr, ErrInvalidSchedule)
+		}
+		step = n
+	}
+
+	var lo, hi int
+
+	switch {
+	case rng == "*":
+		lo, hi = f.min, f.max
+
+	default:
+		loStr, hiStr, isRange := strings.Cut(rng, "-")
+
+		var err error
+		if lo, err = parseValue(loStr, f); err != nil {
+			return 0, err
+		}
+
+		hi = lo
+		switch {
+		case isRange:
+			if hi, err = parseValue(hiStr, f); err != nil {
+				return 0, err
+			}
+
+		// A single value with a step, like 5/15, runs up to the maximum.
+		case hasStep:
+			hi = f.max
+		}
+	}
+
+	if lo > hi {
+		return 0, fmt.Errorf("%s: range[%s]: %w", f.name, rng, ErrInvalidSchedule)
+	}
+
+	var bits uint64
+	for v := lo; v <= hi; v += step {
+		bits |= 1 << uint(v)
+	}
+
+	return bits, nil
+}
+
+func parseValue(s string, f field) (int, error) {
+	if v, exists := f.names[strings.ToLower(s)]; exists {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s: value[%s]: %w", f.name, s, ErrInvalidSchedule)
+	}
+
+	return v, nil
+}
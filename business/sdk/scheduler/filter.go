@@ -0,0 +1,23 @@
+package scheduler
+
+import "github.com/rmsj/service/business/sdk/filter"
+
+// Set of fields that the filter expressions can use.
+const (
+	FilterByRunID       = "a"
+	FilterByTask        = "b"
+	FilterByOwner       = "c"
+	FilterByStatus      = "d"
+	FilterByScheduledAt = "e"
+	FilterByStartedAt   = "f"
+	FilterByFinishedAt  = "g"
+)
+
+// QueryFilter holds the available fields a query can be filtered on.
+// We are using pointer semantics because the With API mutates the value.
+// The expression is on the FilterBy fields.
+type QueryFilter struct {
+	Task   *string
+	Status *string
+	Expr   filter.Expr
+}
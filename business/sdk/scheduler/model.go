@@ -0,0 +1,46 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaskFunc represents a function that executes a scheduled task.
+type TaskFunc func(ctx context.Context) error
+
+// Set of statuses a run can be in.
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Task describes a registered task and when it runs next.
+type Task struct {
+	Name     string
+	Schedule string
+	Next     time.Time
+}
+
+// Run represents the execution of a task for one tick of its schedule.
+type Run struct {
+	ID          uuid.UUID
+	Task        string
+	Owner       string
+	Status      string
+	Error       string
+	ScheduledAt time.Time
+	StartedAt   time.Time
+	FinishedAt  time.Time
+}
+
+// Lease gives an owner the exclusive right to run a task for a tick until it
+// expires or is released.
+type Lease struct {
+	Task      string
+	Tick      time.Time
+	Owner     string
+	ExpiresAt time.Time
+}
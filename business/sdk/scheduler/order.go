@@ -0,0 +1,16 @@
+package scheduler
+
+import "github.com/rmsj/service/business/sdk/order"
+
+// DefaultOrderBy represents the default way we sort, with the most recent
+// runs first.
+var DefaultOrderBy = order.NewBy(OrderByStartedAt, order.DESC)
+
+// Set of fields that the results can be ordered by.
+const (
+	OrderByRunID       = "a"
+	OrderByTask        = "b"
+	OrderByStatus      = "c"
+	OrderByScheduledAt = "d"
+	OrderByStartedAt   = "e"
+)
@@ -0,0 +1,318 @@
+// Package scheduler runs tasks on cron schedules. Every replica of a service
+// runs the scheduler, and a lease stored in the database makes sure only one
+// of them runs each tick of a task.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/sdk/order"
+	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/foundation/logger"
+	"github.com/rmsj/service/foundation/otel"
+	"github.com/rmsj/service/foundation/worker"
+)
+
+// Set of error variables for scheduler operations.
+var (
+	ErrInvalidName   = errors.New("task name is required")
+	ErrDuplicateTask = errors.New("task already registered")
+)
+
+// Storer interface declares the behavior this package needs to persist the
+// leases and the run history.
+type Storer interface {
+	AcquireLease(ctx context.Context, lease Lease, now time.Time) (bool, error)
+	ReleaseLease(ctx context.Context, lease Lease) error
+	CreateRun(ctx context.Context, run Run) error
+	UpdateRun(ctx context.Context, run Run) error
+	QueryRuns(ctx context.Context, filter QueryFilter, orderBy order.By, page page.Page) ([]Run, error)
+	CountRuns(ctx context.Context, filter QueryFilter) (int, error)
+}
+
+// Config contains the settings for the scheduler.
+type Config struct {
+	Log      *logger.Logger
+	Storer   Storer
+	Worker   *worker.Worker
+	Owner    string
+	Interval time.Duration
+	Lease    time.Duration
+}
+
+// Scheduler starts the registered tasks on their schedules.
+type Scheduler struct {
+	cfg   Config
+	mu    sync.Mutex
+	tasks []*task
+}
+
+type task struct {
+	name     string
+	spec     string
+	schedule Schedule
+	fn       TaskFunc
+	next     time.Time
+}
+
+// New constructs a scheduler for use. The lease is the longest a task is
+// expected to run: the task is given it as a deadline, and the ticks that
+// come up while it's still running are skipped.
+func New(cfg Config) *Scheduler {
+	if cfg.Owner == "" {
+		cfg.Owner = uuid.NewString()
+	}
+
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Second
+	}
+
+	if cfg.Lease <= 0 {
+		cfg.Lease = 10 * time.Minute
+	}
+
+	return &Scheduler{
+		cfg: cfg,
+	}
+}
+
+// Register adds a task that runs on the specified cron schedule.
+func (s *Scheduler) Register(name string, spec string, fn TaskFunc) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("parse: task[%s]: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if slices.ContainsFunc(s.tasks, func(t *task) bool { return t.name == name }) {
+		return fmt.Errorf("register: task[%s]: %w", name, ErrDuplicateTask)
+	}
+
+	s.tasks = append(s.tasks, &task{
+		name:     name,
+		spec:     spec,
+		schedule: schedule,
+		fn:       fn,
+		next:     schedule.Next(time.Now()),
+	})
+
+	return nil
+}
+
+// Tasks returns the registered tasks.
+func (s *Scheduler) Tasks() []Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks := make([]Task, len(s.tasks))
+	for i, t := range s.tasks {
+		tasks[i] = Task{
+			Name:     t.name,
+			Schedule: t.spec,
+			Next:     t.next,
+		}
+	}
+
+	return tasks
+}
+
+// Run starts the tasks that are due on every interval until the context is
+// cancelled. The tasks already started keep running; use the worker Shutdown
+// to wait for them.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.cfg.Log.Info(ctx, "scheduler", "status", "started", "owner", s.cfg.Owner, "interval", s.cfg.Interval)
+	defer s.cfg.Log.Info(ctx, "scheduler", "status", "stopped")
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if _, err := s.RunOnce(ctx, time.Now()); err != nil {
+				s.cfg.Log.Error(ctx, "scheduler", "status", "run failed", "err", err)
+			}
+		}
+	}
+}
+
+// RunOnce starts the tasks that are due at the specified time and whose lease
+// could be acquired. It returns the number of tasks started. Ticks that were
+// missed while the scheduler wasn't running are not caught up.
+func (s *Scheduler) RunOnce(ctx context.Context, now time.Time) (int, error) {
+	var started int
+
+	for _, t := range s.due(now) {
+		lease := Lease{
+			Task:      t.name,
+			Tick:      t.tick,
+			Owner:     s.cfg.Owner,
+			ExpiresAt: now.Add(s.cfg.Lease),
+		}
+
+		acquired, err := s.cfg.Storer.AcquireLease(ctx, lease, now)
+		if err != nil {
+			return started, fmt.Errorf("acquirelease: task[%s]: %w", t.name, err)
+		}
+
+		if !acquired {
+			continue
+		}
+
+		taskCtx, cancel := context.WithDeadline(ctx, lease.ExpiresAt)
+
+		_, err = s.cfg.Worker.Start(taskCtx, func(ctx context.Context) {
+			s.execute(ctx, lease, t.fn)
+		})
+
+		cancel()
+
+		if err != nil {
+			s.release(ctx, lease)
+			return started, fmt.Errorf("start: task[%s]: %w", t.name, err)
+		}
+
+		started++
+	}
+
+	return started, nil
+}
+
+// QueryRuns retrieves a list of task runs.
+func (s *Scheduler) QueryRuns(ctx context.Context, filter QueryFilter, orderBy order.By, page page.Page) ([]Run, error) {
+	ctx, span := otel.AddSpan(ctx, "business.scheduler.queryruns")
+	defer span.End()
+
+	runs, err := s.cfg.Storer.QueryRuns(ctx, filter, orderBy, page)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return runs, nil
+}
+
+// CountRuns returns the total number of task runs.
+func (s *Scheduler) CountRuns(ctx context.Context, filter QueryFilter) (int, error) {
+	ctx, span := otel.AddSpan(ctx, "business.scheduler.countruns")
+	defer span.End()
+
+	return s.cfg.Storer.CountRuns(ctx, filter)
+}
+
+// =============================================================================
+
+type dueTask struct {
+	name string
+	tick time.Time
+	fn   TaskFunc
+}
+
+// due returns the tasks whose next tick has come and moves them on to the
+// following tick.
+func (s *Scheduler) due(now time.Time) []dueTask {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []dueTask
+
+	for _, t := range s.tasks {
+		if t.next.IsZero() || t.next.After(now) {
+			continue
+		}
+
+		due = append(due, dueTask{
+			name: t.name,
+			tick: t.next,
+			fn:   t.fn,
+		})
+
+		t.next = t.schedule.Next(now)
+	}
+
+	return due
+}
+
+// execute runs the task, recording the run and releasing the lease once it
+// is done.
+func (s *Scheduler) execute(ctx context.Context, lease Lease, fn TaskFunc) {
+	run := Run{
+		ID:          uuid.New(),
+		Task:        lease.Task,
+		Owner:       lease.Owner,
+		Status:      StatusRunning,
+		ScheduledAt: lease.Tick,
+		StartedAt:   time.Now(),
+	}
+
+	// The task context can be done by the time the run is recorded, so the
+	// history is written with a context of its own.
+	recCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	err := s.cfg.Storer.CreateRun(recCtx, run)
+	cancel()
+
+	if err != nil {
+		s.cfg.Log.Error(ctx, "scheduler", "status", "recording run", "task", run.Task, "err", err)
+	}
+
+	s.cfg.Log.Info(ctx, "scheduler", "status", "task started", "task", run.Task, "run_id", run.ID)
+
+	err = call(ctx, fn)
+
+	run.FinishedAt = time.Now()
+
+	switch err {
+	case nil:
+		run.Status = StatusSucceeded
+		s.cfg.Log.Info(ctx, "scheduler", "status", "task succeeded", "task", run.Task, "run_id", run.ID, "duration", run.FinishedAt.Sub(run.StartedAt))
+
+	default:
+		run.Status = StatusFailed
+		run.Error = err.Error()
+		s.cfg.Log.Error(ctx, "scheduler", "status", "task failed", "task", run.Task, "run_id", run.ID, "err", err)
+	}
+
+	recCtx, cancel = context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	if err := s.cfg.Storer.UpdateRun(recCtx, run); err != nil {
+		s.cfg.Log.Error(ctx, "scheduler", "status", "recording run", "task", run.Task, "run_id", run.ID, "err", err)
+	}
+
+	s.release(recCtx, lease)
+}
+
+// release ends the lease so the next tick of the task isn't skipped.
+func (s *Scheduler) release(ctx context.Context, lease Lease) {
+	lease.ExpiresAt = time.Now()
+
+	if err := s.cfg.Storer.ReleaseLease(ctx, lease); err != nil {
+		s.cfg.Log.Error(ctx, "scheduler", "status", "releasing lease", "task", lease.Task, "err", err)
+	}
+}
+
+// call executes the task, turning a panic into an error.
+func call(ctx context.Context, fn TaskFunc) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("panic: %v", rec)
+		}
+	}()
+
+	return fn(ctx)
+}
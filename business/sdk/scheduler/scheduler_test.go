@@ -0,0 +1,226 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rmsj/service/business/sdk/order"
+	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/business/sdk/scheduler"
+	"github.com/rmsj/service/foundation/logger"
+	"github.com/rmsj/service/foundation/worker"
+)
+
+func Test_Schedule(t *testing.T) {
+	base := time.Date(2024, time.January, 31, 10, 20, 30, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		exp  time.Time
+	}{
+		{"every minute", "* * * * *", base, time.Date(2024, time.January, 31, 10, 21, 0, 0, time.UTC)},
+		{"step", "*/15 * * * *", base, time.Date(2024, time.January, 31, 10, 30, 0, 0, time.UTC)},
+		{"daily", "0 3 * * *", base, time.Date(2024, time.February, 1, 3, 0, 0, 0, time.UTC)},
+		{"list", "5,50 10 * * *", base, time.Date(2024, time.January, 31, 10, 50, 0, 0, time.UTC)},
+		{"range", "0 9-17 * * *", base, time.Date(2024, time.January, 31, 11, 0, 0, 0, time.UTC)},
+		{"weekday", "0 0 * * mon", base, time.Date(2024, time.February, 5, 0, 0, 0, 0, time.UTC)},
+		{"sunday as 7", "0 0 * * 7", base, time.Date(2024, time.February, 4, 0, 0, 0, 0, time.UTC)},
+		{"month name", "0 0 1 mar *", base, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", base, time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"day of month or week", "0 0 15 * fri", base, time.Date(2024, time.February, 2, 0, 0, 0, 0, time.UTC)},
+		{"monthly", "@monthly", base, time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"hourly", "@hourly", base, time.Date(2024, time.January, 31, 11, 0, 0, 0, time.UTC)},
+		{"never", "0 0 30 2 *", base, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		sch, err := scheduler.ParseSchedule(tt.spec)
+		if err != nil {
+			t.Fatalf("%s: Should be able to parse %q : %s", tt.name, tt.spec, err)
+		}
+
+		if got := sch.Next(tt.from); !got.Equal(tt.exp) {
+			t.Errorf("%s: Should get the next time for %q : got %s, exp %s", tt.name, tt.spec, got, tt.exp)
+		}
+	}
+
+	invalid := []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "*/0 * * * *", "5-1 * * * *", "@often", "* * * foo *"}
+
+	for _, spec := range invalid {
+		if _, err := scheduler.ParseSchedule(spec); !errors.Is(err, scheduler.ErrInvalidSchedule) {
+			t.Errorf("Should not be able to parse %q : got %v", spec, err)
+		}
+	}
+}
+
+func Test_RunOnce(t *testing.T) {
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	store := newMemStore()
+
+	var mu sync.Mutex
+	var calls int
+
+	newScheduler := func(owner string) (*scheduler.Scheduler, *worker.Worker) {
+		wrk, err := worker.New(1)
+		if err != nil {
+			t.Fatalf("Should be able to construct the worker : %s", err)
+		}
+
+		sch := scheduler.New(scheduler.Config{
+			Log:    log,
+			Storer: store,
+			Worker: wrk,
+			Owner:  owner,
+		})
+
+		err = sch.Register("report", "@hourly", func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			calls++
+			return errors.New("report failed")
+		})
+		if err != nil {
+			t.Fatalf("Should be able to register the task : %s", err)
+		}
+
+		return sch, wrk
+	}
+
+	schA, wrkA := newScheduler("replica-a")
+	schB, wrkB := newScheduler("replica-b")
+
+	if err := schA.Register("report", "@daily", func(context.Context) error { return nil }); !errors.Is(err, scheduler.ErrDuplicateTask) {
+		t.Errorf("Should not register a task twice : got %v", err)
+	}
+
+	ctx := context.Background()
+
+	// Both replicas see the same tick, only one of them gets the lease.
+	now := schA.Tasks()[0].Next.Add(time.Second)
+
+	startedA, err := schA.RunOnce(ctx, now)
+	if err != nil {
+		t.Fatalf("Should be able to run the tasks : %s", err)
+	}
+
+	startedB, err := schB.RunOnce(ctx, now)
+	if err != nil {
+		t.Fatalf("Should be able to run the tasks : %s", err)
+	}
+
+	if startedA+startedB != 1 {
+		t.Fatalf("Should start the task once : got %d and %d", startedA, startedB)
+	}
+
+	for _, wrk := range []*worker.Worker{wrkA, wrkB} {
+		if err := wrk.Shutdown(ctx); err != nil {
+			t.Fatalf("Should be able to wait for the tasks : %s", err)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("Should have run the task once : got %d", calls)
+	}
+
+	runs, err := schA.QueryRuns(ctx, scheduler.QueryFilter{}, scheduler.DefaultOrderBy, page.MustParse("1", "10"))
+	if err != nil {
+		t.Fatalf("Should be able to query the runs : %s", err)
+	}
+
+	if len(runs) != 1 {
+		t.Fatalf("Should have recorded one run : got %d", len(runs))
+	}
+
+	if runs[0].Status != scheduler.StatusFailed || runs[0].Error != "report failed" {
+		t.Errorf("Should have recorded the failure : got %s %q", runs[0].Status, runs[0].Error)
+	}
+
+	if runs[0].FinishedAt.IsZero() {
+		t.Errorf("Should have recorded when the run finished")
+	}
+
+	if next := schA.Tasks()[0].Next; !next.After(now) {
+		t.Errorf("Should have moved on to the next tick : got %s", next)
+	}
+}
+
+// =============================================================================
+
+type memStore struct {
+	mu     *sync.Mutex
+	leases map[string]scheduler.Lease
+	runs   []scheduler.Run
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		mu:     &sync.Mutex{},
+		leases: make(map[string]scheduler.Lease),
+	}
+}
+
+func (s *memStore) AcquireLease(ctx context.Context, lease scheduler.Lease, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cur, exists := s.leases[lease.Task]; exists && (!cur.Tick.Before(lease.Tick) || cur.ExpiresAt.After(now)) {
+		return false, nil
+	}
+
+	s.leases[lease.Task] = lease
+	return true, nil
+}
+
+func (s *memStore) ReleaseLease(ctx context.Context, lease scheduler.Lease) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cur, exists := s.leases[lease.Task]; exists && cur.Owner == lease.Owner && cur.Tick.Equal(lease.Tick) {
+		s.leases[lease.Task] = lease
+	}
+
+	return nil
+}
+
+func (s *memStore) CreateRun(ctx context.Context, run scheduler.Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.runs = append(s.runs, run)
+	return nil
+}
+
+func (s *memStore) UpdateRun(ctx context.Context, run scheduler.Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.runs {
+		if s.runs[i].ID == run.ID {
+			s.runs[i] = run
+		}
+	}
+
+	return nil
+}
+
+func (s *memStore) QueryRuns(ctx context.Context, filter scheduler.QueryFilter, orderBy order.By, page page.Page) ([]scheduler.Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]scheduler.Run(nil), s.runs...), nil
+}
+
+func (s *memStore) CountRuns(ctx context.Context, filter scheduler.QueryFilter) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.runs), nil
+}
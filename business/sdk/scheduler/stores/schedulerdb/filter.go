@@ -0,0 +1,38 @@
+package schedulerdb
+
+import (
+	"bytes"
+
+	"github.com/rmsj/service/business/sdk/scheduler"
+	"github.com/rmsj/service/business/sdk/sqldb"
+)
+
+var filterByFields = map[string]string{
+	scheduler.FilterByRunID:       "run_id",
+	scheduler.FilterByTask:        "task",
+	scheduler.FilterByOwner:       "owner",
+	scheduler.FilterByStatus:      "status",
+	scheduler.FilterByScheduledAt: "scheduled_at",
+	scheduler.FilterByStartedAt:   "started_at",
+	scheduler.FilterByFinishedAt:  "finished_at",
+}
+
+func applyFilter(filter scheduler.QueryFilter, data map[string]any, buf *bytes.Buffer) error {
+	w := sqldb.NewWhere(data)
+
+	if filter.Task != nil {
+		w.Add("task = " + w.Bind(*filter.Task))
+	}
+
+	if filter.Status != nil {
+		w.Add("status = " + w.Bind(*filter.Status))
+	}
+
+	if err := w.AddExpr(filter.Expr, filterByFields); err != nil {
+		return err
+	}
+
+	buf.WriteString(w.String())
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package schedulerdb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rmsj/service/business/sdk/scheduler"
+)
+
+type run struct {
+	ID          uuid.UUID      `db:"run_id"`
+	Task        string         `db:"task"`
+	Owner       string         `db:"owner"`
+	Status      string         `db:"status"`
+	Error       sql.NullString `db:"error"`
+	ScheduledAt time.Time      `db:"scheduled_at"`
+	StartedAt   time.Time      `db:"started_at"`
+	FinishedAt  sql.NullTime   `db:"finished_at"`
+}
+
+func toDBRun(bus scheduler.Run) run {
+	db := run{
+		ID:     bus.ID,
+		Task:   bus.Task,
+		Owner:  bus.Owner,
+		Status: bus.Status,
+		Error: sql.NullString{
+			String: bus.Error,
+			Valid:  bus.Error != "",
+		},
+		ScheduledAt: bus.ScheduledAt.UTC(),
+		StartedAt:   bus.StartedAt.UTC(),
+		FinishedAt: sql.NullTime{
+			Time:  bus.FinishedAt.UTC(),
+			Valid: !bus.FinishedAt.IsZero(),
+		},
+	}
+
+	return db
+}
+
+func toBusRun(db run) scheduler.Run {
+	bus := scheduler.Run{
+		ID:          db.ID,
+		Task:        db.Task,
+		Owner:       db.Owner,
+		Status:      db.Status,
+		Error:       db.Error.String,
+		ScheduledAt: db.ScheduledAt.In(time.Local),
+		StartedAt:   db.StartedAt.In(time.Local),
+	}
+
+	if db.FinishedAt.Valid {
+		bus.FinishedAt = db.FinishedAt.Time.In(time.Local)
+	}
+
+	return bus
+}
+
+func toBusRuns(dbs []run) []scheduler.Run {
+	bus := make([]scheduler.Run, len(dbs))
+	for i, db := range dbs {
+		bus[i] = toBusRun(db)
+	}
+
+	return bus
+}
+
+// =============================================================================
+
+type lease struct {
+	Task      string    `db:"task"`
+	Tick      time.Time `db:"tick"`
+	Owner     string    `db:"owner"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+func toDBLease(bus scheduler.Lease) lease {
+	return lease{
+		Task:      bus.Task,
+		Tick:      bus.Tick.UTC(),
+		Owner:     bus.Owner,
+		ExpiresAt: bus.ExpiresAt.UTC(),
+	}
+}
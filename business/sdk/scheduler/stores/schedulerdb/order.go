@@ -0,0 +1,25 @@
+package schedulerdb
+
+import (
+	"fmt"
+
+	"github.com/rmsj/service/business/sdk/order"
+	"github.com/rmsj/service/business/sdk/scheduler"
+)
+
+var orderByFields = map[string]string{
+	scheduler.OrderByRunID:       "run_id",
+	scheduler.OrderByTask:        "task",
+	scheduler.OrderByStatus:      "status",
+	scheduler.OrderByScheduledAt: "scheduled_at",
+	scheduler.OrderByStartedAt:   "started_at",
+}
+
+func orderByClause(orderBy order.By) (string, error) {
+	by, exists := orderByFields[orderBy.Field]
+	if !exists {
+		return "", fmt.Errorf("field %q does not exist", orderBy.Field)
+	}
+
+	return " ORDER BY " + by + " " + orderBy.Direction, nil
+}
@@ -0,0 +1,193 @@
+// Package schedulerdb contains scheduler lease and run history related CRUD
+// functionality.
+package schedulerdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/rmsj/service/business/sdk/order"
+	"github.com/rmsj/service/business/sdk/page"
+	"github.com/rmsj/service/business/sdk/scheduler"
+	"github.com/rmsj/service/business/sdk/sqldb"
+	"github.com/rmsj/service/foundation/logger"
+)
+
+// Store manages the set of APIs for scheduler database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// AcquireLease takes the lease of a task for a tick. It succeeds when no
+// replica holds the lease for this or a later tick, and the lease of the
+// previous tick has expired or was released.
+func (s *Store) AcquireLease(ctx context.Context, l scheduler.Lease, now time.Time) (bool, error) {
+	dbLease := toDBLease(l)
+
+	data := map[string]any{
+		"task":       dbLease.Task,
+		"tick":       dbLease.Tick,
+		"owner":      dbLease.Owner,
+		"expires_at": dbLease.ExpiresAt,
+		"now":        now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		scheduler_leases
+	SET
+		tick = :tick,
+		owner = :owner,
+		expires_at = :expires_at
+	WHERE
+		task = :task AND
+		tick < :tick AND
+		expires_at <= :now`
+
+	count, err := sqldb.NamedExecContextWithCount(ctx, s.log, s.db, q, data)
+	if err != nil {
+		return false, fmt.Errorf("namedexeccontextwithcount: %w", err)
+	}
+
+	if count > 0 {
+		return true, nil
+	}
+
+	// There is no lease for the task yet, unless another replica holds it.
+	const ins = `
+	INSERT INTO scheduler_leases
+		(task, tick, owner, expires_at)
+	VALUES
+		(:task, :tick, :owner, :expires_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, ins, dbLease); err != nil {
+		if errors.Is(err, sqldb.ErrDBDuplicatedEntry) {
+			return false, nil
+		}
+		return false, fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return true, nil
+}
+
+// ReleaseLease ends the lease held by the owner for the tick.
+func (s *Store) ReleaseLease(ctx context.Context, l scheduler.Lease) error {
+	const q = `
+	UPDATE
+		scheduler_leases
+	SET
+		expires_at = :expires_at
+	WHERE
+		task = :task AND
+		tick = :tick AND
+		owner = :owner`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBLease(l)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// CreateRun adds a task run to the history.
+func (s *Store) CreateRun(ctx context.Context, r scheduler.Run) error {
+	const q = `
+	INSERT INTO scheduler_runs
+		(run_id, task, owner, status, error, scheduled_at, started_at, finished_at)
+	VALUES
+		(:run_id, :task, :owner, :status, :error, :scheduled_at, :started_at, :finished_at)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBRun(r)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateRun records the outcome of a task run.
+func (s *Store) UpdateRun(ctx context.Context, r scheduler.Run) error {
+	const q = `
+	UPDATE
+		scheduler_runs
+	SET
+		status = :status,
+		error = :error,
+		finished_at = :finished_at
+	WHERE
+		run_id = :run_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBRun(r)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryRuns retrieves a list of task runs from the database.
+func (s *Store) QueryRuns(ctx context.Context, filter scheduler.QueryFilter, orderBy order.By, page page.Page) ([]scheduler.Run, error) {
+	data := map[string]any{
+		"offset":        (page.Number() - 1) * page.RowsPerPage(),
+		"rows_per_page": page.RowsPerPage(),
+	}
+
+	const q = `
+	SELECT
+		run_id, task, owner, status, error, scheduled_at, started_at, finished_at
+	FROM
+		scheduler_runs`
+
+	buf := bytes.NewBufferString(q)
+	if err := applyFilter(filter, data, buf); err != nil {
+		return nil, err
+	}
+
+	orderByClause, err := orderByClause(orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" LIMIT :rows_per_page OFFSET :offset")
+
+	var dbRuns []run
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbRuns); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toBusRuns(dbRuns), nil
+}
+
+// CountRuns returns the total number of task runs in the DB.
+func (s *Store) CountRuns(ctx context.Context, filter scheduler.QueryFilter) (int, error) {
+	data := map[string]any{}
+
+	const q = "SELECT COUNT(run_id) AS `count` FROM scheduler_runs"
+
+	buf := bytes.NewBufferString(q)
+	if err := applyFilter(filter, data, buf); err != nil {
+		return 0, err
+	}
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("db: %w", err)
+	}
+
+	return count.Count, nil
+}
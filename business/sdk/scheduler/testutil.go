@@ -0,0 +1,38 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestSeedRuns is a helper method for testing. It records n succeeded runs
+// of the task, one minute apart.
+func TestSeedRuns(ctx context.Context, n int, task string, sch *Scheduler) ([]Run, error) {
+	now := time.Now().Truncate(time.Minute)
+
+	runs := make([]Run, n)
+	for i := range n {
+		tick := now.Add(-time.Duration(n-i) * time.Minute)
+
+		run := Run{
+			ID:          uuid.New(),
+			Task:        task,
+			Owner:       sch.cfg.Owner,
+			Status:      StatusSucceeded,
+			ScheduledAt: tick,
+			StartedAt:   tick,
+			FinishedAt:  tick.Add(time.Second),
+		}
+
+		if err := sch.cfg.Storer.CreateRun(ctx, run); err != nil {
+			return nil, fmt.Errorf("seeding run: idx: %d : %w", i, err)
+		}
+
+		runs[i] = run
+	}
+
+	return runs, nil
+}
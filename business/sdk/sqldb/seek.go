@@ -0,0 +1,43 @@
+package sqldb
+
+import (
+	"github.com/rmsj/service/business/sdk/order"
+	"github.com/rmsj/service/business/sdk/page"
+)
+
+// Seek returns the predicate and the ordering of a keyset query reading the
+// rows after the cursor, or before it when going backward. The column is the
+// one the rows are ordered by and the ID column breaks the ties between them.
+// The cursor values are added to the data as cursor_value and cursor_id.
+func Seek(cursor page.Cursor, column string, idColumn string, data map[string]any) (string, string) {
+	direction := cursor.OrderBy.Direction
+	if cursor.Backward {
+		direction = reverse(direction)
+	}
+
+	op := ">"
+	if direction == order.DESC {
+		op = "<"
+	}
+
+	data["cursor_id"] = cursor.ID
+
+	if column == idColumn {
+		return idColumn + " " + op + " :cursor_id", " ORDER BY " + idColumn + " " + direction
+	}
+
+	data["cursor_value"] = cursor.Value
+
+	where := "(" + column + " " + op + " :cursor_value OR (" + column + " = :cursor_value AND " + idColumn + " " + op + " :cursor_id))"
+	orderBy := " ORDER BY " + column + " " + direction + ", " + idColumn + " " + direction
+
+	return where, orderBy
+}
+
+func reverse(direction string) string {
+	if direction == order.DESC {
+		return order.ASC
+	}
+
+	return order.DESC
+}
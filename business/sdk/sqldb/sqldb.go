@@ -102,16 +102,28 @@ func ExecContext(ctx context.Context, log *logger.Logger, db sqlx.ExtContext, qu
 
 // NamedExecContext is a helper function to execute a CUD operation with
 // logging and tracing where field replacement is necessary.
-func NamedExecContext(ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, data any) (err error) {
+func NamedExecContext(ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, data any) error {
+	_, err := namedExecContext(ctx, log, db, query, data)
+	return err
+}
+
+// NamedExecContextWithCount is a helper function to execute a CUD operation with
+// logging and tracing where field replacement is necessary. It returns the
+// number of rows affected by the operation.
+func NamedExecContextWithCount(ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, data any) (int64, error) {
+	return namedExecContext(ctx, log, db, query, data)
+}
+
+func namedExecContext(ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, data any) (count int64, err error) {
 	q := queryString(query, data)
 
 	defer func() {
 		if err != nil {
 			switch data.(type) {
 			case struct{}:
-				log.Infoc(ctx, 6, "database.NamedExecContext", "query", q, "ERROR", err)
+				log.Infoc(ctx, 7, "database.NamedExecContext", "query", q, "ERROR", err)
 			default:
-				log.Infoc(ctx, 5, "database.NamedExecContext", "query", q, "ERROR", err)
+				log.Infoc(ctx, 6, "database.NamedExecContext", "query", q, "ERROR", err)
 			}
 		}
 	}()
@@ -119,18 +131,24 @@ func NamedExecContext(ctx context.Context, log *logger.Logger, db sqlx.ExtContex
 	ctx, span := otel.AddSpan(ctx, "business.sdk.sqldb.exec", attribute.String("query", q))
 	defer span.End()
 
-	if _, err := sqlx.NamedExecContext(ctx, db, query, data); err != nil {
+	res, err := sqlx.NamedExecContext(ctx, db, query, data)
+	if err != nil {
 		var mysqlErr *mysql.MySQLError
 		if errors.As(err, &mysqlErr) {
 			switch mysqlErr.Number {
 			case 1062:
-				return ErrDBDuplicatedEntry
+				return 0, ErrDBDuplicatedEntry
 			}
 		}
-		return err
+		return 0, err
 	}
 
-	return nil
+	count, err = res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
 }
 
 // QuerySlice is a helper function for executing queries that return a
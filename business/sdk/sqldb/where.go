@@ -0,0 +1,142 @@
+package sqldb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rmsj/service/business/sdk/filter"
+)
+
+// operators maps the filter operators with a value to their SQL.
+var operators = map[string]string{
+	filter.EQ:   "=",
+	filter.NE:   "<>",
+	filter.GT:   ">",
+	filter.GTE:  ">=",
+	filter.LT:   "<",
+	filter.LTE:  "<=",
+	filter.LIKE: "LIKE",
+}
+
+// Where builds the WHERE clause of a query from predicates joined by AND.
+// Values are never written into the SQL, they are bound as named parameters
+// in the data of the query, under generated names that don't collide with
+// the names a store uses.
+type Where struct {
+	data map[string]any
+	wc   []string
+	n    int
+}
+
+// NewWhere constructs a builder binding its values to the data.
+func NewWhere(data map[string]any) *Where {
+	return &Where{
+		data: data,
+	}
+}
+
+// Bind binds the value in the data and returns the parameter to use for it
+// in a predicate. Times are bound in UTC, as they are stored.
+func (w *Where) Bind(value any) string {
+	if t, ok := value.(time.Time); ok {
+		value = t.UTC()
+	}
+
+	w.n++
+	name := "w" + strconv.Itoa(w.n)
+	w.data[name] = value
+
+	return ":" + name
+}
+
+// Add adds predicates built by the caller, whose values must be bound.
+func (w *Where) Add(predicates ...string) {
+	w.wc = append(w.wc, predicates...)
+}
+
+// AddExpr adds the predicate of a filter expression. The columns map the
+// fields of the expression to the columns of the query, so only the fields
+// in it can be filtered on.
+func (w *Where) AddExpr(expr filter.Expr, columns map[string]string) error {
+	if expr.IsZero() {
+		return nil
+	}
+
+	if err := expr.Validate(); err != nil {
+		return err
+	}
+
+	predicate, err := w.predicate(expr, columns)
+	if err != nil {
+		return err
+	}
+
+	if predicate != "" {
+		w.wc = append(w.wc, predicate)
+	}
+
+	return nil
+}
+
+// String returns the WHERE clause, or nothing when there are no predicates.
+func (w *Where) String() string {
+	if len(w.wc) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(w.wc, " AND ")
+}
+
+func (w *Where) predicate(expr filter.Expr, columns map[string]string) (string, error) {
+	if expr.IsGroup() {
+		var ps []string
+
+		for _, e := range expr.Exprs {
+			p, err := w.predicate(e, columns)
+			if err != nil {
+				return "", err
+			}
+
+			if p != "" {
+				ps = append(ps, p)
+			}
+		}
+
+		switch len(ps) {
+		case 0:
+			return "", nil
+		case 1:
+			return ps[0], nil
+		}
+
+		return "(" + strings.Join(ps, " "+expr.Join+" ") + ")", nil
+	}
+
+	column, exists := columns[expr.Field]
+	if !exists {
+		return "", fmt.Errorf("field %q does not exist", expr.Field)
+	}
+
+	switch expr.Op {
+	case filter.IN:
+		params := make([]string, len(expr.Values))
+		for i, v := range expr.Values {
+			params[i] = w.Bind(v)
+		}
+
+		return column + " IN (" + strings.Join(params, ", ") + ")", nil
+
+	case filter.BETWEEN:
+		return column + " BETWEEN " + w.Bind(expr.Values[0]) + " AND " + w.Bind(expr.Values[1]), nil
+
+	case filter.NULL:
+		return column + " IS NULL", nil
+
+	case filter.NOTNULL:
+		return column + " IS NOT NULL", nil
+	}
+
+	return column + " " + operators[expr.Op] + " " + w.Bind(expr.Values[0]), nil
+}
@@ -4,14 +4,17 @@ import (
 	"context"
 
 	"github.com/rmsj/service/business/domain/authbus"
+	"github.com/rmsj/service/business/domain/orderbus"
 	"github.com/rmsj/service/business/domain/productbus"
 	"github.com/rmsj/service/business/domain/userbus"
+	"github.com/rmsj/service/business/domain/webhookbus"
 )
 
 // User represents an app user specified for the test.
 type User struct {
 	userbus.User
 	Products []productbus.Product
+	Orders   []orderbus.Order
 }
 
 // SeedData represents data that was seeded for the test.
@@ -19,6 +22,7 @@ type SeedData struct {
 	Users           []User
 	Admins          []User
 	PassResetTokens []authbus.PasswordResetToken
+	Subscriptions   []webhookbus.Subscription
 }
 
 // Table represent fields needed for running an unit test.
@@ -0,0 +1,97 @@
+package money
+
+import "fmt"
+
+// The set of currencies that can be used. The digits represent the number of
+// minor units defined by ISO-4217 for the currency.
+var (
+	AUD = newCurrency("AUD", 2)
+	BRL = newCurrency("BRL", 2)
+	CAD = newCurrency("CAD", 2)
+	CHF = newCurrency("CHF", 2)
+	EUR = newCurrency("EUR", 2)
+	GBP = newCurrency("GBP", 2)
+	JPY = newCurrency("JPY", 0)
+	KWD = newCurrency("KWD", 3)
+	NZD = newCurrency("NZD", 2)
+	USD = newCurrency("USD", 2)
+)
+
+// DefaultCurrency is used when a client doesn't specify a currency.
+var DefaultCurrency = USD
+
+// =============================================================================
+
+// Set of known currencies.
+var currencies = make(map[string]Currency)
+
+// Currency represents an ISO-4217 currency in the system.
+type Currency struct {
+	code   string
+	digits int
+}
+
+func newCurrency(code string, digits int) Currency {
+	c := Currency{code, digits}
+	currencies[code] = c
+	return c
+}
+
+// Code returns the ISO-4217 code of the currency.
+func (c Currency) Code() string {
+	return c.code
+}
+
+// Digits returns the number of minor units for the currency.
+func (c Currency) Digits() int {
+	return c.digits
+}
+
+// String returns the ISO-4217 code of the currency.
+func (c Currency) String() string {
+	return c.code
+}
+
+// UnmarshalText implement the unmarshal interface for JSON conversions.
+func (c *Currency) UnmarshalText(data []byte) error {
+	cur, err := ParseCurrency(string(data))
+	if err != nil {
+		return err
+	}
+
+	*c = cur
+	return nil
+}
+
+// MarshalText implement the marshal interface for JSON conversions.
+func (c Currency) MarshalText() ([]byte, error) {
+	return []byte(c.code), nil
+}
+
+// Equal provides support for the go-cmp package and testing.
+func (c Currency) Equal(c2 Currency) bool {
+	return c.code == c2.code
+}
+
+// =============================================================================
+
+// ParseCurrency parses the string value and returns a currency if one exists.
+func ParseCurrency(value string) (Currency, error) {
+	cur, exists := currencies[value]
+	if !exists {
+		return Currency{}, fmt.Errorf("invalid currency %q", value)
+	}
+
+	return cur, nil
+}
+
+// MustParseCurrency parses the string value and returns a currency if one
+// exists. If an error occurs the function panics.
+func MustParseCurrency(value string) Currency {
+	cur, err := ParseCurrency(value)
+	if err != nil {
+		panic(err)
+	}
+
+	return cur
+}
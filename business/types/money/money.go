@@ -2,53 +2,214 @@
 package money
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
 )
 
-// Money represents a money in the system.
+// ErrCurrencyMismatch is returned when an operation involves values in
+// different currencies.
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// maxUnits is the largest amount, in major units, a money value can be
+// parsed with.
+const maxUnits = 1_000_000
+
+// Money represents an exact money amount in the system. The amount is kept
+// in the minor units of the currency, so 10.34 USD is stored as 1034.
 type Money struct {
-	value float64
+	amount   int64
+	currency Currency
+}
+
+// Zero returns a money value with no amount in the specified currency.
+func Zero(currency Currency) Money {
+	return Money{currency: currency}
 }
 
-// Value returns the float value of the money.
-func (m Money) Value() float64 {
-	return m.value
+// Amount returns the amount in the minor units of the currency.
+func (m Money) Amount() int64 {
+	return m.amount
 }
 
-// String returns the value of the money.
+// Currency returns the currency of the money.
+func (m Money) Currency() Currency {
+	return m.currency
+}
+
+// String returns the amount of the money as a decimal string using the
+// number of minor units of the currency.
 func (m Money) String() string {
-	return fmt.Sprintf("%.2f", m.value)
+	digits := m.currency.digits
+	if digits == 0 {
+		return strconv.FormatInt(m.amount, 10)
+	}
+
+	s := fmt.Sprintf("%0*d", digits+1, m.amount)
+
+	return s[:len(s)-digits] + "." + s[len(s)-digits:]
 }
 
 // Equal provides support for the go-cmp package and testing.
 func (m Money) Equal(m2 Money) bool {
-	return m.value == m2.value
+	return m.amount == m2.amount && m.currency.Equal(m2.currency)
 }
 
 // MarshalText provides support for logging and any marshal needs.
 func (m Money) MarshalText() ([]byte, error) {
-	return []byte(m.String()), nil
+	return []byte(m.String() + " " + m.currency.code), nil
+}
+
+// Add returns the sum of both money values. Both values must be in the same
+// currency.
+func (m Money) Add(m2 Money) (Money, error) {
+	if !m.currency.Equal(m2.currency) {
+		return Money{}, fmt.Errorf("add %s to %s: %w", m2.currency, m.currency, ErrCurrencyMismatch)
+	}
+
+	if m2.amount > math.MaxInt64-m.amount {
+		return Money{}, errors.New("add: amount overflow")
+	}
+
+	return Money{m.amount + m2.amount, m.currency}, nil
+}
+
+// Mul returns the money multiplied by the specified quantity.
+func (m Money) Mul(qty int) (Money, error) {
+	if qty < 0 {
+		return Money{}, fmt.Errorf("mul: invalid quantity %d", qty)
+	}
+
+	if qty != 0 && m.amount > math.MaxInt64/int64(qty) {
+		return Money{}, errors.New("mul: amount overflow")
+	}
+
+	return Money{m.amount * int64(qty), m.currency}, nil
+}
+
+// Allocate splits the money according to the specified ratios without losing
+// any minor unit. Each share is rounded down and the remaining minor units are
+// handed out one at a time to the shares in order, so the shares always add
+// up to the original amount.
+func (m Money) Allocate(ratios ...int) ([]Money, error) {
+	if len(ratios) == 0 {
+		return nil, errors.New("allocate: no ratios provided")
+	}
+
+	var total int64
+	for _, r := range ratios {
+		if r < 0 {
+			return nil, fmt.Errorf("allocate: invalid ratio %d", r)
+		}
+		total += int64(r)
+	}
+
+	if total == 0 {
+		return nil, errors.New("allocate: ratios add up to zero")
+	}
+
+	amount := big.NewInt(m.amount)
+	bigTotal := big.NewInt(total)
+
+	shares := make([]Money, len(ratios))
+	remainder := m.amount
+
+	for i, r := range ratios {
+		share := new(big.Int).Mul(amount, big.NewInt(int64(r)))
+		share.Quo(share, bigTotal)
+
+		shares[i] = Money{share.Int64(), m.currency}
+		remainder -= share.Int64()
+	}
+
+	for i := 0; remainder > 0; i++ {
+		if ratios[i%len(ratios)] == 0 {
+			continue
+		}
+		shares[i%len(ratios)].amount++
+		remainder--
+	}
+
+	return shares, nil
 }
 
 // =============================================================================
 
-// Parse parses the float value and returns a money if the value complies
-// with the rules for money.
-func Parse(value float64) (Money, error) {
-	if value < 0 || value > 1_000_000 {
-		return Money{}, fmt.Errorf("invalid money %.2f", value)
+// Parse parses the decimal amount and currency code and returns a money if
+// the values comply with the rules for money. The amount can't have more
+// significant decimal places than the currency allows, no rounding is done.
+func Parse(amount string, currency string) (Money, error) {
+	cur, err := ParseCurrency(currency)
+	if err != nil {
+		return Money{}, err
 	}
 
-	return Money{value}, nil
+	minor, err := parseAmount(amount, cur.digits)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid money %q: %w", amount, err)
+	}
+
+	if minor > maxUnits*pow10(cur.digits) {
+		return Money{}, fmt.Errorf("invalid money %q", amount)
+	}
+
+	return Money{minor, cur}, nil
 }
 
-// MustParse parses the string value and returns a money if the value
-// complies with the rules for a money. If an error occurs the function panics.
-func MustParse(value float64) Money {
-	money, err := Parse(value)
+// MustParse parses the decimal amount and currency code and returns a money
+// if the values comply with the rules for money. If an error occurs the
+// function panics.
+func MustParse(amount string, currency string) Money {
+	money, err := Parse(amount, currency)
 	if err != nil {
 		panic(err)
 	}
 
 	return money
 }
+
+// parseAmount converts a non-negative decimal string into minor units.
+func parseAmount(amount string, digits int) (int64, error) {
+	whole, frac, _ := strings.Cut(amount, ".")
+	if whole == "" || !isDigits(whole) || !isDigits(frac) {
+		return 0, errors.New("not a decimal number")
+	}
+
+	if len(frac) > digits {
+		if strings.Trim(frac[digits:], "0") != "" {
+			return 0, errors.New("too many decimal places")
+		}
+		frac = frac[:digits]
+	}
+
+	frac += strings.Repeat("0", digits-len(frac))
+
+	minor, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return minor, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func pow10(n int) int64 {
+	v := int64(1)
+	for range n {
+		v *= 10
+	}
+
+	return v
+}
@@ -0,0 +1,111 @@
+package money_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rmsj/service/business/types/money"
+)
+
+func Test_Parse(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		exp      string
+		fail     bool
+	}{
+		{amount: "10.34", currency: "USD", exp: "10.34"},
+		{amount: "10.3", currency: "USD", exp: "10.30"},
+		{amount: "10", currency: "USD", exp: "10.00"},
+		{amount: "10.3400", currency: "USD", exp: "10.34"},
+		{amount: "0.05", currency: "USD", exp: "0.05"},
+		{amount: "1000", currency: "JPY", exp: "1000"},
+		{amount: "1.234", currency: "KWD", exp: "1.234"},
+		{amount: "10.345", currency: "USD", fail: true},
+		{amount: "-1", currency: "USD", fail: true},
+		{amount: ".5", currency: "USD", fail: true},
+		{amount: "1e3", currency: "USD", fail: true},
+		{amount: "1000000.01", currency: "USD", fail: true},
+		{amount: "10", currency: "XXX", fail: true},
+	}
+
+	for _, tt := range tests {
+		m, err := money.Parse(tt.amount, tt.currency)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("Parse(%q, %q): expected an error, got %s", tt.amount, tt.currency, m)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Parse(%q, %q): %s", tt.amount, tt.currency, err)
+			continue
+		}
+
+		if m.String() != tt.exp {
+			t.Errorf("Parse(%q, %q): got %s, exp %s", tt.amount, tt.currency, m, tt.exp)
+		}
+	}
+}
+
+func Test_Arithmetic(t *testing.T) {
+	a := money.MustParse("0.10", "USD")
+	b := money.MustParse("0.20", "USD")
+
+	sum, err := a.Add(b)
+	if err != nil {
+		t.Fatalf("Add: %s", err)
+	}
+
+	if exp := money.MustParse("0.30", "USD"); !sum.Equal(exp) {
+		t.Fatalf("Add: got %s, exp %s", sum, exp)
+	}
+
+	if _, err := a.Add(money.MustParse("0.10", "EUR")); !errors.Is(err, money.ErrCurrencyMismatch) {
+		t.Fatalf("Add: expected currency mismatch, got %v", err)
+	}
+
+	prod, err := money.MustParse("19.99", "USD").Mul(3)
+	if err != nil {
+		t.Fatalf("Mul: %s", err)
+	}
+
+	if exp := money.MustParse("59.97", "USD"); !prod.Equal(exp) {
+		t.Fatalf("Mul: got %s, exp %s", prod, exp)
+	}
+}
+
+func Test_Allocate(t *testing.T) {
+	m := money.MustParse("0.05", "USD")
+
+	shares, err := m.Allocate(3, 7)
+	if err != nil {
+		t.Fatalf("Allocate: %s", err)
+	}
+
+	exp := []string{"0.02", "0.03"}
+	for i, share := range shares {
+		if share.String() != exp[i] {
+			t.Errorf("Allocate: share %d: got %s, exp %s", i, share, exp[i])
+		}
+	}
+
+	shares, err = money.MustParse("100.00", "USD").Allocate(1, 1, 1)
+	if err != nil {
+		t.Fatalf("Allocate: %s", err)
+	}
+
+	total := money.Zero(money.USD)
+	for _, share := range shares {
+		total, _ = total.Add(share)
+	}
+
+	if exp := money.MustParse("100.00", "USD"); !total.Equal(exp) {
+		t.Fatalf("Allocate: shares add up to %s, exp %s", total, exp)
+	}
+
+	if shares[0].String() != "33.34" {
+		t.Fatalf("Allocate: first share got %s, exp 33.34", shares[0])
+	}
+}
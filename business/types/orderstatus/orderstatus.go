@@ -0,0 +1,97 @@
+// Package orderstatus represents the order status type in the system.
+package orderstatus
+
+import (
+	"fmt"
+	"slices"
+)
+
+// The set of statuses that can be used.
+var (
+	Draft     = newStatus("draft")
+	Placed    = newStatus("placed")
+	Paid      = newStatus("paid")
+	Shipped   = newStatus("shipped")
+	Cancelled = newStatus("cancelled")
+)
+
+// transitions defines the state machine for an order. The key is the current
+// status and the value is the set of statuses the order can move to.
+var transitions = map[Status][]Status{
+	Draft:     {Placed, Cancelled},
+	Placed:    {Paid, Cancelled},
+	Paid:      {Shipped, Cancelled},
+	Shipped:   {},
+	Cancelled: {},
+}
+
+// =============================================================================
+
+// Set of known statuses.
+var statuses = make(map[string]Status)
+
+// Status represents an order status in the system.
+type Status struct {
+	value string
+}
+
+func newStatus(status string) Status {
+	s := Status{status}
+	statuses[status] = s
+	return s
+}
+
+// String returns the name of the status.
+func (s Status) String() string {
+	return s.value
+}
+
+// UnmarshalText implement the unmarshal interface for JSON conversions.
+func (s *Status) UnmarshalText(data []byte) error {
+	status, err := Parse(string(data))
+	if err != nil {
+		return err
+	}
+
+	s.value = status.value
+	return nil
+}
+
+// MarshalText implement the marshal interface for JSON conversions.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.value), nil
+}
+
+// Equal provides support for the go-cmp package and testing.
+func (s Status) Equal(s2 Status) bool {
+	return s.value == s2.value
+}
+
+// CanTransition reports whether an order in this status is allowed to move
+// to the specified status.
+func (s Status) CanTransition(to Status) bool {
+	return slices.Contains(transitions[s], to)
+}
+
+// =============================================================================
+
+// Parse parses the string value and returns a status if one exists.
+func Parse(value string) (Status, error) {
+	status, exists := statuses[value]
+	if !exists {
+		return Status{}, fmt.Errorf("invalid order status %q", value)
+	}
+
+	return status, nil
+}
+
+// MustParse parses the string value and returns a status if one exists. If
+// an error occurs the function panics.
+func MustParse(value string) Status {
+	status, err := Parse(value)
+	if err != nil {
+		panic(err)
+	}
+
+	return status
+}
@@ -1,9 +1,21 @@
 // Package keystore implements the auth.KeyLookup interface. This implements
 // an in-memory keystore for JWT support.
+//
+// Every key can carry metadata that drives rotation. A key signs tokens once
+// its not-before time is reached and until its retire-after time. Retired keys
+// keep verifying tokens for a grace period, so tokens signed just before the
+// rotation stay valid until they expire. Metadata for a PEM file is read from
+// a JSON file with the same name next to it.
+// Example: /zarf/keys/54bb2165-71e1-41a6-af3e-7da4a0e1e2c1.pem
+// Example: /zarf/keys/54bb2165-71e1-41a6-af3e-7da4a0e1e2c1.json
 package keystore
 
 import (
 	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/json"
@@ -13,30 +25,130 @@ import (
 	"io"
 	"io/fs"
 	"path"
+	"sort"
 	"strings"
+	"sync"
+	"time"
+)
+
+// Set of possible status for a key.
+const (
+	StatusActive  = "active"
+	StatusRetired = "retired"
+	StatusRevoked = "revoked"
 )
 
+// Metadata represents the rotation information of a key.
+type Metadata struct {
+	NotBefore   time.Time `json:"notBefore,omitzero"`
+	RetireAfter time.Time `json:"retireAfter,omitzero"`
+	Status      string    `json:"status,omitempty"`
+}
+
+// Validate checks the metadata is consistent.
+func (md Metadata) Validate() error {
+	switch md.Status {
+	case "", StatusActive, StatusRetired, StatusRevoked:
+	default:
+		return fmt.Errorf("unknown status %q", md.Status)
+	}
+
+	if !md.NotBefore.IsZero() && !md.RetireAfter.IsZero() && !md.RetireAfter.After(md.NotBefore) {
+		return errors.New("retire after must be after not before")
+	}
+
+	return nil
+}
+
+// CanSign reports if a key with this metadata can sign tokens at the
+// specified time.
+func (md Metadata) CanSign(now time.Time) bool {
+	if md.Status != "" && md.Status != StatusActive {
+		return false
+	}
+
+	if now.Before(md.NotBefore) {
+		return false
+	}
+
+	if !md.RetireAfter.IsZero() && !now.Before(md.RetireAfter) {
+		return false
+	}
+
+	return true
+}
+
+// CanVerify reports if a key with this metadata can verify tokens at the
+// specified time, given the grace period retired keys get. Keys that can't
+// sign yet verify already, so they can be published ahead of the rotation.
+// A retired key without a retire-after time has no grace period to run from,
+// so it no longer verifies.
+func (md Metadata) CanVerify(now time.Time, grace time.Duration) bool {
+	switch {
+	case md.Status == StatusRevoked:
+		return false
+
+	case md.Status == StatusRetired && md.RetireAfter.IsZero():
+		return false
+	}
+
+	if !md.RetireAfter.IsZero() && !now.Before(md.RetireAfter.Add(grace)) {
+		return false
+	}
+
+	return true
+}
+
+// =============================================================================
+
 // key represents key information.
 type key struct {
 	privatePEM string
 	publicPEM  string
+	metadata   Metadata
+	fromFS     bool
 }
 
 // KeyStore represents an in memory store implementation of the
 // KeyLookup interface for use with the auth package.
 type KeyStore struct {
+	mu    sync.RWMutex
 	store map[string]key
+	grace time.Duration
+	now   func() time.Time
 }
 
 // New constructs an empty KeyStore ready for use.
-func New() *KeyStore {
-	return &KeyStore{
+func New(options ...func(ks *KeyStore)) *KeyStore {
+	ks := KeyStore{
 		store: make(map[string]key),
+		now:   time.Now,
+	}
+
+	for _, option := range options {
+		option(&ks)
+	}
+
+	return &ks
+}
+
+// WithGracePeriod sets how long retired keys keep verifying tokens after their
+// retire-after time. It should be at least the lifetime of a token.
+func WithGracePeriod(grace time.Duration) func(ks *KeyStore) {
+	return func(ks *KeyStore) {
+		ks.grace = grace
+	}
+}
+
+// WithClock sets the function used to get the current time.
+func WithClock(now func() time.Time) func(ks *KeyStore) {
+	return func(ks *KeyStore) {
+		ks.now = now
 	}
 }
 
 // LoadByJSON is given a JSON document read with two fields, key and pem
-// (private key).
+// (private key), and optionally the metadata fields of the key.
 func (ks *KeyStore) LoadByJSON(document string) (int, error) {
 	if document == "" {
 		return 0, nil
@@ -45,9 +157,14 @@ func (ks *KeyStore) LoadByJSON(document string) (int, error) {
 	var d struct {
 		Key string `json:"key"`
 		PEM string `json:"pem"`
+		Metadata
 	}
 	if err := json.Unmarshal([]byte(document), &d); err != nil {
-		return len(ks.store), fmt.Errorf("unable to marshal document: %w", err)
+		return ks.count(), fmt.Errorf("unable to marshal document: %w", err)
+	}
+
+	if err := d.Metadata.Validate(); err != nil {
+		return 0, fmt.Errorf("validating metadata: %w", err)
 	}
 
 	publicPEM, err := toPublicPEM(d.PEM)
@@ -58,19 +175,27 @@ func (ks *KeyStore) LoadByJSON(document string) (int, error) {
 	key := key{
 		privatePEM: d.PEM,
 		publicPEM:  publicPEM,
+		metadata:   d.Metadata,
 	}
 
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
 	ks.store[d.Key] = key
 
 	return len(ks.store), nil
 }
 
-// LoadByFileSystem loads a set of RSA PEM files rooted inside of a directory. The
+// LoadByFileSystem loads a set of RSA, ECDSA or Ed25519 PEM files rooted inside of a directory. The
 // name of each PEM file will be used as the key id. The function also returns
-// the total number of keys in the store.
+// the total number of keys in the store. Calling it again reloads the
+// directory, replacing every key previously loaded from it, so keys that were
+// removed from the directory are dropped. On error, the store is left as is.
 // Example: ks.LoadRSAKeys(os.DirFS("/zarf/keys/"))
 // Example: /zarf/keys/54bb2165-71e1-41a6-af3e-7da4a0e1e2c1.pem
 func (ks *KeyStore) LoadByFileSystem(fsys fs.FS) (int, error) {
+	keys := make(map[string]key)
+
 	fn := func(fileName string, dirEntry fs.DirEntry, err error) error {
 		if err != nil {
 			return fmt.Errorf("walkdir failure: %w", err)
@@ -84,32 +209,29 @@ func (ks *KeyStore) LoadByFileSystem(fsys fs.FS) (int, error) {
 			return nil
 		}
 
-		file, err := fsys.Open(fileName)
-		if err != nil {
-			return fmt.Errorf("opening key file: %w", err)
-		}
-		defer file.Close()
-
-		// limit PEM file size to 1 megabyte. This should be reasonable for
-		// almost any PEM file and prevents shenanigans like linking the file
-		// to /dev/random or something like that.
-		pem, err := io.ReadAll(io.LimitReader(file, 1024*1024))
+		privatePEM, err := readFile(fsys, fileName)
 		if err != nil {
 			return fmt.Errorf("reading auth private key: %w", err)
 		}
 
-		privatePEM := string(pem)
 		publicPEM, err := toPublicPEM(privatePEM)
 		if err != nil {
 			return fmt.Errorf("converting private PEM to public: %w", err)
 		}
 
+		metadata, err := readMetadata(fsys, strings.TrimSuffix(fileName, ".pem")+".json")
+		if err != nil {
+			return fmt.Errorf("reading metadata of %s: %w", fileName, err)
+		}
+
 		key := key{
 			privatePEM: privatePEM,
 			publicPEM:  publicPEM,
+			metadata:   metadata,
+			fromFS:     true,
 		}
 
-		ks.store[strings.TrimSuffix(dirEntry.Name(), ".pem")] = key
+		keys[strings.TrimSuffix(dirEntry.Name(), ".pem")] = key
 
 		return nil
 	}
@@ -118,33 +240,194 @@ func (ks *KeyStore) LoadByFileSystem(fsys fs.FS) (int, error) {
 		return 0, fmt.Errorf("walking directory: %w", err)
 	}
 
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	for kid, key := range ks.store {
+		if _, exists := keys[kid]; !exists && !key.fromFS {
+			keys[kid] = key
+		}
+	}
+
+	ks.store = keys
+
 	return len(ks.store), nil
 }
 
-// PrivateKey searches the key store for a given kid and returns the private key.
+// Reload reloads the keys from the file system on the specified interval until
+// the context is cancelled. Errors are reported to the error function and the
+// previous keys are kept.
+func (ks *KeyStore) Reload(ctx context.Context, fsys fs.FS, interval time.Duration, errFn func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if _, err := ks.LoadByFileSystem(fsys); err != nil && errFn != nil {
+				errFn(err)
+			}
+		}
+	}
+}
+
+// PrivateKey searches the key store for a given kid and returns the private
+// key. Only keys that can sign at this time are returned.
 func (ks *KeyStore) PrivateKey(kid string) (string, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
 	key, found := ks.store[kid]
 	if !found {
 		return "", errors.New("kid lookup failed")
 	}
 
+	if !key.metadata.CanSign(ks.now()) {
+		return "", errors.New("kid can't be used for signing")
+	}
+
 	return key.privatePEM, nil
 }
 
 // PublicKey searches the key store for a given kid and returns the public key.
+// Only keys that can verify at this time are returned.
 func (ks *KeyStore) PublicKey(kid string) (string, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
 	key, found := ks.store[kid]
 	if !found {
 		return "", errors.New("kid lookup failed")
 	}
 
+	if !key.metadata.CanVerify(ks.now(), ks.grace) {
+		return "", errors.New("kid can't be used for verification")
+	}
+
 	return key.publicPEM, nil
 }
 
+// PublicKeys returns the public key of every key in the store that can verify
+// at this time, indexed by kid.
+func (ks *KeyStore) PublicKeys() map[string]string {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	now := ks.now()
+
+	keys := make(map[string]string, len(ks.store))
+	for kid, key := range ks.store {
+		if key.metadata.CanVerify(now, ks.grace) {
+			keys[kid] = key.publicPEM
+		}
+	}
+
+	return keys
+}
+
+// ActiveKID returns the kid that should be used to sign new tokens. Of the
+// keys that can sign at this time, the one that became valid last wins.
+func (ks *KeyStore) ActiveKID() (string, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	now := ks.now()
+
+	kids := make([]string, 0, len(ks.store))
+	for kid, key := range ks.store {
+		if key.metadata.CanSign(now) {
+			kids = append(kids, kid)
+		}
+	}
+
+	if len(kids) == 0 {
+		return "", errors.New("no key can be used for signing")
+	}
+
+	sort.Slice(kids, func(i, j int) bool {
+		nbi := ks.store[kids[i]].metadata.NotBefore
+		nbj := ks.store[kids[j]].metadata.NotBefore
+		if !nbi.Equal(nbj) {
+			return nbi.After(nbj)
+		}
+		return kids[i] < kids[j]
+	})
+
+	return kids[0], nil
+}
+
+// Metadata returns the metadata of the specified kid.
+func (ks *KeyStore) Metadata(kid string) (Metadata, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	key, found := ks.store[kid]
+	if !found {
+		return Metadata{}, errors.New("kid lookup failed")
+	}
+
+	return key.metadata, nil
+}
+
+func (ks *KeyStore) count() int {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	return len(ks.store)
+}
+
+// =============================================================================
+
+func readFile(fsys fs.FS, fileName string) (string, error) {
+	file, err := fsys.Open(fileName)
+	if err != nil {
+		return "", fmt.Errorf("opening file: %w", err)
+	}
+	defer file.Close()
+
+	// limit file size to 1 megabyte. This should be reasonable for
+	// almost any PEM file and prevents shenanigans like linking the file
+	// to /dev/random or something like that.
+	data, err := io.ReadAll(io.LimitReader(file, 1024*1024))
+	if err != nil {
+		return "", fmt.Errorf("reading file: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// readMetadata reads the metadata file of a key. Keys without a metadata file
+// are active with no time restrictions.
+func readMetadata(fsys fs.FS, fileName string) (Metadata, error) {
+	data, err := readFile(fsys, fileName)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Metadata{}, nil
+		}
+		return Metadata{}, err
+	}
+
+	var md Metadata
+	if err := json.Unmarshal([]byte(data), &md); err != nil {
+		return Metadata{}, fmt.Errorf("decoding: %w", err)
+	}
+
+	if err := md.Validate(); err != nil {
+		return Metadata{}, fmt.Errorf("validating: %w", err)
+	}
+
+	return md, nil
+}
+
+// toPublicPEM derives the PEM encoded public key of a PEM encoded RSA, ECDSA
+// or Ed25519 private key.
 func toPublicPEM(privatePEM string) (string, error) {
 	block, _ := pem.Decode([]byte(privatePEM))
 	if block == nil {
-		return "", errors.New("invalid key: Key must be a PEM encoded PKCS1 or PKCS8 key")
+		return "", errors.New("invalid key: Key must be a PEM encoded PKCS1, PKCS8 or SEC1 key")
 	}
 
 	var parsedKey any
@@ -152,16 +435,32 @@ func toPublicPEM(privatePEM string) (string, error) {
 	if err != nil {
 		parsedKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
 		if err != nil {
-			return "", err
+			parsedKey, err = x509.ParseECPrivateKey(block.Bytes)
+			if err != nil {
+				return "", errors.New("invalid key: Key must be a PKCS1, PKCS8 or SEC1 key")
+			}
 		}
 	}
 
-	pk, ok := parsedKey.(*rsa.PrivateKey)
-	if !ok {
-		return "", errors.New("key is not a valid RSA private key")
+	var publicKey any
+	switch pk := parsedKey.(type) {
+	case *rsa.PrivateKey:
+		publicKey = &pk.PublicKey
+
+	case *ecdsa.PrivateKey:
+		if pk.Curve != elliptic.P256() {
+			return "", fmt.Errorf("unsupported curve %s, only P-256 is supported", pk.Curve.Params().Name)
+		}
+		publicKey = &pk.PublicKey
+
+	case ed25519.PrivateKey:
+		publicKey = pk.Public()
+
+	default:
+		return "", fmt.Errorf("unsupported key type %T", parsedKey)
 	}
 
-	asn1Bytes, err := x509.MarshalPKIXPublicKey(&pk.PublicKey)
+	asn1Bytes, err := x509.MarshalPKIXPublicKey(publicKey)
 	if err != nil {
 		return "", fmt.Errorf("marshaling public key: %w", err)
 	}
@@ -0,0 +1,152 @@
+package keystore_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/rmsj/service/foundation/keystore"
+)
+
+func Test_Rotation(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	fsys := fstest.MapFS{
+		"old.pem":   {Data: newPrivatePEM(t)},
+		"old.json":  {Data: newMetadata(t, keystore.Metadata{RetireAfter: now.Add(time.Hour)})},
+		"new.pem":   {Data: newPrivatePEM(t)},
+		"new.json":  {Data: newMetadata(t, keystore.Metadata{NotBefore: now.Add(time.Hour), Status: keystore.StatusActive})},
+		"bad.pem":   {Data: newPrivatePEM(t)},
+		"bad.json":  {Data: newMetadata(t, keystore.Metadata{Status: keystore.StatusRevoked})},
+		"dead.pem":  {Data: newPrivatePEM(t)},
+		"dead.json": {Data: newMetadata(t, keystore.Metadata{Status: keystore.StatusRetired})},
+	}
+
+	clock := now
+	ks := keystore.New(keystore.WithGracePeriod(8*time.Hour), keystore.WithClock(func() time.Time { return clock }))
+
+	n, err := ks.LoadByFileSystem(fsys)
+	if err != nil {
+		t.Fatalf("Should be able to load the keys: %s", err)
+	}
+
+	if n != 4 {
+		t.Fatalf("Should have loaded 4 keys, got %d", n)
+	}
+
+	type check struct {
+		name     string
+		at       time.Time
+		active   string
+		sign     map[string]bool
+		verify   map[string]bool
+		jwksKids int
+	}
+
+	checks := []check{
+		{
+			name:     "before",
+			at:       now,
+			active:   "old",
+			sign:     map[string]bool{"old": true, "new": false, "bad": false, "dead": false},
+			verify:   map[string]bool{"old": true, "new": true, "bad": false, "dead": false},
+			jwksKids: 2,
+		},
+		{
+			name:     "rotated",
+			at:       now.Add(time.Hour),
+			active:   "new",
+			sign:     map[string]bool{"old": false, "new": true},
+			verify:   map[string]bool{"old": true, "new": true},
+			jwksKids: 2,
+		},
+		{
+			name:     "grace over",
+			at:       now.Add(9 * time.Hour),
+			active:   "new",
+			sign:     map[string]bool{"old": false, "new": true},
+			verify:   map[string]bool{"old": false, "new": true},
+			jwksKids: 1,
+		},
+	}
+
+	for _, c := range checks {
+		clock = c.at
+
+		kid, err := ks.ActiveKID()
+		if err != nil {
+			t.Fatalf("%s: Should be able to get the active kid: %s", c.name, err)
+		}
+
+		if kid != c.active {
+			t.Errorf("%s: Should get the active kid: got %s, exp %s", c.name, kid, c.active)
+		}
+
+		for kid, exp := range c.sign {
+			if _, err := ks.PrivateKey(kid); (err == nil) != exp {
+				t.Errorf("%s: kid[%s] signing: got %v, exp %v", c.name, kid, err == nil, exp)
+			}
+		}
+
+		for kid, exp := range c.verify {
+			if _, err := ks.PublicKey(kid); (err == nil) != exp {
+				t.Errorf("%s: kid[%s] verifying: got %v, exp %v", c.name, kid, err == nil, exp)
+			}
+		}
+
+		if got := len(ks.PublicKeys()); got != c.jwksKids {
+			t.Errorf("%s: Should publish %d keys, got %d", c.name, c.jwksKids, got)
+		}
+	}
+
+	// -------------------------------------------------------------------------
+	// Reloading drops the keys removed from the folder and keeps the store as
+	// is when the folder holds a broken key.
+
+	delete(fsys, "old.pem")
+	delete(fsys, "old.json")
+
+	if n, err := ks.LoadByFileSystem(fsys); err != nil || n != 3 {
+		t.Fatalf("Should be able to reload the keys: n[%d] err[%v]", n, err)
+	}
+
+	if _, err := ks.PublicKey("old"); err == nil {
+		t.Errorf("Should NOT find the removed key")
+	}
+
+	fsys["broken.pem"] = &fstest.MapFile{Data: []byte("not a key")}
+
+	if _, err := ks.LoadByFileSystem(fsys); err == nil {
+		t.Fatal("Should NOT be able to load a broken key")
+	}
+
+	if _, err := ks.PrivateKey("new"); err != nil {
+		t.Errorf("Should keep the keys after a failed reload: %s", err)
+	}
+}
+
+func newPrivatePEM(t *testing.T) []byte {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %s", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	})
+}
+
+func newMetadata(t *testing.T, md keystore.Metadata) []byte {
+	data, err := json.Marshal(md)
+	if err != nil {
+		t.Fatalf("encoding metadata: %s", err)
+	}
+
+	return data
+}
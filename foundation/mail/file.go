@@ -0,0 +1,45 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// File writes every message as an .eml file into a directory, which can be
+// opened with any mail client. It's meant for development.
+type File struct {
+	dir string
+}
+
+// NewFile constructs a sender that writes the messages into the directory,
+// creating it if needed.
+func NewFile(dir string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create dir: %w", err)
+	}
+
+	return &File{
+		dir: dir,
+	}, nil
+}
+
+// Send implements the Sender interface.
+func (f *File) Send(ctx context.Context, msg Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405"), uuid.NewString())
+
+	if err := os.WriteFile(filepath.Join(f.dir, name), data, 0o644); err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package mail
+
+import (
+	"context"
+
+	"github.com/rmsj/service/foundation/logger"
+)
+
+// Log writes who every message is for to the log instead of sending it. It's
+// meant for development. The body is left out, since messages carry links that
+// work as credentials, such as password resets; the File sender keeps them.
+type Log struct {
+	log *logger.Logger
+}
+
+// NewLog constructs a sender that logs the messages.
+func NewLog(log *logger.Logger) *Log {
+	return &Log{
+		log: log,
+	}
+}
+
+// Send implements the Sender interface.
+func (l *Log) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return ErrNoRecipients
+	}
+
+	l.log.Info(ctx, "mail", "from", msg.From.String(), "to", msg.To, "subject", msg.Subject)
+
+	return nil
+}
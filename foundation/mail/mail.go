@@ -0,0 +1,27 @@
+// Package mail provides support for sending email messages through pluggable
+// backends.
+package mail
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+)
+
+// ErrNoRecipients is returned when a message has no recipients.
+var ErrNoRecipients = errors.New("message has no recipients")
+
+// Message represents an email message. A message can carry a plain text
+// body, an HTML body or both, in which case they are sent as alternatives.
+type Message struct {
+	From    mail.Address
+	To      []mail.Address
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender represents the behavior required to send a message.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
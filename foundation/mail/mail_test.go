@@ -0,0 +1,117 @@
+package mail_test
+
+import (
+	"context"
+	"errors"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/rmsj/service/foundation/mail"
+)
+
+func Test_Templates(t *testing.T) {
+	fsys := fstest.MapFS{
+		"welcome.subject.tmpl": {Data: []byte("Welcome {{.Name}}\n")},
+		"welcome.txt.tmpl":     {Data: []byte("Hi {{.Name}}, visit {{.Link}}")},
+		"welcome.html.tmpl":    {Data: []byte(`<p>Hi {{.Name}}, visit <a href="{{.Link}}">here</a></p>`)},
+	}
+
+	tmpls, err := mail.ParseTemplates(fsys)
+	if err != nil {
+		t.Fatalf("Should be able to parse the templates : %s", err)
+	}
+
+	data := struct {
+		Name string
+		Link string
+	}{
+		Name: "<Bill>",
+		Link: "http://example.com/?a=1&b=2",
+	}
+
+	c, err := tmpls.Render("welcome", data)
+	if err != nil {
+		t.Fatalf("Should be able to render the template : %s", err)
+	}
+
+	if c.Subject != "Welcome <Bill>" {
+		t.Errorf("Should render the subject : got %q", c.Subject)
+	}
+
+	if c.Text != "Hi <Bill>, visit http://example.com/?a=1&b=2" {
+		t.Errorf("Should render the text body : got %q", c.Text)
+	}
+
+	if !strings.Contains(c.HTML, "Hi &lt;Bill&gt;") {
+		t.Errorf("Should escape the html body : got %q", c.HTML)
+	}
+
+	if _, err := tmpls.Render("unknown", data); !errors.Is(err, mail.ErrUnknownTemplate) {
+		t.Errorf("Should not render an unknown template : got %v", err)
+	}
+
+	if _, err := mail.ParseTemplates(fstest.MapFS{"orphan.subject.tmpl": {Data: []byte("Orphan")}}); err == nil {
+		t.Errorf("Should not parse a template without a body")
+	}
+}
+
+func Test_Senders(t *testing.T) {
+	msg := mail.Message{
+		From:    netmail.Address{Name: "Service", Address: "no-reply@example.com"},
+		To:      []netmail.Address{{Name: "Bill", Address: "bill@example.com"}},
+		Subject: "Hello",
+		Text:    "Hello Bill",
+		HTML:    "<p>Hello Bill</p>",
+	}
+
+	data, err := msg.Bytes()
+	if err != nil {
+		t.Fatalf("Should be able to render the message : %s", err)
+	}
+
+	for _, exp := range []string{"To: \"Bill\" <bill@example.com>", "multipart/alternative", "text/plain", "text/html", "Hello Bill"} {
+		if !strings.Contains(string(data), exp) {
+			t.Errorf("Should render %q in the message", exp)
+		}
+	}
+
+	ctx := context.Background()
+
+	mem := mail.NewMemory()
+
+	if err := mem.Send(ctx, mail.Message{}); !errors.Is(err, mail.ErrNoRecipients) {
+		t.Errorf("Should not send a message without recipients : got %v", err)
+	}
+
+	if err := mem.Send(ctx, msg); err != nil {
+		t.Fatalf("Should be able to send the message : %s", err)
+	}
+
+	if msgs := mem.Messages(); len(msgs) != 1 || msgs[0].Subject != "Hello" {
+		t.Errorf("Should keep the message : got %+v", msgs)
+	}
+
+	dir := t.TempDir()
+
+	file, err := mail.NewFile(dir)
+	if err != nil {
+		t.Fatalf("Should be able to construct the file sender : %s", err)
+	}
+
+	if err := file.Send(ctx, msg); err != nil {
+		t.Fatalf("Should be able to send the message : %s", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Should have written one file : got %v %v", files, err)
+	}
+
+	if _, err := os.ReadFile(files[0]); err != nil {
+		t.Errorf("Should be able to read the file : %s", err)
+	}
+}
@@ -0,0 +1,42 @@
+package mail
+
+import (
+	"context"
+	"sync"
+)
+
+// Memory keeps every message it's given. It's meant for tests.
+type Memory struct {
+	mu   sync.Mutex
+	msgs []Message
+}
+
+// NewMemory constructs a sender that keeps the messages in memory.
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// Send implements the Sender interface.
+func (m *Memory) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return ErrNoRecipients
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.msgs = append(m.msgs, msg)
+
+	return nil
+}
+
+// Messages returns the messages sent so far.
+func (m *Memory) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	msgs := make([]Message, len(m.msgs))
+	copy(msgs, m.msgs)
+
+	return msgs
+}
@@ -0,0 +1,87 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"strings"
+	"time"
+)
+
+// Bytes renders the message in the MIME format used to send it.
+func (msg Message) Bytes() ([]byte, error) {
+	if len(msg.To) == 0 {
+		return nil, ErrNoRecipients
+	}
+
+	to := make([]string, len(msg.To))
+	for i, addr := range msg.To {
+		to[i] = addr.String()
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", msg.From.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	switch {
+	case msg.Text != "" && msg.HTML != "":
+		boundary, err := newBoundary()
+		if err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		if err := writePart(&buf, "text/plain", msg.Text); err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(&buf, "\r\n--%s\r\n", boundary)
+		if err := writePart(&buf, "text/html", msg.HTML); err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(&buf, "\r\n--%s--\r\n", boundary)
+
+	case msg.HTML != "":
+		if err := writePart(&buf, "text/html", msg.HTML); err != nil {
+			return nil, err
+		}
+
+	default:
+		if err := writePart(&buf, "text/plain", msg.Text); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writePart(buf *bytes.Buffer, contentType string, body string) error {
+	fmt.Fprintf(buf, "Content-Type: %s; charset=utf-8\r\n", contentType)
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(buf)
+	if _, err := w.Write([]byte(body)); err != nil {
+		return fmt.Errorf("write body: %w", err)
+	}
+
+	return w.Close()
+}
+
+func newBoundary() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("boundary: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
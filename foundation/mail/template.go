@@ -0,0 +1,123 @@
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+)
+
+// ErrUnknownTemplate is returned when rendering a template that doesn't exist.
+var ErrUnknownTemplate = errors.New("unknown template")
+
+// Set of file suffixes that make up a template.
+const (
+	suffixSubject = ".subject.tmpl"
+	suffixText    = ".txt.tmpl"
+	suffixHTML    = ".html.tmpl"
+)
+
+// Content represents a rendered template.
+type Content struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Templates renders the subject and bodies of messages. A template named
+// "reset" is made of the files reset.subject.tmpl, reset.txt.tmpl and
+// reset.html.tmpl. The subject is required, and so is at least one of the
+// bodies. HTML bodies are escaped with the rules of html/template.
+type Templates struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// ParseTemplates parses the templates found at the root of the file system.
+func ParseTemplates(fsys fs.FS) (*Templates, error) {
+	t := Templates{
+		subject: texttemplate.New(""),
+		text:    texttemplate.New(""),
+		html:    htmltemplate.New(""),
+	}
+
+	files, err := fs.Glob(fsys, "*.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("glob: %w", err)
+	}
+
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("read: %s: %w", file, err)
+		}
+
+		switch {
+		case strings.HasSuffix(file, suffixSubject):
+			_, err = t.subject.New(strings.TrimSuffix(file, suffixSubject)).Parse(strings.TrimSpace(string(data)))
+
+		case strings.HasSuffix(file, suffixText):
+			_, err = t.text.New(strings.TrimSuffix(file, suffixText)).Parse(string(data))
+
+		case strings.HasSuffix(file, suffixHTML):
+			_, err = t.html.New(strings.TrimSuffix(file, suffixHTML)).Parse(string(data))
+
+		default:
+			err = errors.New("unknown template kind")
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("parse: %s: %w", file, err)
+		}
+	}
+
+	for _, st := range t.subject.Templates() {
+		if st.Name() == "" {
+			continue
+		}
+
+		if t.text.Lookup(st.Name()) == nil && t.html.Lookup(st.Name()) == nil {
+			return nil, fmt.Errorf("template %q has no body", st.Name())
+		}
+	}
+
+	return &t, nil
+}
+
+// Render executes the named template with the data.
+func (t *Templates) Render(name string, data any) (Content, error) {
+	st := t.subject.Lookup(name)
+	if st == nil || name == "" {
+		return Content{}, fmt.Errorf("render: %s: %w", name, ErrUnknownTemplate)
+	}
+
+	var c Content
+	var buf bytes.Buffer
+
+	if err := st.Execute(&buf, data); err != nil {
+		return Content{}, fmt.Errorf("render subject: %s: %w", name, err)
+	}
+	c.Subject = buf.String()
+
+	if tt := t.text.Lookup(name); tt != nil {
+		buf.Reset()
+		if err := tt.Execute(&buf, data); err != nil {
+			return Content{}, fmt.Errorf("render text: %s: %w", name, err)
+		}
+		c.Text = buf.String()
+	}
+
+	if ht := t.html.Lookup(name); ht != nil {
+		buf.Reset()
+		if err := ht.Execute(&buf, data); err != nil {
+			return Content{}, fmt.Errorf("render html: %s: %w", name, err)
+		}
+		c.HTML = buf.String()
+	}
+
+	return c, nil
+}
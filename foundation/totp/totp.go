@@ -0,0 +1,130 @@
+// Package totp implements time-based one-time passwords (RFC 6238) with the
+// parameters authenticator apps default to: HMAC-SHA1, 6 digits and a 30
+// second period.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Parameters of the generated codes.
+const (
+	Digits = 6
+	Period = 30 * time.Second
+)
+
+// secretSize is the size of a secret in bytes, the size of a SHA1 output as
+// recommended by RFC 4226.
+const secretSize = 20
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewSecret generates a random secret, base32 encoded like authenticator
+// apps expect it.
+func NewSecret() (string, error) {
+	key := make([]byte, secretSize)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("generating secret: %w", err)
+	}
+
+	return encoding.EncodeToString(key), nil
+}
+
+// Step returns the time step the specified time falls in.
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period/time.Second)
+}
+
+// Code returns the code of the secret for the specified time.
+func Code(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return generate(key, Step(t), Digits), nil
+}
+
+// Validate checks the code against the secret for the specified time,
+// allowing for the clock of the device to be skew steps off. It returns the
+// step the code matched, which callers should store and refuse codes at or
+// before it, so a code can't be used twice.
+func Validate(secret string, code string, t time.Time, skew int) (int64, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, err
+	}
+
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) != Digits {
+		return 0, errors.New("invalid code")
+	}
+
+	step := Step(t)
+	for i := -skew; i <= skew; i++ {
+		exp := generate(key, step+int64(i), Digits)
+		if subtle.ConstantTimeCompare([]byte(exp), []byte(code)) == 1 {
+			return step + int64(i), nil
+		}
+	}
+
+	return 0, errors.New("invalid code")
+}
+
+// URI returns the otpauth URI of the secret, which authenticator apps read
+// from a QR code to enroll.
+func URI(issuer string, account string, secret string) string {
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprint(Digits))
+	v.Set("period", fmt.Sprint(int(Period/time.Second)))
+
+	return "otpauth://totp/" + label + "?" + v.Encode()
+}
+
+// =============================================================================
+
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.TrimRight(strings.ReplaceAll(secret, " ", ""), "="))
+
+	key, err := encoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("decoding secret: %w", err)
+	}
+
+	return key, nil
+}
+
+// generate implements the HOTP algorithm of RFC 4226 for the counter.
+func generate(key []byte, counter int64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for range digits {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
@@ -0,0 +1,74 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rmsj/service/foundation/totp"
+)
+
+// The SHA1 test vectors of RFC 6238, truncated to 6 digits.
+func Test_Code(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1111111111, code: "050471"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+		{unix: 20000000000, code: "353130"},
+	}
+
+	for _, tt := range tests {
+		code, err := totp.Code(secret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("Should be able to generate a code: %s", err)
+		}
+
+		if code != tt.code {
+			t.Errorf("unix[%d]: got %s, exp %s", tt.unix, code, tt.code)
+		}
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	secret, err := totp.NewSecret()
+	if err != nil {
+		t.Fatalf("Should be able to generate a secret: %s", err)
+	}
+
+	now := time.Unix(1700000000, 0)
+
+	code, err := totp.Code(secret, now.Add(-totp.Period))
+	if err != nil {
+		t.Fatalf("Should be able to generate a code: %s", err)
+	}
+
+	step, err := totp.Validate(secret, code, now, 1)
+	if err != nil {
+		t.Fatalf("Should accept a code one step off: %s", err)
+	}
+
+	if step != totp.Step(now)-1 {
+		t.Errorf("Should return the step the code matched: got %d, exp %d", step, totp.Step(now)-1)
+	}
+
+	if _, err := totp.Validate(secret, code, now, 0); err == nil {
+		t.Error("Should NOT accept a code one step off without skew")
+	}
+
+	if _, err := totp.Validate(secret, "12345", now, 1); err == nil {
+		t.Error("Should NOT accept a short code")
+	}
+
+	uri := totp.URI("Service", "user@example.com", secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/Service:user@example.com?") || !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("Should build the otpauth uri, got %s", uri)
+	}
+}
@@ -3,7 +3,9 @@ package web
 import (
 	"context"
 	"net/http"
+	"unicode"
 
+	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -13,8 +15,13 @@ type ctxKey int
 const (
 	tracerKey ctxKey = iota + 1
 	writerKey
+	requestIDKey
 )
 
+// RequestIDHeader is the header that carries the id of a request, both in
+// the request and in the response.
+const RequestIDHeader = "X-Request-ID"
+
 func setTracer(ctx context.Context, tracer trace.Tracer) context.Context {
 	return context.WithValue(ctx, tracerKey, tracer)
 }
@@ -44,3 +51,35 @@ func GetWriter(ctx context.Context) http.ResponseWriter {
 
 	return v
 }
+
+func setRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// GetRequestID returns the id of the request.
+func GetRequestID(ctx context.Context) string {
+	v, ok := ctx.Value(requestIDKey).(string)
+	if !ok {
+		return ""
+	}
+
+	return v
+}
+
+// requestID returns the id provided by the client, so a request can be
+// followed across services, or generates a new one when it's missing or
+// doesn't look like an id.
+func requestID(r *http.Request) string {
+	id := r.Header.Get(RequestIDHeader)
+	if id == "" || len(id) > 128 {
+		return uuid.NewString()
+	}
+
+	for _, c := range id {
+		if c > unicode.MaxASCII || !unicode.IsPrint(c) || unicode.IsSpace(c) {
+			return uuid.NewString()
+		}
+	}
+
+	return id
+}
@@ -0,0 +1,64 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ETag formats the version of a resource as a strong entity tag.
+func ETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// SetETag sets the entity tag of the resource in the response.
+func SetETag(ctx context.Context, etag string) {
+	GetWriter(ctx).Header().Set("ETag", etag)
+}
+
+// IfMatch reports if the If-Match precondition of the request holds for the
+// entity tag, using the strong comparison. A request without the header
+// always matches.
+func IfMatch(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+
+	for _, tag := range splitETags(header) {
+		if tag == "*" || (!strings.HasPrefix(tag, "W/") && tag == etag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IfNoneMatch reports if the If-None-Match precondition of the request holds
+// for the entity tag, using the weak comparison. When it doesn't, the client
+// already has the current version of the resource. A request without the
+// header always holds.
+func IfNoneMatch(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return true
+	}
+
+	for _, tag := range splitETags(header) {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return false
+		}
+	}
+
+	return true
+}
+
+func splitETags(header string) []string {
+	tags := strings.Split(header, ",")
+	for i, tag := range tags {
+		tags[i] = strings.TrimSpace(tag)
+	}
+
+	return tags
+}
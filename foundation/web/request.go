@@ -3,6 +3,7 @@ package web
 import (
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 )
 
@@ -11,6 +12,17 @@ func Param(r *http.Request, key string) string {
 	return r.PathValue(key)
 }
 
+// RemoteIP returns the IP address of the client connection. Forwarding
+// headers are not trusted, so behind a proxy this is the proxy address.
+func RemoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
 // Decoder represents data that can be decoded.
 type Decoder interface {
 	Decode(data []byte) error
@@ -23,6 +23,25 @@ func (NoResponse) Encode() ([]byte, string, error) {
 	return nil, "", nil
 }
 
+// NotModified tells the Respond function the client already has the current
+// version of the resource, so there is no body to send.
+type NotModified struct{}
+
+// NewNotModified constructs a not modified value.
+func NewNotModified() NotModified {
+	return NotModified{}
+}
+
+// Encode implements the Encoder interface.
+func (NotModified) Encode() ([]byte, string, error) {
+	return nil, "", nil
+}
+
+// HTTPStatus implements the httpStatus interface.
+func (NotModified) HTTPStatus() int {
+	return http.StatusNotModified
+}
+
 // =============================================================================
 
 type httpStatus interface {
@@ -43,25 +62,12 @@ func Respond(ctx context.Context, w http.ResponseWriter, resp Encoder) error {
 		}
 	}
 
-	statusCode := http.StatusOK
-
-	switch v := resp.(type) {
-	case httpStatus:
-		statusCode = v.HTTPStatus()
-
-	case error:
-		statusCode = http.StatusInternalServerError
-
-	default:
-		if resp == nil {
-			statusCode = http.StatusNoContent
-		}
-	}
+	statusCode := StatusCode(resp)
 
 	_, span := addSpan(ctx, "web.send.response", attribute.Int("status", statusCode))
 	defer span.End()
 
-	if statusCode == http.StatusNoContent {
+	if statusCode == http.StatusNoContent || statusCode == http.StatusNotModified {
 		w.WriteHeader(statusCode)
 		return nil
 	}
@@ -81,3 +87,21 @@ func Respond(ctx context.Context, w http.ResponseWriter, resp Encoder) error {
 
 	return nil
 }
+
+// StatusCode returns the status code Respond sends for the response.
+func StatusCode(resp Encoder) int {
+	switch v := resp.(type) {
+	case httpStatus:
+		return v.HTTPStatus()
+
+	case error:
+		return http.StatusInternalServerError
+
+	default:
+		if resp == nil {
+			return http.StatusNoContent
+		}
+	}
+
+	return http.StatusOK
+}
@@ -105,7 +105,8 @@ func (a *App) corsHandler(webHandler HandlerFunc) HandlerFunc {
 		}
 
 		w.Header().Set("Access-Control-Allow-Methods", "POST, PATCH, GET, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, If-Match, If-None-Match, Idempotency-Key")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, Idempotent-Replayed, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After")
 		w.Header().Set("Access-Control-Max-Age", "86400")
 
 		return webHandler(ctx, r)
@@ -152,6 +153,10 @@ func (a *App) HandlerFunc(method string, group string, path string, handlerFunc
 		ctx := setTracer(r.Context(), a.tracer)
 		ctx = setWriter(ctx, w)
 
+		reqID := requestID(r)
+		ctx = setRequestID(ctx, reqID)
+		w.Header().Set(RequestIDHeader, reqID)
+
 		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(w.Header()))
 
 		resp := handlerFunc(ctx, r)
@@ -191,6 +196,10 @@ func (a *App) RawHandlerFunc(method string, group string, path string, rawHandle
 		ctx := setTracer(r.Context(), a.tracer)
 		ctx = setWriter(ctx, w)
 
+		reqID := requestID(r)
+		ctx = setRequestID(ctx, reqID)
+		w.Header().Set(RequestIDHeader, reqID)
+
 		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(w.Header()))
 
 		handlerFunc(ctx, r)
@@ -0,0 +1,18 @@
+package worker
+
+import "time"
+
+// Backoff returns how long to wait before the next attempt of a job that
+// failed the specified number of times. The wait starts at minWait and
+// doubles on every attempt, capped at maxWait.
+func Backoff(attempts int, minWait time.Duration, maxWait time.Duration) time.Duration {
+	wait := minWait
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= maxWait {
+			return maxWait
+		}
+	}
+
+	return wait
+}
@@ -52,6 +52,12 @@ func (w *Worker) Running() int {
 	return len(w.running)
 }
 
+// Available returns the number of jobs that can be started without waiting
+// for a running job to complete.
+func (w *Worker) Available() int {
+	return len(w.sem)
+}
+
 // Shutdown waits for all jobs to complete before it returns.
 func (w *Worker) Shutdown(ctx context.Context) error {
 
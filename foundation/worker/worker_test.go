@@ -47,6 +47,13 @@ func Test_Worker(t *testing.T) {
 		t.Error("Should be no more work running")
 	}
 
+	// Check that all the capacity is available again.
+	if a := w.Available(); a != 4 {
+		t.Errorf("Exp: 4")
+		t.Errorf("Got: %d", a)
+		t.Error("Should have all the capacity available")
+	}
+
 	// Shutdown the system with no work.
 	if err := w.Shutdown(context.Background()); err != nil {
 		t.Fatalf("Should be able to shutdown work cleanly : %s", err)
@@ -150,3 +157,24 @@ func Test_StopWorker(t *testing.T) {
 		t.Fatalf("Should be able to shutdown work cleanly : %s", err)
 	}
 }
+
+func Test_Backoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		exp      time.Duration
+	}{
+		{attempts: 1, exp: time.Second},
+		{attempts: 2, exp: 2 * time.Second},
+		{attempts: 4, exp: 8 * time.Second},
+		{attempts: 5, exp: 10 * time.Second},
+		{attempts: 100, exp: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := worker.Backoff(tt.attempts, time.Second, 10*time.Second); got != tt.exp {
+			t.Errorf("Exp: %s", tt.exp)
+			t.Errorf("Got: %s", got)
+			t.Errorf("Should wait the expected time after %d attempts", tt.attempts)
+		}
+	}
+}